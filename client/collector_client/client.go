@@ -51,8 +51,10 @@ func Start(collectServer string) {
 	cronRunner.Start()
 }
 
+// Stop wait for sending action logs, it can be started again after stopped
 func Stop() {
 	cronRunner.Stop()
+	<-sendLock
 	conn.Close()
 }
 
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/samoslab/nebula/util/file"
+	util_hash "github.com/samoslab/nebula/util/hash"
 )
 
 const (
@@ -48,6 +49,7 @@ type Config struct {
 	ThrottleDuration time.Duration `json:"throttle_duration"`
 	BehindProxy      bool          `json:"behind_proxy"`
 	APIEnabled       bool          `json:"api_enabled"`
+	HashAlgorithm    string        `json:"hash_algorithm"` // key of uploaded file and block: sha1, sha256 or blake2b-256, default sha1
 }
 
 // SetDefault set default value
@@ -84,6 +86,9 @@ func (cfg *Config) Validate() error {
 	if cfg.ConfigDir == "" {
 		return errors.New("need config dir")
 	}
+	if _, err := util_hash.ParseAlgorithm(cfg.HashAlgorithm); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -1,9 +1,7 @@
 package daemon
 
 import (
-	"bytes"
 	"context"
-	"crypto/rsa"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -37,9 +35,7 @@ import (
 	rsalong "github.com/samoslab/nebula/util/rsa"
 	"github.com/sirupsen/logrus"
 
-	"github.com/samoslab/nebula/client/register"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/status"
 )
 
 const (
@@ -88,27 +84,27 @@ type FilePages struct {
 
 // ClientManager client manager
 type ClientManager struct {
-	NodeId        []byte
-	store         *store
-	TempDir       string
-	PubkeyHash    []byte
-	Root          string
-	mutex         sync.Mutex
-	MsgCount      uint32
-	MsgChan       chan string
-	done          chan struct{}
-	quit          chan struct{}
-	TaskChan      chan TaskInfo
-	SpaceM        *SpaceManager
-	webcfg        config.Config
-	TrackerPubkey *rsa.PublicKey
-	serverConn    *grpc.ClientConn
-	Log           logrus.FieldLogger
-	OM            *order.OrderManager
-	FileTypeMap   filetype.SupportType
-	cfg           *config.ClientConfig
-	PM            *progress.ProgressManager
-	mclient       mpb.MatadataServiceClient
+	NodeId      []byte
+	store       *store
+	TempDir     string
+	Root        string
+	mutex       sync.Mutex
+	MsgCount    uint32
+	MsgChan     chan string
+	done        chan struct{}
+	quit        chan struct{}
+	TaskChan    chan TaskInfo
+	SpaceM      *SpaceManager
+	webcfg      config.Config
+	trackers    *Trackers
+	Log         logrus.FieldLogger
+	OM          *order.OrderManager
+	FileTypeMap filetype.SupportType
+	cfg         *config.ClientConfig
+	PM          *progress.ProgressManager
+	mclient     mpb.MatadataServiceClient
+	hashAlg     util_hash.Algorithm
+	localPros   *LocalProviders
 }
 
 // NewClientManager create manager
@@ -122,19 +118,18 @@ func NewClientManager(log logrus.FieldLogger, webcfg config.Config, cfg *config.
 	if cfg == nil {
 		return nil, errors.New("client config nil")
 	}
-	conn, err := common.GrpcDial(webcfg.TrackerServer)
+	hashAlg, err := util_hash.ParseAlgorithm(webcfg.HashAlgorithm)
 	if err != nil {
-		log.Errorf("Rpc dial failed: %s", err.Error())
 		return nil, err
 	}
-	log.Infof("Tracker server %s", webcfg.TrackerServer)
 
-	rsaPubkey, pubkeyHash, err := register.GetPublicKey(webcfg.TrackerServer)
+	trackers, err := NewTrackers(log, webcfg.TrackerServer, cfg.Node)
 	if err != nil {
+		log.Errorf("Connect tracker failed: %s", err.Error())
 		return nil, err
 	}
 
-	om := order.NewOrderManager(conn, log, cfg.Node.PriKey, cfg.Node.NodeId)
+	om := order.NewOrderManager(trackers.Order(), log, cfg.Node.PriKey, cfg.Node.NodeId)
 
 	spaceM := NewSpaceManager()
 	for _, sp := range cfg.Space {
@@ -157,24 +152,24 @@ func NewClientManager(log logrus.FieldLogger, webcfg config.Config, cfg *config.
 	}
 
 	c := &ClientManager{
-		OM:            om,
-		Log:           log,
-		cfg:           cfg,
-		serverConn:    conn,
-		store:         store,
-		SpaceM:        spaceM,
-		webcfg:        webcfg,
-		TrackerPubkey: rsaPubkey,
-		PubkeyHash:    pubkeyHash,
-		TempDir:       os.TempDir(),
-		NodeId:        cfg.Node.NodeId,
-		done:          make(chan struct{}),
-		quit:          make(chan struct{}),
-		FileTypeMap:   filetype.SupportTypes(),
-		PM:            progress.NewProgressManager(),
-		mclient:       mpb.NewMatadataServiceClient(conn),
-		MsgChan:       make(chan string, common.MsgQueueLen),
-		TaskChan:      make(chan TaskInfo, common.TaskQuqueLen),
+		OM:          om,
+		Log:         log,
+		cfg:         cfg,
+		trackers:    trackers,
+		store:       store,
+		SpaceM:      spaceM,
+		webcfg:      webcfg,
+		TempDir:     os.TempDir(),
+		NodeId:      cfg.Node.NodeId,
+		done:        make(chan struct{}),
+		quit:        make(chan struct{}),
+		FileTypeMap: filetype.SupportTypes(),
+		PM:          progress.NewProgressManager(),
+		mclient:     trackers.Metadata(),
+		hashAlg:     hashAlg,
+		localPros:   NewLocalProviders(),
+		MsgChan:     make(chan string, common.MsgQueueLen),
+		TaskChan:    make(chan TaskInfo, common.TaskQuqueLen),
 	}
 
 	collectClient.NodePtr = cfg.Node
@@ -191,6 +186,8 @@ func NewClientManager(log logrus.FieldLogger, webcfg config.Config, cfg *config.
 
 	go c.ExecuteTask()
 	go c.SendProgressMsg()
+	go c.DiscoverLocalProviders()
+	go c.RefreshTrackers()
 
 	return c, nil
 }
@@ -220,7 +217,7 @@ func (c *ClientManager) AddDoneMsg(msg string) {
 
 // Shutdown shutdown tracker connection
 func (c *ClientManager) Shutdown() {
-	c.serverConn.Close()
+	c.trackers.Close()
 	collectClient.Stop()
 	close(c.quit)
 	<-c.done
@@ -660,7 +657,8 @@ func (c *ClientManager) UploadFile(fileName, dest string, interactive, newVersio
 			log.Info("Space password not set")
 			return fmt.Errorf("Password not set")
 		}
-		encryptKey, err = rsalong.EncryptLong(c.TrackerPubkey, password, 256)
+		trackerPubkey, _ := c.trackers.PublicKey()
+		encryptKey, err = rsalong.EncryptLong(trackerPubkey, password, 256)
 		if err != nil {
 			log.WithError(err).Info("Encrypt password")
 			return err
@@ -854,7 +852,7 @@ func deleteTemporaryFile(log logrus.FieldLogger, fileName string) {
 
 func (c *ClientManager) CheckFileExists(fileName, dest string, interactive, newVersion bool, password, encryptKey []byte, sno uint32) (*mpb.CheckFileExistReq, *mpb.CheckFileExistResp, error) {
 	log := c.Log.WithField("filename", fileName)
-	hash, err := util_hash.Sha1File(fileName)
+	hash, err := util_hash.SumFile(c.hashAlg, fileName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -866,18 +864,17 @@ func (c *ClientManager) CheckFileExists(fileName, dest string, interactive, newV
 	_, fname := filepath.Split(fileName)
 	ctx := context.Background()
 	req := &mpb.CheckFileExistReq{
-		FileHash:      hash,
-		FileName:      fname,
-		NodeId:        c.NodeId,
-		EncryptKey:    encryptKey,
-		NewVersion:    newVersion,
-		Interactive:   interactive,
-		PublicKeyHash: c.PubkeyHash,
-		Timestamp:     common.Now(),
-		FileType:      fileType.Value,
-		Version:       common.Version,
-		FileSize:      uint64(fileSize),
-		Parent:        &mpb.FilePath{OneOfPath: &mpb.FilePath_Path{dest}, SpaceNo: sno},
+		FileHash:    hash,
+		FileName:    fname,
+		NodeId:      c.NodeId,
+		EncryptKey:  encryptKey,
+		NewVersion:  newVersion,
+		Interactive: interactive,
+		Timestamp:   common.Now(),
+		FileType:    fileType.Value,
+		Version:     common.Version,
+		FileSize:    uint64(fileSize),
+		Parent:      &mpb.FilePath{OneOfPath: &mpb.FilePath_Path{dest}, SpaceNo: sno},
 	}
 	mtime, err := GetFileModTime(fileName)
 	if err != nil {
@@ -902,36 +899,17 @@ func (c *ClientManager) CheckFileExists(fileName, dest string, interactive, newV
 		c.PM.SetProgress(common.TaskUploadProgressType, common.ProgressKey(sp, sno), req.FileSize, req.FileSize, sno, fileName)
 		fmt.Printf("Origin filesize %d, encrypted size %d\n", req.FileSize, len(req.FileData))
 	}
-	err = req.SignReq(c.cfg.Node.PriKey)
-	if err != nil {
-		return nil, nil, err
-	}
-	log.Info("Check file exist request")
-	rsp, err := c.mclient.CheckFileExist(ctx, req)
-	if err != nil {
-		// tracker public key expired
-		st, ok := status.FromError(err)
-		if !ok {
-			return nil, nil, fmt.Errorf("get status error failed")
-		}
-		if st.Code() == 500 || st.Message() == "tracker public key expired" {
-			rsaPubkey, pubkeyHash, err := register.GetPublicKey(c.webcfg.TrackerServer)
-			if err != nil {
-				return nil, nil, err
-			}
-			c.PubkeyHash = pubkeyHash
-			c.TrackerPubkey = rsaPubkey
-			req.Timestamp = common.Now()
-			req.PublicKeyHash = pubkeyHash
-			err = req.SignReq(c.cfg.Node.PriKey)
-			if err != nil {
-				return nil, nil, err
-			}
-			log.Info("Check file exist request")
-			rsp, err := c.mclient.CheckFileExist(ctx, req)
-			return req, rsp, err
+	var rsp *mpb.CheckFileExistResp
+	err = c.trackers.WithPublicKey(func(pubkeyHash []byte) error {
+		req.Timestamp = common.Now()
+		req.PublicKeyHash = pubkeyHash
+		if err := req.SignReq(c.cfg.Node.PriKey); err != nil {
+			return err
 		}
-	}
+		log.Info("Check file exist request")
+		rsp, err = c.mclient.CheckFileExist(ctx, req)
+		return err
+	})
 	return req, rsp, err
 }
 
@@ -969,7 +947,7 @@ func (c *ClientManager) MkFolder(filepath string, folders []string, interactive
 
 func (c *ClientManager) onlyFileSplit(fileName string, dataNum, verifyNum int, isEncrypt bool, password []byte, sno uint32) ([]common.HashFile, error) {
 	log := c.Log.WithField("filesplit", fileName)
-	fileSlices, err := RsEncoder(c.Log, c.TempDir, fileName, dataNum, verifyNum)
+	fileSlices, err := RsEncoder(c.Log, c.TempDir, fileName, dataNum, verifyNum, c.hashAlg)
 	if err != nil {
 		log.Errorf("Reedsolomon encoder error %v", err)
 		return nil, err
@@ -981,7 +959,7 @@ func (c *ClientManager) onlyFileSplit(fileName string, dataNum, verifyNum int, i
 				log.Errorf("Encrypt error %v", err)
 				return nil, err
 			}
-			hash, err := util_hash.Sha1File(fileSlices[i].FileName)
+			hash, err := util_hash.SumFile(c.hashAlg, fileSlices[i].FileName)
 			if err != nil {
 				return nil, err
 			}
@@ -1086,8 +1064,12 @@ func (c *ClientManager) uploadFileToErasureProvider(pro *mpb.BlockProviderAuth,
 		}
 	}
 
+	var tag *pb.BlockTag
+	if chunkSize > 0 {
+		tag = &pb.BlockTag{ParamStr: paraStr, ChunkSize: chunkSize, Phi: phi}
+	}
 	ha := pro.GetHashAuth()[0]
-	err = client.StorePiece(log, pclient, uploadPara, ha.GetAuth(), ha.GetTicket(), tm, c.PM)
+	err = client.StorePiece(log, pclient, uploadPara, ha.GetAuth(), ha.GetTicket(), tm, c.PM, tag)
 	if err != nil {
 		return nil, err
 	}
@@ -1111,7 +1093,7 @@ func (c *ClientManager) uploadFileToErasureProvider(pro *mpb.BlockProviderAuth,
 	return block, nil
 }
 
-func (c *ClientManager) uploadFileToReplicaProvider(conn *grpc.ClientConn, pro *mpb.ReplicaProvider, uploadPara *common.UploadParameter) ([]byte, error) {
+func (c *ClientManager) uploadFileToReplicaProvider(conn *grpc.ClientConn, pro *mpb.ReplicaProvider, uploadPara *common.UploadParameter, tag *pb.BlockTag) ([]byte, error) {
 	fileInfo := uploadPara.HF
 	server := fmt.Sprintf("%s:%d", pro.GetServer(), pro.GetPort())
 	log := c.Log.WithField("uploading", fileInfo.FileName).WithField("provider", server)
@@ -1119,7 +1101,7 @@ func (c *ClientManager) uploadFileToReplicaProvider(conn *grpc.ClientConn, pro *
 	pclient := pb.NewProviderServiceClient(conn)
 	log.Infof("Upload file hash %x size %d", fileInfo.FileHash, fileInfo.FileSize)
 
-	err := client.StorePiece(log, pclient, uploadPara, pro.Auth, pro.Ticket, pro.Timestamp, c.PM)
+	err := client.StorePiece(log, pclient, uploadPara, pro.Auth, pro.Ticket, pro.Timestamp, c.PM, tag)
 	if err != nil {
 		log.WithError(err).Error("Upload error")
 		return nil, err
@@ -1138,9 +1120,41 @@ func newActionLogFromUpload(fileName string) *tcppb.ActionLog {
 	}
 }
 
+// replicaTag tag of block kept by replica providers to prove it, nil if block is not proved by chunks
+func replicaTag(block *mpb.StoreBlock) *pb.BlockTag {
+	if block.ChunkSize == 0 {
+		return nil
+	}
+	return &pb.BlockTag{ParamStr: block.ParamStr, ChunkSize: block.ChunkSize, Phi: block.Phi}
+}
+
+// uploadReplicas upload to providers concurrently, node id of provider is appended to StoreNodeId of block only if
+// upload to it succeeded, so tracker does not record a provider which has no replica
+func uploadReplicas(providers []*mpb.ReplicaProvider, block *mpb.StoreBlock, upload func(pro *mpb.ReplicaProvider) ([]byte, error)) []error {
+	errArr := []error{}
+	var mutex sync.Mutex
+	ccControl := NewCCController(common.CCUploadFileNum)
+	for _, pro := range providers {
+		ccControl.Add()
+		go func(pro *mpb.ReplicaProvider) {
+			defer ccControl.Done()
+			proID, err := upload(pro)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errArr = append(errArr, err)
+			} else {
+				block.StoreNodeId = append(block.StoreNodeId, proID)
+			}
+		}(pro)
+	}
+	ccControl.Wait()
+	return errArr
+}
+
 func (c *ClientManager) uploadFileByMultiReplica(originFileName, fileName string, req *mpb.CheckFileExistReq, rsp *mpb.CheckFileExistResp, sno uint32) ([]*mpb.StorePartition, error) {
 	log := c.Log
-	hash, err := util_hash.Sha1File(fileName)
+	hash, err := util_hash.SumFile(c.hashAlg, fileName)
 	if err != nil {
 		return nil, err
 	}
@@ -1218,6 +1232,7 @@ func (c *ClientManager) uploadFileByMultiReplica(originFileName, fileName string
 	uniqKey := common.ProgressKey(sp, sno)
 	c.PM.SetPartitionMap(fileName, uniqKey)
 
+	c.localPros.PreferReplica(ufprsp.GetProvider())
 	providers, backupPros, err := GetBestReplicaProvider(ufprsp.GetProvider(), MinReplicaNum)
 	if err != nil {
 		return nil, err
@@ -1228,44 +1243,29 @@ func (c *ClientManager) uploadFileByMultiReplica(originFileName, fileName string
 		c.PM.SetProgress(common.TaskUploadProgressType, uniqKey, 0, uint64(int64(len(providers))*fileSize), sno, originFileName)
 	}
 
+	tag := replicaTag(block)
 	HandlerUpload := func(providers []*mpb.ReplicaProvider, block *mpb.StoreBlock, uploadPara *common.UploadParameter) []error {
-		errArr := []error{}
-		var mutex sync.Mutex
-		ccControl := NewCCController(common.CCUploadFileNum)
-		for _, pro := range providers {
-			ccControl.Add()
-			go func(pro *mpb.ReplicaProvider) {
-				server := fmt.Sprintf("%s:%d", pro.Server, pro.Port)
-				conn, err := common.GrpcDial(server)
-				if err != nil {
-					log.Errorf("Rpc dail failed: %v", err)
-					mutex.Lock()
-					errArr = append(errArr, err)
-					mutex.Unlock()
-					ccControl.Done()
-					return
-				}
-				done := make(chan struct{})
-				go HandleQuit(c.quit, done, ccControl, func() { conn.Close() })
-				defer func() {
+		return uploadReplicas(providers, block, func(pro *mpb.ReplicaProvider) ([]byte, error) {
+			server := fmt.Sprintf("%s:%d", pro.Server, pro.Port)
+			conn, err := common.GrpcDial(server)
+			if err != nil {
+				log.Errorf("Rpc dail failed: %v", err)
+				return nil, err
+			}
+			done := make(chan struct{})
+			go func() {
+				select {
+				case <-c.quit:
 					conn.Close()
-					close(done)
-					ccControl.Done()
-				}()
-				proID, err := c.uploadFileToReplicaProvider(conn, pro, uploadPara)
-				if err != nil {
-					mutex.Lock()
-					errArr = append(errArr, err)
-					mutex.Unlock()
+				case <-done:
 				}
-				mutex.Lock()
-				block.StoreNodeId = append(block.StoreNodeId, proID)
-				mutex.Unlock()
-			}(pro)
-		}
-
-		ccControl.Wait()
-		return errArr
+			}()
+			defer func() {
+				conn.Close()
+				close(done)
+			}()
+			return c.uploadFileToReplicaProvider(conn, pro, uploadPara, tag)
+		})
 	}
 	//al := newActionLogFromUpload(fileName)
 	//defer collectClient.Collect(al)
@@ -1296,54 +1296,34 @@ func (c *ClientManager) uploadFileByMultiReplica(originFileName, fileName string
 
 func (c *ClientManager) UploadFileDone(reqCheck *mpb.CheckFileExistReq, partitions []*mpb.StorePartition, encryptKey []byte) error {
 	req := &mpb.UploadFileDoneReq{
-		NodeId:        c.NodeId,
-		Partition:     partitions,
-		EncryptKey:    encryptKey,
-		Timestamp:     common.Now(),
-		PublicKeyHash: c.PubkeyHash,
-		Version:       common.Version,
-		FileHash:      reqCheck.GetFileHash(),
-		FileSize:      reqCheck.GetFileSize(),
-		FileName:      reqCheck.GetFileName(),
-		FileType:      reqCheck.GetFileType(),
-		FileModTime:   reqCheck.GetFileModTime(),
-		Parent:        reqCheck.GetParent(),
-		Interactive:   reqCheck.GetInteractive(),
-		NewVersion:    reqCheck.GetNewVersion(),
-	}
-	err := req.SignReq(c.cfg.Node.PriKey)
-	if err != nil {
-		return common.NewStatus(errcode.RetSignFailed, err)
+		NodeId:      c.NodeId,
+		Partition:   partitions,
+		EncryptKey:  encryptKey,
+		Version:     common.Version,
+		FileHash:    reqCheck.GetFileHash(),
+		FileSize:    reqCheck.GetFileSize(),
+		FileName:    reqCheck.GetFileName(),
+		FileType:    reqCheck.GetFileType(),
+		FileModTime: reqCheck.GetFileModTime(),
+		Parent:      reqCheck.GetParent(),
+		Interactive: reqCheck.GetInteractive(),
+		NewVersion:  reqCheck.GetNewVersion(),
 	}
 	ctx := context.Background()
 	log := c.Log.WithField("filename", req.GetFileName())
-	log.Info("Upload file done request")
-	ufdrsp, err := c.mclient.UploadFileDone(ctx, req)
+	var ufdrsp *mpb.UploadFileDoneResp
+	err := c.trackers.WithPublicKey(func(pubkeyHash []byte) (err error) {
+		req.Timestamp = common.Now()
+		req.PublicKeyHash = pubkeyHash
+		if err = req.SignReq(c.cfg.Node.PriKey); err != nil {
+			return common.NewStatus(errcode.RetSignFailed, err)
+		}
+		log.Info("Upload file done request")
+		ufdrsp, err = c.mclient.UploadFileDone(ctx, req)
+		return
+	})
 	if err != nil {
-		// tracker public key expired
-		st, ok := status.FromError(err)
-		if !ok {
-			return err
-		}
-		if st.Code() == 500 || st.Message() == "tracker public key expired" {
-			rsaPubkey, pubkeyHash, err := register.GetPublicKey(c.webcfg.TrackerServer)
-			if err != nil {
-				return err
-			}
-			c.PubkeyHash = pubkeyHash
-			c.TrackerPubkey = rsaPubkey
-			req.Timestamp = common.Now()
-			req.PublicKeyHash = pubkeyHash
-			err = req.SignReq(c.cfg.Node.PriKey)
-			if err != nil {
-				return common.NewStatus(errcode.RetSignFailed, err)
-			}
-			log.Info("Check file exist request")
-			ufdrsp, err = c.mclient.UploadFileDone(ctx, req)
-			if err != nil {
-				return err
-			}
-		}
+		return err
 	}
 	log.Infof("Upload done code %d", ufdrsp.GetCode())
 	if ufdrsp.GetCode() != 0 {
@@ -1522,14 +1502,8 @@ func (c *ClientManager) CheckFileExistsInLocal(downFileName string, filehash []b
 	if !util_file.Exists(downFileName) {
 		return false
 	}
-	localHash, err := util_hash.Sha1File(downFileName)
-	if err != nil {
-		return false
-	}
-	if bytes.Compare(localHash, filehash) == 0 {
-		return true
-	}
-	return false
+	ok, err := util_hash.VerifyFileKey(filehash, downFileName)
+	return err == nil && ok
 }
 
 // DownloadFile download file
@@ -1829,6 +1803,7 @@ func (c *ClientManager) saveFileByPartition(fileName string, partition *mpb.Retr
 	for _, block := range partition.GetBlock() {
 		ccControl.Add()
 		go func(log logrus.FieldLogger, block *mpb.RetrieveBlock, fileName string) {
+			c.localPros.PreferRetrieve(block.GetStoreNode())
 			node := BestRetrieveNode(block.GetStoreNode())
 			server := fmt.Sprintf("%s:%d", node.GetServer(), node.GetPort())
 			conn, err := common.GrpcDial(server)
@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	mpb "github.com/samoslab/nebula/tracker/metadata/pb"
+	"github.com/samoslab/nebula/util/lan"
+)
+
+const (
+	// LanDiscoverInterval interval of searching providers on local network
+	LanDiscoverInterval = 2 * time.Minute
+	// LanAddressTTL local address is not used if it is not announced again in ttl
+	LanAddressTTL = 10 * time.Minute
+)
+
+// LocalProviders verified local network addresses of providers, keyed by node id
+type LocalProviders struct {
+	mutex sync.Mutex
+	addrs map[string]*lan.Provider
+}
+
+// NewLocalProviders create local providers
+func NewLocalProviders() *LocalProviders {
+	return &LocalProviders{addrs: map[string]*lan.Provider{}}
+}
+
+// Update add discovered providers and drop expired ones
+func (lp *LocalProviders) Update(pros []*lan.Provider, now time.Time) {
+	lp.mutex.Lock()
+	defer lp.mutex.Unlock()
+	for _, p := range pros {
+		p.Time = now
+		lp.addrs[string(p.NodeId)] = p
+	}
+	for k, p := range lp.addrs {
+		if now.Sub(p.Time) > LanAddressTTL {
+			delete(lp.addrs, k)
+		}
+	}
+}
+
+// Get local address of node
+func (lp *LocalProviders) Get(nodeId []byte) (string, uint32, bool) {
+	lp.mutex.Lock()
+	defer lp.mutex.Unlock()
+	p, ok := lp.addrs[string(nodeId)]
+	if !ok || time.Since(p.Time) > LanAddressTTL {
+		return "", 0, false
+	}
+	return p.Host, p.Port, true
+}
+
+// PreferReplica replace address of replica provider with local address
+func (lp *LocalProviders) PreferReplica(pros []*mpb.ReplicaProvider) {
+	for _, pro := range pros {
+		if host, port, ok := lp.Get(pro.GetNodeId()); ok {
+			pro.Server, pro.Port = host, port
+		}
+	}
+}
+
+// PreferRetrieve replace address of retrieve node with local address
+func (lp *LocalProviders) PreferRetrieve(nodes []*mpb.RetrieveNode) {
+	for _, node := range nodes {
+		if host, port, ok := lp.Get(node.GetNodeId()); ok {
+			node.Server, node.Port = host, port
+		}
+	}
+}
+
+// DiscoverLocalProviders search providers on local network until quit
+func (c *ClientManager) DiscoverLocalProviders() {
+	discover := func() {
+		pros, err := lan.Discover(2)
+		if err != nil {
+			c.Log.Debugf("Discover local providers failed: %v", err)
+			return
+		}
+		for _, p := range pros {
+			c.Log.Debugf("Local provider %x at %s:%d", p.NodeId, p.Host, p.Port)
+		}
+		c.localPros.Update(pros, time.Now())
+	}
+	discover()
+	ticker := time.NewTicker(LanDiscoverInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.quit:
+			return
+		case <-ticker.C:
+			discover()
+		}
+	}
+}
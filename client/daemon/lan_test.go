@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	mpb "github.com/samoslab/nebula/tracker/metadata/pb"
+	"github.com/samoslab/nebula/util/lan"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalProviders(t *testing.T) {
+	lp := NewLocalProviders()
+	now := time.Now()
+	lp.Update([]*lan.Provider{&lan.Provider{NodeId: []byte("node1"), Host: "192.168.1.20", Port: 6666}}, now.Add(-LanAddressTTL-time.Minute))
+	lp.Update([]*lan.Provider{&lan.Provider{NodeId: []byte("node2"), Host: "192.168.1.21", Port: 6667}}, now)
+
+	retrieveNodes := []*mpb.RetrieveNode{
+		&mpb.RetrieveNode{NodeId: []byte("node1"), Server: "1.1.1.1", Port: 6666},
+		&mpb.RetrieveNode{NodeId: []byte("node2"), Server: "2.2.2.2", Port: 6666},
+	}
+	lp.PreferRetrieve(retrieveNodes)
+	// node1 is expired
+	assert.Equal(t, "1.1.1.1", retrieveNodes[0].Server)
+	assert.Equal(t, "192.168.1.21", retrieveNodes[1].Server)
+	assert.Equal(t, uint32(6667), retrieveNodes[1].Port)
+
+	replicaPros := []*mpb.ReplicaProvider{
+		&mpb.ReplicaProvider{NodeId: []byte("node2"), Server: "2.2.2.2", Port: 6666},
+		&mpb.ReplicaProvider{NodeId: []byte("node3"), Server: "3.3.3.3", Port: 6666},
+	}
+	lp.PreferReplica(replicaPros)
+	assert.Equal(t, "192.168.1.21", replicaPros[0].Server)
+	assert.Equal(t, "3.3.3.3", replicaPros[1].Server)
+}
@@ -13,7 +13,7 @@ import (
 )
 
 // RsEncoder reedsolomon stream encoder file
-func RsEncoder(log logrus.FieldLogger, outDir, fName string, dataShards, parShards int) ([]common.HashFile, error) {
+func RsEncoder(log logrus.FieldLogger, outDir, fName string, dataShards, parShards int, alg util_hash.Algorithm) ([]common.HashFile, error) {
 	enc, err := reedsolomon.NewStream(dataShards, parShards)
 	if err != nil {
 		return nil, err
@@ -85,7 +85,7 @@ func RsEncoder(log logrus.FieldLogger, outDir, fName string, dataShards, parShar
 	result := []common.HashFile{}
 	for i := range out {
 		outfn := filepath.Join(dir, fmt.Sprintf("%s.%d", file, i))
-		hash, err := util_hash.Sha1File(outfn)
+		hash, err := util_hash.SumFile(alg, outfn)
 		if err != nil {
 			return nil, err
 		}
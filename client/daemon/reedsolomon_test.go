@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	util_hash "github.com/samoslab/nebula/util/hash"
 	"github.com/samoslab/nebula/util/logger"
 	"github.com/stretchr/testify/require"
 )
@@ -38,7 +39,7 @@ func TestEncoder(t *testing.T) {
 	require.NoError(t, err)
 	originSize, err := GetFileSize(fname)
 	require.NoError(t, err)
-	hashFiles, err := RsEncoder(log, outDir, fname, dataShards, parShards)
+	hashFiles, err := RsEncoder(log, outDir, fname, dataShards, parShards, util_hash.SHA1)
 	require.NoError(t, err)
 	require.Equal(t, 3, len(hashFiles))
 	outDir, file := filepath.Split(fname)
@@ -77,7 +78,7 @@ func TestOddFileSize(t *testing.T) {
 			require.NoError(t, err)
 			originSize, err := GetFileSize(fname)
 			require.NoError(t, err)
-			hashFiles, err := RsEncoder(log, outDir, fname, dataShards, parShards)
+			hashFiles, err := RsEncoder(log, outDir, fname, dataShards, parShards, util_hash.SHA1)
 			require.NoError(t, err)
 			require.Equal(t, dataShards+parShards, len(hashFiles))
 			outDir, file := filepath.Split(fname)
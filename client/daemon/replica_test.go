@@ -0,0 +1,36 @@
+package daemon
+
+import (
+	"errors"
+	"testing"
+
+	mpb "github.com/samoslab/nebula/tracker/metadata/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadReplicas(t *testing.T) {
+	providers := []*mpb.ReplicaProvider{
+		&mpb.ReplicaProvider{NodeId: []byte("node1")},
+		&mpb.ReplicaProvider{NodeId: []byte("node2")},
+		&mpb.ReplicaProvider{NodeId: []byte("node3")},
+	}
+	failed := errors.New("store failed")
+	block := &mpb.StoreBlock{StoreNodeId: [][]byte{}}
+	errArr := uploadReplicas(providers, block, func(pro *mpb.ReplicaProvider) ([]byte, error) {
+		if string(pro.NodeId) == "node2" {
+			return nil, failed
+		}
+		return pro.NodeId, nil
+	})
+	assert.Equal(t, []error{failed}, errArr)
+	assert.ElementsMatch(t, [][]byte{[]byte("node1"), []byte("node3")}, block.StoreNodeId, "failed provider is not recorded")
+}
+
+func TestReplicaTag(t *testing.T) {
+	assert.Nil(t, replicaTag(&mpb.StoreBlock{}))
+	block := &mpb.StoreBlock{ChunkSize: 1024, ParamStr: "param", Phi: [][]byte{[]byte("phi")}}
+	tag := replicaTag(block)
+	assert.Equal(t, block.ChunkSize, tag.ChunkSize)
+	assert.Equal(t, block.ParamStr, tag.ParamStr)
+	assert.Equal(t, block.Phi, tag.Phi)
+}
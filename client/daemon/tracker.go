@@ -0,0 +1,196 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samoslab/nebula/client/common"
+	"github.com/samoslab/nebula/provider/node"
+	mpb "github.com/samoslab/nebula/tracker/metadata/pb"
+	rpb "github.com/samoslab/nebula/tracker/register/client/pb"
+	"github.com/samoslab/nebula/util/failover"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// TrackerRefreshInterval interval of discovering tracker servers by GetTrackerServer
+	TrackerRefreshInterval = 30 * time.Minute
+	// TrackerDownTime unavailable tracker is tried after healthy ones in down time
+	TrackerDownTime = time.Minute
+	// TrackerPublicKeyExpired message of tracker when public key hash of request is not current
+	TrackerPublicKeyExpired = "tracker public key expired"
+)
+
+// NoTrackerErr no tracker server is configured
+var NoTrackerErr = errors.New("no tracker server")
+
+// idempotentMethods calls safe to send again to next tracker after they may have reached one, other calls such as
+// PayOrder, BuyPackage, UploadFileDone, MkFolder, Move and Remove have no dedup key on tracker side
+var idempotentMethods = []string{
+	"/metadata.pb.MatadataService/Ping",
+	"/metadata.pb.MatadataService/GetPublicKey",
+	"/metadata.pb.MatadataService/CheckFileExist",
+	"/metadata.pb.MatadataService/UploadFilePrepare",
+	"/metadata.pb.MatadataService/ListFiles",
+	"/metadata.pb.MatadataService/RetrieveFile",
+	"/metadata.pb.MatadataService/SpaceSysFile",
+	"/register.client.pb.ClientRegisterService/GetPublicKey",
+	"/register.client.pb.ClientRegisterService/GetTrackerServer",
+	"/register.client.pb.OrderService/AllPackage",
+	"/register.client.pb.OrderService/PackageInfo",
+	"/register.client.pb.OrderService/PackageDiscount",
+	"/register.client.pb.OrderService/MyAllOrder",
+	"/register.client.pb.OrderService/OrderInfo",
+	"/register.client.pb.OrderService/RechargeAddress",
+	"/register.client.pb.OrderService/UsageAmount",
+}
+
+// Trackers tracker servers with health state, an idempotent call fails over to next tracker if current one is
+// unavailable or does not answer, other calls only if tracker is not connected before sending.
+// Request is sent unchanged to next tracker, so signature of it is preserved.
+type Trackers struct {
+	mutex      sync.Mutex
+	log        logrus.FieldLogger
+	node       *node.Node
+	servers    *failover.Servers
+	pubkey     *rsa.PublicKey
+	pubkeyHash []byte
+}
+
+// NewTrackers connect to tracker servers separated by comma, discover others and get public key
+func NewTrackers(log logrus.FieldLogger, servers string, node *node.Node) (*Trackers, error) {
+	ts := &Trackers{log: log, node: node, servers: failover.New("Tracker", log, idempotentMethods...)}
+	ts.servers.DownTime = TrackerDownTime
+	for _, addr := range strings.Split(servers, ",") {
+		if err := ts.servers.Add(strings.TrimSpace(addr), true); err != nil {
+			ts.Close()
+			return nil, err
+		}
+	}
+	if len(ts.servers.List()) == 0 {
+		return nil, NoTrackerErr
+	}
+	if err := ts.Discover(); err != nil {
+		log.WithError(err).Warn("Discover tracker servers failed, using configured ones")
+	}
+	if err := ts.RefreshPublicKey(); err != nil {
+		ts.Close()
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Servers address of trackers, healthy ones first
+func (ts *Trackers) Servers() []string {
+	return ts.servers.List()
+}
+
+// Discover get tracker servers by GetTrackerServer, servers not listed are removed except configured ones
+func (ts *Trackers) Discover() error {
+	req := &rpb.GetTrackerServerReq{Version: common.Version, NodeId: ts.node.NodeId, Timestamp: common.Now()}
+	if err := req.SignReq(ts.node.PriKey); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	resp, err := rpb.NewClientRegisterServiceClient(ts.servers.Conn()).GetTrackerServer(ctx, req)
+	if err != nil {
+		return err
+	}
+	listed := make([]string, 0, len(resp.GetServer()))
+	for _, s := range resp.GetServer() {
+		listed = append(listed, net.JoinHostPort(s.GetServer(), strconv.Itoa(int(s.GetPort()))))
+	}
+	return ts.servers.Update(listed)
+}
+
+// PublicKey current public key of tracker and hash of it
+func (ts *Trackers) PublicKey() (*rsa.PublicKey, []byte) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	return ts.pubkey, ts.pubkeyHash
+}
+
+// RefreshPublicKey get public key from tracker
+func (ts *Trackers) RefreshPublicKey() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	resp, err := rpb.NewClientRegisterServiceClient(ts.servers.Conn()).GetPublicKey(ctx, &rpb.GetPublicKeyReq{Version: common.Version})
+	if err != nil {
+		return err
+	}
+	pubkey, err := x509.ParsePKCS1PublicKey(resp.GetPublicKey())
+	if err != nil {
+		return err
+	}
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.pubkey, ts.pubkeyHash = pubkey, resp.GetPublicKeyHash()
+	return nil
+}
+
+// PublicKeyExpired is true if tracker rejects request for public key hash of it
+func PublicKeyExpired(err error) bool {
+	st, ok := status.FromError(err)
+	return ok && err != nil && (st.Code() == 500 || st.Message() == TrackerPublicKeyExpired)
+}
+
+// WithPublicKey call f with current public key hash, f is called again with refreshed one if public key expired,
+// f should set the hash and sign request again
+func (ts *Trackers) WithPublicKey(f func(pubkeyHash []byte) error) error {
+	_, pubkeyHash := ts.PublicKey()
+	err := f(pubkeyHash)
+	if !PublicKeyExpired(err) {
+		return err
+	}
+	ts.log.Info("Tracker public key expired, refresh it")
+	if err = ts.RefreshPublicKey(); err != nil {
+		return err
+	}
+	_, pubkeyHash = ts.PublicKey()
+	return f(pubkeyHash)
+}
+
+// Close connections of trackers
+func (ts *Trackers) Close() {
+	ts.servers.Close()
+}
+
+// Metadata metadata client failing over trackers
+func (ts *Trackers) Metadata() mpb.MatadataServiceClient {
+	return mpb.NewMatadataServiceClient(ts.servers.Conn())
+}
+
+// Order order client failing over trackers
+func (ts *Trackers) Order() rpb.OrderServiceClient {
+	return rpb.NewOrderServiceClient(ts.servers.Conn())
+}
+
+// TrackerServers address of trackers, healthy ones first
+func (c *ClientManager) TrackerServers() []string {
+	return c.trackers.Servers()
+}
+
+// RefreshTrackers discover tracker servers until quit
+func (c *ClientManager) RefreshTrackers() {
+	ticker := time.NewTicker(TrackerRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.quit:
+			return
+		case <-ticker.C:
+			if err := c.trackers.Discover(); err != nil {
+				c.Log.WithError(err).Warn("Discover tracker servers failed")
+			}
+		}
+	}
+}
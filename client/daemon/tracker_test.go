@@ -0,0 +1,126 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/samoslab/nebula/provider/node"
+	rpb "github.com/samoslab/nebula/tracker/register/client/pb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeTracker answers GetTrackerServer with listed servers and GetPublicKey with a new hash on every call
+type fakeTracker struct {
+	rpb.ClientRegisterServiceServer
+	mutex   sync.Mutex
+	addr    string
+	listed  []string
+	pubkey  []byte
+	pubkeys int
+	srv     *grpc.Server
+}
+
+func startFakeTracker(t *testing.T, pubkey *rsa.PublicKey) *fakeTracker {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ft := &fakeTracker{addr: lis.Addr().String(), pubkey: x509.MarshalPKCS1PublicKey(pubkey), srv: grpc.NewServer()}
+	rpb.RegisterClientRegisterServiceServer(ft.srv, ft)
+	go ft.srv.Serve(lis)
+	return ft
+}
+
+func (ft *fakeTracker) list(addrs ...string) {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	ft.listed = addrs
+}
+
+func (ft *fakeTracker) GetTrackerServer(ctx context.Context, req *rpb.GetTrackerServerReq) (*rpb.GetTrackerServerResp, error) {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	resp := &rpb.GetTrackerServerResp{}
+	for _, addr := range ft.listed {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		p, _ := strconv.Atoi(port)
+		resp.Server = append(resp.Server, &rpb.TrackerServer{Server: host, Port: uint32(p)})
+	}
+	return resp, nil
+}
+
+func (ft *fakeTracker) GetPublicKey(ctx context.Context, req *rpb.GetPublicKeyReq) (*rpb.GetPublicKeyResp, error) {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	ft.pubkeys++
+	return &rpb.GetPublicKeyResp{PublicKey: ft.pubkey, PublicKeyHash: []byte(strconv.Itoa(ft.pubkeys))}, nil
+}
+
+func newTestTrackers(t *testing.T) (*Trackers, *fakeTracker) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	ft := startFakeTracker(t, &key.PublicKey)
+	ft.list(ft.addr, "127.0.0.1:1", "127.0.0.1:2")
+	ts, err := NewTrackers(logrus.StandardLogger(), ft.addr, node.NewNode(1))
+	require.NoError(t, err)
+	return ts, ft
+}
+
+func TestDiscover(t *testing.T) {
+	ts, ft := newTestTrackers(t)
+	defer ft.srv.Stop()
+	defer ts.Close()
+	assert.Equal(t, []string{ft.addr, "127.0.0.1:1", "127.0.0.1:2"}, ts.Servers())
+
+	ft.list("127.0.0.1:2", "127.0.0.1:3")
+	require.NoError(t, ts.Discover())
+	assert.Equal(t, []string{ft.addr, "127.0.0.1:2", "127.0.0.1:3"}, ts.Servers(), "configured tracker is kept, tracker not listed is removed")
+
+	ft.list()
+	require.NoError(t, ts.Discover())
+	assert.Equal(t, []string{ft.addr}, ts.Servers())
+}
+
+func TestWithPublicKey(t *testing.T) {
+	ts, ft := newTestTrackers(t)
+	defer ft.srv.Stop()
+	defer ts.Close()
+	_, pubkeyHash := ts.PublicKey()
+	assert.Equal(t, []byte("1"), pubkeyHash)
+
+	var hashes []string
+	err := ts.WithPublicKey(func(pubkeyHash []byte) error {
+		hashes = append(hashes, string(pubkeyHash))
+		if len(hashes) == 1 {
+			return status.Error(codes.Unknown, TrackerPublicKeyExpired)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, hashes, "call is retried with refreshed public key")
+	_, pubkeyHash = ts.PublicKey()
+	assert.Equal(t, []byte("2"), pubkeyHash)
+
+	hashes = nil
+	failed := errors.New("failed")
+	err = ts.WithPublicKey(func(pubkeyHash []byte) error {
+		hashes = append(hashes, string(pubkeyHash))
+		return failed
+	})
+	assert.Equal(t, failed, err)
+	assert.Equal(t, []string{"2"}, hashes, "other errors are not retried")
+	assert.Equal(t, 2, ft.pubkeys)
+}
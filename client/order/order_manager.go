@@ -10,7 +10,6 @@ import (
 	pb "github.com/samoslab/nebula/tracker/register/client/pb"
 	rsalong "github.com/samoslab/nebula/util/rsa"
 	"github.com/sirupsen/logrus"
-	"google.golang.org/grpc"
 )
 
 // OrderManager order manager
@@ -94,8 +93,7 @@ func NewOrderFromPbOrder(o *pb.Order) *Order {
 }
 
 // NewOrderManager create order manager ,only communicate with tracker server
-func NewOrderManager(conn *grpc.ClientConn, log logrus.FieldLogger, privateKey *rsa.PrivateKey, nodeId []byte) *OrderManager {
-	oc := pb.NewOrderServiceClient(conn)
+func NewOrderManager(oc pb.OrderServiceClient, log logrus.FieldLogger, privateKey *rsa.PrivateKey, nodeId []byte) *OrderManager {
 	return &OrderManager{
 		orderClient: oc,
 		Log:         log,
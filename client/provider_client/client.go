@@ -15,6 +15,8 @@ import (
 	"github.com/samoslab/nebula/client/progress"
 	pb "github.com/samoslab/nebula/provider/pb"
 	tcppb "github.com/samoslab/nebula/tracker/collector/client/pb"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	"github.com/samoslab/nebula/util/merkle"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
@@ -23,6 +25,15 @@ import (
 
 const streamDataSize = 32 * 1024
 const smallFileSize = 512 * 1024
+const capabilityTTL = 30 * time.Minute
+const capabilityQueryTimeout = 10 * time.Second
+
+var capabilities = pb.NewCapabilityCache(capabilityTTL)
+
+// GetCapability return protocol version and features of provider, cached per server
+func GetCapability(client pb.ProviderServiceClient, server string) (*pb.Capability, error) {
+	return capabilities.Query(client, server, capabilityQueryTimeout)
+}
 
 func now() uint64 {
 	return uint64(time.Now().UnixNano())
@@ -71,18 +82,19 @@ func GetPingTime(ip string, port uint32) int {
 	defer cancel()
 	pclient := pb.NewProviderServiceClient(conn)
 	req := &pb.PingReq{
-		Version: common.Version,
+		Version: pb.ProtocolVersion,
 	}
-	_, err = pclient.Ping(ctx, req)
+	resp, err := pclient.Ping(ctx, req)
 	if err != nil {
 		return common.NetworkUnreachable
 	}
+	capabilities.Put(server, pb.NewCapability(resp))
 	timeEnd := time.Now().Unix()
 	return int(timeEnd - timeStart)
 }
 
-// StorePiece store blocks to privider
-func StorePiece(log logrus.FieldLogger, client pb.ProviderServiceClient, uploadPara *common.UploadParameter, auth []byte, ticket string, tm uint64, pm *progress.ProgressManager) error {
+// StorePiece store blocks to privider, tag is sent along with the block for provider proving, nil if no tag
+func StorePiece(log logrus.FieldLogger, client pb.ProviderServiceClient, uploadPara *common.UploadParameter, auth []byte, ticket string, tm uint64, pm *progress.ProgressManager, tag *pb.BlockTag) error {
 	fileInfo := uploadPara.HF
 	filePath := fileInfo.FileName
 	fileSize := uint64(fileInfo.FileSize)
@@ -97,7 +109,19 @@ func StorePiece(log logrus.FieldLogger, client pb.ProviderServiceClient, uploadP
 	if !ok {
 		log.Errorf("file %s not in reverse partition map", filePath)
 	}
+	capability, err := GetCapability(client, uploadPara.Provider)
+	if err != nil {
+		log.Errorf("query capability failed: %s", err.Error())
+		return err
+	}
+	if alg, _, _ := util_hash.ParseKey(fileInfo.FileHash); alg != util_hash.SHA1 && !capability.Has(pb.Feature_MULTIHASH_KEY) {
+		return fmt.Errorf("provider %s does not support %s key", uploadPara.Provider, alg)
+	}
+	if !capability.Has(pb.Feature_BLOCK_TAG) {
+		tag = nil
+	}
 	req := &pb.StoreReq{
+		Version:   capability.NegotiatedVersion(),
 		Timestamp: tm,
 		Auth:      auth,
 		Ticket:    ticket,
@@ -109,6 +133,7 @@ func StorePiece(log logrus.FieldLogger, client pb.ProviderServiceClient, uploadP
 	al := newActionLogFromStoreReq(req)
 	defer collectClient.Collect(al)
 	if fileSize < smallFileSize {
+		req.Tag = tag
 		req.Data, err = ioutil.ReadAll(file)
 		if err != nil {
 			SetActionLog(err, al)
@@ -147,9 +172,10 @@ func StorePiece(log logrus.FieldLogger, client pb.ProviderServiceClient, uploadP
 	}
 	defer stream.CloseSend()
 	buf := make([]byte, streamDataSize)
+	tags := tag.Split(int((fileSize + streamDataSize - 1) / streamDataSize))
 	first := true
 	sendBytes := 0
-	for {
+	for i := 0; ; i++ {
 		bytesRead, err := file.Read(buf)
 		if err != nil {
 			if err == io.EOF {
@@ -161,7 +187,7 @@ func StorePiece(log logrus.FieldLogger, client pb.ProviderServiceClient, uploadP
 		}
 		if first {
 			first = false
-			req.Data = buf[:bytesRead]
+			req.Data, req.Tag = buf[:bytesRead], tags[0]
 			if err := stream.Send(req); err != nil {
 				log.Errorf("Rpc First Send StoreReq failed: %s", err.Error())
 				if err == io.EOF {
@@ -173,7 +199,11 @@ func StorePiece(log logrus.FieldLogger, client pb.ProviderServiceClient, uploadP
 			sendBytes += bytesRead
 			log.Infof("Rpc first send store req success")
 		} else {
-			if err := stream.Send(&pb.StoreReq{Data: buf[:bytesRead]}); err != nil {
+			next := &pb.StoreReq{Data: buf[:bytesRead]}
+			if i < len(tags) {
+				next.Tag = tags[i]
+			}
+			if err := stream.Send(next); err != nil {
 				log.Errorf("Rpc Send non-first StoreReq failed: %s", err.Error())
 				if err == io.EOF {
 					break
@@ -290,3 +320,46 @@ func Retrieve(log logrus.FieldLogger, client pb.ProviderServiceClient, filePath
 	al.Success, al.EndTime, al.TransportSize = true, now(), uint64(fileSize)
 	return nil
 }
+
+// RetrieveChunk download one chunk of block and verify it with merkle path, root is required
+func RetrieveChunk(log logrus.FieldLogger, client pb.ProviderServiceClient, server string, auth []byte, ticket string, tm uint64, fileKey, blockKey []byte, fileSize, blockSize uint64, chunkIndex uint32, root []byte) ([]byte, error) {
+	if len(root) == 0 {
+		return nil, errors.New("merkle root is required")
+	}
+	capability, err := GetCapability(client, server)
+	if err != nil {
+		return nil, err
+	}
+	if !capability.Has(pb.Feature_CHUNK_PROOF) {
+		return nil, fmt.Errorf("provider %s does not support chunk proof", server)
+	}
+	req := &pb.ChunkProofReq{
+		Version:    capability.NegotiatedVersion(),
+		Timestamp:  tm,
+		Auth:       auth,
+		Ticket:     ticket,
+		FileKey:    fileKey,
+		FileSize:   fileSize,
+		BlockKey:   blockKey,
+		BlockSize:  blockSize,
+		ChunkIndex: chunkIndex,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	resp, err := client.GetChunkProof(ctx, req)
+	if err != nil {
+		log.Errorf("Rpc GetChunkProof failed: %s", err.Error())
+		return nil, err
+	}
+	// chunk count from provider is not trusted, it is known from block size
+	chunkCount := merkle.ChunkCount(blockSize, merkle.DefaultChunkSize)
+	if resp.ChunkSize != merkle.DefaultChunkSize || resp.ChunkCount != chunkCount {
+		log.Errorf("Chunk %d of block %x has chunk size %d count %d, expected %d %d", chunkIndex, blockKey, resp.ChunkSize, resp.ChunkCount, merkle.DefaultChunkSize, chunkCount)
+		return nil, fmt.Errorf("chunk size %d or count %d of provider is wrong", resp.ChunkSize, resp.ChunkCount)
+	}
+	if !merkle.Verify(root, resp.Data, chunkIndex, chunkCount, resp.Path) {
+		log.Errorf("Chunk %d of block %x verify failed", chunkIndex, blockKey)
+		return nil, fmt.Errorf("chunk %d merkle verify failed", chunkIndex)
+	}
+	return resp.Data, nil
+}
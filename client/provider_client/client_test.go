@@ -0,0 +1,62 @@
+package provider_client
+
+import (
+	"math/rand"
+	"testing"
+
+	pb "github.com/samoslab/nebula/provider/pb"
+	"github.com/samoslab/nebula/util/merkle"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// chunkProvider answers GetChunkProof with resp, other methods are not used
+type chunkProvider struct {
+	pb.ProviderServiceClient
+	resp *pb.ChunkProofResp
+}
+
+func (self *chunkProvider) GetChunkProof(ctx context.Context, in *pb.ChunkProofReq, opts ...grpc.CallOption) (*pb.ChunkProofResp, error) {
+	return self.resp, nil
+}
+
+func TestRetrieveChunk(t *testing.T) {
+	server := "chunk-provider:6666"
+	capabilities.Put(server, pb.NewCapability(&pb.PingResp{Version: pb.ProtocolVersion, Features: []pb.Feature{pb.Feature_CHUNK_PROOF}}))
+	data := make([]byte, 2*merkle.DefaultChunkSize+10)
+	rand.Read(data)
+	size := uint64(len(data))
+	leaves := merkle.Leaves(data, merkle.DefaultChunkSize)
+	root := merkle.Root(leaves)
+	chunk := func(i int) []byte {
+		end := (i + 1) * merkle.DefaultChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		return data[i*merkle.DefaultChunkSize : end]
+	}
+	path, err := merkle.Path(leaves, 1)
+	require.NoError(t, err)
+	provider := &chunkProvider{resp: &pb.ChunkProofResp{Data: chunk(1), ChunkSize: merkle.DefaultChunkSize, ChunkCount: 3, Path: path, Root: root}}
+	got, err := RetrieveChunk(logrus.StandardLogger(), provider, server, nil, "", 0, nil, nil, size, size, 1, root)
+	require.NoError(t, err)
+	assert.Equal(t, chunk(1), got)
+
+	// last chunk sent as chunk 1 of a block claimed to have 2 chunks, it verifies against the claimed count
+	forgedPath, err := merkle.Path([][]byte{merkle.Root(leaves[:2]), leaves[2]}, 1)
+	require.NoError(t, err)
+	require.True(t, merkle.Verify(root, chunk(2), 1, 2, forgedPath))
+	provider.resp = &pb.ChunkProofResp{Data: chunk(2), ChunkSize: merkle.DefaultChunkSize, ChunkCount: 2, Path: forgedPath, Root: root}
+	_, err = RetrieveChunk(logrus.StandardLogger(), provider, server, nil, "", 0, nil, nil, size, size, 1, root)
+	assert.Error(t, err, "forged chunk count")
+	provider.resp.ChunkCount = 3
+	_, err = RetrieveChunk(logrus.StandardLogger(), provider, server, nil, "", 0, nil, nil, size, size, 1, root)
+	assert.Error(t, err, "moved chunk with real count")
+
+	provider.resp = &pb.ChunkProofResp{Data: chunk(1), ChunkSize: merkle.DefaultChunkSize / 2, ChunkCount: 3, Path: path, Root: root}
+	_, err = RetrieveChunk(logrus.StandardLogger(), provider, server, nil, "", 0, nil, nil, size, size, 1, root)
+	assert.Error(t, err, "wrong chunk size")
+}
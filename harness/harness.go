@@ -0,0 +1,404 @@
+// Package harness runs a tracker, several providers and a client in one process on temp dirs and ephemeral ports,
+// so scenarios like upload, download, provider loss, replicate and prove can be tested without network or auth bypass.
+package harness
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/boltdb/bolt"
+	client_config "github.com/samoslab/nebula/client/config"
+	"github.com/samoslab/nebula/client/daemon"
+	"github.com/samoslab/nebula/provider/chaos"
+	provider_config "github.com/samoslab/nebula/provider/config"
+	"github.com/samoslab/nebula/provider/discovery"
+	provider_impl "github.com/samoslab/nebula/provider/impl"
+	"github.com/samoslab/nebula/provider/node"
+	ppb "github.com/samoslab/nebula/provider/pb"
+	ccpb "github.com/samoslab/nebula/tracker/collector/client/pb"
+	collector_impl "github.com/samoslab/nebula/tracker/collector/impl"
+	cppb "github.com/samoslab/nebula/tracker/collector/provider/pb"
+	"github.com/samoslab/nebula/tracker/config"
+	metadata_impl "github.com/samoslab/nebula/tracker/metadata/impl"
+	mpb "github.com/samoslab/nebula/tracker/metadata/pb"
+	client_impl "github.com/samoslab/nebula/tracker/register/client/impl"
+	rcpb "github.com/samoslab/nebula/tracker/register/client/pb"
+	"github.com/samoslab/nebula/tracker/registry"
+	task_impl "github.com/samoslab/nebula/tracker/task/impl"
+	tpb "github.com/samoslab/nebula/tracker/task/pb"
+	"github.com/samoslab/nebula/util/failover"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+var TasksNotDrainedErr = errors.New("tasks are not finished before timeout")
+
+// Options of cluster
+type Options struct {
+	Providers int
+	Trackers  int // tracker servers sharing db, all of them are listed by GetTrackerServer
+	Policy    metadata_impl.Policy
+	Task      task_impl.Config
+	Log       logrus.FieldLogger // log of client
+}
+
+// DefaultOptions is small enough to store every store type with 6 providers
+func DefaultOptions() Options {
+	policy := metadata_impl.DefaultPolicy()
+	policy.TinyFileSize, policy.ReplicaMaxSize, policy.DataPieceCount, policy.VerifyPieceCount, policy.SpareProviders, policy.ChunkSize =
+		1024, 64*1024, 2, 1, 1, 16*1024
+	return Options{Providers: 6, Trackers: 1, Policy: policy, Task: task_impl.DefaultConfig(), Log: logrus.StandardLogger()}
+}
+
+// Cluster is trackers with providers and a client
+type Cluster struct {
+	Dir          string
+	TrackerAddr  string // the one configured in client and providers
+	TrackerAddrs []string
+	Registry     *registry.Registry
+	Key          *config.Key
+	Metadata     *metadata_impl.MetadataService
+	Task         *task_impl.ProviderTaskService
+	Collector    *collector_impl.Collector
+	Providers    []*Provider
+	Client       *daemon.ClientManager
+	ClientNode   *node.Node
+	db           *bolt.DB
+	trackers     []*grpc.Server
+}
+
+// Provider is a provider of cluster, it keeps dir and address after stopped so it can be started again
+type Provider struct {
+	Node    *node.Node
+	Dir     string
+	Addr    string
+	Service *provider_impl.ProviderService
+	Chaos   *chaos.Config // faults injected into serving clients after provider is started, nil if none
+	storage *provider_config.Storage
+	tasks   *failover.Servers
+	server  *grpc.Server
+}
+
+// Running is false after provider is stopped or lost
+func (self *Provider) Running() bool {
+	return self.server != nil
+}
+
+// New start cluster in dir, it is removed by Close
+func New(dir string, opts Options) (c *Cluster, err error) {
+	c = &Cluster{Dir: dir}
+	defer func() {
+		if err != nil {
+			c.Close()
+			c = nil
+		}
+	}()
+	if c.db, err = bolt.Open(filepath.Join(dir, "tracker.db"), 0600, nil); err != nil {
+		return
+	}
+	if c.Registry, err = registry.New(c.db); err != nil {
+		return
+	}
+	if c.Key, err = config.LoadKey(filepath.Join(dir, "tracker.key")); err != nil {
+		return
+	}
+	if c.Metadata, err = metadata_impl.NewMetadataService(c.db, c.Registry, c.Key, opts.Policy); err != nil {
+		return
+	}
+	if c.Task, err = task_impl.NewProviderTaskService(c.db, c.Metadata.Store(), c.Registry, c.Key, opts.Task); err != nil {
+		return
+	}
+	if c.Collector, err = collector_impl.NewCollector(c.db, c.Registry, c.Key); err != nil {
+		return
+	}
+	if err = c.startTrackers(opts.Trackers); err != nil {
+		return
+	}
+	for i := 0; i < opts.Providers; i++ {
+		p := &Provider{Node: node.NewNode(10), Dir: filepath.Join(dir, "provider-"+strconv.Itoa(i)), Addr: "127.0.0.1:0"}
+		if err = os.MkdirAll(p.Dir, 0700); err != nil {
+			return
+		}
+		if err = c.startProvider(p); err != nil {
+			return
+		}
+		c.Providers = append(c.Providers, p)
+		host, port, _ := net.SplitHostPort(p.Addr)
+		portNum, _ := strconv.Atoi(port)
+		if err = c.Registry.PutProvider(&registry.Provider{NodeId: p.Node.NodeId, PublicKey: p.Node.PubKeyBytes,
+			Host: host, Port: uint32(portNum), Availability: 1, Storage: []uint64{p.storage.Volume}, Total: p.storage.Volume}); err != nil {
+			return
+		}
+	}
+	err = c.startClient(opts.Log)
+	return
+}
+
+// startTrackers listen first so every tracker lists all of them
+func (self *Cluster) startTrackers(count int) error {
+	if count < 1 {
+		count = 1
+	}
+	regConfig := client_impl.DefaultConfig()
+	var listeners []net.Listener
+	for i := 0; i < count; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return err
+		}
+		listeners = append(listeners, lis)
+		addr := lis.Addr().(*net.TCPAddr)
+		regConfig.TrackerServers = append(regConfig.TrackerServers, config.Server{Host: addr.IP.String(), Port: uint32(addr.Port)})
+		self.TrackerAddrs = append(self.TrackerAddrs, addr.String())
+	}
+	self.TrackerAddr = self.TrackerAddrs[0]
+	for _, lis := range listeners {
+		server := grpc.NewServer()
+		mpb.RegisterMatadataServiceServer(server, self.Metadata)
+		tpb.RegisterProviderTaskServiceServer(server, self.Task)
+		rcpb.RegisterClientRegisterServiceServer(server, client_impl.NewClientRegisterService(self.Registry, self.Key, regConfig))
+		cppb.RegisterProviderCollectorServiceServer(server, collector_impl.NewProviderCollectorService(self.Collector))
+		ccpb.RegisterClientCollectorServiceServer(server, collector_impl.NewClientCollectorService(self.Collector))
+		go server.Serve(lis)
+		self.trackers = append(self.trackers, server)
+	}
+	return nil
+}
+
+// StopTracker stop serving on tracker, client fails over to others
+func (self *Cluster) StopTracker(i int) {
+	self.trackers[i].Stop()
+}
+
+func serve(server *grpc.Server, addr string) (string, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	go server.Serve(lis)
+	return lis.Addr().String(), nil
+}
+
+func (self *Cluster) startProvider(p *Provider) (err error) {
+	if p.storage, err = provider_config.NewStorage(p.Dir, 0); err != nil {
+		return
+	}
+	// task service is on every tracker, provider fails over to next one like daemon does
+	p.tasks = discovery.NewTasks(self.TrackerAddrs)
+	if p.Service, err = provider_impl.NewProviderServiceWithOptions(provider_impl.Options{Node: p.Node,
+		Storages: dirStorages{p.storage}, TaskClient: tpb.NewProviderTaskServiceClient(p.tasks.Conn())}); err != nil {
+		p.tasks.Close()
+		p.storage.SmallFileDb.Close()
+		return
+	}
+	p.server = grpc.NewServer()
+	if p.Chaos != nil {
+		ppb.RegisterProviderServiceServer(p.server, chaos.NewService(p.Service, p.Chaos))
+	} else {
+		ppb.RegisterProviderServiceServer(p.server, p.Service)
+	}
+	if p.Addr, err = serve(p.server, p.Addr); err != nil {
+		p.close()
+	}
+	return
+}
+
+func (self *Provider) close() {
+	self.server.Stop()
+	self.server = nil
+	self.Service.CloseTaskProcessor()
+	self.Service.Close()
+	self.tasks.Close()
+	self.storage.SmallFileDb.Close()
+}
+
+func (self *Cluster) startClient(log logrus.FieldLogger) error {
+	self.ClientNode = node.NewNode(10)
+	if err := self.Registry.PutClient(&registry.Client{NodeId: self.ClientNode.NodeId, PublicKey: self.ClientNode.PubKeyBytes,
+		ContactEmail: "client@example.com", EmailVerified: true}); err != nil {
+		return err
+	}
+	configDir := filepath.Join(self.Dir, "client")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return err
+	}
+	cfg := &client_config.ClientConfig{NodeId: self.ClientNode.NodeIdStr(),
+		PublicKey:    self.ClientNode.PublicKeyStr(),
+		PrivateKey:   self.ClientNode.PrivateKeyStr(),
+		Email:        "client@example.com",
+		Node:         self.ClientNode,
+		Root:         configDir,
+		SelfFileName: filepath.Join(configDir, "config.json")}
+	webcfg := client_config.Config{TrackerServer: self.TrackerAddr, CollectServer: self.TrackerAddr, ConfigDir: configDir}
+	var err error
+	self.Client, err = daemon.NewClientManager(log, webcfg, cfg)
+	return err
+}
+
+// StopProvider stop serving, the provider is still registered so tracker hands it out
+func (self *Cluster) StopProvider(i int) {
+	if p := self.Providers[i]; p.Running() {
+		p.close()
+	}
+}
+
+// StartProvider start stopped provider with its data on the same address
+func (self *Cluster) StartProvider(i int) error {
+	if p := self.Providers[i]; !p.Running() {
+		return self.startProvider(p)
+	}
+	return nil
+}
+
+// RestartProvider start provider again to apply its chaos config
+func (self *Cluster) RestartProvider(i int) error {
+	self.StopProvider(i)
+	return self.StartProvider(i)
+}
+
+// LoseProvider stop and unregister provider, blocks it held are replicated in next schedule
+func (self *Cluster) LoseProvider(i int) error {
+	self.StopProvider(i)
+	return self.Registry.RemoveProvider(self.Providers[i].Node.NodeId)
+}
+
+// ProviderIndex return index of provider with node id, -1 if not found
+func (self *Cluster) ProviderIndex(nodeId []byte) int {
+	for i, p := range self.Providers {
+		if string(p.Node.NodeId) == string(nodeId) {
+			return i
+		}
+	}
+	return -1
+}
+
+// RunTasks schedule a round of tasks and let running providers process them until none of them has unfinished task
+func (self *Cluster) RunTasks(timeout time.Duration) error {
+	if err := self.Task.Schedule(); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		pending := 0
+		for _, p := range self.Providers {
+			if !p.Running() {
+				continue
+			}
+			tasks, err := self.Task.Tasks(p.Node.NodeId)
+			if err != nil {
+				return err
+			}
+			if len(tasks) > 0 {
+				pending += len(tasks)
+				p.Service.GetTask()
+			}
+		}
+		if pending == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return TasksNotDrainedErr
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Upload file to folder of space 0
+func (self *Cluster) Upload(localPath string, folder string) error {
+	return self.Client.UploadFile(localPath, folder, false, false, false, 0)
+}
+
+// Download file in folder of space 0 to destDir, it returns path of downloaded file
+func (self *Cluster) Download(folder string, name string, destDir string) (string, error) {
+	f, err := self.Find(folder, name)
+	if err != nil {
+		return "", err
+	}
+	path := folder + "/" + name
+	if folder == "/" {
+		path = "/" + name
+	}
+	if err = self.Client.DownloadFile(path, destDir, f.FileHash, f.FileSize, 0); err != nil {
+		return "", err
+	}
+	return filepath.Join(destDir, name), nil
+}
+
+// Find file in folder of space 0
+func (self *Cluster) Find(folder string, name string) (*daemon.DownFile, error) {
+	pages, err := self.Client.ListFiles(folder, 100, 1, "", true, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range pages.Files {
+		if f.FileName == name {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("file %s not found in %s", name, folder)
+}
+
+// Holders return node ids of providers storing each block of file
+func (self *Cluster) Holders(folder string, name string) ([][][]byte, error) {
+	f, err := self.Find(folder, name)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hex.DecodeString(f.FileHash)
+	if err != nil {
+		return nil, err
+	}
+	file, err := self.Metadata.Store().File(hash, f.FileSize)
+	if err != nil {
+		return nil, err
+	}
+	var res [][][]byte
+	for _, p := range file.Partitions {
+		for _, b := range p.Block {
+			res = append(res, b.StoreNodeId)
+		}
+	}
+	return res, nil
+}
+
+// Close stop all nodes and remove dir
+func (self *Cluster) Close() {
+	if self.Client != nil {
+		self.Client.Shutdown()
+	}
+	for _, p := range self.Providers {
+		if p.Running() {
+			p.close()
+		}
+	}
+	for _, tracker := range self.trackers {
+		tracker.Stop()
+	}
+	if self.db != nil {
+		self.db.Close()
+	}
+	os.RemoveAll(self.Dir)
+}
+
+// TempCluster create cluster in a new temp dir
+func TempCluster(opts Options) (*Cluster, error) {
+	dir, err := ioutil.TempDir("", "nebula-harness")
+	if err != nil {
+		return nil, err
+	}
+	c, err := New(dir, opts)
+	if err != nil {
+		os.RemoveAll(dir)
+	}
+	return c, err
+}
@@ -0,0 +1,192 @@
+package harness
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samoslab/nebula/provider/chaos"
+	"github.com/samoslab/nebula/provider/loadgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCluster(t *testing.T) (*Cluster, string) {
+	return newTestClusterWithOptions(t, DefaultOptions())
+}
+
+func newTestClusterWithOptions(t *testing.T, opts Options) (*Cluster, string) {
+	if testing.Short() {
+		t.Skip("cluster scenario is skipped in short mode")
+	}
+	c, err := TempCluster(opts)
+	require.NoError(t, err)
+	local := filepath.Join(c.Dir, "local")
+	require.NoError(t, os.MkdirAll(local, 0700))
+	return c, local
+}
+
+func writeRandomFile(t *testing.T, dir string, name string, size int) []byte {
+	data := make([]byte, size)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), data, 0600))
+	return data
+}
+
+func assertDownload(t *testing.T, c *Cluster, name string, data []byte) {
+	dest, err := ioutil.TempDir(c.Dir, "download")
+	require.NoError(t, err)
+	path, err := c.Download("/", name, dest)
+	require.NoError(t, err)
+	got, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(data, got), "content of %s changed", name)
+}
+
+func TestUploadDownload(t *testing.T) {
+	c, local := newTestCluster(t)
+	defer c.Close()
+	files := map[string]int{"tiny": 512, "replica": 20 * 1024, "erasure": 200 * 1024}
+	contents := make(map[string][]byte, len(files))
+	for name, size := range files {
+		contents[name] = writeRandomFile(t, local, name, size)
+		require.NoError(t, c.Upload(filepath.Join(local, name), "/"))
+	}
+	holders, err := c.Holders("/", "replica")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(holders))
+	assert.True(t, len(holders[0]) >= 3)
+	holders, err = c.Holders("/", "erasure")
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(holders))
+	for name, data := range contents {
+		assertDownload(t, c, name, data)
+	}
+}
+
+func TestProviderLossAndReplicate(t *testing.T) {
+	c, local := newTestCluster(t)
+	defer c.Close()
+	data := writeRandomFile(t, local, "replica", 20*1024)
+	require.NoError(t, c.Upload(filepath.Join(local, "replica"), "/"))
+	holders, err := c.Holders("/", "replica")
+	require.NoError(t, err)
+	require.True(t, len(holders) == 1 && len(holders[0]) >= 3)
+	before := len(holders[0])
+	lost := c.ProviderIndex(holders[0][0])
+	require.NoError(t, c.LoseProvider(lost))
+	// the other holder is down for a while, the block is still retrieved from the rest
+	down := c.ProviderIndex(holders[0][1])
+	c.StopProvider(down)
+	assertDownload(t, c, "replica", data)
+	require.NoError(t, c.StartProvider(down))
+
+	require.NoError(t, c.RunTasks(30*time.Second))
+	holders, err = c.Holders("/", "replica")
+	require.NoError(t, err)
+	require.Equal(t, before+1, len(holders[0]), "block is replicated to a new provider")
+	c.StopProvider(c.ProviderIndex(holders[0][1]))
+	c.StopProvider(c.ProviderIndex(holders[0][2]))
+	assertDownload(t, c, "replica", data)
+}
+
+func TestProve(t *testing.T) {
+	c, local := newTestCluster(t)
+	defer c.Close()
+	// replica providers get the block tag on upload, so they prove the block like erasure ones
+	files := []string{"erasure", "replica"}
+	writeRandomFile(t, local, "erasure", 100*1024)
+	writeRandomFile(t, local, "replica", 20*1024)
+	before := make(map[string][][][]byte, len(files))
+	for _, name := range files {
+		require.NoError(t, c.Upload(filepath.Join(local, name), "/"))
+		holders, err := c.Holders("/", name)
+		require.NoError(t, err)
+		before[name] = holders
+	}
+
+	require.NoError(t, c.RunTasks(time.Minute))
+	for _, name := range files {
+		after, err := c.Holders("/", name)
+		require.NoError(t, err)
+		assert.Equal(t, before[name], after, "holders which prove blocks of %s are kept", name)
+	}
+}
+
+func TestShardRecovery(t *testing.T) {
+	c, local := newTestCluster(t)
+	defer c.Close()
+	data := writeRandomFile(t, local, "erasure", 200*1024)
+	require.NoError(t, c.Upload(filepath.Join(local, "erasure"), "/"))
+	holders, err := c.Holders("/", "erasure")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(holders))
+	// one shard is lost at a time, the other one is slow, parity shard recovers the file
+	faulty := c.ProviderIndex(holders[0][0])
+	c.Providers[faulty].Chaos = &chaos.Config{Seed: 1, Rules: map[string]*chaos.Rule{
+		"Retrieve":      {Unavailable: 1},
+		"RetrieveSmall": {Unavailable: 1}}}
+	require.NoError(t, c.RestartProvider(faulty))
+	slow := c.ProviderIndex(holders[1][0])
+	c.Providers[slow].Chaos = &chaos.Config{Seed: 1, Rules: map[string]*chaos.Rule{
+		"*": {Latency: chaos.Duration(100 * time.Millisecond), Bandwidth: 1024 * 1024}}}
+	require.NoError(t, c.RestartProvider(slow))
+	assertDownload(t, c, "erasure", data)
+}
+
+func TestLoad(t *testing.T) {
+	c, _ := newTestCluster(t)
+	defer c.Close()
+	p := c.Providers[0]
+	report, err := loadgen.Run(&loadgen.Config{Providers: []string{p.Addr}, PublicKeys: [][]byte{p.Node.PubKeyBytes},
+		Concurrency: 4, Requests: 40, ReadRatio: 0.5, Sizes: []loadgen.SizeWeight{{Size: 4096, Weight: 3}, {Size: 600 * 1024, Weight: 1}}, Seed: 1})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(40), report.Store.Count+report.Retrieve.Count)
+	assert.Equal(t, uint64(0), report.Store.Errors+report.Retrieve.Errors, "%v %v", report.Store.Codes, report.Retrieve.Codes)
+}
+
+func TestTrackerFailover(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Trackers = 2
+	c, local := newTestClusterWithOptions(t, opts)
+	defer c.Close()
+	// client is configured with the first tracker only, the other one is discovered
+	assert.Equal(t, c.TrackerAddrs, c.Client.TrackerServers())
+	tiny := writeRandomFile(t, local, "tiny", 512)
+	require.NoError(t, c.Upload(filepath.Join(local, "tiny"), "/"))
+
+	c.StopTracker(0)
+	replica := writeRandomFile(t, local, "replica", 20*1024)
+	require.NoError(t, c.Upload(filepath.Join(local, "replica"), "/"))
+	assertDownload(t, c, "tiny", tiny)
+	assertDownload(t, c, "replica", replica)
+	assert.Equal(t, []string{c.TrackerAddrs[1], c.TrackerAddrs[0]}, c.Client.TrackerServers(), "stopped tracker is tried last")
+}
+
+func TestProviderTaskFailover(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Trackers = 2
+	c, local := newTestClusterWithOptions(t, opts)
+	defer c.Close()
+	data := writeRandomFile(t, local, "replica", 20*1024)
+	require.NoError(t, c.Upload(filepath.Join(local, "replica"), "/"))
+	holders, err := c.Holders("/", "replica")
+	require.NoError(t, err)
+	before := len(holders[0])
+	require.NoError(t, c.LoseProvider(c.ProviderIndex(holders[0][0])))
+
+	// providers get and finish tasks on the other tracker
+	c.StopTracker(0)
+	require.NoError(t, c.RunTasks(30*time.Second))
+	holders, err = c.Holders("/", "replica")
+	require.NoError(t, err)
+	require.Equal(t, before+1, len(holders[0]), "block is replicated to a new provider")
+	c.StopProvider(c.ProviderIndex(holders[0][1]))
+	c.StopProvider(c.ProviderIndex(holders[0][2]))
+	assertDownload(t, c, "replica", data)
+}
@@ -0,0 +1,41 @@
+package harness
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/samoslab/nebula/provider/config"
+)
+
+// dirStorages is a single main storage in provider dir
+type dirStorages struct {
+	main *config.Storage
+}
+
+func (self dirStorages) GetStorage(index byte) *config.Storage {
+	if index != 0 {
+		return nil
+	}
+	return self.main
+}
+
+func (self dirStorages) GetStoragePath(index byte, subPath string) string {
+	return self.main.Path + filepath.FromSlash(subPath)
+}
+
+func (self dirStorages) GetWriteStorage(size uint64) *config.Storage {
+	if self.main.Failed() {
+		return nil
+	}
+	return self.main
+}
+
+func (self dirStorages) AvailableVolume() (total uint64, max uint64) {
+	return self.main.Volume, self.main.Volume
+}
+
+func (self dirStorages) RecordIOErrorOfPath(path string, err error) {
+	if strings.HasPrefix(path, self.main.Path) {
+		self.main.RecordIOError(err)
+	}
+}
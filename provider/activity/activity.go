@@ -0,0 +1,284 @@
+// Package activity keep action logs and task outcomes in local daily files,
+// so that disputes of earnings or lost blocks can be checked without the collector.
+//
+// Each file holds one JSON entry per line, it can be queried while daemon is running.
+package activity
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tcppb "github.com/samoslab/nebula/tracker/collector/provider/pb"
+	ttpb "github.com/samoslab/nebula/tracker/task/pb"
+	log "github.com/sirupsen/logrus"
+)
+
+const file_prefix = "activity-"
+const file_suffix = ".jsonl"
+const day_layout = "20060102"
+
+const (
+	TypeStore    = "STORE"
+	TypeRetrieve = "RETRIEVE"
+)
+
+// Entry is an action log or a task outcome, hash is hex string
+type Entry struct {
+	Time          int64 // unix ns, when finished
+	Type          string
+	AsClient      bool `json:",omitempty"`
+	Success       bool
+	Ticket        string `json:",omitempty"`
+	TaskId        string `json:",omitempty"`
+	FileHash      string `json:",omitempty"`
+	FileSize      uint64 `json:",omitempty"`
+	BlockHash     string
+	BlockSize     uint64
+	BeginTime     int64  `json:",omitempty"` // unix ns
+	TransportSize uint64 `json:",omitempty"`
+	Remark        string `json:",omitempty"`
+}
+
+func actionType(t uint32) string {
+	switch t {
+	case 1:
+		return TypeStore
+	case 2:
+		return TypeRetrieve
+	}
+	return fmt.Sprintf("ACTION-%d", t)
+}
+
+func fromActionLog(al *tcppb.ActionLog) *Entry {
+	e := &Entry{Time: int64(al.EndTime),
+		Type:          actionType(al.Type),
+		AsClient:      al.AsClient,
+		Success:       al.Success,
+		Ticket:        al.Ticket,
+		FileHash:      hex.EncodeToString(al.FileHash),
+		FileSize:      al.FileSize,
+		BlockHash:     hex.EncodeToString(al.BlockHash),
+		BlockSize:     al.BlockSize,
+		BeginTime:     int64(al.BeginTime),
+		TransportSize: al.TransportSize,
+		Remark:        al.Info}
+	if e.Time == 0 {
+		e.Time = time.Now().UnixNano()
+	}
+	return e
+}
+
+func fromTask(ta *ttpb.Task, success bool, remark string) *Entry {
+	return &Entry{Time: time.Now().UnixNano(),
+		Type:      ta.Type.String(),
+		Success:   success,
+		TaskId:    hex.EncodeToString(ta.Id),
+		FileHash:  hex.EncodeToString(ta.FileHash),
+		FileSize:  ta.FileSize,
+		BlockHash: hex.EncodeToString(ta.BlockHash),
+		BlockSize: ta.BlockSize,
+		Remark:    remark}
+}
+
+type writer struct {
+	dir  string
+	keep time.Duration
+	day  string
+	file *os.File
+}
+
+var mutex sync.Mutex
+var current *writer
+
+// Start journal in dir, files older than keep are deleted when rotating
+func Start(dir string, keep time.Duration) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	current = &writer{dir: dir, keep: keep}
+	current.prune(time.Now())
+	return nil
+}
+
+func Stop() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if current != nil && current.file != nil {
+		current.file.Close()
+	}
+	current = nil
+}
+
+// RecordAction journal action log, it does nothing if journal is not started
+func RecordAction(al *tcppb.ActionLog) {
+	record(fromActionLog(al))
+}
+
+// RecordTask journal task outcome, it does nothing if journal is not started
+func RecordTask(ta *ttpb.Task, success bool, remark string) {
+	record(fromTask(ta, success, remark))
+}
+
+func record(e *Entry) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if current == nil {
+		return
+	}
+	if err := current.write(e); err != nil {
+		log.Warnf("write activity journal failed: %s", err)
+	}
+}
+
+func filePath(dir string, day string) string {
+	return dir + string(os.PathSeparator) + file_prefix + day + file_suffix
+}
+
+func (self *writer) write(e *Entry) error {
+	t := time.Unix(0, e.Time)
+	if day := t.Format(day_layout); day != self.day || self.file == nil {
+		if self.file != nil {
+			self.file.Close()
+			self.file = nil
+			self.prune(t)
+		}
+		file, err := os.OpenFile(filePath(self.dir, day), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+		self.file, self.day = file, day
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = self.file.Write(append(b, '\n'))
+	return err
+}
+
+func (self *writer) prune(now time.Time) {
+	if self.keep <= 0 {
+		return
+	}
+	days, err := listDays(self.dir)
+	if err != nil {
+		log.Warnf("list activity journal failed: %s", err)
+		return
+	}
+	cutoff := now.Add(-self.keep).Format(day_layout)
+	for _, day := range days {
+		if day < cutoff {
+			if err = os.Remove(filePath(self.dir, day)); err != nil {
+				log.Warnf("remove activity journal failed: %s", err)
+			}
+		}
+	}
+}
+
+// listDays list days of journal files in order
+func listDays(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	res := make([]string, 0, len(infos))
+	for _, fi := range infos {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, file_prefix) || !strings.HasSuffix(name, file_suffix) {
+			continue
+		}
+		day := name[len(file_prefix) : len(name)-len(file_suffix)]
+		if _, err := time.Parse(day_layout, day); err == nil {
+			res = append(res, day)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// Filter of Query, zero value matches all
+type Filter struct {
+	BlockHash string // hex
+	Ticket    string
+	Type      string
+	From      time.Time
+	To        time.Time
+	Success   *bool
+}
+
+func (self *Filter) match(e *Entry) bool {
+	if len(self.BlockHash) > 0 && !strings.EqualFold(self.BlockHash, e.BlockHash) {
+		return false
+	}
+	if len(self.Ticket) > 0 && self.Ticket != e.Ticket {
+		return false
+	}
+	if len(self.Type) > 0 && !strings.EqualFold(self.Type, e.Type) {
+		return false
+	}
+	if !self.From.IsZero() && e.Time < self.From.UnixNano() {
+		return false
+	}
+	if !self.To.IsZero() && e.Time >= self.To.UnixNano() {
+		return false
+	}
+	if self.Success != nil && *self.Success != e.Success {
+		return false
+	}
+	return true
+}
+
+// Query read entries matched by filter from journal files in dir, broken lines are skipped
+func Query(dir string, filter *Filter) ([]*Entry, error) {
+	days, err := listDays(dir)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Entry, 0, 64)
+	for _, day := range days {
+		t, _ := time.ParseInLocation(day_layout, day, time.Local)
+		if !filter.To.IsZero() && !t.Before(filter.To) {
+			continue
+		}
+		if !filter.From.IsZero() && !t.AddDate(0, 0, 1).After(filter.From) {
+			continue
+		}
+		if res, err = queryFile(filePath(dir, day), filter, res); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func queryFile(path string, filter *Filter, res []*Entry) ([]*Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		e := &Entry{}
+		if err = json.Unmarshal(scanner.Bytes(), e); err != nil {
+			continue
+		}
+		if filter.match(e) {
+			res = append(res, e)
+		}
+	}
+	return res, scanner.Err()
+}
@@ -0,0 +1,68 @@
+package activity
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	tcppb "github.com/samoslab/nebula/tracker/collector/provider/pb"
+	ttpb "github.com/samoslab/nebula/tracker/task/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "activity")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	old := filePath(dir, time.Now().AddDate(0, 0, -100).Format(day_layout))
+	require.NoError(t, ioutil.WriteFile(old, []byte("{}\n"), 0600))
+
+	RecordAction(&tcppb.ActionLog{Type: 1, Ticket: "ignored"})
+	require.NoError(t, Start(dir, 90*24*time.Hour))
+	_, err = os.Stat(old)
+	require.True(t, os.IsNotExist(err))
+	yesterday := time.Now().AddDate(0, 0, -1)
+	RecordAction(&tcppb.ActionLog{Type: 1, Ticket: "t1", Success: true, BlockHash: []byte{1, 2}, BlockSize: 10,
+		BeginTime: uint64(yesterday.UnixNano()), EndTime: uint64(yesterday.UnixNano())})
+	RecordAction(&tcppb.ActionLog{Type: 2, Ticket: "t2", BlockHash: []byte{3, 4}, Info: "file not exist"})
+	RecordTask(&ttpb.Task{Id: []byte{9}, Type: ttpb.TaskType_REMOVE, BlockHash: []byte{1, 2}}, true, "")
+	Stop()
+	RecordAction(&tcppb.ActionLog{Type: 1, Ticket: "ignored"})
+
+	all, err := Query(dir, &Filter{})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(all))
+	require.Equal(t, "t1", all[0].Ticket)
+
+	res, err := Query(dir, &Filter{BlockHash: "0102"})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(res))
+	require.Equal(t, "REMOVE", res[1].Type)
+	require.Equal(t, "09", res[1].TaskId)
+
+	failed := false
+	res, err = Query(dir, &Filter{Success: &failed})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(res))
+	require.Equal(t, TypeRetrieve, res[0].Type)
+	require.Equal(t, "file not exist", res[0].Remark)
+
+	res, err = Query(dir, &Filter{From: time.Now().Add(-time.Hour), Ticket: "t2"})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(res))
+	res, err = Query(dir, &Filter{To: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(res))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteCSV(buf, all))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, 4, len(lines))
+	require.True(t, strings.HasPrefix(lines[0], "time,type,"))
+	buf.Reset()
+	require.NoError(t, WriteJSON(buf, all))
+	require.Contains(t, buf.String(), `"Ticket": "t2"`)
+}
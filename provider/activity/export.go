@@ -0,0 +1,43 @@
+package activity
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csv_header = []string{"time", "type", "asClient", "success", "ticket", "taskId", "fileHash", "fileSize",
+	"blockHash", "blockSize", "beginTime", "transportSize", "remark"}
+
+func formatTime(ns int64) string {
+	if ns == 0 {
+		return ""
+	}
+	return time.Unix(0, ns).Format(time.RFC3339Nano)
+}
+
+// WriteCSV export entries with header, time is RFC3339
+func WriteCSV(w io.Writer, entries []*Entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csv_header); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := cw.Write([]string{formatTime(e.Time), e.Type, strconv.FormatBool(e.AsClient), strconv.FormatBool(e.Success),
+			e.Ticket, e.TaskId, e.FileHash, strconv.FormatUint(e.FileSize, 10), e.BlockHash, strconv.FormatUint(e.BlockSize, 10),
+			formatTime(e.BeginTime), strconv.FormatUint(e.TransportSize, 10), e.Remark}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON export entries as a JSON array
+func WriteJSON(w io.Writer, entries []*Entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
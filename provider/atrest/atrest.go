@@ -0,0 +1,299 @@
+// Package atrest encrypt blocks stored on provider disk with node encrypt key.
+//
+// Sealed data is: magic(4) | format(1) | key version length(1) | key version | iv(16) | AES-256-CTR ciphertext.
+// CTR mode keeps ciphertext the same length as plaintext and allows reading from any offset,
+// so prove, fragment and chunk proof can seek into sealed block file. Integrity is checked by block key as before.
+package atrest
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+const header_magic = "NBAR"
+const format_version byte = 1
+const iv_len = aes.BlockSize
+const key_derive_prefix = "nebula-provider-at-rest:"
+
+var (
+	ErrNoKey         = errors.New("no encrypt key")
+	ErrUnknownFormat = errors.New("unknown at-rest format")
+	ErrInvalidHeader = errors.New("invalid at-rest header")
+)
+
+// Cipher seal with the current key version and open with any known key version
+type Cipher struct {
+	version string
+	blocks  map[string]cipher.Block
+}
+
+// NewCipher from node encrypt keys, current version is the greatest numeric version
+func NewCipher(keys map[string][]byte) (*Cipher, error) {
+	c := &Cipher{blocks: make(map[string]cipher.Block, len(keys))}
+	current := -1
+	for v, k := range keys {
+		if len(v) == 0 || len(v) > 255 || len(k) == 0 {
+			return nil, fmt.Errorf("invalid encrypt key version: %s", v)
+		}
+		dk := sha256.Sum256(append([]byte(key_derive_prefix), k...))
+		block, err := aes.NewCipher(dk[:])
+		if err != nil {
+			return nil, err
+		}
+		c.blocks[v] = block
+		if n, err := strconv.Atoi(v); err == nil && n > current {
+			current, c.version = n, v
+		}
+	}
+	if c.version == "" {
+		return nil, ErrNoKey
+	}
+	return c, nil
+}
+
+// Version current key version used by seal
+func (self *Cipher) Version() string {
+	return self.version
+}
+
+// parseHeader parse header of sealed data, ok is false if data is not sealed
+func parseHeader(b []byte) (version string, iv []byte, headerLen int, ok bool, err error) {
+	if len(b) < len(header_magic) || !bytes.Equal(b[:len(header_magic)], []byte(header_magic)) {
+		return "", nil, 0, false, nil
+	}
+	if len(b) < len(header_magic)+2 {
+		return "", nil, 0, true, ErrInvalidHeader
+	}
+	if b[len(header_magic)] != format_version {
+		return "", nil, 0, true, ErrUnknownFormat
+	}
+	vl := int(b[len(header_magic)+1])
+	headerLen = len(header_magic) + 2 + vl + iv_len
+	if vl == 0 || len(b) < headerLen {
+		return "", nil, 0, true, ErrInvalidHeader
+	}
+	start := len(header_magic) + 2
+	return string(b[start : start+vl]), b[start+vl : headerLen], headerLen, true, nil
+}
+
+// max_header_len is enough bytes to parse any header
+const max_header_len = len(header_magic) + 2 + 255 + iv_len
+
+// IsSealed check data begin with at-rest header, plaintext which happens to begin with magic is always sealed when stored
+func IsSealed(b []byte) bool {
+	return len(b) >= len(header_magic) && bytes.Equal(b[:len(header_magic)], []byte(header_magic))
+}
+
+// SealedVersion return key version of sealed data, empty if not sealed
+func SealedVersion(b []byte) string {
+	version, _, _, ok, err := parseHeader(b)
+	if !ok || err != nil {
+		return ""
+	}
+	return version
+}
+
+func (self *Cipher) newHeader() ([]byte, []byte, error) {
+	header := make([]byte, 0, len(header_magic)+2+len(self.version)+iv_len)
+	header = append(header, header_magic...)
+	header = append(header, format_version, byte(len(self.version)))
+	header = append(header, self.version...)
+	iv := make([]byte, iv_len)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, nil, err
+	}
+	return append(header, iv...), iv, nil
+}
+
+// xorAt encrypt or decrypt buf at offset of plaintext
+func xorAt(block cipher.Block, iv []byte, offset int64, buf []byte) {
+	counter := make([]byte, iv_len)
+	copy(counter, iv)
+	add := uint64(offset / iv_len)
+	for i := iv_len - 1; i >= 0 && add > 0; i-- {
+		sum := uint64(counter[i]) + add&0xff
+		counter[i] = byte(sum)
+		add = add>>8 + sum>>8
+	}
+	stream := cipher.NewCTR(block, counter)
+	if skip := int(offset % iv_len); skip > 0 {
+		pad := make([]byte, skip)
+		stream.XORKeyStream(pad, pad)
+	}
+	stream.XORKeyStream(buf, buf)
+}
+
+// Seal encrypt data with current key version
+func (self *Cipher) Seal(data []byte) ([]byte, error) {
+	header, iv, err := self.newHeader()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]byte, len(header)+len(data))
+	copy(res, header)
+	copy(res[len(header):], data)
+	xorAt(self.blocks[self.version], iv, 0, res[len(header):])
+	return res, nil
+}
+
+// Open decrypt sealed data, data not sealed is returned as it is
+func (self *Cipher) Open(b []byte) ([]byte, error) {
+	version, iv, headerLen, ok, err := parseHeader(b)
+	if !ok {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	block, found := self.blocks[version]
+	if !found {
+		return nil, fmt.Errorf("unknown encrypt key version: %s", version)
+	}
+	res := make([]byte, len(b)-headerLen)
+	copy(res, b[headerLen:])
+	xorAt(block, iv, 0, res)
+	return res, nil
+}
+
+// SealFile encrypt src file to dst file, dst is created or truncated
+func (self *Cipher) SealFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return self.SealTo(dst, in)
+}
+
+// SealTo encrypt all data read from reader to dst file, dst is created or truncated
+func (self *Cipher) SealTo(dst string, reader io.Reader) error {
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	header, iv, err := self.newHeader()
+	if err != nil {
+		return err
+	}
+	if _, err = out.Write(header); err != nil {
+		return err
+	}
+	w := &cipher.StreamWriter{S: cipher.NewCTR(self.blocks[self.version], iv), W: out}
+	if _, err = io.Copy(w, reader); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// File is plaintext view of block file whether sealed or not
+type File struct {
+	file      *os.File
+	block     cipher.Block
+	iv        []byte
+	version   string
+	headerLen int64
+	size      int64
+	pos       int64
+}
+
+// OpenFile open block file for reading plaintext
+func (self *Cipher) OpenFile(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f := &File{file: file, size: fileInfo.Size()}
+	buf := make([]byte, max_header_len)
+	n, err := file.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+	version, iv, headerLen, ok, err := parseHeader(buf[:n])
+	if !ok {
+		return f, nil
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	block, found := self.blocks[version]
+	if !found {
+		file.Close()
+		return nil, fmt.Errorf("unknown encrypt key version: %s", version)
+	}
+	f.block, f.iv, f.version = block, iv, version
+	f.headerLen, f.size = int64(headerLen), f.size-int64(headerLen)
+	return f, nil
+}
+
+// Sealed is file encrypted
+func (self *File) Sealed() bool {
+	return self.block != nil
+}
+
+// Version key version of sealed file, empty if not sealed
+func (self *File) Version() string {
+	return self.version
+}
+
+// Size plaintext size
+func (self *File) Size() int64 {
+	return self.size
+}
+
+func (self *File) ReadAt(p []byte, off int64) (int, error) {
+	if off >= self.size {
+		return 0, io.EOF
+	}
+	n, err := self.file.ReadAt(p, self.headerLen+off)
+	if self.block != nil && n > 0 {
+		xorAt(self.block, self.iv, off, p[:n])
+	}
+	return n, err
+}
+
+// Read return io.EOF only when nothing read, like os.File
+func (self *File) Read(p []byte) (int, error) {
+	n, err := self.ReadAt(p, self.pos)
+	self.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (self *File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += self.pos
+	case io.SeekEnd:
+		offset += self.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	self.pos = offset
+	return offset, nil
+}
+
+func (self *File) Close() error {
+	return self.file.Close()
+}
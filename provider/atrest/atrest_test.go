@@ -0,0 +1,117 @@
+package atrest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func newTestCipher(t *testing.T, versions ...string) *Cipher {
+	keys := make(map[string][]byte, len(versions))
+	for _, v := range versions {
+		keys[v] = []byte("encrypt key of version " + v)
+	}
+	c, err := NewCipher(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSeal(t *testing.T) {
+	c := newTestCipher(t, "0", "2", "10")
+	if c.Version() != "10" {
+		t.Errorf("current version should be 10, got %s", c.Version())
+	}
+	if _, err := NewCipher(nil); err != ErrNoKey {
+		t.Errorf("expect ErrNoKey, got %v", err)
+	}
+	data := make([]byte, 1000)
+	rand.Read(data)
+	sealed, err := c.Seal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || SealedVersion(sealed) != "10" || bytes.Contains(sealed, data[:32]) {
+		t.Errorf("seal failed")
+	}
+	opened, err := c.Open(sealed)
+	if err != nil || !bytes.Equal(opened, data) {
+		t.Errorf("open failed: %v", err)
+	}
+	plain, err := c.Open(data[:10])
+	if err != nil || !bytes.Equal(plain, data[:10]) {
+		t.Errorf("not sealed data should be returned as it is")
+	}
+	if _, err = newTestCipher(t, "0").Open(sealed); err == nil {
+		t.Errorf("open with unknown key version should fail")
+	}
+	if _, err = c.Open([]byte(header_magic)); err != ErrInvalidHeader {
+		t.Errorf("expect ErrInvalidHeader, got %v", err)
+	}
+}
+
+func TestXorAt(t *testing.T) {
+	c := newTestCipher(t, "0")
+	iv := bytes.Repeat([]byte{0xff}, iv_len)
+	data := make([]byte, 100)
+	rand.Read(data)
+	whole := append([]byte{}, data...)
+	xorAt(c.blocks["0"], iv, 0, whole)
+	for _, off := range []int64{1, 15, 16, 17, 33, 99} {
+		part := append([]byte{}, data[off:]...)
+		xorAt(c.blocks["0"], iv, off, part)
+		if !bytes.Equal(part, whole[off:]) {
+			t.Errorf("xor at offset %d not same", off)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	c := newTestCipher(t, "0")
+	data := make([]byte, 100*1024+7)
+	rand.Read(data)
+	src, err := ioutil.TempFile("", "atrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(src.Name())
+	src.Write(data)
+	src.Close()
+	dst := src.Name() + ".sealed"
+	defer os.Remove(dst)
+	if err = c.SealFile(src.Name(), dst); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{src.Name(), dst} {
+		f, err := c.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Sealed() != (path == dst) || f.Size() != int64(len(data)) {
+			t.Errorf("wrong file info, sealed: %t size: %d", f.Sealed(), f.Size())
+		}
+		all, err := ioutil.ReadAll(f)
+		if err != nil || !bytes.Equal(all, data) {
+			t.Errorf("read all not same: %v", err)
+		}
+		if _, err = f.Seek(50000, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 100)
+		if n, err := f.Read(buf); err != nil || n != 100 || !bytes.Equal(buf, data[50000:50100]) {
+			t.Errorf("read after seek not same: %v", err)
+		}
+		f.Seek(-3, io.SeekEnd)
+		if n, err := f.Read(buf); err != nil || n != 3 || !bytes.Equal(buf[:3], data[len(data)-3:]) {
+			t.Errorf("read tail not same, n: %d error: %v", n, err)
+		}
+		if _, err := f.Read(buf); err != io.EOF {
+			t.Errorf("expect EOF, got %v", err)
+		}
+		f.Close()
+	}
+}
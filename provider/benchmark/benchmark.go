@@ -0,0 +1,164 @@
+// Package benchmark measure disk io of storage paths and network throughput by Store/Retrieve RPC.
+package benchmark
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand"
+	"os"
+	"time"
+
+	"github.com/samoslab/nebula/provider/config"
+	pb "github.com/samoslab/nebula/provider/pb"
+	provider_client "github.com/samoslab/nebula/provider/provider_client"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	"google.golang.org/grpc"
+)
+
+const seq_block_size = 1024 * 1024
+const rand_block_size = 4 * 1024
+const benchmark_ticket = "benchmark"
+const small_file_limit = 512 * 1024
+
+// DefaultDiskSize is big enough to pass disk cache of most controllers, but file system cache still affects read speed
+const DefaultDiskSize = 256 * 1024 * 1024
+
+const DefaultRandOps = 2000
+
+// DefaultNetworkSize is the size of block sent and received
+const DefaultNetworkSize = 16 * 1024 * 1024
+
+func perSecond(n uint64, elapsed time.Duration) uint64 {
+	if elapsed <= 0 {
+		elapsed = time.Nanosecond
+	}
+	return uint64(float64(n) / elapsed.Seconds())
+}
+
+// Disk measure sequential and random io of path with a temp file of size bytes, the file is removed after benchmark
+func Disk(path string, size int64, randOps int) (*config.DiskBenchmark, error) {
+	if size < seq_block_size {
+		return nil, errors.New("size is too small")
+	}
+	size = size / seq_block_size * seq_block_size
+	filePath := fmt.Sprintf("%s%cnebula-benchmark-%d.tmp", path, os.PathSeparator, time.Now().UnixNano())
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(filePath)
+	defer file.Close()
+	res := &config.DiskBenchmark{Path: path}
+	buf := make([]byte, seq_block_size)
+	if _, err = rand.Read(buf); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	for written := int64(0); written < size; written += seq_block_size {
+		if _, err = file.Write(buf); err != nil {
+			return nil, err
+		}
+	}
+	if err = file.Sync(); err != nil {
+		return nil, err
+	}
+	res.SeqWrite = perSecond(uint64(size), time.Since(start))
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	start = time.Now()
+	for {
+		_, err = io.ReadFull(file, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	res.SeqRead = perSecond(uint64(size), time.Since(start))
+
+	blocks := size / rand_block_size
+	rbuf := buf[:rand_block_size]
+	start = time.Now()
+	for i := 0; i < randOps; i++ {
+		if _, err = file.ReadAt(rbuf, mrand.Int63n(blocks)*rand_block_size); err != nil {
+			return nil, err
+		}
+	}
+	res.RandReadIops = perSecond(uint64(randOps), time.Since(start))
+
+	start = time.Now()
+	for i := 0; i < randOps; i++ {
+		if _, err = file.WriteAt(rbuf, mrand.Int63n(blocks)*rand_block_size); err != nil {
+			return nil, err
+		}
+	}
+	if err = file.Sync(); err != nil {
+		return nil, err
+	}
+	res.RandWriteIops = perSecond(uint64(randOps), time.Since(start))
+	return res, nil
+}
+
+// Network measure throughput by storing a random block to peer and retrieving it back, result unit: bps.
+// peer must be a stand-in server started by Serve, because provider checks auth signed by tracker.
+func Network(peer string, size uint64) (up uint64, down uint64, err error) {
+	if size < small_file_limit {
+		return 0, 0, errors.New("size is too small")
+	}
+	conn, err := grpc.Dial(peer, grpc.WithInsecure())
+	if err != nil {
+		return 0, 0, fmt.Errorf("RPC Dial peer %s failed: %s", peer, err)
+	}
+	defer conn.Close()
+	psc := pb.NewProviderServiceClient(conn)
+	data := make([]byte, size)
+	if _, err = rand.Read(data); err != nil {
+		return 0, 0, err
+	}
+	key := util_hash.Sha1(data)
+	timestamp := uint64(time.Now().Unix())
+	start := time.Now()
+	if err = provider_client.StoreReader(psc, bytes.NewReader(data), nil, timestamp, benchmark_ticket, key, size, key, size, nil); err != nil {
+		return 0, 0, fmt.Errorf("store to peer %s failed: %s", peer, err)
+	}
+	up = perSecond(size*8, time.Since(start))
+	start = time.Now()
+	if _, err = provider_client.Retrieve(psc, os.DevNull, nil, timestamp, benchmark_ticket, key, size, key, size); err != nil {
+		return 0, 0, fmt.Errorf("retrieve from peer %s failed: %s", peer, err)
+	}
+	down = perSecond(size*8, time.Since(start))
+	return up, down, nil
+}
+
+// Run benchmark all paths and network, loopback stand-in is used if peer is empty
+func Run(paths []string, peer string, diskSize int64, networkSize uint64) (*config.BenchmarkResult, error) {
+	res := &config.BenchmarkResult{Time: time.Now().Unix(), Peer: peer, Disks: make([]config.DiskBenchmark, 0, len(paths))}
+	for _, path := range paths {
+		d, err := Disk(path, diskSize, DefaultRandOps)
+		if err != nil {
+			return nil, fmt.Errorf("benchmark disk %s failed: %s", path, err)
+		}
+		res.Disks = append(res.Disks, *d)
+	}
+	addr := peer
+	if len(addr) == 0 {
+		server, lis, err := Serve("127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		defer server.Stop()
+		addr = lis.String()
+	}
+	var err error
+	res.UpBandwidth, res.DownBandwidth, err = Network(addr, networkSize)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,36 @@
+package benchmark
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benchmark-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	res, err := Run([]string{dir}, "", 4*1024*1024, 2*1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Disks) != 1 || res.Disks[0].Path != dir {
+		t.Fatalf("wrong disk result: %+v", res.Disks)
+	}
+	d := res.Disks[0]
+	if d.SeqWrite == 0 || d.SeqRead == 0 || d.RandReadIops == 0 || d.RandWriteIops == 0 {
+		t.Errorf("disk result should not be zero: %+v", d)
+	}
+	if res.UpBandwidth == 0 || res.DownBandwidth == 0 || res.Peer != "" {
+		t.Errorf("wrong network result: %+v", res)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("temp file should be removed")
+	}
+	if _, err = Disk(dir, 1024, 1); err == nil {
+		t.Errorf("too small size should fail")
+	}
+}
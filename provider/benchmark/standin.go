@@ -0,0 +1,90 @@
+package benchmark
+
+import (
+	"crypto/rand"
+	"io"
+	"net"
+
+	pb "github.com/samoslab/nebula/provider/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const stream_data_size = 32 * 1024
+
+// Serve start stand-in provider which accepts Store without auth and discards data, Retrieve returns random data of block size
+func Serve(listen string) (*grpc.Server, net.Addr, error) {
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, nil, err
+	}
+	server := grpc.NewServer(grpc.MaxRecvMsgSize(520 * 1024))
+	pb.RegisterProviderServiceServer(server, &standInService{})
+	go server.Serve(lis)
+	return server, lis.Addr(), nil
+}
+
+type standInService struct {
+}
+
+func (self *standInService) Ping(ctx context.Context, req *pb.PingReq) (*pb.PingResp, error) {
+	return &pb.PingResp{Version: pb.ProtocolVersion}, nil
+}
+
+func (self *standInService) Store(stream pb.ProviderService_StoreServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(req.Data) == 0 {
+			break
+		}
+	}
+	return stream.SendAndClose(&pb.StoreResp{Success: true})
+}
+
+func (self *standInService) StoreSmall(ctx context.Context, req *pb.StoreReq) (*pb.StoreResp, error) {
+	return &pb.StoreResp{Success: true}, nil
+}
+
+func (self *standInService) Retrieve(req *pb.RetrieveReq, stream pb.ProviderService_RetrieveServer) error {
+	buf := make([]byte, stream_data_size)
+	rand.Read(buf)
+	for sent := uint64(0); sent < req.BlockSize; {
+		n := uint64(len(buf))
+		if req.BlockSize-sent < n {
+			n = req.BlockSize - sent
+		}
+		if err := stream.Send(&pb.RetrieveResp{Data: buf[:n]}); err != nil {
+			return err
+		}
+		sent += n
+	}
+	return nil
+}
+
+func (self *standInService) RetrieveSmall(ctx context.Context, req *pb.RetrieveReq) (*pb.RetrieveResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "stand-in provider only supports Store and Retrieve")
+}
+
+func (self *standInService) Remove(ctx context.Context, req *pb.RemoveReq) (*pb.RemoveResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "stand-in provider only supports Store and Retrieve")
+}
+
+func (self *standInService) GetFragment(ctx context.Context, req *pb.GetFragmentReq) (*pb.GetFragmentResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "stand-in provider only supports Store and Retrieve")
+}
+
+func (self *standInService) CheckAvailable(ctx context.Context, req *pb.CheckAvailableReq) (*pb.CheckAvailableResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "stand-in provider only supports Store and Retrieve")
+}
+
+func (self *standInService) GetChunkProof(ctx context.Context, req *pb.ChunkProofReq) (*pb.ChunkProofResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "stand-in provider only supports Store and Retrieve")
+}
@@ -0,0 +1,31 @@
+//go:build chaos
+// +build chaos
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/samoslab/nebula/provider/chaos"
+	pb "github.com/samoslab/nebula/provider/pb"
+)
+
+// chaosFlag fault injector is only built into resilience testing binary by: go build -tags chaos
+func chaosFlag(command *flag.FlagSet) *string {
+	return command.String("chaosConfig", "", "inject faults into serving clients by rules of this json file, for resilience testing only, never use it in production")
+}
+
+func withChaos(service pb.ProviderServiceServer, chaosConfig string) pb.ProviderServiceServer {
+	if len(chaosConfig) == 0 {
+		return service
+	}
+	cc, err := chaos.LoadConfig(chaosConfig)
+	if err != nil {
+		fmt.Println("load chaos config failed: " + err.Error())
+		os.Exit(6)
+	}
+	fmt.Println("Warning: faults are injected by chaos config " + chaosConfig)
+	return chaos.NewService(service, cc)
+}
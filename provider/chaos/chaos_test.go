@@ -0,0 +1,144 @@
+package chaos
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/samoslab/nebula/provider/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeProviderService struct {
+	pb.ProviderServiceServer
+	data []byte
+}
+
+func (self *fakeProviderService) Ping(ctx context.Context, req *pb.PingReq) (*pb.PingResp, error) {
+	return &pb.PingResp{}, nil
+}
+
+func (self *fakeProviderService) RetrieveSmall(ctx context.Context, req *pb.RetrieveReq) (*pb.RetrieveResp, error) {
+	// data is shared like cached block, faults must not change it
+	return &pb.RetrieveResp{Data: self.data}, nil
+}
+
+func (self *fakeProviderService) Retrieve(req *pb.RetrieveReq, stream pb.ProviderService_RetrieveServer) error {
+	for i := 0; i < len(self.data); i += 4 {
+		stream.Send(&pb.RetrieveResp{Data: self.data[i : i+4]})
+	}
+	return nil
+}
+
+type fakeRetrieveStream struct {
+	grpc.ServerStream
+	sent [][]byte
+}
+
+func (self *fakeRetrieveStream) Context() context.Context {
+	return context.Background()
+}
+
+func (self *fakeRetrieveStream) Send(resp *pb.RetrieveResp) error {
+	self.sent = append(self.sent, resp.Data)
+	return nil
+}
+
+func newTestService(rules map[string]*Rule) *Service {
+	return NewService(&fakeProviderService{data: []byte("0123456789abcdef")}, &Config{Seed: 1, Rules: rules})
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaos")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "chaos.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"seed": 7, "rules": {"Retrieve": {"latency": "150ms", "bitFlip": 0.1}, "*": {"unavailable": 0.5}}}`), 0600))
+	c, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), c.Seed)
+	assert.Equal(t, Duration(150*time.Millisecond), c.rule("Retrieve").Latency)
+	assert.Equal(t, 0.1, c.rule("Retrieve").BitFlip)
+	assert.Equal(t, 0.5, c.rule("Ping").Unavailable)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"rules": {"Ping": {"unavailable": 2}}}`), 0600))
+	_, err = LoadConfig(path)
+	assert.Error(t, err)
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"rules": {"Ping": {"latency": "soon"}}}`), 0600))
+	_, err = LoadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestInjectError(t *testing.T) {
+	s := newTestService(map[string]*Rule{"Ping": {Unavailable: 1}, "RetrieveSmall": {Exhausted: 1}})
+	_, err := s.Ping(context.Background(), &pb.PingReq{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = s.RetrieveSmall(context.Background(), &pb.RetrieveReq{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	s = newTestService(map[string]*Rule{"Retrieve": {Unavailable: 1}})
+	_, err = s.Ping(context.Background(), &pb.PingReq{})
+	assert.NoError(t, err)
+}
+
+func TestSameSeedSameFaults(t *testing.T) {
+	run := func() (res []bool) {
+		s := newTestService(map[string]*Rule{"*": {Unavailable: 0.5}})
+		for i := 0; i < 32; i++ {
+			_, err := s.Ping(context.Background(), &pb.PingReq{})
+			res = append(res, err == nil)
+		}
+		return
+	}
+	first := run()
+	assert.Equal(t, first, run())
+	assert.Contains(t, first, true)
+	assert.Contains(t, first, false)
+}
+
+func bitDiff(a []byte, b []byte) int {
+	diff := 0
+	for i := range a {
+		for x := a[i] ^ b[i]; x != 0; x &= x - 1 {
+			diff++
+		}
+	}
+	return diff
+}
+
+func TestBitFlip(t *testing.T) {
+	orig := []byte("0123456789abcdef")
+	s := newTestService(map[string]*Rule{"RetrieveSmall": {BitFlip: 1}, "Retrieve": {BitFlip: 1}})
+	resp, err := s.RetrieveSmall(context.Background(), &pb.RetrieveReq{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, bitDiff(orig, resp.Data))
+	assert.Equal(t, orig, s.inner.(*fakeProviderService).data, "data of provider is not changed")
+
+	stream := &fakeRetrieveStream{}
+	require.NoError(t, s.Retrieve(&pb.RetrieveReq{}, stream))
+	assert.Equal(t, 4, bitDiff(orig, bytes.Join(stream.sent, nil)), "a bit of every message is flipped")
+	assert.Equal(t, orig, s.inner.(*fakeProviderService).data, "data of provider is not changed")
+}
+
+func TestRetrieveDisconnect(t *testing.T) {
+	s := newTestService(map[string]*Rule{"Retrieve": {Disconnect: 1}})
+	stream := &fakeRetrieveStream{}
+	err := s.Retrieve(&pb.RetrieveReq{}, stream)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, len(stream.sent))
+
+	s = newTestService(map[string]*Rule{"Retrieve": {SlowStart: Duration(50 * time.Millisecond), Bandwidth: 160}})
+	stream = &fakeRetrieveStream{}
+	start := time.Now()
+	require.NoError(t, s.Retrieve(&pb.RetrieveReq{}, stream))
+	assert.True(t, time.Since(start) >= 150*time.Millisecond)
+	assert.True(t, bytes.Equal([]byte("0123456789abcdef"), bytes.Join(stream.sent, nil)))
+}
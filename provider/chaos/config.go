@@ -0,0 +1,98 @@
+// Package chaos wraps a provider service to inject faults by per-method rules, it is for resilience testing of clients only,
+// provider daemon has it only if built with tag chaos.
+package chaos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+const any_method = "*"
+
+var ProbabilityOutOfRangeErr = errors.New("probability must be between 0 and 1")
+
+// Duration is time.Duration in json as string, eg: "150ms", "2s"
+type Duration time.Duration
+
+func (self Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(self).String())
+}
+
+func (self *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*self = Duration(d)
+	return nil
+}
+
+// Rule is faults injected into calls of a method, probabilities are between 0 and 1
+type Rule struct {
+	Latency     Duration `json:"latency,omitempty"`     // delay before each call is handled
+	Jitter      Duration `json:"jitter,omitempty"`      // random extra delay up to jitter
+	SlowStart   Duration `json:"slowStart,omitempty"`   // delay before first message of stream is received or sent
+	Bandwidth   uint64   `json:"bandwidth,omitempty"`   // bytes per second of transported data, 0 is unlimited
+	Unavailable float64  `json:"unavailable,omitempty"` // probability to fail call with codes.Unavailable
+	Exhausted   float64  `json:"exhausted,omitempty"`   // probability to fail call with codes.ResourceExhausted
+	Disconnect  float64  `json:"disconnect,omitempty"`  // probability to break stream after each message
+	BitFlip     float64  `json:"bitFlip,omitempty"`     // probability to flip a random bit of each retrieved data
+}
+
+func (self *Rule) check() error {
+	for _, p := range []float64{self.Unavailable, self.Exhausted, self.Disconnect, self.BitFlip} {
+		if p < 0 || p > 1 {
+			return ProbabilityOutOfRangeErr
+		}
+	}
+	return nil
+}
+
+// Config of chaos service, faults are same in each run of same seed if calls are serial
+type Config struct {
+	Seed  int64            `json:"seed"`  // random seed, current time if 0
+	Rules map[string]*Rule `json:"rules"` // key is method name, eg: Retrieve, rule of "*" is used by methods without rule
+}
+
+// LoadConfig read config from json file, eg:
+//
+//	{"seed": 1, "rules": {"Retrieve": {"latency": "100ms", "bitFlip": 0.1}, "*": {"unavailable": 0.05}}}
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	if err = json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, c.Check()
+}
+
+// Check return error if a rule is invalid
+func (self *Config) Check() error {
+	for method, r := range self.Rules {
+		if r == nil {
+			continue
+		}
+		if err := r.check(); err != nil {
+			return fmt.Errorf("rule of %s: %s", method, err)
+		}
+	}
+	return nil
+}
+
+// rule return nil if method has no fault
+func (self *Config) rule(method string) *Rule {
+	if r, ok := self.Rules[method]; ok {
+		return r
+	}
+	return self.Rules[any_method]
+}
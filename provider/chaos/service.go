@@ -0,0 +1,288 @@
+package chaos
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	pb "github.com/samoslab/nebula/provider/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Service is a provider service which injects faults into calls of wrapped service
+type Service struct {
+	inner  pb.ProviderServiceServer
+	config *Config
+	mutex  sync.Mutex
+	random *rand.Rand
+}
+
+// NewService wrap provider service, config must be checked
+func NewService(inner pb.ProviderServiceServer, config *Config) *Service {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Service{inner: inner, config: config, random: rand.New(rand.NewSource(seed))}
+}
+
+func (self *Service) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.random.Float64() < p
+}
+
+func (self *Service) intn(n int) int {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.random.Intn(n)
+}
+
+func (self *Service) random63n(n int64) int64 {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.random.Int63n(n)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// before delay call and return injected error
+func (self *Service) before(ctx context.Context, rule *Rule) error {
+	if rule == nil {
+		return nil
+	}
+	d := time.Duration(rule.Latency)
+	if rule.Jitter > 0 {
+		d += time.Duration(self.random63n(int64(rule.Jitter)))
+	}
+	if err := sleep(ctx, d); err != nil {
+		return err
+	}
+	if self.chance(rule.Unavailable) {
+		return status.Error(codes.Unavailable, "chaos: provider is unavailable")
+	}
+	if self.chance(rule.Exhausted) {
+		return status.Error(codes.ResourceExhausted, "chaos: provider resource is exhausted")
+	}
+	return nil
+}
+
+// throttle delay transport of size bytes to bandwidth
+func (self *Service) throttle(ctx context.Context, rule *Rule, size int) error {
+	if rule == nil || rule.Bandwidth == 0 || size == 0 {
+		return nil
+	}
+	return sleep(ctx, time.Duration(uint64(size)*uint64(time.Second)/rule.Bandwidth))
+}
+
+// disconnect return error to break stream
+func (self *Service) disconnect(rule *Rule) error {
+	if rule != nil && self.chance(rule.Disconnect) {
+		return status.Error(codes.Unavailable, "chaos: stream is disconnected")
+	}
+	return nil
+}
+
+// flip return copy of data with a random bit changed, data itself is not changed as provider may share it, eg: cache
+func (self *Service) flip(rule *Rule, data []byte) []byte {
+	if rule == nil || len(data) == 0 || !self.chance(rule.BitFlip) {
+		return data
+	}
+	res := append([]byte(nil), data...)
+	i := self.intn(len(res) * 8)
+	res[i/8] ^= 1 << uint(i%8)
+	return res
+}
+
+func (self *Service) Ping(ctx context.Context, req *pb.PingReq) (*pb.PingResp, error) {
+	if err := self.before(ctx, self.config.rule("Ping")); err != nil {
+		return nil, err
+	}
+	return self.inner.Ping(ctx, req)
+}
+
+func (self *Service) Store(stream pb.ProviderService_StoreServer) error {
+	rule := self.config.rule("Store")
+	if err := self.before(stream.Context(), rule); err != nil {
+		return err
+	}
+	if rule == nil {
+		return self.inner.Store(stream)
+	}
+	return self.inner.Store(&storeStream{ProviderService_StoreServer: stream, service: self, rule: rule})
+}
+
+func (self *Service) StoreSmall(ctx context.Context, req *pb.StoreReq) (*pb.StoreResp, error) {
+	rule := self.config.rule("StoreSmall")
+	if err := self.before(ctx, rule); err != nil {
+		return nil, err
+	}
+	if err := self.throttle(ctx, rule, len(req.Data)); err != nil {
+		return nil, err
+	}
+	return self.inner.StoreSmall(ctx, req)
+}
+
+func (self *Service) Retrieve(req *pb.RetrieveReq, stream pb.ProviderService_RetrieveServer) error {
+	rule := self.config.rule("Retrieve")
+	if err := self.before(stream.Context(), rule); err != nil {
+		return err
+	}
+	if rule == nil {
+		return self.inner.Retrieve(req, stream)
+	}
+	rs := &retrieveStream{ProviderService_RetrieveServer: stream, service: self, rule: rule}
+	err := self.inner.Retrieve(req, rs)
+	// provider does not check error of sending, stream is broken regardless
+	if rs.broken != nil {
+		return rs.broken
+	}
+	return err
+}
+
+func (self *Service) RetrieveSmall(ctx context.Context, req *pb.RetrieveReq) (*pb.RetrieveResp, error) {
+	rule := self.config.rule("RetrieveSmall")
+	if err := self.before(ctx, rule); err != nil {
+		return nil, err
+	}
+	resp, err := self.inner.RetrieveSmall(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	if err = self.throttle(ctx, rule, len(resp.Data)); err != nil {
+		return nil, err
+	}
+	flipped := *resp
+	flipped.Data = self.flip(rule, resp.Data)
+	return &flipped, nil
+}
+
+func (self *Service) Remove(ctx context.Context, req *pb.RemoveReq) (*pb.RemoveResp, error) {
+	if err := self.before(ctx, self.config.rule("Remove")); err != nil {
+		return nil, err
+	}
+	return self.inner.Remove(ctx, req)
+}
+
+func (self *Service) GetFragment(ctx context.Context, req *pb.GetFragmentReq) (*pb.GetFragmentResp, error) {
+	rule := self.config.rule("GetFragment")
+	if err := self.before(ctx, rule); err != nil {
+		return nil, err
+	}
+	resp, err := self.inner.GetFragment(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	flipped := *resp
+	flipped.Data = make([][]byte, 0, len(resp.Data))
+	for _, d := range resp.Data {
+		if err = self.throttle(ctx, rule, len(d)); err != nil {
+			return nil, err
+		}
+		flipped.Data = append(flipped.Data, self.flip(rule, d))
+	}
+	return &flipped, nil
+}
+
+func (self *Service) CheckAvailable(ctx context.Context, req *pb.CheckAvailableReq) (*pb.CheckAvailableResp, error) {
+	if err := self.before(ctx, self.config.rule("CheckAvailable")); err != nil {
+		return nil, err
+	}
+	return self.inner.CheckAvailable(ctx, req)
+}
+
+func (self *Service) GetChunkProof(ctx context.Context, req *pb.ChunkProofReq) (*pb.ChunkProofResp, error) {
+	rule := self.config.rule("GetChunkProof")
+	if err := self.before(ctx, rule); err != nil {
+		return nil, err
+	}
+	resp, err := self.inner.GetChunkProof(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	if err = self.throttle(ctx, rule, len(resp.Data)); err != nil {
+		return nil, err
+	}
+	flipped := *resp
+	flipped.Data = self.flip(rule, resp.Data)
+	return &flipped, nil
+}
+
+// storeStream inject faults into received messages
+type storeStream struct {
+	pb.ProviderService_StoreServer
+	service  *Service
+	rule     *Rule
+	received bool
+}
+
+func (self *storeStream) Recv() (*pb.StoreReq, error) {
+	ctx := self.Context()
+	if !self.received {
+		self.received = true
+		if err := sleep(ctx, time.Duration(self.rule.SlowStart)); err != nil {
+			return nil, err
+		}
+	}
+	req, err := self.ProviderService_StoreServer.Recv()
+	if err != nil {
+		return req, err
+	}
+	if err = self.service.throttle(ctx, self.rule, len(req.Data)); err != nil {
+		return nil, err
+	}
+	if err = self.service.disconnect(self.rule); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// retrieveStream inject faults into sent messages, nothing is sent after it is broken
+type retrieveStream struct {
+	pb.ProviderService_RetrieveServer
+	service *Service
+	rule    *Rule
+	sent    bool
+	broken  error
+}
+
+func (self *retrieveStream) Send(resp *pb.RetrieveResp) error {
+	if self.broken == nil {
+		self.broken = self.send(resp)
+	}
+	return self.broken
+}
+
+func (self *retrieveStream) send(resp *pb.RetrieveResp) error {
+	ctx := self.Context()
+	if !self.sent {
+		self.sent = true
+		if err := sleep(ctx, time.Duration(self.rule.SlowStart)); err != nil {
+			return err
+		}
+	}
+	if err := self.service.throttle(ctx, self.rule, len(resp.Data)); err != nil {
+		return err
+	}
+	flipped := *resp
+	flipped.Data = self.service.flip(self.rule, resp.Data)
+	if err := self.ProviderService_RetrieveServer.Send(&flipped); err != nil {
+		return err
+	}
+	return self.service.disconnect(self.rule)
+}
@@ -7,13 +7,16 @@ import (
 
 	proto "github.com/golang/protobuf/proto"
 	"github.com/robfig/cron"
+	"github.com/samoslab/nebula/provider/activity"
 	"github.com/samoslab/nebula/provider/node"
 	pb "github.com/samoslab/nebula/tracker/collector/provider/pb"
+	"github.com/samoslab/nebula/util/failover"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 )
 
 func Collect(al *pb.ActionLog) {
+	activity.RecordAction(al)
 	l := len(queue)
 	if l < cap(queue)*9/10 {
 		queue <- al
@@ -31,18 +34,16 @@ const send_immediate_min = 20
 var queue = make(chan *pb.ActionLog, 2000)
 var cronRunner *cron.Cron
 var sendLock = make(chan bool, 1)
-var conn *grpc.ClientConn
+var servers *failover.Servers
 
 func sendLockOff() {
 	sendLock <- false
 }
-func Start(collectorServer string) {
+
+// Start sending action logs to collectors, it is switched to next collector if sending failed
+func Start(collectors *failover.Servers) {
 	sendLockOff()
-	var err error
-	conn, err = grpc.Dial(collectorServer, grpc.WithInsecure())
-	if err != nil {
-		log.Fatalf("dial collector failed: %s", err)
-	}
+	servers = collectors
 
 	cronRunner = cron.New()
 	cronRunner.AddFunc("4,19,34,49 * * * * *", send)
@@ -51,7 +52,6 @@ func Start(collectorServer string) {
 
 func Stop() {
 	cronRunner.Stop()
-	conn.Close()
 }
 
 func send() {
@@ -66,8 +66,12 @@ func send() {
 }
 
 func doSend() error {
+	return servers.Do(context.Background(), true, sendTo)
+}
+
+func sendTo(ctx context.Context, conn *grpc.ClientConn) error {
 	pcsc := pb.NewProviderCollectorServiceClient(conn)
-	stream, err := pcsc.Collect(context.Background())
+	stream, err := pcsc.Collect(ctx)
 	var req *pb.CollectReq
 	if err != nil {
 		fmt.Printf("RPC Collect failed: %s", err.Error())
@@ -81,11 +85,13 @@ func doSend() error {
 		if size > batch_max {
 			size = batch_max
 		}
-		req = buildReq(size)
+		var bs []*pb.ActionLog
+		req, bs = buildReq(size)
 		if req == nil {
 			continue
 		}
 		if err = stream.Send(req); err != nil {
+			requeue(bs)
 			return err
 		}
 	}
@@ -93,7 +99,18 @@ func doSend() error {
 	return err
 }
 
-func buildReq(size int) *pb.CollectReq {
+// requeue action logs of batch not sent, so they are sent to next collector
+func requeue(bs []*pb.ActionLog) {
+	for _, al := range bs {
+		select {
+		case queue <- al:
+		default:
+			log.Warnf("queue is full, abandon action log, ticket: %s", al.Ticket)
+		}
+	}
+}
+
+func buildReq(size int) (*pb.CollectReq, []*pb.ActionLog) {
 	bs := make([]*pb.ActionLog, 0, size)
 	for i := 0; i < size; i++ {
 		bs = append(bs, <-queue)
@@ -106,7 +123,7 @@ func buildReq(size int) *pb.CollectReq {
 	data, err := proto.Marshal(batch)
 	if err != nil {
 		log.Errorf("buildReq marshal proto error: %s", err)
-		return nil
+		return nil, nil
 	}
-	return &pb.CollectReq{Data: data}
+	return &pb.CollectReq{Data: data}, bs
 }
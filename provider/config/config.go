@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/koding/multiconfig"
 	"github.com/robfig/cron"
@@ -28,6 +30,26 @@ type ExtraStorageInfo struct {
 	Index  byte // 1-based
 }
 
+// DiskBenchmark speed of a storage path, sequential speed unit: byte per second
+type DiskBenchmark struct {
+	Path          string
+	SeqWrite      uint64
+	SeqRead       uint64
+	RandReadIops  uint64
+	RandWriteIops uint64
+}
+
+// BenchmarkResult is measured by benchmark command, bandwidth unit: bps
+type BenchmarkResult struct {
+	Time          int64
+	Peer          string `json:",omitempty"` // empty means local loopback stand-in
+	UpBandwidth   uint64
+	DownBandwidth uint64
+	Disks         []DiskBenchmark
+}
+
+const benchmark_history_max = 10
+
 type ProviderConfig struct {
 	NodeId            string
 	WalletAddress     string
@@ -42,11 +64,37 @@ type ProviderConfig struct {
 	UpBandwidth       uint64
 	DownBandwidth     uint64
 	EncryptKey        map[string]string  // key: version, eg: 0, 1, 2
+	EncryptAtRest     bool               `json:",omitempty"` // seal new blocks with the greatest version of EncryptKey
+	RemoveGraceHours  uint32             `json:",omitempty"` // keep removed blocks in trash before purge, 0 is default 72 hours
+	ActivityKeepDays  uint32             `json:",omitempty"` // keep activity journal, 0 is default 90 days
+	Benchmark         []BenchmarkResult  `json:",omitempty"` // the last one is the latest
 	ExtraStorage      []ExtraStorageInfo `json:",omitempty"` //key:storage index, 1-based eg: 1, 2, 3
 }
 
 var providerConfig *ProviderConfig
 
+const default_remove_grace_hours = 72
+
+// RemoveGracePeriod how long removed blocks stay in trash
+func RemoveGracePeriod() time.Duration {
+	hours := uint32(default_remove_grace_hours)
+	if providerConfig != nil && providerConfig.RemoveGraceHours > 0 {
+		hours = providerConfig.RemoveGraceHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+const default_activity_keep_days = 90
+
+// ActivityKeepPeriod how long activity journal files are kept
+func ActivityKeepPeriod() time.Duration {
+	days := uint32(default_activity_keep_days)
+	if providerConfig != nil && providerConfig.ActivityKeepDays > 0 {
+		days = providerConfig.ActivityKeepDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 const config_filename = "config.json"
 
 var configFilePath string
@@ -58,6 +106,26 @@ func ConfigExists(configDir string) bool {
 	return util_file.Exists(configDir + string(os.PathSeparator) + config_filename)
 }
 
+// UptimeJournalPath is beside config file, so it is kept when storages change
+func UptimeJournalPath() string {
+	return filepath.Dir(configFilePath) + string(os.PathSeparator) + "uptime.journal"
+}
+
+// UsageSamplePath is beside config file, stored bytes are sampled into it for ledger
+func UsageSamplePath() string {
+	return filepath.Dir(configFilePath) + string(os.PathSeparator) + "usage.samples"
+}
+
+// ActivityJournalDir is beside config file
+func ActivityJournalDir() string {
+	return filepath.Dir(configFilePath) + string(os.PathSeparator) + "activity"
+}
+
+// ServersCachePath is beside config file, discovered tracker side servers are cached in it
+func ServersCachePath() string {
+	return filepath.Dir(configFilePath) + string(os.PathSeparator) + "servers.json"
+}
+
 func LoadConfig(configDir string) error {
 	configFilePath = configDir + string(os.PathSeparator) + config_filename
 	if !util_file.Exists(configFilePath) {
@@ -140,6 +208,7 @@ func StartAutoCheck() {
 	cronRunner.AddFunc("0,15,30,45 * * * * *", checkAndReload)
 	cronRunner.AddFunc("7 */3 * * * *", checkStorageAvailableSpace)
 	cronRunner.AddFunc("37 1,31 * * * *", checkStorageAvailableSpaceOfConf)
+	cronRunner.AddFunc("23 * * * * *", checkStorageHealth)
 	cronRunner.Start()
 }
 
@@ -193,6 +262,22 @@ func readConfig() (*ProviderConfig, error) {
 	return pc, nil
 }
 
+// AddBenchmark keep result in config, only the latest results are kept
+func (self *ProviderConfig) AddBenchmark(result BenchmarkResult) {
+	self.Benchmark = append(self.Benchmark, result)
+	if len(self.Benchmark) > benchmark_history_max {
+		self.Benchmark = self.Benchmark[len(self.Benchmark)-benchmark_history_max:]
+	}
+}
+
+// LastBenchmark return nil if never benchmarked
+func (self *ProviderConfig) LastBenchmark() *BenchmarkResult {
+	if len(self.Benchmark) == 0 {
+		return nil
+	}
+	return &self.Benchmark[len(self.Benchmark)-1]
+}
+
 func GetProviderConfig() *ProviderConfig {
 	return providerConfig
 }
@@ -51,3 +51,26 @@ func getConfigFileSize() int64 {
 	}
 	return fileInfo.Size()
 }
+
+func TestBenchmarkConfig(t *testing.T) {
+	configFilePath = "/tmp/config-benchmark-test.json"
+	removeConfigFile()
+	defer removeConfigFile()
+	providerConfig = &ProviderConfig{NodeId: "test-node-id", MainStoragePath: "/main/storage/path"}
+	for i := 1; i <= benchmark_history_max+2; i++ {
+		providerConfig.AddBenchmark(BenchmarkResult{Time: int64(i), UpBandwidth: 8000000, DownBandwidth: 20000000,
+			Disks: []DiskBenchmark{DiskBenchmark{Path: "/main/storage/path", SeqWrite: 100000000, RandReadIops: 300}}})
+	}
+	if len(providerConfig.Benchmark) != benchmark_history_max || providerConfig.LastBenchmark().Time != benchmark_history_max+2 {
+		t.Errorf("only latest benchmark results should be kept")
+	}
+	SaveProviderConfig()
+	pc, err := readConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := pc.LastBenchmark()
+	if len(pc.Benchmark) != benchmark_history_max || last.Time != benchmark_history_max+2 || len(last.Disks) != 1 || last.Disks[0].RandReadIops != 300 {
+		t.Errorf("read benchmark from config failed: %+v", pc.Benchmark)
+	}
+}
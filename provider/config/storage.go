@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
@@ -22,6 +23,7 @@ import (
 
 const sys_folder = "nebula"
 const tmp_folder = "temp"
+const trash_folder = "trash"
 const sep = string(os.PathSeparator)
 const filename_suffix = ".blk"
 
@@ -34,6 +36,102 @@ type Storage struct {
 	Index       byte // 0 as Main Storage
 	Volume      uint64
 	SmallFileDb *leveldb.DB
+	failed      int32
+	ioErrors    uint64
+}
+
+const probe_filename = "probe"
+
+var StorageFailedErr = errors.New("storage failed")
+
+var storageFailedCallback func(index byte)
+
+// OnStorageFailed set callback which is called once in new goroutine for each failed storage
+func OnStorageFailed(callback func(index byte)) {
+	storageFailedCallback = callback
+}
+
+// Failed storage is not read or written until provider restart
+func (self *Storage) Failed() bool {
+	return atomic.LoadInt32(&self.failed) == 1
+}
+
+func (self *Storage) IOErrors() uint64 {
+	return atomic.LoadUint64(&self.ioErrors)
+}
+
+func (self *Storage) indexFilePath() string {
+	return self.Path + sep + sys_folder + sep + "storage-" + strconv.FormatInt(int64(self.Index), 10) + ".nebula"
+}
+
+// probe check storage is still mounted and writable
+func (self *Storage) probe() error {
+	if _, err := os.Stat(self.indexFilePath()); err != nil {
+		return err
+	}
+	p := self.TempPath() + sep + probe_filename
+	content := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err := ioutil.WriteFile(p, content, 0600); err != nil {
+		return err
+	}
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	if string(b) != string(content) {
+		return errors.New("probe file content not same")
+	}
+	return os.Remove(p)
+}
+
+// RecordIOError probe storage after io error, storage is marked failed if probe fails too
+func (self *Storage) RecordIOError(err error) {
+	atomic.AddUint64(&self.ioErrors, 1)
+	if self.Failed() {
+		return
+	}
+	if er := self.probe(); er != nil {
+		self.markFailed(fmt.Errorf("%s, probe error: %s", err, er))
+	}
+}
+
+func (self *Storage) markFailed(err error) {
+	if !atomic.CompareAndSwapInt32(&self.failed, 0, 1) {
+		return
+	}
+	log.Errorf("storage %d %s failed, stop reading and writing it, restart provider after fixing, error: %s", self.Index, self.Path, err)
+	sl := make([]*Storage, 0, len(storageSlice))
+	for _, s := range storageSlice {
+		if s != self {
+			sl = append(sl, s)
+		}
+	}
+	storageSlice = sl
+	if callback := storageFailedCallback; callback != nil {
+		go callback(self.Index)
+	}
+}
+
+// RecordIOErrorOfPath record io error to the storage which path belongs to
+func RecordIOErrorOfPath(path string, err error) {
+	for _, s := range storageMap {
+		if strings.HasPrefix(path, s.Path+sep) {
+			s.RecordIOError(err)
+			return
+		}
+	}
+}
+
+// checkStorageHealth probe all storages, so unmounted or read-only disk is found without waiting for io error
+func checkStorageHealth() {
+	for _, s := range storageMap {
+		if s.Failed() {
+			continue
+		}
+		if err := s.probe(); err != nil {
+			s.markFailed(err)
+		}
+	}
 }
 
 func (self *Storage) initStorage() error {
@@ -51,7 +149,7 @@ func (self *Storage) initStorage() error {
 		if err != nil {
 			return fmt.Errorf("mkdir sys folder: %s failed: %s", p, err)
 		}
-		newFile, err := os.Create(p + sep + "storage-" + strconv.FormatInt(int64(self.Index), 10) + ".nebula")
+		newFile, err := os.Create(self.indexFilePath())
 		if err != nil {
 			return fmt.Errorf("create storage index file failed: %s", err)
 		}
@@ -76,9 +174,20 @@ func (self *Storage) initStorage() error {
 			return err
 		}
 	}
+	trashPath := self.Path + sep + sys_folder + sep + trash_folder
+	if !util_file.Exists(trashPath) {
+		if err = os.MkdirAll(trashPath, 0700); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// TrashSubPath sub path of removed block file waiting to be purged, it is on the same disk so still counts against free space
+func (self *Storage) TrashSubPath(key []byte) string {
+	return slash + sys_folder + slash + trash_folder + slash + hex.EncodeToString(key) + filename_suffix
+}
+
 func (self *Storage) TempPath() string {
 	return self.Path + sep + sys_folder + sep + tmp_folder
 }
@@ -119,19 +228,29 @@ func (self *Storage) TempFilePath(key []byte) string {
 	return self.TempPath() + sep + hex.EncodeToString(key) + "-" + randStr(8) + filename_suffix
 }
 
-func (self *Storage) GetPathPair(key []byte) (fullPath string, subPath string, err error) {
+// SubPath sub folders are decided by the last 4 bytes of key, which are digest bytes for both legacy sha1 key and multihash key
+func SubPath(key []byte) (string, error) {
+	if len(key) < 4 {
+		return "", errors.New("key is too short")
+	}
 	val := util_bytes.ToUint32(key, len(key)-4)
 	sub1 := util_num.FixLength(val&(ModFactor-1), 4)
 	sub2 := util_num.FixLength((val>>ModFactorExp)&(ModFactor-1), 4)
-	filename := hex.EncodeToString(key)
-	subPath = slash + sub1 + slash + sub2 + slash + filename + filename_suffix
-	fullFolder := self.Path + sep + sub1 + sep + sub2
+	return slash + sub1 + slash + sub2 + slash + hex.EncodeToString(key) + filename_suffix, nil
+}
+
+// GetPathPair full path and sub path of key in storage, sub folders are created if not exist
+func (self *Storage) GetPathPair(key []byte) (fullPath string, subPath string, err error) {
+	if subPath, err = SubPath(key); err != nil {
+		return
+	}
+	fullPath = self.Path + strings.Replace(subPath, slash, sep, -1)
+	fullFolder := fullPath[:strings.LastIndex(fullPath, sep)]
 	if !util_file.Exists(fullFolder) {
 		if err = os.MkdirAll(fullFolder, 0700); err != nil {
 			return
 		}
 	}
-	fullPath = fullFolder + sep + filename + filename_suffix
 	return
 }
 
@@ -194,8 +313,32 @@ func GetStorage(index byte) *Storage {
 	return storageMap[idx]
 }
 
+// DbPath path of db in sys folder of storage, dbs are in main storage
+func (self *Storage) DbPath(name string) string {
+	return self.Path + sep + sys_folder + sep + name
+}
+
+const (
+	ProviderDbName = "provider-db"
+	MerkleDbName   = "merkle-db"
+	TrashDbName    = "trash-db"
+	TagDbName      = "tag-db"
+)
+
 func ProviderDbPath() string {
-	return GetStorage(0).Path + sep + sys_folder + sep + "provider-db"
+	return GetStorage(0).DbPath(ProviderDbName)
+}
+
+func MerkleDbPath() string {
+	return GetStorage(0).DbPath(MerkleDbName)
+}
+
+func TrashDbPath() string {
+	return GetStorage(0).DbPath(TrashDbName)
+}
+
+func TagDbPath() string {
+	return GetStorage(0).DbPath(TagDbName)
 }
 
 var storageSlice []*Storage
@@ -213,9 +356,13 @@ func checkStorageAvailableSpace() {
 	}
 	sl := make([]*Storage, 0, len(storageSlice))
 	for _, s := range storageSlice {
+		if s.Failed() {
+			continue
+		}
 		_, free, err := disk.Space(s.Path)
 		if err != nil {
 			log.Warnf("get storage %s free space error:%s", s.Path, err)
+			s.RecordIOError(err)
 			continue
 		}
 		if (s.Index == 0 && free <= min_available_volume_of_main) || (s.Index != 0 && free <= min_available_volume) {
@@ -252,7 +399,9 @@ func checkStorageAvailableSpaceOfConf() {
 		storageMap["0"] = s
 	}
 	s.cleanTemp()
-	if s.Volume > min_available_volume_of_main {
+	if s.Failed() {
+		log.Errorf("main storage failed")
+	} else if s.Volume > min_available_volume_of_main {
 		sl = append(sl, s)
 	} else {
 		log.Errorf("main storage available space less than 1GB")
@@ -272,6 +421,9 @@ func checkStorageAvailableSpaceOfConf() {
 				}
 				storageMap[idx] = s
 			}
+			if s.Failed() {
+				continue
+			}
 			if s.Volume > min_available_volume {
 				sl = append(sl, s)
 			} else {
@@ -283,6 +435,16 @@ func checkStorageAvailableSpaceOfConf() {
 	checkStorageOfConfFirst = false
 }
 
+// InitStorage open storages of config without auto check, used by offline command
+func InitStorage() {
+	checkStorageAvailableSpaceOfConf()
+}
+
+// CloseStorage close storages opened by InitStorage
+func CloseStorage() {
+	stopStorage()
+}
+
 func stopStorage() {
 	if storageMap != nil {
 		for _, v := range storageMap {
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStorageFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := NewStorage(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.SmallFileDb.Close()
+	failed := make(chan byte, 1)
+	OnStorageFailed(func(index byte) { failed <- index })
+	defer OnStorageFailed(nil)
+	storageMap = map[string]*Storage{"1": s}
+	storageSlice = []*Storage{s}
+	defer func() { storageMap, storageSlice = nil, nil }()
+
+	s.RecordIOError(errors.New("read error"))
+	if s.Failed() || s.IOErrors() != 1 {
+		t.Errorf("healthy storage should not be marked failed")
+	}
+	checkStorageHealth()
+	if s.Failed() {
+		t.Errorf("healthy storage should pass probe")
+	}
+	// simulate unmounted disk
+	os.RemoveAll(dir)
+	RecordIOErrorOfPath(dir+sep+"0001"+sep+"0002"+sep+"abcd.blk", errors.New("read error"))
+	if !s.Failed() {
+		t.Fatalf("storage should be marked failed")
+	}
+	if len(storageSlice) != 0 {
+		t.Errorf("failed storage should not be written")
+	}
+	select {
+	case idx := <-failed:
+		if idx != 1 {
+			t.Errorf("wrong failed storage index: %d", idx)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("failed callback not called")
+	}
+	s.RecordIOError(errors.New("read error"))
+	select {
+	case <-failed:
+		t.Errorf("failed callback should be called once")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSubPath(t *testing.T) {
+	// last 4 bytes 0x00004003: low 13 bits 3, next 13 bits 2
+	sub, err := SubPath([]byte{0xaa, 0xbb, 0, 0, 0x40, 0x03})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub != "/0003/0002/aabb00004003.blk" {
+		t.Errorf("wrong sub path: %s", sub)
+	}
+	if _, err = SubPath([]byte{1, 2, 3}); err == nil {
+		t.Errorf("short key should fail")
+	}
+	dir, err := ioutil.TempDir("", "storage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := &Storage{Path: dir}
+	full, sub, err := s.GetPathPair([]byte{0xaa, 0xbb, 0, 0, 0x40, 0x03})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if full != s.Path+filepath.FromSlash(sub) {
+		t.Errorf("wrong full path: %s", full)
+	}
+	if _, err = os.Stat(filepath.Dir(full)); err != nil {
+		t.Errorf("sub folders should be created: %s", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Package discovery keeps servers on tracker side that provider daemon talks to: trackers, task servers and collectors.
+// Lists are fetched from tracker by GetTrackerServer and GetCollectorServer, cached in a file so they survive restart
+// while tracker is down, and refreshed periodically. Configured servers are kept at the head of lists.
+//
+// Tracker does not tell where task service is, so task list is a guess: task service is expected on every tracker host
+// at port of configured task server. A guessed server without task service fails calls with Unavailable, and calls
+// fail over to the next one.
+package discovery
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	client "github.com/samoslab/nebula/provider/register_client"
+	pb "github.com/samoslab/nebula/tracker/register/provider/pb"
+	"github.com/samoslab/nebula/util/failover"
+	log "github.com/sirupsen/logrus"
+)
+
+const refresh_interval = 10 * time.Minute
+
+// methods safe to send again to next server after they may have reached one, FinishProve and FinishTask are not,
+// tracker deletes the task when it is finished and verifies a proof once
+var (
+	trackerIdempotent = []string{
+		"/register_provider_pb.ProviderRegisterService/GetTrackerServer",
+		"/register_provider_pb.ProviderRegisterService/GetCollectorServer",
+	}
+	taskIdempotent = []string{
+		"/task.pb.ProviderTaskService/TaskList",
+		"/task.pb.ProviderTaskService/GetOppositeInfo",
+		"/task.pb.ProviderTaskService/GetProveInfo",
+		"/task.pb.ProviderTaskService/VerifyBlocks",
+	}
+)
+
+// cache of lists, tasks are not fetched from tracker but guessed from trackers, see package doc
+type cache struct {
+	Trackers   []string `json:"trackers"`
+	Tasks      []string `json:"tasks"`
+	Collectors []string `json:"collectors"`
+}
+
+type Discovery struct {
+	Trackers   *failover.Servers
+	Tasks      *failover.Servers
+	Collectors *failover.Servers
+	tracker    string
+	task       string
+	collector  string
+	cachePath  string
+	quit       chan struct{}
+}
+
+// New create lists with configured servers and cached ones
+func New(trackerServer string, taskServer string, collectorServer string, cachePath string) *Discovery {
+	self := &Discovery{tracker: trackerServer, task: taskServer, collector: collectorServer, cachePath: cachePath}
+	var c cache
+	if b, err := ioutil.ReadFile(cachePath); err == nil {
+		if err = json.Unmarshal(b, &c); err != nil {
+			log.Warnf("parse servers cache %s failed: %s", cachePath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		log.Warnf("read servers cache %s failed: %s", cachePath, err)
+	}
+	self.Trackers = newServers("tracker", trackerIdempotent, trackerServer, c.Trackers)
+	self.Tasks = newServers("task", taskIdempotent, taskServer, c.Tasks)
+	// collector ignores action logs sent again, so every call is safe to send again
+	self.Collectors = newServers("collector", nil, collectorServer, c.Collectors)
+	return self
+}
+
+// NewTasks list of task servers at addresses
+func NewTasks(addrs []string) *failover.Servers {
+	servers := newServers("task", taskIdempotent, "", nil)
+	for _, addr := range addrs {
+		servers.Add(addr, true)
+	}
+	return servers
+}
+
+// newServers list with configured server that is always kept and cached ones
+func newServers(kind string, idempotent []string, configured string, cached []string) *failover.Servers {
+	servers := failover.New(kind, log.StandardLogger(), idempotent...)
+	if err := servers.Add(configured, true); err != nil {
+		log.Warnf("add %s server %s failed: %s", kind, configured, err)
+	}
+	if err := servers.Update(cached); err != nil {
+		log.Warnf("add cached %s servers failed: %s", kind, err)
+	}
+	return servers
+}
+
+// Refresh fetch lists from tracker and cache them
+func (self *Discovery) Refresh() error {
+	prsc := pb.NewProviderRegisterServiceClient(self.Trackers.Conn())
+	trackers, err := client.GetTrackerServer(prsc)
+	if err != nil {
+		return err
+	}
+	collectors, err := client.GetCollectorServer(prsc)
+	if err != nil {
+		return err
+	}
+	c := cache{Trackers: addresses(trackers), Collectors: addresses(collectors)}
+	if _, port, err := net.SplitHostPort(self.task); err == nil {
+		for _, tracker := range c.Trackers {
+			if host, _, err := net.SplitHostPort(tracker); err == nil {
+				c.Tasks = append(c.Tasks, net.JoinHostPort(host, port))
+			}
+		}
+	}
+	for _, l := range []struct {
+		servers *failover.Servers
+		addrs   []string
+	}{{self.Trackers, c.Trackers}, {self.Tasks, c.Tasks}, {self.Collectors, c.Collectors}} {
+		if err = l.servers.Update(l.addrs); err != nil {
+			return err
+		}
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(self.cachePath, b, 0600)
+}
+
+// addresses of servers sorted, so order of list does not change between refreshes
+func addresses(servers map[string]uint32) []string {
+	res := make([]string, 0, len(servers))
+	for host, port := range servers {
+		res = append(res, net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10)))
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Start refresh lists now and periodically until Stop
+func (self *Discovery) Start() {
+	if err := self.Refresh(); err != nil {
+		log.Warnf("refresh tracker side servers failed, configured and cached ones are used: %s", err)
+	}
+	self.quit = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(refresh_interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-self.quit:
+				return
+			case <-ticker.C:
+				if err := self.Refresh(); err != nil {
+					log.Warnf("refresh tracker side servers failed: %s", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop refreshing and close connections
+func (self *Discovery) Stop() {
+	if self.quit != nil {
+		close(self.quit)
+	}
+	self.Trackers.Close()
+	self.Tasks.Close()
+	self.Collectors.Close()
+}
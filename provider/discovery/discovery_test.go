@@ -0,0 +1,28 @@
+package discovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "servers.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"trackers":["t2:6677","t1:6677"],"tasks":["t2:6622"],"collectors":["c2:6688"]}`), 0600))
+	d := New("t1:6677", "t1:6622", "c1:6688", path)
+	defer d.Stop()
+	assert.Equal(t, []string{"t1:6677", "t2:6677"}, d.Trackers.List(), "configured server is first")
+	assert.Equal(t, []string{"t1:6622", "t2:6622"}, d.Tasks.List())
+	assert.Equal(t, []string{"c1:6688", "c2:6688"}, d.Collectors.List())
+
+	d = New("t1:6677", "t1:6622", "c1:6688", filepath.Join(dir, "missing.json"))
+	defer d.Stop()
+	assert.Equal(t, []string{"t1:6677"}, d.Trackers.List())
+}
@@ -0,0 +1,181 @@
+package impl
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/samoslab/nebula/provider/atrest"
+	"github.com/samoslab/nebula/provider/config"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	log "github.com/sirupsen/logrus"
+	leveldb_errors "github.com/syndtr/goleveldb/leveldb/errors"
+)
+
+const sealing_suffix = ".sealing"
+
+func encryptAtRest() bool {
+	pc := config.GetProviderConfig()
+	return pc != nil && pc.EncryptAtRest
+}
+
+// putSmallFile save small file, plaintext which begins with at-rest magic is sealed even if encryption is disabled
+func (self *ProviderService) putSmallFile(storage *config.Storage, key []byte, data []byte) error {
+	if encryptAtRest() || atrest.IsSealed(data) {
+		sealed, err := self.cipher.Seal(data)
+		if err != nil {
+			return err
+		}
+		data = sealed
+	}
+	err := storage.SmallFileDb.Put(key, data, nil)
+	if err != nil {
+		storage.RecordIOError(err)
+	}
+	return err
+}
+
+// getSmallFile read plaintext of small file
+func (self *ProviderService) getSmallFile(storage *config.Storage, key []byte) ([]byte, error) {
+	if storage.Failed() {
+		return nil, config.StorageFailedErr
+	}
+	data, err := storage.SmallFileDb.Get(key, nil)
+	if err != nil {
+		if err != leveldb_errors.ErrNotFound {
+			storage.RecordIOError(err)
+		}
+		return nil, err
+	}
+	return self.cipher.Open(data)
+}
+
+// openBlockFile open plaintext view of block file, io error is recorded to its storage
+func (self *ProviderService) openBlockFile(path string) (*atrest.File, error) {
+	file, err := self.cipher.OpenFile(path)
+	if err != nil {
+		self.storages.RecordIOErrorOfPath(path, err)
+	}
+	return file, err
+}
+
+// verifyBlockFile check plaintext of block file with key
+func (self *ProviderService) verifyBlockFile(key []byte, path string) (bool, error) {
+	file, err := self.openBlockFile(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	ok, err := util_hash.VerifyReaderKey(key, file)
+	if err != nil {
+		self.storages.RecordIOErrorOfPath(path, err)
+	}
+	return ok, err
+}
+
+// needSealFile check plaintext file should be sealed before moved to storage
+func needSealFile(path string) (bool, error) {
+	if encryptAtRest() {
+		return true, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	buf := make([]byte, 4)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, err
+	}
+	return atrest.IsSealed(buf[:n]), nil
+}
+
+// MigrateAtRest seal stored blocks which are plaintext or sealed by old key version,
+// storages must be initialized and daemon must be stopped, the provider db lock makes sure of it.
+func MigrateAtRest() (sealed int, skipped int, err error) {
+	ps, err := newOfflineProviderService()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer ps.closeOffline()
+	iter := ps.providerDb.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		key := append([]byte{}, iter.Key()...)
+		found, smallFile, storageIdx, subPath := ps.querySubPath(key)
+		if !found {
+			log.Warnf("storage of block is not available, key: %x", key)
+			skipped++
+			continue
+		}
+		var done bool
+		var er error
+		if smallFile {
+			done, er = ps.migrateSmallFile(key, storageIdx)
+		} else {
+			done, er = ps.migrateBlockFile(key, ps.storages.GetStoragePath(storageIdx, subPath))
+		}
+		if er != nil {
+			log.Warnf("seal block failed, key: %x error: %s", key, er)
+			skipped++
+		} else if done {
+			sealed++
+		}
+	}
+	return sealed, skipped, iter.Error()
+}
+
+func (self *ProviderService) migrateSmallFile(key []byte, storageIdx byte) (bool, error) {
+	storage := self.storages.GetStorage(storageIdx)
+	if storage == nil {
+		return false, fmt.Errorf("storage %d not available", storageIdx)
+	}
+	raw, err := storage.SmallFileDb.Get(key, nil)
+	if err != nil {
+		return false, err
+	}
+	if atrest.SealedVersion(raw) == self.cipher.Version() {
+		return false, nil
+	}
+	data, err := self.cipher.Open(raw)
+	if err != nil {
+		return false, err
+	}
+	if !util_hash.VerifyKey(key, data) {
+		return false, fmt.Errorf("hash verify failed")
+	}
+	data, err = self.cipher.Seal(data)
+	if err != nil {
+		return false, err
+	}
+	return true, storage.SmallFileDb.Put(key, data, nil)
+}
+
+func (self *ProviderService) migrateBlockFile(key []byte, path string) (bool, error) {
+	file, err := self.openBlockFile(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	if file.Version() == self.cipher.Version() {
+		return false, nil
+	}
+	ok, err := util_hash.VerifyReaderKey(key, file)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, fmt.Errorf("hash verify failed")
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	tempPath := path + sealing_suffix
+	if err = self.cipher.SealTo(tempPath, file); err != nil {
+		os.Remove(tempPath)
+		return false, err
+	}
+	file.Close()
+	return true, os.Rename(tempPath, path)
+}
@@ -3,6 +3,7 @@ package impl
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -12,6 +13,8 @@ import (
 	"time"
 
 	gosync "github.com/lrita/gosync"
+	"github.com/samoslab/nebula/provider/activity"
+	"github.com/samoslab/nebula/provider/atrest"
 	client "github.com/samoslab/nebula/provider/collector_client"
 	"github.com/samoslab/nebula/provider/config"
 	"github.com/samoslab/nebula/provider/node"
@@ -20,7 +23,9 @@ import (
 	task_client "github.com/samoslab/nebula/provider/task_client"
 	tcppb "github.com/samoslab/nebula/tracker/collector/provider/pb"
 	ttpb "github.com/samoslab/nebula/tracker/task/pb"
+	"github.com/samoslab/nebula/util/filecheck"
 	util_hash "github.com/samoslab/nebula/util/hash"
+	"github.com/samoslab/nebula/util/merkle"
 	log "github.com/sirupsen/logrus"
 	"github.com/syndtr/goleveldb/leveldb"
 	leveldb_errors "github.com/syndtr/goleveldb/leveldb/errors"
@@ -39,7 +44,13 @@ type ProviderService struct {
 	node               *node.Node
 	nodeIdHash         []byte
 	providerDb         *leveldb.DB
+	merkleDb           *leveldb.DB
+	tagDb              *leveldb.DB
+	trashDb            *leveldb.DB
+	cipher             *atrest.Cipher
+	storages           Storages
 	taskGetting        gosync.Mutex
+	trashPurging       gosync.Mutex
 	blocksVerifying    gosync.Mutex
 	replicateChan      chan *ttpb.Task
 	sendChan           chan *ttpb.Task
@@ -51,28 +62,102 @@ type ProviderService struct {
 	ptsc               ttpb.ProviderTaskServiceClient
 }
 
-func NewProviderService(taskServer string, private bool) *ProviderService {
+func NewProviderService(taskClient ttpb.ProviderTaskServiceClient, private bool) *ProviderService {
 	if os.Getenv("NEBULA_TEST_MODE") == "1" {
 		skip_check_auth = true
 	}
-	ps := &ProviderService{}
-	ps.node = node.LoadFormConfig()
+	ps, err := NewProviderServiceWithOptions(Options{Node: node.LoadFormConfig(), TaskClient: taskClient, Private: private})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	config.OnStorageFailed(ps.reportFailedStorage)
+	return ps
+}
+
+// Options of ProviderService, nil Storages means storages of provider config
+type Options struct {
+	Node       *node.Node
+	Storages   Storages
+	TaskServer string
+	TaskClient ttpb.ProviderTaskServiceClient // used instead of dialing TaskServer if not nil
+	Private    bool
+}
+
+// NewProviderServiceWithOptions create provider without global config, so several providers can run in one process
+func NewProviderServiceWithOptions(opts Options) (*ProviderService, error) {
+	ps := &ProviderService{node: opts.Node, storages: opts.Storages}
+	if ps.storages == nil {
+		ps.storages = configStorages{}
+	}
 	ps.nodeIdHash = util_hash.Sha1(ps.node.NodeId)
 	var err error
-	ps.providerDb, err = leveldb.OpenFile(config.ProviderDbPath(), nil)
+	ps.cipher, err = atrest.NewCipher(ps.node.EncryptKey)
 	if err != nil {
-		log.Fatalf("open Provider DB failed:%s", err)
+		return nil, fmt.Errorf("create at-rest cipher failed: %s", err)
 	}
-	ps.initTaskProcessor(taskServer, private)
-	return ps
+	main := ps.storages.GetStorage(0)
+	if ps.providerDb, err = leveldb.OpenFile(main.DbPath(config.ProviderDbName), nil); err != nil {
+		return nil, fmt.Errorf("open Provider DB failed: %s", err)
+	}
+	if ps.merkleDb, err = leveldb.OpenFile(main.DbPath(config.MerkleDbName), nil); err != nil {
+		ps.providerDb.Close()
+		return nil, fmt.Errorf("open Merkle DB failed: %s", err)
+	}
+	if ps.tagDb, err = leveldb.OpenFile(main.DbPath(config.TagDbName), nil); err != nil {
+		ps.providerDb.Close()
+		ps.merkleDb.Close()
+		return nil, fmt.Errorf("open Tag DB failed: %s", err)
+	}
+	if ps.trashDb, err = leveldb.OpenFile(main.DbPath(config.TrashDbName), nil); err != nil {
+		ps.providerDb.Close()
+		ps.merkleDb.Close()
+		ps.tagDb.Close()
+		return nil, fmt.Errorf("open Trash DB failed: %s", err)
+	}
+	ps.trashPurging = gosync.NewMutex()
+	if err = ps.initTaskProcessor(opts.TaskServer, opts.TaskClient, opts.Private); err != nil {
+		ps.Close()
+		return nil, err
+	}
+	return ps, nil
 }
 
 func (self *ProviderService) Close() {
 	self.providerDb.Close()
+	self.merkleDb.Close()
+	self.tagDb.Close()
+	self.trashDb.Close()
+}
+
+// newOfflineProviderService open dbs for offline command without task processor, storages must be initialized,
+// it fails if daemon is running because of db lock
+func newOfflineProviderService() (*ProviderService, error) {
+	ps := &ProviderService{node: node.LoadFormConfig(), storages: configStorages{}}
+	var err error
+	ps.cipher, err = atrest.NewCipher(ps.node.EncryptKey)
+	if err != nil {
+		return nil, fmt.Errorf("create at-rest cipher failed: %s", err)
+	}
+	ps.providerDb, err = leveldb.OpenFile(config.ProviderDbPath(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("open Provider DB failed: %s", err)
+	}
+	ps.trashDb, err = leveldb.OpenFile(config.TrashDbPath(), nil)
+	if err != nil {
+		ps.providerDb.Close()
+		return nil, fmt.Errorf("open Trash DB failed: %s", err)
+	}
+	return ps, nil
+}
+
+func (self *ProviderService) closeOffline() {
+	self.providerDb.Close()
+	self.trashDb.Close()
 }
 
 func (self *ProviderService) Ping(ctx context.Context, req *pb.PingReq) (*pb.PingResp, error) {
-	return &pb.PingResp{NodeIdHash: self.nodeIdHash}, nil
+	version, features := pb.Handshake(req.Version)
+	return &pb.PingResp{NodeIdHash: self.nodeIdHash, Version: version, Features: features}, nil
 }
 
 func now() uint64 {
@@ -115,7 +200,7 @@ func (self *ProviderService) StoreSmall(ctx context.Context, req *pb.StoreReq) (
 		logWarnAndSetActionLog(err, al)
 		return
 	}
-	if !bytes.Equal(req.BlockKey, util_hash.Sha1(req.Data)) {
+	if !util_hash.VerifyKey(req.BlockKey, req.Data) {
 		err = status.Errorf(codes.InvalidArgument, "check data hash failed, blockKey: %x", req.BlockKey)
 		logWarnAndSetActionLog(err, al)
 		return
@@ -132,14 +217,20 @@ func (self *ProviderService) StoreSmall(ctx context.Context, req *pb.StoreReq) (
 		logWarnAndSetActionLog(err, al)
 		return
 	}
-	storage := config.GetWriteStorage(req.BlockSize)
+	if revived, er := self.revive(req.BlockKey); revived {
+		al.Success, al.EndTime = true, now()
+		return &pb.StoreResp{Success: true}, nil
+	} else if er != nil {
+		log.Warnf("revive failed, blockKey: %x error: %s", req.BlockKey, er)
+	}
+	storage := self.storages.GetWriteStorage(req.BlockSize)
 	if storage == nil {
 		err = status.Errorf(codes.ResourceExhausted, "available disk space of this provider is not enlough, blockKey: %s blockSize: %d", req.BlockKey, req.BlockSize)
 		logWarnAndSetActionLog(err, al)
 		al.TransportSize += uint64(len(req.Data))
 		return
 	}
-	if err = storage.SmallFileDb.Put(req.BlockKey, req.Data, nil); err != nil {
+	if err = self.putSmallFile(storage, req.BlockKey, req.Data); err != nil {
 		err = status.Errorf(codes.Internal, "save to small file db failed, blockKey: %x error: %s", req.BlockKey, err)
 		logWarnAndSetActionLog(err, al)
 		return
@@ -149,6 +240,8 @@ func (self *ProviderService) StoreSmall(ctx context.Context, req *pb.StoreReq) (
 		logWarnAndSetActionLog(err, al)
 		return
 	}
+	self.saveMerkleLeaves(req.BlockKey, merkle.Leaves(req.Data, merkle.DefaultChunkSize))
+	self.saveTag(req.BlockKey, req.BlockSize, req.Tag)
 	al.Success, al.EndTime = true, now()
 	return &pb.StoreResp{Success: true}, nil
 }
@@ -161,6 +254,7 @@ func (self *ProviderService) Store(stream pb.ProviderService_StoreServer) (er er
 	var storage *config.Storage
 	var blockKey []byte
 	var blockSize uint64
+	var tag *pb.BlockTag
 	for {
 		req, err := stream.Recv()
 		if err != nil {
@@ -181,6 +275,12 @@ func (self *ProviderService) Store(stream pb.ProviderService_StoreServer) (er er
 				al.TransportSize += uint64(len(req.Data))
 				return
 			}
+			if _, _, err = util_hash.ParseKey(blockKey); err != nil {
+				er = status.Errorf(codes.InvalidArgument, "parse key failed, blockKey: %x error: %s", blockKey, err)
+				logWarnAndSetActionLog(er, al)
+				al.TransportSize += uint64(len(req.Data))
+				return
+			}
 			if !skip_check_auth {
 				if err = req.CheckAuth(self.node.PubKeyBytes); err != nil {
 					er = status.Errorf(codes.Unauthenticated, "check auth failed, blockKey: %x error: %s", blockKey, err)
@@ -195,7 +295,19 @@ func (self *ProviderService) Store(stream pb.ProviderService_StoreServer) (er er
 				al.TransportSize += uint64(len(req.Data))
 				return
 			}
-			storage = config.GetWriteStorage(blockSize)
+			if revived, err := self.revive(blockKey); revived {
+				al.TransportSize += uint64(len(req.Data))
+				if err = stream.SendAndClose(&pb.StoreResp{Success: true}); err != nil {
+					er = status.Errorf(codes.Unknown, "RPC SendAndClose failed, blockKey: %x error: %s", blockKey, err)
+					logWarnAndSetActionLog(er, al)
+					return
+				}
+				al.Success, al.EndTime = true, now()
+				return nil
+			} else if err != nil {
+				log.Warnf("revive failed, blockKey: %x error: %s", blockKey, err)
+			}
+			storage = self.storages.GetWriteStorage(blockSize)
 			if storage == nil {
 				er = status.Errorf(codes.ResourceExhausted, "available disk space of this provider is not enlough, blockKey: %s blockSize: %d", blockKey, blockSize)
 				logWarnAndSetActionLog(er, al)
@@ -208,6 +320,7 @@ func (self *ProviderService) Store(stream pb.ProviderService_StoreServer) (er er
 				os.O_WRONLY|os.O_TRUNC|os.O_CREATE,
 				0600)
 			if err != nil {
+				storage.RecordIOError(err)
 				er = status.Errorf(codes.Internal, "open temp write file failed, blockKey: %x error: %s", blockKey, err)
 				logWarnAndSetActionLog(er, al)
 				al.TransportSize += uint64(len(req.Data))
@@ -215,6 +328,7 @@ func (self *ProviderService) Store(stream pb.ProviderService_StoreServer) (er er
 			}
 			defer file.Close()
 		}
+		tag = tag.Merge(req.Tag)
 		if len(req.Data) == 0 {
 			break
 		}
@@ -225,6 +339,7 @@ func (self *ProviderService) Store(stream pb.ProviderService_StoreServer) (er er
 			return
 		}
 		if _, err = file.Write(req.Data); err != nil {
+			storage.RecordIOError(err)
 			er = status.Errorf(codes.Internal, "write file failed, blockKey: %x error: %s", blockKey, err)
 			logWarnAndSetActionLog(er, al)
 			return
@@ -241,14 +356,14 @@ func (self *ProviderService) Store(stream pb.ProviderService_StoreServer) (er er
 		logWarnAndSetActionLog(er, al)
 		return
 	}
-	hash, err := util_hash.Sha1File(tempFilePath)
+	ok, err := util_hash.VerifyFileKey(blockKey, tempFilePath)
 	if err != nil {
-		er = status.Errorf(codes.Internal, "sha1 sum file %s failed, blockKey: %x error: %s", tempFilePath, blockKey, err)
+		er = status.Errorf(codes.Internal, "hash sum file %s failed, blockKey: %x error: %s", tempFilePath, blockKey, err)
 		logWarnAndSetActionLog(er, al)
 		return
 	}
-	if !bytes.Equal(hash, blockKey) {
-		er = status.Errorf(codes.InvalidArgument, "hash verify failed, blockKey: %x error: %s", blockKey, err)
+	if !ok {
+		er = status.Errorf(codes.InvalidArgument, "hash verify failed, blockKey: %x", blockKey)
 		logWarnAndSetActionLog(er, al)
 		return
 	}
@@ -258,10 +373,12 @@ func (self *ProviderService) Store(stream pb.ProviderService_StoreServer) (er er
 		return
 	}
 	if err := self.saveFile(blockKey, blockSize, tempFilePath, storage); err != nil {
+		storage.RecordIOError(err)
 		er = status.Errorf(codes.Internal, "save file failed, tempFilePath: %s blockKey: %x error: %s", tempFilePath, blockKey, err)
 		logWarnAndSetActionLog(er, al)
 		return
 	}
+	self.saveTag(blockKey, blockSize, tag)
 	if err := stream.SendAndClose(&pb.StoreResp{Success: true}); err != nil {
 		er = status.Errorf(codes.Unknown, "RPC SendAndClose failed, blockKey: %x error: %s", blockKey, err)
 		logWarnAndSetActionLog(er, al)
@@ -299,8 +416,8 @@ func (self *ProviderService) RetrieveSmall(ctx context.Context, req *pb.Retrieve
 		logWarnAndSetActionLog(err, al)
 		return
 	}
-	storage := config.GetStorage(storageIdx)
-	data, err := storage.SmallFileDb.Get(req.BlockKey, nil)
+	storage := self.storages.GetStorage(storageIdx)
+	data, err := self.getSmallFile(storage, req.BlockKey)
 	if err != nil {
 		err = status.Errorf(codes.Internal, "read small file error, blockKey: %x error: %s", req.BlockKey, err)
 		logWarnAndSetActionLog(err, al)
@@ -311,13 +428,17 @@ func (self *ProviderService) RetrieveSmall(ctx context.Context, req *pb.Retrieve
 		logWarnAndSetActionLog(err, al)
 		return
 	}
-	if !bytes.Equal(util_hash.Sha1(data), req.BlockKey) {
-		err = status.Errorf(codes.DataLoss, "hash verify failed, blockKey: %x error: %s", req.BlockKey, err)
+	if !util_hash.VerifyKey(req.BlockKey, data) {
+		err = status.Errorf(codes.DataLoss, "hash verify failed, blockKey: %x", req.BlockKey)
 		logWarnAndSetActionLog(err, al)
 		return
 	}
 	al.Success, al.EndTime, al.TransportSize = true, now(), uint64(len(data))
-	return &pb.RetrieveResp{Data: data}, nil
+	resp = &pb.RetrieveResp{Data: data}
+	if req.WithTag {
+		resp.Tag = self.getTag(req.BlockKey)
+	}
+	return resp, nil
 }
 
 func (self *ProviderService) Retrieve(req *pb.RetrieveReq, stream pb.ProviderService_RetrieveServer) (err error) {
@@ -346,35 +467,39 @@ func (self *ProviderService) Retrieve(req *pb.RetrieveReq, stream pb.ProviderSer
 		logWarnAndSetActionLog(err, al)
 		return
 	}
-	path := config.GetStoragePath(storageIdx, subPath)
-	hash, err := util_hash.Sha1File(path)
+	path := self.storages.GetStoragePath(storageIdx, subPath)
+	ok, err := self.verifyBlockFile(req.BlockKey, path)
 	if err != nil {
-		err = status.Errorf(codes.Internal, "sha1 sum file %s failed, blockKey: %x error: %s", path, req.BlockKey, err)
+		err = status.Errorf(codes.Internal, "hash sum file %s failed, blockKey: %x error: %s", path, req.BlockKey, err)
 		logWarnAndSetActionLog(err, al)
 		return
 	}
-	if !bytes.Equal(hash, req.BlockKey) {
-		err = status.Errorf(codes.DataLoss, "hash verify failed, blockKey: %x error: %s", req.BlockKey, err)
+	if !ok {
+		err = status.Errorf(codes.DataLoss, "hash verify failed, blockKey: %x", req.BlockKey)
 		logWarnAndSetActionLog(err, al)
 		return
 	}
-	file, err := os.Open(path)
+	file, err := self.openBlockFile(path)
 	if err != nil {
 		err = status.Errorf(codes.Internal, "open file failed, blockKey: %x error: %s", req.BlockKey, err)
 		logWarnAndSetActionLog(err, al)
 		return
 	}
 	defer file.Close()
-	if err = sendFileToStream(req.BlockKey, path, file, stream, al); err != nil {
+	var tags []*pb.BlockTag
+	if req.WithTag {
+		tags = self.getTag(req.BlockKey).Split(int((req.BlockSize + stream_data_size - 1) / stream_data_size))
+	}
+	if err = sendFileToStream(req.BlockKey, path, file, stream, al, tags); err != nil {
 		return err
 	}
 	al.Success, al.EndTime = true, now()
 	return nil
 }
 
-func sendFileToStream(key []byte, path string, file *os.File, stream pb.ProviderService_RetrieveServer, al *tcppb.ActionLog) (er error) {
+func sendFileToStream(key []byte, path string, file io.Reader, stream pb.ProviderService_RetrieveServer, al *tcppb.ActionLog, tags []*pb.BlockTag) (er error) {
 	buf := make([]byte, stream_data_size)
-	for {
+	for i := 0; ; i++ {
 		bytesRead, err := file.Read(buf)
 		if err != nil {
 			if err == io.EOF {
@@ -385,7 +510,11 @@ func sendFileToStream(key []byte, path string, file *os.File, stream pb.Provider
 			return
 		}
 		if bytesRead > 0 {
-			stream.Send(&pb.RetrieveResp{Data: buf[:bytesRead]})
+			resp := &pb.RetrieveResp{Data: buf[:bytesRead]}
+			if i < len(tags) {
+				resp.Tag = tags[i]
+			}
+			stream.Send(resp)
 			al.TransportSize += uint64(bytesRead)
 		}
 		if bytesRead < stream_data_size {
@@ -403,32 +532,17 @@ func (self *ProviderService) Remove(ctx context.Context, req *pb.RemoveReq) (res
 			return
 		}
 	}
-	found, smallFile, storageIdx, subPath := self.querySubPath(req.Key)
-	if !found {
+	if found, _, _, _ := self.querySubPath(req.Key); !found {
 		err = status.Errorf(codes.NotFound, "file not exist, key: %x", req.Key)
 		log.Warnln(err)
 		return
 	}
-	if err = self.providerDb.Delete(req.Key, nil); err != nil {
-		err = status.Errorf(codes.Internal, "delete from provider db failed, key: %x error: %s", req.Key, err)
+	// block is kept in trash for grace period like removed by task, so a wrong remove can be revived
+	if err = self.trashBlock(req.Key, req.Size); err != nil {
+		err = status.Errorf(codes.Internal, "move to trash failed, key: %x error: %s", req.Key, err)
 		log.Warnln(err)
 		return
 	}
-	if smallFile {
-		storage := config.GetStorage(storageIdx)
-		if err = storage.SmallFileDb.Delete(req.Key, nil); err != nil {
-			err = status.Errorf(codes.Internal, "delete from small file db failed, key: %x error: %s", req.Key, err)
-			log.Warnln(err)
-			return
-		}
-	} else {
-		path := config.GetStoragePath(storageIdx, subPath)
-		if err = os.Remove(path); err != nil {
-			err = status.Errorf(codes.Internal, "remove file failed, key: %x error: %s", req.Key, err)
-			log.Warnln(err)
-			return
-		}
-	}
 	return &pb.RemoveResp{Success: true}, nil
 }
 
@@ -460,8 +574,8 @@ func (self *ProviderService) GetFragment(ctx context.Context, req *pb.GetFragmen
 	}
 	var res [][]byte
 	if smallFile {
-		storage := config.GetStorage(storageIdx)
-		data, er := storage.SmallFileDb.Get(req.Key, nil)
+		storage := self.storages.GetStorage(storageIdx)
+		data, er := self.getSmallFile(storage, req.Key)
 		if er != nil {
 			err = status.Errorf(codes.Internal, "read small file error, blockKey: %x error: %s", req.Key, er)
 			log.Warnln(err)
@@ -469,8 +583,8 @@ func (self *ProviderService) GetFragment(ctx context.Context, req *pb.GetFragmen
 		}
 		res, err = getFragmentFromByteSlice(req.Key, data, req.Positions, req.Size)
 	} else {
-		path := config.GetStoragePath(storageIdx, subPath)
-		res, err = getFragmentFromFile(req.Key, path, req.Positions, req.Size)
+		path := self.storages.GetStoragePath(storageIdx, subPath)
+		res, err = self.getFragmentFromFile(req.Key, path, req.Positions, req.Size)
 	}
 	if err != nil {
 		return
@@ -493,22 +607,16 @@ func getFragmentFromByteSlice(key []byte, data []byte, positions []byte, size ui
 	return res, nil
 }
 
-func getFragmentFromFile(key []byte, path string, positions []byte, size uint32) (fragment [][]byte, err error) {
+func (self *ProviderService) getFragmentFromFile(key []byte, path string, positions []byte, size uint32) (fragment [][]byte, err error) {
 	res := make([][]byte, 0, len(positions))
-	fileInfo, er := os.Stat(path)
-	if er != nil {
-		err = status.Errorf(codes.Internal, "stat file failed, key: %x error: %s", key, er)
-		log.Warnln(err)
-		return
-	}
-	fileSize := uint64(fileInfo.Size())
-	file, er := os.Open(path)
+	file, er := self.openBlockFile(path)
 	if er != nil {
 		err = status.Errorf(codes.Internal, "open file failed, key: %x error: %s", key, er)
 		log.Warnln(err)
 		return
 	}
 	defer file.Close()
+	fileSize := uint64(file.Size())
 	for _, posPercent := range positions {
 		pos := int64(posPercent) * int64(fileSize) / 100
 		if pos+int64(size) > int64(fileSize) {
@@ -538,6 +646,23 @@ func (self *ProviderService) saveFile(key []byte, fileSize uint64, tmpFilePath s
 	if err != nil {
 		return err
 	}
+	leaves, err := merkle.LeavesOfFile(tmpFilePath, merkle.DefaultChunkSize)
+	if err != nil {
+		return err
+	}
+	seal, err := needSealFile(tmpFilePath)
+	if err != nil {
+		return err
+	}
+	if seal {
+		sealedPath := storage.TempFilePath(key)
+		if err = self.cipher.SealFile(tmpFilePath, sealedPath); err != nil {
+			os.Remove(sealedPath)
+			return err
+		}
+		os.Remove(tmpFilePath)
+		tmpFilePath = sealedPath
+	}
 	err = os.Rename(tmpFilePath, fullPath)
 	if err != nil {
 		return err
@@ -546,7 +671,11 @@ func (self *ProviderService) saveFile(key []byte, fileSize uint64, tmpFilePath s
 	pathSlice := make([]byte, len(path)+1)
 	copy(pathSlice[1:], path)
 	pathSlice[0] = storage.Index
-	return self.providerDb.Put(key, pathSlice, nil)
+	if err = self.providerDb.Put(key, pathSlice, nil); err != nil {
+		return err
+	}
+	self.saveMerkleLeaves(key, leaves)
+	return nil
 }
 
 func (self *ProviderService) queryByKey(key []byte) []byte {
@@ -559,10 +688,13 @@ func (self *ProviderService) queryByKey(key []byte) []byte {
 	return nil
 }
 
+// querySubPath block on failed storage is treated as missing, so it is not read and can be stored again
 func (self *ProviderService) querySubPath(key []byte) (found bool, smallFile bool, storageIdx byte, subPath string) {
 	bytes := self.queryByKey(key)
 	if len(bytes) == 0 {
 		return false, false, 0, ""
+	} else if storage := self.storages.GetStorage(bytes[0]); storage == nil || storage.Failed() {
+		return false, false, 0, ""
 	} else if len(bytes) == 1 {
 		return true, true, bytes[0], ""
 	} else {
@@ -581,11 +713,19 @@ func (self *ProviderService) CheckAvailable(ctx context.Context, req *pb.CheckAv
 			return
 		}
 	}
-	total, max := config.AvailableVolume()
-	return &pb.CheckAvailableResp{Total: total, MaxFileSize: max, Version: 1}, nil
+	total, max := self.storages.AvailableVolume()
+	return &pb.CheckAvailableResp{Total: total, MaxFileSize: max, Version: pb.ProtocolVersion}, nil
 }
 
-func (self *ProviderService) initTaskProcessor(taskServer string, private bool) {
+func (self *ProviderService) initTaskProcessor(taskServer string, taskClient ttpb.ProviderTaskServiceClient, private bool) error {
+	if self.ptsc = taskClient; self.ptsc == nil {
+		var err error
+		self.taskConnection, err = grpc.Dial(taskServer, grpc.WithInsecure())
+		if err != nil {
+			return fmt.Errorf("RPC Dial taskServer %s failed: %s", taskServer, err)
+		}
+		self.ptsc = ttpb.NewProviderTaskServiceClient(self.taskConnection)
+	}
 	self.taskGetting = gosync.NewMutex()
 	self.blocksVerifying = gosync.NewMutex()
 	self.shutdownSignal = make(chan bool, 1)
@@ -615,18 +755,14 @@ func (self *ProviderService) initTaskProcessor(taskServer string, private bool)
 		go self.processReplicate(closeSig)
 	}
 	self.waitClose.Add(replicateThread + sendTread + processRemoveAndProve)
-	var err error
-	self.taskConnection, err = grpc.Dial(taskServer, grpc.WithInsecure())
-	if err != nil {
-		fmt.Printf("RPC Dial taskServer %s failed: %s\n", taskServer, err.Error())
-		os.Exit(60)
-	}
-	self.ptsc = ttpb.NewProviderTaskServiceClient(self.taskConnection)
+	return nil
 }
 
 func (self *ProviderService) CloseTaskProcessor() {
-	defer self.taskConnection.Close()
-	self.shutdownSignal <- true
+	if self.taskConnection != nil {
+		defer self.taskConnection.Close()
+	}
+	close(self.shutdownSignal)
 	for _, closeSig := range self.closeSignal {
 		closeSig <- true
 	}
@@ -644,7 +780,7 @@ func (self *ProviderService) processReplicate(closeSig chan bool) {
 				fmt.Printf("Task [%x] info error, REPLICATE task haven't opposite id\n", ta.Id)
 				continue
 			}
-			resp, err := task_client.GetOppositeInfo(self.ptsc, ta.Id)
+			resp, err := task_client.GetOppositeInfo(self.ptsc, self.node, ta.Id)
 			if err != nil {
 				fmt.Printf("Get task [%x] opposite info failed: %s\n", ta.Id, err.Error())
 				continue
@@ -655,12 +791,13 @@ func (self *ProviderService) processReplicate(closeSig chan bool) {
 			}
 			var remark string
 			success := true
-			if err = self.taskReplicate(ta.FileHash, ta.FileSize, ta.BlockHash, ta.BlockSize, resp.Timestamp, resp.Info); err != nil {
+			if err = self.taskReplicate(ta.FileHash, ta.FileSize, ta.BlockHash, ta.BlockSize, resp.Timestamp, resp.Info, recordTag(resp)); err != nil {
 				remark = err.Error()
 				success = false
 				fmt.Printf("taskReplicate failed, blockKey: %x, error: %s\n", ta.BlockHash, remark)
 			}
-			if err = task_client.FinishTask(self.ptsc, ta.Id, uint64(time.Now().Unix()), success, remark); err != nil {
+			activity.RecordTask(ta, success, remark)
+			if err = task_client.FinishTask(self.ptsc, self.node, ta.Id, uint64(time.Now().Unix()), success, remark); err != nil {
 				fmt.Printf("Finish replicate task [%x] failed: %s\n", ta.Id, err.Error())
 			}
 		}
@@ -678,7 +815,7 @@ func (self *ProviderService) processSend(closeSig chan bool) {
 				fmt.Printf("Task [%x] info error, SEND task haven't single opposite id\n", ta.Id)
 				continue
 			}
-			resp, err := task_client.GetOppositeInfo(self.ptsc, ta.Id)
+			resp, err := task_client.GetOppositeInfo(self.ptsc, self.node, ta.Id)
 			if err != nil {
 				fmt.Printf("Get task [%x] opposite info failed: %s\n", ta.Id, err.Error())
 				continue
@@ -689,12 +826,13 @@ func (self *ProviderService) processSend(closeSig chan bool) {
 			}
 			var remark string
 			success := true
-			if err = self.taskSend(ta.FileHash, ta.FileSize, ta.BlockHash, ta.BlockSize, resp.Timestamp, resp.Info[0]); err != nil {
+			if err = self.taskSend(ta.FileHash, ta.FileSize, ta.BlockHash, ta.BlockSize, resp.Timestamp, resp.Info[0], recordTag(resp)); err != nil {
 				remark = err.Error()
 				success = false
 				fmt.Printf("taskSend failed, blockKey: %x, error: %s\n", ta.BlockHash, remark)
 			}
-			if err = task_client.FinishTask(self.ptsc, ta.Id, uint64(time.Now().Unix()), success, remark); err != nil {
+			activity.RecordTask(ta, success, remark)
+			if err = task_client.FinishTask(self.ptsc, self.node, ta.Id, uint64(time.Now().Unix()), success, remark); err != nil {
 				fmt.Printf("Finish send task [%x] failed: %s\n", ta.Id, err.Error())
 			}
 		}
@@ -716,11 +854,12 @@ func (self *ProviderService) processRemoveAndProve(closeSig chan bool) {
 					success = false
 					fmt.Printf("taskRemove failed, blockKey: %x, error: %s\n", ta.BlockHash, remark)
 				}
-				if err := task_client.FinishTask(self.ptsc, ta.Id, uint64(time.Now().Unix()), success, remark); err != nil {
+				activity.RecordTask(ta, success, remark)
+				if err := task_client.FinishTask(self.ptsc, self.node, ta.Id, uint64(time.Now().Unix()), success, remark); err != nil {
 					fmt.Printf("Finish remove task [%x] failed: %s\n", ta.Id, err.Error())
 				}
 			} else if ta.Type == ttpb.TaskType_PROVE {
-				proofId, chunkSize, chunkSeq, err := task_client.GetProveInfo(self.ptsc, ta.Id)
+				proofId, chunkSize, chunkSeq, err := task_client.GetProveInfo(self.ptsc, self.node, ta.Id)
 				if err != nil {
 					fmt.Printf("Get task [%x] prove info failed: %s\n", ta.Id, err.Error())
 					continue
@@ -733,12 +872,13 @@ func (self *ProviderService) processRemoveAndProve(closeSig chan bool) {
 					fmt.Printf("task [%x] prove id not same\n", ta.Id)
 					continue
 				}
-				result, err := self.taskProve(ta.BlockHash, ta.BlockSize, chunkSize, chunkSeq)
+				result, sigma, err := self.taskProve(ta.BlockHash, ta.BlockSize, chunkSize, chunkSeq)
 				var remark string
 				if err != nil {
 					remark = err.Error()
 				}
-				if err = task_client.FinishProve(self.ptsc, ta.Id, proofId, uint64(time.Now().Unix()), result, remark); err != nil {
+				activity.RecordTask(ta, err == nil, remark)
+				if err = task_client.FinishProve(self.ptsc, self.node, ta.Id, proofId, uint64(time.Now().Unix()), result, sigma, remark); err != nil {
 					fmt.Printf("Finish prove task [%x] failed: %s\n", ta.Id, err.Error())
 				}
 			}
@@ -752,7 +892,7 @@ func (self *ProviderService) GetTask() {
 	} else {
 		return
 	}
-	taskList, err := task_client.TaskList(self.ptsc, len(self.removeAndProveChan) == 0,
+	taskList, err := task_client.TaskList(self.ptsc, self.node, len(self.removeAndProveChan) == 0,
 		len(self.removeAndProveChan) == 0, len(self.sendChan) == 0, len(self.replicateChan) == 0)
 	if err != nil {
 		fmt.Printf("Get task list failed: %s\n", err.Error())
@@ -775,32 +915,15 @@ func (self *ProviderService) GetTask() {
 	}
 }
 
+// taskRemove move block into trash, it is purged after grace period unless revived by store or replicate
 func (self *ProviderService) taskRemove(fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64) (err error) {
-	found, smallFile, storageIdx, subPath := self.querySubPath(blockHash)
-	if !found {
-		return
-	}
-	if err = self.providerDb.Delete(blockHash, nil); err != nil {
-		return fmt.Errorf("delete from provider db failed, error: %s", err)
-	}
-	if smallFile {
-		storage := config.GetStorage(storageIdx)
-		if err = storage.SmallFileDb.Delete(blockHash, nil); err != nil {
-			return fmt.Errorf("delete from small file db failed, error: %s", err)
-		}
-	} else {
-		path := config.GetStoragePath(storageIdx, subPath)
-		if err = os.Remove(path); err != nil {
-			return fmt.Errorf("remove file failed, error: %s", err)
-		}
-	}
-	return
+	return self.trashBlock(blockHash, blockSize)
 }
 
-func (self *ProviderService) taskProve(blockHash []byte, blockSize uint64, chunkSize uint32, chunkSeq map[uint32][]byte) (result []byte, err error) {
+func (self *ProviderService) taskProve(blockHash []byte, blockSize uint64, chunkSize uint32, chunkSeq map[uint32][]byte) (result []byte, sigma []byte, err error) {
 	found, smallFile, storageIdx, subPath := self.querySubPath(blockHash)
 	if !found {
-		return nil, fmt.Errorf("file not exist")
+		return nil, nil, fmt.Errorf("file not exist")
 	}
 	keys := make([]uint32, 0, len(chunkSeq))
 	for k, _ := range chunkSeq {
@@ -809,16 +932,16 @@ func (self *ProviderService) taskProve(blockHash []byte, blockSize uint64, chunk
 	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
 	res := big.NewInt(0)
 	if smallFile {
-		storage := config.GetStorage(storageIdx)
-		data, er := storage.SmallFileDb.Get(blockHash, nil)
+		storage := self.storages.GetStorage(storageIdx)
+		data, er := self.getSmallFile(storage, blockHash)
 		if er != nil {
-			return nil, fmt.Errorf("read small file error, error: %s", er)
+			return nil, nil, fmt.Errorf("read small file error, error: %s", er)
 		}
 		length := int64(len(data))
 		for _, k := range keys {
 			start := int64(k-1) * int64(chunkSize)
 			if k < 1 || start >= length {
-				return nil, fmt.Errorf("seq out of range: %d", k)
+				return nil, nil, fmt.Errorf("seq out of range: %d", k)
 			}
 			end := start + int64(chunkSize)
 			if end > length {
@@ -832,27 +955,23 @@ func (self *ProviderService) taskProve(blockHash []byte, blockSize uint64, chunk
 			res.Add(res, bm)
 		}
 	} else {
-		path := config.GetStoragePath(storageIdx, subPath)
-		fileInfo, er := os.Stat(path)
-		if er != nil {
-			return nil, fmt.Errorf("stat file failed, error: %s", er)
-		}
-		fileSize := int64(fileInfo.Size())
-		file, er := os.Open(path)
+		path := self.storages.GetStoragePath(storageIdx, subPath)
+		file, er := self.openBlockFile(path)
 		if er != nil {
-			return nil, fmt.Errorf("open file failed, error: %s", er)
+			return nil, nil, fmt.Errorf("open file failed, error: %s", er)
 		}
 		defer file.Close()
+		fileSize := file.Size()
 		buf := make([]byte, chunkSize)
 		for _, k := range keys {
 			start := int64(k-1) * int64(chunkSize)
 			if start >= fileSize {
-				return nil, fmt.Errorf("seq out of range")
+				return nil, nil, fmt.Errorf("seq out of range")
 			}
 			file.Seek(start, 0)
 			bytesRead, er := file.Read(buf)
 			if er != nil && er != io.EOF {
-				return nil, fmt.Errorf("read file failed, error: %s", er)
+				return nil, nil, fmt.Errorf("read file failed, error: %s", er)
 			}
 			if bytesRead > 0 {
 				bm := new(big.Int)
@@ -862,14 +981,22 @@ func (self *ProviderService) taskProve(blockHash []byte, blockSize uint64, chunk
 				bm.Mul(bm, bv)
 				res.Add(res, bm)
 			} else {
-				return nil, fmt.Errorf("read file get nothing, start position: %d", start)
+				return nil, nil, fmt.Errorf("read file get nothing, start position: %d", start)
 			}
 		}
 	}
-	return res.Bytes(), nil
+	tag := self.getTag(blockHash)
+	if tag == nil || tag.ChunkSize != chunkSize {
+		return res.Bytes(), nil, nil
+	}
+	sigma, err = filecheck.Prove(tag.ParamStr, tag.Phi, chunkSeq)
+	if err != nil {
+		return res.Bytes(), nil, fmt.Errorf("aggregate tag failed, error: %s", err)
+	}
+	return res.Bytes(), sigma, nil
 }
 
-func (self *ProviderService) taskSend(fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64, timestamp uint64, oppositeInfo *ttpb.OppositeInfo) (err error) {
+func (self *ProviderService) taskSend(fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64, timestamp uint64, oppositeInfo *ttpb.OppositeInfo, record *pb.BlockTag) (err error) {
 	found, smallFile, storageIdx, subPath := self.querySubPath(blockHash)
 	if !found {
 		return fmt.Errorf("file not exist")
@@ -881,71 +1008,97 @@ func (self *ProviderService) taskSend(fileHash []byte, fileSize uint64, blockHas
 	}
 	defer conn.Close()
 	psc := pb.NewProviderServiceClient(conn)
+	capability, err := provider_client.GetCapability(psc, providerAddr)
+	if err != nil {
+		return fmt.Errorf("query capability of provider %s failed: %s", providerAddr, err)
+	}
+	if alg, _, _ := util_hash.ParseKey(blockHash); alg != util_hash.SHA1 && !capability.Has(pb.Feature_MULTIHASH_KEY) {
+		return fmt.Errorf("provider %s does not support %s key", providerAddr, alg)
+	}
+	var tag *pb.BlockTag
+	if capability.Has(pb.Feature_BLOCK_TAG) {
+		tag = pickTag(record, self.getTag(blockHash), blockSize)
+	}
 	if smallFile {
-		storage := config.GetStorage(storageIdx)
-		data, er := storage.SmallFileDb.Get(blockHash, nil)
+		storage := self.storages.GetStorage(storageIdx)
+		data, er := self.getSmallFile(storage, blockHash)
 		if er != nil {
 			return fmt.Errorf("read small file error, error: %s", er)
 		}
 		if len(data) != int(blockSize) {
 			return fmt.Errorf("file length not same")
 		}
-		if !bytes.Equal(util_hash.Sha1(data), blockHash) {
+		if !util_hash.VerifyKey(blockHash, data) {
 			return fmt.Errorf("hash verify failed")
 		}
-		return provider_client.StoreSmall(psc, data, oppositeInfo.Auth, timestamp, oppositeInfo.Ticket, fileHash, fileSize, blockHash, blockSize)
+		return provider_client.StoreSmall(psc, data, oppositeInfo.Auth, timestamp, oppositeInfo.Ticket, fileHash, fileSize, blockHash, blockSize, tag)
 	} else {
-		path := config.GetStoragePath(storageIdx, subPath)
-		fileInfo, er := os.Stat(path)
+		path := self.storages.GetStoragePath(storageIdx, subPath)
+		file, er := self.openBlockFile(path)
 		if er != nil {
-			return fmt.Errorf("stat file failed, error: %s", er)
+			return fmt.Errorf("open file failed, error: %s", er)
 		}
-		if fileInfo.Size() != int64(blockSize) {
+		defer file.Close()
+		if file.Size() != int64(blockSize) {
 			return fmt.Errorf("file length not same")
 		}
-		hash, err := util_hash.Sha1File(path)
+		ok, err := util_hash.VerifyReaderKey(blockHash, file)
 		if err != nil {
-			return fmt.Errorf("sha1 sum file error: %s", err)
+			return fmt.Errorf("hash sum file error: %s", err)
 		}
-		if !bytes.Equal(hash, blockHash) {
+		if !ok {
 			return fmt.Errorf("hash verify failed")
 		}
-		return provider_client.Store(psc, path, oppositeInfo.Auth, timestamp, oppositeInfo.Ticket, fileHash, fileSize, blockHash, blockSize)
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("seek file failed, error: %s", err)
+		}
+		return provider_client.StoreReader(psc, file, oppositeInfo.Auth, timestamp, oppositeInfo.Ticket, fileHash, fileSize, blockHash, blockSize, tag)
 	}
 }
 
-func (self *ProviderService) taskReplicate(fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64, timestamp uint64, oppositeInfo []*ttpb.OppositeInfo) (err error) {
+func (self *ProviderService) taskReplicate(fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64, timestamp uint64, oppositeInfo []*ttpb.OppositeInfo, record *pb.BlockTag) (err error) {
 	found, smallFile, storageIdx, subPath := self.querySubPath(blockHash)
+	if !found {
+		if revived, er := self.revive(blockHash); revived {
+			found, smallFile, storageIdx, subPath = self.querySubPath(blockHash)
+		} else if er != nil {
+			log.Warnf("revive failed, blockKey: %x error: %s", blockHash, er)
+		}
+	}
 	var storage *config.Storage
 	if found {
 		if smallFile {
-			storage := config.GetStorage(storageIdx)
-			data, er := storage.SmallFileDb.Get(blockHash, nil)
+			storage := self.storages.GetStorage(storageIdx)
+			data, er := self.getSmallFile(storage, blockHash)
 			if er != nil {
 				return fmt.Errorf("read small file error, error: %s", er)
 			}
-			if len(data) == int(blockSize) && bytes.Equal(util_hash.Sha1(data), blockHash) {
+			if len(data) == int(blockSize) && util_hash.VerifyKey(blockHash, data) {
+				self.saveTag(blockHash, blockSize, record)
 				return nil
 			}
 		} else {
-			path := config.GetStoragePath(storageIdx, subPath)
-			fileInfo, er := os.Stat(path)
+			path := self.storages.GetStoragePath(storageIdx, subPath)
+			file, er := self.openBlockFile(path)
 			if er != nil {
-				return fmt.Errorf("stat file failed, error: %s", er)
+				return fmt.Errorf("open file failed, error: %s", er)
 			}
-			if fileInfo.Size() == int64(blockSize) {
-				hash, err := util_hash.Sha1File(path)
+			size := file.Size()
+			file.Close()
+			if size == int64(blockSize) {
+				ok, err := self.verifyBlockFile(blockHash, path)
 				if err != nil {
-					return fmt.Errorf("sha1 sum file error: %s", err)
+					return fmt.Errorf("hash sum file error: %s", err)
 				}
-				if bytes.Equal(hash, blockHash) {
+				if ok {
+					self.saveTag(blockHash, blockSize, record)
 					return nil
 				}
 			}
 		}
-		storage = config.GetStorage(storageIdx)
+		storage = self.storages.GetStorage(storageIdx)
 	} else {
-		storage = config.GetWriteStorage(blockSize)
+		storage = self.storages.GetWriteStorage(blockSize)
 	}
 	smallFile = (blockSize < small_file_limit)
 	providers := testPing(oppositeInfo)
@@ -960,7 +1113,7 @@ func (self *ProviderService) taskReplicate(fileHash []byte, fileSize uint64, blo
 		defer conn.Close()
 		psc := pb.NewProviderServiceClient(conn)
 		if smallFile {
-			data, err := provider_client.RetrieveSmall(psc, pro.Auth, timestamp, pro.Ticket, fileHash, fileSize, blockHash, blockSize)
+			data, tag, err := provider_client.RetrieveSmall(psc, pro.Auth, timestamp, pro.Ticket, fileHash, fileSize, blockHash, blockSize)
 			if err != nil {
 				errs = append(errs, fmt.Errorf("retrieve small file failed, provider id: %s, block key: %x error: %s", pro.NodeId, blockHash, err))
 				continue
@@ -969,16 +1122,18 @@ func (self *ProviderService) taskReplicate(fileHash []byte, fileSize uint64, blo
 				errs = append(errs, fmt.Errorf("file length wrong, provider id: %s, block key: %x", pro.NodeId, blockHash))
 				continue
 			}
-			if !bytes.Equal(blockHash, util_hash.Sha1(data)) {
+			if !util_hash.VerifyKey(blockHash, data) {
 				errs = append(errs, fmt.Errorf("check data hash failed, provider id: %s, block key: %x", pro.NodeId, blockHash))
 				continue
 			}
-			if err = storage.SmallFileDb.Put(blockHash, data, nil); err != nil {
+			if err = self.putSmallFile(storage, blockHash, data); err != nil {
 				return fmt.Errorf("save to small file db failed, error: %s", err)
 			}
 			if err = self.providerDb.Put(blockHash, []byte{storage.Index}, nil); err != nil {
 				return fmt.Errorf("save to provider db failed, error: %s", err)
 			}
+			self.saveMerkleLeaves(blockHash, merkle.Leaves(data, merkle.DefaultChunkSize))
+			self.saveTag(blockHash, blockSize, pickTag(record, tag, blockSize))
 		} else {
 			tempFilePath := storage.TempFilePath(blockHash)
 			file, err := os.OpenFile(
@@ -989,7 +1144,8 @@ func (self *ProviderService) taskReplicate(fileHash []byte, fileSize uint64, blo
 				return fmt.Errorf("open temp write file failed, error: %s", err)
 			}
 			defer file.Close()
-			if err = provider_client.Retrieve(psc, tempFilePath, pro.Auth, timestamp, pro.Ticket, fileHash, fileSize, blockHash, blockSize); err != nil {
+			tag, err := provider_client.Retrieve(psc, tempFilePath, pro.Auth, timestamp, pro.Ticket, fileHash, fileSize, blockHash, blockSize)
+			if err != nil {
 				errs = append(errs, fmt.Errorf("retrieve file failed, provider id: %s, block key: %x error: %s", pro.NodeId, blockHash, err))
 				continue
 			}
@@ -1001,11 +1157,11 @@ func (self *ProviderService) taskReplicate(fileHash []byte, fileSize uint64, blo
 				errs = append(errs, fmt.Errorf("file length wrong, provider id: %s, block key: %x", pro.NodeId, blockHash))
 				continue
 			}
-			hash, err := util_hash.Sha1File(tempFilePath)
+			ok, err := util_hash.VerifyFileKey(blockHash, tempFilePath)
 			if err != nil {
-				return fmt.Errorf("sha1 sum file %s failed, error: %s", tempFilePath, err)
+				return fmt.Errorf("hash sum file %s failed, error: %s", tempFilePath, err)
 			}
-			if !bytes.Equal(hash, blockHash) {
+			if !ok {
 				errs = append(errs, fmt.Errorf("check data hash failed, provider id: %s, block key: %x", pro.NodeId, blockHash))
 				continue
 			}
@@ -1013,7 +1169,7 @@ func (self *ProviderService) taskReplicate(fileHash []byte, fileSize uint64, blo
 				return fmt.Errorf("close temp file failed, tempFilePath: %s error: %s", tempFilePath, err)
 			}
 			if found {
-				path := config.GetStoragePath(storageIdx, subPath)
+				path := self.storages.GetStoragePath(storageIdx, subPath)
 				if err = os.Remove(path); err != nil {
 					return fmt.Errorf("remove old file failed, path: %s error: %s", path, err)
 				}
@@ -1021,6 +1177,7 @@ func (self *ProviderService) taskReplicate(fileHash []byte, fileSize uint64, blo
 			if err := self.saveFile(blockHash, blockSize, tempFilePath, storage); err != nil {
 				return fmt.Errorf("save file failed, tempFilePath: %s error: %s", tempFilePath, err)
 			}
+			self.saveTag(blockHash, blockSize, pickTag(record, tag, blockSize))
 		}
 		return nil
 	}
@@ -1035,7 +1192,7 @@ func (self *ProviderService) taskReplicate(fileHash []byte, fileSize uint64, blo
 	if len(errMsg) == 0 {
 		errMsg = "all opposite provider ping timeout"
 	}
-	return fmt.Errorf(errMsg)
+	return errors.New(errMsg)
 }
 
 type OppositeProvider struct {
@@ -1073,6 +1230,38 @@ func (self *ProviderService) VerifyBlocks() {
 	} else {
 		return
 	}
+	self.verifyBlocksBy(self.verifyBlock)
+}
+
+// reportFailedStorage report blocks indexed to failed storage as miss by VerifyBlocks, so tracker starts repair in minutes.
+// only index is checked, blocks on other storages are not read.
+func (self *ProviderService) reportFailedStorage(index byte) {
+	fmt.Printf("storage %d failed, report missing blocks\n", index)
+	for i := uint(0); ; i++ {
+		self.blocksVerifying.Lock()
+		err := self.verifyBlocksBy(func(hash []byte, size uint64) bool {
+			found, _, _, _ := self.querySubPath(hash)
+			return found
+		})
+		self.blocksVerifying.UnLock()
+		if err == nil {
+			return
+		}
+		wait := time.Duration(1<<i) * time.Minute
+		if wait > 30*time.Minute {
+			wait = 30 * time.Minute
+		}
+		select {
+		case <-self.shutdownSignal:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// verifyBlocksBy page through blocks of this provider in tracker and report blocks failed to verify as miss,
+// it returns nil if finished or shutdown
+func (self *ProviderService) verifyBlocksBy(verify func(hash []byte, size uint64) bool) error {
 	query := true
 	var previous, last uint64
 	var miss, blocks []*ttpb.HashAndSize
@@ -1083,9 +1272,9 @@ func (self *ProviderService) VerifyBlocks() {
 		for i := 1; i < 4; i++ {
 			select {
 			case <-self.shutdownSignal:
-				return
+				return nil
 			default:
-				last, blocks, respHasNext, err = task_client.VerifyBlocks(self.ptsc, query, previous, miss)
+				last, blocks, respHasNext, err = task_client.VerifyBlocks(self.ptsc, self.node, query, previous, miss)
 				// fmt.Printf("i: %d, req query: %t, previous: %d, miss count: %d, resp last: %d, blocks count: %d, respHasNext: %t, err: %s\n", i, query, previous, len(miss), last, len(blocks), respHasNext, err)
 				if err != nil {
 					fmt.Printf("verifyBlocks %d times get data from task server error: %s\n", i, err)
@@ -1098,14 +1287,14 @@ func (self *ProviderService) VerifyBlocks() {
 		}
 		if err != nil {
 			fmt.Printf("verifyBlocks reach the maximum number of retries and terminate\n")
-			return
+			return err
 		}
 		if !hasNext {
 			query = false
 		}
 		if len(blocks) == 0 {
 			fmt.Printf("VerifyBlocks finished, last: %d, previous miss: %d, current timestamp: %d\n", previous, len(miss), time.Now().Unix())
-			return
+			return nil
 		} else {
 			fmt.Printf("VerifyBlocks get %d blocks, last: %d, previous miss: %d, current timestamp: %d\n", len(blocks), previous, len(miss), time.Now().Unix())
 		}
@@ -1113,9 +1302,9 @@ func (self *ProviderService) VerifyBlocks() {
 		for _, block := range blocks {
 			select {
 			case <-self.shutdownSignal:
-				return
+				return nil
 			default:
-				if !self.verifyBlock(block.Hash, block.Size) {
+				if !verify(block.Hash, block.Size) {
 					miss = append(miss, block)
 				}
 			}
@@ -1129,15 +1318,15 @@ func (self *ProviderService) verifyBlock(hash []byte, size uint64) bool {
 		return false
 	}
 	if smallFile {
-		storage := config.GetStorage(storageIdx)
+		storage := self.storages.GetStorage(storageIdx)
 		if storage == nil {
 			return false
 		}
-		data, err := storage.SmallFileDb.Get(hash, nil)
-		return err == nil && len(data) > 0 && bytes.Equal(hash, util_hash.Sha1(data))
+		data, err := self.getSmallFile(storage, hash)
+		return err == nil && len(data) > 0 && util_hash.VerifyKey(hash, data)
 	} else {
-		path := config.GetStoragePath(storageIdx, subPath)
-		sum, err := util_hash.Sha1File(path)
-		return err == nil && len(sum) > 0 && bytes.Equal(hash, sum)
+		path := self.storages.GetStoragePath(storageIdx, subPath)
+		ok, err := self.verifyBlockFile(hash, path)
+		return err == nil && ok
 	}
 }
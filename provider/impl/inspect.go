@@ -0,0 +1,106 @@
+package impl
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/samoslab/nebula/provider/atrest"
+	util_hash "github.com/samoslab/nebula/util/hash"
+)
+
+const sealed_header_peek = 64
+
+var BlockNotFoundErr = errors.New("block is neither in provider db nor in trash")
+
+// BlockInfo is where a block is kept, for inspection
+type BlockInfo struct {
+	Key           []byte
+	Entry         []byte // raw value in provider db, or location in tombstone if in trash
+	StorageIndex  byte
+	SmallFile     bool
+	Path          string // full path of large block file
+	StoredSize    int64  // size in storage, sealed size if sealed, -1 if not readable
+	SealVersion   string // key version of at-rest sealing, empty if plaintext
+	StorageFailed bool
+	Trash         *Tombstone // not nil if block is in trash
+}
+
+func (self *ProviderService) inspectBlock(key []byte) (*BlockInfo, error) {
+	info := &BlockInfo{Key: key, StoredSize: -1}
+	info.Entry = self.queryByKey(key)
+	if len(info.Entry) == 0 {
+		if info.Trash = self.getTombstone(key); info.Trash == nil {
+			return nil, BlockNotFoundErr
+		}
+		info.Entry = info.Trash.location
+	}
+	info.StorageIndex, info.SmallFile = info.Entry[0], len(info.Entry) == 1
+	storage := self.storages.GetStorage(info.StorageIndex)
+	if storage == nil || storage.Failed() {
+		info.StorageFailed = true
+		return info, nil
+	}
+	if info.SmallFile {
+		if data, err := storage.SmallFileDb.Get(key, nil); err == nil {
+			info.StoredSize, info.SealVersion = int64(len(data)), atrest.SealedVersion(data)
+		}
+		return info, nil
+	}
+	if info.Trash != nil {
+		info.Path, _ = self.trashFilePath(info.StorageIndex, key)
+	} else {
+		info.Path = self.storages.GetStoragePath(info.StorageIndex, string(info.Entry[1:]))
+	}
+	file, err := os.Open(info.Path)
+	if err != nil {
+		return info, nil
+	}
+	defer file.Close()
+	if fileInfo, err := file.Stat(); err == nil {
+		info.StoredSize = fileInfo.Size()
+	}
+	buf := make([]byte, sealed_header_peek)
+	n, _ := io.ReadFull(file, buf)
+	info.SealVersion = atrest.SealedVersion(buf[:n])
+	return info, nil
+}
+
+// verifyBlockHash check hash of plaintext, block in trash is verified too
+func (self *ProviderService) verifyBlockHash(key []byte) (bool, error) {
+	info, err := self.inspectBlock(key)
+	if err != nil {
+		return false, err
+	}
+	if info.StorageFailed {
+		return false, errors.New("storage of block failed")
+	}
+	if !info.SmallFile {
+		return self.verifyBlockFile(key, info.Path)
+	}
+	data, err := self.getSmallFile(self.storages.GetStorage(info.StorageIndex), key)
+	if err != nil {
+		return false, err
+	}
+	return util_hash.VerifyKey(key, data), nil
+}
+
+// InspectBlock locate block in storages, daemon must be stopped
+func InspectBlock(key []byte) (*BlockInfo, error) {
+	ps, err := newOfflineProviderService()
+	if err != nil {
+		return nil, err
+	}
+	defer ps.closeOffline()
+	return ps.inspectBlock(key)
+}
+
+// VerifyBlock check hash of block, daemon must be stopped
+func VerifyBlock(key []byte) (bool, error) {
+	ps, err := newOfflineProviderService()
+	if err != nil {
+		return false, err
+	}
+	defer ps.closeOffline()
+	return ps.verifyBlockHash(key)
+}
@@ -0,0 +1,132 @@
+package impl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samoslab/nebula/provider/atrest"
+	"github.com/samoslab/nebula/provider/config"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// mainStorages is a single main storage
+type mainStorages struct {
+	main *config.Storage
+}
+
+func (self mainStorages) GetStorage(index byte) *config.Storage {
+	if index != 0 {
+		return nil
+	}
+	return self.main
+}
+
+func (self mainStorages) GetStoragePath(index byte, subPath string) string {
+	return self.main.Path + filepath.FromSlash(subPath)
+}
+
+func (self mainStorages) GetWriteStorage(size uint64) *config.Storage {
+	return self.main
+}
+
+func (self mainStorages) AvailableVolume() (total uint64, max uint64) {
+	return self.main.Volume, self.main.Volume
+}
+
+func (self mainStorages) RecordIOErrorOfPath(path string, err error) {
+}
+
+func newInspectTestService(t *testing.T, dir string) *ProviderService {
+	storage, err := config.NewStorage(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := &ProviderService{storages: mainStorages{storage}}
+	if ps.cipher, err = atrest.NewCipher(map[string][]byte{"1": []byte("secret")}); err != nil {
+		t.Fatal(err)
+	}
+	for _, db := range []struct {
+		name string
+		db   **leveldb.DB
+	}{{config.ProviderDbName, &ps.providerDb}, {config.MerkleDbName, &ps.merkleDb}, {config.TagDbName, &ps.tagDb}, {config.TrashDbName, &ps.trashDb}} {
+		if *db.db, err = leveldb.OpenFile(storage.DbPath(db.name), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ps
+}
+
+func TestInspectBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspect-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ps := newInspectTestService(t, dir)
+	defer ps.Close()
+	defer ps.storages.GetStorage(0).SmallFileDb.Close()
+
+	small := []byte("small block")
+	smallKey := util_hash.Sha1(small)
+	if err = ps.putSmallFile(ps.storages.GetStorage(0), smallKey, small); err != nil {
+		t.Fatal(err)
+	}
+	if err = ps.providerDb.Put(smallKey, []byte{0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	info, err := ps.inspectBlock(smallKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.SmallFile || info.StorageIndex != 0 || info.StoredSize != int64(len(small)) || info.SealVersion != "" || info.Trash != nil {
+		t.Errorf("wrong small block info: %+v", info)
+	}
+	if ok, err := ps.verifyBlockHash(smallKey); err != nil || !ok {
+		t.Errorf("small block should be verified: %s", err)
+	}
+
+	large := bytes.Repeat([]byte("large block "), 1024)
+	largeKey := util_hash.Sha1(large)
+	tmp := filepath.Join(dir, "large.tmp")
+	if err = ioutil.WriteFile(tmp, large, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ps.saveFile(largeKey, uint64(len(large)), tmp, ps.storages.GetStorage(0)); err != nil {
+		t.Fatal(err)
+	}
+	info, err = ps.inspectBlock(largeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, _ := config.SubPath(largeKey)
+	if info.SmallFile || string(info.Entry[1:]) != sub || info.Path != dir+filepath.FromSlash(sub) || info.StoredSize != int64(len(large)) {
+		t.Errorf("wrong large block info: %+v", info)
+	}
+	if ok, err := ps.verifyBlockHash(largeKey); err != nil || !ok {
+		t.Errorf("large block should be verified: %s", err)
+	}
+
+	if err = ps.trashBlock(largeKey, uint64(len(large))); err != nil {
+		t.Fatal(err)
+	}
+	info, err = ps.inspectBlock(largeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Trash == nil || info.StoredSize != int64(len(large)) {
+		t.Errorf("block should be found in trash: %+v", info)
+	}
+	if err = ioutil.WriteFile(info.Path, small, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := ps.verifyBlockHash(largeKey); ok {
+		t.Errorf("changed block should not be verified")
+	}
+	if _, err = ps.inspectBlock([]byte("missing block key000")); err != BlockNotFoundErr {
+		t.Errorf("missing block should not be found: %v", err)
+	}
+}
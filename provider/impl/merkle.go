@@ -0,0 +1,126 @@
+package impl
+
+import (
+	"io"
+
+	pb "github.com/samoslab/nebula/provider/pb"
+	"github.com/samoslab/nebula/util/merkle"
+	log "github.com/sirupsen/logrus"
+	leveldb_errors "github.com/syndtr/goleveldb/leveldb/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (self *ProviderService) saveMerkleLeaves(key []byte, leaves [][]byte) {
+	if err := self.merkleDb.Put(key, merkle.Pack(leaves), nil); err != nil {
+		log.Warnf("save merkle leaves failed, key: %x error: %s", key, err)
+	}
+}
+
+func (self *ProviderService) deleteMerkleLeaves(key []byte) {
+	if err := self.merkleDb.Delete(key, nil); err != nil {
+		log.Warnf("delete merkle leaves failed, key: %x error: %s", key, err)
+	}
+}
+
+// getMerkleLeaves read leaves from merkle db, blocks stored before merkle db existed are computed and saved on first use
+func (self *ProviderService) getMerkleLeaves(key []byte, smallFile bool, storageIdx byte, subPath string) ([][]byte, error) {
+	val, err := self.merkleDb.Get(key, nil)
+	if err == nil {
+		return merkle.Unpack(val)
+	} else if err != leveldb_errors.ErrNotFound {
+		return nil, err
+	}
+	var leaves [][]byte
+	if smallFile {
+		data, err := self.getSmallFile(self.storages.GetStorage(storageIdx), key)
+		if err != nil {
+			return nil, err
+		}
+		leaves = merkle.Leaves(data, merkle.DefaultChunkSize)
+	} else {
+		file, err := self.openBlockFile(self.storages.GetStoragePath(storageIdx, subPath))
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		leaves, err = merkle.LeavesOfReader(file, merkle.DefaultChunkSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+	self.saveMerkleLeaves(key, leaves)
+	return leaves, nil
+}
+
+func (self *ProviderService) GetChunkProof(ctx context.Context, req *pb.ChunkProofReq) (resp *pb.ChunkProofResp, err error) {
+	if !skip_check_auth {
+		if err = req.CheckAuth(self.node.PubKeyBytes); err != nil {
+			err = status.Errorf(codes.Unauthenticated, "check auth failed, blockKey: %x error: %s", req.BlockKey, err)
+			log.Warnln(err)
+			return
+		}
+	}
+	found, smallFile, storageIdx, subPath := self.querySubPath(req.BlockKey)
+	if !found {
+		err = status.Errorf(codes.NotFound, "file not exist, blockKey: %x", req.BlockKey)
+		log.Warnln(err)
+		return
+	}
+	leaves, er := self.getMerkleLeaves(req.BlockKey, smallFile, storageIdx, subPath)
+	if er != nil {
+		err = status.Errorf(codes.Internal, "read merkle leaves failed, blockKey: %x error: %s", req.BlockKey, er)
+		log.Warnln(err)
+		return
+	}
+	path, er := merkle.Path(leaves, req.ChunkIndex)
+	if er != nil {
+		err = status.Errorf(codes.OutOfRange, "chunk index %d out of range, chunk count: %d, blockKey: %x", req.ChunkIndex, len(leaves), req.BlockKey)
+		log.Warnln(err)
+		return
+	}
+	data, er := self.readChunk(req.BlockKey, smallFile, storageIdx, subPath, req.ChunkIndex)
+	if er != nil {
+		err = status.Errorf(codes.Internal, "read chunk failed, blockKey: %x error: %s", req.BlockKey, er)
+		log.Warnln(err)
+		return
+	}
+	return &pb.ChunkProofResp{Data: data,
+		ChunkSize:  merkle.DefaultChunkSize,
+		ChunkCount: uint32(len(leaves)),
+		Path:       path,
+		Root:       merkle.Root(leaves)}, nil
+}
+
+func (self *ProviderService) readChunk(key []byte, smallFile bool, storageIdx byte, subPath string, index uint32) ([]byte, error) {
+	start := int64(index) * merkle.DefaultChunkSize
+	if smallFile {
+		data, err := self.getSmallFile(self.storages.GetStorage(storageIdx), key)
+		if err != nil {
+			return nil, err
+		}
+		if start > int64(len(data)) {
+			return nil, merkle.OutOfRangeErr
+		}
+		end := start + merkle.DefaultChunkSize
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		return data[start:end], nil
+	}
+	file, err := self.openBlockFile(self.storages.GetStoragePath(storageIdx, subPath))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err = file.Seek(start, 0); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, merkle.DefaultChunkSize)
+	bytesRead, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:bytesRead], nil
+}
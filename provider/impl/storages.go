@@ -0,0 +1,38 @@
+package impl
+
+import (
+	"github.com/samoslab/nebula/provider/config"
+)
+
+// Storages is where blocks and dbs of a provider are kept
+type Storages interface {
+	GetStorage(index byte) *config.Storage
+	GetStoragePath(index byte, subPath string) string
+	// GetWriteStorage return nil if no storage has enough space
+	GetWriteStorage(size uint64) *config.Storage
+	AvailableVolume() (total uint64, max uint64)
+	RecordIOErrorOfPath(path string, err error)
+}
+
+// configStorages are storages of provider config, dbs are in main storage
+type configStorages struct{}
+
+func (self configStorages) GetStorage(index byte) *config.Storage {
+	return config.GetStorage(index)
+}
+
+func (self configStorages) GetStoragePath(index byte, subPath string) string {
+	return config.GetStoragePath(index, subPath)
+}
+
+func (self configStorages) GetWriteStorage(size uint64) *config.Storage {
+	return config.GetWriteStorage(size)
+}
+
+func (self configStorages) AvailableVolume() (total uint64, max uint64) {
+	return config.AvailableVolume()
+}
+
+func (self configStorages) RecordIOErrorOfPath(path string, err error) {
+	config.RecordIOErrorOfPath(path, err)
+}
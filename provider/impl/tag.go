@@ -0,0 +1,67 @@
+package impl
+
+import (
+	"github.com/golang/protobuf/proto"
+	pb "github.com/samoslab/nebula/provider/pb"
+	ttpb "github.com/samoslab/nebula/tracker/task/pb"
+	log "github.com/sirupsen/logrus"
+	leveldb_errors "github.com/syndtr/goleveldb/leveldb/errors"
+)
+
+// saveTag keep pairing tags of block for prove, incomplete tag is ignored
+func (self *ProviderService) saveTag(key []byte, blockSize uint64, tag *pb.BlockTag) {
+	if tag == nil {
+		return
+	}
+	if !tag.Complete(blockSize) {
+		log.Warnf("ignore incomplete tag, key: %x blockSize: %d chunkSize: %d phi count: %d", key, blockSize, tag.ChunkSize, len(tag.Phi))
+		return
+	}
+	b, err := proto.Marshal(tag)
+	if err != nil {
+		log.Warnf("marshal tag failed, key: %x error: %s", key, err)
+		return
+	}
+	if err = self.tagDb.Put(key, b, nil); err != nil {
+		log.Warnf("save tag failed, key: %x error: %s", key, err)
+	}
+}
+
+func (self *ProviderService) getTag(key []byte) *pb.BlockTag {
+	b, err := self.tagDb.Get(key, nil)
+	if err != nil {
+		if err != leveldb_errors.ErrNotFound {
+			log.Errorf("get tag %x from tag db error: %s", key, err)
+		}
+		return nil
+	}
+	tag := &pb.BlockTag{}
+	if err = proto.Unmarshal(b, tag); err != nil {
+		log.Errorf("unmarshal tag %x error: %s", key, err)
+		return nil
+	}
+	return tag
+}
+
+func (self *ProviderService) deleteTag(key []byte) {
+	if err := self.tagDb.Delete(key, nil); err != nil {
+		log.Warnf("delete tag failed, key: %x error: %s", key, err)
+	}
+}
+
+// recordTag tag of block in file record given by tracker with REPLICATE or SEND task, nil if block is not tagged
+func recordTag(resp *ttpb.GetOppositeInfoResp) *pb.BlockTag {
+	if len(resp.Phi) == 0 {
+		return nil
+	}
+	return &pb.BlockTag{ParamStr: resp.ParamStr, ChunkSize: resp.ChunkSize, Phi: resp.Phi}
+}
+
+// pickTag prefer tag of file record, tracker challenges block with its chunk size, held tag may be missing if
+// block came from a provider without tag
+func pickTag(record *pb.BlockTag, held *pb.BlockTag, blockSize uint64) *pb.BlockTag {
+	if record.Complete(blockSize) {
+		return record
+	}
+	return held
+}
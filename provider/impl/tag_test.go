@@ -0,0 +1,25 @@
+package impl
+
+import (
+	"testing"
+
+	pb "github.com/samoslab/nebula/provider/pb"
+	ttpb "github.com/samoslab/nebula/tracker/task/pb"
+)
+
+func TestPickTag(t *testing.T) {
+	if recordTag(&ttpb.GetOppositeInfoResp{}) != nil {
+		t.Errorf("untagged block should have no record tag")
+	}
+	record := recordTag(&ttpb.GetOppositeInfoResp{ChunkSize: 1024, ParamStr: "param", Phi: [][]byte{{1}, {2}}})
+	held := &pb.BlockTag{ParamStr: "param", ChunkSize: 2048, Phi: [][]byte{{3}}}
+	if pickTag(record, held, 2048) != record {
+		t.Errorf("tag of file record should be preferred")
+	}
+	if pickTag(nil, held, 2048) != held {
+		t.Errorf("held tag should be kept without record tag")
+	}
+	if pickTag(record, nil, 4096) != nil {
+		t.Errorf("incomplete record tag should not be picked")
+	}
+}
@@ -0,0 +1,251 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/samoslab/nebula/provider/config"
+	util_bytes "github.com/samoslab/nebula/util/bytes"
+	log "github.com/sirupsen/logrus"
+	leveldb_errors "github.com/syndtr/goleveldb/leveldb/errors"
+)
+
+const tombstone_header_len = 16
+
+var NotInTrashErr = errors.New("block is not in trash")
+
+// Tombstone is removed block waiting to be purged, location is the provider db value before remove
+type Tombstone struct {
+	Key        []byte
+	RemoveTime time.Time
+	BlockSize  uint64
+	location   []byte
+}
+
+func (self *Tombstone) SmallFile() bool {
+	return len(self.location) == 1
+}
+
+func (self *Tombstone) StorageIndex() byte {
+	return self.location[0]
+}
+
+func (self *Tombstone) PurgeTime() time.Time {
+	return self.RemoveTime.Add(config.RemoveGracePeriod())
+}
+
+func (self *Tombstone) subPath() string {
+	return string(self.location[1:])
+}
+
+func (self *Tombstone) encode() []byte {
+	b := make([]byte, tombstone_header_len+len(self.location))
+	copy(b, util_bytes.FromUint64(uint64(self.RemoveTime.Unix())))
+	copy(b[8:], util_bytes.FromUint64(self.BlockSize))
+	copy(b[tombstone_header_len:], self.location)
+	return b
+}
+
+func decodeTombstone(key []byte, b []byte) (*Tombstone, error) {
+	if len(b) <= tombstone_header_len {
+		return nil, fmt.Errorf("invalid tombstone, key: %x", key)
+	}
+	return &Tombstone{Key: key,
+		RemoveTime: time.Unix(int64(util_bytes.ToUint64(b, 0)), 0),
+		BlockSize:  util_bytes.ToUint64(b, 8),
+		location:   append([]byte{}, b[tombstone_header_len:]...)}, nil
+}
+
+func (self *ProviderService) getTombstone(key []byte) *Tombstone {
+	b, err := self.trashDb.Get(key, nil)
+	if err != nil {
+		if err != leveldb_errors.ErrNotFound {
+			log.Errorf("get %x from trash db error: %s", key, err)
+		}
+		return nil
+	}
+	t, err := decodeTombstone(key, b)
+	if err != nil {
+		log.Errorln(err)
+		return nil
+	}
+	return t
+}
+
+func (self *ProviderService) trashFilePath(storageIdx byte, key []byte) (string, error) {
+	storage := self.storages.GetStorage(storageIdx)
+	if storage == nil {
+		return "", fmt.Errorf("storage %d not available", storageIdx)
+	}
+	return self.storages.GetStoragePath(storageIdx, storage.TrashSubPath(key)), nil
+}
+
+// trashBlock write tombstone and move block file into trash, merkle leaves and tags are kept for revive
+func (self *ProviderService) trashBlock(key []byte, blockSize uint64) error {
+	location := self.queryByKey(key)
+	if len(location) == 0 {
+		return nil
+	}
+	t := &Tombstone{Key: key, RemoveTime: time.Now(), BlockSize: blockSize, location: location}
+	if err := self.trashDb.Put(key, t.encode(), nil); err != nil {
+		return fmt.Errorf("save to trash db failed, error: %s", err)
+	}
+	if err := self.providerDb.Delete(key, nil); err != nil {
+		return fmt.Errorf("delete from provider db failed, error: %s", err)
+	}
+	if t.SmallFile() {
+		return nil
+	}
+	trashPath, err := self.trashFilePath(t.StorageIndex(), key)
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(self.storages.GetStoragePath(t.StorageIndex(), t.subPath()), trashPath); err != nil {
+		return fmt.Errorf("move file to trash failed, error: %s", err)
+	}
+	return nil
+}
+
+// revive restore tombstoned block instead of receiving it again, tombstone whose data is lost is dropped
+func (self *ProviderService) revive(key []byte) (bool, error) {
+	t := self.getTombstone(key)
+	if t == nil {
+		return false, nil
+	}
+	if err := self.restore(t); err != nil {
+		if er := self.trashDb.Delete(key, nil); er != nil {
+			log.Warnf("delete tombstone failed, key: %x error: %s", key, er)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (self *ProviderService) restore(t *Tombstone) error {
+	if t.SmallFile() {
+		storage := self.storages.GetStorage(t.StorageIndex())
+		if storage == nil {
+			return fmt.Errorf("storage %d not available", t.StorageIndex())
+		}
+		if _, err := storage.SmallFileDb.Get(t.Key, nil); err != nil {
+			return fmt.Errorf("read small file error, error: %s", err)
+		}
+	} else {
+		trashPath, err := self.trashFilePath(t.StorageIndex(), t.Key)
+		if err != nil {
+			return err
+		}
+		path := self.storages.GetStoragePath(t.StorageIndex(), t.subPath())
+		if err = os.Rename(trashPath, path); err != nil {
+			if _, er := os.Stat(path); er != nil {
+				return fmt.Errorf("move file from trash failed, error: %s", err)
+			}
+		}
+	}
+	if err := self.providerDb.Put(t.Key, t.location, nil); err != nil {
+		return fmt.Errorf("save to provider db failed, error: %s", err)
+	}
+	if err := self.trashDb.Delete(t.Key, nil); err != nil {
+		return fmt.Errorf("delete from trash db failed, error: %s", err)
+	}
+	return nil
+}
+
+// PurgeTrash delete blocks whose grace period is over
+func (self *ProviderService) PurgeTrash() {
+	if self.trashPurging.TryLock() {
+		defer self.trashPurging.UnLock()
+	} else {
+		return
+	}
+	now := time.Now()
+	purged := 0
+	iter := self.trashDb.NewIterator(nil, nil)
+	for iter.Next() {
+		key := append([]byte{}, iter.Key()...)
+		t, err := decodeTombstone(key, iter.Value())
+		if err != nil {
+			log.Warnln(err)
+			continue
+		}
+		if t.PurgeTime().After(now) {
+			continue
+		}
+		if err = self.purge(t); err != nil {
+			log.Warnf("purge block failed, key: %x error: %s", key, err)
+			continue
+		}
+		purged++
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		log.Errorf("iterate trash db error: %s", err)
+	}
+	if purged > 0 {
+		log.Infof("purged %d blocks from trash", purged)
+	}
+}
+
+func (self *ProviderService) purge(t *Tombstone) error {
+	if len(self.queryByKey(t.Key)) == 0 {
+		if t.SmallFile() {
+			if storage := self.storages.GetStorage(t.StorageIndex()); storage != nil {
+				if err := storage.SmallFileDb.Delete(t.Key, nil); err != nil {
+					return fmt.Errorf("delete from small file db failed, error: %s", err)
+				}
+			}
+		} else {
+			trashPath, err := self.trashFilePath(t.StorageIndex(), t.Key)
+			if err != nil {
+				return err
+			}
+			if err = os.Remove(trashPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove file failed, error: %s", err)
+			}
+			// file was not moved if it crashed during remove
+			if err = os.Remove(self.storages.GetStoragePath(t.StorageIndex(), t.subPath())); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove file failed, error: %s", err)
+			}
+		}
+		self.deleteMerkleLeaves(t.Key)
+		self.deleteTag(t.Key)
+	}
+	return self.trashDb.Delete(t.Key, nil)
+}
+
+// ListTrash list tombstoned blocks, daemon must be stopped
+func ListTrash() ([]*Tombstone, error) {
+	ps, err := newOfflineProviderService()
+	if err != nil {
+		return nil, err
+	}
+	defer ps.closeOffline()
+	res := make([]*Tombstone, 0, 16)
+	iter := ps.trashDb.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		t, err := decodeTombstone(append([]byte{}, iter.Key()...), iter.Value())
+		if err != nil {
+			log.Warnln(err)
+			continue
+		}
+		res = append(res, t)
+	}
+	return res, iter.Error()
+}
+
+// RestoreTrash restore tombstoned block, daemon must be stopped
+func RestoreTrash(key []byte) error {
+	ps, err := newOfflineProviderService()
+	if err != nil {
+		return err
+	}
+	defer ps.closeOffline()
+	t := ps.getTombstone(key)
+	if t == nil {
+		return NotInTrashErr
+	}
+	return ps.restore(t)
+}
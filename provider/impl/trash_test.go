@@ -0,0 +1,230 @@
+package impl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gosync "github.com/lrita/gosync"
+	"github.com/samoslab/nebula/provider/config"
+	pb "github.com/samoslab/nebula/provider/pb"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	"golang.org/x/net/context"
+)
+
+func TestTombstone(t *testing.T) {
+	for _, location := range [][]byte{{2}, append([]byte{1}, "/0001/0002/abcd.blk"...)} {
+		ts := &Tombstone{Key: []byte{1, 2, 3}, RemoveTime: time.Unix(time.Now().Unix(), 0), BlockSize: 123456789, location: location}
+		d, err := decodeTombstone(ts.Key, ts.encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !d.RemoveTime.Equal(ts.RemoveTime) || d.BlockSize != ts.BlockSize || !bytes.Equal(d.location, location) {
+			t.Errorf("decode tombstone not same")
+		}
+		if d.SmallFile() != (len(location) == 1) || d.StorageIndex() != location[0] {
+			t.Errorf("wrong location")
+		}
+	}
+	if _, err := decodeTombstone(nil, make([]byte, tombstone_header_len)); err == nil {
+		t.Errorf("tombstone without location should be invalid")
+	}
+}
+
+func newTrashTestService(t *testing.T) (*ProviderService, func()) {
+	dir, err := ioutil.TempDir("", "trash-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := newInspectTestService(t, dir)
+	ps.trashPurging = gosync.NewMutex()
+	return ps, func() {
+		ps.storages.GetStorage(0).SmallFileDb.Close()
+		ps.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// storeTestBlocks store a small and a large block with merkle leaves
+func storeTestBlocks(t *testing.T, ps *ProviderService) (smallKey []byte, largeKey []byte) {
+	storage := ps.storages.GetStorage(0)
+	small := []byte("small block")
+	smallKey = util_hash.Sha1(small)
+	if err := ps.putSmallFile(storage, smallKey, small); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.providerDb.Put(smallKey, []byte{0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	large := bytes.Repeat([]byte("large block "), 1024)
+	largeKey = util_hash.Sha1(large)
+	tmp := filepath.Join(storage.Path, "large.tmp")
+	if err := ioutil.WriteFile(tmp, large, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.saveFile(largeKey, uint64(len(large)), tmp, storage); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range [][]byte{smallKey, largeKey} {
+		ps.saveMerkleLeaves(key, [][]byte{key})
+	}
+	return
+}
+
+// expire move remove time of tombstone before grace period
+func expire(t *testing.T, ps *ProviderService, key []byte) {
+	ts := ps.getTombstone(key)
+	if ts == nil {
+		t.Fatalf("%x is not in trash", key)
+	}
+	ts.RemoveTime = time.Now().Add(-config.RemoveGracePeriod() - time.Minute)
+	if err := ps.trashDb.Put(key, ts.encode(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	ps, clean := newTrashTestService(t)
+	defer clean()
+	smallKey, largeKey := storeTestBlocks(t, ps)
+	for _, key := range [][]byte{smallKey, largeKey} {
+		if err := ps.trashBlock(key, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	trashPath, err := ps.trashFilePath(0, largeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps.PurgeTrash()
+	if ps.getTombstone(smallKey) == nil || ps.getTombstone(largeKey) == nil {
+		t.Fatalf("block in grace period should not be purged")
+	}
+	if _, err = os.Stat(trashPath); err != nil {
+		t.Errorf("file in grace period should be kept: %s", err)
+	}
+
+	expire(t, ps, smallKey)
+	expire(t, ps, largeKey)
+	ps.PurgeTrash()
+	for _, key := range [][]byte{smallKey, largeKey} {
+		if ps.getTombstone(key) != nil {
+			t.Errorf("expired tombstone %x should be purged", key)
+		}
+		if _, err = ps.merkleDb.Get(key, nil); err == nil {
+			t.Errorf("merkle leaves of %x should be purged", key)
+		}
+	}
+	if _, err = ps.storages.GetStorage(0).SmallFileDb.Get(smallKey, nil); err == nil {
+		t.Errorf("small file should be purged")
+	}
+	if _, err = os.Stat(trashPath); !os.IsNotExist(err) {
+		t.Errorf("file should be purged: %v", err)
+	}
+}
+
+func TestStoreRevive(t *testing.T) {
+	ps, clean := newTrashTestService(t)
+	defer clean()
+	skip_check_auth = true
+	defer func() { skip_check_auth = false }()
+	smallKey, largeKey := storeTestBlocks(t, ps)
+	for _, key := range [][]byte{smallKey, largeKey} {
+		if err := ps.trashBlock(key, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	small := []byte("small block")
+	resp, err := ps.StoreSmall(context.Background(), &pb.StoreReq{BlockKey: smallKey, BlockSize: uint64(len(small)), Data: small})
+	if err != nil || !resp.Success {
+		t.Fatalf("store trashed block should succeed: %v", err)
+	}
+	if ps.getTombstone(smallKey) != nil {
+		t.Errorf("revived block should not be in trash")
+	}
+	if found, smallFile, _, _ := ps.querySubPath(smallKey); !found || !smallFile {
+		t.Errorf("revived block should be found")
+	}
+
+	if revived, err := ps.revive(largeKey); !revived || err != nil {
+		t.Fatalf("trashed large block should be revived: %v", err)
+	}
+	if ok, err := ps.verifyBlockHash(largeKey); err != nil || !ok {
+		t.Errorf("revived large block should be verified: %v", err)
+	}
+	if _, err = ps.merkleDb.Get(largeKey, nil); err != nil {
+		t.Errorf("merkle leaves should be kept for revived block: %s", err)
+	}
+
+	// tombstone whose data is lost is dropped, block is received again
+	if err = ps.trashBlock(largeKey, 0); err != nil {
+		t.Fatal(err)
+	}
+	trashPath, _ := ps.trashFilePath(0, largeKey)
+	if err = os.Remove(trashPath); err != nil {
+		t.Fatal(err)
+	}
+	if revived, err := ps.revive(largeKey); revived || err == nil {
+		t.Errorf("block whose file is lost should not be revived")
+	}
+	if ps.getTombstone(largeKey) != nil {
+		t.Errorf("tombstone whose file is lost should be dropped")
+	}
+}
+
+func TestPurgeTrashStorageFailed(t *testing.T) {
+	ps, clean := newTrashTestService(t)
+	defer clean()
+	smallKey, _ := storeTestBlocks(t, ps)
+	if err := ps.trashBlock(smallKey, 0); err != nil {
+		t.Fatal(err)
+	}
+	expire(t, ps, smallKey)
+	// large block on storage 1 which is not available
+	lostKey := util_hash.Sha1([]byte("block on lost storage"))
+	lost := &Tombstone{Key: lostKey, RemoveTime: time.Now().Add(-config.RemoveGracePeriod() - time.Minute), BlockSize: 1 << 20,
+		location: append([]byte{1}, "/0001/0002/lost.blk"...)}
+	if err := ps.trashDb.Put(lostKey, lost.encode(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ps.PurgeTrash()
+	if ps.getTombstone(lostKey) == nil {
+		t.Errorf("tombstone should be kept when its storage failed, so it is purged later")
+	}
+	if ps.getTombstone(smallKey) != nil {
+		t.Errorf("failed storage should not stop purging other blocks")
+	}
+}
+
+func TestRemoveToTrash(t *testing.T) {
+	ps, clean := newTrashTestService(t)
+	defer clean()
+	skip_check_auth = true
+	defer func() { skip_check_auth = false }()
+	_, largeKey := storeTestBlocks(t, ps)
+	resp, err := ps.Remove(context.Background(), &pb.RemoveReq{Key: largeKey, Size: 12 * 1024})
+	if err != nil || !resp.Success {
+		t.Fatalf("remove should succeed: %v", err)
+	}
+	if found, _, _, _ := ps.querySubPath(largeKey); found {
+		t.Errorf("removed block should not be found")
+	}
+	ts := ps.getTombstone(largeKey)
+	if ts == nil || ts.BlockSize != 12*1024 {
+		t.Fatalf("removed block should be in trash: %+v", ts)
+	}
+	if _, err = ps.merkleDb.Get(largeKey, nil); err != nil {
+		t.Errorf("merkle leaves should be kept in grace period: %s", err)
+	}
+	if revived, err := ps.revive(largeKey); !revived || err != nil {
+		t.Errorf("removed block should be revived in grace period: %v", err)
+	}
+	if _, err = ps.Remove(context.Background(), &pb.RemoveReq{Key: []byte("missing block key000")}); err == nil {
+		t.Errorf("remove missing block should fail")
+	}
+}
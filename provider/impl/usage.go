@@ -0,0 +1,36 @@
+package impl
+
+import (
+	"os"
+)
+
+// StoredBytes sum size of stored blocks, sealed size is counted so it is a little more than plaintext
+func (self *ProviderService) StoredBytes() (total uint64, blocks uint64, err error) {
+	iter := self.providerDb.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		location := iter.Value()
+		if len(location) == 0 {
+			continue
+		}
+		storage := self.storages.GetStorage(location[0])
+		if storage == nil || storage.Failed() {
+			continue
+		}
+		if len(location) == 1 {
+			data, er := storage.SmallFileDb.Get(iter.Key(), nil)
+			if er != nil {
+				continue
+			}
+			total += uint64(len(data))
+		} else {
+			fileInfo, er := os.Stat(self.storages.GetStoragePath(location[0], string(location[1:])))
+			if er != nil {
+				continue
+			}
+			total += uint64(fileInfo.Size())
+		}
+		blocks++
+	}
+	return total, blocks, iter.Error()
+}
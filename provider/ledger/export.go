@@ -0,0 +1,40 @@
+package ledger
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+var csv_header = []string{"date", "walletAddress", "storedByteHours", "maxStoredBytes", "ingressBytes", "egressBytes",
+	"storeIngressBytes", "replicaIngressBytes", "retrieveEgressBytes", "sendEgressBytes",
+	"stores", "retrieves", "replicates", "sends", "removes", "proveSuccess", "proveFailure"}
+
+func u(n uint64) string {
+	return strconv.FormatUint(n, 10)
+}
+
+// WriteCSV export days with header
+func WriteCSV(w io.Writer, days []*Day) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csv_header); err != nil {
+		return err
+	}
+	for _, d := range days {
+		if err := cw.Write([]string{d.Date, d.WalletAddress, strconv.FormatFloat(d.StoredByteHours, 'f', 0, 64), u(d.MaxStoredBytes),
+			u(d.Ingress()), u(d.Egress()), u(d.StoreIngress), u(d.ReplicaIngress), u(d.RetrieveEgress), u(d.SendEgress),
+			u(d.Stores), u(d.Retrieves), u(d.Replicates), u(d.Sends), u(d.Removes), u(d.ProveSuccess), u(d.ProveFailure)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON export days as a JSON array
+func WriteJSON(w io.Writer, days []*Day) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(days)
+}
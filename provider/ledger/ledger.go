@@ -0,0 +1,187 @@
+// Package ledger compute daily usage of provider from stored bytes samples and activity journal,
+// so that operators can reconcile bills without asking tracker.
+package ledger
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samoslab/nebula/provider/activity"
+	ttpb "github.com/samoslab/nebula/tracker/task/pb"
+	util_bytes "github.com/samoslab/nebula/util/bytes"
+)
+
+const sample_len = 16
+
+// SampleInterval is how often daemon samples stored bytes
+const SampleInterval = time.Hour
+
+// max_gap is the longest interval a sample is counted, daemon is down if there is no later sample
+const max_gap = 2 * SampleInterval
+
+const day_layout = "2006-01-02"
+
+// Sample is stored bytes at a time
+type Sample struct {
+	Time  time.Time
+	Bytes uint64
+}
+
+var mutex sync.Mutex
+
+// RecordSample append sample of now to file
+func RecordSample(path string, bytes uint64) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	b := make([]byte, sample_len)
+	copy(b, util_bytes.FromUint64(uint64(time.Now().Unix())))
+	copy(b[8:], util_bytes.FromUint64(bytes))
+	_, err = file.Write(b)
+	return err
+}
+
+// ReadSamples read all samples of file, a partial record written by crash is ignored
+func ReadSamples(path string) ([]Sample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	res := make([]Sample, 0, 1024)
+	buf := make([]byte, sample_len)
+	for {
+		if _, err = io.ReadFull(r, buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return res, nil
+			}
+			return nil, err
+		}
+		res = append(res, Sample{Time: time.Unix(int64(util_bytes.ToUint64(buf, 0)), 0), Bytes: util_bytes.ToUint64(buf, 8)})
+	}
+}
+
+// Day is usage of a local day, traffic unit: byte.
+// Ingress is received by Store as server and Retrieve as client (replicate), egress is the opposite direction.
+type Day struct {
+	Date            string
+	WalletAddress   string
+	StoredByteHours float64
+	MaxStoredBytes  uint64
+	StoreIngress    uint64
+	ReplicaIngress  uint64
+	RetrieveEgress  uint64
+	SendEgress      uint64
+	Stores          uint64
+	Retrieves       uint64
+	Replicates      uint64
+	Sends           uint64
+	Removes         uint64
+	ProveSuccess    uint64
+	ProveFailure    uint64
+}
+
+func (self *Day) Ingress() uint64 {
+	return self.StoreIngress + self.ReplicaIngress
+}
+
+func (self *Day) Egress() uint64 {
+	return self.RetrieveEgress + self.SendEgress
+}
+
+// Compute daily usage of local days in [from, to), days without any usage are omitted
+func Compute(samples []Sample, entries []*activity.Entry, walletAddress string, from time.Time, to time.Time) []*Day {
+	days := make(map[string]*Day, 32)
+	get := func(t time.Time) *Day {
+		date := t.Format(day_layout)
+		d, ok := days[date]
+		if !ok {
+			d = &Day{Date: date, WalletAddress: walletAddress}
+			days[date] = d
+		}
+		return d
+	}
+	for i, s := range samples {
+		end := s.Time.Add(max_gap)
+		if i+1 < len(samples) && samples[i+1].Time.Before(end) {
+			end = samples[i+1].Time
+		}
+		start := s.Time
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		// split interval at local midnight
+		for start.Before(end) {
+			y, m, dd := start.Date()
+			next := time.Date(y, m, dd+1, 0, 0, 0, 0, start.Location())
+			if next.After(end) {
+				next = end
+			}
+			d := get(start)
+			d.StoredByteHours += float64(s.Bytes) * next.Sub(start).Hours()
+			if s.Bytes > d.MaxStoredBytes {
+				d.MaxStoredBytes = s.Bytes
+			}
+			start = next
+		}
+	}
+	for _, e := range entries {
+		t := time.Unix(0, e.Time)
+		if t.Before(from) || !t.Before(to) {
+			continue
+		}
+		if !e.Success && e.Type != ttpb.TaskType_PROVE.String() {
+			continue
+		}
+		d := get(t)
+		switch e.Type {
+		case activity.TypeStore:
+			if e.AsClient {
+				d.SendEgress += e.TransportSize
+			} else {
+				d.StoreIngress += e.TransportSize
+				d.Stores++
+			}
+		case activity.TypeRetrieve:
+			if e.AsClient {
+				d.ReplicaIngress += e.TransportSize
+			} else {
+				d.RetrieveEgress += e.TransportSize
+				d.Retrieves++
+			}
+		case ttpb.TaskType_REPLICATE.String():
+			d.Replicates++
+		case ttpb.TaskType_SEND.String():
+			d.Sends++
+		case ttpb.TaskType_REMOVE.String():
+			d.Removes++
+		case ttpb.TaskType_PROVE.String():
+			if e.Success {
+				d.ProveSuccess++
+			} else {
+				d.ProveFailure++
+			}
+		}
+	}
+	res := make([]*Day, 0, len(days))
+	for _, d := range days {
+		res = append(res, d)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Date < res[j].Date })
+	return res
+}
@@ -0,0 +1,75 @@
+package ledger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samoslab/nebula/provider/activity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute(t *testing.T) {
+	day1 := time.Date(2018, 6, 1, 0, 0, 0, 0, time.Local)
+	at := func(hours float64) time.Time { return day1.Add(time.Duration(hours * float64(time.Hour))) }
+	samples := []Sample{
+		{at(22), 1000},
+		{at(23), 2000},
+		// daemon was down, the sample is counted for max_gap only
+		{at(24), 3000},
+		{at(30), 4000},
+		{at(31), 4000},
+	}
+	entries := []*activity.Entry{
+		{Time: at(22.5).UnixNano(), Type: activity.TypeStore, Success: true, TransportSize: 100},
+		{Time: at(22.6).UnixNano(), Type: activity.TypeStore, Success: false, TransportSize: 50},
+		{Time: at(23.5).UnixNano(), Type: activity.TypeRetrieve, Success: true, TransportSize: 70},
+		{Time: at(25).UnixNano(), Type: activity.TypeStore, AsClient: true, Success: true, TransportSize: 30},
+		{Time: at(25).UnixNano(), Type: activity.TypeRetrieve, AsClient: true, Success: true, TransportSize: 40},
+		{Time: at(26).UnixNano(), Type: "PROVE", Success: true},
+		{Time: at(27).UnixNano(), Type: "PROVE", Success: false},
+		{Time: at(50).UnixNano(), Type: "REMOVE", Success: true},
+	}
+	days := Compute(samples, entries, "wallet", day1, day1.AddDate(0, 0, 2))
+	require.Equal(t, 2, len(days))
+	d := days[0]
+	require.Equal(t, "2018-06-01", d.Date)
+	require.Equal(t, "wallet", d.WalletAddress)
+	require.Equal(t, float64(1000+2000), d.StoredByteHours)
+	require.Equal(t, uint64(2000), d.MaxStoredBytes)
+	require.Equal(t, uint64(100), d.Ingress())
+	require.Equal(t, uint64(70), d.Egress())
+	require.Equal(t, uint64(1), d.Stores)
+	d = days[1]
+	require.Equal(t, float64(3000*2+4000+4000*2), d.StoredByteHours)
+	require.Equal(t, uint64(40), d.ReplicaIngress)
+	require.Equal(t, uint64(30), d.SendEgress)
+	require.Equal(t, uint64(1), d.ProveSuccess)
+	require.Equal(t, uint64(1), d.ProveFailure)
+	require.Equal(t, uint64(0), d.Removes)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteCSV(buf, days))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, 3, len(lines))
+	require.True(t, strings.HasPrefix(lines[1], "2018-06-01,wallet,3000,2000,100,70,"))
+}
+
+func TestSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ledger")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := dir + string(os.PathSeparator) + "usage.samples"
+	samples, err := ReadSamples(path)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(samples))
+	require.NoError(t, RecordSample(path, 123))
+	require.NoError(t, RecordSample(path, 456))
+	samples, err = ReadSamples(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(samples))
+	require.Equal(t, uint64(456), samples[1].Bytes)
+}
@@ -0,0 +1,357 @@
+// Package loadgen generate Store/Retrieve load against providers to find how many concurrent streams they sustain.
+package loadgen
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/samoslab/nebula/provider/pb"
+	provider_client "github.com/samoslab/nebula/provider/provider_client"
+	util_bytes "github.com/samoslab/nebula/util/bytes"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const small_file_limit = 512 * 1024
+const load_ticket = "loadgen"
+
+var NoProviderErr = errors.New("no provider")
+var NoSizeErr = errors.New("no block size")
+var PublicKeyCountErr = errors.New("count of public keys must be 1 or same as providers")
+
+// SizeWeight is a block size and its weight in mix
+type SizeWeight struct {
+	Size   uint64
+	Weight int
+}
+
+// Config of load
+type Config struct {
+	Providers   []string      // address of providers, eg: 127.0.0.1:6666
+	PublicKeys  [][]byte      // public key of each provider to generate auth, one key for all providers, empty for stand-in providers
+	Concurrency int           // concurrent requests
+	Duration    time.Duration // stop after duration, it is ignored if Requests is more than 0
+	Requests    int           // total requests
+	ReadRatio   float64       // fraction of Retrieve in requests, between 0 and 1
+	Sizes       []SizeWeight  // block size mix
+	Seed        int64         // random seed of operations and sizes, current time if 0
+}
+
+var size_pattern = regexp.MustCompile(`^(\d+)([KkMm]?)$`)
+
+// ParseSize parse size with optional unit K or M, eg: 4096, 64K, 1M
+func ParseSize(s string) (uint64, error) {
+	m := size_pattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	n, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToUpper(m[2]) {
+	case "K":
+		n *= 1024
+	case "M":
+		n *= 1024 * 1024
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("size must be more than 0: %s", s)
+	}
+	return n, nil
+}
+
+// ParseMix parse block size mix, weight is 1 if omitted, eg: 64K:3,1M:1,4M
+func ParseMix(s string) ([]SizeWeight, error) {
+	var res []SizeWeight
+	for _, item := range strings.Split(s, ",") {
+		if len(strings.TrimSpace(item)) == 0 {
+			continue
+		}
+		arr := strings.Split(item, ":")
+		if len(arr) > 2 {
+			return nil, fmt.Errorf("invalid size mix: %s", item)
+		}
+		size, err := ParseSize(arr[0])
+		if err != nil {
+			return nil, err
+		}
+		weight := 1
+		if len(arr) == 2 {
+			if weight, err = strconv.Atoi(strings.TrimSpace(arr[1])); err != nil || weight <= 0 {
+				return nil, fmt.Errorf("invalid weight: %s", item)
+			}
+		}
+		res = append(res, SizeWeight{Size: size, Weight: weight})
+	}
+	if len(res) == 0 {
+		return nil, NoSizeErr
+	}
+	return res, nil
+}
+
+// LoadPublicKey read public key of provider from file, content is hex as PublicKey in provider config, or PEM
+func LoadPublicKey(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var key []byte
+	if block, _ := pem.Decode(b); block != nil {
+		key = block.Bytes
+	} else if key, err = hex.DecodeString(strings.TrimSpace(string(b))); err != nil {
+		return nil, fmt.Errorf("public key file %s is neither hex nor PEM", path)
+	}
+	if _, err = x509.ParsePKCS1PublicKey(key); err != nil {
+		return nil, fmt.Errorf("parse public key in %s failed: %s", path, err)
+	}
+	return key, nil
+}
+
+type block struct {
+	key  []byte
+	size uint64
+}
+
+// target is a provider with blocks stored to it
+type target struct {
+	addr      string
+	publicKey []byte
+	client    pb.ProviderServiceClient
+	mutex     sync.Mutex
+	blocks    []block
+}
+
+func (self *target) add(b block) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.blocks = append(self.blocks, b)
+}
+
+// pick return false if nothing is stored
+func (self *target) pick(random *rand.Rand) (block, bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if len(self.blocks) == 0 {
+		return block{}, false
+	}
+	return self.blocks[random.Intn(len(self.blocks))], true
+}
+
+func (self *target) store(data []byte) error {
+	key := util_hash.Sha1(data)
+	size := uint64(len(data))
+	timestamp := uint64(time.Now().Unix())
+	var auth []byte
+	if self.publicKey != nil {
+		auth = pb.GenStoreAuth(self.publicKey, key, size, key, size, timestamp, load_ticket)
+	}
+	var err error
+	if size < small_file_limit {
+		err = provider_client.StoreSmall(self.client, data, auth, timestamp, load_ticket, key, size, key, size, nil)
+	} else {
+		err = provider_client.StoreReader(self.client, bytes.NewReader(data), auth, timestamp, load_ticket, key, size, key, size, nil)
+	}
+	if err == nil {
+		self.add(block{key: key, size: size})
+	}
+	return err
+}
+
+func (self *target) retrieve(b block) error {
+	timestamp := uint64(time.Now().Unix())
+	var auth []byte
+	if self.publicKey != nil {
+		auth = pb.GenRetrieveAuth(self.publicKey, b.key, b.size, b.key, b.size, timestamp, load_ticket)
+	}
+	if b.size < small_file_limit {
+		data, _, err := provider_client.RetrieveSmall(self.client, auth, timestamp, load_ticket, b.key, b.size, b.key, b.size)
+		if err == nil && uint64(len(data)) != b.size {
+			err = fmt.Errorf("retrieved %d bytes, expected %d", len(data), b.size)
+		}
+		return err
+	}
+	_, err := provider_client.Retrieve(self.client, os.DevNull, auth, timestamp, load_ticket, b.key, b.size, b.key, b.size)
+	return err
+}
+
+// worker generate requests by its own random
+type worker struct {
+	random *rand.Rand
+	sizes  []SizeWeight
+	total  int
+	buf    []byte
+	seq    uint64
+}
+
+func (self *worker) size() uint64 {
+	n := self.random.Intn(self.total)
+	for _, s := range self.sizes {
+		if n < s.Weight {
+			return s.Size
+		}
+		n -= s.Weight
+	}
+	return self.sizes[len(self.sizes)-1].Size
+}
+
+// data is random buf with unique head to avoid AlreadyExists of same block
+func (self *worker) data(size uint64) []byte {
+	self.seq++
+	data := self.buf[:size]
+	head := append(util_bytes.FromUint64(self.seq), util_bytes.FromUint64(uint64(self.random.Int63()))...)
+	copy(data, head)
+	return data
+}
+
+func (self *Config) check() error {
+	if len(self.Providers) == 0 {
+		return NoProviderErr
+	}
+	if len(self.Sizes) == 0 {
+		return NoSizeErr
+	}
+	if len(self.PublicKeys) > 1 && len(self.PublicKeys) != len(self.Providers) {
+		return PublicKeyCountErr
+	}
+	if self.ReadRatio < 0 || self.ReadRatio > 1 {
+		return errors.New("read ratio must be between 0 and 1")
+	}
+	if self.Concurrency <= 0 {
+		return errors.New("concurrency must be more than 0")
+	}
+	if self.Requests <= 0 && self.Duration <= 0 {
+		return errors.New("requests or duration is required")
+	}
+	return nil
+}
+
+func dial(config *Config) ([]*target, []*grpc.ClientConn, error) {
+	targets := make([]*target, 0, len(config.Providers))
+	conns := make([]*grpc.ClientConn, 0, len(config.Providers))
+	for i, addr := range config.Providers {
+		conn, err := grpc.Dial(addr, grpc.WithInsecure())
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return nil, nil, fmt.Errorf("RPC Dial provider %s failed: %s", addr, err)
+		}
+		conns = append(conns, conn)
+		t := &target{addr: addr, client: pb.NewProviderServiceClient(conn)}
+		if len(config.PublicKeys) == 1 {
+			t.publicKey = config.PublicKeys[0]
+		} else if len(config.PublicKeys) > 0 {
+			t.publicKey = config.PublicKeys[i]
+		}
+		targets = append(targets, t)
+	}
+	return targets, conns, nil
+}
+
+// Run generate load, blocks are stored to each provider before measuring if there are reads
+func Run(config *Config) (*Report, error) {
+	if err := config.check(); err != nil {
+		return nil, err
+	}
+	targets, conns, err := dial(config)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}()
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	var maxSize uint64
+	total := 0
+	for _, s := range config.Sizes {
+		total += s.Weight
+		if s.Size > maxSize {
+			maxSize = s.Size
+		}
+	}
+	workers := make([]*worker, config.Concurrency)
+	for i := range workers {
+		w := &worker{random: rand.New(rand.NewSource(seed + int64(i))), sizes: config.Sizes, total: total, buf: make([]byte, maxSize)}
+		w.random.Read(w.buf)
+		workers[i] = w
+	}
+	if config.ReadRatio > 0 {
+		for _, t := range targets {
+			for _, w := range workers {
+				if err = t.store(w.data(w.size())); err != nil {
+					return nil, fmt.Errorf("store warm-up block to %s failed: %s", t.addr, err)
+				}
+			}
+		}
+	}
+	report := newReport()
+	var issued int
+	var mutex sync.Mutex
+	// next return false when load is finished
+	next := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if config.Requests > 0 {
+			issued++
+			return issued <= config.Requests
+		}
+		return time.Since(report.start) < config.Duration
+	}
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			for next() {
+				t := targets[w.random.Intn(len(targets))]
+				if w.random.Float64() < config.ReadRatio {
+					if b, ok := t.pick(w.random); ok {
+						start := time.Now()
+						err := t.retrieve(b)
+						report.Retrieve.add(b.size, time.Since(start), err)
+						continue
+					}
+				}
+				data := w.data(w.size())
+				start := time.Now()
+				err := t.store(data)
+				report.Store.add(uint64(len(data)), time.Since(start), err)
+			}
+		}(w)
+	}
+	wg.Wait()
+	report.Elapsed = time.Since(report.start)
+	return report, nil
+}
+
+var code_pattern = regexp.MustCompile(`code = (\w+)`)
+
+// errorCode return gRPC code name of error, provider_client wraps some errors in text
+func errorCode(err error) string {
+	if s, ok := status.FromError(err); ok {
+		return s.Code().String()
+	}
+	if m := code_pattern.FindStringSubmatch(err.Error()); m != nil {
+		return m[1]
+	}
+	return "Unknown"
+}
@@ -0,0 +1,95 @@
+package loadgen
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samoslab/nebula/provider/benchmark"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("64K:3, 1M:1,4096")
+	require.NoError(t, err)
+	assert.Equal(t, []SizeWeight{{64 * 1024, 3}, {1024 * 1024, 1}, {4096, 1}}, mix)
+	for _, s := range []string{"", "0", "1G", "64K:0", "64K:x", "64K:1:2"} {
+		_, err = ParseMix(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "loadgen")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	pk, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	der := x509.MarshalPKCS1PublicKey(&pk.PublicKey)
+
+	path := filepath.Join(dir, "hex")
+	require.NoError(t, ioutil.WriteFile(path, []byte(hex.EncodeToString(der)+"\n"), 0600))
+	key, err := LoadPublicKey(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(der, key))
+
+	path = filepath.Join(dir, "pem")
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: der}), 0600))
+	key, err = LoadPublicKey(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(der, key))
+
+	path = filepath.Join(dir, "wrong")
+	require.NoError(t, ioutil.WriteFile(path, []byte("0011"), 0600))
+	_, err = LoadPublicKey(path)
+	assert.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	s := newReport().Store
+	for i := 100; i > 0; i-- {
+		s.add(1, time.Duration(i)*time.Millisecond, nil)
+	}
+	assert.Equal(t, 50*time.Millisecond, s.Percentile(50))
+	assert.Equal(t, 99*time.Millisecond, s.Percentile(99))
+	assert.Equal(t, 100*time.Millisecond, s.Percentile(100))
+	assert.Equal(t, time.Millisecond, s.Percentile(0))
+	assert.Equal(t, uint64(100), s.Throughput(time.Second))
+}
+
+func TestRun(t *testing.T) {
+	server, addr, err := benchmark.Serve("127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Stop()
+
+	report, err := Run(&Config{Providers: []string{addr.String()}, Concurrency: 4, Requests: 20, ReadRatio: 0.5,
+		Sizes: []SizeWeight{{Size: 1024 * 1024, Weight: 1}}, Seed: 1})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), report.Store.Count+report.Retrieve.Count)
+	assert.True(t, report.Retrieve.Count > 0)
+	assert.Equal(t, uint64(0), report.Store.Errors+report.Retrieve.Errors)
+	assert.Equal(t, report.Store.Count*1024*1024, report.Store.Bytes)
+	var out bytes.Buffer
+	report.Print(&out)
+	assert.Contains(t, out.String(), "Store: requests")
+
+	// stand-in does not support RetrieveSmall
+	report, err = Run(&Config{Providers: []string{addr.String()}, Concurrency: 2, Duration: 100 * time.Millisecond, ReadRatio: 1,
+		Sizes: []SizeWeight{{Size: 4096, Weight: 1}}})
+	require.NoError(t, err)
+	assert.True(t, report.Retrieve.Count > 0)
+	assert.Equal(t, report.Retrieve.Count, report.Retrieve.Codes["Unimplemented"])
+
+	_, err = Run(&Config{Providers: []string{addr.String()}, PublicKeys: [][]byte{nil, nil}, Concurrency: 1, Requests: 1,
+		Sizes: []SizeWeight{{Size: 4096, Weight: 1}}})
+	assert.Equal(t, PublicKeyCountErr, err)
+}
@@ -0,0 +1,103 @@
+package loadgen
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OpStats is stats of requests of an operation
+type OpStats struct {
+	Name      string
+	Count     uint64
+	Errors    uint64
+	Bytes     uint64            // transported bytes of succeeded requests
+	Codes     map[string]uint64 // count of each gRPC error code
+	mutex     sync.Mutex
+	latencies []time.Duration // latencies of succeeded requests
+	sorted    bool
+}
+
+func (self *OpStats) add(size uint64, latency time.Duration, err error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.Count++
+	if err != nil {
+		self.Errors++
+		self.Codes[errorCode(err)]++
+		return
+	}
+	self.Bytes += size
+	self.latencies = append(self.latencies, latency)
+	self.sorted = false
+}
+
+// Percentile return latency at p of succeeded requests, p is between 0 and 100
+func (self *OpStats) Percentile(p float64) time.Duration {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if len(self.latencies) == 0 {
+		return 0
+	}
+	if !self.sorted {
+		sort.Slice(self.latencies, func(i, j int) bool { return self.latencies[i] < self.latencies[j] })
+		self.sorted = true
+	}
+	i := int(p/100*float64(len(self.latencies))+0.5) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(self.latencies) {
+		i = len(self.latencies) - 1
+	}
+	return self.latencies[i]
+}
+
+// Throughput unit: byte/s
+func (self *OpStats) Throughput(elapsed time.Duration) uint64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return uint64(float64(self.Bytes) / elapsed.Seconds())
+}
+
+// Report of a load run, warm-up requests are not counted
+type Report struct {
+	Elapsed  time.Duration
+	Store    *OpStats
+	Retrieve *OpStats
+	start    time.Time
+}
+
+func newReport() *Report {
+	return &Report{Store: &OpStats{Name: "Store", Codes: make(map[string]uint64)},
+		Retrieve: &OpStats{Name: "Retrieve", Codes: make(map[string]uint64)},
+		start:    time.Now()}
+}
+
+func round(d time.Duration) time.Duration {
+	return d / time.Microsecond * time.Microsecond
+}
+
+// Print report as text
+func (self *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "elapsed: %s\n", round(self.Elapsed))
+	for _, s := range []*OpStats{self.Store, self.Retrieve} {
+		if s.Count == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s: requests %d, errors %d, %.1f req/s, throughput %.2f MB/s\n", s.Name, s.Count, s.Errors,
+			float64(s.Count)/self.Elapsed.Seconds(), float64(s.Throughput(self.Elapsed))/1024/1024)
+		fmt.Fprintf(w, "  latency p50 %s, p90 %s, p99 %s, max %s\n", round(s.Percentile(50)), round(s.Percentile(90)),
+			round(s.Percentile(99)), round(s.Percentile(100)))
+		codes := make([]string, 0, len(s.Codes))
+		for c := range s.Codes {
+			codes = append(codes, c)
+		}
+		sort.Strings(codes)
+		for _, c := range codes {
+			fmt.Fprintf(w, "  error %s: %d\n", c, s.Codes[c])
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"os"
@@ -17,19 +18,28 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/robfig/cron"
+	"github.com/samoslab/nebula/provider/activity"
+	"github.com/samoslab/nebula/provider/benchmark"
 	collector "github.com/samoslab/nebula/provider/collector_client"
 	"github.com/samoslab/nebula/provider/config"
+	"github.com/samoslab/nebula/provider/discovery"
 	"github.com/samoslab/nebula/provider/disk"
 	"github.com/samoslab/nebula/provider/impl"
+	"github.com/samoslab/nebula/provider/ledger"
 	"github.com/samoslab/nebula/provider/node"
 	pb "github.com/samoslab/nebula/provider/pb"
 	client "github.com/samoslab/nebula/provider/register_client"
+	"github.com/samoslab/nebula/provider/relay"
+	"github.com/samoslab/nebula/provider/uptime"
 	trp_pb "github.com/samoslab/nebula/tracker/register/provider/pb"
+	tpb "github.com/samoslab/nebula/tracker/task/pb"
 	util_hash "github.com/samoslab/nebula/util/hash"
+	"github.com/samoslab/nebula/util/lan"
 	util_rsa "github.com/samoslab/nebula/util/rsa"
 	upnp "github.com/samoslab/nebula/util/upnp"
 	"github.com/skycoin/skycoin/src/cipher"
@@ -58,11 +68,18 @@ func main() {
 	daemonCommand := flag.NewFlagSet("daemon", flag.ExitOnError)
 	daemonConfigDirFlag := daemonCommand.String("configDir", defaultConfigDirFlag, "config directory")
 	daemonTrackerServerFlag := daemonCommand.String("trackerServer", "tracker.store.samos.io:6677", "tracker server address, eg: tracker.store.samos.io:6677")
-	daemonCollectorServerFlag := daemonCommand.String("collectorServer", "collector.store.samos.io:6688", "collector server address, eg: collector.store.samos.io:6688")
-	daemonTaskServerFlag := daemonCommand.String("taskServer", "task.store.samos.io:6622", "task server address, eg: task.store.samos.io:6622")
+	daemonCollectorServerFlag := daemonCommand.String("collectorServer", "collector.store.samos.io:6688", "collector server address, others are discovered from tracker, eg: collector.store.samos.io:6688")
+	daemonTaskServerFlag := daemonCommand.String("taskServer", "task.store.samos.io:6622", "task server address, tracker does not list task servers, so task service is guessed on every discovered tracker host at the port of it, eg: task.store.samos.io:6622")
 	listenFlag := daemonCommand.String("listen", ":6666", "listen address and port, eg: 111.111.111.111:6666 or :6666")
 	disableAutoRefreshIpFlag := daemonCommand.Bool("disableAutoRefreshIp", false, "disable auto refresh provider ip or enable auto refresh provider ip")
 	quietFlag := daemonCommand.Bool("quiet", false, "not print dot when running")
+	relayListenFlag := daemonCommand.String("relayListen", "", "offer relay for private network nodes on this address, public network node only, eg: :6670")
+	relayPortsFlag := daemonCommand.String("relayPorts", "", "outer ports range allocated to private network nodes by relay, must be reachable, any free port if not specified, eg: 20000-20099")
+	relayCapacityFlag := daemonCommand.Uint("relayCapacity", 32, "max count of private network nodes served by relay")
+	relayFlag := daemonCommand.String("relay", "", "relay server of private network node, assigned by tracker if not specified, eg: 111.111.111.111:6670")
+	disableRelayFlag := daemonCommand.Bool("disableRelay", false, "private network node does not serve clients through relay")
+	disableLanAnnounceFlag := daemonCommand.Bool("disableLanAnnounce", false, "private network node does not serve clients on local network, or announce itself by SSDP")
+	chaosConfigFlag := chaosFlag(daemonCommand)
 
 	registerCommand := flag.NewFlagSet("register", flag.ExitOnError)
 	registerConfigDirFlag := registerCommand.String("configDir", defaultConfigDirFlag, "config directory")
@@ -79,6 +96,8 @@ func main() {
 	portFlag := registerCommand.Uint("port", 6666, "outer network port for client to connect, eg:6666")
 	hostFlag := registerCommand.String("host", "", "outer ip or domain for client to connect, eg: 123.123.123.123")
 	dynamicDomainFlag := registerCommand.String("dynamicDomain", "", "dynamic domain for client to connect, eg: mydomain.xicp.net")
+	registerBenchmarkFlag := registerCommand.Bool("benchmark", false, "benchmark storage and network before register, measured bandwidth is used if upBandwidth or downBandwidth is not specified and benchmarkPeer is specified")
+	registerBenchmarkPeerFlag := registerCommand.String("benchmarkPeer", "", "benchmark peer started by \"benchmark -serve\", local loopback is used if not specified, eg: 111.111.111.111:6667")
 
 	verifyEmailCommand := flag.NewFlagSet("verifyEmail", flag.ExitOnError)
 	verifyEmailConfigDirFlag := verifyEmailCommand.String("configDir", defaultConfigDirFlag, "config directory")
@@ -106,6 +125,41 @@ func main() {
 	switchPublicPortFlag := switchPublicCommand.Uint("port", 6666, "outer network port for client to connect, eg:6666")
 	switchPublicHostFlag := switchPublicCommand.String("host", "", "outer ip or domain for client to connect, eg: 123.123.123.123")
 	switchPublicDynamicDomainFlag := switchPublicCommand.String("dynamicDomain", "", "dynamic domain for client to connect, eg: mydomain.xicp.net")
+
+	encryptAtRestCommand := flag.NewFlagSet("encryptAtRest", flag.ExitOnError)
+	encryptAtRestConfigDirFlag := encryptAtRestCommand.String("configDir", defaultConfigDirFlag, "config directory")
+
+	benchmarkCommand := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	benchmarkConfigDirFlag := benchmarkCommand.String("configDir", defaultConfigDirFlag, "config directory")
+	benchmarkPathFlag := benchmarkCommand.String("path", "", "storage paths to benchmark if not registered, separated by comma")
+	benchmarkPeerFlag := benchmarkCommand.String("peer", "", "benchmark peer started by \"benchmark -serve\", local loopback is used if not specified, eg: 111.111.111.111:6667")
+	benchmarkServeFlag := benchmarkCommand.String("serve", "", "serve as benchmark peer on listen address, eg: :6667")
+	benchmarkSizeFlag := benchmarkCommand.Uint("size", benchmark.DefaultDiskSize/1024/1024, "size of disk benchmark file, unit: MB")
+
+	trashCommand := flag.NewFlagSet("trash", flag.ExitOnError)
+	trashConfigDirFlag := trashCommand.String("configDir", defaultConfigDirFlag, "config directory")
+	restoreFlag := trashCommand.String("restore", "", "hex block key to restore from trash, list blocks in trash if not specified")
+
+	journalCommand := flag.NewFlagSet("journal", flag.ExitOnError)
+	journalConfigDirFlag := journalCommand.String("configDir", defaultConfigDirFlag, "config directory")
+	journalBlockFlag := journalCommand.String("block", "", "filter by hex block hash")
+	journalTicketFlag := journalCommand.String("ticket", "", "filter by ticket")
+	journalTypeFlag := journalCommand.String("type", "", "filter by type: STORE, RETRIEVE, REPLICATE, SEND, REMOVE or PROVE")
+	journalFromFlag := journalCommand.String("from", "", "filter by time range beginning, RFC3339 or date, eg: 2018-06-01 or 2018-06-01T08:00:00+08:00")
+	journalToFlag := journalCommand.String("to", "", "filter by time range end (exclusive), RFC3339 or date")
+	journalOutcomeFlag := journalCommand.String("outcome", "", "filter by outcome: success or failure")
+	journalFormatFlag := journalCommand.String("format", "csv", "export format: csv or json")
+	journalOutputFlag := journalCommand.String("output", "", "export to file, print if not specified")
+
+	ledgerCommand := flag.NewFlagSet("ledger", flag.ExitOnError)
+	ledgerConfigDirFlag := ledgerCommand.String("configDir", defaultConfigDirFlag, "config directory")
+	ledgerFromFlag := ledgerCommand.String("from", "", "first day of report, RFC3339 or date, default is 30 days ago, eg: 2018-06-01")
+	ledgerToFlag := ledgerCommand.String("to", "", "end of report (exclusive), RFC3339 or date, default is now")
+	ledgerFormatFlag := ledgerCommand.String("format", "csv", "export format: csv or json")
+	ledgerOutputFlag := ledgerCommand.String("output", "", "export to file, print if not specified")
+
+	uptimeCommand := flag.NewFlagSet("uptime", flag.ExitOnError)
+	uptimeConfigDirFlag := uptimeCommand.String("configDir", defaultConfigDirFlag, "config directory")
 	if len(os.Args) == 1 {
 		fmt.Printf("usage: %s <command> [<args>]\n", os.Args[0])
 		fmt.Println("The most commonly used commands are: ")
@@ -115,7 +169,7 @@ func main() {
 		verifyEmailCommand.PrintDefaults()
 		fmt.Println(" resendVerifyCode [-configDir config-dir] [-trackerServer tracker-server-and-port]")
 		resendVerifyCodeCommand.PrintDefaults()
-		fmt.Println(" daemon [-configDir config-dir] [-trackerServer tracker-server-and-port] [-listen listen-address-and-port] [-disableAutoRefreshIp] [-quiet]")
+		fmt.Println(" daemon [-configDir config-dir] [-trackerServer tracker-server-and-port] [-listen listen-address-and-port] [-disableAutoRefreshIp] [-quiet] [-relayListen relay-listen-address] [-relayPorts relay-port-range] [-relayCapacity relay-capacity] [-relay relay-server] [-disableRelay] [-disableLanAnnounce]")
 		daemonCommand.PrintDefaults()
 		fmt.Println(" addStorage [-configDir config-dir] [-trackerServer tracker-server-and-port] -path storage-path -volume storage-volume")
 		addStorageCommand.PrintDefaults()
@@ -123,17 +177,31 @@ func main() {
 		switchPrivateCommand.PrintDefaults()
 		fmt.Println(" switchPublic [-configDir config-dir] [-trackerServer tracker-server-and-port] [-listen listen-address-and-port] [-host outer-host] [-dynamicDomain dynamic-domain] [-port outer-port]")
 		switchPublicCommand.PrintDefaults()
+		fmt.Println(" encryptAtRest [-configDir config-dir]")
+		encryptAtRestCommand.PrintDefaults()
+		fmt.Println(" benchmark [-configDir config-dir] [-path storage-paths] [-peer peer-address] [-serve listen-address] [-size disk-file-size]")
+		benchmarkCommand.PrintDefaults()
+		fmt.Println(" trash [-configDir config-dir] [-restore block-key]")
+		trashCommand.PrintDefaults()
+		fmt.Println(" journal [-configDir config-dir] [-block block-hash] [-ticket ticket] [-type type] [-from time] [-to time] [-outcome success-or-failure] [-format csv-or-json] [-output file]")
+		journalCommand.PrintDefaults()
+		fmt.Println(" ledger [-configDir config-dir] [-from time] [-to time] [-format csv-or-json] [-output file]")
+		ledgerCommand.PrintDefaults()
+		fmt.Println(" uptime [-configDir config-dir]")
+		uptimeCommand.PrintDefaults()
 		os.Exit(101)
 	}
 
 	switch os.Args[1] {
 	case "daemon":
 		daemonCommand.Parse(os.Args[2:])
-		daemon(*daemonConfigDirFlag, *daemonTrackerServerFlag, *daemonCollectorServerFlag, *daemonTaskServerFlag, *listenFlag, *disableAutoRefreshIpFlag, *quietFlag)
+		daemon(*daemonConfigDirFlag, *daemonTrackerServerFlag, *daemonCollectorServerFlag, *daemonTaskServerFlag, *listenFlag, *disableAutoRefreshIpFlag, *quietFlag,
+			*relayListenFlag, *relayPortsFlag, *relayCapacityFlag, *relayFlag, *disableRelayFlag, *disableLanAnnounceFlag, *chaosConfigFlag)
 	case "register":
 		registerCommand.Parse(os.Args[2:])
 		register(*registerConfigDirFlag, *registerTrackerServerFlag, *registerListenFlag, *walletAddressFlag, *billEmailFlag, *availabilityFlag,
-			*upBandwidthFlag, *downBandwidthFlag, *portFlag, *hostFlag, *dynamicDomainFlag, *mainStoragePathFlag, *mainStorageVolumeFlag, *extraStorageFlag)
+			*upBandwidthFlag, *downBandwidthFlag, *portFlag, *hostFlag, *dynamicDomainFlag, *mainStoragePathFlag, *mainStorageVolumeFlag, *extraStorageFlag,
+			*registerBenchmarkFlag, *registerBenchmarkPeerFlag)
 	case "addStorage":
 		addStorageCommand.Parse(os.Args[2:])
 		addStorage(*addStorageConfigDirFlag, *addStorageTrackerServerFlag, *pathFlag, *volumeFlag)
@@ -149,6 +217,25 @@ func main() {
 	case "switchPublic":
 		switchPublicCommand.Parse(os.Args[2:])
 		switchPublic(*switchPublicConfigDirFlag, *switchPublicTrackerServerFlag, *switchPublicListenFlag, *switchPublicPortFlag, *switchPublicHostFlag, *switchPublicDynamicDomainFlag)
+	case "encryptAtRest":
+		encryptAtRestCommand.Parse(os.Args[2:])
+		encryptAtRest(*encryptAtRestConfigDirFlag)
+	case "benchmark":
+		benchmarkCommand.Parse(os.Args[2:])
+		runBenchmark(*benchmarkConfigDirFlag, *benchmarkPathFlag, *benchmarkPeerFlag, *benchmarkServeFlag, *benchmarkSizeFlag)
+	case "trash":
+		trashCommand.Parse(os.Args[2:])
+		trash(*trashConfigDirFlag, *restoreFlag)
+	case "journal":
+		journalCommand.Parse(os.Args[2:])
+		queryJournal(*journalConfigDirFlag, *journalBlockFlag, *journalTicketFlag, *journalTypeFlag, *journalFromFlag, *journalToFlag,
+			*journalOutcomeFlag, *journalFormatFlag, *journalOutputFlag)
+	case "ledger":
+		ledgerCommand.Parse(os.Args[2:])
+		exportLedger(*ledgerConfigDirFlag, *ledgerFromFlag, *ledgerToFlag, *ledgerFormatFlag, *ledgerOutputFlag)
+	case "uptime":
+		uptimeCommand.Parse(os.Args[2:])
+		printUptime(*uptimeConfigDirFlag)
 	default:
 		fmt.Printf("%q is not valid command.\n", os.Args[1])
 		os.Exit(102)
@@ -222,7 +309,8 @@ func resendVerifyCode(configDir string, trackerServer string) {
 	fmt.Println("resendVerifyCode success, you can verify bill email.")
 }
 
-func daemon(configDir string, trackerServer string, collectorServer string, taskServer string, listen string, disableAutoRefreshIpFlag bool, quietFlag bool) {
+func daemon(configDir string, trackerServer string, collectorServer string, taskServer string, listen string, disableAutoRefreshIpFlag bool, quietFlag bool,
+	relayListen string, relayPorts string, relayCapacity uint, relayServer string, disableRelay bool, disableLanAnnounce bool, chaosConfig string) {
 	err := config.LoadConfig(configDir)
 	if err != nil {
 		if err == config.NoConfErr {
@@ -237,47 +325,118 @@ func daemon(configDir string, trackerServer string, collectorServer string, task
 	}
 	config.StartAutoCheck()
 	defer config.StopAutoCheck()
-	collector.Start(collectorServer)
+	journal, err := uptime.Open(config.UptimeJournalPath())
+	if err != nil {
+		fmt.Println("open uptime journal failed: " + err.Error())
+		os.Exit(4)
+	}
+	defer journal.Close()
+	journal.Record(uptime.KindStart, true)
+	defer journal.Record(uptime.KindStop, true)
+	if err = activity.Start(config.ActivityJournalDir(), config.ActivityKeepPeriod()); err != nil {
+		fmt.Println("start activity journal failed: " + err.Error())
+		os.Exit(5)
+	}
+	defer activity.Stop()
+	servers := discovery.New(trackerServer, taskServer, collectorServer, config.ServersCachePath())
+	servers.Start()
+	defer servers.Stop()
+	collector.Start(servers.Collectors)
 	defer collector.Stop()
 	var port int
 	private := config.GetProviderConfig().Private
-	providerServer := impl.NewProviderService(taskServer, private)
+	providerServer := impl.NewProviderService(tpb.NewProviderTaskServiceClient(servers.Tasks.Conn()), private)
+	service := withChaos(providerServer, chaosConfig)
 	if !private {
 		port, err = strconv.Atoi(strings.Split(listen, ":")[1])
 		if err != nil {
 			fmt.Println("parse listen port error: " + err.Error())
 			os.Exit(2)
 		}
-		// connect to router
-		igd, err := upnp.Discover()
-		if err != nil {
-			fmt.Println("use upnp get router failed: " + err.Error())
-		} else {
-			err = igd.Forward(uint16(port), "Samos storage")
-			if err != nil {
-				fmt.Println("use upnp port mapping failed: " + err.Error())
-			}
+		// connect to router, mapping is renewed until shutdown
+		if lease := mapPort(uint16(port)); lease != nil {
+			defer lease.Close()
 		}
 		grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(520 * 1024))
-		go startServer(listen, grpcServer, providerServer)
+		go startServer(listen, grpcServer, providerServer, service)
 		defer grpcServer.GracefulStop()
+		if len(relayListen) > 0 {
+			if offered := startRelayServer(trackerServer, relayListen, relayPorts, relayCapacity); offered != nil {
+				defer offered.Close()
+			}
+		}
 	}
 	cronRunner := cron.New()
 	if private {
 		fmt.Println("Starting samos private network node.")
-		cronRunner.AddFunc("@every 5m", func() { client.PrivateAlive(trackerServer) })
+		cronRunner.AddFunc("@every 5m", func() {
+			journal.Record(uptime.KindPrivateAlive, client.PrivateAlive(trackerServer) == nil)
+		})
+		// private network node serves clients through relay and on local network, service is registered once for both
+		grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(520 * 1024))
+		pb.RegisterProviderServiceServer(grpcServer, service)
+		serving := false
+		if !disableRelay {
+			if tunnel := startRelayTunnel(trackerServer, relayServer, grpcServer); tunnel != nil {
+				serving = true
+				defer tunnel.Close()
+				nodeIdHash := util_hash.Sha1(node.LoadFormConfig().NodeId)
+				cronRunner.AddFunc("@every 5m", func() {
+					err := uptime.CheckReachable(fmt.Sprintf("%s:%d", tunnel.Host(), tunnel.Port()), nodeIdHash)
+					if err != nil {
+						log.Warningf("reachability check through relay failed: %s", err)
+					}
+					journal.Record(uptime.KindReachability, err == nil)
+				})
+			}
+		}
+		if !disableLanAnnounce {
+			if announcer := startLanAnnounce(listen, grpcServer); announcer != nil {
+				serving = true
+				defer announcer.Close()
+			}
+		}
+		if serving {
+			defer providerServer.Close()
+			defer grpcServer.GracefulStop()
+		}
 	}
+	var publicIp atomic.Value
+	publicIp.Store("")
 	if !disableAutoRefreshIpFlag && !config.GetProviderConfig().Ddns && !private {
-		refreshIp(trackerServer, port, true)
+		publicIp.Store(refreshIp(trackerServer, port, true))
+		journal.Record(uptime.KindRefreshIp, true)
 		cronRunner.AddFunc("@every 2m", func() {
-			refreshIp(trackerServer, port, false)
+			ip := refreshIp(trackerServer, port, false)
+			journal.Record(uptime.KindRefreshIp, len(ip) > 0)
+			if len(ip) > 0 {
+				publicIp.Store(ip)
+			}
 		})
 	}
+	if !private {
+		nodeIdHash := util_hash.Sha1(node.LoadFormConfig().NodeId)
+		cronRunner.AddFunc("@every 5m", func() {
+			// public address is known only when ip is refreshed by tracker
+			if ip := publicIp.Load().(string); len(ip) > 0 {
+				err := uptime.CheckReachable(fmt.Sprintf("%s:%d", ip, port), nodeIdHash)
+				if err != nil {
+					log.Warningf("self reachability check failed: %s", err)
+				}
+				journal.Record(uptime.KindReachability, err == nil)
+			}
+		})
+	}
+	cronRunner.AddFunc("@every 1m", func() { journal.Record(uptime.KindHeartbeat, true) })
+	cronRunner.AddFunc("@every 1h", func() { warnLowAvailability(journal) })
 	fmt.Println("Node is running.")
 	if !quietFlag {
 		cronRunner.AddFunc("0 * * * * *", func() { fmt.Print(".") })
 	}
 	cronRunner.AddFunc("@every 1m", func() { providerServer.GetTask() })
+	cronRunner.AddFunc("@every 1h", func() { providerServer.PurgeTrash() })
+	go sampleUsage(providerServer)
+	cronRunner.AddFunc(fmt.Sprintf("@every %s", ledger.SampleInterval), func() { sampleUsage(providerServer) })
 	rand.Seed(time.Now().UnixNano())
 	random := rand.Intn(300)
 	cronRunner.AddFunc(fmt.Sprintf("%d %d 0 %d/3 * *", random%60, 30+random/60, time.Now().Day()%3+1), func() { providerServer.VerifyBlocks() })
@@ -289,20 +448,187 @@ func daemon(configDir string, trackerServer string, collectorServer string, task
 	providerServer.CloseTaskProcessor()
 }
 
-func startServer(listen string, grpcServer *grpc.Server, providerServer *impl.ProviderService) {
+// mapPort map port on router by UPnP, PCP or NAT-PMP and keep renewing it, nil if failed
+func mapPort(port uint16) *upnp.Lease {
+	mapper, err := upnp.DiscoverMapper()
+	if err != nil {
+		fmt.Println("get port mapping router failed: " + err.Error())
+		return nil
+	}
+	lease, err := upnp.Keep(mapper, "TCP", port, "Samos storage", upnp.DefaultLifetime)
+	if err != nil {
+		fmt.Printf("use %s port mapping failed: %s\n", mapper.Protocol(), err.Error())
+		return nil
+	}
+	// discover external IP
+	externalIp, err := mapper.ExternalIP()
+	if err != nil {
+		fmt.Printf("use %s get outer ip failed: %s\n", mapper.Protocol(), err.Error())
+	} else {
+		fmt.Printf("use %s get outer ip is: %s\n", mapper.Protocol(), externalIp)
+	}
+	if ep := lease.ExternalPort(); ep != port {
+		fmt.Printf("router mapped outer port %d instead of %d\n", ep, port)
+	}
+	return lease
+}
+
+func startServer(listen string, grpcServer *grpc.Server, providerServer *impl.ProviderService, service pb.ProviderServiceServer) {
 	lis, err := net.Listen("tcp", listen)
 	if err != nil {
 		fmt.Printf("failed to listen: %s, error: %s\n", listen, err.Error())
 		os.Exit(3)
 	}
 	defer providerServer.Close()
-	pb.RegisterProviderServiceServer(grpcServer, providerServer)
+	pb.RegisterProviderServiceServer(grpcServer, service)
 	grpcServer.Serve(lis)
 }
 
+// startRelayServer offer relay to private network nodes and tell tracker, nil if failed
+func startRelayServer(trackerServer string, listen string, ports string, capacity uint) *relay.Server {
+	var portMin, portMax uint64
+	if len(ports) > 0 {
+		arr := strings.Split(ports, "-")
+		var err1, err2 error
+		portMin, err1 = strconv.ParseUint(arr[0], 10, 16)
+		portMax, err2 = strconv.ParseUint(arr[len(arr)-1], 10, 16)
+		if len(arr) > 2 || err1 != nil || err2 != nil || portMin == 0 {
+			fmt.Printf("relayPorts is invalid: %s\n", ports)
+			os.Exit(6)
+		}
+	}
+	port, err := strconv.Atoi(strings.Split(listen, ":")[1])
+	if err != nil {
+		fmt.Println("parse relay listen port error: " + err.Error())
+		os.Exit(6)
+	}
+	conn, err := grpc.Dial(trackerServer, grpc.WithInsecure())
+	if err != nil {
+		fmt.Printf("RPC Dial failed: %s\n", err.Error())
+		return nil
+	}
+	defer conn.Close()
+	prsc := trp_pb.NewProviderRegisterServiceClient(conn)
+	// grants of NATed providers are signed by tracker, key is fetched once so relay is restarted if tracker key changes
+	pubKeyBytes, _, _, err := client.GetPublicKey(prsc)
+	if err != nil {
+		fmt.Println("get tracker public key failed: " + err.Error())
+		return nil
+	}
+	trackerKey, err := x509.ParsePKCS1PublicKey(pubKeyBytes)
+	if err != nil {
+		fmt.Println("parse tracker public key failed: " + err.Error())
+		return nil
+	}
+	server, err := relay.NewServer(listen, uint16(portMin), uint16(portMax), int(capacity), trackerKey)
+	if err != nil {
+		fmt.Printf("failed to start relay: %s, error: %s\n", listen, err.Error())
+		os.Exit(6)
+	}
+	go server.Serve()
+	if err = client.OfferRelay(prsc, uint32(port), uint32(capacity)); err != nil {
+		fmt.Println("offer relay failed: " + err.Error())
+		server.Close()
+		return nil
+	}
+	fmt.Printf("Relay for private network nodes is listening on %s.\n", listen)
+	return server
+}
+
+// startRelayTunnel serve clients of private network node through relay, nil if no relay is available
+func startRelayTunnel(trackerServer string, relayServer string, grpcServer *grpc.Server) *relay.Listener {
+	// grant of tracker is required by configured relay too
+	chosenId, chosen, grant, err := getRelayServer(trackerServer)
+	if err != nil {
+		fmt.Println("get relay server failed: " + err.Error())
+		return nil
+	}
+	var relayNodeId []byte
+	if len(relayServer) == 0 {
+		relayNodeId, relayServer = chosenId, chosen
+	}
+	if len(relayServer) == 0 {
+		fmt.Println("no relay server is available, clients can not connect to this node.")
+		return nil
+	}
+	host, _, err := net.SplitHostPort(relayServer)
+	if err != nil {
+		fmt.Printf("relay server is invalid: %s\n", relayServer)
+		return nil
+	}
+	// grant is renewed on reconnection, last one is used while tracker is unreachable
+	renewGrant := func() (*relay.Grant, error) {
+		if _, _, g, err := getRelayServer(trackerServer); err == nil {
+			grant = g
+		} else if grant.Expire < uint64(time.Now().Unix()) {
+			return nil, err
+		}
+		return grant, nil
+	}
+	// port may change when tunnel is reconnected, tracker is told every time
+	tunnel, err := relay.Listen(relayServer, node.LoadFormConfig(), renewGrant, func(port uint16) {
+		go switchRelay(trackerServer, relayNodeId, host, port)
+	})
+	if err != nil {
+		fmt.Printf("connect relay %s failed: %s\n", relayServer, err.Error())
+		return nil
+	}
+	fmt.Printf("Serving clients through relay %s, outer port: %d.\n", relayServer, tunnel.Port())
+	go grpcServer.Serve(tunnel)
+	return tunnel
+}
+
+func getRelayServer(trackerServer string) (relayNodeId []byte, server string, grant *relay.Grant, err error) {
+	conn, err := grpc.Dial(trackerServer, grpc.WithInsecure())
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer conn.Close()
+	return client.GetRelayServer(trp_pb.NewProviderRegisterServiceClient(conn))
+}
+
+// startLanAnnounce serve clients on local network and announce by SSDP, nil if failed
+func startLanAnnounce(listen string, grpcServer *grpc.Server) *lan.Announcer {
+	port, err := strconv.Atoi(strings.Split(listen, ":")[1])
+	if err != nil {
+		fmt.Println("parse listen port error: " + err.Error())
+		os.Exit(2)
+	}
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		fmt.Printf("failed to listen: %s, error: %s\n", listen, err.Error())
+		return nil
+	}
+	no := node.LoadFormConfig()
+	announcer, err := lan.Announce(no.NodeId, no.PubKeyBytes, no.PriKey, uint16(port))
+	if err != nil {
+		fmt.Println("announce on local network failed: " + err.Error())
+		lis.Close()
+		return nil
+	}
+	go grpcServer.Serve(lis)
+	fmt.Printf("Serving clients on local network, port: %d.\n", port)
+	return announcer
+}
+
+func switchRelay(trackerServer string, relayNodeId []byte, host string, port uint16) {
+	conn, err := grpc.Dial(trackerServer, grpc.WithInsecure())
+	if err != nil {
+		log.Warningf("RPC Dial failed when switch relay, info: %s", err)
+		return
+	}
+	defer conn.Close()
+	code, errMsg, err := client.SwitchRelay(trp_pb.NewProviderRegisterServiceClient(conn), relayNodeId, host, uint32(port))
+	if err != nil {
+		log.Warningf("switch relay failed, info: %s", err)
+	} else if code != 0 {
+		log.Warningf("switch relay failed, code: %d, errMsg: %s", code, errMsg)
+	}
+}
+
 func register(configDir string, trackerServer string, listen string, walletAddress string, billEmail string,
 	availability string, upBandwidth uint, downBandwidth uint, port uint, host string, dynamicDomain string,
-	mainStoragePath string, mainStorageVolume string, extraStorageFlag string) {
+	mainStoragePath string, mainStorageVolume string, extraStorageFlag string, benchmarkFlag bool, benchmarkPeer string) {
 	if config.ConfigExists(configDir) {
 		fmt.Println("config file is adready exsits: " + configDir)
 		os.Exit(2)
@@ -342,12 +668,13 @@ func register(configDir string, trackerServer string, listen string, walletAddre
 		fmt.Println("availability must equal or more than 97%.")
 		os.Exit(10)
 	}
-	if upBandwidth == 0 {
+	measuredBandwidth := benchmarkFlag && len(benchmarkPeer) > 0
+	if upBandwidth == 0 && !measuredBandwidth {
 		fmt.Println("upBandwidth is required.")
 		os.Exit(11)
 	}
 	upBandwidthBps := uint64(upBandwidth) * 1000 * 1000
-	if downBandwidth == 0 {
+	if downBandwidth == 0 && !measuredBandwidth {
 		fmt.Println("downBandwidth is required.")
 		os.Exit(12)
 	}
@@ -428,10 +755,34 @@ func register(configDir string, trackerServer string, listen string, walletAddre
 			index++
 		}
 	}
-	// TODO speed test
 	testUpBandwidthBps := upBandwidthBps
 	testDownBandwidthBps := downBandwidthBps
-	doRegister(configDir, trackerServer, listen, walletAddress, billEmail, availFloat, upBandwidthBps, downBandwidthBps, testUpBandwidthBps, testDownBandwidthBps, uint32(port), host, dynamicDomain, mainStoragePath, mainStorageVolumeByte, extraStorage)
+	var benchmarkResult *config.BenchmarkResult
+	if benchmarkFlag {
+		paths := make([]string, 0, 1+len(extraStorage))
+		paths = append(paths, mainStoragePath)
+		for _, esi := range extraStorage {
+			paths = append(paths, esi.Path)
+		}
+		fmt.Println("Benchmarking, it takes a few minutes...")
+		benchmarkResult, err = benchmark.Run(paths, benchmarkPeer, benchmark.DefaultDiskSize, benchmark.DefaultNetworkSize)
+		if err != nil {
+			fmt.Println("benchmark failed: " + err.Error())
+			os.Exit(28)
+		}
+		printBenchmark(benchmarkResult, nil)
+		// bandwidth measured over local loopback is not the bandwidth of network, configured values are reported
+		if measuredBandwidth {
+			testUpBandwidthBps, testDownBandwidthBps = benchmarkResult.UpBandwidth, benchmarkResult.DownBandwidth
+			if upBandwidthBps == 0 {
+				upBandwidthBps = testUpBandwidthBps
+			}
+			if downBandwidthBps == 0 {
+				downBandwidthBps = testDownBandwidthBps
+			}
+		}
+	}
+	doRegister(configDir, trackerServer, listen, walletAddress, billEmail, availFloat, upBandwidthBps, downBandwidthBps, testUpBandwidthBps, testDownBandwidthBps, uint32(port), host, dynamicDomain, mainStoragePath, mainStorageVolumeByte, extraStorage, benchmarkResult)
 }
 
 func parseStorageVolume(volStr string) (volume uint64, err error) {
@@ -477,29 +828,20 @@ func encrypt(pubKey *rsa.PublicKey, data []byte) []byte {
 func doRegister(configDir string, trackerServer string, listen string, walletAddress string, billEmail string,
 	availability float64, upBandwidth uint64, downBandwidth uint64,
 	testUpBandwidth uint64, testDownBandwidth uint64, port uint32, host string,
-	dynamicDomain string, mainStoragePath string, mainStorageVolume uint64, extraStorage []config.ExtraStorageInfo) {
+	dynamicDomain string, mainStoragePath string, mainStorageVolume uint64, extraStorage []config.ExtraStorageInfo,
+	benchmarkResult *config.BenchmarkResult) {
 	no := node.NewNode(10)
 	pc := newProviderConfig(no, walletAddress, billEmail, availability, upBandwidth, downBandwidth, mainStoragePath, mainStorageVolume, extraStorage)
+	if benchmarkResult != nil {
+		pc.AddBenchmark(*benchmarkResult)
+	}
 	extraStorageSlice := make([]uint64, 0, len(extraStorage))
 	for _, v := range extraStorage {
 		extraStorageSlice = append(extraStorageSlice, v.Volume)
 	}
 	// connect to router
-	igd, err := upnp.Discover()
-	if err != nil {
-		fmt.Println("use upnp get router failed: " + err.Error())
-	} else {
-		err = igd.Forward(uint16(port), "Samos storage")
-		if err != nil {
-			fmt.Println("use upnp port mapping failed: " + err.Error())
-		}
-		// discover external IP
-		externalIp, err := igd.ExternalIP()
-		if err != nil {
-			fmt.Println("use upnp get outer ip failed: " + err.Error())
-		} else {
-			fmt.Println("use upnp get outer ip is: " + externalIp)
-		}
+	if lease := mapPort(uint16(port)); lease != nil {
+		defer lease.Close()
 	}
 	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(520 * 1024))
 	go startPingServer(listen, grpcServer, util_hash.Sha1(no.NodeId))
@@ -680,6 +1022,9 @@ func (self *pingProviderService) GetFragment(ctx context.Context, req *pb.GetFra
 func (self *pingProviderService) CheckAvailable(ctx context.Context, req *pb.CheckAvailableReq) (resp *pb.CheckAvailableResp, err error) {
 	return nil, nil
 }
+func (self *pingProviderService) GetChunkProof(ctx context.Context, req *pb.ChunkProofReq) (*pb.ChunkProofResp, error) {
+	return nil, nil
+}
 func addStorage(configDir string, trackerServer string, path string, volumeStr string) {
 	volume, err := parseStorageVolume(volumeStr)
 	if err != nil {
@@ -823,21 +1168,8 @@ func switchPublic(configDir string, trackerServer string, listen string, port ui
 	}
 	pc := config.GetProviderConfig()
 	// connect to router
-	igd, err := upnp.Discover()
-	if err != nil {
-		fmt.Println("use upnp get router failed: " + err.Error())
-	} else {
-		err = igd.Forward(uint16(port), "Samos storage")
-		if err != nil {
-			fmt.Println("use upnp port mapping failed: " + err.Error())
-		}
-		// discover external IP
-		externalIp, err := igd.ExternalIP()
-		if err != nil {
-			fmt.Println("use upnp get outer ip failed: " + err.Error())
-		} else {
-			fmt.Println("use upnp get outer ip is: " + externalIp)
-		}
+	if lease := mapPort(uint16(port)); lease != nil {
+		defer lease.Close()
 	}
 	nodeId, _, _, _, _, err := config.ParseNode()
 	if err != nil {
@@ -916,6 +1248,373 @@ func switchPublic(configDir string, trackerServer string, listen string, port ui
 	fmt.Println("SwitchPublic failed, the maximum number of retries was reached")
 }
 
+// encryptAtRest enable at-rest encryption and seal existing blocks, daemon must be stopped
+func encryptAtRest(configDir string) {
+	err := config.LoadConfig(configDir)
+	if err != nil {
+		if err == config.NoConfErr {
+			fmt.Printf("Config file is not ready, please run \"%s register\" to register first\n", os.Args[0])
+			os.Exit(200)
+		} else if err == config.ConfVerifyErr {
+			fmt.Println("Config file wrong, can not encrypt at rest.")
+			os.Exit(201)
+		}
+		fmt.Println("failed to load config, can not encrypt at rest: " + err.Error())
+		os.Exit(202)
+	}
+	pc := config.GetProviderConfig()
+	if !pc.EncryptAtRest {
+		pc.EncryptAtRest = true
+		config.SaveProviderConfig()
+	}
+	config.InitStorage()
+	defer config.CloseStorage()
+	sealed, skipped, err := impl.MigrateAtRest()
+	if err != nil {
+		fmt.Printf("encrypt at rest failed, please stop daemon and retry: %s\n", err.Error())
+		os.Exit(2)
+	}
+	fmt.Printf("encrypt at rest finished, sealed %d blocks, skipped %d blocks\n", sealed, skipped)
+}
+
+// runBenchmark benchmark storage paths and network, result is kept in config if registered
+func runBenchmark(configDir string, pathFlag string, peer string, serve string, sizeMB uint) {
+	if len(serve) > 0 {
+		server, addr, err := benchmark.Serve(serve)
+		if err != nil {
+			fmt.Printf("failed to listen: %s, error: %s\n", serve, err.Error())
+			os.Exit(2)
+		}
+		defer server.Stop()
+		fmt.Printf("Benchmark peer is serving on %s\n", addr)
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+		return
+	}
+	var pc *config.ProviderConfig
+	paths := make([]string, 0, 8)
+	if config.ConfigExists(configDir) {
+		if err := config.LoadConfig(configDir); err != nil {
+			fmt.Println("failed to load config, can not benchmark: " + err.Error())
+			os.Exit(3)
+		}
+		pc = config.GetProviderConfig()
+		paths = append(paths, pc.MainStoragePath)
+		for _, esi := range pc.ExtraStorage {
+			paths = append(paths, esi.Path)
+		}
+	} else if len(pathFlag) > 0 {
+		paths = append(paths, strings.Split(pathFlag, ",")...)
+	} else {
+		fmt.Println("path is required if not registered.")
+		os.Exit(4)
+	}
+	fmt.Println("Benchmarking, it takes a few minutes...")
+	res, err := benchmark.Run(paths, peer, int64(sizeMB)*1024*1024, benchmark.DefaultNetworkSize)
+	if err != nil {
+		fmt.Println("benchmark failed: " + err.Error())
+		os.Exit(5)
+	}
+	if pc == nil {
+		printBenchmark(res, nil)
+		return
+	}
+	printBenchmark(res, pc.LastBenchmark())
+	pc.AddBenchmark(*res)
+	config.SaveProviderConfig()
+}
+
+func changeStr(current uint64, previous uint64) string {
+	if previous == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%+.1f%%)", (float64(current)/float64(previous)-1)*100)
+}
+
+// printBenchmark print result and change compared with previous result of same peer and path
+func printBenchmark(res *config.BenchmarkResult, previous *config.BenchmarkResult) {
+	if previous != nil && previous.Peer != res.Peer {
+		previous = nil
+	}
+	peer := res.Peer
+	if len(peer) == 0 {
+		peer = "local loopback"
+	}
+	var up, down uint64
+	if previous != nil {
+		fmt.Printf("compare with benchmark at %s\n", time.Unix(previous.Time, 0).Format(time.RFC3339))
+		up, down = previous.UpBandwidth, previous.DownBandwidth
+	}
+	fmt.Printf("network with %s: up %.1f Mbps%s, down %.1f Mbps%s\n", peer,
+		float64(res.UpBandwidth)/1000/1000, changeStr(res.UpBandwidth, up),
+		float64(res.DownBandwidth)/1000/1000, changeStr(res.DownBandwidth, down))
+	for _, d := range res.Disks {
+		var p config.DiskBenchmark
+		if previous != nil {
+			for _, pd := range previous.Disks {
+				if pd.Path == d.Path {
+					p = pd
+				}
+			}
+		}
+		fmt.Printf("disk %s: sequential write %.1f MB/s%s, sequential read %.1f MB/s%s, random read %d IOPS%s, random write %d IOPS%s\n", d.Path,
+			float64(d.SeqWrite)/1024/1024, changeStr(d.SeqWrite, p.SeqWrite),
+			float64(d.SeqRead)/1024/1024, changeStr(d.SeqRead, p.SeqRead),
+			d.RandReadIops, changeStr(d.RandReadIops, p.RandReadIops),
+			d.RandWriteIops, changeStr(d.RandWriteIops, p.RandWriteIops))
+	}
+}
+
+func parseTimeFlag(name string, value string) time.Time {
+	if len(value) == 0 {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.ParseInLocation("2006-01-02", value, time.Local)
+	}
+	if err != nil {
+		fmt.Printf("%s: %s is not valid time, eg: 2018-06-01 or 2018-06-01T08:00:00+08:00\n", name, value)
+		os.Exit(2)
+	}
+	return t
+}
+
+// queryJournal export activity journal entries matched by filter, it works while daemon is running
+func queryJournal(configDir string, block string, ticket string, typ string, from string, to string, outcome string, format string, output string) {
+	err := config.LoadConfig(configDir)
+	if err != nil {
+		if err == config.NoConfErr {
+			fmt.Printf("Config file is not ready, please run \"%s register\" to register first\n", os.Args[0])
+			os.Exit(200)
+		} else if err == config.ConfVerifyErr {
+			fmt.Println("Config file wrong, can not query journal.")
+			os.Exit(201)
+		}
+		fmt.Println("failed to load config, can not query journal: " + err.Error())
+		os.Exit(202)
+	}
+	filter := &activity.Filter{BlockHash: block, Ticket: ticket, Type: typ,
+		From: parseTimeFlag("from", from), To: parseTimeFlag("to", to)}
+	switch outcome {
+	case "":
+	case "success", "failure":
+		success := outcome == "success"
+		filter.Success = &success
+	default:
+		fmt.Printf("outcome: %s is not valid, must be success or failure\n", outcome)
+		os.Exit(3)
+	}
+	var write func(w io.Writer, entries []*activity.Entry) error
+	switch format {
+	case "csv":
+		write = activity.WriteCSV
+	case "json":
+		write = activity.WriteJSON
+	default:
+		fmt.Printf("format: %s is not valid, must be csv or json\n", format)
+		os.Exit(4)
+	}
+	entries, err := activity.Query(config.ActivityJournalDir(), filter)
+	if err != nil {
+		fmt.Println("query journal failed: " + err.Error())
+		os.Exit(5)
+	}
+	w := io.Writer(os.Stdout)
+	if len(output) > 0 {
+		file, err := os.Create(output)
+		if err != nil {
+			fmt.Printf("create output file %s failed: %s\n", output, err.Error())
+			os.Exit(6)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err = write(w, entries); err != nil {
+		fmt.Println("export journal failed: " + err.Error())
+		os.Exit(7)
+	}
+	if len(output) > 0 {
+		fmt.Printf("exported %d entries to %s\n", len(entries), output)
+	}
+}
+
+func sampleUsage(providerServer *impl.ProviderService) {
+	total, _, err := providerServer.StoredBytes()
+	if err != nil {
+		log.Warningf("sum stored bytes failed: %s", err)
+		return
+	}
+	if err = ledger.RecordSample(config.UsageSamplePath(), total); err != nil {
+		log.Warningf("record usage sample failed: %s", err)
+	}
+}
+
+// exportLedger export daily usage computed from usage samples and activity journal, it works while daemon is running
+func exportLedger(configDir string, from string, to string, format string, output string) {
+	err := config.LoadConfig(configDir)
+	if err != nil {
+		if err == config.NoConfErr {
+			fmt.Printf("Config file is not ready, please run \"%s register\" to register first\n", os.Args[0])
+			os.Exit(200)
+		} else if err == config.ConfVerifyErr {
+			fmt.Println("Config file wrong, can not export ledger.")
+			os.Exit(201)
+		}
+		fmt.Println("failed to load config, can not export ledger: " + err.Error())
+		os.Exit(202)
+	}
+	toTime := parseTimeFlag("to", to)
+	if toTime.IsZero() {
+		toTime = time.Now()
+	}
+	fromTime := parseTimeFlag("from", from)
+	if fromTime.IsZero() {
+		y, m, d := toTime.AddDate(0, 0, -30).Date()
+		fromTime = time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+	var write func(w io.Writer, days []*ledger.Day) error
+	switch format {
+	case "csv":
+		write = ledger.WriteCSV
+	case "json":
+		write = ledger.WriteJSON
+	default:
+		fmt.Printf("format: %s is not valid, must be csv or json\n", format)
+		os.Exit(3)
+	}
+	samples, err := ledger.ReadSamples(config.UsageSamplePath())
+	if err != nil {
+		fmt.Println("read usage samples failed: " + err.Error())
+		os.Exit(4)
+	}
+	entries, err := activity.Query(config.ActivityJournalDir(), &activity.Filter{From: fromTime, To: toTime})
+	if err != nil {
+		fmt.Println("query activity journal failed: " + err.Error())
+		os.Exit(5)
+	}
+	days := ledger.Compute(samples, entries, config.GetProviderConfig().WalletAddress, fromTime, toTime)
+	w := io.Writer(os.Stdout)
+	if len(output) > 0 {
+		file, err := os.Create(output)
+		if err != nil {
+			fmt.Printf("create output file %s failed: %s\n", output, err.Error())
+			os.Exit(6)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err = write(w, days); err != nil {
+		fmt.Println("export ledger failed: " + err.Error())
+		os.Exit(7)
+	}
+	if len(output) > 0 {
+		fmt.Printf("exported %d days to %s\n", len(days), output)
+	}
+}
+
+var availability_windows = []time.Duration{7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+// warnLowAvailability warn if availability of any window is below the promised one, and prune old journal events
+func warnLowAvailability(journal *uptime.Journal) {
+	if err := journal.Prune(uptime.JournalKeep); err != nil {
+		log.Warningf("prune uptime journal failed: %s", err)
+	}
+	events, err := uptime.ReadEvents(config.UptimeJournalPath())
+	if err != nil {
+		log.Warningf("read uptime journal failed: %s", err)
+		return
+	}
+	promised := config.GetProviderConfig().Availability
+	now := time.Now()
+	for _, window := range availability_windows {
+		r := uptime.Compute(events, window, now)
+		if r.Availability() < promised {
+			log.Warningf("availability of last %d days is %.2f%%, below promised %.2f%%", int(window.Hours()/24), r.Availability()*100, promised*100)
+			fmt.Printf("\nWarning: availability of last %d days is %.2f%%, below promised %.2f%%\n", int(window.Hours()/24), r.Availability()*100, promised*100)
+		}
+	}
+}
+
+// printUptime print availability computed from uptime journal, it works while daemon is running
+func printUptime(configDir string) {
+	err := config.LoadConfig(configDir)
+	if err != nil {
+		if err == config.NoConfErr {
+			fmt.Printf("Config file is not ready, please run \"%s register\" to register first\n", os.Args[0])
+			os.Exit(200)
+		} else if err == config.ConfVerifyErr {
+			fmt.Println("Config file wrong, can not read uptime journal.")
+			os.Exit(201)
+		}
+		fmt.Println("failed to load config, can not read uptime journal: " + err.Error())
+		os.Exit(202)
+	}
+	events, err := uptime.ReadEvents(config.UptimeJournalPath())
+	if err != nil {
+		fmt.Println("read uptime journal failed: " + err.Error())
+		os.Exit(2)
+	}
+	promised := config.GetProviderConfig().Availability
+	fmt.Printf("promised availability: %.2f%%\n", promised*100)
+	now := time.Now()
+	for _, window := range availability_windows {
+		r := uptime.Compute(events, window, now)
+		fmt.Printf("last %d days: availability %.2f%%, observed %.1f hours, running %.1f hours, up %.1f hours",
+			int(window.Hours()/24), r.Availability()*100, r.Observed.Hours(), r.Running.Hours(), r.Up.Hours())
+		for _, kind := range []uptime.Kind{uptime.KindRefreshIp, uptime.KindPrivateAlive, uptime.KindReachability} {
+			if n := r.Failures[kind]; n > 0 {
+				fmt.Printf(", %s failed %d times", kind, n)
+			}
+		}
+		if r.Availability() < promised {
+			fmt.Print(", BELOW PROMISED")
+		}
+		fmt.Println()
+	}
+}
+
+// trash list or restore blocks removed by tracker in grace period, daemon must be stopped
+func trash(configDir string, restore string) {
+	err := config.LoadConfig(configDir)
+	if err != nil {
+		if err == config.NoConfErr {
+			fmt.Printf("Config file is not ready, please run \"%s register\" to register first\n", os.Args[0])
+			os.Exit(200)
+		} else if err == config.ConfVerifyErr {
+			fmt.Println("Config file wrong, can not access trash.")
+			os.Exit(201)
+		}
+		fmt.Println("failed to load config, can not access trash: " + err.Error())
+		os.Exit(202)
+	}
+	config.InitStorage()
+	defer config.CloseStorage()
+	if len(restore) > 0 {
+		key, err := hex.DecodeString(restore)
+		if err != nil {
+			fmt.Printf("decode block key %s failed: %s\n", restore, err.Error())
+			os.Exit(2)
+		}
+		if err = impl.RestoreTrash(key); err != nil {
+			fmt.Printf("restore block %s failed, please stop daemon and retry: %s\n", restore, err.Error())
+			os.Exit(3)
+		}
+		fmt.Println("restore success: " + restore)
+		return
+	}
+	list, err := impl.ListTrash()
+	if err != nil {
+		fmt.Printf("list trash failed, please stop daemon and retry: %s\n", err.Error())
+		os.Exit(4)
+	}
+	for _, t := range list {
+		fmt.Printf("%x\tsize: %d\tremoved: %s\tpurge: %s\n", t.Key, t.BlockSize, t.RemoveTime.Format(time.RFC3339), t.PurgeTime().Format(time.RFC3339))
+	}
+	fmt.Printf("%d blocks in trash\n", len(list))
+}
+
 func newProviderConfig(no *node.Node, walletAddress string, billEmail string,
 	availability float64, upBandwidth uint64, downBandwidth uint64,
 	mainStoragePath string, mainStorageVolume uint64, extraStorage []config.ExtraStorageInfo) *config.ProviderConfig {
@@ -0,0 +1,20 @@
+//go:build !chaos
+// +build !chaos
+
+package main
+
+import (
+	"flag"
+
+	pb "github.com/samoslab/nebula/provider/pb"
+)
+
+// chaosFlag production binary has no fault injector and no chaosConfig flag
+func chaosFlag(command *flag.FlagSet) *string {
+	var none string
+	return &none
+}
+
+func withChaos(service pb.ProviderServiceServer, chaosConfig string) pb.ProviderServiceServer {
+	return service
+}
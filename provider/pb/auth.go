@@ -58,6 +58,10 @@ func (self *RetrieveReq) CheckAuth(publicKeyBytes []byte) error {
 	return checkAuth(publicKeyBytes, method_retrieve, self.FileKey, self.FileSize, self.BlockKey, self.BlockSize, self.Timestamp, self.Ticket, self.Auth)
 }
 
+func (self *ChunkProofReq) CheckAuth(publicKeyBytes []byte) error {
+	return checkAuth(publicKeyBytes, method_retrieve, self.FileKey, self.FileSize, self.BlockKey, self.BlockSize, self.Timestamp, self.Ticket, self.Auth)
+}
+
 func (self *RemoveReq) CheckAuth(publicKeyBytes []byte) error {
 	return checkAuth(publicKeyBytes, method_remove, nil, 0, self.Key, self.Size, self.Timestamp, "", self.Auth)
 }
@@ -88,6 +92,9 @@ func (self *StoreReq) GenAuth(publicKeyBytes []byte) {
 func (self *RetrieveReq) GenAuth(publicKeyBytes []byte) {
 	self.Auth = genAuth(publicKeyBytes, method_retrieve, self.FileKey, self.FileSize, self.BlockKey, self.BlockSize, self.Timestamp, self.Ticket)
 }
+func (self *ChunkProofReq) GenAuth(publicKeyBytes []byte) {
+	self.Auth = genAuth(publicKeyBytes, method_retrieve, self.FileKey, self.FileSize, self.BlockKey, self.BlockSize, self.Timestamp, self.Ticket)
+}
 func (self *RemoveReq) GenAuth(publicKeyBytes []byte) {
 	self.Auth = genAuth(publicKeyBytes, method_remove, nil, 0, self.Key, self.Size, self.Timestamp, "")
 }
@@ -31,3 +31,24 @@ func Test(t *testing.T) {
 		t.Errorf("failed")
 	}
 }
+
+func TestBlockTagSplit(t *testing.T) {
+	tag := &BlockTag{ParamStr: "type a", ChunkSize: 10, Phi: [][]byte{{1}, {2}, {3}, {4}, {5}}}
+	for parts := 1; parts < 8; parts++ {
+		var merged *BlockTag
+		for _, p := range tag.Split(parts) {
+			merged = merged.Merge(p)
+		}
+		if merged.ParamStr != tag.ParamStr || merged.ChunkSize != tag.ChunkSize || len(merged.Phi) != len(tag.Phi) {
+			t.Errorf("parts %d merged tag not same", parts)
+		}
+		for i := range tag.Phi {
+			if merged.Phi[i][0] != tag.Phi[i][0] {
+				t.Errorf("parts %d phi order wrong", parts)
+			}
+		}
+	}
+	if !tag.Complete(50) || tag.Complete(51) {
+		t.Errorf("complete check failed")
+	}
+}
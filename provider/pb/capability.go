@@ -0,0 +1,122 @@
+package provider_pb
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ProtocolVersion is sent in PingReq and returned in PingResp and CheckAvailableResp, 1 is the version before capability handshake
+const ProtocolVersion uint32 = 2
+
+const legacy_protocol_version uint32 = 1
+
+var supported_features = []Feature{Feature_CHUNK_PROOF, Feature_BLOCK_TAG, Feature_MULTIHASH_KEY}
+
+// SupportedFeatures features this build of provider serves
+func SupportedFeatures() []Feature {
+	return append([]Feature{}, supported_features...)
+}
+
+// Capability is protocol version and features of a provider
+type Capability struct {
+	Version  uint32
+	features map[Feature]bool
+}
+
+// NewCapability from ping response, provider without handshake is legacy version without any feature
+func NewCapability(resp *PingResp) *Capability {
+	c := &Capability{Version: legacy_protocol_version, features: make(map[Feature]bool)}
+	if resp == nil || resp.Version == 0 {
+		return c
+	}
+	c.Version = resp.Version
+	for _, f := range resp.Features {
+		c.features[f] = true
+	}
+	return c
+}
+
+// Has check provider supports feature, nil capability supports nothing
+func (self *Capability) Has(f Feature) bool {
+	return self != nil && self.features[f]
+}
+
+// NegotiatedVersion is the lower one of two sides
+func (self *Capability) NegotiatedVersion() uint32 {
+	if self == nil {
+		return legacy_protocol_version
+	}
+	return NegotiateVersion(self.Version)
+}
+
+// NegotiateVersion is the lower one of version of other side and ProtocolVersion, 0 is a caller older than capability
+// handshake
+func NegotiateVersion(version uint32) uint32 {
+	if version == 0 {
+		return legacy_protocol_version
+	}
+	if version < ProtocolVersion {
+		return version
+	}
+	return ProtocolVersion
+}
+
+// Handshake answer provider gives to ping with version of caller, legacy caller gets no feature
+func Handshake(version uint32) (uint32, []Feature) {
+	version = NegotiateVersion(version)
+	if version == legacy_protocol_version {
+		return version, nil
+	}
+	return version, SupportedFeatures()
+}
+
+type capabilityEntry struct {
+	capability *Capability
+	expire     time.Time
+}
+
+// CapabilityCache keep capability per node, expired entry is queried again so upgraded provider is picked up
+type CapabilityCache struct {
+	mutex sync.RWMutex
+	ttl   time.Duration
+	m     map[string]*capabilityEntry
+}
+
+func NewCapabilityCache(ttl time.Duration) *CapabilityCache {
+	return &CapabilityCache{ttl: ttl, m: make(map[string]*capabilityEntry)}
+}
+
+// Get return nil if not cached or expired
+func (self *CapabilityCache) Get(node string) *Capability {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+	e, ok := self.m[node]
+	if !ok || time.Now().After(e.expire) {
+		return nil
+	}
+	return e.capability
+}
+
+func (self *CapabilityCache) Put(node string, capability *Capability) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.m[node] = &capabilityEntry{capability: capability, expire: time.Now().Add(self.ttl)}
+}
+
+// Query return cached capability of node, or ping provider with protocol version, capability is not cached if ping failed
+func (self *CapabilityCache) Query(psc ProviderServiceClient, node string, timeout time.Duration) (*Capability, error) {
+	if c := self.Get(node); c != nil {
+		return c, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := psc.Ping(ctx, &PingReq{Version: ProtocolVersion})
+	if err != nil {
+		return nil, err
+	}
+	c := NewCapability(resp)
+	self.Put(node, c)
+	return c, nil
+}
@@ -0,0 +1,42 @@
+package provider_pb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCapability(t *testing.T) {
+	legacy := NewCapability(&PingResp{NodeIdHash: []byte{1}})
+	if legacy.Version != legacy_protocol_version || legacy.Has(Feature_CHUNK_PROOF) || legacy.NegotiatedVersion() != legacy_protocol_version {
+		t.Errorf("provider without handshake should be legacy")
+	}
+	c := NewCapability(&PingResp{Version: ProtocolVersion + 1, Features: []Feature{Feature_BLOCK_TAG, Feature_TLS}})
+	if !c.Has(Feature_BLOCK_TAG) || !c.Has(Feature_TLS) || c.Has(Feature_BATCH_STORE) {
+		t.Errorf("features error")
+	}
+	if c.NegotiatedVersion() != ProtocolVersion {
+		t.Errorf("negotiated version should be the lower one: %d", c.NegotiatedVersion())
+	}
+	if version, features := Handshake(ProtocolVersion + 1); version != ProtocolVersion || len(features) == 0 {
+		t.Errorf("newer caller should get protocol version and features: %d %v", version, features)
+	}
+	for _, caller := range []uint32{0, legacy_protocol_version} {
+		if version, features := Handshake(caller); version != legacy_protocol_version || features != nil {
+			t.Errorf("legacy caller %d should get legacy version without features: %d %v", caller, version, features)
+		}
+	}
+	var none *Capability
+	if none.Has(Feature_BLOCK_TAG) {
+		t.Errorf("nil capability should have no feature")
+	}
+
+	cache := NewCapabilityCache(50 * time.Millisecond)
+	cache.Put("127.0.0.1:6666", c)
+	if cache.Get("127.0.0.1:6666") != c || cache.Get("127.0.0.1:7777") != nil {
+		t.Errorf("cache get error")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if cache.Get("127.0.0.1:6666") != nil {
+		t.Errorf("cache should expire")
+	}
+}
@@ -11,6 +11,7 @@ It has these top-level messages:
 	PingReq
 	PingResp
 	StoreReq
+	BlockTag
 	StoreResp
 	RetrieveReq
 	RetrieveResp
@@ -20,6 +21,8 @@ It has these top-level messages:
 	GetFragmentResp
 	CheckAvailableReq
 	CheckAvailableResp
+	ChunkProofReq
+	ChunkProofResp
 */
 package provider_pb
 
@@ -43,6 +46,42 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type Feature int32
+
+const (
+	Feature_NONE            Feature = 0
+	Feature_CHUNK_PROOF     Feature = 1
+	Feature_BLOCK_TAG       Feature = 2
+	Feature_MULTIHASH_KEY   Feature = 3
+	Feature_RANGED_RETRIEVE Feature = 4
+	Feature_BATCH_STORE     Feature = 5
+	Feature_TLS             Feature = 6
+)
+
+var Feature_name = map[int32]string{
+	0: "NONE",
+	1: "CHUNK_PROOF",
+	2: "BLOCK_TAG",
+	3: "MULTIHASH_KEY",
+	4: "RANGED_RETRIEVE",
+	5: "BATCH_STORE",
+	6: "TLS",
+}
+var Feature_value = map[string]int32{
+	"NONE":            0,
+	"CHUNK_PROOF":     1,
+	"BLOCK_TAG":       2,
+	"MULTIHASH_KEY":   3,
+	"RANGED_RETRIEVE": 4,
+	"BATCH_STORE":     5,
+	"TLS":             6,
+}
+
+func (x Feature) String() string {
+	return proto.EnumName(Feature_name, int32(x))
+}
+func (Feature) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
 type PingReq struct {
 	Version uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
 }
@@ -60,7 +99,9 @@ func (m *PingReq) GetVersion() uint32 {
 }
 
 type PingResp struct {
-	NodeIdHash []byte `protobuf:"bytes,1,opt,name=nodeIdHash,proto3" json:"nodeIdHash,omitempty"`
+	NodeIdHash []byte    `protobuf:"bytes,1,opt,name=nodeIdHash,proto3" json:"nodeIdHash,omitempty"`
+	Version    uint32    `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
+	Features   []Feature `protobuf:"varint,3,rep,packed,name=features,enum=provider.pb.Feature" json:"features,omitempty"`
 }
 
 func (m *PingResp) Reset()                    { *m = PingResp{} }
@@ -75,16 +116,31 @@ func (m *PingResp) GetNodeIdHash() []byte {
 	return nil
 }
 
+func (m *PingResp) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *PingResp) GetFeatures() []Feature {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
 type StoreReq struct {
-	Data      []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
-	Version   uint32 `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
-	Auth      []byte `protobuf:"bytes,3,opt,name=auth,proto3" json:"auth,omitempty"`
-	Timestamp uint64 `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
-	Ticket    string `protobuf:"bytes,5,opt,name=ticket" json:"ticket,omitempty"`
-	FileKey   []byte `protobuf:"bytes,6,opt,name=fileKey,proto3" json:"fileKey,omitempty"`
-	FileSize  uint64 `protobuf:"varint,7,opt,name=fileSize" json:"fileSize,omitempty"`
-	BlockKey  []byte `protobuf:"bytes,8,opt,name=blockKey,proto3" json:"blockKey,omitempty"`
-	BlockSize uint64 `protobuf:"varint,9,opt,name=blockSize" json:"blockSize,omitempty"`
+	Data      []byte    `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Version   uint32    `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
+	Auth      []byte    `protobuf:"bytes,3,opt,name=auth,proto3" json:"auth,omitempty"`
+	Timestamp uint64    `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	Ticket    string    `protobuf:"bytes,5,opt,name=ticket" json:"ticket,omitempty"`
+	FileKey   []byte    `protobuf:"bytes,6,opt,name=fileKey,proto3" json:"fileKey,omitempty"`
+	FileSize  uint64    `protobuf:"varint,7,opt,name=fileSize" json:"fileSize,omitempty"`
+	BlockKey  []byte    `protobuf:"bytes,8,opt,name=blockKey,proto3" json:"blockKey,omitempty"`
+	BlockSize uint64    `protobuf:"varint,9,opt,name=blockSize" json:"blockSize,omitempty"`
+	Tag       *BlockTag `protobuf:"bytes,10,opt,name=tag" json:"tag,omitempty"`
 }
 
 func (m *StoreReq) Reset()                    { *m = StoreReq{} }
@@ -155,6 +211,45 @@ func (m *StoreReq) GetBlockSize() uint64 {
 	return 0
 }
 
+func (m *StoreReq) GetTag() *BlockTag {
+	if m != nil {
+		return m.Tag
+	}
+	return nil
+}
+
+type BlockTag struct {
+	ParamStr  string   `protobuf:"bytes,1,opt,name=paramStr" json:"paramStr,omitempty"`
+	ChunkSize uint32   `protobuf:"varint,2,opt,name=chunkSize" json:"chunkSize,omitempty"`
+	Phi       [][]byte `protobuf:"bytes,3,rep,name=phi,proto3" json:"phi,omitempty"`
+}
+
+func (m *BlockTag) Reset()                    { *m = BlockTag{} }
+func (m *BlockTag) String() string            { return proto.CompactTextString(m) }
+func (*BlockTag) ProtoMessage()               {}
+func (*BlockTag) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *BlockTag) GetParamStr() string {
+	if m != nil {
+		return m.ParamStr
+	}
+	return ""
+}
+
+func (m *BlockTag) GetChunkSize() uint32 {
+	if m != nil {
+		return m.ChunkSize
+	}
+	return 0
+}
+
+func (m *BlockTag) GetPhi() [][]byte {
+	if m != nil {
+		return m.Phi
+	}
+	return nil
+}
+
 type StoreResp struct {
 	Success bool `protobuf:"varint,1,opt,name=success" json:"success,omitempty"`
 }
@@ -162,7 +257,7 @@ type StoreResp struct {
 func (m *StoreResp) Reset()                    { *m = StoreResp{} }
 func (m *StoreResp) String() string            { return proto.CompactTextString(m) }
 func (*StoreResp) ProtoMessage()               {}
-func (*StoreResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+func (*StoreResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
 
 func (m *StoreResp) GetSuccess() bool {
 	if m != nil {
@@ -180,12 +275,13 @@ type RetrieveReq struct {
 	FileSize  uint64 `protobuf:"varint,6,opt,name=fileSize" json:"fileSize,omitempty"`
 	BlockKey  []byte `protobuf:"bytes,7,opt,name=blockKey,proto3" json:"blockKey,omitempty"`
 	BlockSize uint64 `protobuf:"varint,8,opt,name=blockSize" json:"blockSize,omitempty"`
+	WithTag   bool   `protobuf:"varint,9,opt,name=withTag" json:"withTag,omitempty"`
 }
 
 func (m *RetrieveReq) Reset()                    { *m = RetrieveReq{} }
 func (m *RetrieveReq) String() string            { return proto.CompactTextString(m) }
 func (*RetrieveReq) ProtoMessage()               {}
-func (*RetrieveReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*RetrieveReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
 func (m *RetrieveReq) GetVersion() uint32 {
 	if m != nil {
@@ -243,14 +339,22 @@ func (m *RetrieveReq) GetBlockSize() uint64 {
 	return 0
 }
 
+func (m *RetrieveReq) GetWithTag() bool {
+	if m != nil {
+		return m.WithTag
+	}
+	return false
+}
+
 type RetrieveResp struct {
-	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Data []byte    `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Tag  *BlockTag `protobuf:"bytes,2,opt,name=tag" json:"tag,omitempty"`
 }
 
 func (m *RetrieveResp) Reset()                    { *m = RetrieveResp{} }
 func (m *RetrieveResp) String() string            { return proto.CompactTextString(m) }
 func (*RetrieveResp) ProtoMessage()               {}
-func (*RetrieveResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*RetrieveResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 func (m *RetrieveResp) GetData() []byte {
 	if m != nil {
@@ -259,6 +363,13 @@ func (m *RetrieveResp) GetData() []byte {
 	return nil
 }
 
+func (m *RetrieveResp) GetTag() *BlockTag {
+	if m != nil {
+		return m.Tag
+	}
+	return nil
+}
+
 type RemoveReq struct {
 	Version   uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
 	Auth      []byte `protobuf:"bytes,2,opt,name=auth,proto3" json:"auth,omitempty"`
@@ -270,7 +381,7 @@ type RemoveReq struct {
 func (m *RemoveReq) Reset()                    { *m = RemoveReq{} }
 func (m *RemoveReq) String() string            { return proto.CompactTextString(m) }
 func (*RemoveReq) ProtoMessage()               {}
-func (*RemoveReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*RemoveReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 func (m *RemoveReq) GetVersion() uint32 {
 	if m != nil {
@@ -314,7 +425,7 @@ type RemoveResp struct {
 func (m *RemoveResp) Reset()                    { *m = RemoveResp{} }
 func (m *RemoveResp) String() string            { return proto.CompactTextString(m) }
 func (*RemoveResp) ProtoMessage()               {}
-func (*RemoveResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*RemoveResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 func (m *RemoveResp) GetSuccess() bool {
 	if m != nil {
@@ -335,7 +446,7 @@ type GetFragmentReq struct {
 func (m *GetFragmentReq) Reset()                    { *m = GetFragmentReq{} }
 func (m *GetFragmentReq) String() string            { return proto.CompactTextString(m) }
 func (*GetFragmentReq) ProtoMessage()               {}
-func (*GetFragmentReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*GetFragmentReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
 func (m *GetFragmentReq) GetVersion() uint32 {
 	if m != nil {
@@ -386,7 +497,7 @@ type GetFragmentResp struct {
 func (m *GetFragmentResp) Reset()                    { *m = GetFragmentResp{} }
 func (m *GetFragmentResp) String() string            { return proto.CompactTextString(m) }
 func (*GetFragmentResp) ProtoMessage()               {}
-func (*GetFragmentResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (*GetFragmentResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
 func (m *GetFragmentResp) GetData() [][]byte {
 	if m != nil {
@@ -405,7 +516,7 @@ type CheckAvailableReq struct {
 func (m *CheckAvailableReq) Reset()                    { *m = CheckAvailableReq{} }
 func (m *CheckAvailableReq) String() string            { return proto.CompactTextString(m) }
 func (*CheckAvailableReq) ProtoMessage()               {}
-func (*CheckAvailableReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (*CheckAvailableReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
 
 func (m *CheckAvailableReq) GetVersion() uint32 {
 	if m != nil {
@@ -444,7 +555,7 @@ type CheckAvailableResp struct {
 func (m *CheckAvailableResp) Reset()                    { *m = CheckAvailableResp{} }
 func (m *CheckAvailableResp) String() string            { return proto.CompactTextString(m) }
 func (*CheckAvailableResp) ProtoMessage()               {}
-func (*CheckAvailableResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*CheckAvailableResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
 func (m *CheckAvailableResp) GetTotal() uint64 {
 	if m != nil {
@@ -467,10 +578,139 @@ func (m *CheckAvailableResp) GetVersion() uint32 {
 	return 0
 }
 
+type ChunkProofReq struct {
+	Version    uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Auth       []byte `protobuf:"bytes,2,opt,name=auth,proto3" json:"auth,omitempty"`
+	Timestamp  uint64 `protobuf:"varint,3,opt,name=timestamp" json:"timestamp,omitempty"`
+	Ticket     string `protobuf:"bytes,4,opt,name=ticket" json:"ticket,omitempty"`
+	FileKey    []byte `protobuf:"bytes,5,opt,name=fileKey,proto3" json:"fileKey,omitempty"`
+	FileSize   uint64 `protobuf:"varint,6,opt,name=fileSize" json:"fileSize,omitempty"`
+	BlockKey   []byte `protobuf:"bytes,7,opt,name=blockKey,proto3" json:"blockKey,omitempty"`
+	BlockSize  uint64 `protobuf:"varint,8,opt,name=blockSize" json:"blockSize,omitempty"`
+	ChunkIndex uint32 `protobuf:"varint,9,opt,name=chunkIndex" json:"chunkIndex,omitempty"`
+}
+
+func (m *ChunkProofReq) Reset()                    { *m = ChunkProofReq{} }
+func (m *ChunkProofReq) String() string            { return proto.CompactTextString(m) }
+func (*ChunkProofReq) ProtoMessage()               {}
+func (*ChunkProofReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *ChunkProofReq) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *ChunkProofReq) GetAuth() []byte {
+	if m != nil {
+		return m.Auth
+	}
+	return nil
+}
+
+func (m *ChunkProofReq) GetTimestamp() uint64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *ChunkProofReq) GetTicket() string {
+	if m != nil {
+		return m.Ticket
+	}
+	return ""
+}
+
+func (m *ChunkProofReq) GetFileKey() []byte {
+	if m != nil {
+		return m.FileKey
+	}
+	return nil
+}
+
+func (m *ChunkProofReq) GetFileSize() uint64 {
+	if m != nil {
+		return m.FileSize
+	}
+	return 0
+}
+
+func (m *ChunkProofReq) GetBlockKey() []byte {
+	if m != nil {
+		return m.BlockKey
+	}
+	return nil
+}
+
+func (m *ChunkProofReq) GetBlockSize() uint64 {
+	if m != nil {
+		return m.BlockSize
+	}
+	return 0
+}
+
+func (m *ChunkProofReq) GetChunkIndex() uint32 {
+	if m != nil {
+		return m.ChunkIndex
+	}
+	return 0
+}
+
+type ChunkProofResp struct {
+	Data       []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	ChunkSize  uint32   `protobuf:"varint,2,opt,name=chunkSize" json:"chunkSize,omitempty"`
+	ChunkCount uint32   `protobuf:"varint,3,opt,name=chunkCount" json:"chunkCount,omitempty"`
+	Path       [][]byte `protobuf:"bytes,4,rep,name=path,proto3" json:"path,omitempty"`
+	Root       []byte   `protobuf:"bytes,5,opt,name=root,proto3" json:"root,omitempty"`
+}
+
+func (m *ChunkProofResp) Reset()                    { *m = ChunkProofResp{} }
+func (m *ChunkProofResp) String() string            { return proto.CompactTextString(m) }
+func (*ChunkProofResp) ProtoMessage()               {}
+func (*ChunkProofResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *ChunkProofResp) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *ChunkProofResp) GetChunkSize() uint32 {
+	if m != nil {
+		return m.ChunkSize
+	}
+	return 0
+}
+
+func (m *ChunkProofResp) GetChunkCount() uint32 {
+	if m != nil {
+		return m.ChunkCount
+	}
+	return 0
+}
+
+func (m *ChunkProofResp) GetPath() [][]byte {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+func (m *ChunkProofResp) GetRoot() []byte {
+	if m != nil {
+		return m.Root
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*PingReq)(nil), "provider.pb.PingReq")
 	proto.RegisterType((*PingResp)(nil), "provider.pb.PingResp")
 	proto.RegisterType((*StoreReq)(nil), "provider.pb.StoreReq")
+	proto.RegisterType((*BlockTag)(nil), "provider.pb.BlockTag")
 	proto.RegisterType((*StoreResp)(nil), "provider.pb.StoreResp")
 	proto.RegisterType((*RetrieveReq)(nil), "provider.pb.RetrieveReq")
 	proto.RegisterType((*RetrieveResp)(nil), "provider.pb.RetrieveResp")
@@ -480,6 +720,9 @@ func init() {
 	proto.RegisterType((*GetFragmentResp)(nil), "provider.pb.GetFragmentResp")
 	proto.RegisterType((*CheckAvailableReq)(nil), "provider.pb.CheckAvailableReq")
 	proto.RegisterType((*CheckAvailableResp)(nil), "provider.pb.CheckAvailableResp")
+	proto.RegisterType((*ChunkProofReq)(nil), "provider.pb.ChunkProofReq")
+	proto.RegisterType((*ChunkProofResp)(nil), "provider.pb.ChunkProofResp")
+	proto.RegisterEnum("provider.pb.Feature", Feature_name, Feature_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -493,7 +736,7 @@ const _ = grpc.SupportPackageIsVersion4
 // Client API for ProviderService service
 
 type ProviderServiceClient interface {
-	// no error
+	// no error, also capability handshake: version of PingReq is protocol version of caller, PingResp returns protocol version and features of provider
 	Ping(ctx context.Context, in *PingReq, opts ...grpc.CallOption) (*PingResp, error)
 	// codes.Unknown, "RPC Recv failed unexpectadely while reading chunks from stream, blockKey: %x error: %s"
 	// codes.InvalidArgument, "check data size failed, blockKey: %x"
@@ -540,6 +783,12 @@ type ProviderServiceClient interface {
 	// deprecated
 	GetFragment(ctx context.Context, in *GetFragmentReq, opts ...grpc.CallOption) (*GetFragmentResp, error)
 	CheckAvailable(ctx context.Context, in *CheckAvailableReq, opts ...grpc.CallOption) (*CheckAvailableResp, error)
+	// codes.Unauthenticated, "check auth failed, blockKey: %x error: %s"
+	// codes.NotFound, "file not exist, blockKey: %x"
+	// codes.Internal, "read merkle leaves failed, blockKey: %x error: %s"
+	// codes.OutOfRange, "chunk index %d out of range, chunk count: %d, blockKey: %x"
+	// codes.Internal, "read chunk failed, blockKey: %x error: %s"
+	GetChunkProof(ctx context.Context, in *ChunkProofReq, opts ...grpc.CallOption) (*ChunkProofResp, error)
 }
 
 type providerServiceClient struct {
@@ -670,10 +919,19 @@ func (c *providerServiceClient) CheckAvailable(ctx context.Context, in *CheckAva
 	return out, nil
 }
 
+func (c *providerServiceClient) GetChunkProof(ctx context.Context, in *ChunkProofReq, opts ...grpc.CallOption) (*ChunkProofResp, error) {
+	out := new(ChunkProofResp)
+	err := grpc.Invoke(ctx, "/provider.pb.ProviderService/GetChunkProof", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for ProviderService service
 
 type ProviderServiceServer interface {
-	// no error
+	// no error, also capability handshake: version of PingReq is protocol version of caller, PingResp returns protocol version and features of provider
 	Ping(context.Context, *PingReq) (*PingResp, error)
 	// codes.Unknown, "RPC Recv failed unexpectadely while reading chunks from stream, blockKey: %x error: %s"
 	// codes.InvalidArgument, "check data size failed, blockKey: %x"
@@ -720,6 +978,12 @@ type ProviderServiceServer interface {
 	// deprecated
 	GetFragment(context.Context, *GetFragmentReq) (*GetFragmentResp, error)
 	CheckAvailable(context.Context, *CheckAvailableReq) (*CheckAvailableResp, error)
+	// codes.Unauthenticated, "check auth failed, blockKey: %x error: %s"
+	// codes.NotFound, "file not exist, blockKey: %x"
+	// codes.Internal, "read merkle leaves failed, blockKey: %x error: %s"
+	// codes.OutOfRange, "chunk index %d out of range, chunk count: %d, blockKey: %x"
+	// codes.Internal, "read chunk failed, blockKey: %x error: %s"
+	GetChunkProof(context.Context, *ChunkProofReq) (*ChunkProofResp, error)
 }
 
 func RegisterProviderServiceServer(s *grpc.Server, srv ProviderServiceServer) {
@@ -881,6 +1145,24 @@ func _ProviderService_CheckAvailable_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProviderService_GetChunkProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChunkProofReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServiceServer).GetChunkProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/provider.pb.ProviderService/GetChunkProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServiceServer).GetChunkProof(ctx, req.(*ChunkProofReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ProviderService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "provider.pb.ProviderService",
 	HandlerType: (*ProviderServiceServer)(nil),
@@ -909,6 +1191,10 @@ var _ProviderService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CheckAvailable",
 			Handler:    _ProviderService_CheckAvailable_Handler,
 		},
+		{
+			MethodName: "GetChunkProof",
+			Handler:    _ProviderService_GetChunkProof_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -928,43 +1214,62 @@ var _ProviderService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("provider.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 599 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x55, 0xdd, 0x6e, 0xd3, 0x4c,
-	0x10, 0xfd, 0xdc, 0x38, 0x89, 0x33, 0xf9, 0xe9, 0xc7, 0xa8, 0x04, 0x63, 0xaa, 0x12, 0x19, 0x15,
-	0x45, 0x5c, 0x44, 0x08, 0xc4, 0x0d, 0x88, 0x0b, 0x54, 0x29, 0xfc, 0xdd, 0x54, 0xce, 0x13, 0x6c,
-	0x9c, 0x6d, 0xb3, 0x8a, 0x9d, 0x35, 0xde, 0x6d, 0x44, 0x91, 0x10, 0xaf, 0xc2, 0x13, 0xf0, 0x4e,
-	0xbc, 0x02, 0x4f, 0x80, 0x76, 0x6d, 0x27, 0xde, 0x34, 0x8e, 0x04, 0xaa, 0xb8, 0x9b, 0x99, 0x9d,
-	0x39, 0x73, 0x7c, 0x32, 0x33, 0x81, 0x5e, 0x92, 0xf2, 0x15, 0x9b, 0xd1, 0x74, 0x94, 0xa4, 0x5c,
-	0x72, 0x6c, 0x6f, 0xfc, 0xa9, 0xff, 0x08, 0x9a, 0xe7, 0x6c, 0x79, 0x19, 0xd0, 0x4f, 0xe8, 0x42,
-	0x73, 0x45, 0x53, 0xc1, 0xf8, 0xd2, 0xb5, 0x06, 0xd6, 0xb0, 0x1b, 0x14, 0xae, 0xff, 0x04, 0x9c,
-	0x2c, 0x49, 0x24, 0x78, 0x02, 0xb0, 0xe4, 0x33, 0xfa, 0x7e, 0xf6, 0x8e, 0x88, 0xb9, 0x4e, 0xec,
-	0x04, 0xa5, 0x88, 0xff, 0xcb, 0x02, 0x67, 0x22, 0x79, 0x4a, 0x15, 0x24, 0x82, 0x3d, 0x23, 0x92,
-	0xe4, 0x69, 0xda, 0x2e, 0xb7, 0x39, 0x30, 0xda, 0xa8, 0x6c, 0x72, 0x25, 0xe7, 0x6e, 0x2d, 0xcb,
-	0x56, 0x36, 0x1e, 0x43, 0x4b, 0xb2, 0x98, 0x0a, 0x49, 0xe2, 0xc4, 0xb5, 0x07, 0xd6, 0xd0, 0x0e,
-	0x36, 0x01, 0xec, 0x43, 0x43, 0xb2, 0x70, 0x41, 0xa5, 0x5b, 0x1f, 0x58, 0xc3, 0x56, 0x90, 0x7b,
-	0xaa, 0xc7, 0x05, 0x8b, 0xe8, 0x47, 0x7a, 0xed, 0x36, 0x34, 0x58, 0xe1, 0xa2, 0x07, 0x8e, 0x32,
-	0x27, 0xec, 0x0b, 0x75, 0x9b, 0x1a, 0x6e, 0xed, 0xab, 0xb7, 0x69, 0xc4, 0xc3, 0x85, 0x2a, 0x73,
-	0x74, 0xd9, 0xda, 0x57, 0x3c, 0xb4, 0xad, 0x0b, 0x5b, 0x19, 0x8f, 0x75, 0xc0, 0x3f, 0x85, 0x56,
-	0xfe, 0xcd, 0x22, 0x51, 0xcd, 0xc5, 0x55, 0x18, 0x52, 0x21, 0xf4, 0x77, 0x3b, 0x41, 0xe1, 0xfa,
-	0x3f, 0x2d, 0x68, 0x07, 0x54, 0xa6, 0x8c, 0xae, 0xe8, 0x5e, 0xc5, 0xd7, 0x52, 0x1c, 0x54, 0x49,
-	0x51, 0xab, 0x96, 0xc2, 0xae, 0x92, 0xa2, 0x5e, 0x2d, 0x45, 0x63, 0x8f, 0x14, 0xcd, 0x7d, 0x52,
-	0x38, 0xdb, 0x52, 0xf8, 0xd0, 0xd9, 0x7c, 0xa2, 0x48, 0x76, 0x8d, 0x80, 0xff, 0x15, 0x5a, 0x01,
-	0x8d, 0xf9, 0xed, 0x8b, 0xf0, 0x3f, 0xd4, 0x16, 0xf4, 0x5a, 0x2b, 0xd0, 0x09, 0x94, 0xa9, 0x30,
-	0x84, 0xe2, 0x59, 0xd7, 0xa9, 0xda, 0xf6, 0x1f, 0x03, 0x14, 0xed, 0xf7, 0xfe, 0x5c, 0xdf, 0x2d,
-	0xe8, 0xbd, 0xa5, 0x72, 0x9c, 0x92, 0xcb, 0x98, 0x2e, 0xe5, 0xbf, 0x25, 0xdb, 0xcd, 0xc8, 0x2a,
-	0x8c, 0x84, 0x0b, 0x26, 0x19, 0x5f, 0x8a, 0x7c, 0x98, 0x37, 0x01, 0xff, 0x14, 0x0e, 0x0d, 0x86,
-	0x86, 0xe0, 0xb5, 0xb5, 0xe0, 0xdf, 0xe0, 0xce, 0xd9, 0x9c, 0x86, 0x8b, 0x37, 0x2b, 0xc2, 0x22,
-	0x32, 0x8d, 0x6e, 0x5d, 0x78, 0xf3, 0x2a, 0xd8, 0x37, 0xae, 0xc2, 0x05, 0xe0, 0x36, 0x01, 0x91,
-	0xe0, 0x11, 0xd4, 0x25, 0x97, 0x24, 0xd2, 0xfd, 0xed, 0x20, 0x73, 0x70, 0x00, 0xed, 0x98, 0x7c,
-	0x1e, 0x17, 0xa3, 0x79, 0xa0, 0xdf, 0xca, 0xa1, 0x32, 0xf3, 0x9a, 0xc1, 0xfc, 0xd9, 0x0f, 0x1b,
-	0x0e, 0xcf, 0xf3, 0xf3, 0x36, 0xa1, 0xe9, 0x8a, 0x85, 0x14, 0x5f, 0x80, 0xad, 0xae, 0x17, 0x1e,
-	0x8d, 0x4a, 0x87, 0x6f, 0x94, 0x5f, 0x3d, 0xef, 0xee, 0x8e, 0xa8, 0x48, 0xfc, 0xff, 0xf0, 0x25,
-	0xd4, 0xf5, 0x4e, 0xa3, 0x99, 0x51, 0xdc, 0x36, 0xaf, 0xbf, 0x2b, 0xac, 0x2a, 0x87, 0x16, 0xbe,
-	0x06, 0xd0, 0x81, 0x49, 0x4c, 0xa2, 0xe8, 0x8f, 0x01, 0xf0, 0x0c, 0x9c, 0x62, 0x87, 0xd0, 0x35,
-	0xb2, 0x4a, 0xd7, 0xc3, 0xbb, 0x5f, 0xf1, 0xa2, 0x20, 0x9e, 0x5a, 0x38, 0x86, 0x6e, 0x11, 0xcb,
-	0x68, 0xfc, 0x1d, 0x12, 0xbe, 0x82, 0x46, 0xb6, 0x2d, 0xd8, 0xdf, 0x4a, 0xcb, 0x37, 0xd8, 0xbb,
-	0xb7, 0x33, 0xae, 0x8b, 0x3f, 0x40, 0xbb, 0x34, 0x9f, 0xf8, 0xc0, 0xc8, 0x34, 0x77, 0xcb, 0x3b,
-	0xae, 0x7e, 0xd4, 0x58, 0x13, 0xe8, 0x99, 0x33, 0x84, 0x27, 0x46, 0xc5, 0x8d, 0x09, 0xf7, 0x1e,
-	0xee, 0x7d, 0x57, 0xa0, 0xd3, 0x86, 0xfe, 0x4f, 0x7c, 0xfe, 0x3b, 0x00, 0x00, 0xff, 0xff, 0xcf,
-	0xd5, 0x7f, 0xb2, 0x25, 0x07, 0x00, 0x00,
+	// 899 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xdc, 0x56, 0x41, 0x6f, 0xe3, 0x44,
+	0x14, 0x5e, 0xc7, 0x4e, 0xe2, 0xbc, 0x34, 0xa9, 0xf7, 0xb1, 0x5b, 0x8c, 0x77, 0x55, 0x22, 0xa3,
+	0x85, 0x88, 0x43, 0xb5, 0x2a, 0xe2, 0x02, 0xe2, 0x90, 0x86, 0xa4, 0x29, 0x29, 0x4d, 0x35, 0xce,
+	0xae, 0xc4, 0x29, 0x9a, 0x26, 0xd3, 0xc4, 0x6a, 0x12, 0x1b, 0x7b, 0x12, 0x76, 0x91, 0x10, 0x07,
+	0x6e, 0xfc, 0x05, 0x2e, 0xfc, 0x4e, 0xb8, 0xa0, 0x19, 0xdb, 0x89, 0x1d, 0xea, 0x20, 0x50, 0xc5,
+	0x61, 0x6f, 0xef, 0x7d, 0xf3, 0xe6, 0xbd, 0xe7, 0xf7, 0xcd, 0x7c, 0x63, 0xa8, 0xfb, 0x81, 0xb7,
+	0x76, 0x27, 0x2c, 0x38, 0xf1, 0x03, 0x8f, 0x7b, 0x58, 0xdd, 0xfa, 0x37, 0xf6, 0x47, 0x50, 0xbe,
+	0x76, 0x97, 0x53, 0xc2, 0xbe, 0x47, 0x13, 0xca, 0x6b, 0x16, 0x84, 0xae, 0xb7, 0x34, 0x95, 0x86,
+	0xd2, 0xac, 0x91, 0xc4, 0xb5, 0xd7, 0xa0, 0x47, 0x41, 0xa1, 0x8f, 0xc7, 0x00, 0x4b, 0x6f, 0xc2,
+	0x2e, 0x26, 0x3d, 0x1a, 0xce, 0x64, 0xe0, 0x01, 0x49, 0x21, 0xe9, 0x2c, 0x85, 0x4c, 0x16, 0x7c,
+	0x09, 0xfa, 0x2d, 0xa3, 0x7c, 0x15, 0xb0, 0xd0, 0x54, 0x1b, 0x6a, 0xb3, 0x7e, 0xfa, 0xe4, 0x24,
+	0xd5, 0xca, 0x49, 0x37, 0x5a, 0x24, 0x9b, 0x28, 0xfb, 0xb7, 0x02, 0xe8, 0x0e, 0xf7, 0x02, 0x26,
+	0xda, 0x43, 0xd0, 0x26, 0x94, 0xd3, 0xb8, 0xa4, 0xb4, 0xf7, 0x14, 0x43, 0xd0, 0xe8, 0x8a, 0xcf,
+	0x4c, 0x35, 0x8a, 0x16, 0x36, 0x3e, 0x87, 0x0a, 0x77, 0x17, 0x2c, 0xe4, 0x74, 0xe1, 0x9b, 0x5a,
+	0x43, 0x69, 0x6a, 0x64, 0x0b, 0xe0, 0x11, 0x94, 0xb8, 0x3b, 0xbe, 0x63, 0xdc, 0x2c, 0x36, 0x94,
+	0x66, 0x85, 0xc4, 0x9e, 0xa8, 0x71, 0xeb, 0xce, 0x59, 0x9f, 0xbd, 0x35, 0x4b, 0x32, 0x59, 0xe2,
+	0xa2, 0x05, 0xba, 0x30, 0x1d, 0xf7, 0x47, 0x66, 0x96, 0x65, 0xba, 0x8d, 0x2f, 0xd6, 0x6e, 0xe6,
+	0xde, 0xf8, 0x4e, 0x6c, 0xd3, 0xe5, 0xb6, 0x8d, 0x2f, 0xfa, 0x90, 0xb6, 0xdc, 0x58, 0x89, 0xfa,
+	0xd8, 0x00, 0xf8, 0x09, 0xa8, 0x9c, 0x4e, 0x4d, 0x68, 0x28, 0xcd, 0xea, 0xe9, 0xd3, 0xcc, 0x84,
+	0xce, 0x44, 0xd0, 0x90, 0x4e, 0x89, 0x88, 0xb0, 0x5f, 0x83, 0x9e, 0x00, 0xa2, 0x9c, 0x4f, 0x03,
+	0xba, 0x70, 0x78, 0x20, 0x07, 0x54, 0x21, 0x1b, 0x5f, 0x94, 0x1b, 0xcf, 0x56, 0xcb, 0xa8, 0x5c,
+	0x34, 0xa6, 0x2d, 0x80, 0x06, 0xa8, 0xfe, 0xcc, 0x95, 0x84, 0x1c, 0x10, 0x61, 0xda, 0x2f, 0xa0,
+	0x12, 0x0f, 0x3d, 0xf4, 0xc5, 0xd7, 0x87, 0xab, 0xf1, 0x98, 0x85, 0xa1, 0xcc, 0xab, 0x93, 0xc4,
+	0xb5, 0xff, 0x54, 0xa0, 0x4a, 0x18, 0x0f, 0x5c, 0xb6, 0x66, 0x7b, 0x8f, 0xcf, 0x86, 0x8b, 0x42,
+	0x1e, 0x17, 0x6a, 0x3e, 0x17, 0x5a, 0x1e, 0x17, 0xc5, 0x7c, 0x2e, 0x4a, 0x7b, 0xb8, 0x28, 0xef,
+	0xe3, 0x42, 0xdf, 0xe5, 0xc2, 0x84, 0xf2, 0x0f, 0x2e, 0x9f, 0x0d, 0xe9, 0x54, 0xf2, 0xa4, 0x93,
+	0xc4, 0xb5, 0xfb, 0x70, 0xb0, 0xfd, 0xf8, 0xd0, 0xbf, 0xf7, 0x74, 0xc6, 0x4c, 0x16, 0xfe, 0x91,
+	0xc9, 0x9f, 0xa0, 0x42, 0xd8, 0xc2, 0x7b, 0xf8, 0x39, 0x1a, 0xa0, 0xde, 0xb1, 0xb7, 0x72, 0x88,
+	0x07, 0x44, 0x98, 0x22, 0x47, 0x28, 0x3e, 0xb5, 0x28, 0x43, 0xa5, 0x6d, 0x7f, 0x0c, 0x90, 0x94,
+	0xdf, 0xcb, 0xf8, 0xef, 0x0a, 0xd4, 0xcf, 0x19, 0xef, 0x06, 0x74, 0xba, 0x60, 0x4b, 0xfe, 0xff,
+	0x36, 0x5b, 0x8b, 0x9a, 0x15, 0x39, 0x7c, 0x2f, 0x74, 0xb9, 0xeb, 0x2d, 0xc3, 0xf8, 0x42, 0x6e,
+	0x01, 0xfb, 0x05, 0x1c, 0x66, 0x3a, 0xcc, 0x30, 0xa3, 0x26, 0xcc, 0xd8, 0x3f, 0xc3, 0xe3, 0xf6,
+	0x8c, 0x8d, 0xef, 0x5a, 0x6b, 0xea, 0xce, 0xe9, 0xcd, 0xfc, 0xc1, 0x07, 0x9f, 0x55, 0x49, 0x6d,
+	0x57, 0x25, 0xed, 0x5b, 0xc0, 0xdd, 0x06, 0x42, 0x1f, 0x9f, 0x40, 0x91, 0x7b, 0x9c, 0xce, 0x65,
+	0x7d, 0x8d, 0x44, 0x0e, 0x36, 0xa0, 0xba, 0xa0, 0x6f, 0xba, 0xc9, 0xe9, 0x2e, 0xc8, 0xb5, 0x34,
+	0x94, 0xee, 0x5c, 0xcd, 0x2a, 0xf7, 0x2f, 0x05, 0xa8, 0xb5, 0xc5, 0x5d, 0xbf, 0x0e, 0x3c, 0xef,
+	0xf6, 0x5d, 0xbd, 0xa6, 0xc7, 0x00, 0x52, 0xd0, 0x2e, 0x96, 0x13, 0xf6, 0x46, 0xde, 0xd4, 0x1a,
+	0x49, 0x21, 0xf6, 0xaf, 0x0a, 0xd4, 0xd3, 0x53, 0xc8, 0xb9, 0xaf, 0xfb, 0x85, 0x32, 0x29, 0xd2,
+	0xf6, 0x56, 0x4b, 0x1e, 0xcf, 0x39, 0x85, 0x88, 0x8c, 0x3e, 0xe5, 0x82, 0x6c, 0x79, 0xce, 0x84,
+	0x2d, 0xb0, 0xc0, 0xf3, 0x78, 0x3c, 0x05, 0x69, 0x7f, 0xba, 0x82, 0x72, 0xfc, 0xd2, 0xa1, 0x0e,
+	0xda, 0xd5, 0xe0, 0xaa, 0x63, 0x3c, 0xc2, 0x43, 0xa8, 0xb6, 0x7b, 0xaf, 0xae, 0xfa, 0xa3, 0x6b,
+	0x32, 0x18, 0x74, 0x0d, 0x05, 0x6b, 0x50, 0x39, 0xbb, 0x1c, 0xb4, 0xfb, 0xa3, 0x61, 0xeb, 0xdc,
+	0x28, 0xe0, 0x63, 0xa8, 0x7d, 0xfb, 0xea, 0x72, 0x78, 0xd1, 0x6b, 0x39, 0xbd, 0x51, 0xbf, 0xf3,
+	0x9d, 0xa1, 0xe2, 0x7b, 0x70, 0x48, 0x5a, 0x57, 0xe7, 0x9d, 0xaf, 0x47, 0xa4, 0x33, 0x24, 0x17,
+	0x9d, 0xd7, 0x1d, 0x43, 0x13, 0x79, 0xce, 0x5a, 0xc3, 0x76, 0x6f, 0xe4, 0x0c, 0x07, 0xa4, 0x63,
+	0x14, 0xb1, 0x0c, 0xea, 0xf0, 0xd2, 0x31, 0x4a, 0xa7, 0x7f, 0x68, 0x70, 0x78, 0x1d, 0x2b, 0x90,
+	0xc3, 0x82, 0xb5, 0x3b, 0x66, 0xf8, 0x39, 0x68, 0xe2, 0x5d, 0xc7, 0xec, 0x3b, 0x1c, 0xff, 0x0f,
+	0x58, 0x4f, 0xef, 0x41, 0x43, 0xdf, 0x7e, 0x84, 0x5f, 0x40, 0x51, 0x3e, 0x10, 0x98, 0x8d, 0x48,
+	0x5e, 0x6a, 0xeb, 0xe8, 0x3e, 0x58, 0xec, 0x6c, 0x2a, 0xf8, 0x15, 0x80, 0x04, 0x9c, 0x05, 0x9d,
+	0xcf, 0xff, 0x75, 0x02, 0x6c, 0x83, 0x9e, 0xc8, 0x2e, 0x9a, 0x99, 0xa8, 0xd4, 0x53, 0x64, 0x7d,
+	0x90, 0xb3, 0x22, 0x52, 0xbc, 0x54, 0xb0, 0x0b, 0xb5, 0x04, 0x8b, 0xda, 0xf8, 0x6f, 0x99, 0xf0,
+	0x4b, 0x28, 0x45, 0xba, 0x89, 0x47, 0x3b, 0x61, 0xb1, 0x96, 0x5b, 0xef, 0xdf, 0x8b, 0xcb, 0xcd,
+	0xdf, 0x40, 0x35, 0xa5, 0x54, 0xf8, 0x2c, 0x13, 0x99, 0x55, 0x59, 0xeb, 0x79, 0xfe, 0xa2, 0xcc,
+	0xe5, 0x40, 0x3d, 0xab, 0x26, 0x78, 0x9c, 0xd9, 0xf1, 0x37, 0xad, 0xb3, 0x3e, 0xdc, 0xbb, 0x1e,
+	0x37, 0x58, 0x3b, 0x67, 0x7c, 0x7b, 0x6d, 0xd0, 0xda, 0xd9, 0x93, 0x52, 0x15, 0xeb, 0x59, 0xee,
+	0x9a, 0xc8, 0x75, 0x53, 0x92, 0x7f, 0x9e, 0x9f, 0xfd, 0x35, 0x00, 0xe6, 0x53, 0x9c, 0x5e, 0x8b,
+	0x0a, 0x00, 0x00,
 }
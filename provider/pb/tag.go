@@ -0,0 +1,54 @@
+package provider_pb
+
+// Merge append phi of other to self, used to collect tag split across stream messages
+func (self *BlockTag) Merge(other *BlockTag) *BlockTag {
+	if other == nil {
+		return self
+	}
+	if self == nil {
+		self = &BlockTag{}
+	}
+	if len(self.ParamStr) == 0 {
+		self.ParamStr = other.ParamStr
+	}
+	if self.ChunkSize == 0 {
+		self.ChunkSize = other.ChunkSize
+	}
+	self.Phi = append(self.Phi, other.Phi...)
+	return self
+}
+
+// Split tag for sending with parts stream messages, the first part carries paramStr and chunkSize
+func (self *BlockTag) Split(parts int) []*BlockTag {
+	if parts < 1 {
+		parts = 1
+	}
+	res := make([]*BlockTag, parts)
+	if self == nil {
+		return res
+	}
+	per := (len(self.Phi) + parts - 1) / parts
+	for i := 0; i < parts; i++ {
+		start, end := i*per, (i+1)*per
+		if start > len(self.Phi) {
+			start = len(self.Phi)
+		}
+		if end > len(self.Phi) {
+			end = len(self.Phi)
+		}
+		if i == 0 {
+			res[i] = &BlockTag{ParamStr: self.ParamStr, ChunkSize: self.ChunkSize, Phi: self.Phi[start:end]}
+		} else if start < end {
+			res[i] = &BlockTag{Phi: self.Phi[start:end]}
+		}
+	}
+	return res
+}
+
+// Complete check phi count matches chunk count of block
+func (self *BlockTag) Complete(blockSize uint64) bool {
+	if self == nil || len(self.ParamStr) == 0 || self.ChunkSize == 0 || len(self.Phi) == 0 {
+		return false
+	}
+	return uint64(len(self.Phi)) == (blockSize+uint64(self.ChunkSize)-1)/uint64(self.ChunkSize)
+}
@@ -1,6 +1,7 @@
 package provider_client
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	client "github.com/samoslab/nebula/provider/collector_client"
 	pb "github.com/samoslab/nebula/provider/pb"
 	tcppb "github.com/samoslab/nebula/tracker/collector/provider/pb"
+	"github.com/samoslab/nebula/util/merkle"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -17,6 +19,15 @@ import (
 
 const stream_data_size = 32 * 1024
 const small_file_limit = 512 * 1024
+const capability_ttl = 30 * time.Minute
+const capability_query_timeout = 10 * time.Second
+
+var capabilities = pb.NewCapabilityCache(capability_ttl)
+
+// GetCapability protocol version and features of provider at addr, cached for capability_ttl
+func GetCapability(psc pb.ProviderServiceClient, addr string) (*pb.Capability, error) {
+	return capabilities.Query(psc, addr, capability_query_timeout)
+}
 
 func Ping(host string, port uint32, timeout int) (nodeIdHash []byte, latency int64, err error) {
 	providerAddr := fmt.Sprintf("%s:%d", host, port)
@@ -29,11 +40,13 @@ func Ping(host string, port uint32, timeout int) (nodeIdHash []byte, latency int
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
 	defer cancel()
 	start := time.Now().UnixNano()
-	resp, err := psc.Ping(ctx, &pb.PingReq{})
+	resp, err := psc.Ping(ctx, &pb.PingReq{Version: pb.ProtocolVersion})
 	if err != nil {
 		return nil, 0, err
 	}
-	return resp.NodeIdHash, time.Now().UnixNano() - start, nil
+	latency = time.Now().UnixNano() - start
+	capabilities.Put(providerAddr, pb.NewCapability(resp))
+	return resp.NodeIdHash, latency, nil
 }
 
 func now() uint64 {
@@ -71,7 +84,7 @@ func newActionLogFromRetrieveReq(ticket string,
 }
 
 func StoreSmall(psc pb.ProviderServiceClient, data []byte, auth []byte, timestamp uint64, ticket string,
-	fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64) error {
+	fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64, tag *pb.BlockTag) error {
 	if blockSize >= small_file_limit || int(blockSize) != len(data) {
 		return fmt.Errorf("check data size failed")
 	}
@@ -84,7 +97,8 @@ func StoreSmall(psc pb.ProviderServiceClient, data []byte, auth []byte, timestam
 		FileKey:   fileHash,
 		FileSize:  fileSize,
 		BlockKey:  blockHash,
-		BlockSize: blockSize}
+		BlockSize: blockSize,
+		Tag:       tag}
 	al := newActionLogFromStoreReq(ticket, fileHash, fileSize, blockHash, blockSize)
 	al.TransportSize = uint64(len(data))
 	defer client.Collect(al)
@@ -108,7 +122,7 @@ func StoreSmall(psc pb.ProviderServiceClient, data []byte, auth []byte, timestam
 }
 
 func Store(psc pb.ProviderServiceClient, filePath string, auth []byte, timestamp uint64, ticket string,
-	fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64) error {
+	fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64, tag *pb.BlockTag) error {
 	fileInfo, er := os.Stat(filePath)
 	if er != nil {
 		return fmt.Errorf("stat file failed, error: %s", er)
@@ -121,7 +135,15 @@ func Store(psc pb.ProviderServiceClient, filePath string, auth []byte, timestamp
 		return fmt.Errorf("open file failed: %s", err.Error())
 	}
 	defer file.Close()
+	return StoreReader(psc, file, auth, timestamp, ticket, fileHash, fileSize, blockHash, blockSize, tag)
+}
 
+// StoreReader send blockSize bytes read from reader, reader must fill the buffer unless reaching the end like os.File
+func StoreReader(psc pb.ProviderServiceClient, reader io.Reader, auth []byte, timestamp uint64, ticket string,
+	fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64, tag *pb.BlockTag) error {
+	if blockSize < small_file_limit {
+		return fmt.Errorf("check data size failed")
+	}
 	req := &pb.StoreReq{Auth: auth,
 		Timestamp: timestamp,
 		Ticket:    ticket,
@@ -136,10 +158,11 @@ func Store(psc pb.ProviderServiceClient, filePath string, auth []byte, timestamp
 	defer stream.CloseSend()
 	first := true
 	buf := make([]byte, stream_data_size)
+	tags := tag.Split(int((blockSize + stream_data_size - 1) / stream_data_size))
 	al := newActionLogFromStoreReq(ticket, fileHash, fileSize, blockHash, blockSize)
 	defer client.Collect(al)
-	for {
-		bytesRead, err := file.Read(buf)
+	for i := 0; ; i++ {
+		bytesRead, err := reader.Read(buf)
 		if err != nil {
 			if err == io.EOF {
 				break
@@ -150,7 +173,7 @@ func Store(psc pb.ProviderServiceClient, filePath string, auth []byte, timestamp
 		}
 		if first {
 			first = false
-			req.Data = buf[:bytesRead]
+			req.Data, req.Tag = buf[:bytesRead], tags[0]
 			if err := stream.Send(req); err != nil {
 				if err == io.EOF {
 					break
@@ -161,7 +184,11 @@ func Store(psc pb.ProviderServiceClient, filePath string, auth []byte, timestamp
 				return err
 			}
 		} else {
-			if err := stream.Send(&pb.StoreReq{Data: buf[:bytesRead]}); err != nil {
+			next := &pb.StoreReq{Data: buf[:bytesRead]}
+			if i < len(tags) {
+				next.Tag = tags[i]
+			}
+			if err := stream.Send(next); err != nil {
 				if err == io.EOF {
 					break
 				}
@@ -197,9 +224,9 @@ func Store(psc pb.ProviderServiceClient, filePath string, auth []byte, timestamp
 }
 
 func RetrieveSmall(psc pb.ProviderServiceClient, auth []byte, timestamp uint64, ticket string,
-	fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64) (data []byte, err error) {
+	fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64) (data []byte, tag *pb.BlockTag, err error) {
 	if blockSize >= small_file_limit {
-		return nil, fmt.Errorf("check data size failed")
+		return nil, nil, fmt.Errorf("check data size failed")
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -209,22 +236,23 @@ func RetrieveSmall(psc pb.ProviderServiceClient, auth []byte, timestamp uint64,
 		FileKey:   fileHash,
 		FileSize:  fileSize,
 		BlockKey:  blockHash,
-		BlockSize: blockSize}
+		BlockSize: blockSize,
+		WithTag:   true}
 	al := newActionLogFromRetrieveReq(ticket, fileHash, fileSize, blockHash, blockSize)
 	defer client.Collect(al)
 	resp, err := psc.RetrieveSmall(ctx, req)
 	if err != nil {
 		setActionLog(err, al)
-		return nil, err
+		return nil, nil, err
 	}
 	al.Success, al.EndTime, al.TransportSize = true, now(), uint64(len(data))
-	return resp.Data, nil
+	return resp.Data, resp.Tag, nil
 }
 
 func Retrieve(psc pb.ProviderServiceClient, filePath string, auth []byte, timestamp uint64, ticket string,
-	fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64) error {
+	fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64) (tag *pb.BlockTag, er error) {
 	if blockSize < small_file_limit {
-		return fmt.Errorf("check data size failed")
+		return nil, fmt.Errorf("check data size failed")
 	}
 	req := &pb.RetrieveReq{Auth: auth,
 		Timestamp: timestamp,
@@ -232,10 +260,11 @@ func Retrieve(psc pb.ProviderServiceClient, filePath string, auth []byte, timest
 		FileKey:   fileHash,
 		FileSize:  fileSize,
 		BlockKey:  blockHash,
-		BlockSize: blockSize}
+		BlockSize: blockSize,
+		WithTag:   true}
 	stream, err := psc.Retrieve(context.Background(), req)
 	if err != nil {
-		return fmt.Errorf("RPC Retrieve failed: %s", err.Error())
+		return nil, fmt.Errorf("RPC Retrieve failed: %s", err.Error())
 	}
 	al := newActionLogFromStoreReq(ticket, fileHash, fileSize, blockHash, blockSize)
 	defer client.Collect(al)
@@ -249,8 +278,9 @@ func Retrieve(psc pb.ProviderServiceClient, filePath string, auth []byte, timest
 		if err != nil {
 			err = fmt.Errorf("RPC Recv failed: %s", err.Error())
 			setActionLog(err, al)
-			return err
+			return nil, err
 		}
+		tag = tag.Merge(resp.Tag)
 		if len(resp.Data) == 0 {
 			break
 		}
@@ -261,16 +291,47 @@ func Retrieve(psc pb.ProviderServiceClient, filePath string, auth []byte, timest
 				os.O_WRONLY|os.O_TRUNC|os.O_CREATE,
 				0666)
 			if err != nil {
-				return fmt.Errorf("open file failed: %s", err.Error())
+				return nil, fmt.Errorf("open file failed: %s", err.Error())
 			}
 			defer file.Close()
 		}
 		if _, err = file.Write(resp.Data); err != nil {
 			err = fmt.Errorf("write file %d bytes failed : %s", len(resp.Data), err.Error())
 			setActionLog(err, al)
-			return err
+			return nil, err
 		}
 	}
 	al.Success, al.EndTime = true, now()
-	return nil
+	return tag, nil
+}
+
+// GetChunkProof get chunk of block and verify it by merkle path, skip verify if root is empty
+func GetChunkProof(psc pb.ProviderServiceClient, auth []byte, timestamp uint64, ticket string,
+	fileHash []byte, fileSize uint64, blockHash []byte, blockSize uint64, chunkIndex uint32, root []byte) (data []byte, chunkRoot []byte, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req := &pb.ChunkProofReq{Auth: auth,
+		Timestamp:  timestamp,
+		Ticket:     ticket,
+		FileKey:    fileHash,
+		FileSize:   fileSize,
+		BlockKey:   blockHash,
+		BlockSize:  blockSize,
+		ChunkIndex: chunkIndex}
+	resp, err := psc.GetChunkProof(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(root) > 0 && !bytes.Equal(root, resp.Root) {
+		return nil, nil, fmt.Errorf("merkle root not same")
+	}
+	// chunk count from provider is not trusted, it is known from block size
+	chunkCount := merkle.ChunkCount(blockSize, merkle.DefaultChunkSize)
+	if resp.ChunkSize != merkle.DefaultChunkSize || resp.ChunkCount != chunkCount {
+		return nil, nil, fmt.Errorf("chunk size %d or count %d of provider is wrong, expected %d %d", resp.ChunkSize, resp.ChunkCount, merkle.DefaultChunkSize, chunkCount)
+	}
+	if !merkle.Verify(resp.Root, resp.Data, chunkIndex, chunkCount, resp.Path) {
+		return nil, nil, fmt.Errorf("merkle path verify failed, chunk index: %d", chunkIndex)
+	}
+	return resp.Data, resp.Root, nil
 }
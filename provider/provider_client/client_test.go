@@ -0,0 +1,52 @@
+package provider_client
+
+import (
+	"math/rand"
+	"testing"
+
+	pb "github.com/samoslab/nebula/provider/pb"
+	"github.com/samoslab/nebula/util/merkle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// chunkProvider answers GetChunkProof with resp, other methods are not used
+type chunkProvider struct {
+	pb.ProviderServiceClient
+	resp *pb.ChunkProofResp
+}
+
+func (self *chunkProvider) GetChunkProof(ctx context.Context, in *pb.ChunkProofReq, opts ...grpc.CallOption) (*pb.ChunkProofResp, error) {
+	return self.resp, nil
+}
+
+func TestGetChunkProof(t *testing.T) {
+	data := make([]byte, 2*merkle.DefaultChunkSize+10)
+	rand.Read(data)
+	size := uint64(len(data))
+	leaves := merkle.Leaves(data, merkle.DefaultChunkSize)
+	root := merkle.Root(leaves)
+	path, err := merkle.Path(leaves, 1)
+	require.NoError(t, err)
+	chunk1, chunk2 := data[merkle.DefaultChunkSize:2*merkle.DefaultChunkSize], data[2*merkle.DefaultChunkSize:]
+	provider := &chunkProvider{resp: &pb.ChunkProofResp{Data: chunk1, ChunkSize: merkle.DefaultChunkSize, ChunkCount: 3, Path: path, Root: root}}
+	got, gotRoot, err := GetChunkProof(provider, nil, 0, "", nil, size, nil, size, 1, root)
+	require.NoError(t, err)
+	assert.Equal(t, chunk1, got)
+	assert.Equal(t, root, gotRoot)
+
+	// last chunk sent as chunk 1 of a block claimed to have 2 chunks
+	forgedPath, err := merkle.Path([][]byte{merkle.Root(leaves[:2]), leaves[2]}, 1)
+	require.NoError(t, err)
+	provider.resp = &pb.ChunkProofResp{Data: chunk2, ChunkSize: merkle.DefaultChunkSize, ChunkCount: 2, Path: forgedPath, Root: root}
+	_, _, err = GetChunkProof(provider, nil, 0, "", nil, size, nil, size, 1, root)
+	assert.Error(t, err, "forged chunk count")
+	// root is not known by caller, chunk count is still checked
+	_, _, err = GetChunkProof(provider, nil, 0, "", nil, size, nil, size, 1, nil)
+	assert.Error(t, err, "forged chunk count without root")
+	provider.resp.ChunkCount = 3
+	_, _, err = GetChunkProof(provider, nil, 0, "", nil, size, nil, size, 1, root)
+	assert.Error(t, err, "moved chunk with real count")
+}
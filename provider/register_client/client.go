@@ -8,6 +8,7 @@ import (
 
 	"github.com/samoslab/nebula/provider/config"
 	"github.com/samoslab/nebula/provider/node"
+	"github.com/samoslab/nebula/provider/relay"
 	pb "github.com/samoslab/nebula/tracker/register/provider/pb"
 	"google.golang.org/grpc"
 )
@@ -236,3 +237,51 @@ func RefreshIp(client pb.ProviderRegisterServiceClient, port uint32) (ip string,
 	return resp.Ip, nil
 
 }
+
+func OfferRelay(client pb.ProviderRegisterServiceClient, port uint32, capacity uint32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	node := node.LoadFormConfig()
+	req := &pb.OfferRelayReq{Version: 1,
+		NodeId:    node.NodeId,
+		Timestamp: uint64(time.Now().Unix()),
+		Port:      port,
+		Capacity:  capacity}
+	req.SignReq(node.PriKey)
+	_, err := client.OfferRelay(ctx, req)
+	return err
+}
+
+// GetRelayServer return relay chosen by tracker, server is empty if no relay is available, grant is given anyway
+func GetRelayServer(client pb.ProviderRegisterServiceClient) (relayNodeId []byte, server string, grant *relay.Grant, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	node := node.LoadFormConfig()
+	req := &pb.GetRelayServerReq{Version: 1,
+		NodeId:    node.NodeId,
+		Timestamp: uint64(time.Now().Unix())}
+	req.SignReq(node.PriKey)
+	resp, err := client.GetRelayServer(ctx, req)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return resp.RelayNodeId, resp.Server, &relay.Grant{Expire: resp.GrantExpire, Sign: resp.Grant}, nil
+}
+
+func SwitchRelay(client pb.ProviderRegisterServiceClient, relayNodeId []byte, host string, port uint32) (code uint32, errMsg string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	node := node.LoadFormConfig()
+	req := &pb.SwitchRelayReq{Version: 1,
+		NodeId:      node.NodeId,
+		Timestamp:   uint64(time.Now().Unix()),
+		RelayNodeId: relayNodeId,
+		Host:        host,
+		Port:        port}
+	req.SignReq(node.PriKey)
+	resp, err := client.SwitchRelay(ctx, req)
+	if err != nil {
+		return 9999, "", err
+	}
+	return resp.Code, resp.ErrMsg, nil
+}
@@ -0,0 +1,51 @@
+package relay
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const grant_prefix = "nebula-relay-grant:"
+
+var GrantExpiredErr = errors.New("relay grant expired")
+
+// Grant is issued by tracker to registered provider, relay serves only nodes holding a valid grant
+type Grant struct {
+	Expire uint64 // unix time
+	Sign   []byte // signed by tracker
+}
+
+func grantHash(nodeId []byte, expire uint64) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte(grant_prefix))
+	hasher.Write(nodeId)
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, expire)
+	hasher.Write(b)
+	return hasher.Sum(nil)
+}
+
+// SignGrant is called by tracker for registered node
+func SignGrant(trackerKey *rsa.PrivateKey, nodeId []byte, expire uint64) (*Grant, error) {
+	sign, err := rsa.SignPKCS1v15(rand.Reader, trackerKey, crypto.SHA256, grantHash(nodeId, expire))
+	if err != nil {
+		return nil, err
+	}
+	return &Grant{Expire: expire, Sign: sign}, nil
+}
+
+// VerifyGrant check grant is signed by tracker for node and not expired
+func VerifyGrant(trackerKey *rsa.PublicKey, nodeId []byte, grant *Grant) error {
+	if grant.Expire < uint64(time.Now().Unix()) {
+		return GrantExpiredErr
+	}
+	if err := rsa.VerifyPKCS1v15(trackerKey, crypto.SHA256, grantHash(nodeId, grant.Expire), grant.Sign); err != nil {
+		return AuthFailedErr
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package relay
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/samoslab/nebula/provider/node"
+	log "github.com/sirupsen/logrus"
+)
+
+const reconnect_min_interval = time.Second
+const reconnect_max_interval = 2 * time.Minute
+
+var ListenerClosedErr = errors.New("relay listener closed")
+
+// Listener is net.Listener of NATed provider, connections of clients are accepted through relay server.
+// Control connection is reconnected automatically until Close, port allocated by relay may change then.
+type Listener struct {
+	server  string
+	node    *node.Node
+	grant   func() (*Grant, error)
+	onReady func(port uint16)
+	conns   chan net.Conn
+	mutex   sync.Mutex
+	control net.Conn
+	port    uint16
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Listen connect to relay server and wait until public port is allocated, grant is called for grant of tracker
+// on every (re)connection, onReady is called with the port on every (re)connection, it may be nil.
+func Listen(server string, no *node.Node, grant func() (*Grant, error), onReady func(port uint16)) (*Listener, error) {
+	l := &Listener{server: server, node: no, grant: grant, onReady: onReady, conns: make(chan net.Conn, 16), done: make(chan struct{})}
+	control, port, err := l.connect()
+	if err != nil {
+		return nil, err
+	}
+	l.setControl(control, port)
+	go l.run(control)
+	return l, nil
+}
+
+func (self *Listener) connect() (net.Conn, uint16, error) {
+	grant, err := self.grant()
+	if err != nil {
+		return nil, 0, err
+	}
+	conn, err := net.DialTimeout("tcp", self.server, handshake_timeout)
+	if err != nil {
+		return nil, 0, err
+	}
+	conn.SetDeadline(time.Now().Add(handshake_timeout))
+	port, err := self.handshake(conn, grant)
+	if err != nil {
+		conn.Close()
+		return nil, 0, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, port, nil
+}
+
+func (self *Listener) handshake(conn net.Conn, grant *Grant) (uint16, error) {
+	if err := writeFrame(conn, msg_hello, []byte{protocol_version, kind_control}); err != nil {
+		return 0, err
+	}
+	nonce, err := expectFrame(conn, msg_challenge)
+	if err != nil {
+		return 0, err
+	}
+	auth, err := encodeAuth(self.node.PubKeyBytes, self.node.PriKey, grant, nonce)
+	if err != nil {
+		return 0, err
+	}
+	if err = writeFrame(conn, msg_auth, auth); err != nil {
+		return 0, err
+	}
+	payload, err := expectFrame(conn, msg_ready)
+	if err != nil {
+		return 0, err
+	}
+	if len(payload) != 2 {
+		return 0, errors.New("invalid relay ready frame")
+	}
+	return binary.BigEndian.Uint16(payload), nil
+}
+
+func (self *Listener) setControl(control net.Conn, port uint16) {
+	self.mutex.Lock()
+	self.control = control
+	self.port = port
+	self.mutex.Unlock()
+	if self.onReady != nil {
+		self.onReady(port)
+	}
+}
+
+func (self *Listener) closed() bool {
+	select {
+	case <-self.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// run serve control connection and reconnect when it is lost
+func (self *Listener) run(control net.Conn) {
+	interval := reconnect_min_interval
+	for {
+		self.serveControl(control)
+		for {
+			if self.closed() {
+				return
+			}
+			select {
+			case <-self.done:
+				return
+			case <-time.After(interval):
+			}
+			var port uint16
+			var err error
+			control, port, err = self.connect()
+			if err == nil {
+				log.Infof("relay %s reconnected, public port: %d", self.server, port)
+				interval = reconnect_min_interval
+				self.setControl(control, port)
+				break
+			}
+			log.Warnf("reconnect relay %s failed: %s", self.server, err)
+			if interval *= 2; interval > reconnect_max_interval {
+				interval = reconnect_max_interval
+			}
+		}
+	}
+}
+
+func (self *Listener) serveControl(control net.Conn) {
+	defer control.Close()
+	var writeMutex sync.Mutex
+	send := func(typ byte) error {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		return writeFrame(control, typ, nil)
+	}
+	for {
+		control.SetReadDeadline(time.Now().Add(idle_timeout))
+		typ, payload, err := readFrame(control)
+		if err != nil {
+			if !self.closed() {
+				log.Warnf("relay %s control connection lost: %s", self.server, err)
+			}
+			return
+		}
+		switch typ {
+		case msg_ping:
+			if send(msg_pong) != nil {
+				return
+			}
+		case msg_connect:
+			go self.dialData(payload)
+		}
+	}
+}
+
+// dialData dial data connection for token, it is accepted when relay splices it with client connection
+func (self *Listener) dialData(token []byte) {
+	conn, err := net.DialTimeout("tcp", self.server, handshake_timeout)
+	if err != nil {
+		log.Warnf("dial relay %s data connection failed: %s", self.server, err)
+		return
+	}
+	if err = writeFrame(conn, msg_hello, append([]byte{protocol_version, kind_data}, token...)); err != nil {
+		conn.Close()
+		return
+	}
+	select {
+	case self.conns <- conn:
+	case <-self.done:
+		conn.Close()
+	}
+}
+
+func (self *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-self.conns:
+		return conn, nil
+	case <-self.done:
+		return nil, ListenerClosedErr
+	}
+}
+
+func (self *Listener) Close() error {
+	self.once.Do(func() {
+		close(self.done)
+		self.mutex.Lock()
+		self.control.Close()
+		self.mutex.Unlock()
+	})
+	return nil
+}
+
+// Addr is public address of relay for this provider
+func (self *Listener) Addr() net.Addr {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	addr, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(self.Host(), strconv.Itoa(int(self.port))))
+	if err != nil {
+		return &net.TCPAddr{Port: int(self.port)}
+	}
+	return addr
+}
+
+// Host of relay server
+func (self *Listener) Host() string {
+	host, _, err := net.SplitHostPort(self.server)
+	if err != nil {
+		return self.server
+	}
+	return host
+}
+
+// Port allocated by relay server for this provider
+func (self *Listener) Port() uint16 {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.port
+}
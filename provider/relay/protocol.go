@@ -0,0 +1,155 @@
+// Package relay let provider behind NAT serve clients through a reachable provider.
+//
+// NATed provider keeps an outbound control connection to relay and authenticates by node key and
+// a grant signed by tracker, so relay serves only providers registered on tracker.
+// Relay allocates a public port for it, each client connection to that port is announced over
+// control connection by a one-time token, then NATed provider dials a data connection with the token
+// and relay splices the two connections.
+//
+// RPCs are forwarded as raw bytes of insecure gRPC, so relay can read and change the traffic. Provider
+// still checks tracker auth of every request and client verifies hash of every block it retrieves,
+// blocks are encrypted by client if password is set, but other content of requests and responses is not protected.
+package relay
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	util_hash "github.com/samoslab/nebula/util/hash"
+)
+
+const protocol_version = 2
+
+const (
+	msg_hello byte = iota + 1
+	msg_challenge
+	msg_auth
+	msg_ready
+	msg_connect
+	msg_ping
+	msg_pong
+	msg_error
+)
+
+const (
+	kind_control byte = 1
+	kind_data    byte = 2
+)
+
+const nonce_len = 32
+const token_len = 16
+const max_frame_len = 8 * 1024
+const sign_prefix = "nebula-relay:"
+
+var AuthFailedErr = errors.New("relay auth failed")
+
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	if len(payload) > max_frame_len {
+		return fmt.Errorf("relay frame is too long: %d", len(payload))
+	}
+	b := make([]byte, 3+len(payload))
+	b[0] = typ
+	binary.BigEndian.PutUint16(b[1:], uint16(len(payload)))
+	copy(b[3:], payload)
+	_, err := w.Write(b)
+	return err
+}
+
+func readFrame(r io.Reader) (typ byte, payload []byte, err error) {
+	header := make([]byte, 3)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	l := binary.BigEndian.Uint16(header[1:])
+	if l > max_frame_len {
+		return 0, nil, fmt.Errorf("relay frame is too long: %d", l)
+	}
+	payload = make([]byte, l)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
+
+// expectFrame read a frame of type, error frame is converted to error
+func expectFrame(r io.Reader, typ byte) ([]byte, error) {
+	t, payload, err := readFrame(r)
+	if err != nil {
+		return nil, err
+	}
+	if t == msg_error {
+		return nil, fmt.Errorf("relay error: %s", payload)
+	}
+	if t != typ {
+		return nil, fmt.Errorf("unexpected relay frame type: %d", t)
+	}
+	return payload, nil
+}
+
+func randBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+func authHash(nonce []byte) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte(sign_prefix))
+	hasher.Write(nonce)
+	return hasher.Sum(nil)
+}
+
+// encodeAuth is public key length(2) | public key | grant expire(8) | grant sign length(2) | grant sign | sign of challenge nonce
+func encodeAuth(pubKeyBytes []byte, priKey *rsa.PrivateKey, grant *Grant, nonce []byte) ([]byte, error) {
+	sign, err := rsa.SignPKCS1v15(rand.Reader, priKey, crypto.SHA256, authHash(nonce))
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 2+len(pubKeyBytes)+10+len(grant.Sign)+len(sign))
+	binary.BigEndian.PutUint16(b, uint16(len(pubKeyBytes)))
+	i := 2 + copy(b[2:], pubKeyBytes)
+	binary.BigEndian.PutUint64(b[i:], grant.Expire)
+	binary.BigEndian.PutUint16(b[i+8:], uint16(len(grant.Sign)))
+	i += 10 + copy(b[i+10:], grant.Sign)
+	copy(b[i:], sign)
+	return b, nil
+}
+
+// verifyAuth return node id which is hash of public key and grant of node signed by tracker
+func verifyAuth(payload []byte, nonce []byte, trackerKey *rsa.PublicKey) (nodeId []byte, grant *Grant, err error) {
+	if len(payload) < 2 {
+		return nil, nil, AuthFailedErr
+	}
+	l := int(binary.BigEndian.Uint16(payload))
+	if len(payload) < 2+l+10 {
+		return nil, nil, AuthFailedErr
+	}
+	pubKeyBytes := payload[2 : 2+l]
+	i := 2 + l
+	grant = &Grant{Expire: binary.BigEndian.Uint64(payload[i:])}
+	gl := int(binary.BigEndian.Uint16(payload[i+8:]))
+	i += 10
+	if len(payload) <= i+gl {
+		return nil, nil, AuthFailedErr
+	}
+	grant.Sign = payload[i : i+gl]
+	pubKey, err := x509.ParsePKCS1PublicKey(pubKeyBytes)
+	if err != nil {
+		return nil, nil, AuthFailedErr
+	}
+	if err = rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, authHash(nonce), payload[i+gl:]); err != nil {
+		return nil, nil, AuthFailedErr
+	}
+	nodeId = util_hash.Sha1(pubKeyBytes)
+	if err = VerifyGrant(trackerKey, nodeId, grant); err != nil {
+		return nil, nil, err
+	}
+	return nodeId, grant, nil
+}
@@ -0,0 +1,152 @@
+package relay
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/samoslab/nebula/provider/node"
+	"github.com/stretchr/testify/require"
+)
+
+func echo(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			io.Copy(conn, conn)
+			conn.Close()
+		}()
+	}
+}
+
+func roundTrip(t *testing.T, port uint16, msg string) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port))), time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Write([]byte(msg))
+	require.NoError(t, err)
+	buf := make([]byte, len(msg))
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, msg, string(buf))
+}
+
+// granted return grant func of node signed by tracker key
+func granted(trackerKey *rsa.PrivateKey, no *node.Node, ttl time.Duration) func() (*Grant, error) {
+	return func() (*Grant, error) {
+		return SignGrant(trackerKey, no.NodeId, uint64(time.Now().Add(ttl).Unix()))
+	}
+}
+
+func TestRelay(t *testing.T) {
+	trackerKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	server, err := NewServer("127.0.0.1:0", 0, 0, 1, &trackerKey.PublicKey)
+	require.NoError(t, err)
+	go server.Serve()
+	defer server.Close()
+	no := node.NewNode(1)
+	ready := make(chan uint16, 4)
+	l, err := Listen(server.Addr().String(), no, granted(trackerKey, no, time.Hour), func(port uint16) { ready <- port })
+	require.NoError(t, err)
+	go echo(l)
+	port := <-ready
+	require.Equal(t, port, l.Port())
+	require.Equal(t, "127.0.0.1", l.Host())
+	require.Equal(t, 1, server.Tunnels())
+	roundTrip(t, port, "hello")
+	roundTrip(t, port, "world")
+
+	// relay is full for another node
+	other := node.NewNode(1)
+	_, err = Listen(server.Addr().String(), other, granted(trackerKey, other, time.Hour), nil)
+	require.Error(t, err)
+
+	// control connection is reconnected after relay drops it
+	server.mutex.Lock()
+	for _, tu := range server.tunnels {
+		tu.close()
+	}
+	server.mutex.Unlock()
+	select {
+	case port = <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay is not reconnected")
+	}
+	roundTrip(t, port, "again")
+
+	require.NoError(t, l.Close())
+	_, err = l.Accept()
+	require.Equal(t, ListenerClosedErr, err)
+}
+
+func TestAuth(t *testing.T) {
+	trackerKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	no := node.NewNode(1)
+	grant, err := granted(trackerKey, no, time.Hour)()
+	require.NoError(t, err)
+	nonce, err := randBytes(nonce_len)
+	require.NoError(t, err)
+	auth, err := encodeAuth(no.PubKeyBytes, no.PriKey, grant, nonce)
+	require.NoError(t, err)
+	nodeId, g, err := verifyAuth(auth, nonce, &trackerKey.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, no.NodeId, nodeId)
+	require.Equal(t, grant.Expire, g.Expire)
+	nonce[0]++
+	_, _, err = verifyAuth(auth, nonce, &trackerKey.PublicKey)
+	require.Equal(t, AuthFailedErr, err)
+	_, _, err = verifyAuth(auth[:10], nonce, &trackerKey.PublicKey)
+	require.Equal(t, AuthFailedErr, err)
+}
+
+func TestGrant(t *testing.T) {
+	trackerKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	server, err := NewServer("127.0.0.1:0", 0, 0, 0, &trackerKey.PublicKey)
+	require.NoError(t, err)
+	go server.Serve()
+	defer server.Close()
+
+	// any valid node key is not enough, grant must be signed by tracker
+	no := node.NewNode(1)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = Listen(server.Addr().String(), no, granted(otherKey, no, time.Hour), nil)
+	require.Error(t, err)
+	// grant of other node
+	other := node.NewNode(1)
+	_, err = Listen(server.Addr().String(), no, granted(trackerKey, other, time.Hour), nil)
+	require.Error(t, err)
+	_, err = Listen(server.Addr().String(), no, granted(trackerKey, no, -time.Minute), nil)
+	require.Error(t, err)
+	require.Equal(t, 0, server.Tunnels())
+
+	// tunnel is closed when grant expires, node reconnects with a fresh grant
+	ready := make(chan uint16, 4)
+	ttl := 2 * time.Second
+	l, err := Listen(server.Addr().String(), no, func() (*Grant, error) {
+		g, err := granted(trackerKey, no, ttl)()
+		ttl = time.Hour
+		return g, err
+	}, func(port uint16) { ready <- port })
+	require.NoError(t, err)
+	defer l.Close()
+	go echo(l)
+	<-ready
+	select {
+	case port := <-ready:
+		roundTrip(t, port, "renewed")
+	case <-time.After(10 * time.Second):
+		t.Fatal("tunnel is not reconnected after grant expired")
+	}
+}
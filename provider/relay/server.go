@@ -0,0 +1,329 @@
+package relay
+
+import (
+	"crypto/rsa"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ping_interval of control connection, it is closed if nothing is read in idle_timeout
+const ping_interval = 30 * time.Second
+const idle_timeout = 3 * ping_interval
+
+// pending_timeout is how long client connection waits for data connection of NATed provider
+const pending_timeout = 10 * time.Second
+
+const handshake_timeout = 10 * time.Second
+
+var ServerClosedErr = errors.New("relay server closed")
+
+// Server relays client connections to NATed providers
+type Server struct {
+	listener  net.Listener
+	portMin   uint16 // public ports range, 0 means any free port
+	portMax   uint16
+	capacity  int
+	tracker   *rsa.PublicKey // key of tracker which signs grants
+	mutex     sync.Mutex
+	tunnels   map[string]*tunnel
+	pending   map[string]net.Conn
+	closed    bool
+	waitClose sync.WaitGroup
+}
+
+type tunnel struct {
+	nodeId     []byte
+	control    net.Conn
+	writeMutex sync.Mutex
+	public     net.Listener
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+func (self *tunnel) send(typ byte, payload []byte) error {
+	self.writeMutex.Lock()
+	defer self.writeMutex.Unlock()
+	return writeFrame(self.control, typ, payload)
+}
+
+func (self *tunnel) close() {
+	self.closeOnce.Do(func() {
+		close(self.done)
+		self.control.Close()
+		if self.public != nil {
+			self.public.Close()
+		}
+	})
+}
+
+// NewServer listen for NATed providers, public ports are allocated in [portMin, portMax].
+// Only providers holding grant signed by trackerKey are served.
+func NewServer(listen string, portMin uint16, portMax uint16, capacity int, trackerKey *rsa.PublicKey) (*Server, error) {
+	if portMin > portMax {
+		return nil, fmt.Errorf("invalid port range: %d-%d", portMin, portMax)
+	}
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{listener: lis, portMin: portMin, portMax: portMax, capacity: capacity, tracker: trackerKey,
+		tunnels: make(map[string]*tunnel, 8), pending: make(map[string]net.Conn, 16)}, nil
+}
+
+func (self *Server) Addr() net.Addr {
+	return self.listener.Addr()
+}
+
+// Tunnels count of connected NATed providers
+func (self *Server) Tunnels() int {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return len(self.tunnels)
+}
+
+// Serve accept connections until Close
+func (self *Server) Serve() error {
+	for {
+		conn, err := self.listener.Accept()
+		if err != nil {
+			self.mutex.Lock()
+			closed := self.closed
+			self.mutex.Unlock()
+			if closed {
+				return ServerClosedErr
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		go self.handle(conn)
+	}
+}
+
+func (self *Server) Close() {
+	self.mutex.Lock()
+	self.closed = true
+	tunnels := make([]*tunnel, 0, len(self.tunnels))
+	for _, t := range self.tunnels {
+		tunnels = append(tunnels, t)
+	}
+	for token, conn := range self.pending {
+		conn.Close()
+		delete(self.pending, token)
+	}
+	self.mutex.Unlock()
+	self.listener.Close()
+	for _, t := range tunnels {
+		t.close()
+	}
+	self.waitClose.Wait()
+}
+
+func (self *Server) handle(conn net.Conn) {
+	conn.SetDeadline(time.Now().Add(handshake_timeout))
+	payload, err := expectFrame(conn, msg_hello)
+	if err != nil || len(payload) < 2 || payload[0] != protocol_version {
+		writeFrame(conn, msg_error, []byte("unsupported relay protocol"))
+		conn.Close()
+		return
+	}
+	switch payload[1] {
+	case kind_control:
+		self.handleControl(conn)
+	case kind_data:
+		self.handleData(conn, payload[2:])
+	default:
+		conn.Close()
+	}
+}
+
+func (self *Server) handleControl(conn net.Conn) {
+	nonce, err := randBytes(nonce_len)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	if err = writeFrame(conn, msg_challenge, nonce); err != nil {
+		conn.Close()
+		return
+	}
+	payload, err := expectFrame(conn, msg_auth)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	nodeId, grant, err := verifyAuth(payload, nonce, self.tracker)
+	if err != nil {
+		writeFrame(conn, msg_error, []byte(err.Error()))
+		conn.Close()
+		return
+	}
+	t := &tunnel{nodeId: nodeId, control: conn, done: make(chan struct{})}
+	key := hex.EncodeToString(nodeId)
+	self.mutex.Lock()
+	old, reconnect := self.tunnels[key]
+	if self.closed || (!reconnect && self.capacity > 0 && len(self.tunnels) >= self.capacity) {
+		self.mutex.Unlock()
+		writeFrame(conn, msg_error, []byte("relay is full"))
+		conn.Close()
+		return
+	}
+	self.tunnels[key] = t
+	self.waitClose.Add(1)
+	self.mutex.Unlock()
+	defer self.waitClose.Done()
+	if reconnect {
+		old.close()
+	}
+	defer func() {
+		t.close()
+		self.mutex.Lock()
+		if self.tunnels[key] == t {
+			delete(self.tunnels, key)
+		}
+		self.mutex.Unlock()
+	}()
+	t.public, err = self.listenPublic()
+	if err != nil {
+		log.Warnf("relay allocate public port for node %s failed: %s", key, err)
+		writeFrame(conn, msg_error, []byte("relay has no free port"))
+		return
+	}
+	port := make([]byte, 2)
+	binary.BigEndian.PutUint16(port, uint16(t.public.Addr().(*net.TCPAddr).Port))
+	conn.SetDeadline(time.Time{})
+	if err = t.send(msg_ready, port); err != nil {
+		return
+	}
+	log.Infof("relay tunnel of node %s is ready on port %d", key, t.public.Addr().(*net.TCPAddr).Port)
+	// node reconnects with a fresh grant, so tunnel of node not registered any more does not last
+	expire := time.AfterFunc(time.Until(time.Unix(int64(grant.Expire), 0)), t.close)
+	defer expire.Stop()
+	go self.acceptPublic(t)
+	go self.ping(t)
+	for {
+		conn.SetReadDeadline(time.Now().Add(idle_timeout))
+		typ, _, err := readFrame(conn)
+		if err != nil {
+			if err != io.EOF {
+				log.Infof("relay tunnel of node %s closed: %s", key, err)
+			}
+			return
+		}
+		if typ == msg_ping {
+			t.send(msg_pong, nil)
+		}
+	}
+}
+
+func (self *Server) ping(t *tunnel) {
+	ticker := time.NewTicker(ping_interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			if err := t.send(msg_ping, nil); err != nil {
+				t.close()
+				return
+			}
+		}
+	}
+}
+
+func (self *Server) listenPublic() (net.Listener, error) {
+	host, _, err := net.SplitHostPort(self.listener.Addr().String())
+	if err != nil {
+		return nil, err
+	}
+	if self.portMin == 0 {
+		return net.Listen("tcp", net.JoinHostPort(host, "0"))
+	}
+	for port := int(self.portMin); port <= int(self.portMax); port++ {
+		lis, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err == nil {
+			return lis, nil
+		}
+	}
+	return nil, fmt.Errorf("all ports in %d-%d are in use", self.portMin, self.portMax)
+}
+
+// acceptPublic announce each client connection to NATed provider by token
+func (self *Server) acceptPublic(t *tunnel) {
+	for {
+		conn, err := t.public.Accept()
+		if err != nil {
+			t.close()
+			return
+		}
+		token, err := randBytes(token_len)
+		if err != nil {
+			conn.Close()
+			continue
+		}
+		key := string(token)
+		self.mutex.Lock()
+		self.pending[key] = conn
+		self.mutex.Unlock()
+		time.AfterFunc(pending_timeout, func() {
+			if c := self.takePending(key); c != nil {
+				c.Close()
+			}
+		})
+		if err = t.send(msg_connect, token); err != nil {
+			t.close()
+			return
+		}
+	}
+}
+
+func (self *Server) takePending(key string) net.Conn {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	conn, ok := self.pending[key]
+	if !ok {
+		return nil
+	}
+	delete(self.pending, key)
+	return conn
+}
+
+func (self *Server) handleData(conn net.Conn, token []byte) {
+	client := self.takePending(string(token))
+	if client == nil {
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+	splice(client, conn)
+}
+
+// splice copy both directions until either side closes
+func splice(a net.Conn, b net.Conn) {
+	done := make(chan struct{}, 2)
+	cp := func(dst net.Conn, src net.Conn) {
+		io.Copy(dst, src)
+		if tc, ok := dst.(*net.TCPConn); ok {
+			tc.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go cp(a, b)
+	go cp(b, a)
+	<-done
+	<-done
+	a.Close()
+	b.Close()
+}
@@ -8,10 +8,9 @@ import (
 	pb "github.com/samoslab/nebula/tracker/task/pb"
 )
 
-func TaskList(client pb.ProviderTaskServiceClient, remove bool, prove bool, send bool, replicate bool) (list []*pb.Task, err error) {
+func TaskList(client pb.ProviderTaskServiceClient, no *node.Node, remove bool, prove bool, send bool, replicate bool) (list []*pb.Task, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
-	node := node.LoadFormConfig()
 	var cate uint32 = 0
 	if remove {
 		cate |= 0x1
@@ -28,28 +27,27 @@ func TaskList(client pb.ProviderTaskServiceClient, remove bool, prove bool, send
 	if cate == 0 {
 		return
 	}
-	req := &pb.TaskListReq{NodeId: node.NodeId,
+	req := &pb.TaskListReq{NodeId: no.NodeId,
 		Timestamp: uint64(time.Now().Unix()),
 		Category:  cate}
-	req.SignReq(node.PriKey)
+	req.SignReq(no.PriKey)
 	resp, er := client.TaskList(ctx, req)
 	if er != nil {
 		return nil, er
 	}
-	if err = resp.CheckAuth(node.PubKeyBytes); err != nil {
+	if err = resp.CheckAuth(no.PubKeyBytes); err != nil {
 		return
 	}
 	return resp.Task, nil
 }
 
-func GetOppositeInfo(client pb.ProviderTaskServiceClient, taskId []byte) (resp *pb.GetOppositeInfoResp, err error) {
+func GetOppositeInfo(client pb.ProviderTaskServiceClient, no *node.Node, taskId []byte) (resp *pb.GetOppositeInfoResp, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
-	node := node.LoadFormConfig()
-	req := &pb.GetOppositeInfoReq{NodeId: node.NodeId,
+	req := &pb.GetOppositeInfoReq{NodeId: no.NodeId,
 		Timestamp: uint64(time.Now().Unix()),
 		TaskId:    taskId}
-	req.SignReq(node.PriKey)
+	req.SignReq(no.PriKey)
 	resp, er := client.GetOppositeInfo(ctx, req)
 	if er != nil {
 		return nil, er
@@ -57,14 +55,13 @@ func GetOppositeInfo(client pb.ProviderTaskServiceClient, taskId []byte) (resp *
 	return resp, nil
 }
 
-func GetProveInfo(client pb.ProviderTaskServiceClient, taskId []byte) (proofId []byte, chunkSize uint32, chunkSeq map[uint32][]byte, err error) {
+func GetProveInfo(client pb.ProviderTaskServiceClient, no *node.Node, taskId []byte) (proofId []byte, chunkSize uint32, chunkSeq map[uint32][]byte, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
-	node := node.LoadFormConfig()
-	req := &pb.GetProveInfoReq{NodeId: node.NodeId,
+	req := &pb.GetProveInfoReq{NodeId: no.NodeId,
 		Timestamp: uint64(time.Now().Unix()),
 		TaskId:    taskId}
-	req.SignReq(node.PriKey)
+	req.SignReq(no.PriKey)
 	resp, er := client.GetProveInfo(ctx, req)
 	if er != nil {
 		return nil, 0, nil, er
@@ -72,47 +69,45 @@ func GetProveInfo(client pb.ProviderTaskServiceClient, taskId []byte) (proofId [
 	return resp.ProofId, resp.ChunkSize, resp.ChunkSeq, nil
 }
 
-func FinishProve(client pb.ProviderTaskServiceClient, taskId []byte, proofId []byte, finishedTime uint64, result []byte, remark string) (err error) {
+func FinishProve(client pb.ProviderTaskServiceClient, no *node.Node, taskId []byte, proofId []byte, finishedTime uint64, result []byte, sigma []byte, remark string) (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
-	node := node.LoadFormConfig()
-	req := &pb.FinishProveReq{NodeId: node.NodeId,
+	req := &pb.FinishProveReq{NodeId: no.NodeId,
 		Timestamp:    uint64(time.Now().Unix()),
 		TaskId:       taskId,
 		ProofId:      proofId,
 		FinishedTime: finishedTime,
 		Result:       result,
+		Sigma:        sigma,
 		Remark:       remark}
-	req.SignReq(node.PriKey)
+	req.SignReq(no.PriKey)
 	_, err = client.FinishProve(ctx, req)
 	return
 }
 
-func FinishTask(client pb.ProviderTaskServiceClient, taskId []byte, finishedTime uint64, success bool, remark string) (err error) {
+func FinishTask(client pb.ProviderTaskServiceClient, no *node.Node, taskId []byte, finishedTime uint64, success bool, remark string) (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
-	node := node.LoadFormConfig()
-	req := &pb.FinishTaskReq{NodeId: node.NodeId,
+	req := &pb.FinishTaskReq{NodeId: no.NodeId,
 		Timestamp:    uint64(time.Now().Unix()),
 		TaskId:       taskId,
 		FinishedTime: finishedTime,
 		Success:      success,
 		Remark:       remark}
-	req.SignReq(node.PriKey)
+	req.SignReq(no.PriKey)
 	_, err = client.FinishTask(ctx, req)
 	return
 }
 
-func VerifyBlocks(client pb.ProviderTaskServiceClient, query bool, previous uint64, miss []*pb.HashAndSize) (last uint64, blocks []*pb.HashAndSize, hasNext bool, err error) {
+func VerifyBlocks(client pb.ProviderTaskServiceClient, no *node.Node, query bool, previous uint64, miss []*pb.HashAndSize) (last uint64, blocks []*pb.HashAndSize, hasNext bool, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
-	node := node.LoadFormConfig()
-	req := &pb.VerifyBlocksReq{NodeId: node.NodeId,
+	req := &pb.VerifyBlocksReq{NodeId: no.NodeId,
 		Timestamp: uint64(time.Now().Unix()),
 		Query:     query,
 		Previous:  previous,
 		Miss:      miss}
-	req.SignReq(node.PriKey)
+	req.SignReq(no.PriKey)
 	resp, er := client.VerifyBlocks(ctx, req)
 	if er != nil {
 		err = er
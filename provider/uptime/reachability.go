@@ -0,0 +1,31 @@
+package uptime
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	pb "github.com/samoslab/nebula/provider/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// CheckReachable ping provider at public address and check it is this node,
+// router without hairpin NAT fails this check though node is reachable from outside.
+func CheckReachable(address string, nodeIdHash []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, address, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return fmt.Errorf("RPC Dial %s failed: %s", address, err)
+	}
+	defer conn.Close()
+	resp, err := pb.NewProviderServiceClient(conn).Ping(ctx, &pb.PingReq{Version: pb.ProtocolVersion})
+	if err != nil {
+		return fmt.Errorf("ping %s failed: %s", address, err)
+	}
+	if !bytes.Equal(resp.NodeIdHash, nodeIdHash) {
+		return fmt.Errorf("%s is not this node", address)
+	}
+	return nil
+}
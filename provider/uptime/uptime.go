@@ -0,0 +1,277 @@
+// Package uptime keep a local journal of provider daemon uptime and reachability, and compute availability from it.
+//
+// The journal is an append-only file of fixed size records: unix time(8) | kind(1) | ok(1).
+// It is a plain file instead of a leveldb so that the uptime command can read it while daemon is running.
+package uptime
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	util_bytes "github.com/samoslab/nebula/util/bytes"
+)
+
+const record_len = 10
+
+// max_gap is the longest interval between events still counted as running, heartbeat is recorded every minute
+const max_gap = 3 * time.Minute
+
+// JournalKeep is how long events are kept, a little longer than the longest availability window
+const JournalKeep = 31 * 24 * time.Hour
+
+type Kind byte
+
+const (
+	KindStart Kind = iota + 1
+	KindStop
+	KindHeartbeat
+	KindRefreshIp
+	KindPrivateAlive
+	KindReachability
+)
+
+func (self Kind) String() string {
+	switch self {
+	case KindStart:
+		return "start"
+	case KindStop:
+		return "stop"
+	case KindHeartbeat:
+		return "heartbeat"
+	case KindRefreshIp:
+		return "refresh ip"
+	case KindPrivateAlive:
+		return "private alive"
+	case KindReachability:
+		return "reachability"
+	}
+	return fmt.Sprintf("unknown(%d)", byte(self))
+}
+
+// check is event kind whose failure makes node unavailable though it is running
+func (self Kind) check() bool {
+	return self == KindRefreshIp || self == KindPrivateAlive || self == KindReachability
+}
+
+type Event struct {
+	Time time.Time
+	Kind Kind
+	Ok   bool
+}
+
+func (self *Event) encode() []byte {
+	b := make([]byte, record_len)
+	copy(b, util_bytes.FromUint64(uint64(self.Time.Unix())))
+	b[8] = byte(self.Kind)
+	if self.Ok {
+		b[9] = 1
+	}
+	return b
+}
+
+func decodeEvent(b []byte) Event {
+	return Event{Time: time.Unix(int64(util_bytes.ToUint64(b, 0)), 0), Kind: Kind(b[8]), Ok: b[9] == 1}
+}
+
+var ClosedErr = errors.New("uptime journal is closed")
+
+// Journal is opened by daemon for appending events
+type Journal struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+}
+
+func Open(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{path: path, file: file}, nil
+}
+
+// Record append event of now
+func (self *Journal) Record(kind Kind, ok bool) error {
+	return self.record(Event{Time: time.Now(), Kind: kind, Ok: ok})
+}
+
+func (self *Journal) record(e Event) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.file == nil {
+		return ClosedErr
+	}
+	_, err := self.file.Write(e.encode())
+	return err
+}
+
+// Prune drop events older than keep by rewriting journal
+func (self *Journal) Prune(keep time.Duration) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.file == nil {
+		return ClosedErr
+	}
+	events, err := ReadEvents(self.path)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-keep)
+	i := 0
+	for i < len(events) && events[i].Time.Before(cutoff) {
+		i++
+	}
+	if i == 0 {
+		return nil
+	}
+	tempPath := self.path + ".pruning"
+	temp, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(temp)
+	for _, e := range events[i:] {
+		w.Write(e.encode())
+	}
+	if err = w.Flush(); err != nil {
+		temp.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err = os.Rename(tempPath, self.path); err != nil {
+		return err
+	}
+	self.file.Close()
+	self.file, err = os.OpenFile(self.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	return err
+}
+
+func (self *Journal) Close() error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.file == nil {
+		return nil
+	}
+	err := self.file.Close()
+	self.file = nil
+	return err
+}
+
+// ReadEvents read all events of journal file, a partial record written by crash is ignored
+func ReadEvents(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	res := make([]Event, 0, 1024)
+	buf := make([]byte, record_len)
+	for {
+		if _, err = io.ReadFull(r, buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return res, nil
+			}
+			return nil, err
+		}
+		res = append(res, decodeEvent(buf))
+	}
+}
+
+// Report is availability in a window ending at Time
+type Report struct {
+	Time     time.Time
+	Window   time.Duration
+	Observed time.Duration // part of window covered by journal
+	Up       time.Duration // running and all checks ok
+	Running  time.Duration
+	Failures map[Kind]int
+}
+
+// Availability is ratio of up time in observed time, 1 if nothing observed
+func (self *Report) Availability() float64 {
+	if self.Observed <= 0 {
+		return 1
+	}
+	return float64(self.Up) / float64(self.Observed)
+}
+
+// Compute availability of window ending at now, events must be in time order.
+// Node is running from start until stop or max_gap after its last event, and it is up when no check is failing.
+func Compute(events []Event, window time.Duration, now time.Time) *Report {
+	from := now.Add(-window)
+	res := &Report{Time: now, Window: window, Failures: make(map[Kind]int, 4)}
+	if len(events) == 0 {
+		return res
+	}
+	observedFrom := from
+	if events[0].Time.After(from) {
+		observedFrom = events[0].Time
+	}
+	if observedFrom.Before(now) {
+		res.Observed = now.Sub(observedFrom)
+	}
+	running := false
+	failing := make(map[Kind]bool, 4)
+	var last time.Time
+	account := func(to time.Time) {
+		if !running {
+			return
+		}
+		if to.Sub(last) > max_gap {
+			to = last.Add(max_gap)
+		}
+		start := last
+		if start.Before(from) {
+			start = from
+		}
+		if !to.After(start) {
+			return
+		}
+		res.Running += to.Sub(start)
+		if len(failing) == 0 {
+			res.Up += to.Sub(start)
+		}
+	}
+	for _, e := range events {
+		if e.Time.After(now) {
+			break
+		}
+		account(e.Time)
+		last = e.Time
+		inWindow := !e.Time.Before(from)
+		switch {
+		case e.Kind == KindStart:
+			running = true
+			failing = make(map[Kind]bool, 4)
+		case e.Kind == KindStop:
+			running = false
+		case e.Kind.check():
+			running = true
+			if e.Ok {
+				delete(failing, e.Kind)
+			} else {
+				failing[e.Kind] = true
+				if inWindow {
+					res.Failures[e.Kind]++
+				}
+			}
+		default:
+			running = true
+		}
+	}
+	account(now)
+	return res
+}
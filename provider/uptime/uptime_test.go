@@ -0,0 +1,73 @@
+package uptime
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	at := func(min int) time.Time { return now.Add(time.Duration(min) * time.Minute) }
+	events := []Event{
+		{at(-60), KindStart, true},
+		{at(-59), KindHeartbeat, true},
+		{at(-58), KindReachability, false},
+		{at(-56), KindReachability, true},
+		{at(-55), KindHeartbeat, true},
+		{at(-54), KindStop, true},
+		{at(-30), KindStart, true},
+		{at(-29), KindHeartbeat, true},
+		// crashed, only max_gap is counted
+		{at(-10), KindStart, true},
+		{at(-9), KindRefreshIp, false},
+		{at(-7), KindHeartbeat, true},
+		{at(-4), KindHeartbeat, true},
+		{at(-1), KindHeartbeat, true},
+	}
+	r := Compute(events, time.Hour, now)
+	require.Equal(t, time.Hour, r.Observed)
+	require.Equal(t, (6+4+10)*time.Minute, r.Running)
+	require.Equal(t, (4+4+1)*time.Minute, r.Up)
+	require.Equal(t, 1, r.Failures[KindReachability])
+	require.Equal(t, 1, r.Failures[KindRefreshIp])
+
+	r = Compute(events, 30*time.Minute, now)
+	require.Equal(t, 30*time.Minute, r.Observed)
+	require.Equal(t, (4+10)*time.Minute, r.Running)
+	require.Equal(t, 0, r.Failures[KindReachability])
+
+	r = Compute(events, 2*time.Hour, now)
+	require.Equal(t, time.Hour, r.Observed)
+	require.InDelta(t, 9.0/60, r.Availability(), 0.0001)
+
+	require.Equal(t, 1.0, Compute(nil, time.Hour, now).Availability())
+}
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uptime")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := dir + string(os.PathSeparator) + "uptime.journal"
+	j, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, j.record(Event{time.Now().Add(-40 * 24 * time.Hour), KindStart, true}))
+	require.NoError(t, j.Record(KindStart, true))
+	require.NoError(t, j.Record(KindReachability, false))
+	events, err := ReadEvents(path)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(events))
+	require.NoError(t, j.Prune(JournalKeep))
+	require.NoError(t, j.Record(KindStop, true))
+	require.NoError(t, j.Close())
+	require.Equal(t, ClosedErr, j.Record(KindStart, true))
+	events, err = ReadEvents(path)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(events))
+	require.Equal(t, KindStart, events[0].Kind)
+	require.False(t, events[1].Ok)
+	require.Equal(t, KindStop, events[2].Kind)
+}
@@ -66,3 +66,6 @@ func (self *pingProviderService) GetFragment(ctx context.Context, req *pb.GetFra
 func (self *pingProviderService) CheckAvailable(ctx context.Context, req *pb.CheckAvailableReq) (resp *pb.CheckAvailableResp, err error) {
 	return nil, nil
 }
+func (self *pingProviderService) GetChunkProof(ctx context.Context, req *pb.ChunkProofReq) (*pb.ChunkProofResp, error) {
+	return nil, nil
+}
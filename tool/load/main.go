@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/samoslab/nebula/provider/loadgen"
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	providersFlag := flag.String("providers", "127.0.0.1:6666", "provider addresses separated by comma, eg: 10.0.0.1:6666,10.0.0.2:6666")
+	publicKeysFlag := flag.String("publicKeys", "", "public key files of providers separated by comma to generate auth, one file for all providers, content is PublicKey in provider config or PEM; no auth if empty, which only works with stand-in provider of \"benchmark -serve\"")
+	concurrencyFlag := flag.Int("concurrency", 8, "concurrent requests")
+	durationFlag := flag.Duration("duration", 30*time.Second, "duration of load, it is ignored if requests is specified")
+	requestsFlag := flag.Int("requests", 0, "total requests")
+	readRatioFlag := flag.Float64("readRatio", 0.5, "fraction of Retrieve in requests, between 0 and 1")
+	sizesFlag := flag.String("sizes", "64K:3,1M:1", "block size mix as size:weight separated by comma, block less than 512K is stored and retrieved by unary RPC, eg: 64K:3,1M:1,4M")
+	seedFlag := flag.Int64("seed", 0, "random seed, current time if 0")
+	flag.Parse()
+	// action logs are not sent to collector by this tool
+	log.SetLevel(log.ErrorLevel)
+
+	sizes, err := loadgen.ParseMix(*sizesFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	config := &loadgen.Config{Providers: strings.Split(*providersFlag, ","),
+		Concurrency: *concurrencyFlag,
+		Duration:    *durationFlag,
+		Requests:    *requestsFlag,
+		ReadRatio:   *readRatioFlag,
+		Sizes:       sizes,
+		Seed:        *seedFlag}
+	if len(*publicKeysFlag) > 0 {
+		for _, path := range strings.Split(*publicKeysFlag, ",") {
+			key, err := loadgen.LoadPublicKey(path)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(3)
+			}
+			config.PublicKeys = append(config.PublicKeys, key)
+		}
+	}
+	report, err := loadgen.Run(config)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(4)
+	}
+	report.Print(os.Stdout)
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/samoslab/nebula/provider/config"
+	"github.com/samoslab/nebula/provider/impl"
+	pb "github.com/samoslab/nebula/provider/pb"
+	"github.com/syndtr/goleveldb/leveldb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+const home_config_folder = ".samos-nebula-provider"
+
+func usage() {
+	fmt.Printf(`Usage: %s <command> [arguments]
+
+Commands:
+  locate         locate block on provider: storage, path, small or large file
+  dump           dump provider db entry of block
+  verify         verify hash of block stored on provider
+  subpath        print sub path of block key in storage
+  id             decode node id, task id or ticket in hex or base64
+  ping           ping provider and print latency stats
+  clientdb       inspect client bolt db
+  repairLeveldb  recover corrupted leveldb
+
+Commands of provider block need the daemon stopped because of db lock.
+Run "%s <command> -h" for arguments of a command.
+`, os.Args[0], os.Args[0])
+}
+
+func main() {
+	var defaultConfigDir string
+	if usr, err := user.Current(); err == nil {
+		defaultConfigDir = filepath.Join(usr.HomeDir, home_config_folder)
+	}
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+	args := os.Args[2:]
+	switch os.Args[1] {
+	case "locate", "dump", "verify":
+		cmd := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+		configDirFlag := cmd.String("configDir", defaultConfigDir, "config directory of provider")
+		cmd.Parse(args)
+		key := parseBlockKey(cmd)
+		loadProviderConfig(*configDirFlag)
+		config.InitStorage()
+		defer config.CloseStorage()
+		switch os.Args[1] {
+		case "locate":
+			locate(key)
+		case "dump":
+			dump(key)
+		default:
+			verify(key)
+		}
+	case "subpath":
+		cmd := flag.NewFlagSet("subpath", flag.ExitOnError)
+		cmd.Parse(args)
+		sub, err := config.SubPath(parseBlockKey(cmd))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		fmt.Println(sub)
+	case "id":
+		cmd := flag.NewFlagSet("id", flag.ExitOnError)
+		cmd.Parse(args)
+		if cmd.NArg() != 1 {
+			fmt.Println("id in hex or base64 is required")
+			os.Exit(2)
+		}
+		decodeId(cmd.Arg(0))
+	case "ping":
+		cmd := flag.NewFlagSet("ping", flag.ExitOnError)
+		countFlag := cmd.Int("count", 5, "times of ping")
+		intervalFlag := cmd.Duration("interval", time.Second, "interval between pings")
+		timeoutFlag := cmd.Duration("timeout", 10*time.Second, "timeout of each ping")
+		cmd.Parse(args)
+		if cmd.NArg() != 1 {
+			fmt.Println("provider address is required, eg: 111.111.111.111:6666")
+			os.Exit(2)
+		}
+		ping(cmd.Arg(0), *countFlag, *intervalFlag, *timeoutFlag)
+	case "clientdb":
+		cmd := flag.NewFlagSet("clientdb", flag.ExitOnError)
+		pathFlag := cmd.String("path", "", "path of client db, eg: ~/.samos-nebula-client/data.db")
+		bucketFlag := cmd.String("bucket", "", "list entries of bucket, all buckets are listed with counts if empty")
+		keyFlag := cmd.String("key", "", "print value of key in bucket")
+		cmd.Parse(args)
+		inspectClientDb(*pathFlag, *bucketFlag, *keyFlag)
+	case "repairLeveldb":
+		cmd := flag.NewFlagSet("repairLeveldb", flag.ExitOnError)
+		cmd.Parse(args)
+		if cmd.NArg() != 1 {
+			fmt.Println("path of leveldb is required")
+			os.Exit(2)
+		}
+		db, err := leveldb.RecoverFile(cmd.Arg(0), nil)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(3)
+		}
+		db.Close()
+		fmt.Println("recover success")
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Printf("unknown command: %s\n", os.Args[1])
+		usage()
+		os.Exit(1)
+	}
+}
+
+func parseBlockKey(cmd *flag.FlagSet) []byte {
+	if cmd.NArg() != 1 {
+		fmt.Println("block key in hex is required")
+		os.Exit(2)
+	}
+	key, err := hex.DecodeString(cmd.Arg(0))
+	if err != nil {
+		fmt.Printf("decode block key %s failed: %s\n", cmd.Arg(0), err)
+		os.Exit(2)
+	}
+	return key
+}
+
+func loadProviderConfig(configDir string) {
+	if err := config.LoadConfig(configDir); err != nil {
+		if err == config.NoConfErr {
+			fmt.Printf("Config file is not found in %s\n", configDir)
+			os.Exit(200)
+		} else if err == config.ConfVerifyErr {
+			fmt.Println("Config file wrong, can not inspect block.")
+			os.Exit(201)
+		}
+		fmt.Println("failed to load config, can not inspect block: " + err.Error())
+		os.Exit(202)
+	}
+}
+
+func inspectBlock(key []byte) *impl.BlockInfo {
+	info, err := impl.InspectBlock(key)
+	if err != nil {
+		fmt.Printf("inspect block %x failed, please stop daemon and retry: %s\n", key, err)
+		os.Exit(3)
+	}
+	return info
+}
+
+func locate(key []byte) {
+	info := inspectBlock(key)
+	fmt.Printf("key:      %x\n", info.Key)
+	fmt.Printf("storage:  %d", info.StorageIndex)
+	if s := config.GetStorage(info.StorageIndex); s != nil {
+		fmt.Printf(" %s", s.Path)
+	}
+	if info.StorageFailed {
+		fmt.Print(" (failed)")
+	}
+	fmt.Println()
+	if info.SmallFile {
+		fmt.Println("type:     small file in storage db")
+	} else {
+		fmt.Println("type:     large file")
+		fmt.Printf("path:     %s\n", info.Path)
+	}
+	if info.StoredSize >= 0 {
+		fmt.Printf("size:     %d\n", info.StoredSize)
+	} else {
+		fmt.Println("size:     not readable")
+	}
+	if len(info.SealVersion) > 0 {
+		fmt.Printf("sealed:   key version %s\n", info.SealVersion)
+	} else {
+		fmt.Println("sealed:   no")
+	}
+	if info.Trash != nil {
+		fmt.Printf("trash:    removed %s, purge %s\n", info.Trash.RemoveTime.Format(time.RFC3339), info.Trash.PurgeTime().Format(time.RFC3339))
+	}
+}
+
+func dump(key []byte) {
+	info := inspectBlock(key)
+	db := "provider db"
+	if info.Trash != nil {
+		db = "tombstone in trash db"
+	}
+	fmt.Printf("%s entry: %x\n", db, info.Entry)
+	fmt.Printf("  [0] storage index: %d\n", info.Entry[0])
+	if len(info.Entry) > 1 {
+		fmt.Printf("  [1:] sub path: %s\n", info.Entry[1:])
+	} else {
+		fmt.Println("  no sub path: small file")
+	}
+	if info.Trash != nil {
+		fmt.Printf("  block size: %d\n", info.Trash.BlockSize)
+	}
+}
+
+func verify(key []byte) {
+	ok, err := impl.VerifyBlock(key)
+	if err != nil {
+		fmt.Printf("verify block %x failed: %s\n", key, err)
+		os.Exit(3)
+	}
+	if !ok {
+		fmt.Printf("block %x is corrupted\n", key)
+		os.Exit(4)
+	}
+	fmt.Printf("block %x is verified\n", key)
+}
+
+func decodeId(s string) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		if b, err = base64.StdEncoding.DecodeString(s); err != nil {
+			fmt.Println("id is neither hex nor base64")
+			os.Exit(2)
+		}
+	}
+	fmt.Printf("hex:    %x\n", b)
+	fmt.Printf("base64: %s\n", base64.StdEncoding.EncodeToString(b))
+	if len(b) == 16 {
+		fmt.Printf("uuid:   %x-%x-%x-%x-%x\n", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+		fmt.Println("kind:   task id, ticket or proof id")
+		return
+	}
+	if len(b) == 20 {
+		fmt.Println("kind:   node id or legacy sha1 block key")
+	}
+	if sub, err := config.SubPath(b); err == nil {
+		fmt.Printf("path:   %s if block key\n", sub)
+	}
+}
+
+func ping(addr string, count int, interval time.Duration, timeout time.Duration) {
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		fmt.Printf("RPC Dial failed: %s\n", err)
+		os.Exit(3)
+	}
+	defer conn.Close()
+	psc := pb.NewProviderServiceClient(conn)
+	var latencies []time.Duration
+	var last *pb.PingResp
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		start := time.Now()
+		resp, err := psc.Ping(ctx, &pb.PingReq{Version: pb.ProtocolVersion})
+		latency := time.Since(start)
+		cancel()
+		if err != nil {
+			fmt.Printf("ping %d failed: %s\n", i+1, err)
+			continue
+		}
+		last = resp
+		latencies = append(latencies, latency)
+		fmt.Printf("ping %d: %s\n", i+1, latency)
+	}
+	fmt.Printf("%d sent, %d succeeded\n", count, len(latencies))
+	if len(latencies) == 0 {
+		os.Exit(4)
+	}
+	fmt.Printf("node id hash: %x, protocol version: %d, features: %v\n", last.NodeIdHash, last.Version, last.Features)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var sum time.Duration
+	for _, l := range latencies {
+		sum += l
+	}
+	fmt.Printf("latency min %s, avg %s, median %s, max %s\n", latencies[0], sum/time.Duration(len(latencies)),
+		latencies[len(latencies)/2], latencies[len(latencies)-1])
+}
+
+func printValue(v []byte) {
+	var out bytes.Buffer
+	if json.Indent(&out, v, "  ", "  ") == nil {
+		fmt.Printf("  %s\n", out.String())
+	} else if isPrintable(v) {
+		fmt.Printf("  %s\n", v)
+	} else {
+		fmt.Printf("  %x\n", v)
+	}
+}
+
+func isPrintable(b []byte) bool {
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func inspectClientDb(path string, bucket string, key string) {
+	if len(path) == 0 {
+		fmt.Println("path of client db is required")
+		os.Exit(2)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		fmt.Printf("open %s failed, please stop client and retry: %s\n", path, err)
+		os.Exit(3)
+	}
+	defer db.Close()
+	err = db.View(func(tx *bolt.Tx) error {
+		if len(bucket) == 0 {
+			return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+				fmt.Printf("%s\t%d keys\n", name, b.Stats().KeyN)
+				return nil
+			})
+		}
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("bucket %s not found", bucket)
+		}
+		if len(key) > 0 {
+			v := b.Get([]byte(key))
+			if v == nil {
+				return fmt.Errorf("key %s not found", key)
+			}
+			printValue(v)
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			if isPrintable(k) {
+				fmt.Println(string(k))
+			} else {
+				fmt.Printf("%x\n", k)
+			}
+			if v == nil {
+				fmt.Println("  (bucket)")
+			} else {
+				printValue(v)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(4)
+	}
+}
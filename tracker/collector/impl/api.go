@@ -0,0 +1,114 @@
+package impl
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const date_layout = "2006-01-02"
+
+// ProviderStats is stats of a provider returned by api
+type ProviderStats struct {
+	NodeId string `json:"node_id"`
+	*Stats
+	SuccessRate   float64 `json:"success_rate"`
+	Throughput    float64 `json:"throughput"` // unit: byte/s
+	BillableBytes uint64  `json:"billable_bytes"`
+}
+
+// parseDate parse date in UTC, it returns def if s is empty
+func parseDate(s string, def uint32) (uint32, error) {
+	if len(s) == 0 {
+		return def, nil
+	}
+	t, err := time.Parse(date_layout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date %s, format: %s", s, date_layout)
+	}
+	return day(uint64(t.UnixNano())), nil
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// NewApiHandler serve queries and exports:
+//
+//	GET /api/v1/stats?nodeId=hex&from=2006-01-02&to=2006-01-02  stats of providers, all providers if nodeId is empty
+//	GET /api/v1/export?side=client|provider&from=2006-01-02&to=2006-01-02  logs as json lines
+//
+// from and to are inclusive days in UTC, all days if omitted
+func NewApiHandler(c *Collector) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/stats", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		from, err := parseDate(q.Get("from"), 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseDate(q.Get("to"), math.MaxUint32)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var nodeId []byte
+		if len(q.Get("nodeId")) > 0 {
+			if nodeId, err = hex.DecodeString(q.Get("nodeId")); err != nil {
+				http.Error(w, "invalid nodeId", http.StatusBadRequest)
+				return
+			}
+		}
+		stats, err := c.Stats(nodeId, from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		res := make([]*ProviderStats, 0, len(stats))
+		for id, s := range stats {
+			res = append(res, &ProviderStats{NodeId: hex.EncodeToString([]byte(id)), Stats: s,
+				SuccessRate: s.SuccessRate(), Throughput: s.Throughput(), BillableBytes: s.BillableBytes()})
+		}
+		sort.Slice(res, func(i, j int) bool { return res[i].NodeId < res[j].NodeId })
+		writeJson(w, res)
+	})
+	mux.HandleFunc("/api/v1/export", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		side := q.Get("side")
+		if side != "client" && side != "provider" {
+			http.Error(w, "side must be client or provider", http.StatusBadRequest)
+			return
+		}
+		from, err := parseDate(q.Get("from"), 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseDate(q.Get("to"), math.MaxUint32)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		end := uint64(math.MaxUint64)
+		if to < math.MaxUint32 {
+			end = (uint64(to) + 1) * nanos_per_day
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		if err = c.Export(side == "client", uint64(from)*nanos_per_day, end, func(l *Log) error {
+			return enc.Encode(l)
+		}); err != nil {
+			// header is sent, error can only be appended
+			fmt.Fprintf(w, "{\"error\":%q}\n", err.Error())
+		}
+	})
+	return mux
+}
@@ -0,0 +1,146 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+)
+
+var (
+	clientLogBkt   = []byte("collector_client_log")   // match key|node -> Log, reported by client or provider acting as client
+	providerLogBkt = []byte("collector_provider_log") // match key|node -> Log, reported by provider serving the transport
+	statsBkt       = []byte("collector_stats")        // node|day -> Stats
+)
+
+const nanos_per_day = 24 * 3600 * 1000 * 1000 * 1000
+
+// Log is an action log reported by client or provider, time unit is ns
+type Log struct {
+	NodeId        []byte `json:"node_id"` // reporter
+	AsClient      bool   `json:"as_client"`
+	Type          uint32 `json:"type"` // 1:Store, 2:Retrieve
+	Ticket        string `json:"ticket"`
+	Success       bool   `json:"success"`
+	FileHash      []byte `json:"file_hash"`
+	FileSize      uint64 `json:"file_size"`
+	BlockHash     []byte `json:"block_hash"`
+	BlockSize     uint64 `json:"block_size"`
+	BeginTime     uint64 `json:"begin_time"`
+	EndTime       uint64 `json:"end_time"`
+	TransportSize uint64 `json:"transport_size"`
+	Info          string `json:"info,omitempty"`
+}
+
+// Stats is aggregated action logs of a provider in a day
+type Stats struct {
+	Reported      uint64 `json:"reported"` // logs reported by provider
+	Matched       uint64 `json:"matched"`  // logs of provider confirmed by opposite
+	Success       uint64 `json:"success"`  // matched logs both sides succeeded
+	StoreBytes    uint64 `json:"store_bytes"`
+	RetrieveBytes uint64 `json:"retrieve_bytes"`
+	Duration      uint64 `json:"duration"` // ns of succeeded transports
+}
+
+func (self *Stats) add(o *Stats) {
+	self.Reported += o.Reported
+	self.Matched += o.Matched
+	self.Success += o.Success
+	self.StoreBytes += o.StoreBytes
+	self.RetrieveBytes += o.RetrieveBytes
+	self.Duration += o.Duration
+}
+
+// SuccessRate is rate of succeeded logs in matched logs, 0 if none matched
+func (self *Stats) SuccessRate() float64 {
+	if self.Matched == 0 {
+		return 0
+	}
+	return float64(self.Success) / float64(self.Matched)
+}
+
+// Throughput is billable bytes per second of succeeded transports
+func (self *Stats) Throughput() float64 {
+	if self.Duration == 0 {
+		return 0
+	}
+	return float64(self.StoreBytes+self.RetrieveBytes) * 1e9 / float64(self.Duration)
+}
+
+// BillableBytes is traffic confirmed by both sides
+func (self *Stats) BillableBytes() uint64 {
+	return self.StoreBytes + self.RetrieveBytes
+}
+
+func lengthPrefixed(b []byte) []byte {
+	return append([]byte{byte(len(b))}, b...)
+}
+
+// matchKey is ticket|type|block hash, logs of both sides of a transport have the same match key
+func matchKey(l *Log) []byte {
+	key := lengthPrefixed([]byte(l.Ticket))
+	key = append(key, byte(l.Type))
+	return append(key, lengthPrefixed(l.BlockHash)...)
+}
+
+func logKey(l *Log) []byte {
+	return append(matchKey(l), lengthPrefixed(l.NodeId)...)
+}
+
+func day(ns uint64) uint32 {
+	return uint32(ns / nanos_per_day)
+}
+
+func statsKey(nodeId []byte, d uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, d)
+	return append(lengthPrefixed(nodeId), b...)
+}
+
+// addStats add delta to stats of provider in the day
+func addStats(tx *bolt.Tx, nodeId []byte, d uint32, delta *Stats) error {
+	bkt := tx.Bucket(statsBkt)
+	key := statsKey(nodeId, d)
+	s := &Stats{}
+	if data := bkt.Get(key); data != nil {
+		if err := json.Unmarshal(data, s); err != nil {
+			return err
+		}
+	}
+	s.add(delta)
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return bkt.Put(key, data)
+}
+
+// putLog save log if it does not exist, it returns false if the log is duplicated
+func putLog(tx *bolt.Tx, bkt []byte, l *Log) (bool, error) {
+	b := tx.Bucket(bkt)
+	key := logKey(l)
+	if b.Get(key) != nil {
+		return false, nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return false, err
+	}
+	return true, b.Put(key, data)
+}
+
+// opposites return logs of the other side with the same match key
+func opposites(tx *bolt.Tx, bkt []byte, l *Log) ([]*Log, error) {
+	prefix := matchKey(l)
+	var res []*Log
+	c := tx.Bucket(bkt).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		o := &Log{}
+		if err := json.Unmarshal(v, o); err != nil {
+			return nil, err
+		}
+		res = append(res, o)
+	}
+	return res, nil
+}
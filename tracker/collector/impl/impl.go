@@ -0,0 +1,277 @@
+// Package impl is a self-hostable collector server, it verifies action logs reported by clients and providers,
+// and cross-matches both sides of transports by ticket to rate providers.
+package impl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+
+	"github.com/boltdb/bolt"
+	proto "github.com/golang/protobuf/proto"
+	cpb "github.com/samoslab/nebula/tracker/collector/client/pb"
+	ppb "github.com/samoslab/nebula/tracker/collector/provider/pb"
+	"github.com/samoslab/nebula/tracker/config"
+	"github.com/samoslab/nebula/tracker/registry"
+	"github.com/samoslab/nebula/util/dbutil"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Collector struct {
+	db       *bolt.DB
+	registry *registry.Registry
+	key      *config.Key
+}
+
+// NewCollector key is the tracker key, tickets of logs are verified by it
+func NewCollector(db *bolt.DB, reg *registry.Registry, key *config.Key) (*Collector, error) {
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bkt := range [][]byte{clientLogBkt, providerLogBkt, statsBkt} {
+			if _, err := tx.CreateBucketIfNotExists(bkt); err != nil {
+				return dbutil.NewCreateBucketFailedErr(bkt, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &Collector{db: db, registry: reg, key: key}, nil
+}
+
+// match aggregate a transport reported by both sides into stats of the provider
+func match(tx *bolt.Tx, server *Log, client *Log) error {
+	delta := &Stats{Matched: 1}
+	if server.Success && client.Success {
+		delta.Success = 1
+		size := server.TransportSize
+		if client.TransportSize < size {
+			size = client.TransportSize
+		}
+		if server.Type == 1 {
+			delta.StoreBytes = size
+		} else {
+			delta.RetrieveBytes = size
+		}
+		if server.EndTime > server.BeginTime {
+			delta.Duration = server.EndTime - server.BeginTime
+		}
+	}
+	return addStats(tx, server.NodeId, day(server.BeginTime), delta)
+}
+
+// Add save logs and aggregate them, duplicated logs and logs with ticket not issued by tracker are ignored,
+// it returns count of new logs
+func (self *Collector) Add(logs []*Log) (added int, err error) {
+	forged := 0
+	err = self.db.Update(func(tx *bolt.Tx) error {
+		forged = 0
+		for _, l := range logs {
+			if !self.key.VerifyTicket(l.Ticket) {
+				forged++
+				continue
+			}
+			bkt, oppositeBkt := providerLogBkt, clientLogBkt
+			if l.AsClient {
+				bkt, oppositeBkt = clientLogBkt, providerLogBkt
+			}
+			ok, err := putLog(tx, bkt, l)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			added++
+			if !l.AsClient {
+				if err = addStats(tx, l.NodeId, day(l.BeginTime), &Stats{Reported: 1}); err != nil {
+					return err
+				}
+			}
+			ops, err := opposites(tx, oppositeBkt, l)
+			if err != nil {
+				return err
+			}
+			for _, o := range ops {
+				if bytes.Equal(o.NodeId, l.NodeId) {
+					continue
+				}
+				server, client := l, o
+				if l.AsClient {
+					server, client = o, l
+				}
+				if err = match(tx, server, client); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err == nil && forged > 0 {
+		log.Warnf("ignored %d logs with ticket not issued by tracker", forged)
+	}
+	return
+}
+
+// Stats return aggregated stats of providers between day from and to inclusive, key is node id
+func (self *Collector) Stats(nodeId []byte, from uint32, to uint32) (map[string]*Stats, error) {
+	res := make(map[string]*Stats)
+	err := self.db.View(func(tx *bolt.Tx) error {
+		var prefix []byte
+		if nodeId != nil {
+			prefix = lengthPrefixed(nodeId)
+		}
+		c := tx.Bucket(statsBkt).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			id, d := k[1:1+int(k[0])], binary.BigEndian.Uint32(k[len(k)-4:])
+			if d < from || d > to {
+				continue
+			}
+			s := &Stats{}
+			if err := json.Unmarshal(v, s); err != nil {
+				return err
+			}
+			if _, ok := res[string(id)]; !ok {
+				res[string(id)] = &Stats{}
+			}
+			res[string(id)].add(s)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Export call f with logs of side whose begin time is in [from, to), time unit is ns
+func (self *Collector) Export(client bool, from uint64, to uint64, f func(l *Log) error) error {
+	bkt := providerLogBkt
+	if client {
+		bkt = clientLogBkt
+	}
+	return self.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bkt).ForEach(func(k, v []byte) error {
+			l := &Log{}
+			if err := json.Unmarshal(v, l); err != nil {
+				return err
+			}
+			if l.BeginTime < from || l.BeginTime >= to {
+				return nil
+			}
+			return f(l)
+		})
+	})
+}
+
+func (self *Collector) save(nodeId []byte, logs []*Log) error {
+	if _, err := self.Add(logs); err != nil {
+		log.Errorf("save batch of node %x failed: %s", nodeId, err)
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+func verifyFailed(nodeId []byte, err error) error {
+	log.Warnf("verify batch of node %x failed: %s", nodeId, err)
+	return status.Error(codes.Unauthenticated, err.Error())
+}
+
+// ProviderCollectorService receive action logs from providers
+type ProviderCollectorService struct {
+	collector *Collector
+}
+
+func NewProviderCollectorService(c *Collector) *ProviderCollectorService {
+	return &ProviderCollectorService{collector: c}
+}
+
+func (self *ProviderCollectorService) Collect(stream ppb.ProviderCollectorService_CollectServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&ppb.CollectResp{})
+		} else if err != nil {
+			return err
+		}
+		b := &ppb.Batch{}
+		if err = proto.Unmarshal(req.Data, b); err != nil {
+			return status.Error(codes.InvalidArgument, "unmarshal batch failed: "+err.Error())
+		}
+		pubKey, err := self.collector.registry.ProviderKey(b.NodeId)
+		if err != nil {
+			return verifyFailed(b.NodeId, err)
+		}
+		if err = b.VerifySign(pubKey); err != nil {
+			return verifyFailed(b.NodeId, err)
+		}
+		logs := make([]*Log, 0, len(b.ActionLog))
+		for _, al := range b.ActionLog {
+			logs = append(logs, &Log{NodeId: b.NodeId,
+				AsClient:      al.AsClient,
+				Type:          al.Type,
+				Ticket:        al.Ticket,
+				Success:       al.Success,
+				FileHash:      al.FileHash,
+				FileSize:      al.FileSize,
+				BlockHash:     al.BlockHash,
+				BlockSize:     al.BlockSize,
+				BeginTime:     al.BeginTime,
+				EndTime:       al.EndTime,
+				TransportSize: al.TransportSize,
+				Info:          al.Info})
+		}
+		if err = self.collector.save(b.NodeId, logs); err != nil {
+			return err
+		}
+	}
+}
+
+// ClientCollectorService receive action logs from clients
+type ClientCollectorService struct {
+	collector *Collector
+}
+
+func NewClientCollectorService(c *Collector) *ClientCollectorService {
+	return &ClientCollectorService{collector: c}
+}
+
+func (self *ClientCollectorService) Collect(stream cpb.ClientCollectorService_CollectServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&cpb.CollectResp{})
+		} else if err != nil {
+			return err
+		}
+		b := &cpb.Batch{}
+		if err = proto.Unmarshal(req.Data, b); err != nil {
+			return status.Error(codes.InvalidArgument, "unmarshal batch failed: "+err.Error())
+		}
+		pubKey, err := self.collector.registry.ClientKey(b.NodeId)
+		if err != nil {
+			return verifyFailed(b.NodeId, err)
+		}
+		if err = b.VerifySign(pubKey); err != nil {
+			return verifyFailed(b.NodeId, err)
+		}
+		logs := make([]*Log, 0, len(b.ActionLog))
+		for _, al := range b.ActionLog {
+			logs = append(logs, &Log{NodeId: b.NodeId,
+				AsClient:      true,
+				Type:          al.Type,
+				Ticket:        al.Ticket,
+				Success:       al.Success,
+				FileHash:      al.FileHash,
+				FileSize:      al.FileSize,
+				BlockHash:     al.BlockHash,
+				BlockSize:     al.BlockSize,
+				BeginTime:     al.BeginTime,
+				EndTime:       al.EndTime,
+				TransportSize: al.TransportSize,
+				Info:          al.Info})
+		}
+		if err = self.collector.save(b.NodeId, logs); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package impl
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/samoslab/nebula/tracker/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCollector(t *testing.T) (*Collector, func()) {
+	tr := testutil.NewTracker(t)
+	c, err := NewCollector(tr.DB, tr.Registry, tr.Key)
+	require.NoError(t, err)
+	return c, tr.Close
+}
+
+const test_begin = 3*nanos_per_day + 1000
+
+// testLogs logs of transports with tickets issued by key of collector
+func testLogs(c *Collector, provider []byte, client []byte) []*Log {
+	t1, t2, t3 := c.key.NewTicket(), c.key.NewTicket(), c.key.NewTicket()
+	return []*Log{
+		{NodeId: provider, Type: 1, Ticket: t1, Success: true, BlockHash: []byte("b1"), BeginTime: test_begin, EndTime: test_begin + 2e9, TransportSize: 1000},
+		{NodeId: client, AsClient: true, Type: 1, Ticket: t1, Success: true, BlockHash: []byte("b1"), BeginTime: test_begin, EndTime: test_begin + 2e9, TransportSize: 900},
+		{NodeId: provider, Type: 2, Ticket: t2, Success: true, BlockHash: []byte("b2"), BeginTime: test_begin, EndTime: test_begin + 2e9, TransportSize: 500},
+		{NodeId: client, AsClient: true, Type: 2, Ticket: t2, Success: false, BlockHash: []byte("b2"), BeginTime: test_begin, EndTime: test_begin + 2e9, TransportSize: 100},
+		// only reported by provider, not billable
+		{NodeId: provider, Type: 2, Ticket: t3, Success: true, BlockHash: []byte("b3"), BeginTime: test_begin, EndTime: test_begin + 2e9, TransportSize: 5000},
+	}
+}
+
+func TestAddAndStats(t *testing.T) {
+	c, clean := newTestCollector(t)
+	defer clean()
+	provider, client := []byte("provider"), []byte("client")
+
+	logs := testLogs(c, provider, client)
+	added, err := c.Add(logs)
+	require.NoError(t, err)
+	assert.Equal(t, 5, added)
+	added, err = c.Add(logs)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+	// both sides of a transport not arranged by tracker
+	forged := []*Log{
+		{NodeId: provider, Type: 1, Ticket: "forged", Success: true, BlockHash: []byte("b4"), BeginTime: test_begin, EndTime: test_begin + 2e9, TransportSize: 1e9},
+		{NodeId: client, AsClient: true, Type: 1, Ticket: "forged", Success: true, BlockHash: []byte("b4"), BeginTime: test_begin, EndTime: test_begin + 2e9, TransportSize: 1e9},
+	}
+	added, err = c.Add(forged)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added, "logs with ticket not issued by tracker are not credited")
+
+	stats, err := c.Stats(provider, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(stats))
+	s := stats[string(provider)]
+	assert.Equal(t, uint64(3), s.Reported)
+	assert.Equal(t, uint64(2), s.Matched)
+	assert.Equal(t, uint64(1), s.Success)
+	assert.Equal(t, 0.5, s.SuccessRate())
+	assert.Equal(t, uint64(900), s.BillableBytes())
+	assert.Equal(t, float64(450), s.Throughput())
+
+	stats, err = c.Stats(nil, 4, 10)
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+
+	var exported []*Log
+	require.NoError(t, c.Export(true, 0, 4*nanos_per_day, func(l *Log) error {
+		exported = append(exported, l)
+		return nil
+	}))
+	assert.Equal(t, 2, len(exported))
+}
+
+func TestApi(t *testing.T) {
+	c, clean := newTestCollector(t)
+	defer clean()
+	provider, client := []byte("provider"), []byte("client")
+	_, err := c.Add(testLogs(c, provider, client))
+	require.NoError(t, err)
+	server := httptest.NewServer(NewApiHandler(c))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/stats?from=1970-01-04&to=1970-01-04&nodeId=" + hex.EncodeToString(provider))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var stats []*ProviderStats
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	require.Equal(t, 1, len(stats))
+	assert.Equal(t, hex.EncodeToString(provider), stats[0].NodeId)
+	assert.Equal(t, uint64(900), stats[0].BillableBytes)
+
+	resp, err = http.Get(server.URL + "/api/v1/stats?from=yesterday")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/api/v1/export?side=provider&from=1970-01-04")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	lines := 0
+	for scanner := bufio.NewScanner(resp.Body); scanner.Scan(); lines++ {
+		l := &Log{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), l))
+		assert.Equal(t, provider, l.NodeId)
+	}
+	assert.Equal(t, 3, lines)
+}
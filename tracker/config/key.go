@@ -0,0 +1,55 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+
+	util_hash "github.com/samoslab/nebula/util/hash"
+)
+
+const key_bits = 256 * 8
+
+// Key is RSA key of tracker, nodes encrypt secrets by the public key and get it by GetPublicKey
+type Key struct {
+	PriKey      *rsa.PrivateKey
+	PubKeyBytes []byte // PKCS1 DER
+	PubKeyHash  []byte
+}
+
+func NewKey(priKey *rsa.PrivateKey) *Key {
+	pubKeyBytes := x509.MarshalPKCS1PublicKey(&priKey.PublicKey)
+	return &Key{PriKey: priKey, PubKeyBytes: pubKeyBytes, PubKeyHash: util_hash.Sha1(pubKeyBytes)}
+}
+
+// LoadKey read PEM key file, a new key is generated and saved if file does not exist
+func LoadKey(path string) (*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		priKey, err := rsa.GenerateKey(rand.Reader, key_bits)
+		if err != nil {
+			return nil, err
+		}
+		data = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priKey)})
+		if err = ioutil.WriteFile(path, data, 0600); err != nil {
+			return nil, err
+		}
+		return NewKey(priKey), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "RSA PRIVATE KEY" {
+		return nil, errors.New("invalid tracker key file: " + path)
+	}
+	priKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return NewKey(priKey), nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Server is address of tracker or collector handed out to nodes
+type Server struct {
+	Host string
+	Port uint32
+}
+
+func (self Server) String() string {
+	return net.JoinHostPort(self.Host, strconv.Itoa(int(self.Port)))
+}
+
+// ParseServers parse comma separated host:port list, eg: tracker1.example.com:6677,10.0.0.2:6677
+func ParseServers(s string) ([]Server, error) {
+	var res []Server
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		host, portStr, err := net.SplitHostPort(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server %s: %s", item, err)
+		}
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil || port == 0 {
+			return nil, fmt.Errorf("invalid port of server %s", item)
+		}
+		res = append(res, Server{Host: host, Port: uint32(port)})
+	}
+	return res, nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	ticket_id_len  = 16
+	ticket_mac_len = 16
+)
+
+// ticketSecret MAC key of tickets derived from private key, so trackers sharing the key verify tickets of each other
+func (self *Key) ticketSecret() []byte {
+	h := sha256.New()
+	h.Write([]byte("nebula ticket"))
+	h.Write(self.PriKey.D.Bytes())
+	return h.Sum(nil)
+}
+
+func (self *Key) ticketMac(id []byte) []byte {
+	mac := hmac.New(sha256.New, self.ticketSecret())
+	mac.Write(id)
+	return mac.Sum(nil)[:ticket_mac_len]
+}
+
+// NewTicket ticket of a transport arranged by tracker, it is random id with MAC, so collector credits only
+// transports with tickets issued by tracker
+func (self *Key) NewTicket() string {
+	id := make([]byte, ticket_id_len, ticket_id_len+ticket_mac_len)
+	rand.Read(id)
+	return hex.EncodeToString(append(id, self.ticketMac(id)...))
+}
+
+// VerifyTicket is true if ticket is issued by NewTicket of the key
+func (self *Key) VerifyTicket(ticket string) bool {
+	b, err := hex.DecodeString(ticket)
+	if err != nil || len(b) != ticket_id_len+ticket_mac_len {
+		return false
+	}
+	return hmac.Equal(b[ticket_id_len:], self.ticketMac(b[:ticket_id_len]))
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/boltdb/bolt"
+	ccpb "github.com/samoslab/nebula/tracker/collector/client/pb"
+	collector_impl "github.com/samoslab/nebula/tracker/collector/impl"
+	cppb "github.com/samoslab/nebula/tracker/collector/provider/pb"
+	"github.com/samoslab/nebula/tracker/config"
+	"github.com/samoslab/nebula/tracker/metadata/impl"
+	mpb "github.com/samoslab/nebula/tracker/metadata/pb"
+	client_impl "github.com/samoslab/nebula/tracker/register/client/impl"
+	rcpb "github.com/samoslab/nebula/tracker/register/client/pb"
+	"github.com/samoslab/nebula/tracker/register/mailer"
+	provider_impl "github.com/samoslab/nebula/tracker/register/provider/impl"
+	rppb "github.com/samoslab/nebula/tracker/register/provider/pb"
+	"github.com/samoslab/nebula/tracker/registry"
+	task_impl "github.com/samoslab/nebula/tracker/task/impl"
+	ttpb "github.com/samoslab/nebula/tracker/task/pb"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+const home_config_folder = ".samos-nebula-tracker"
+const key_file = "tracker.key"
+const db_file = "tracker.db"
+
+func main() {
+	var defaultConfigDirFlag string
+	usr, err := user.Current()
+	if err != nil {
+		fmt.Println("Get OS current user failed: ", err.Error())
+		fmt.Println("Be sure to use the -configDir parameter because of this system limitations.")
+		defaultConfigDirFlag, err = filepath.Abs(filepath.Dir(os.Args[0]))
+		if err != nil {
+			fmt.Printf("Get path of %s error: %s\n", os.Args[0], err)
+			os.Exit(100)
+		}
+	} else {
+		defaultConfigDirFlag = usr.HomeDir + string(os.PathSeparator) + home_config_folder
+	}
+
+	daemonCommand := flag.NewFlagSet("daemon", flag.ExitOnError)
+	daemonConfigDirFlag := daemonCommand.String("configDir", defaultConfigDirFlag, "config directory, tracker key and database are kept in it")
+	listenFlag := daemonCommand.String("listen", ":6677", "listen address and port of metadata service, eg: 111.111.111.111:6677 or :6677")
+	replicaMaxSizeFlag := daemonCommand.Uint64("replicaMaxSize", impl.DefaultPolicy().ReplicaMaxSize, "file not larger than it is stored as multiple replicas, unit: byte")
+	replicaCountFlag := daemonCommand.Uint("replicaCount", uint(impl.DefaultPolicy().ReplicaCount), "replica count of file stored as multiple replicas")
+	dataPieceFlag := daemonCommand.Uint("dataPiece", uint(impl.DefaultPolicy().DataPieceCount), "data piece count of file stored by erasure code")
+	verifyPieceFlag := daemonCommand.Uint("verifyPiece", uint(impl.DefaultPolicy().VerifyPieceCount), "verify piece count of file stored by erasure code")
+	chunkSizeFlag := daemonCommand.Uint("chunkSize", uint(impl.DefaultPolicy().ChunkSize), "chunk size of proof of storage, 0 means blocks are not tagged")
+	taskListenFlag := daemonCommand.String("taskListen", ":6622", "listen address and port of provider task service, eg: :6622")
+	scheduleIntervalFlag := daemonCommand.Duration("scheduleInterval", time.Minute, "interval of scheduling provider tasks, eg: 1m, 30s")
+	trackerServersFlag := daemonCommand.String("trackerServers", "", "tracker servers handed out to nodes, comma separated, eg: tracker1.example.com:6677,tracker2.example.com:6677")
+	collectorServersFlag := daemonCommand.String("collectorServers", "", "collector servers handed out to providers, comma separated, eg: collector.example.com:6688")
+	mailFileFlag := daemonCommand.String("mailFile", "", "append verify code mails to this file instead of sending them, mails are logged if neither it nor smtp is specified")
+	smtpFlag := daemonCommand.String("smtp", "", "smtp server sending verify code mails, eg: smtp.example.com:587")
+	smtpFromFlag := daemonCommand.String("smtpFrom", "", "sender address of mails")
+	smtpUserFlag := daemonCommand.String("smtpUser", "", "smtp username, auth is skipped if it is empty")
+	smtpPasswordFlag := daemonCommand.String("smtpPassword", "", "smtp password")
+	collectorListenFlag := daemonCommand.String("collectorListen", ":6688", "listen address and port of collector service, empty means collector is disabled")
+	collectorApiListenFlag := daemonCommand.String("collectorApiListen", "127.0.0.1:6689", "listen address and port of collector query and export api, empty means api is disabled")
+	packagesFlag := daemonCommand.String("packages", "", "json file of packages clients can buy, a free basic package is used if not specified")
+
+	importNodesCommand := flag.NewFlagSet("importNodes", flag.ExitOnError)
+	importNodesConfigDirFlag := importNodesCommand.String("configDir", defaultConfigDirFlag, "config directory")
+	importNodesFileFlag := importNodesCommand.String("file", "", "json file of clients and providers, format: {\"clients\":[...],\"providers\":[...]}")
+
+	rechargeCommand := flag.NewFlagSet("recharge", flag.ExitOnError)
+	rechargeConfigDirFlag := rechargeCommand.String("configDir", defaultConfigDirFlag, "config directory")
+	rechargeNodeIdFlag := rechargeCommand.String("nodeId", "", "node id of client in hex")
+	rechargeAmountFlag := rechargeCommand.Uint64("amount", 0, "amount added to balance of client")
+
+	if len(os.Args) == 1 {
+		fmt.Printf("usage: %s <command> [<args>]\n", os.Args[0])
+		fmt.Println("The most commonly used commands are: ")
+		fmt.Println(" daemon [-configDir config-dir] [-listen listen-address-and-port] [-replicaMaxSize size] [-replicaCount count] [-dataPiece count] [-verifyPiece count] [-chunkSize size] [-taskListen listen-address-and-port] [-scheduleInterval interval]" +
+			" [-trackerServers servers] [-collectorServers servers] [-mailFile mail-file] [-smtp smtp-server] [-smtpFrom sender] [-smtpUser username] [-smtpPassword password] [-packages packages-json-file]" +
+			" [-collectorListen listen-address-and-port] [-collectorApiListen listen-address-and-port]")
+		daemonCommand.PrintDefaults()
+		fmt.Println(" importNodes [-configDir config-dir] -file nodes-json-file")
+		importNodesCommand.PrintDefaults()
+		fmt.Println(" recharge [-configDir config-dir] -nodeId node-id -amount amount")
+		rechargeCommand.PrintDefaults()
+		os.Exit(101)
+	}
+
+	switch os.Args[1] {
+	case "daemon":
+		daemonCommand.Parse(os.Args[2:])
+		policy := impl.DefaultPolicy()
+		policy.ReplicaMaxSize, policy.ReplicaCount, policy.DataPieceCount, policy.VerifyPieceCount, policy.ChunkSize =
+			*replicaMaxSizeFlag, uint32(*replicaCountFlag), uint32(*dataPieceFlag), uint32(*verifyPieceFlag), uint32(*chunkSizeFlag)
+		rc := registerConfig{mailer: mailer.LogMailer{}, collectorListen: *collectorListenFlag, collectorApiListen: *collectorApiListenFlag}
+		var err error
+		if rc.trackerServers, err = config.ParseServers(*trackerServersFlag); err != nil {
+			fmt.Println(err)
+			os.Exit(103)
+		}
+		if rc.collectorServers, err = config.ParseServers(*collectorServersFlag); err != nil {
+			fmt.Println(err)
+			os.Exit(103)
+		}
+		if len(*smtpFlag) > 0 {
+			rc.mailer = mailer.NewSmtpMailer(*smtpFlag, *smtpFromFlag, *smtpUserFlag, *smtpPasswordFlag)
+		} else if len(*mailFileFlag) > 0 {
+			rc.mailer = mailer.NewFileMailer(*mailFileFlag)
+		}
+		rc.packages = client_impl.DefaultPackages()
+		if len(*packagesFlag) > 0 {
+			if rc.packages, err = client_impl.LoadPackages(*packagesFlag); err != nil {
+				fmt.Printf("load packages failed: %s\n", err)
+				os.Exit(104)
+			}
+		}
+		daemon(*daemonConfigDirFlag, *listenFlag, policy, *taskListenFlag, *scheduleIntervalFlag, rc)
+	case "importNodes":
+		importNodesCommand.Parse(os.Args[2:])
+		importNodes(*importNodesConfigDirFlag, *importNodesFileFlag)
+	case "recharge":
+		rechargeCommand.Parse(os.Args[2:])
+		recharge(*rechargeConfigDirFlag, *rechargeNodeIdFlag, *rechargeAmountFlag)
+	default:
+		fmt.Printf("%q is not valid command.\n", os.Args[1])
+		os.Exit(102)
+	}
+}
+
+func openDb(configDir string) *bolt.DB {
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		fmt.Printf("create config directory %s failed: %s\n", configDir, err)
+		os.Exit(200)
+	}
+	db, err := bolt.Open(filepath.Join(configDir, db_file), 0600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		fmt.Printf("open database failed: %s\n", err)
+		os.Exit(201)
+	}
+	return db
+}
+
+func openRegistry(db *bolt.DB) *registry.Registry {
+	reg, err := registry.New(db)
+	if err != nil {
+		fmt.Printf("open registry failed: %s\n", err)
+		os.Exit(202)
+	}
+	return reg
+}
+
+// registerConfig is config of register and order services
+type registerConfig struct {
+	trackerServers   []config.Server
+	collectorServers []config.Server
+	mailer           mailer.Mailer
+	packages         []*client_impl.Package
+	// empty collectorListen disables the built-in collector
+	collectorListen    string
+	collectorApiListen string
+}
+
+func daemon(configDir string, listen string, policy impl.Policy, taskListen string, scheduleInterval time.Duration, rc registerConfig) {
+	db := openDb(configDir)
+	defer db.Close()
+	reg := openRegistry(db)
+	key, err := config.LoadKey(filepath.Join(configDir, key_file))
+	if err != nil {
+		fmt.Printf("load tracker key failed: %s\n", err)
+		os.Exit(203)
+	}
+	metadataServer, err := impl.NewMetadataService(db, reg, key, policy)
+	if err != nil {
+		fmt.Printf("create metadata service failed: %s\n", err)
+		os.Exit(204)
+	}
+	taskServer, err := task_impl.NewProviderTaskService(db, metadataServer.Store(), reg, key, task_impl.DefaultConfig())
+	if err != nil {
+		fmt.Printf("create provider task service failed: %s\n", err)
+		os.Exit(205)
+	}
+	ledger, err := client_impl.NewLedger(db)
+	if err != nil {
+		fmt.Printf("open ledger failed: %s\n", err)
+		os.Exit(206)
+	}
+	orderServer, err := client_impl.NewOrderService(db, reg, rc.packages, ledger)
+	if err != nil {
+		fmt.Printf("create order service failed: %s\n", err)
+		os.Exit(207)
+	}
+	metadataServer.SetAccountant(orderServer)
+	providerConfig := provider_impl.DefaultConfig()
+	providerConfig.TrackerServers, providerConfig.CollectorServers, providerConfig.Mailer = rc.trackerServers, rc.collectorServers, rc.mailer
+	clientConfig := client_impl.DefaultConfig()
+	clientConfig.TrackerServers, clientConfig.Mailer = rc.trackerServers, rc.mailer
+	grpcServer := grpc.NewServer()
+	mpb.RegisterMatadataServiceServer(grpcServer, metadataServer)
+	rppb.RegisterProviderRegisterServiceServer(grpcServer, provider_impl.NewProviderRegisterService(reg, key, providerConfig))
+	rcpb.RegisterClientRegisterServiceServer(grpcServer, client_impl.NewClientRegisterService(reg, key, clientConfig))
+	rcpb.RegisterOrderServiceServer(grpcServer, orderServer)
+	defer serve(listen, grpcServer).GracefulStop()
+	if len(rc.collectorListen) > 0 {
+		collector, err := collector_impl.NewCollector(db, reg, key)
+		if err != nil {
+			fmt.Printf("create collector failed: %s\n", err)
+			os.Exit(208)
+		}
+		collectorGrpcServer := grpc.NewServer()
+		cppb.RegisterProviderCollectorServiceServer(collectorGrpcServer, collector_impl.NewProviderCollectorService(collector))
+		ccpb.RegisterClientCollectorServiceServer(collectorGrpcServer, collector_impl.NewClientCollectorService(collector))
+		defer serve(rc.collectorListen, collectorGrpcServer).GracefulStop()
+		if len(rc.collectorApiListen) > 0 {
+			apiServer := &http.Server{Addr: rc.collectorApiListen, Handler: collector_impl.NewApiHandler(collector)}
+			go func() {
+				if err := apiServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					log.Errorf("collector api server failed: %s", err)
+				}
+			}()
+			defer apiServer.Close()
+		}
+	}
+	taskGrpcServer := grpc.NewServer()
+	ttpb.RegisterProviderTaskServiceServer(taskGrpcServer, taskServer)
+	defer serve(taskListen, taskGrpcServer).GracefulStop()
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	fmt.Println("Tracker is running.")
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	for {
+		select {
+		case <-sigChan:
+			return
+		case <-ticker.C:
+			if err := taskServer.Schedule(); err != nil {
+				log.Errorf("schedule provider tasks failed: %s", err)
+			}
+		}
+	}
+}
+
+func serve(listen string, grpcServer *grpc.Server) *grpc.Server {
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		fmt.Printf("failed to listen: %s, error: %s\n", listen, err.Error())
+		os.Exit(3)
+	}
+	go grpcServer.Serve(lis)
+	return grpcServer
+}
+
+type nodesFile struct {
+	Clients   []*registry.Client   `json:"clients"`
+	Providers []*registry.Provider `json:"providers"`
+}
+
+func importNodes(configDir string, file string) {
+	if len(file) == 0 {
+		fmt.Println("file is required")
+		os.Exit(1)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		fmt.Printf("read file %s failed: %s\n", file, err)
+		os.Exit(2)
+	}
+	nodes := &nodesFile{}
+	if err = json.Unmarshal(data, nodes); err != nil {
+		fmt.Printf("parse file %s failed: %s\n", file, err)
+		os.Exit(3)
+	}
+	db := openDb(configDir)
+	defer db.Close()
+	reg := openRegistry(db)
+	for _, c := range nodes.Clients {
+		if _, err = c.PubKey(); err != nil {
+			fmt.Printf("invalid public key of client %x: %s\n", c.NodeId, err)
+			os.Exit(4)
+		}
+		if err = reg.PutClient(c); err != nil {
+			fmt.Printf("import client %x failed: %s\n", c.NodeId, err)
+			os.Exit(5)
+		}
+	}
+	for _, p := range nodes.Providers {
+		if _, err = p.PubKey(); err != nil {
+			fmt.Printf("invalid public key of provider %x: %s\n", p.NodeId, err)
+			os.Exit(4)
+		}
+		if err = reg.PutProvider(p); err != nil {
+			fmt.Printf("import provider %x failed: %s\n", p.NodeId, err)
+			os.Exit(5)
+		}
+	}
+	fmt.Printf("Imported %d clients and %d providers.\n", len(nodes.Clients), len(nodes.Providers))
+}
+
+func recharge(configDir string, nodeIdStr string, amount uint64) {
+	nodeId, err := hex.DecodeString(nodeIdStr)
+	if err != nil || len(nodeId) == 0 {
+		fmt.Println("invalid node id: " + nodeIdStr)
+		os.Exit(1)
+	}
+	db := openDb(configDir)
+	defer db.Close()
+	if _, err = openRegistry(db).Client(nodeId); err != nil {
+		fmt.Printf("client %s: %s\n", nodeIdStr, err)
+		os.Exit(2)
+	}
+	ledger, err := client_impl.NewLedger(db)
+	if err != nil {
+		fmt.Printf("open ledger failed: %s\n", err)
+		os.Exit(3)
+	}
+	if err = ledger.Recharge(nodeId, amount); err != nil {
+		fmt.Printf("recharge failed: %s\n", err)
+		os.Exit(4)
+	}
+	balance, err := ledger.Balance(nodeId)
+	if err != nil {
+		fmt.Printf("get balance failed: %s\n", err)
+		os.Exit(5)
+	}
+	fmt.Printf("Recharged %d, balance of client %s is %d now.\n", amount, nodeIdStr, balance)
+}
@@ -0,0 +1,489 @@
+// Package impl is a self-hostable implementation of MatadataService backed by bolt.
+package impl
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	ppb "github.com/samoslab/nebula/provider/pb"
+	"github.com/samoslab/nebula/tracker/config"
+	pb "github.com/samoslab/nebula/tracker/metadata/pb"
+	"github.com/samoslab/nebula/tracker/metadata/store"
+	"github.com/samoslab/nebula/tracker/registry"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	util_rsa "github.com/samoslab/nebula/util/rsa"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const timestamp_expired = 900
+const timestamp_ahead = -300
+
+const list_max_page_size = 2000
+
+// upload_expired seconds pending upload is kept after prepare, upload of a large file may take long
+const upload_expired = 24 * 3600
+
+// sys_file is tiny file in top level folder of space, it is returned by SpaceSysFile
+const sys_file = "/.nebula"
+
+// codes of CheckFileExistResp, 0 and 1 are defined by client protocol
+const (
+	code_ok = iota
+	code_upload
+	code_auth_failed
+	code_parent_not_exist
+	code_exists
+	code_no_provider
+	code_system_error
+	code_invalid_name
+	code_invalid_data
+)
+
+var PublicKeyExpiredErr = errors.New("tracker public key expired")
+
+// Policy decides how files are stored
+type Policy struct {
+	TinyFileSize     uint64 // file smaller than it is saved in metadata
+	ReplicaMaxSize   uint64 // file not larger than it is stored as MultiReplica
+	ReplicaCount     uint32
+	DataPieceCount   uint32
+	VerifyPieceCount uint32
+	SpareProviders   uint32 // standby providers of each ErasureCode partition
+	ChunkSize        uint32 // chunk size of proof of storage, 0 means blocks are not tagged
+}
+
+func DefaultPolicy() Policy {
+	return Policy{TinyFileSize: 8 * 1024,
+		ReplicaMaxSize:   4 * 1024 * 1024,
+		ReplicaCount:     3,
+		DataPieceCount:   4,
+		VerifyPieceCount: 2,
+		SpareProviders:   2,
+		ChunkSize:        256 * 1024}
+}
+
+// Accountant records usage of clients, volume is negative when files are removed
+type Accountant interface {
+	AddUsage(nodeId []byte, volume int64, up uint64, down uint64) error
+}
+
+type MetadataService struct {
+	store      *store.Store
+	registry   *registry.Registry
+	key        *config.Key
+	policy     Policy
+	accountant Accountant
+}
+
+func NewMetadataService(db *bolt.DB, reg *registry.Registry, key *config.Key, policy Policy) (*MetadataService, error) {
+	st, err := store.New(db)
+	if err != nil {
+		return nil, err
+	}
+	return &MetadataService{store: st, registry: reg, key: key, policy: policy}, nil
+}
+
+// SetAccountant enable usage accounting, it must be called before serving
+func (self *MetadataService) SetAccountant(a Accountant) {
+	self.accountant = a
+}
+
+func (self *MetadataService) addUsage(nodeId []byte, volume int64, up uint64, down uint64) {
+	if self.accountant == nil {
+		return
+	}
+	if err := self.accountant.AddUsage(nodeId, volume, up, down); err != nil {
+		log.Errorf("add usage of client %x failed: %s", nodeId, err)
+	}
+}
+
+// Store is used by task scheduler to walk stored files
+func (self *MetadataService) Store() *store.Store {
+	return self.store
+}
+
+type signedReq interface {
+	VerifySign(pubKey *rsa.PublicKey) error
+}
+
+func (self *MetadataService) verify(nodeId []byte, timestamp uint64, req signedReq) (*rsa.PublicKey, error) {
+	interval := time.Now().Unix() - int64(timestamp)
+	if interval > timestamp_expired || interval < timestamp_ahead {
+		return nil, errors.New("timestamp expired")
+	}
+	pubKey, err := self.registry.ClientKey(nodeId)
+	if err != nil {
+		return nil, err
+	}
+	if err = req.VerifySign(pubKey); err != nil {
+		return nil, errors.New("verify sign failed")
+	}
+	return pubKey, nil
+}
+
+func (self *MetadataService) checkPublicKeyHash(hash []byte) error {
+	if string(hash) != string(self.key.PubKeyHash) {
+		return status.Error(codes.InvalidArgument, PublicKeyExpiredErr.Error())
+	}
+	return nil
+}
+
+func now() uint64 {
+	return uint64(time.Now().Unix())
+}
+
+func parentCode(err error) uint32 {
+	switch err {
+	case store.NotExistErr, store.NotFolderErr:
+		return code_parent_not_exist
+	case store.InvalidNameErr:
+		return code_invalid_name
+	}
+	if strings.HasPrefix(err.Error(), store.ExistErr.Error()) {
+		return code_exists
+	}
+	return code_system_error
+}
+
+func (self *MetadataService) Ping(ctx context.Context, req *pb.PingReq) (*pb.PingResp, error) {
+	return &pb.PingResp{}, nil
+}
+
+func (self *MetadataService) GetPublicKey(ctx context.Context, req *pb.GetPublicKeyReq) (*pb.GetPublicKeyResp, error) {
+	return &pb.GetPublicKeyResp{PublicKey: self.key.PubKeyBytes, PublicKeyHash: self.key.PubKeyHash}, nil
+}
+
+func (self *MetadataService) MkFolder(ctx context.Context, req *pb.MkFolderReq) (*pb.MkFolderResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.MkFolderResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	if err := self.store.MkFolder(req.NodeId, req.Parent, req.Folder, req.Interactive); err != nil {
+		return &pb.MkFolderResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	return &pb.MkFolderResp{}, nil
+}
+
+// storeType suggest how to store a file by its size and reachable providers
+func (self *MetadataService) storeType(fileSize uint64) (*pb.CheckFileExistResp, error) {
+	pros, err := self.registry.Choose(int(self.policy.DataPieceCount+self.policy.VerifyPieceCount), nil)
+	if err != nil {
+		return nil, err
+	}
+	p := self.policy
+	if fileSize > p.ReplicaMaxSize && len(pros) >= int(p.DataPieceCount+p.VerifyPieceCount) {
+		return &pb.CheckFileExistResp{Code: code_upload, StoreType: pb.FileStoreType_ErasureCode,
+			DataPieceCount: p.DataPieceCount, VerifyPieceCount: p.VerifyPieceCount, ChunkSize: p.ChunkSize}, nil
+	}
+	if len(pros) < int(p.ReplicaCount) {
+		return &pb.CheckFileExistResp{Code: code_no_provider, ErrMsg: "not enough providers"}, nil
+	}
+	return &pb.CheckFileExistResp{Code: code_upload, StoreType: pb.FileStoreType_MultiReplica,
+		ReplicaCount: p.ReplicaCount, ChunkSize: p.ChunkSize}, nil
+}
+
+func (self *MetadataService) CheckFileExist(ctx context.Context, req *pb.CheckFileExistReq) (*pb.CheckFileExistResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.CheckFileExistResp{Code: code_auth_failed, ErrMsg: err.Error()}, nil
+	}
+	if err := self.checkPublicKeyHash(req.PublicKeyHash); err != nil {
+		return nil, err
+	}
+	existing, err := self.store.CheckName(req.NodeId, req.Parent, req.FileName)
+	if err != nil {
+		return &pb.CheckFileExistResp{Code: parentCode(err), ErrMsg: err.Error()}, nil
+	}
+	if existing != nil && req.Interactive && !req.NewVersion &&
+		(existing.Folder || string(existing.FileHash) != string(req.FileHash) || existing.FileSize != req.FileSize) {
+		return &pb.CheckFileExistResp{Code: code_exists, ErrMsg: store.ExistErr.Error()}, nil
+	}
+	e := &store.Entry{Name: req.FileName, ModTime: req.FileModTime, FileHash: req.FileHash, FileSize: req.FileSize, FileType: req.FileType, EncryptKey: req.EncryptKey}
+	var f *store.File
+	if _, err = self.store.File(req.FileHash, req.FileSize); err == store.NotExistErr {
+		if req.FileSize >= self.policy.TinyFileSize || uint64(len(req.FileData)) != req.FileSize {
+			resp, err := self.storeType(req.FileSize)
+			if err != nil {
+				log.Errorf("choose providers failed: %s", err)
+				return &pb.CheckFileExistResp{Code: code_system_error, ErrMsg: "System error: " + err.Error()}, nil
+			}
+			return resp, nil
+		}
+		// tiny file is stored as given, it must not be stored under hash of other content
+		if !util_hash.VerifyKey(req.FileHash, req.FileData) {
+			return &pb.CheckFileExistResp{Code: code_invalid_data, ErrMsg: "file data does not match file hash"}, nil
+		}
+		f = &store.File{Hash: req.FileHash, Size: req.FileSize, Data: req.FileData}
+	} else if err != nil {
+		return &pb.CheckFileExistResp{Code: code_system_error, ErrMsg: "System error: " + err.Error()}, nil
+	}
+	volume, err := self.store.AddFile(req.NodeId, req.Parent, e, f, req.Interactive, req.NewVersion)
+	if err != nil {
+		return &pb.CheckFileExistResp{Code: parentCode(err), ErrMsg: err.Error()}, nil
+	}
+	self.addUsage(req.NodeId, volume, uint64(len(req.FileData)), 0)
+	return &pb.CheckFileExistResp{Code: code_ok}, nil
+}
+
+func (self *MetadataService) UploadFilePrepare(ctx context.Context, req *pb.UploadFilePrepareReq) (*pb.UploadFilePrepareResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if len(req.Partition) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no partition")
+	}
+	// providers are recorded, UploadFileDone only accepts them as holders of pieces
+	u := store.NewUpload(now() + upload_expired)
+	var resp *pb.UploadFilePrepareResp
+	if len(req.Partition) == 1 && len(req.Partition[0].Piece) == 1 {
+		var err error
+		if resp, err = self.prepareReplica(req, u); err != nil {
+			return nil, err
+		}
+	} else {
+		resp = &pb.UploadFilePrepareResp{Partition: make([]*pb.ErasureCodePartition, 0, len(req.Partition))}
+		for _, p := range req.Partition {
+			ecp, err := self.prepareErasureCode(req, p, u)
+			if err != nil {
+				return nil, err
+			}
+			resp.Partition = append(resp.Partition, ecp)
+		}
+	}
+	if err := self.store.PrepareUpload(req.NodeId, req.FileHash, req.FileSize, u); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return resp, nil
+}
+
+func (self *MetadataService) prepareReplica(req *pb.UploadFilePrepareReq, u *store.Upload) (*pb.UploadFilePrepareResp, error) {
+	piece := req.Partition[0].Piece[0]
+	// more providers than replica count, client chooses the best
+	pros, err := self.registry.Choose(int(self.policy.ReplicaCount+self.policy.SpareProviders), nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if len(pros) < int(self.policy.ReplicaCount) {
+		return nil, status.Error(codes.Unavailable, "not enough providers")
+	}
+	ts := now()
+	resp := &pb.UploadFilePrepareResp{ReplicaCount: self.policy.ReplicaCount, Provider: make([]*pb.ReplicaProvider, 0, len(pros))}
+	for _, pro := range pros {
+		ticket := self.key.NewTicket()
+		u.Assign(piece.Hash, uint64(piece.Size), pro.NodeId)
+		resp.Provider = append(resp.Provider, &pb.ReplicaProvider{NodeId: pro.NodeId,
+			Server:    pro.Server(),
+			Port:      pro.Port,
+			Timestamp: ts,
+			Ticket:    ticket,
+			Auth:      ppb.GenStoreAuth(pro.PublicKey, req.FileHash, req.FileSize, piece.Hash, uint64(piece.Size), ts, ticket)})
+	}
+	return resp, nil
+}
+
+func (self *MetadataService) prepareErasureCode(req *pb.UploadFilePrepareReq, p *pb.SplitPartition, u *store.Upload) (*pb.ErasureCodePartition, error) {
+	pros, err := self.registry.Choose(len(p.Piece)+int(self.policy.SpareProviders), nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if len(pros) < len(p.Piece) {
+		return nil, status.Error(codes.Unavailable, "not enough providers")
+	}
+	ts := now()
+	hashAuth := func(pro *registry.Provider, piece *pb.PieceHashAndSize) *pb.PieceHashAuth {
+		ticket := self.key.NewTicket()
+		u.Assign(piece.Hash, uint64(piece.Size), pro.NodeId)
+		return &pb.PieceHashAuth{Hash: piece.Hash,
+			Size:   piece.Size,
+			Ticket: ticket,
+			Auth:   ppb.GenStoreAuth(pro.PublicKey, req.FileHash, req.FileSize, piece.Hash, uint64(piece.Size), ts, ticket)}
+	}
+	ecp := &pb.ErasureCodePartition{Timestamp: ts, ProviderAuth: make([]*pb.BlockProviderAuth, 0, len(pros))}
+	for i, pro := range pros {
+		bpa := &pb.BlockProviderAuth{NodeId: pro.NodeId, Server: pro.Server(), Port: pro.Port}
+		if i < len(p.Piece) {
+			bpa.HashAuth = []*pb.PieceHashAuth{hashAuth(pro, p.Piece[i])}
+		} else {
+			bpa.Spare = true
+			for _, piece := range p.Piece {
+				bpa.HashAuth = append(bpa.HashAuth, hashAuth(pro, piece))
+			}
+		}
+		ecp.ProviderAuth = append(ecp.ProviderAuth, bpa)
+	}
+	return ecp, nil
+}
+
+func (self *MetadataService) UploadFileDone(ctx context.Context, req *pb.UploadFileDoneReq) (*pb.UploadFileDoneResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.UploadFileDoneResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	if err := self.checkPublicKeyHash(req.PublicKeyHash); err != nil {
+		return nil, err
+	}
+	if len(req.Partition) == 0 {
+		return &pb.UploadFileDoneResp{Code: 1, ErrMsg: "no partition"}, nil
+	}
+	f := &store.File{Hash: req.FileHash, Size: req.FileSize, StoreType: pb.FileStoreType_ErasureCode, Partitions: req.Partition}
+	if len(req.Partition) == 1 && len(req.Partition[0].Block) == 1 {
+		f.StoreType = pb.FileStoreType_MultiReplica
+	}
+	u, err := self.store.PendingUpload(req.NodeId, req.FileHash, req.FileSize)
+	if err == store.NotExistErr {
+		return &pb.UploadFileDoneResp{Code: 1, ErrMsg: "upload is not prepared or expired"}, nil
+	} else if err != nil {
+		return &pb.UploadFileDoneResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	for _, p := range req.Partition {
+		for _, b := range p.Block {
+			if len(b.StoreNodeId) == 0 {
+				return &pb.UploadFileDoneResp{Code: 1, ErrMsg: "block is not stored"}, nil
+			}
+			for _, id := range b.StoreNodeId {
+				if !u.IsAssigned(b.Hash, b.Size, id) {
+					return &pb.UploadFileDoneResp{Code: 1, ErrMsg: fmt.Sprintf("block %x is not assigned to provider %x", b.Hash, id)}, nil
+				}
+			}
+		}
+	}
+	e := &store.Entry{Name: req.FileName, ModTime: req.FileModTime, FileHash: req.FileHash, FileSize: req.FileSize, FileType: req.FileType, EncryptKey: req.EncryptKey}
+	volume, err := self.store.AddFile(req.NodeId, req.Parent, e, f, req.Interactive, req.NewVersion)
+	if err != nil {
+		return &pb.UploadFileDoneResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	if err = self.store.DropUpload(req.NodeId, req.FileHash, req.FileSize); err != nil {
+		log.Warnf("drop pending upload of file %x failed: %s", req.FileHash, err)
+	}
+	self.addUsage(req.NodeId, volume, req.FileSize, 0)
+	return &pb.UploadFileDoneResp{}, nil
+}
+
+func (self *MetadataService) ListFiles(ctx context.Context, req *pb.ListFilesReq) (*pb.ListFilesResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.ListFilesResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	if req.PageSize == 0 || req.PageSize > list_max_page_size {
+		return &pb.ListFilesResp{Code: 1, ErrMsg: "page size must be between 1 and 2000"}, nil
+	}
+	total, page, err := self.store.List(req.NodeId, req.Parent, req.SortType, req.AscOrder, req.PageSize, req.PageNum)
+	if err != nil {
+		return &pb.ListFilesResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	resp := &pb.ListFilesResp{TotalRecord: total, Fof: make([]*pb.FileOrFolder, 0, len(page))}
+	for _, e := range page {
+		resp.Fof = append(resp.Fof, &pb.FileOrFolder{Id: e.Id,
+			Folder:   e.Folder,
+			Name:     e.Name,
+			ModTime:  e.ModTime,
+			FileHash: e.FileHash,
+			FileSize: e.FileSize,
+			FileType: e.FileType})
+	}
+	return resp, nil
+}
+
+// reencrypt decrypt key encrypted by tracker public key and encrypt it by client public key
+func (self *MetadataService) reencrypt(encryptKey []byte, pubKey *rsa.PublicKey) ([]byte, error) {
+	if len(encryptKey) == 0 {
+		return nil, nil
+	}
+	key, err := util_rsa.DecryptLong(self.key.PriKey, encryptKey, self.key.PriKey.Size())
+	if err != nil {
+		return nil, err
+	}
+	return util_rsa.EncryptLong(pubKey, key, pubKey.Size())
+}
+
+func (self *MetadataService) RetrieveFile(ctx context.Context, req *pb.RetrieveFileReq) (*pb.RetrieveFileResp, error) {
+	pubKey, err := self.verify(req.NodeId, req.Timestamp, req)
+	if err != nil {
+		return &pb.RetrieveFileResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	e, err := self.store.OwnedFile(req.NodeId, req.SpaceNo, req.FileHash, req.FileSize)
+	if err != nil {
+		return &pb.RetrieveFileResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	f, err := self.store.File(req.FileHash, req.FileSize)
+	if err != nil {
+		return &pb.RetrieveFileResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	resp := &pb.RetrieveFileResp{FileType: e.FileType}
+	self.addUsage(req.NodeId, 0, 0, f.Size)
+	if resp.EncryptKey, err = self.reencrypt(e.EncryptKey, pubKey); err != nil {
+		log.Errorf("reencrypt key of file %x failed: %s", req.FileHash, err)
+		return &pb.RetrieveFileResp{Code: 1, ErrMsg: "System error: " + err.Error()}, nil
+	}
+	if f.Tiny() {
+		resp.FileData = f.Data
+		return resp, nil
+	}
+	resp.Timestamp = now()
+	resp.Partition = make([]*pb.RetrievePartition, 0, len(f.Partitions))
+	for _, p := range f.Partitions {
+		rp := &pb.RetrievePartition{Block: make([]*pb.RetrieveBlock, 0, len(p.Block))}
+		for _, b := range p.Block {
+			rb := &pb.RetrieveBlock{Hash: b.Hash, Size: b.Size, BlockSeq: b.BlockSeq, Checksum: b.Checksum}
+			for _, nodeId := range b.StoreNodeId {
+				pro, err := self.registry.Provider(nodeId)
+				if err != nil || !pro.Reachable() {
+					continue
+				}
+				ticket := self.key.NewTicket()
+				rb.StoreNode = append(rb.StoreNode, &pb.RetrieveNode{NodeId: nodeId,
+					Server: pro.Server(),
+					Port:   pro.Port,
+					Ticket: ticket,
+					Auth:   ppb.GenRetrieveAuth(pro.PublicKey, f.Hash, f.Size, b.Hash, b.Size, resp.Timestamp, ticket)})
+			}
+			rp.Block = append(rp.Block, rb)
+		}
+		resp.Partition = append(resp.Partition, rp)
+	}
+	return resp, nil
+}
+
+func (self *MetadataService) Remove(ctx context.Context, req *pb.RemoveReq) (*pb.RemoveResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.RemoveResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	removed, err := self.store.Remove(req.NodeId, req.Target, req.Recursive)
+	if err != nil {
+		return &pb.RemoveResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	if removed > 0 {
+		self.addUsage(req.NodeId, -int64(removed), 0, 0)
+	}
+	return &pb.RemoveResp{}, nil
+}
+
+func (self *MetadataService) Move(ctx context.Context, req *pb.MoveReq) (*pb.MoveResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.MoveResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	if err := self.store.Move(req.NodeId, req.Source, req.Dest); err != nil {
+		return &pb.MoveResp{Code: 1, ErrMsg: err.Error()}, nil
+	}
+	return &pb.MoveResp{}, nil
+}
+
+func (self *MetadataService) SpaceSysFile(ctx context.Context, req *pb.SpaceSysFileReq) (*pb.SpaceSysFileResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	e, err := self.store.Resolve(req.NodeId, &pb.FilePath{OneOfPath: &pb.FilePath_Path{Path: sys_file}, SpaceNo: req.SpaceNo})
+	if err == store.NotExistErr {
+		return &pb.SpaceSysFileResp{}, nil
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	f, err := self.store.File(e.FileHash, e.FileSize)
+	if err != nil {
+		return &pb.SpaceSysFileResp{}, nil
+	}
+	return &pb.SpaceSysFileResp{Data: f.Data}, nil
+}
@@ -0,0 +1,331 @@
+package impl
+
+import (
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	ppb "github.com/samoslab/nebula/provider/pb"
+	"github.com/samoslab/nebula/tracker/config"
+	pb "github.com/samoslab/nebula/tracker/metadata/pb"
+	"github.com/samoslab/nebula/tracker/metadata/store"
+	"github.com/samoslab/nebula/tracker/testutil"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	util_rsa "github.com/samoslab/nebula/util/rsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/status"
+)
+
+type testEnv struct {
+	ms      *MetadataService
+	key     *config.Key
+	priKey  *rsa.PrivateKey
+	nodeId  []byte
+	proKeys map[string][]byte
+}
+
+func newTestEnv(t *testing.T, providers int) (*testEnv, func()) {
+	tr := testutil.NewTracker(t)
+	client := tr.AddClient(t)
+	env := &testEnv{key: tr.Key, priKey: client.PriKey, nodeId: client.NodeId, proKeys: make(map[string][]byte)}
+	for i := 0; i < providers; i++ {
+		p := tr.AddProvider(t)
+		env.proKeys[string(p.NodeId)] = p.PubKey
+	}
+	var err error
+	env.ms, err = NewMetadataService(tr.DB, tr.Registry, env.key, DefaultPolicy())
+	require.NoError(t, err)
+	return env, tr.Close
+}
+
+func (self *testEnv) checkReq(parent string, data []byte, size uint64, name string) *pb.CheckFileExistReq {
+	hash := util_hash.Sha1([]byte(name))
+	if data != nil {
+		hash = util_hash.Sha1(data)
+	}
+	return &pb.CheckFileExistReq{NodeId: self.nodeId,
+		Timestamp:     uint64(time.Now().Unix()),
+		Parent:        &pb.FilePath{OneOfPath: &pb.FilePath_Path{Path: parent}},
+		FileHash:      hash,
+		FileSize:      size,
+		FileName:      name,
+		FileData:      data,
+		PublicKeyHash: self.key.PubKeyHash}
+}
+
+func TestTinyFile(t *testing.T) {
+	env, clean := newTestEnv(t, 0)
+	defer clean()
+	ctx := context.Background()
+
+	mk := &pb.MkFolderReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), Parent: &pb.FilePath{OneOfPath: &pb.FilePath_Path{Path: "/"}}, Folder: []string{"docs"}}
+	require.NoError(t, mk.SignReq(env.priKey))
+	mkResp, err := env.ms.MkFolder(ctx, mk)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), mkResp.Code, mkResp.ErrMsg)
+
+	data := []byte("hello nebula")
+	key, err := util_rsa.EncryptLong(&env.key.PriKey.PublicKey, []byte("password"), env.key.PriKey.Size())
+	require.NoError(t, err)
+	req := env.checkReq("/docs", data, uint64(len(data)), "a.txt")
+	req.EncryptKey = key
+	require.NoError(t, req.SignReq(env.priKey))
+	resp, err := env.ms.CheckFileExist(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_ok), resp.Code, resp.ErrMsg)
+
+	// same name and different content
+	req = env.checkReq("/docs", []byte("other"), 5, "a.txt")
+	req.Interactive = true
+	require.NoError(t, req.SignReq(env.priKey))
+	resp, err = env.ms.CheckFileExist(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_exists), resp.Code)
+
+	list := &pb.ListFilesReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), Parent: &pb.FilePath{OneOfPath: &pb.FilePath_Path{Path: "/docs"}}, PageSize: 10, PageNum: 1}
+	require.NoError(t, list.SignReq(env.priKey))
+	listResp, err := env.ms.ListFiles(ctx, list)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), listResp.TotalRecord)
+	assert.Equal(t, "a.txt", listResp.Fof[0].Name)
+
+	retrieve := &pb.RetrieveFileReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), FileHash: util_hash.Sha1(data), FileSize: uint64(len(data))}
+	require.NoError(t, retrieve.SignReq(env.priKey))
+	rResp, err := env.ms.RetrieveFile(ctx, retrieve)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), rResp.Code, rResp.ErrMsg)
+	assert.Equal(t, data, rResp.FileData)
+	password, err := util_rsa.DecryptLong(env.priKey, rResp.EncryptKey, env.priKey.Size())
+	require.NoError(t, err)
+	assert.Equal(t, "password", string(password))
+
+	sys := []byte("{}")
+	req = env.checkReq("/", sys, uint64(len(sys)), ".nebula")
+	require.NoError(t, req.SignReq(env.priKey))
+	resp, err = env.ms.CheckFileExist(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_ok), resp.Code, resp.ErrMsg)
+	sysReq := &pb.SpaceSysFileReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, sysReq.SignReq(env.priKey))
+	sysResp, err := env.ms.SpaceSysFile(ctx, sysReq)
+	require.NoError(t, err)
+	assert.Equal(t, sys, sysResp.Data)
+}
+
+func TestCheckFileExistAuth(t *testing.T) {
+	env, clean := newTestEnv(t, 0)
+	defer clean()
+	req := env.checkReq("/", nil, 1024*1024, "big")
+	req.PublicKeyHash = []byte("old")
+	require.NoError(t, req.SignReq(env.priKey))
+	_, err := env.ms.CheckFileExist(context.Background(), req)
+	st, _ := status.FromError(err)
+	assert.Equal(t, PublicKeyExpiredErr.Error(), st.Message())
+
+	req = env.checkReq("/", nil, 1024*1024, "big")
+	require.NoError(t, req.SignReq(env.priKey))
+	req.FileSize++
+	resp, err := env.ms.CheckFileExist(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_auth_failed), resp.Code)
+
+	req = env.checkReq("/", nil, 1024*1024, "big")
+	require.NoError(t, req.SignReq(env.priKey))
+	resp, err = env.ms.CheckFileExist(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_no_provider), resp.Code)
+}
+
+func TestMultiReplica(t *testing.T) {
+	env, clean := newTestEnv(t, 4)
+	defer clean()
+	ctx := context.Background()
+	req := env.checkReq("/", nil, 1024*1024, "r.bin")
+	require.NoError(t, req.SignReq(env.priKey))
+	resp, err := env.ms.CheckFileExist(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, uint32(code_upload), resp.Code, resp.ErrMsg)
+	assert.Equal(t, pb.FileStoreType_MultiReplica, resp.StoreType)
+	assert.Equal(t, uint32(3), resp.ReplicaCount)
+
+	piece := &pb.PieceHashAndSize{Hash: util_hash.Sha1([]byte("encrypted")), Size: 1024*1024 + 16}
+	prepare := &pb.UploadFilePrepareReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), FileHash: req.FileHash, FileSize: req.FileSize,
+		Partition: []*pb.SplitPartition{&pb.SplitPartition{Piece: []*pb.PieceHashAndSize{piece}}}}
+	require.NoError(t, prepare.SignReq(env.priKey))
+	pResp, err := env.ms.UploadFilePrepare(ctx, prepare)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), pResp.ReplicaCount)
+	require.Len(t, pResp.Provider, 4)
+	var stored [][]byte
+	for _, p := range pResp.Provider {
+		sr := &ppb.StoreReq{FileKey: req.FileHash, FileSize: req.FileSize, BlockKey: piece.Hash, BlockSize: uint64(piece.Size), Timestamp: p.Timestamp, Ticket: p.Ticket, Auth: p.Auth}
+		assert.NoError(t, sr.CheckAuth(env.proKeys[string(p.NodeId)]))
+		stored = append(stored, p.NodeId)
+	}
+
+	uploadDone := func(holders ...[]byte) *pb.UploadFileDoneResp {
+		done := &pb.UploadFileDoneReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), Parent: req.Parent, FileHash: req.FileHash, FileSize: req.FileSize, FileName: req.FileName, PublicKeyHash: env.key.PubKeyHash,
+			Partition: []*pb.StorePartition{&pb.StorePartition{Block: []*pb.StoreBlock{&pb.StoreBlock{Hash: piece.Hash, Size: uint64(piece.Size), StoreNodeId: holders}}}}}
+		require.NoError(t, done.SignReq(env.priKey))
+		dResp, err := env.ms.UploadFileDone(ctx, done)
+		require.NoError(t, err)
+		return dResp
+	}
+	assert.Equal(t, uint32(1), uploadDone(stored[0], util_hash.Sha1([]byte("other provider"))).Code, "provider not assigned by prepare is rejected")
+	dResp := uploadDone(stored[:3]...)
+	require.Equal(t, uint32(0), dResp.Code, dResp.ErrMsg)
+	assert.Equal(t, uint32(1), uploadDone(stored[:3]...).Code, "pending upload is dropped when done")
+
+	// uploaded file exists
+	require.NoError(t, req.SignReq(env.priKey))
+	resp, err = env.ms.CheckFileExist(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_ok), resp.Code)
+
+	retrieve := &pb.RetrieveFileReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), FileHash: req.FileHash, FileSize: req.FileSize}
+	require.NoError(t, retrieve.SignReq(env.priKey))
+	rResp, err := env.ms.RetrieveFile(ctx, retrieve)
+	require.NoError(t, err)
+	require.Len(t, rResp.Partition, 1)
+	require.Len(t, rResp.Partition[0].Block, 1)
+	nodes := rResp.Partition[0].Block[0].StoreNode
+	require.Len(t, nodes, 3)
+	for _, n := range nodes {
+		rr := &ppb.RetrieveReq{FileKey: req.FileHash, FileSize: req.FileSize, BlockKey: piece.Hash, BlockSize: uint64(piece.Size), Timestamp: rResp.Timestamp, Ticket: n.Ticket, Auth: n.Auth}
+		assert.NoError(t, rr.CheckAuth(env.proKeys[string(n.NodeId)]))
+	}
+
+	remove := &pb.RemoveReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), Target: &pb.FilePath{OneOfPath: &pb.FilePath_Path{Path: "/r.bin"}}}
+	require.NoError(t, remove.SignReq(env.priKey))
+	rmResp, err := env.ms.Remove(ctx, remove)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), rmResp.Code, rmResp.ErrMsg)
+	// file is queued for removing blocks from providers
+	removed, err := env.ms.Store().TakeRemoved(10)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, req.FileHash, removed[0].Hash)
+}
+
+func TestErasureCode(t *testing.T) {
+	env, clean := newTestEnv(t, 8)
+	defer clean()
+	ctx := context.Background()
+	req := env.checkReq("/", nil, 64*1024*1024, "e.bin")
+	require.NoError(t, req.SignReq(env.priKey))
+	resp, err := env.ms.CheckFileExist(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, uint32(code_upload), resp.Code, resp.ErrMsg)
+	assert.Equal(t, pb.FileStoreType_ErasureCode, resp.StoreType)
+	assert.Equal(t, uint32(4), resp.DataPieceCount)
+	assert.Equal(t, uint32(2), resp.VerifyPieceCount)
+
+	partition := &pb.SplitPartition{}
+	for i := 0; i < 6; i++ {
+		partition.Piece = append(partition.Piece, &pb.PieceHashAndSize{Hash: util_hash.Sha1([]byte{byte(i)}), Size: 16 * 1024 * 1024})
+	}
+	prepare := &pb.UploadFilePrepareReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), FileHash: req.FileHash, FileSize: req.FileSize, Partition: []*pb.SplitPartition{partition}}
+	require.NoError(t, prepare.SignReq(env.priKey))
+	pResp, err := env.ms.UploadFilePrepare(ctx, prepare)
+	require.NoError(t, err)
+	require.Len(t, pResp.Partition, 1)
+	auths := pResp.Partition[0].ProviderAuth
+	require.Len(t, auths, 8)
+	for i, a := range auths {
+		if i < 6 {
+			assert.False(t, a.Spare)
+			require.Len(t, a.HashAuth, 1)
+			assert.Equal(t, partition.Piece[i].Hash, a.HashAuth[0].Hash)
+		} else {
+			assert.True(t, a.Spare)
+			assert.Len(t, a.HashAuth, 6)
+		}
+		for _, ha := range a.HashAuth {
+			sr := &ppb.StoreReq{FileKey: req.FileHash, FileSize: req.FileSize, BlockKey: ha.Hash, BlockSize: uint64(ha.Size), Timestamp: pResp.Partition[0].Timestamp, Ticket: ha.Ticket, Auth: ha.Auth}
+			assert.NoError(t, sr.CheckAuth(env.proKeys[string(a.NodeId)]))
+		}
+	}
+
+	uploadDone := func(holder func(i int) []byte) *pb.UploadFileDoneResp {
+		sp := &pb.StorePartition{}
+		for i, piece := range partition.Piece {
+			sp.Block = append(sp.Block, &pb.StoreBlock{Hash: piece.Hash, Size: uint64(piece.Size), BlockSeq: uint32(i), StoreNodeId: [][]byte{holder(i)}})
+		}
+		done := &pb.UploadFileDoneReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), Parent: req.Parent, FileHash: req.FileHash, FileSize: req.FileSize, FileName: req.FileName, PublicKeyHash: env.key.PubKeyHash,
+			Partition: []*pb.StorePartition{sp}}
+		require.NoError(t, done.SignReq(env.priKey))
+		dResp, err := env.ms.UploadFileDone(ctx, done)
+		require.NoError(t, err)
+		return dResp
+	}
+	assert.Equal(t, uint32(1), uploadDone(func(i int) []byte { return auths[(i+1)%6].NodeId }).Code, "piece stored by provider assigned to another piece is rejected")
+	dResp := uploadDone(func(i int) []byte {
+		if i == 0 {
+			return auths[6].NodeId
+		}
+		return auths[i].NodeId
+	})
+	assert.Equal(t, uint32(0), dResp.Code, "spare provider can store any piece: %s", dResp.ErrMsg)
+}
+
+type testAccountant struct {
+	volume int64
+}
+
+func (self *testAccountant) AddUsage(nodeId []byte, volume int64, up uint64, down uint64) error {
+	self.volume += volume
+	return nil
+}
+
+func TestTinyFileHash(t *testing.T) {
+	env, clean := newTestEnv(t, 0)
+	defer clean()
+	req := env.checkReq("/", []byte("forged"), 6, "a.txt")
+	req.FileHash = util_hash.Sha1([]byte("victim"))
+	require.NoError(t, req.SignReq(env.priKey))
+	resp, err := env.ms.CheckFileExist(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_invalid_data), resp.Code)
+	_, err = env.ms.Store().File(req.FileHash, req.FileSize)
+	assert.Equal(t, store.NotExistErr, err, "data not matching hash is not stored")
+}
+
+func TestUsage(t *testing.T) {
+	env, clean := newTestEnv(t, 0)
+	defer clean()
+	acc := &testAccountant{}
+	env.ms.SetAccountant(acc)
+	ctx := context.Background()
+	check := func(parent string, data string, name string, newVersion bool) {
+		req := env.checkReq(parent, []byte(data), uint64(len(data)), name)
+		req.NewVersion = newVersion
+		require.NoError(t, req.SignReq(env.priKey))
+		resp, err := env.ms.CheckFileExist(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, uint32(code_ok), resp.Code, resp.ErrMsg)
+	}
+
+	mk := &pb.MkFolderReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), Parent: &pb.FilePath{OneOfPath: &pb.FilePath_Path{Path: "/"}}, Folder: []string{"docs"}}
+	require.NoError(t, mk.SignReq(env.priKey))
+	mkResp, err := env.ms.MkFolder(ctx, mk)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), mkResp.Code, mkResp.ErrMsg)
+
+	check("/docs", "0123456789", "a.txt", false)
+	assert.Equal(t, int64(10), acc.volume)
+	check("/docs", "0123456789", "a.txt", false)
+	assert.Equal(t, int64(10), acc.volume, "adding same file again is not counted")
+	check("/docs", "01234", "a.txt", true)
+	assert.Equal(t, int64(5), acc.volume, "replaced version is given back")
+	check("/docs", "abc", "b.txt", false)
+	assert.Equal(t, int64(8), acc.volume)
+
+	remove := &pb.RemoveReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), Target: &pb.FilePath{OneOfPath: &pb.FilePath_Path{Path: "/docs"}}, Recursive: true}
+	require.NoError(t, remove.SignReq(env.priKey))
+	rmResp, err := env.ms.Remove(ctx, remove)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), rmResp.Code, rmResp.ErrMsg)
+	assert.Equal(t, int64(0), acc.volume, "files in removed folder are given back")
+}
@@ -0,0 +1,744 @@
+// Package store keeps folder tree of clients and stored files of self-hosted metadata server in bolt.
+//
+// Files are content addressed by hash and size and shared by entries of all clients with reference count,
+// file whose last entry is removed is queued for task scheduler to remove its blocks from providers.
+package store
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	mpb "github.com/samoslab/nebula/tracker/metadata/pb"
+	"github.com/samoslab/nebula/util/dbutil"
+)
+
+var (
+	entryBkt   = []byte("metadata_entry")    // owner|space|parent id|name -> Entry
+	entryIdBkt = []byte("metadata_entry_id") // owner|space|id -> parent id|name
+	fileBkt    = []byte("metadata_file")     // hash|size -> File
+	removedBkt = []byte("metadata_removed")  // sequence -> File
+	uploadBkt  = []byte("metadata_upload")   // owner length|owner|hash|size -> Upload
+)
+
+const id_len = 16
+
+// RootId is id of top level folder of each space
+var RootId = make([]byte, id_len)
+
+var (
+	NotExistErr    = errors.New("file or folder does not exist")
+	NotFolderErr   = errors.New("not a folder")
+	ExistErr       = errors.New("file or folder already exists")
+	NotEmptyErr    = errors.New("folder is not empty")
+	InvalidNameErr = errors.New("invalid file or folder name")
+)
+
+// Entry is a file or folder in folder tree of a client space
+type Entry struct {
+	Id         []byte `json:"id"`
+	ParentId   []byte `json:"parent_id"`
+	Folder     bool   `json:"folder"`
+	Name       string `json:"name"`
+	ModTime    uint64 `json:"mod_time"`
+	FileHash   []byte `json:"file_hash,omitempty"`
+	FileSize   uint64 `json:"file_size,omitempty"`
+	FileType   string `json:"file_type,omitempty"`
+	EncryptKey []byte `json:"encrypt_key,omitempty"` // encrypted by tracker public key
+}
+
+// File is stored content shared by entries
+type File struct {
+	Hash       []byte                `json:"hash"`
+	Size       uint64                `json:"size"`
+	Data       []byte                `json:"data,omitempty"` // content of tiny file
+	StoreType  mpb.FileStoreType     `json:"store_type"`
+	Partitions []*mpb.StorePartition `json:"partitions,omitempty"`
+	RefCount   uint32                `json:"ref_count"`
+	Created    uint64                `json:"created"`
+}
+
+// Tiny file is stored in metadata instead of providers
+func (self *File) Tiny() bool {
+	return len(self.Partitions) == 0
+}
+
+type Store struct {
+	db *bolt.DB
+}
+
+func New(db *bolt.DB) (*Store, error) {
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bkt := range [][]byte{entryBkt, entryIdBkt, fileBkt, removedBkt, uploadBkt} {
+			if _, err := tx.CreateBucketIfNotExists(bkt); err != nil {
+				return dbutil.NewCreateBucketFailedErr(bkt, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// spaceKey is owner length(1)|owner|space
+func spaceKey(owner []byte, space uint32) []byte {
+	k := make([]byte, 1+len(owner)+4)
+	k[0] = byte(len(owner))
+	copy(k[1:], owner)
+	binary.BigEndian.PutUint32(k[1+len(owner):], space)
+	return k
+}
+
+func childKey(owner []byte, space uint32, parentId []byte, name string) []byte {
+	return append(append(spaceKey(owner, space), parentId...), name...)
+}
+
+func idKey(owner []byte, space uint32, id []byte) []byte {
+	return append(spaceKey(owner, space), id...)
+}
+
+func fileKey(hash []byte, size uint64) []byte {
+	k := make([]byte, len(hash)+8)
+	copy(k, hash)
+	binary.BigEndian.PutUint64(k[len(hash):], size)
+	return k
+}
+
+func newId() ([]byte, error) {
+	id := make([]byte, id_len)
+	_, err := rand.Read(id)
+	return id, err
+}
+
+func checkName(name string) error {
+	if len(name) == 0 || name == "." || name == ".." || strings.ContainsAny(name, "/\x00") {
+		return InvalidNameErr
+	}
+	return nil
+}
+
+// SplitPath split path like /folder1/folder2 to names
+func SplitPath(path string) []string {
+	var names []string
+	for _, n := range strings.Split(path, "/") {
+		if len(n) > 0 {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+func getEntry(tx *bolt.Tx, owner []byte, space uint32, parentId []byte, name string) (*Entry, error) {
+	data := tx.Bucket(entryBkt).Get(childKey(owner, space, parentId, name))
+	if data == nil {
+		return nil, NotExistErr
+	}
+	e := &Entry{}
+	return e, json.Unmarshal(data, e)
+}
+
+func getEntryById(tx *bolt.Tx, owner []byte, space uint32, id []byte) (*Entry, error) {
+	if bytes.Equal(id, RootId) {
+		return &Entry{Id: RootId, Folder: true}, nil
+	}
+	v := tx.Bucket(entryIdBkt).Get(idKey(owner, space, id))
+	if v == nil || len(v) < id_len {
+		return nil, NotExistErr
+	}
+	return getEntry(tx, owner, space, v[:id_len], string(v[id_len:]))
+}
+
+func putEntry(tx *bolt.Tx, owner []byte, space uint32, e *Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err = tx.Bucket(entryBkt).Put(childKey(owner, space, e.ParentId, e.Name), data); err != nil {
+		return err
+	}
+	return tx.Bucket(entryIdBkt).Put(idKey(owner, space, e.Id), append(append([]byte{}, e.ParentId...), e.Name...))
+}
+
+func deleteEntry(tx *bolt.Tx, owner []byte, space uint32, e *Entry) error {
+	if err := tx.Bucket(entryBkt).Delete(childKey(owner, space, e.ParentId, e.Name)); err != nil {
+		return err
+	}
+	return tx.Bucket(entryIdBkt).Delete(idKey(owner, space, e.Id))
+}
+
+func resolvePath(tx *bolt.Tx, owner []byte, space uint32, path string) (*Entry, error) {
+	e := &Entry{Id: RootId, Folder: true}
+	for _, name := range SplitPath(path) {
+		if !e.Folder {
+			return nil, NotFolderErr
+		}
+		var err error
+		if e, err = getEntry(tx, owner, space, e.Id, name); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func resolve(tx *bolt.Tx, owner []byte, fp *mpb.FilePath) (*Entry, error) {
+	if fp == nil {
+		return nil, NotExistErr
+	}
+	switch v := fp.OneOfPath.(type) {
+	case *mpb.FilePath_Id:
+		if len(v.Id) == 0 {
+			return &Entry{Id: RootId, Folder: true}, nil
+		}
+		return getEntryById(tx, owner, fp.SpaceNo, v.Id)
+	case *mpb.FilePath_Path:
+		return resolvePath(tx, owner, fp.SpaceNo, v.Path)
+	}
+	return &Entry{Id: RootId, Folder: true}, nil
+}
+
+func resolveFolder(tx *bolt.Tx, owner []byte, fp *mpb.FilePath) (*Entry, error) {
+	e, err := resolve(tx, owner, fp)
+	if err != nil {
+		return nil, err
+	}
+	if !e.Folder {
+		return nil, NotFolderErr
+	}
+	return e, nil
+}
+
+// Resolve find entry by path or id
+func (self *Store) Resolve(owner []byte, fp *mpb.FilePath) (e *Entry, err error) {
+	err = self.db.View(func(tx *bolt.Tx) error {
+		e, err = resolve(tx, owner, fp)
+		return err
+	})
+	return
+}
+
+// MkFolder create folders in parent, existing folder is an error only if interactive
+func (self *Store) MkFolder(owner []byte, parent *mpb.FilePath, folders []string, interactive bool) error {
+	for _, f := range folders {
+		if err := checkName(f); err != nil {
+			return err
+		}
+	}
+	return self.db.Update(func(tx *bolt.Tx) error {
+		p, err := resolveFolder(tx, owner, parent)
+		if err != nil {
+			return err
+		}
+		now := uint64(time.Now().Unix())
+		for _, f := range folders {
+			if e, err := getEntry(tx, owner, parent.SpaceNo, p.Id, f); err == nil {
+				if interactive || !e.Folder {
+					return fmt.Errorf("%s: %s", ExistErr, f)
+				}
+				continue
+			}
+			id, err := newId()
+			if err != nil {
+				return err
+			}
+			if err = putEntry(tx, owner, parent.SpaceNo, &Entry{Id: id, ParentId: p.Id, Folder: true, Name: f, ModTime: now}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CheckName return nil if file name can be added to parent folder
+func (self *Store) CheckName(owner []byte, parent *mpb.FilePath, name string) (existing *Entry, err error) {
+	if err = checkName(name); err != nil {
+		return nil, err
+	}
+	err = self.db.View(func(tx *bolt.Tx) error {
+		p, err := resolveFolder(tx, owner, parent)
+		if err != nil {
+			return err
+		}
+		existing, err = getEntry(tx, owner, parent.SpaceNo, p.Id, name)
+		if err == NotExistErr {
+			existing, err = nil, nil
+		}
+		return err
+	})
+	return
+}
+
+// File return stored file, NotExistErr if not stored
+func (self *Store) File(hash []byte, size uint64) (f *File, err error) {
+	err = self.db.View(func(tx *bolt.Tx) error {
+		f, err = getFile(tx, hash, size)
+		return err
+	})
+	return
+}
+
+func getFile(tx *bolt.Tx, hash []byte, size uint64) (*File, error) {
+	data := tx.Bucket(fileBkt).Get(fileKey(hash, size))
+	if data == nil {
+		return nil, NotExistErr
+	}
+	f := &File{}
+	return f, json.Unmarshal(data, f)
+}
+
+func putFile(tx *bolt.Tx, f *File) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(fileBkt).Put(fileKey(f.Hash, f.Size), data)
+}
+
+// AddFile add entry to parent folder, file is stored if it is not stored yet.
+// Name conflict is resolved by replacing if newVersion, else error if interactive, else adding timestamp suffix.
+// volume is change of size of files owned: 0 if same file is already there, size of new file less replaced one.
+func (self *Store) AddFile(owner []byte, parent *mpb.FilePath, e *Entry, f *File, interactive bool, newVersion bool) (volume int64, err error) {
+	if err = checkName(e.Name); err != nil {
+		return
+	}
+	err = self.db.Update(func(tx *bolt.Tx) error {
+		volume = 0
+		p, err := resolveFolder(tx, owner, parent)
+		if err != nil {
+			return err
+		}
+		space := parent.SpaceNo
+		if old, err := getEntry(tx, owner, space, p.Id, e.Name); err == nil {
+			switch {
+			case !old.Folder && bytes.Equal(old.FileHash, e.FileHash) && old.FileSize == e.FileSize:
+				return nil
+			case newVersion && !old.Folder:
+				removed, err := removeEntry(tx, owner, space, old, false)
+				if err != nil {
+					return err
+				}
+				volume -= int64(removed)
+			case interactive:
+				return fmt.Errorf("%s: %s", ExistErr, e.Name)
+			default:
+				e.Name = suffixName(e.Name, time.Now())
+			}
+		}
+		stored, err := getFile(tx, e.FileHash, e.FileSize)
+		if err == NotExistErr {
+			if f == nil {
+				return NotExistErr
+			}
+			stored, f.RefCount, f.Created = f, 0, uint64(time.Now().Unix())
+		} else if err != nil {
+			return err
+		}
+		stored.RefCount++
+		if err = putFile(tx, stored); err != nil {
+			return err
+		}
+		if e.Id, err = newId(); err != nil {
+			return err
+		}
+		e.ParentId, e.Folder = p.Id, false
+		if err = putEntry(tx, owner, space, e); err != nil {
+			return err
+		}
+		volume += int64(e.FileSize)
+		return nil
+	})
+	if err != nil {
+		volume = 0
+	}
+	return
+}
+
+// suffixName add timestamp before extension, eg: a.txt -> a_20180102150405.txt
+func suffixName(name string, t time.Time) string {
+	suffix := "_" + t.Format("20060102150405")
+	if i := strings.LastIndex(name, "."); i > 0 {
+		return name[:i] + suffix + name[i:]
+	}
+	return name + suffix
+}
+
+// List children of folder, folders are listed before files
+func (self *Store) List(owner []byte, parent *mpb.FilePath, sortType mpb.SortType, asc bool, pageSize uint32, pageNum uint32) (total uint32, page []*Entry, err error) {
+	var all []*Entry
+	err = self.db.View(func(tx *bolt.Tx) error {
+		p, err := resolveFolder(tx, owner, parent)
+		if err != nil {
+			return err
+		}
+		all, err = children(tx, owner, parent.SpaceNo, p.Id)
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	less := func(a, b *Entry) bool {
+		switch sortType {
+		case mpb.SortType_ModTime:
+			if a.ModTime != b.ModTime {
+				return a.ModTime < b.ModTime
+			}
+		case mpb.SortType_Size:
+			if a.FileSize != b.FileSize {
+				return a.FileSize < b.FileSize
+			}
+		}
+		return a.Name < b.Name
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Folder != all[j].Folder {
+			return all[i].Folder
+		}
+		if asc {
+			return less(all[i], all[j])
+		}
+		return less(all[j], all[i])
+	})
+	total = uint32(len(all))
+	if pageNum == 0 {
+		pageNum = 1
+	}
+	start := uint64(pageNum-1) * uint64(pageSize)
+	if start >= uint64(len(all)) {
+		return total, nil, nil
+	}
+	end := start + uint64(pageSize)
+	if end > uint64(len(all)) {
+		end = uint64(len(all))
+	}
+	return total, all[start:end], nil
+}
+
+func children(tx *bolt.Tx, owner []byte, space uint32, parentId []byte) ([]*Entry, error) {
+	prefix := append(spaceKey(owner, space), parentId...)
+	var res []*Entry
+	c := tx.Bucket(entryBkt).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		e := &Entry{}
+		if err := json.Unmarshal(v, e); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+// Remove file or folder, folder must be empty if not recursive. removed is total size of files removed.
+func (self *Store) Remove(owner []byte, target *mpb.FilePath, recursive bool) (removed uint64, err error) {
+	err = self.db.Update(func(tx *bolt.Tx) error {
+		e, err := resolve(tx, owner, target)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(e.Id, RootId) {
+			return InvalidNameErr
+		}
+		removed, err = removeEntry(tx, owner, target.SpaceNo, e, recursive)
+		return err
+	})
+	if err != nil {
+		removed = 0
+	}
+	return
+}
+
+// removeEntry return total size of files in removed entry
+func removeEntry(tx *bolt.Tx, owner []byte, space uint32, e *Entry, recursive bool) (uint64, error) {
+	if e.Folder {
+		children, err := children(tx, owner, space, e.Id)
+		if err != nil {
+			return 0, err
+		}
+		if len(children) > 0 && !recursive {
+			return 0, NotEmptyErr
+		}
+		var removed uint64
+		for _, c := range children {
+			size, err := removeEntry(tx, owner, space, c, true)
+			if err != nil {
+				return 0, err
+			}
+			removed += size
+		}
+		return removed, deleteEntry(tx, owner, space, e)
+	}
+	if err := deleteEntry(tx, owner, space, e); err != nil {
+		return 0, err
+	}
+	return e.FileSize, unrefFile(tx, e.FileHash, e.FileSize)
+}
+
+// unrefFile decrease reference count of file, file not referenced any more is queued to be removed from providers
+func unrefFile(tx *bolt.Tx, hash []byte, size uint64) error {
+	f, err := getFile(tx, hash, size)
+	if err == NotExistErr {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if f.RefCount > 1 {
+		f.RefCount--
+		return putFile(tx, f)
+	}
+	if err = tx.Bucket(fileBkt).Delete(fileKey(f.Hash, f.Size)); err != nil {
+		return err
+	}
+	if f.Tiny() {
+		return nil
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	bkt := tx.Bucket(removedBkt)
+	seq, err := bkt.NextSequence()
+	if err != nil {
+		return err
+	}
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return bkt.Put(k, data)
+}
+
+// Move source to dest path, source is moved into dest if dest is an existing folder, else renamed to dest
+func (self *Store) Move(owner []byte, source *mpb.FilePath, dest string) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		space := source.SpaceNo
+		e, err := resolve(tx, owner, source)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(e.Id, RootId) {
+			return InvalidNameErr
+		}
+		parent, name := (*Entry)(nil), e.Name
+		if d, err := resolvePath(tx, owner, space, dest); err == nil && d.Folder {
+			parent = d
+		} else {
+			names := SplitPath(dest)
+			if len(names) == 0 {
+				return InvalidNameErr
+			}
+			name = names[len(names)-1]
+			if parent, err = resolvePath(tx, owner, space, strings.Join(names[:len(names)-1], "/")); err != nil {
+				return err
+			}
+			if !parent.Folder {
+				return NotFolderErr
+			}
+		}
+		if err = checkName(name); err != nil {
+			return err
+		}
+		if bytes.Equal(parent.Id, e.ParentId) && name == e.Name {
+			return nil
+		}
+		if _, err = getEntry(tx, owner, space, parent.Id, name); err == nil {
+			return fmt.Errorf("%s: %s", ExistErr, name)
+		}
+		// folder can not be moved into itself or its descendant
+		for p := parent; e.Folder && !bytes.Equal(p.Id, RootId); {
+			if bytes.Equal(p.Id, e.Id) {
+				return InvalidNameErr
+			}
+			if p, err = getEntryById(tx, owner, space, p.ParentId); err != nil {
+				return err
+			}
+		}
+		if err = tx.Bucket(entryBkt).Delete(childKey(owner, space, e.ParentId, e.Name)); err != nil {
+			return err
+		}
+		e.ParentId, e.Name = parent.Id, name
+		return putEntry(tx, owner, space, e)
+	})
+}
+
+// OwnedFile find entry of file in space, it is used to check ownership before retrieving
+func (self *Store) OwnedFile(owner []byte, space uint32, hash []byte, size uint64) (e *Entry, err error) {
+	prefix := spaceKey(owner, space)
+	err = self.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(entryBkt).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			en := &Entry{}
+			if err := json.Unmarshal(v, en); err != nil {
+				return err
+			}
+			if !en.Folder && en.FileSize == size && bytes.Equal(en.FileHash, hash) {
+				e = en
+				return nil
+			}
+		}
+		return NotExistErr
+	})
+	return
+}
+
+// ForEachFile iterate stored files, iteration stops if f returns error
+func (self *Store) ForEachFile(f func(file *File) error) error {
+	return self.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(fileBkt).ForEach(func(k, v []byte) error {
+			file := &File{}
+			if err := json.Unmarshal(v, file); err != nil {
+				return err
+			}
+			return f(file)
+		})
+	})
+}
+
+// UpdateFile load stored file, change it by f and save it in one transaction
+func (self *Store) UpdateFile(hash []byte, size uint64, f func(file *File) error) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		file, err := getFile(tx, hash, size)
+		if err != nil {
+			return err
+		}
+		if err = f(file); err != nil {
+			return err
+		}
+		return putFile(tx, file)
+	})
+}
+
+// TakeRemoved pop at most max files whose last entry is removed
+func (self *Store) TakeRemoved(max int) ([]*File, error) {
+	var res []*File
+	err := self.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(removedBkt)
+		var keys [][]byte
+		c := bkt.Cursor()
+		for k, v := c.First(); k != nil && len(res) < max; k, v = c.Next() {
+			f := &File{}
+			if err := json.Unmarshal(v, f); err != nil {
+				return err
+			}
+			res = append(res, f)
+			keys = append(keys, k)
+		}
+		// deleting by cursor while iterating skips keys
+		for _, k := range keys {
+			if err := bkt.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Upload is providers assigned to pieces of a file by upload prepare, client can only report them as holders
+type Upload struct {
+	Assigned map[string][][]byte `json:"assigned"` // hex of piece hash|size -> node ids
+	Expire   uint64              `json:"expire"`   // unix time
+}
+
+func NewUpload(expire uint64) *Upload {
+	return &Upload{Assigned: make(map[string][][]byte), Expire: expire}
+}
+
+// Assign piece to provider
+func (self *Upload) Assign(hash []byte, size uint64, nodeId []byte) {
+	self.assign(hex.EncodeToString(fileKey(hash, size)), nodeId)
+}
+
+func (self *Upload) assign(k string, nodeId []byte) {
+	for _, id := range self.Assigned[k] {
+		if bytes.Equal(id, nodeId) {
+			return
+		}
+	}
+	self.Assigned[k] = append(self.Assigned[k], nodeId)
+}
+
+// IsAssigned check piece is assigned to provider
+func (self *Upload) IsAssigned(hash []byte, size uint64, nodeId []byte) bool {
+	for _, id := range self.Assigned[hex.EncodeToString(fileKey(hash, size))] {
+		if bytes.Equal(id, nodeId) {
+			return true
+		}
+	}
+	return false
+}
+
+func uploadKey(owner []byte, hash []byte, size uint64) []byte {
+	return append(append([]byte{byte(len(owner))}, owner...), fileKey(hash, size)...)
+}
+
+// PrepareUpload merge assignment into pending upload of owner, so providers of a retried prepare are kept.
+// Expired pending uploads of abandoned uploads are dropped.
+func (self *Store) PrepareUpload(owner []byte, hash []byte, size uint64, u *Upload) error {
+	now := uint64(time.Now().Unix())
+	return self.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(uploadBkt)
+		var expired [][]byte
+		if err := bkt.ForEach(func(k, v []byte) error {
+			p := &Upload{}
+			if err := json.Unmarshal(v, p); err != nil || p.Expire < now {
+				expired = append(expired, append([]byte{}, k...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := bkt.Delete(k); err != nil {
+				return err
+			}
+		}
+		key := uploadKey(owner, hash, size)
+		if data := bkt.Get(key); data != nil {
+			p := &Upload{}
+			if err := json.Unmarshal(data, p); err != nil {
+				return err
+			}
+			for k, ids := range p.Assigned {
+				for _, id := range ids {
+					u.assign(k, id)
+				}
+			}
+		}
+		data, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		return bkt.Put(key, data)
+	})
+}
+
+// PendingUpload assignment of upload of owner, NotExistErr if it is not prepared or expired
+func (self *Store) PendingUpload(owner []byte, hash []byte, size uint64) (u *Upload, err error) {
+	err = self.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(uploadBkt).Get(uploadKey(owner, hash, size))
+		if data == nil {
+			return NotExistErr
+		}
+		u = &Upload{}
+		if err := json.Unmarshal(data, u); err != nil {
+			return err
+		}
+		if u.Expire < uint64(time.Now().Unix()) {
+			return NotExistErr
+		}
+		return nil
+	})
+	return
+}
+
+// DropUpload delete pending upload of owner when upload is done
+func (self *Store) DropUpload(owner []byte, hash []byte, size uint64) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(uploadBkt).Delete(uploadKey(owner, hash, size))
+	})
+}
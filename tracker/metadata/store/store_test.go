@@ -0,0 +1,123 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	mpb "github.com/samoslab/nebula/tracker/metadata/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func path(p string) *mpb.FilePath {
+	return &mpb.FilePath{OneOfPath: &mpb.FilePath_Path{Path: p}}
+}
+
+func TestSuffixName(t *testing.T) {
+	tm := time.Date(2018, 1, 2, 15, 4, 5, 0, time.UTC)
+	assert.Equal(t, "a_20180102150405.txt", suffixName("a.txt", tm))
+	assert.Equal(t, "a_20180102150405", suffixName("a", tm))
+	assert.Equal(t, ".nebula_20180102150405", suffixName(".nebula", tm))
+}
+
+func TestFolderTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := bolt.Open(filepath.Join(dir, "store.db"), 0600, nil)
+	require.NoError(t, err)
+	defer db.Close()
+	st, err := New(db)
+	require.NoError(t, err)
+	owner := []byte("owner")
+
+	require.NoError(t, st.MkFolder(owner, path("/"), []string{"a", "b"}, false))
+	require.NoError(t, st.MkFolder(owner, path("/a"), []string{"c"}, false))
+	assert.NoError(t, st.MkFolder(owner, path("/"), []string{"a"}, false))
+	assert.Error(t, st.MkFolder(owner, path("/"), []string{"a"}, true))
+
+	f := &File{Hash: []byte("hash"), Size: 4, Data: []byte("data")}
+	volume, err := st.AddFile(owner, path("/a/c"), &Entry{Name: "f", FileHash: f.Hash, FileSize: f.Size}, f, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), volume)
+	volume, err = st.AddFile(owner, path("/a/c"), &Entry{Name: "f", FileHash: f.Hash, FileSize: f.Size}, f, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), volume, "same file is already there")
+	// same name, different file is suffixed
+	g := &File{Hash: []byte("hash2"), Size: 5, Data: []byte("data2")}
+	volume, err = st.AddFile(owner, path("/a/c"), &Entry{Name: "f", FileHash: g.Hash, FileSize: g.Size}, g, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), volume)
+	total, _, err := st.List(owner, path("/a/c"), mpb.SortType_Name, true, 10, 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), total)
+
+	// folder can not be moved into its descendant
+	assert.Equal(t, InvalidNameErr, st.Move(owner, path("/a"), "/a/c"))
+	require.NoError(t, st.Move(owner, path("/a"), "/b"))
+	e, err := st.Resolve(owner, path("/b/a/c/f"))
+	require.NoError(t, err)
+	assert.Equal(t, f.Hash, e.FileHash)
+	require.NoError(t, st.Move(owner, path("/b/a/c/f"), "/g"))
+	_, err = st.Resolve(owner, path("/g"))
+	assert.NoError(t, err)
+
+	_, err = st.Remove(owner, path("/b"), false)
+	assert.Equal(t, NotEmptyErr, err)
+	removed, err := st.Remove(owner, path("/b"), true)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), removed, "sizes of files in removed folder")
+	_, err = st.File(g.Hash, g.Size)
+	assert.Equal(t, NotExistErr, err)
+	stored, err := st.File(f.Hash, f.Size)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), stored.RefCount)
+	// other owner can not see the file
+	_, err = st.OwnedFile([]byte("other"), 0, f.Hash, f.Size)
+	assert.Equal(t, NotExistErr, err)
+}
+
+func TestPendingUpload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := bolt.Open(filepath.Join(dir, "store.db"), 0600, nil)
+	require.NoError(t, err)
+	defer db.Close()
+	st, err := New(db)
+	require.NoError(t, err)
+	owner, hash, piece := []byte("owner"), []byte("hash"), []byte("piece")
+	expire := uint64(time.Now().Unix()) + 60
+
+	u := NewUpload(expire)
+	u.Assign(piece, 10, []byte("p1"))
+	require.NoError(t, st.PrepareUpload(owner, hash, 100, u))
+	// retried prepare keeps providers of the first one
+	u = NewUpload(expire)
+	u.Assign(piece, 10, []byte("p2"))
+	require.NoError(t, st.PrepareUpload(owner, hash, 100, u))
+	pending, err := st.PendingUpload(owner, hash, 100)
+	require.NoError(t, err)
+	assert.True(t, pending.IsAssigned(piece, 10, []byte("p1")))
+	assert.True(t, pending.IsAssigned(piece, 10, []byte("p2")))
+	assert.False(t, pending.IsAssigned(piece, 11, []byte("p1")), "size is part of piece")
+	_, err = st.PendingUpload([]byte("other"), hash, 100)
+	assert.Equal(t, NotExistErr, err, "upload of other client")
+
+	require.NoError(t, st.DropUpload(owner, hash, 100))
+	_, err = st.PendingUpload(owner, hash, 100)
+	assert.Equal(t, NotExistErr, err)
+
+	require.NoError(t, st.PrepareUpload(owner, hash, 100, NewUpload(expire-120)))
+	_, err = st.PendingUpload(owner, hash, 100)
+	assert.Equal(t, NotExistErr, err, "expired upload")
+	require.NoError(t, st.PrepareUpload(owner, []byte("other hash"), 100, NewUpload(expire)))
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket(uploadBkt).Get(uploadKey(owner, hash, 100)), "expired upload is dropped")
+		return nil
+	}))
+}
@@ -0,0 +1,190 @@
+// Package impl is a self-hostable implementation of ClientRegisterService and OrderService.
+package impl
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/samoslab/nebula/tracker/config"
+	pb "github.com/samoslab/nebula/tracker/register/client/pb"
+	"github.com/samoslab/nebula/tracker/register/mailer"
+	"github.com/samoslab/nebula/tracker/registry"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	util_rsa "github.com/samoslab/nebula/util/rsa"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const timestamp_expired = 900
+const timestamp_ahead = -300
+
+// codes of responses, 500 means client should get tracker public key again
+const (
+	code_ok                 = 0
+	code_auth_failed        = 2
+	code_invalid_argument   = 3
+	code_registered         = 4
+	code_verify_code_wrong  = 5
+	code_not_found          = 6
+	code_unpaid_exists      = 7
+	code_payment_failed     = 8
+	code_no_package         = 9
+	code_system_error       = 300
+	code_public_key_expired = 500
+)
+
+type Config struct {
+	TrackerServers []config.Server
+	Mailer         mailer.Mailer
+	// ResendInterval is minimum interval between verify code emails
+	ResendInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{Mailer: mailer.LogMailer{}, ResendInterval: time.Minute}
+}
+
+type ClientRegisterService struct {
+	registry *registry.Registry
+	key      *config.Key
+	config   Config
+}
+
+func NewClientRegisterService(reg *registry.Registry, key *config.Key, config Config) *ClientRegisterService {
+	return &ClientRegisterService{registry: reg, key: key, config: config}
+}
+
+type signedReq interface {
+	VerifySign(pubKey *rsa.PublicKey) error
+}
+
+// verify check timestamp and sign of request from registered client
+func verify(reg *registry.Registry, nodeId []byte, timestamp uint64, req signedReq) (*registry.Client, error) {
+	interval := time.Now().Unix() - int64(timestamp)
+	if interval > timestamp_expired || interval < timestamp_ahead {
+		return nil, errors.New("timestamp expired")
+	}
+	c, err := reg.Client(nodeId)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := c.PubKey()
+	if err != nil {
+		return nil, err
+	}
+	if err = req.VerifySign(pubKey); err != nil {
+		return nil, errors.New("verify sign failed")
+	}
+	return c, nil
+}
+
+func now() uint64 {
+	return uint64(time.Now().Unix())
+}
+
+func (self *ClientRegisterService) sendVerifyCode(c *registry.Client) {
+	if err := mailer.SendVerifyCode(self.config.Mailer, c.ContactEmail, c.VerifyCode); err != nil {
+		log.Errorf("send verify code to client %x failed: %s", c.NodeId, err)
+	}
+}
+
+func (self *ClientRegisterService) GetPublicKey(ctx context.Context, req *pb.GetPublicKeyReq) (*pb.GetPublicKeyResp, error) {
+	return &pb.GetPublicKeyResp{PublicKey: self.key.PubKeyBytes, PublicKeyHash: self.key.PubKeyHash}, nil
+}
+
+func (self *ClientRegisterService) Register(ctx context.Context, req *pb.RegisterReq) (*pb.RegisterResp, error) {
+	if string(req.PublicKeyHash) != string(self.key.PubKeyHash) {
+		return &pb.RegisterResp{Code: code_public_key_expired, ErrMsg: "tracker public key expired"}, nil
+	}
+	pubKeyBytes, err := util_rsa.DecryptLong(self.key.PriKey, req.PublicKeyEnc, self.key.PriKey.Size())
+	if err != nil {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "decrypt public key failed: " + err.Error()}, nil
+	}
+	email, err := util_rsa.DecryptLong(self.key.PriKey, req.ContactEmailEnc, self.key.PriKey.Size())
+	if err != nil {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "decrypt contact email failed: " + err.Error()}, nil
+	}
+	if _, err = x509.ParsePKCS1PublicKey(pubKeyBytes); err != nil {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "invalid public key: " + err.Error()}, nil
+	}
+	if string(req.NodeId) != string(util_hash.Sha1(pubKeyBytes)) {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "node id does not match public key"}, nil
+	}
+	if !strings.Contains(string(email), "@") {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "invalid contact email"}, nil
+	}
+	if _, err = self.registry.Client(req.NodeId); err == nil {
+		return &pb.RegisterResp{Code: code_registered, ErrMsg: "client is registered"}, nil
+	} else if err != registry.NotFoundErr {
+		return &pb.RegisterResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	c := &registry.Client{NodeId: req.NodeId, PublicKey: pubKeyBytes, ContactEmail: string(email), VerifyCode: mailer.NewVerifyCode(), VerifyCodeAt: now()}
+	if err = self.registry.PutClient(c); err != nil {
+		return &pb.RegisterResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	self.sendVerifyCode(c)
+	log.Infof("client %x registered", req.NodeId)
+	return &pb.RegisterResp{}, nil
+}
+
+func (self *ClientRegisterService) VerifyContactEmail(ctx context.Context, req *pb.VerifyContactEmailReq) (*pb.VerifyContactEmailResp, error) {
+	if _, err := verify(self.registry, req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.VerifyContactEmailResp{Code: code_auth_failed, ErrMsg: err.Error()}, nil
+	}
+	wrong := false
+	if err := self.registry.UpdateClient(req.NodeId, func(c *registry.Client) error {
+		if c.EmailVerified {
+			return nil
+		}
+		if len(c.VerifyCode) == 0 || c.VerifyCode != req.VerifyCode {
+			wrong = true
+			return nil
+		}
+		c.EmailVerified, c.VerifyCode = true, ""
+		return nil
+	}); err != nil {
+		return &pb.VerifyContactEmailResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	if wrong {
+		return &pb.VerifyContactEmailResp{Code: code_verify_code_wrong, ErrMsg: "verify code is wrong"}, nil
+	}
+	return &pb.VerifyContactEmailResp{}, nil
+}
+
+func (self *ClientRegisterService) ResendVerifyCode(ctx context.Context, req *pb.ResendVerifyCodeReq) (*pb.ResendVerifyCodeResp, error) {
+	if _, err := verify(self.registry, req.NodeId, req.Timestamp, req); err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	var client *registry.Client
+	if err := self.registry.UpdateClient(req.NodeId, func(c *registry.Client) error {
+		if c.EmailVerified || now()-c.VerifyCodeAt < uint64(self.config.ResendInterval/time.Second) {
+			return nil
+		}
+		c.VerifyCode, c.VerifyCodeAt = mailer.NewVerifyCode(), now()
+		client = c
+		return nil
+	}); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if client == nil {
+		return &pb.ResendVerifyCodeResp{Success: false}, nil
+	}
+	self.sendVerifyCode(client)
+	return &pb.ResendVerifyCodeResp{Success: true}, nil
+}
+
+func (self *ClientRegisterService) GetTrackerServer(ctx context.Context, req *pb.GetTrackerServerReq) (*pb.GetTrackerServerResp, error) {
+	if _, err := verify(self.registry, req.NodeId, req.Timestamp, req); err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	resp := &pb.GetTrackerServerResp{Server: make([]*pb.TrackerServer, 0, len(self.config.TrackerServers))}
+	for _, s := range self.config.TrackerServers {
+		resp.Server = append(resp.Server, &pb.TrackerServer{Server: s.Host, Port: s.Port})
+	}
+	return resp, nil
+}
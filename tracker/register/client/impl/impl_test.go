@@ -0,0 +1,301 @@
+package impl
+
+import (
+	"crypto/rsa"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/samoslab/nebula/tracker/config"
+	pb "github.com/samoslab/nebula/tracker/register/client/pb"
+	"github.com/samoslab/nebula/tracker/register/mailer"
+	"github.com/samoslab/nebula/tracker/registry"
+	"github.com/samoslab/nebula/tracker/testutil"
+	util_rsa "github.com/samoslab/nebula/util/rsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+type testEnv struct {
+	db       *bolt.DB
+	crs      *ClientRegisterService
+	reg      *registry.Registry
+	key      *config.Key
+	mailFile string
+	priKey   *rsa.PrivateKey
+	pubKey   []byte
+	nodeId   []byte
+}
+
+func newTestEnv(t *testing.T) (*testEnv, func()) {
+	tr := testutil.NewTracker(t)
+	env := &testEnv{db: tr.DB, reg: tr.Registry, key: tr.Key, mailFile: filepath.Join(tr.Dir, "mail.txt")}
+	cfg := DefaultConfig()
+	cfg.Mailer = mailer.NewFileMailer(env.mailFile)
+	cfg.TrackerServers = []config.Server{{Host: "tracker.example.com", Port: 6677}}
+	env.crs = NewClientRegisterService(env.reg, env.key, cfg)
+	// not registered, tests register it by the service
+	client := testutil.NewNode(t)
+	env.priKey, env.pubKey, env.nodeId = client.PriKey, client.PubKey, client.NodeId
+	return env, tr.Close
+}
+
+func (self *testEnv) register(t *testing.T) *pb.RegisterResp {
+	pubKeyEnc, err := util_rsa.EncryptLong(&self.key.PriKey.PublicKey, self.pubKey, self.key.PriKey.Size())
+	require.NoError(t, err)
+	emailEnc, err := util_rsa.EncryptLong(&self.key.PriKey.PublicKey, []byte("client@example.com"), self.key.PriKey.Size())
+	require.NoError(t, err)
+	resp, err := self.crs.Register(context.Background(), &pb.RegisterReq{NodeId: self.nodeId, PublicKeyEnc: pubKeyEnc, ContactEmailEnc: emailEnc, PublicKeyHash: self.key.PubKeyHash})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestRegister(t *testing.T) {
+	env, clean := newTestEnv(t)
+	defer clean()
+	ctx := context.Background()
+
+	resp := env.register(t)
+	require.Equal(t, uint32(0), resp.Code, resp.ErrMsg)
+	assert.Equal(t, uint32(code_registered), env.register(t).Code)
+	c, err := env.reg.Client(env.nodeId)
+	require.NoError(t, err)
+	assert.Equal(t, "client@example.com", c.ContactEmail)
+	assert.False(t, c.EmailVerified)
+
+	data, err := ioutil.ReadFile(env.mailFile)
+	require.NoError(t, err)
+	mail := strings.TrimSpace(string(data))
+	assert.Contains(t, mail, "client@example.com")
+	verify := &pb.VerifyContactEmailReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), VerifyCode: mail[strings.LastIndex(mail, " ")+1:]}
+	require.NoError(t, verify.SignReq(env.priKey))
+	vResp, err := env.crs.VerifyContactEmail(ctx, verify)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), vResp.Code, vResp.ErrMsg)
+	c, err = env.reg.Client(env.nodeId)
+	require.NoError(t, err)
+	assert.True(t, c.EmailVerified)
+
+	ts := &pb.GetTrackerServerReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, ts.SignReq(env.priKey))
+	tsResp, err := env.crs.GetTrackerServer(ctx, ts)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tsResp.Server))
+	assert.Equal(t, uint32(6677), tsResp.Server[0].Port)
+}
+
+func TestOrder(t *testing.T) {
+	env, clean := newTestEnv(t)
+	defer clean()
+	ctx := context.Background()
+	require.Equal(t, uint32(0), env.register(t).Code)
+	ledger, err := NewLedger(env.db)
+	require.NoError(t, err)
+	packages := []*Package{{Id: 7, Name: "Standard", Price: 100, Volume: 10, Netflow: 20, UpNetflow: 10, DownNetflow: 10, ValidDays: 30,
+		Discount: map[uint32]string{3: "0.9", 12: "0.8"}}}
+	svc, err := NewOrderService(env.db, env.reg, packages, ledger)
+	require.NoError(t, err)
+
+	all, err := svc.AllPackage(ctx, &pb.AllPackageReq{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(all.AllPackage))
+	assert.Equal(t, int64(7), all.AllPackage[0].Id)
+
+	buy := func(quantity uint32, cancelUnpaid bool) *pb.BuyPackageResp {
+		req := &pb.BuyPackageReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), PackageId: 7, Quanlity: quantity, CancelUnpaid: cancelUnpaid}
+		require.NoError(t, req.SignReq(env.priKey))
+		resp, err := svc.BuyPackage(ctx, req)
+		require.NoError(t, err)
+		return resp
+	}
+	first := buy(1, false)
+	require.Equal(t, uint32(0), first.Code, first.ErrMsg)
+	assert.Equal(t, uint64(100), first.Order.TotalAmount)
+	assert.Equal(t, uint32(code_unpaid_exists), buy(4, false).Code)
+	second := buy(4, true)
+	require.Equal(t, uint32(0), second.Code, second.ErrMsg)
+	assert.Equal(t, uint64(360), second.Order.TotalAmount)
+	assert.Equal(t, "0.9", second.Order.Discount)
+	assert.Equal(t, uint32(120), second.Order.ValidDays)
+
+	mine := &pb.MyAllOrderReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, mine.SignReq(env.priKey))
+	mineResp, err := svc.MyAllOrder(ctx, mine)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(mineResp.MyAllOrder))
+	assert.Equal(t, second.Order.Id, mineResp.MyAllOrder[0].Id)
+
+	pay := &pb.PayOrderReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), OrderId: second.Order.Id}
+	require.NoError(t, pay.SignReq(env.priKey))
+	payResp, err := svc.PayOrder(ctx, pay)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_payment_failed), payResp.Code)
+
+	require.NoError(t, ledger.Recharge(env.nodeId, 500))
+	recharge := &pb.RechargeAddressReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, recharge.SignReq(env.priKey))
+	rResp, err := svc.RechargeAddress(ctx, recharge)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), rResp.Code, rResp.ErrMsg)
+	assert.Equal(t, uint64(500), rResp.Balance)
+	address, err := util_rsa.DecryptLong(env.priKey, rResp.RechargeAddressEnc, env.priKey.Size())
+	require.NoError(t, err)
+	assert.Equal(t, "ledger:"+hex.EncodeToString(env.nodeId), string(address))
+
+	payResp, err = svc.PayOrder(ctx, pay)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), payResp.Code, payResp.ErrMsg)
+	balance, err := ledger.Balance(env.nodeId)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(140), balance)
+
+	remove := &pb.RemoveOrderReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), OrderId: second.Order.Id}
+	require.NoError(t, remove.SignReq(env.priKey))
+	removeResp, err := svc.RemoveOrder(ctx, remove)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_invalid_argument), removeResp.Code)
+
+	require.NoError(t, svc.AddUsage(env.nodeId, 3*mega_byte, 3*mega_byte, 0))
+	require.NoError(t, svc.AddUsage(env.nodeId, -mega_byte, 0, 5*mega_byte))
+	usage := &pb.UsageAmountReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, usage.SignReq(env.priKey))
+	uResp, err := svc.UsageAmount(ctx, usage)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), uResp.Code, uResp.ErrMsg)
+	assert.Equal(t, int64(7), uResp.PackageId)
+	assert.Equal(t, uint32(10*1024), uResp.Volume)
+	assert.Equal(t, uint32(2), uResp.UsageVolume)
+	assert.Equal(t, uint32(3), uResp.UsageUpNetflow)
+	assert.Equal(t, uint32(5), uResp.UsageDownNetflow)
+	assert.Equal(t, uint32(8), uResp.UsageNetflow)
+	assert.True(t, uResp.EndTime > uint64(time.Now().Unix()+119*seconds_per_day))
+}
+
+// blockingPayment wait for release before paying
+type blockingPayment struct {
+	*Ledger
+	paying  chan struct{}
+	release chan struct{}
+}
+
+func (self *blockingPayment) Pay(nodeId []byte, orderId []byte, amount uint64) error {
+	self.paying <- struct{}{}
+	<-self.release
+	return self.Ledger.Pay(nodeId, orderId, amount)
+}
+
+func TestPayOrderOnce(t *testing.T) {
+	env, clean := newTestEnv(t)
+	defer clean()
+	ctx := context.Background()
+	require.Equal(t, uint32(0), env.register(t).Code)
+	ledger, err := NewLedger(env.db)
+	require.NoError(t, err)
+	require.NoError(t, ledger.Recharge(env.nodeId, 1000))
+	payment := &blockingPayment{Ledger: ledger, paying: make(chan struct{}, 8), release: make(chan struct{})}
+	svc, err := NewOrderService(env.db, env.reg, []*Package{{Id: 7, Name: "Standard", Price: 100, Volume: 10, Netflow: 20, ValidDays: 30}}, payment)
+	require.NoError(t, err)
+	buy := func(cancelUnpaid bool) *pb.BuyPackageResp {
+		req := &pb.BuyPackageReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), PackageId: 7, Quanlity: 1, CancelUnpaid: cancelUnpaid}
+		require.NoError(t, req.SignReq(env.priKey))
+		resp, err := svc.BuyPackage(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, uint32(0), resp.Code, resp.ErrMsg)
+		return resp
+	}
+	order := buy(false).Order
+
+	pay := &pb.PayOrderReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), OrderId: order.Id}
+	require.NoError(t, pay.SignReq(env.priKey))
+	results := make(chan *pb.PayOrderResp, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			resp, err := svc.PayOrder(ctx, pay)
+			assert.NoError(t, err)
+			results <- resp
+		}()
+	}
+	<-payment.paying
+	<-payment.paying
+	// order being paid is neither cancelled by buying another nor removed
+	buy(true)
+	remove := &pb.RemoveOrderReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), OrderId: order.Id}
+	require.NoError(t, remove.SignReq(env.priKey))
+	removeResp, err := svc.RemoveOrder(ctx, remove)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_invalid_argument), removeResp.Code)
+	close(payment.release)
+	for i := 0; i < 2; i++ {
+		resp := <-results
+		assert.Equal(t, uint32(0), resp.Code, resp.ErrMsg)
+	}
+
+	balance, err := ledger.Balance(env.nodeId)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(900), balance, "order is charged once")
+	info := &pb.OrderInfoReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), OrderId: order.Id}
+	require.NoError(t, info.SignReq(env.priKey))
+	infoResp, err := svc.OrderInfo(ctx, info)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), infoResp.Code, infoResp.ErrMsg)
+	assert.True(t, infoResp.Order.Paid)
+	resp, err := svc.PayOrder(ctx, pay)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_invalid_argument), resp.Code)
+}
+
+func TestUsagePeriod(t *testing.T) {
+	env, clean := newTestEnv(t)
+	defer clean()
+	ctx := context.Background()
+	require.Equal(t, uint32(0), env.register(t).Code)
+	ledger, err := NewLedger(env.db)
+	require.NoError(t, err)
+	require.NoError(t, ledger.Recharge(env.nodeId, 1000))
+	svc, err := NewOrderService(env.db, env.reg, []*Package{{Id: 7, Name: "Standard", Price: 100, Volume: 10, Netflow: 20, ValidDays: 30}}, ledger)
+	require.NoError(t, err)
+	buy := &pb.BuyPackageReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), PackageId: 7, Quanlity: 3}
+	require.NoError(t, buy.SignReq(env.priKey))
+	buyResp, err := svc.BuyPackage(ctx, buy)
+	require.NoError(t, err)
+	pay := &pb.PayOrderReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix()), OrderId: buyResp.Order.Id}
+	require.NoError(t, pay.SignReq(env.priKey))
+	payResp, err := svc.PayOrder(ctx, pay)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), payResp.Code, payResp.ErrMsg)
+	usage := func() *pb.UsageAmountResp {
+		req := &pb.UsageAmountReq{NodeId: env.nodeId, Timestamp: uint64(time.Now().Unix())}
+		require.NoError(t, req.SignReq(env.priKey))
+		resp, err := svc.UsageAmount(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, uint32(0), resp.Code, resp.ErrMsg)
+		return resp
+	}
+
+	require.NoError(t, svc.AddUsage(env.nodeId, 4*mega_byte, 4*mega_byte, 6*mega_byte))
+	resp := usage()
+	assert.Equal(t, uint32(4), resp.UsageVolume)
+	assert.Equal(t, uint32(10), resp.UsageNetflow)
+
+	// order started one package period ago, netflow of last period is not counted
+	require.NoError(t, env.db.Update(func(tx *bolt.Tx) error {
+		o, err := getOrder(tx, env.nodeId, buyResp.Order.Id)
+		if err != nil {
+			return err
+		}
+		o.StartTime -= 31 * seconds_per_day
+		return putOrder(tx, env.nodeId, o)
+	}))
+	resp = usage()
+	assert.Equal(t, uint32(4), resp.UsageVolume, "volume is kept across periods")
+	assert.Equal(t, uint32(0), resp.UsageNetflow)
+	require.NoError(t, svc.AddUsage(env.nodeId, 0, mega_byte, 0))
+	resp = usage()
+	assert.Equal(t, uint32(1), resp.UsageUpNetflow)
+	assert.Equal(t, uint32(0), resp.UsageDownNetflow)
+}
@@ -0,0 +1,555 @@
+package impl
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+
+	"github.com/boltdb/bolt"
+	pb "github.com/samoslab/nebula/tracker/register/client/pb"
+	"github.com/samoslab/nebula/tracker/registry"
+	"github.com/samoslab/nebula/util/dbutil"
+	util_rsa "github.com/samoslab/nebula/util/rsa"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	orderBkt = []byte("order_order") // node|order id -> Order
+	usageBkt = []byte("order_usage") // node -> Usage
+)
+
+const seconds_per_day = 24 * 3600
+const mega_byte = 1024 * 1024
+
+var OrderNotFoundErr = errors.New("order not found")
+
+// Package is a plan clients buy, volume and netflow unit is GigaByte
+type Package struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	Price       uint64 `json:"price"`
+	Volume      uint32 `json:"volume"`
+	Netflow     uint32 `json:"netflow"`
+	UpNetflow   uint32 `json:"up_netflow"`
+	DownNetflow uint32 `json:"down_netflow"`
+	ValidDays   uint32 `json:"valid_days"`
+	Remark      string `json:"remark"`
+	// Discount is rate of price when buying at least the quantity, eg: {"6": "0.9", "12": "0.8"}
+	Discount map[uint32]string `json:"discount,omitempty"`
+}
+
+func (self *Package) toPb() *pb.Package {
+	return &pb.Package{Id: self.Id,
+		Name:        self.Name,
+		Price:       self.Price,
+		Volume:      self.Volume,
+		Netflow:     self.Netflow,
+		UpNetflow:   self.UpNetflow,
+		DownNetflow: self.DownNetflow,
+		ValidDays:   self.ValidDays,
+		Remark:      self.Remark}
+}
+
+// discount return discount rate of quantity, empty if no discount
+func (self *Package) discount(quantity uint32) (string, float64, error) {
+	var best uint32
+	for q := range self.Discount {
+		if q <= quantity && q > best {
+			best = q
+		}
+	}
+	if best == 0 {
+		return "", 1, nil
+	}
+	rate, err := strconv.ParseFloat(self.Discount[best], 64)
+	if err != nil || rate <= 0 || rate > 1 {
+		return "", 0, fmt.Errorf("invalid discount %s of package %d", self.Discount[best], self.Id)
+	}
+	return self.Discount[best], rate, nil
+}
+
+func DefaultPackages() []*Package {
+	return []*Package{{Id: 1, Name: "Basic", Volume: 100, Netflow: 200, UpNetflow: 100, DownNetflow: 100, ValidDays: 30, Remark: "free package of self-hosted tracker"}}
+}
+
+// LoadPackages read json array of Package
+func LoadPackages(path string) ([]*Package, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var res []*Package
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	for _, p := range res {
+		for q := range p.Discount {
+			if _, _, err = p.discount(q); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+type Order struct {
+	Id          []byte   `json:"id"`
+	Creation    uint64   `json:"creation"`
+	Package     *Package `json:"package"`
+	Quantity    uint32   `json:"quantity"`
+	TotalAmount uint64   `json:"total_amount"`
+	Upgraded    bool     `json:"upgraded"` // paid when another order is in use
+	Discount    string   `json:"discount,omitempty"`
+	ValidDays   uint32   `json:"valid_days"`
+	StartTime   uint64   `json:"start_time,omitempty"`
+	EndTime     uint64   `json:"end_time,omitempty"`
+	Paying      bool     `json:"paying,omitempty"` // claimed by PayOrder, it is not removed until paying fails
+	Paid        bool     `json:"paid"`
+	PayTime     uint64   `json:"pay_time,omitempty"`
+}
+
+func (self *Order) toPb() *pb.Order {
+	return &pb.Order{Id: self.Id,
+		Creation:    self.Creation,
+		PackageId:   self.Package.Id,
+		Package:     self.Package.toPb(),
+		Quanlity:    self.Quantity,
+		TotalAmount: self.TotalAmount,
+		Upgraded:    self.Upgraded,
+		Discount:    self.Discount,
+		Volume:      self.Package.Volume,
+		Netflow:     self.Package.Netflow,
+		UpNetflow:   self.Package.UpNetflow,
+		DownNetflow: self.Package.DownNetflow,
+		ValidDays:   self.ValidDays,
+		StartTime:   self.StartTime,
+		EndTime:     self.EndTime,
+		Paid:        self.Paid,
+		PayTime:     self.PayTime,
+		Remark:      self.Package.Remark}
+}
+
+func (self *Order) active(ts uint64) bool {
+	return self.Paid && self.StartTime <= ts && ts < self.EndTime
+}
+
+// periodStart is start of period of order at ts, netflow of package is allowance of each ValidDays of package
+func (self *Order) periodStart(ts uint64) uint64 {
+	period := uint64(self.Package.ValidDays) * seconds_per_day
+	if period == 0 || ts < self.StartTime {
+		return self.StartTime
+	}
+	return self.StartTime + (ts-self.StartTime)/period*period
+}
+
+// Usage is accumulated usage of client, unit is byte. Volume is kept while netflow is counted in each period of order.
+type Usage struct {
+	Volume      int64  `json:"volume"`
+	UpNetflow   uint64 `json:"up_netflow"`
+	DownNetflow uint64 `json:"down_netflow"`
+	Period      uint64 `json:"period,omitempty"` // start of period netflow is counted in
+}
+
+// netflow in period, it is zero if nothing is counted in period yet
+func (self *Usage) netflow(period uint64) (up uint64, down uint64) {
+	if self.Period != period {
+		return 0, 0
+	}
+	return self.UpNetflow, self.DownNetflow
+}
+
+type OrderService struct {
+	db       *bolt.DB
+	registry *registry.Registry
+	packages []*Package
+	payment  Payment
+}
+
+func NewOrderService(db *bolt.DB, reg *registry.Registry, packages []*Package, payment Payment) (*OrderService, error) {
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bkt := range [][]byte{orderBkt, usageBkt} {
+			if _, err := tx.CreateBucketIfNotExists(bkt); err != nil {
+				return dbutil.NewCreateBucketFailedErr(bkt, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &OrderService{db: db, registry: reg, packages: packages, payment: payment}, nil
+}
+
+func (self *OrderService) pkg(id int64) *Package {
+	for _, p := range self.packages {
+		if p.Id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// orderKey is node id length(1)|node id|order id
+func orderKey(nodeId []byte, orderId []byte) []byte {
+	return append(append([]byte{byte(len(nodeId))}, nodeId...), orderId...)
+}
+
+func putOrder(tx *bolt.Tx, nodeId []byte, o *Order) error {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(orderBkt).Put(orderKey(nodeId, o.Id), data)
+}
+
+func getOrder(tx *bolt.Tx, nodeId []byte, orderId []byte) (*Order, error) {
+	data := tx.Bucket(orderBkt).Get(orderKey(nodeId, orderId))
+	if data == nil {
+		return nil, OrderNotFoundErr
+	}
+	o := &Order{}
+	return o, json.Unmarshal(data, o)
+}
+
+// orders of node sorted by creation
+func orders(tx *bolt.Tx, nodeId []byte) ([]*Order, error) {
+	prefix := orderKey(nodeId, nil)
+	var res []*Order
+	c := tx.Bucket(orderBkt).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		o := &Order{}
+		if err := json.Unmarshal(v, o); err != nil {
+			return nil, err
+		}
+		res = append(res, o)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Creation < res[j].Creation })
+	return res, nil
+}
+
+// activeOrder is the latest started order in use
+func activeOrder(all []*Order, ts uint64) *Order {
+	var res *Order
+	for _, o := range all {
+		if o.active(ts) && (res == nil || o.StartTime >= res.StartTime) {
+			res = o
+		}
+	}
+	return res
+}
+
+// AddUsage accumulate usage of client, volume is negative when files are removed
+func (self *OrderService) AddUsage(nodeId []byte, volume int64, up uint64, down uint64) error {
+	ts := now()
+	return self.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(usageBkt)
+		u := &Usage{}
+		if data := bkt.Get(nodeId); data != nil {
+			if err := json.Unmarshal(data, u); err != nil {
+				return err
+			}
+		}
+		all, err := orders(tx, nodeId)
+		if err != nil {
+			return err
+		}
+		if o := activeOrder(all, ts); o != nil {
+			if period := o.periodStart(ts); period != u.Period {
+				u.Period, u.UpNetflow, u.DownNetflow = period, 0, 0
+			}
+		}
+		u.Volume += volume
+		if u.Volume < 0 {
+			u.Volume = 0
+		}
+		u.UpNetflow += up
+		u.DownNetflow += down
+		data, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		return bkt.Put(nodeId, data)
+	})
+}
+
+func (self *OrderService) Usage(nodeId []byte) (*Usage, error) {
+	u := &Usage{}
+	err := self.db.View(func(tx *bolt.Tx) error {
+		if data := tx.Bucket(usageBkt).Get(nodeId); data != nil {
+			return json.Unmarshal(data, u)
+		}
+		return nil
+	})
+	return u, err
+}
+
+func (self *OrderService) AllPackage(ctx context.Context, req *pb.AllPackageReq) (*pb.AllPackageResp, error) {
+	resp := &pb.AllPackageResp{AllPackage: make([]*pb.Package, 0, len(self.packages))}
+	for _, p := range self.packages {
+		resp.AllPackage = append(resp.AllPackage, p.toPb())
+	}
+	return resp, nil
+}
+
+func (self *OrderService) PackageInfo(ctx context.Context, req *pb.PackageInfoReq) (*pb.PackageInfoResp, error) {
+	p := self.pkg(req.PackageId)
+	if p == nil {
+		return nil, status.Errorf(codes.NotFound, "package %d not found", req.PackageId)
+	}
+	return &pb.PackageInfoResp{Package: p.toPb()}, nil
+}
+
+func (self *OrderService) PackageDiscount(ctx context.Context, req *pb.PackageDiscountReq) (*pb.PackageDiscountResp, error) {
+	p := self.pkg(req.PackageId)
+	if p == nil {
+		return nil, status.Errorf(codes.NotFound, "package %d not found", req.PackageId)
+	}
+	return &pb.PackageDiscountResp{Discount: p.Discount}, nil
+}
+
+func (self *OrderService) BuyPackage(ctx context.Context, req *pb.BuyPackageReq) (*pb.BuyPackageResp, error) {
+	if _, err := verify(self.registry, req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.BuyPackageResp{Code: code_auth_failed, ErrMsg: err.Error()}, nil
+	}
+	p := self.pkg(req.PackageId)
+	if p == nil {
+		return &pb.BuyPackageResp{Code: code_not_found, ErrMsg: fmt.Sprintf("package %d not found", req.PackageId)}, nil
+	}
+	if req.Quanlity == 0 {
+		return &pb.BuyPackageResp{Code: code_invalid_argument, ErrMsg: "quanlity must be greater than 0"}, nil
+	}
+	discount, rate, err := p.discount(req.Quanlity)
+	if err != nil {
+		return &pb.BuyPackageResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	o := &Order{Id: make([]byte, 16),
+		Creation:    now(),
+		Package:     p,
+		Quantity:    req.Quanlity,
+		TotalAmount: uint64(float64(p.Price*uint64(req.Quanlity))*rate + 0.5),
+		Discount:    discount,
+		ValidDays:   p.ValidDays * req.Quanlity}
+	rand.Read(o.Id)
+	unpaid := false
+	if err = self.db.Update(func(tx *bolt.Tx) error {
+		all, err := orders(tx, req.NodeId)
+		if err != nil {
+			return err
+		}
+		for _, exist := range all {
+			if exist.Paid {
+				continue
+			}
+			if !req.CancelUnpaid {
+				unpaid = true
+				return nil
+			}
+			if exist.Paying {
+				// it becomes paid or is released by PayOrder
+				continue
+			}
+			if err = tx.Bucket(orderBkt).Delete(orderKey(req.NodeId, exist.Id)); err != nil {
+				return err
+			}
+		}
+		return putOrder(tx, req.NodeId, o)
+	}); err != nil {
+		return &pb.BuyPackageResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	if unpaid {
+		return &pb.BuyPackageResp{Code: code_unpaid_exists, ErrMsg: "unpaid order exists"}, nil
+	}
+	return &pb.BuyPackageResp{Order: o.toPb()}, nil
+}
+
+func (self *OrderService) MyAllOrder(ctx context.Context, req *pb.MyAllOrderReq) (*pb.MyAllOrderResp, error) {
+	if _, err := verify(self.registry, req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.MyAllOrderResp{Code: code_auth_failed, ErrMsg: err.Error()}, nil
+	}
+	var all []*Order
+	if err := self.db.View(func(tx *bolt.Tx) (err error) {
+		all, err = orders(tx, req.NodeId)
+		return
+	}); err != nil {
+		return &pb.MyAllOrderResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	ts := now()
+	resp := &pb.MyAllOrderResp{MyAllOrder: make([]*pb.Order, 0, len(all))}
+	for _, o := range all {
+		if req.OnlyNotExpired && o.Paid && o.EndTime <= ts {
+			continue
+		}
+		resp.MyAllOrder = append(resp.MyAllOrder, o.toPb())
+	}
+	return resp, nil
+}
+
+func (self *OrderService) OrderInfo(ctx context.Context, req *pb.OrderInfoReq) (*pb.OrderInfoResp, error) {
+	if _, err := verify(self.registry, req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.OrderInfoResp{Code: code_auth_failed, ErrMsg: err.Error()}, nil
+	}
+	var o *Order
+	if err := self.db.View(func(tx *bolt.Tx) (err error) {
+		o, err = getOrder(tx, req.NodeId, req.OrderId)
+		return
+	}); err == OrderNotFoundErr {
+		return &pb.OrderInfoResp{Code: code_not_found, ErrMsg: err.Error()}, nil
+	} else if err != nil {
+		return &pb.OrderInfoResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	return &pb.OrderInfoResp{Order: o.toPb()}, nil
+}
+
+// RemoveOrder remove unpaid order
+func (self *OrderService) RemoveOrder(ctx context.Context, req *pb.RemoveOrderReq) (*pb.RemoveOrderResp, error) {
+	if _, err := verify(self.registry, req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.RemoveOrderResp{Code: code_auth_failed, ErrMsg: err.Error()}, nil
+	}
+	paid := false
+	if err := self.db.Update(func(tx *bolt.Tx) error {
+		o, err := getOrder(tx, req.NodeId, req.OrderId)
+		if err != nil {
+			return err
+		}
+		if o.Paid || o.Paying {
+			paid = true
+			return nil
+		}
+		return tx.Bucket(orderBkt).Delete(orderKey(req.NodeId, req.OrderId))
+	}); err == OrderNotFoundErr {
+		return &pb.RemoveOrderResp{Code: code_not_found, ErrMsg: err.Error()}, nil
+	} else if err != nil {
+		return &pb.RemoveOrderResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	if paid {
+		return &pb.RemoveOrderResp{Code: code_invalid_argument, ErrMsg: "paid or paying order can not be removed"}, nil
+	}
+	return &pb.RemoveOrderResp{}, nil
+}
+
+func (self *OrderService) RechargeAddress(ctx context.Context, req *pb.RechargeAddressReq) (*pb.RechargeAddressResp, error) {
+	c, err := verify(self.registry, req.NodeId, req.Timestamp, req)
+	if err != nil {
+		return &pb.RechargeAddressResp{Code: code_auth_failed, ErrMsg: err.Error()}, nil
+	}
+	address, err := self.payment.RechargeAddress(req.NodeId)
+	if err != nil {
+		return &pb.RechargeAddressResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	balance, err := self.payment.Balance(req.NodeId)
+	if err != nil {
+		return &pb.RechargeAddressResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	pubKey, err := c.PubKey()
+	if err != nil {
+		return &pb.RechargeAddressResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	enc, err := util_rsa.EncryptLong(pubKey, []byte(address), pubKey.Size())
+	if err != nil {
+		return &pb.RechargeAddressResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	return &pb.RechargeAddressResp{RechargeAddressEnc: enc, Balance: balance}, nil
+}
+
+// PayOrder pay order by payment backend, paid order starts immediately.
+// Order is claimed before paying so it is not removed meanwhile, paying it again concurrently or after failure
+// is safe because payment is idempotent per order id.
+func (self *OrderService) PayOrder(ctx context.Context, req *pb.PayOrderReq) (*pb.PayOrderResp, error) {
+	if _, err := verify(self.registry, req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.PayOrderResp{Code: code_auth_failed, ErrMsg: err.Error()}, nil
+	}
+	var o *Order
+	if err := self.db.Update(func(tx *bolt.Tx) (err error) {
+		if o, err = getOrder(tx, req.NodeId, req.OrderId); err != nil || o.Paid {
+			return
+		}
+		o.Paying = true
+		return putOrder(tx, req.NodeId, o)
+	}); err == OrderNotFoundErr {
+		return &pb.PayOrderResp{Code: code_not_found, ErrMsg: err.Error()}, nil
+	} else if err != nil {
+		return &pb.PayOrderResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	if o.Paid {
+		return &pb.PayOrderResp{Code: code_invalid_argument, ErrMsg: "order is paid"}, nil
+	}
+	if err := self.payment.Pay(req.NodeId, o.Id, o.TotalAmount); err != nil {
+		if er := self.updateOrder(req.NodeId, o.Id, func(o *Order) { o.Paying = false }); er != nil {
+			return &pb.PayOrderResp{Code: code_system_error, ErrMsg: er.Error()}, nil
+		}
+		if err == InsufficientBalanceErr {
+			return &pb.PayOrderResp{Code: code_payment_failed, ErrMsg: err.Error()}, nil
+		}
+		return &pb.PayOrderResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	if err := self.db.Update(func(tx *bolt.Tx) error {
+		all, err := orders(tx, req.NodeId)
+		if err != nil {
+			return err
+		}
+		ts := now()
+		upgraded := activeOrder(all, ts) != nil
+		return updateOrder(tx, req.NodeId, o.Id, func(o *Order) {
+			o.Paid, o.Paying, o.PayTime, o.StartTime, o.EndTime = true, false, ts, ts, ts+uint64(o.ValidDays)*seconds_per_day
+			o.Upgraded = upgraded
+		})
+	}); err != nil {
+		return &pb.PayOrderResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	return &pb.PayOrderResp{}, nil
+}
+
+// updateOrder update unpaid order, order paid by concurrent PayOrder is not changed
+func updateOrder(tx *bolt.Tx, nodeId []byte, orderId []byte, f func(o *Order)) error {
+	o, err := getOrder(tx, nodeId, orderId)
+	if err != nil || o.Paid {
+		return err
+	}
+	f(o)
+	return putOrder(tx, nodeId, o)
+}
+
+func (self *OrderService) updateOrder(nodeId []byte, orderId []byte, f func(o *Order)) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		return updateOrder(tx, nodeId, orderId, f)
+	})
+}
+
+func (self *OrderService) UsageAmount(ctx context.Context, req *pb.UsageAmountReq) (*pb.UsageAmountResp, error) {
+	if _, err := verify(self.registry, req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.UsageAmountResp{Code: code_auth_failed, ErrMsg: err.Error()}, nil
+	}
+	var all []*Order
+	if err := self.db.View(func(tx *bolt.Tx) (err error) {
+		all, err = orders(tx, req.NodeId)
+		return
+	}); err != nil {
+		return &pb.UsageAmountResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	ts := now()
+	o := activeOrder(all, ts)
+	if o == nil {
+		return &pb.UsageAmountResp{Code: code_no_package, ErrMsg: "no package in use"}, nil
+	}
+	u, err := self.Usage(req.NodeId)
+	if err != nil {
+		return &pb.UsageAmountResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	up, down := u.netflow(o.periodStart(ts))
+	return &pb.UsageAmountResp{PackageId: o.Package.Id,
+		Volume:           o.Package.Volume * 1024,
+		Netflow:          o.Package.Netflow * 1024,
+		UpNetflow:        o.Package.UpNetflow * 1024,
+		DownNetflow:      o.Package.DownNetflow * 1024,
+		UsageVolume:      uint32(u.Volume / mega_byte),
+		UsageNetflow:     uint32((up + down) / mega_byte),
+		UsageUpNetflow:   uint32(up / mega_byte),
+		UsageDownNetflow: uint32(down / mega_byte),
+		EndTime:          o.EndTime}, nil
+}
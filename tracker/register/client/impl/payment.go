@@ -0,0 +1,98 @@
+package impl
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+
+	"github.com/boltdb/bolt"
+	"github.com/samoslab/nebula/util/dbutil"
+)
+
+var (
+	balanceBkt = []byte("order_balance") // node -> balance
+	paymentBkt = []byte("order_payment") // node id length(1)|node|order id -> amount paid
+)
+
+var InsufficientBalanceErr = errors.New("insufficient balance")
+
+// Payment is backend paying orders, such as a wallet of cryptocurrency
+type Payment interface {
+	// RechargeAddress is where client recharges its balance
+	RechargeAddress(nodeId []byte) (string, error)
+	Balance(nodeId []byte) (uint64, error)
+	// Pay deduct amount from balance of node, it returns InsufficientBalanceErr if balance is not enough.
+	// It is idempotent per order id, paying an order paid before succeeds without deducting again.
+	Pay(nodeId []byte, orderId []byte, amount uint64) error
+}
+
+// Ledger is Payment keeping balances in bolt, tracker operator recharges clients by Recharge
+type Ledger struct {
+	db *bolt.DB
+}
+
+func NewLedger(db *bolt.DB) (*Ledger, error) {
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bkt := range [][]byte{balanceBkt, paymentBkt} {
+			if _, err := tx.CreateBucketIfNotExists(bkt); err != nil {
+				return dbutil.NewCreateBucketFailedErr(bkt, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &Ledger{db: db}, nil
+}
+
+func getBalance(bkt *bolt.Bucket, nodeId []byte) uint64 {
+	if v := bkt.Get(nodeId); v != nil {
+		return binary.BigEndian.Uint64(v)
+	}
+	return 0
+}
+
+func putBalance(bkt *bolt.Bucket, nodeId []byte, balance uint64) error {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, balance)
+	return bkt.Put(nodeId, b)
+}
+
+// RechargeAddress is account name of node in ledger
+func (self *Ledger) RechargeAddress(nodeId []byte) (string, error) {
+	return "ledger:" + hex.EncodeToString(nodeId), nil
+}
+
+func (self *Ledger) Balance(nodeId []byte) (balance uint64, err error) {
+	err = self.db.View(func(tx *bolt.Tx) error {
+		balance = getBalance(tx.Bucket(balanceBkt), nodeId)
+		return nil
+	})
+	return
+}
+
+func (self *Ledger) Pay(nodeId []byte, orderId []byte, amount uint64) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		payments := tx.Bucket(paymentBkt)
+		key := orderKey(nodeId, orderId)
+		if payments.Get(key) != nil {
+			return nil
+		}
+		bkt := tx.Bucket(balanceBkt)
+		balance := getBalance(bkt, nodeId)
+		if balance < amount {
+			return InsufficientBalanceErr
+		}
+		if err := putBalance(bkt, nodeId, balance-amount); err != nil {
+			return err
+		}
+		return putBalance(payments, key, amount)
+	})
+}
+
+func (self *Ledger) Recharge(nodeId []byte, amount uint64) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(balanceBkt)
+		return putBalance(bkt, nodeId, getBalance(bkt, nodeId)+amount)
+	})
+}
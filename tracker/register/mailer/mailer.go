@@ -0,0 +1,94 @@
+// Package mailer sends verify code emails of client and provider registration.
+package mailer
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const verify_code_digits = 6
+
+type Mailer interface {
+	Send(to string, subject string, content string) error
+}
+
+// NewVerifyCode generate random decimal verify code
+func NewVerifyCode() string {
+	max := big.NewInt(1)
+	for i := 0; i < verify_code_digits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%0*d", verify_code_digits, n)
+}
+
+func SendVerifyCode(m Mailer, to string, code string) error {
+	return m.Send(to, "Samos Nebula verify code", "Your verify code is: "+code)
+}
+
+// LogMailer write mails to log instead of sending them, it is used by tracker without mail server
+type LogMailer struct{}
+
+func (self LogMailer) Send(to string, subject string, content string) error {
+	log.Infof("mail to %s, subject: %s, content: %s", to, subject, content)
+	return nil
+}
+
+// FileMailer append mails to a file, one line per mail
+type FileMailer struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFileMailer(path string) *FileMailer {
+	return &FileMailer{path: path}
+}
+
+func (self *FileMailer) Send(to string, subject string, content string) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	f, err := os.OpenFile(self.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\t%s\n", time.Now().Format(time.RFC3339), to, subject, content)
+	return err
+}
+
+// SmtpMailer send mails by smtp server, auth is skipped if username is empty
+type SmtpMailer struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+func NewSmtpMailer(addr string, from string, username string, password string) *SmtpMailer {
+	return &SmtpMailer{addr: addr, from: from, username: username, password: password}
+}
+
+func (self *SmtpMailer) Send(to string, subject string, content string) error {
+	var auth smtp.Auth
+	if len(self.username) > 0 {
+		host, _, err := net.SplitHostPort(self.addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", self.username, self.password, host)
+	}
+	msg := strings.Join([]string{"From: " + self.from, "To: " + to, "Subject: " + subject, "", content}, "\r\n")
+	return smtp.SendMail(self.addr, auth, self.from, []string{to}, []byte(msg))
+}
@@ -0,0 +1,451 @@
+// Package impl is a self-hostable implementation of ProviderRegisterService backed by registry.
+package impl
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samoslab/nebula/provider/relay"
+	"github.com/samoslab/nebula/provider/uptime"
+	"github.com/samoslab/nebula/tracker/config"
+	"github.com/samoslab/nebula/tracker/register/mailer"
+	pb "github.com/samoslab/nebula/tracker/register/provider/pb"
+	"github.com/samoslab/nebula/tracker/registry"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	util_rsa "github.com/samoslab/nebula/util/rsa"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const timestamp_expired = 900
+const timestamp_ahead = -300
+
+// relay_grant_ttl is how long grant of relay is valid, NATed provider gets a fresh one when it reconnects to relay
+const relay_grant_ttl = 24 * time.Hour
+
+// codes of responses, 27, 300 and 500 are handled by provider register command
+const (
+	code_ok                 = 0
+	code_auth_failed        = 2
+	code_invalid_argument   = 3
+	code_registered         = 4
+	code_verify_code_wrong  = 5
+	code_ping_failed        = 27
+	code_system_error       = 300
+	code_public_key_expired = 500
+)
+
+type Config struct {
+	TrackerServers   []config.Server
+	CollectorServers []config.Server
+	Mailer           mailer.Mailer
+	// Ping check provider listening at address is the node, it is uptime.CheckReachable by default
+	Ping func(address string, nodeIdHash []byte) error
+	// ResendInterval is minimum interval between verify code emails
+	ResendInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{Mailer: mailer.LogMailer{}, Ping: uptime.CheckReachable, ResendInterval: time.Minute}
+}
+
+type ProviderRegisterService struct {
+	registry *registry.Registry
+	key      *config.Key
+	config   Config
+}
+
+func NewProviderRegisterService(reg *registry.Registry, key *config.Key, config Config) *ProviderRegisterService {
+	return &ProviderRegisterService{registry: reg, key: key, config: config}
+}
+
+type signedReq interface {
+	VerifySign(pubKey *rsa.PublicKey) error
+}
+
+func checkTimestamp(timestamp uint64) error {
+	interval := time.Now().Unix() - int64(timestamp)
+	if interval > timestamp_expired || interval < timestamp_ahead {
+		return errors.New("timestamp expired")
+	}
+	return nil
+}
+
+func (self *ProviderRegisterService) verify(nodeId []byte, timestamp uint64, req signedReq) (*registry.Provider, error) {
+	if err := checkTimestamp(timestamp); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	pro, err := self.registry.Provider(nodeId)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	pubKey, err := pro.PubKey()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err = req.VerifySign(pubKey); err != nil {
+		return nil, status.Error(codes.Unauthenticated, "verify sign failed")
+	}
+	return pro, nil
+}
+
+// decrypt fields encrypted by tracker public key, empty field is kept empty
+func (self *ProviderRegisterService) decrypt(data ...[]byte) ([][]byte, error) {
+	res := make([][]byte, len(data))
+	for i, d := range data {
+		if len(d) == 0 {
+			continue
+		}
+		var err error
+		if res[i], err = util_rsa.DecryptLong(self.key.PriKey, d, self.key.PriKey.Size()); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (self *ProviderRegisterService) ping(host string, port uint32, nodeId []byte) error {
+	return self.config.Ping(net.JoinHostPort(host, strconv.Itoa(int(port))), util_hash.Sha1(nodeId))
+}
+
+func (self *ProviderRegisterService) sendVerifyCode(p *registry.Provider) {
+	if err := mailer.SendVerifyCode(self.config.Mailer, p.BillEmail, p.VerifyCode); err != nil {
+		log.Errorf("send verify code to provider %x failed: %s", p.NodeId, err)
+	}
+}
+
+func peerIp(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+func now() uint64 {
+	return uint64(time.Now().Unix())
+}
+
+func (self *ProviderRegisterService) GetPublicKey(ctx context.Context, req *pb.GetPublicKeyReq) (*pb.GetPublicKeyResp, error) {
+	return &pb.GetPublicKeyResp{PublicKey: self.key.PubKeyBytes, PublicKeyHash: self.key.PubKeyHash, Ip: peerIp(ctx)}, nil
+}
+
+func (self *ProviderRegisterService) Register(ctx context.Context, req *pb.RegisterReq) (*pb.RegisterResp, error) {
+	if string(req.PublicKeyHash) != string(self.key.PubKeyHash) {
+		return &pb.RegisterResp{Code: code_public_key_expired, ErrMsg: "tracker public key expired"}, nil
+	}
+	if err := checkTimestamp(req.Timestamp); err != nil {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: err.Error()}, nil
+	}
+	fields, err := self.decrypt(req.NodeIdEnc, req.PublicKeyEnc, req.EncryptKeyEnc, req.WalletAddressEnc, req.BillEmailEnc, req.HostEnc, req.DynamicDomainEnc)
+	if err != nil {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "decrypt failed: " + err.Error()}, nil
+	}
+	nodeId, pubKeyBytes := fields[0], fields[1]
+	pubKey, err := x509.ParsePKCS1PublicKey(pubKeyBytes)
+	if err != nil {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "invalid public key: " + err.Error()}, nil
+	}
+	if string(nodeId) != string(util_hash.Sha1(pubKeyBytes)) {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "node id does not match public key"}, nil
+	}
+	if err = req.VerifySign(pubKey); err != nil {
+		return &pb.RegisterResp{Code: code_auth_failed, ErrMsg: "verify sign failed"}, nil
+	}
+	p := &registry.Provider{NodeId: nodeId,
+		PublicKey:     pubKeyBytes,
+		EncryptKey:    fields[2],
+		WalletAddress: string(fields[3]),
+		BillEmail:     string(fields[4]),
+		Storage:       append([]uint64{req.MainStorageVolume}, req.ExtraStorageVolume...),
+		Availability:  req.Availability,
+		UpBandwidth:   req.UpBandwidth,
+		DownBandwidth: req.DownBandwidth,
+		VerifyCode:    mailer.NewVerifyCode(),
+		VerifyCodeAt:  now(),
+		LastSeen:      now()}
+	if !strings.Contains(p.BillEmail, "@") {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "invalid bill email"}, nil
+	}
+	if req.MainStorageVolume == 0 {
+		return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "main storage volume is required"}, nil
+	}
+	if _, err = self.registry.Provider(nodeId); err == nil {
+		return &pb.RegisterResp{Code: code_registered, ErrMsg: "provider is registered"}, nil
+	} else if err != registry.NotFoundErr {
+		return &pb.RegisterResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	if req.ConfirmInner {
+		p.Private = true
+	} else {
+		p.Host, p.DynamicDomain, p.Port = string(fields[5]), string(fields[6]), req.Port
+		if len(p.Host) == 0 && len(p.DynamicDomain) == 0 || p.Port == 0 {
+			return &pb.RegisterResp{Code: code_invalid_argument, ErrMsg: "host or dynamic domain and port are required"}, nil
+		}
+		if err = self.ping(p.Server(), p.Port, nodeId); err != nil {
+			return &pb.RegisterResp{Code: code_ping_failed, ErrMsg: err.Error()}, nil
+		}
+	}
+	if err = self.registry.PutProvider(p); err != nil {
+		return &pb.RegisterResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	self.sendVerifyCode(p)
+	log.Infof("provider %x registered, private: %t", nodeId, p.Private)
+	return &pb.RegisterResp{}, nil
+}
+
+func (self *ProviderRegisterService) VerifyBillEmail(ctx context.Context, req *pb.VerifyBillEmailReq) (*pb.VerifyBillEmailResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.VerifyBillEmailResp{Code: code_auth_failed, ErrMsg: status.Convert(err).Message()}, nil
+	}
+	wrong := false
+	if err := self.registry.UpdateProvider(req.NodeId, func(p *registry.Provider) error {
+		if p.EmailVerified {
+			return nil
+		}
+		if len(p.VerifyCode) == 0 || p.VerifyCode != req.VerifyCode {
+			wrong = true
+			return nil
+		}
+		p.EmailVerified, p.VerifyCode = true, ""
+		return nil
+	}); err != nil {
+		return &pb.VerifyBillEmailResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	if wrong {
+		return &pb.VerifyBillEmailResp{Code: code_verify_code_wrong, ErrMsg: "verify code is wrong"}, nil
+	}
+	return &pb.VerifyBillEmailResp{}, nil
+}
+
+func (self *ProviderRegisterService) ResendVerifyCode(ctx context.Context, req *pb.ResendVerifyCodeReq) (*pb.ResendVerifyCodeResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	var pro *registry.Provider
+	if err := self.registry.UpdateProvider(req.NodeId, func(p *registry.Provider) error {
+		if p.EmailVerified || now()-p.VerifyCodeAt < uint64(self.config.ResendInterval/time.Second) {
+			return nil
+		}
+		p.VerifyCode, p.VerifyCodeAt = mailer.NewVerifyCode(), now()
+		pro = p
+		return nil
+	}); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if pro == nil {
+		return &pb.ResendVerifyCodeResp{Success: false}, nil
+	}
+	self.sendVerifyCode(pro)
+	return &pb.ResendVerifyCodeResp{Success: true}, nil
+}
+
+func (self *ProviderRegisterService) AddExtraStorage(ctx context.Context, req *pb.AddExtraStorageReq) (*pb.AddExtraStorageResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	if req.Volume == 0 {
+		return &pb.AddExtraStorageResp{Success: false}, nil
+	}
+	if err := self.registry.UpdateProvider(req.NodeId, func(p *registry.Provider) error {
+		p.Storage = append(p.Storage, req.Volume)
+		return nil
+	}); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.AddExtraStorageResp{Success: true}, nil
+}
+
+func (self *ProviderRegisterService) GetTrackerServer(ctx context.Context, req *pb.GetTrackerServerReq) (*pb.GetTrackerServerResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	resp := &pb.GetTrackerServerResp{Server: make([]*pb.TrackerServer, 0, len(self.config.TrackerServers))}
+	for _, s := range self.config.TrackerServers {
+		resp.Server = append(resp.Server, &pb.TrackerServer{Server: s.Host, Port: s.Port})
+	}
+	return resp, nil
+}
+
+func (self *ProviderRegisterService) GetCollectorServer(ctx context.Context, req *pb.GetCollectorServerReq) (*pb.GetCollectorServerResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	resp := &pb.GetCollectorServerResp{Server: make([]*pb.CollectorServer, 0, len(self.config.CollectorServers))}
+	for _, s := range self.config.CollectorServers {
+		resp.Server = append(resp.Server, &pb.CollectorServer{Server: s.Host, Port: s.Port})
+	}
+	return resp, nil
+}
+
+// RefreshIp update host of public provider to the ip it connects from, provider using dynamic domain or relay keeps its address
+func (self *ProviderRegisterService) RefreshIp(ctx context.Context, req *pb.RefreshIpReq) (*pb.RefreshIpResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	ip := peerIp(ctx)
+	if len(ip) == 0 {
+		return nil, status.Error(codes.Internal, "unknown peer address")
+	}
+	if err := self.registry.UpdateProvider(req.NodeId, func(p *registry.Provider) error {
+		p.LastSeen = now()
+		if p.Private || len(p.DynamicDomain) > 0 || len(p.RelayNodeId) > 0 {
+			return nil
+		}
+		p.Host = ip
+		if req.Port > 0 {
+			p.Port = req.Port
+		}
+		return nil
+	}); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.RefreshIpResp{Ip: ip}, nil
+}
+
+func (self *ProviderRegisterService) SwitchPrivate(ctx context.Context, req *pb.SwitchPrivateReq) (*pb.SwitchPrivateResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	if err := self.registry.UpdateProvider(req.NodeId, func(p *registry.Provider) error {
+		p.Private, p.Host, p.Port, p.DynamicDomain, p.RelayNodeId, p.RelayPort, p.RelayCapacity = true, "", 0, "", nil, 0, 0
+		return nil
+	}); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.SwitchPrivateResp{Success: true}, nil
+}
+
+func (self *ProviderRegisterService) SwitchPublic(ctx context.Context, req *pb.SwitchPublicReq) (*pb.SwitchPublicResp, error) {
+	if string(req.PublicKeyHash) != string(self.key.PubKeyHash) {
+		return &pb.SwitchPublicResp{Code: code_public_key_expired, ErrMsg: "tracker public key expired"}, nil
+	}
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.SwitchPublicResp{Code: code_auth_failed, ErrMsg: status.Convert(err).Message()}, nil
+	}
+	fields, err := self.decrypt(req.HostEnc, req.DynamicDomainEnc)
+	if err != nil {
+		return &pb.SwitchPublicResp{Code: code_invalid_argument, ErrMsg: "decrypt failed: " + err.Error()}, nil
+	}
+	host, dynamicDomain := string(fields[0]), string(fields[1])
+	server := host
+	if len(dynamicDomain) > 0 {
+		server = dynamicDomain
+	}
+	if len(server) == 0 || req.Port == 0 {
+		return &pb.SwitchPublicResp{Code: code_invalid_argument, ErrMsg: "host or dynamic domain and port are required"}, nil
+	}
+	if err = self.ping(server, req.Port, req.NodeId); err != nil {
+		return &pb.SwitchPublicResp{Code: code_ping_failed, ErrMsg: err.Error()}, nil
+	}
+	if err = self.registry.UpdateProvider(req.NodeId, func(p *registry.Provider) error {
+		p.Private, p.Host, p.Port, p.DynamicDomain, p.RelayNodeId = false, host, req.Port, dynamicDomain, nil
+		return nil
+	}); err != nil {
+		return &pb.SwitchPublicResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	return &pb.SwitchPublicResp{}, nil
+}
+
+func (self *ProviderRegisterService) PrivateAlive(ctx context.Context, req *pb.PrivateAliveReq) (*pb.PrivateAliveResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	if err := self.registry.UpdateProvider(req.NodeId, func(p *registry.Provider) error {
+		p.Total, p.MaxFileSize, p.LastSeen = req.Total, req.MaxFileSize, now()
+		return nil
+	}); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.PrivateAliveResp{}, nil
+}
+
+func (self *ProviderRegisterService) OfferRelay(ctx context.Context, req *pb.OfferRelayReq) (*pb.OfferRelayResp, error) {
+	pro, err := self.verify(req.NodeId, req.Timestamp, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Port > 0 && (pro.Private || len(pro.RelayNodeId) > 0) {
+		return nil, status.Error(codes.FailedPrecondition, "only public provider can offer relay")
+	}
+	if err = self.registry.UpdateProvider(req.NodeId, func(p *registry.Provider) error {
+		p.RelayPort, p.RelayCapacity = req.Port, req.Capacity
+		return nil
+	}); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.OfferRelayResp{}, nil
+}
+
+func (self *ProviderRegisterService) GetRelayServer(ctx context.Context, req *pb.GetRelayServerReq) (*pb.GetRelayServerResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	// grant is given even if no relay is listed, provider may use a configured relay
+	grant, err := relay.SignGrant(self.key.PriKey, req.NodeId, uint64(time.Now().Add(relay_grant_ttl).Unix()))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &pb.GetRelayServerResp{GrantExpire: grant.Expire, Grant: grant.Sign}
+	all, err := self.registry.Providers()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var relays []*registry.Provider
+	for _, p := range all {
+		if p.RelayPort > 0 && p.Reachable() && string(p.NodeId) != string(req.NodeId) {
+			relays = append(relays, p)
+		}
+	}
+	if len(relays) == 0 {
+		return resp, nil
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(relays))))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	r := relays[n.Int64()]
+	resp.RelayNodeId, resp.Server = r.NodeId, net.JoinHostPort(r.Server(), strconv.Itoa(int(r.RelayPort)))
+	return resp, nil
+}
+
+func (self *ProviderRegisterService) SwitchRelay(ctx context.Context, req *pb.SwitchRelayReq) (*pb.SwitchRelayResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return &pb.SwitchRelayResp{Code: code_auth_failed, ErrMsg: status.Convert(err).Message()}, nil
+	}
+	relay, err := self.registry.Provider(req.RelayNodeId)
+	if err != nil {
+		return &pb.SwitchRelayResp{Code: code_invalid_argument, ErrMsg: fmt.Sprintf("relay %x: %s", req.RelayNodeId, err)}, nil
+	}
+	if relay.RelayPort == 0 {
+		return &pb.SwitchRelayResp{Code: code_invalid_argument, ErrMsg: fmt.Sprintf("provider %x does not offer relay", req.RelayNodeId)}, nil
+	}
+	if len(req.Host) == 0 || req.Port == 0 {
+		return &pb.SwitchRelayResp{Code: code_invalid_argument, ErrMsg: "host and port are required"}, nil
+	}
+	if err = self.ping(req.Host, req.Port, req.NodeId); err != nil {
+		return &pb.SwitchRelayResp{Code: code_ping_failed, ErrMsg: err.Error()}, nil
+	}
+	if err = self.registry.UpdateProvider(req.NodeId, func(p *registry.Provider) error {
+		p.Host, p.Port, p.DynamicDomain, p.RelayNodeId = req.Host, req.Port, "", req.RelayNodeId
+		return nil
+	}); err != nil {
+		return &pb.SwitchRelayResp{Code: code_system_error, ErrMsg: err.Error()}, nil
+	}
+	return &pb.SwitchRelayResp{}, nil
+}
@@ -0,0 +1,250 @@
+package impl
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samoslab/nebula/provider/relay"
+	"github.com/samoslab/nebula/tracker/config"
+	"github.com/samoslab/nebula/tracker/register/mailer"
+	pb "github.com/samoslab/nebula/tracker/register/provider/pb"
+	"github.com/samoslab/nebula/tracker/registry"
+	"github.com/samoslab/nebula/tracker/testutil"
+	util_rsa "github.com/samoslab/nebula/util/rsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/peer"
+)
+
+type testEnv struct {
+	prs      *ProviderRegisterService
+	reg      *registry.Registry
+	key      *config.Key
+	mailFile string
+	pinged   []string
+	pingErr  error
+}
+
+func newTestEnv(t *testing.T) (*testEnv, func()) {
+	tr := testutil.NewTracker(t)
+	env := &testEnv{reg: tr.Registry, key: tr.Key, mailFile: filepath.Join(tr.Dir, "mail.txt")}
+	cfg := DefaultConfig()
+	cfg.Mailer = mailer.NewFileMailer(env.mailFile)
+	cfg.TrackerServers = []config.Server{{Host: "tracker.example.com", Port: 6677}}
+	cfg.CollectorServers = []config.Server{{Host: "collector.example.com", Port: 6688}}
+	cfg.Ping = func(address string, nodeIdHash []byte) error {
+		env.pinged = append(env.pinged, address)
+		return env.pingErr
+	}
+	env.prs = NewProviderRegisterService(env.reg, env.key, cfg)
+	return env, tr.Close
+}
+
+func (self *testEnv) encrypt(t *testing.T, data string) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	res, err := util_rsa.EncryptLong(&self.key.PriKey.PublicKey, []byte(data), self.key.PriKey.Size())
+	require.NoError(t, err)
+	return res
+}
+
+// lastVerifyCode read verify code from the last mail
+func (self *testEnv) lastVerifyCode(t *testing.T) string {
+	data, err := ioutil.ReadFile(self.mailFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	content := lines[len(lines)-1]
+	return content[strings.LastIndex(content, " ")+1:]
+}
+
+func (self *testEnv) registerReq(t *testing.T, pro *testutil.Node, host string, port uint32, private bool) *pb.RegisterReq {
+	req := &pb.RegisterReq{Timestamp: uint64(time.Now().Unix()),
+		PublicKeyHash:     self.key.PubKeyHash,
+		NodeIdEnc:         self.encrypt(t, string(pro.NodeId)),
+		PublicKeyEnc:      self.encrypt(t, string(pro.PubKey)),
+		EncryptKeyEnc:     self.encrypt(t, "0123456789abcdef"),
+		WalletAddressEnc:  self.encrypt(t, "wallet"),
+		BillEmailEnc:      self.encrypt(t, "provider@example.com"),
+		MainStorageVolume: 1 << 30,
+		Availability:      0.98,
+		Port:              port,
+		HostEnc:           self.encrypt(t, host),
+		ConfirmInner:      private}
+	require.NoError(t, req.SignReq(pro.PriKey))
+	return req
+}
+
+func TestRegisterPublic(t *testing.T) {
+	env, clean := newTestEnv(t)
+	defer clean()
+	ctx := context.Background()
+	pro := testutil.NewNode(t)
+
+	expired := env.registerReq(t, pro, "10.0.0.1", 6666, false)
+	expired.PublicKeyHash = []byte("old")
+	resp, err := env.prs.Register(ctx, expired)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_public_key_expired), resp.Code)
+
+	env.pingErr = errors.New("connection refused")
+	resp, err = env.prs.Register(ctx, env.registerReq(t, pro, "10.0.0.1", 6666, false))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_ping_failed), resp.Code)
+	assert.Equal(t, []string{"10.0.0.1:6666"}, env.pinged)
+
+	env.pingErr = nil
+	resp, err = env.prs.Register(ctx, env.registerReq(t, pro, "10.0.0.1", 6666, false))
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), resp.Code, resp.ErrMsg)
+	p, err := env.reg.Provider(pro.NodeId)
+	require.NoError(t, err)
+	assert.True(t, p.Reachable())
+	assert.Equal(t, "provider@example.com", p.BillEmail)
+	assert.Equal(t, "wallet", p.WalletAddress)
+	assert.Equal(t, []byte("0123456789abcdef"), p.EncryptKey)
+	assert.False(t, p.EmailVerified)
+
+	resp, err = env.prs.Register(ctx, env.registerReq(t, pro, "10.0.0.1", 6666, false))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_registered), resp.Code)
+
+	wrong := &pb.VerifyBillEmailReq{NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix()), VerifyCode: "wrong"}
+	require.NoError(t, wrong.SignReq(pro.PriKey))
+	vResp, err := env.prs.VerifyBillEmail(ctx, wrong)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(code_verify_code_wrong), vResp.Code)
+
+	verify := &pb.VerifyBillEmailReq{NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix()), VerifyCode: env.lastVerifyCode(t)}
+	require.NoError(t, verify.SignReq(pro.PriKey))
+	vResp, err = env.prs.VerifyBillEmail(ctx, verify)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), vResp.Code, vResp.ErrMsg)
+	p, err = env.reg.Provider(pro.NodeId)
+	require.NoError(t, err)
+	assert.True(t, p.EmailVerified)
+
+	resend := &pb.ResendVerifyCodeReq{NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, resend.SignReq(pro.PriKey))
+	rResp, err := env.prs.ResendVerifyCode(ctx, resend)
+	require.NoError(t, err)
+	assert.False(t, rResp.Success)
+
+	ts := &pb.GetTrackerServerReq{NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, ts.SignReq(pro.PriKey))
+	tsResp, err := env.prs.GetTrackerServer(ctx, ts)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tsResp.Server))
+	assert.Equal(t, "tracker.example.com", tsResp.Server[0].Server)
+	cs := &pb.GetCollectorServerReq{NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, cs.SignReq(pro.PriKey))
+	csResp, err := env.prs.GetCollectorServer(ctx, cs)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(csResp.Server))
+	assert.Equal(t, uint32(6688), csResp.Server[0].Port)
+
+	refresh := &pb.RefreshIpReq{NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix()), Port: 7777}
+	require.NoError(t, refresh.SignReq(pro.PriKey))
+	peerCtx := peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 50000}})
+	ipResp, err := env.prs.RefreshIp(peerCtx, refresh)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3.4", ipResp.Ip)
+	p, err = env.reg.Provider(pro.NodeId)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3.4", p.Host)
+	assert.Equal(t, uint32(7777), p.Port)
+}
+
+func TestSwitchPrivateAndPublic(t *testing.T) {
+	env, clean := newTestEnv(t)
+	defer clean()
+	ctx := context.Background()
+	pro := testutil.NewNode(t)
+
+	resp, err := env.prs.Register(ctx, env.registerReq(t, pro, "", 0, true))
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), resp.Code, resp.ErrMsg)
+	assert.Empty(t, env.pinged)
+	p, err := env.reg.Provider(pro.NodeId)
+	require.NoError(t, err)
+	assert.True(t, p.Private)
+	assert.False(t, p.Reachable())
+
+	alive := &pb.PrivateAliveReq{Version: 1, NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix()), Total: 1 << 30, MaxFileSize: 1 << 20}
+	require.NoError(t, alive.SignReq(pro.PriKey))
+	_, err = env.prs.PrivateAlive(ctx, alive)
+	require.NoError(t, err)
+	p, err = env.reg.Provider(pro.NodeId)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1<<30), p.Total)
+	assert.Equal(t, uint64(1<<20), p.MaxFileSize)
+
+	// private provider keeps no address when refreshing ip
+	refresh := &pb.RefreshIpReq{NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix()), Port: 6666}
+	require.NoError(t, refresh.SignReq(pro.PriKey))
+	_, err = env.prs.RefreshIp(peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 50000}}), refresh)
+	require.NoError(t, err)
+	p, err = env.reg.Provider(pro.NodeId)
+	require.NoError(t, err)
+	assert.False(t, p.Reachable())
+
+	public := &pb.SwitchPublicReq{NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix()), PublicKeyHash: env.key.PubKeyHash,
+		Port: 6666, DynamicDomainEnc: env.encrypt(t, "pro.example.com")}
+	require.NoError(t, public.SignReq(pro.PriKey))
+	sResp, err := env.prs.SwitchPublic(ctx, public)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), sResp.Code, sResp.ErrMsg)
+	assert.Equal(t, []string{"pro.example.com:6666"}, env.pinged)
+	p, err = env.reg.Provider(pro.NodeId)
+	require.NoError(t, err)
+	assert.False(t, p.Private)
+	assert.True(t, p.Reachable())
+	assert.Equal(t, "pro.example.com", p.Server())
+
+	private := &pb.SwitchPrivateReq{NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, private.SignReq(pro.PriKey))
+	pResp, err := env.prs.SwitchPrivate(ctx, private)
+	require.NoError(t, err)
+	assert.True(t, pResp.Success)
+	p, err = env.reg.Provider(pro.NodeId)
+	require.NoError(t, err)
+	assert.True(t, p.Private)
+	assert.False(t, p.Reachable())
+
+	stale := &pb.SwitchPrivateReq{NodeId: pro.NodeId, Timestamp: uint64(time.Now().Add(-time.Hour).Unix())}
+	require.NoError(t, stale.SignReq(pro.PriKey))
+	_, err = env.prs.SwitchPrivate(ctx, stale)
+	assert.Error(t, err)
+}
+
+func TestRelayGrant(t *testing.T) {
+	env, clean := newTestEnv(t)
+	defer clean()
+	ctx := context.Background()
+	pro := testutil.NewNode(t)
+	resp, err := env.prs.Register(ctx, env.registerReq(t, pro, "", 0, true))
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), resp.Code, resp.ErrMsg)
+
+	req := &pb.GetRelayServerReq{Version: 1, NodeId: pro.NodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, req.SignReq(pro.PriKey))
+	gResp, err := env.prs.GetRelayServer(ctx, req)
+	require.NoError(t, err)
+	assert.Empty(t, gResp.Server, "no relay is offered")
+	grant := &relay.Grant{Expire: gResp.GrantExpire, Sign: gResp.Grant}
+	assert.NoError(t, relay.VerifyGrant(&env.key.PriKey.PublicKey, pro.NodeId, grant))
+	assert.Equal(t, relay.AuthFailedErr, relay.VerifyGrant(&env.key.PriKey.PublicKey, testutil.NewNode(t).NodeId, grant))
+
+	// node not registered gets no grant
+	other := testutil.NewNode(t)
+	req = &pb.GetRelayServerReq{Version: 1, NodeId: other.NodeId, Timestamp: uint64(time.Now().Unix())}
+	require.NoError(t, req.SignReq(other.PriKey))
+	_, err = env.prs.GetRelayServer(ctx, req)
+	assert.Error(t, err)
+}
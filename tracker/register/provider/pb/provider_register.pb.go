@@ -32,6 +32,12 @@ It has these top-level messages:
 	SwitchPublicResp
 	PrivateAliveReq
 	PrivateAliveResp
+	OfferRelayReq
+	OfferRelayResp
+	GetRelayServerReq
+	GetRelayServerResp
+	SwitchRelayReq
+	SwitchRelayResp
 */
 package register_provider_pb
 
@@ -927,6 +933,240 @@ func (m *PrivateAliveResp) String() string            { return proto.CompactText
 func (*PrivateAliveResp) ProtoMessage()               {}
 func (*PrivateAliveResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
 
+// reachable provider offers relay for NATed providers
+type OfferRelayReq struct {
+	Version   uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	NodeId    []byte `protobuf:"bytes,2,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Timestamp uint64 `protobuf:"varint,3,opt,name=timestamp" json:"timestamp,omitempty"`
+	Sign      []byte `protobuf:"bytes,4,opt,name=sign,proto3" json:"sign,omitempty"`
+	Port      uint32 `protobuf:"varint,5,opt,name=port" json:"port,omitempty"`
+	Capacity  uint32 `protobuf:"varint,6,opt,name=capacity" json:"capacity,omitempty"`
+}
+
+func (m *OfferRelayReq) Reset()                    { *m = OfferRelayReq{} }
+func (m *OfferRelayReq) String() string            { return proto.CompactTextString(m) }
+func (*OfferRelayReq) ProtoMessage()               {}
+func (*OfferRelayReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *OfferRelayReq) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *OfferRelayReq) GetNodeId() []byte {
+	if m != nil {
+		return m.NodeId
+	}
+	return nil
+}
+
+func (m *OfferRelayReq) GetTimestamp() uint64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *OfferRelayReq) GetSign() []byte {
+	if m != nil {
+		return m.Sign
+	}
+	return nil
+}
+
+func (m *OfferRelayReq) GetPort() uint32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *OfferRelayReq) GetCapacity() uint32 {
+	if m != nil {
+		return m.Capacity
+	}
+	return 0
+}
+
+type OfferRelayResp struct {
+}
+
+func (m *OfferRelayResp) Reset()                    { *m = OfferRelayResp{} }
+func (m *OfferRelayResp) String() string            { return proto.CompactTextString(m) }
+func (*OfferRelayResp) ProtoMessage()               {}
+func (*OfferRelayResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+type GetRelayServerReq struct {
+	Version   uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	NodeId    []byte `protobuf:"bytes,2,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Timestamp uint64 `protobuf:"varint,3,opt,name=timestamp" json:"timestamp,omitempty"`
+	Sign      []byte `protobuf:"bytes,4,opt,name=sign,proto3" json:"sign,omitempty"`
+}
+
+func (m *GetRelayServerReq) Reset()                    { *m = GetRelayServerReq{} }
+func (m *GetRelayServerReq) String() string            { return proto.CompactTextString(m) }
+func (*GetRelayServerReq) ProtoMessage()               {}
+func (*GetRelayServerReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *GetRelayServerReq) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *GetRelayServerReq) GetNodeId() []byte {
+	if m != nil {
+		return m.NodeId
+	}
+	return nil
+}
+
+func (m *GetRelayServerReq) GetTimestamp() uint64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *GetRelayServerReq) GetSign() []byte {
+	if m != nil {
+		return m.Sign
+	}
+	return nil
+}
+
+type GetRelayServerResp struct {
+	RelayNodeId []byte `protobuf:"bytes,1,opt,name=relayNodeId,proto3" json:"relayNodeId,omitempty"`
+	Server      string `protobuf:"bytes,2,opt,name=server" json:"server,omitempty"`
+	GrantExpire uint64 `protobuf:"varint,3,opt,name=grantExpire" json:"grantExpire,omitempty"`
+	Grant       []byte `protobuf:"bytes,4,opt,name=grant,proto3" json:"grant,omitempty"`
+}
+
+func (m *GetRelayServerResp) Reset()                    { *m = GetRelayServerResp{} }
+func (m *GetRelayServerResp) String() string            { return proto.CompactTextString(m) }
+func (*GetRelayServerResp) ProtoMessage()               {}
+func (*GetRelayServerResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *GetRelayServerResp) GetRelayNodeId() []byte {
+	if m != nil {
+		return m.RelayNodeId
+	}
+	return nil
+}
+
+func (m *GetRelayServerResp) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *GetRelayServerResp) GetGrantExpire() uint64 {
+	if m != nil {
+		return m.GrantExpire
+	}
+	return 0
+}
+
+func (m *GetRelayServerResp) GetGrant() []byte {
+	if m != nil {
+		return m.Grant
+	}
+	return nil
+}
+
+// NATed provider reports the public address allocated by relay, tracker hands it out as provider address
+type SwitchRelayReq struct {
+	Version     uint32 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	NodeId      []byte `protobuf:"bytes,2,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Timestamp   uint64 `protobuf:"varint,3,opt,name=timestamp" json:"timestamp,omitempty"`
+	Sign        []byte `protobuf:"bytes,4,opt,name=sign,proto3" json:"sign,omitempty"`
+	RelayNodeId []byte `protobuf:"bytes,5,opt,name=relayNodeId,proto3" json:"relayNodeId,omitempty"`
+	Host        string `protobuf:"bytes,6,opt,name=host" json:"host,omitempty"`
+	Port        uint32 `protobuf:"varint,7,opt,name=port" json:"port,omitempty"`
+}
+
+func (m *SwitchRelayReq) Reset()                    { *m = SwitchRelayReq{} }
+func (m *SwitchRelayReq) String() string            { return proto.CompactTextString(m) }
+func (*SwitchRelayReq) ProtoMessage()               {}
+func (*SwitchRelayReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *SwitchRelayReq) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *SwitchRelayReq) GetNodeId() []byte {
+	if m != nil {
+		return m.NodeId
+	}
+	return nil
+}
+
+func (m *SwitchRelayReq) GetTimestamp() uint64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *SwitchRelayReq) GetSign() []byte {
+	if m != nil {
+		return m.Sign
+	}
+	return nil
+}
+
+func (m *SwitchRelayReq) GetRelayNodeId() []byte {
+	if m != nil {
+		return m.RelayNodeId
+	}
+	return nil
+}
+
+func (m *SwitchRelayReq) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *SwitchRelayReq) GetPort() uint32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+type SwitchRelayResp struct {
+	Code   uint32 `protobuf:"varint,1,opt,name=code" json:"code,omitempty"`
+	ErrMsg string `protobuf:"bytes,2,opt,name=errMsg" json:"errMsg,omitempty"`
+}
+
+func (m *SwitchRelayResp) Reset()                    { *m = SwitchRelayResp{} }
+func (m *SwitchRelayResp) String() string            { return proto.CompactTextString(m) }
+func (*SwitchRelayResp) ProtoMessage()               {}
+func (*SwitchRelayResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *SwitchRelayResp) GetCode() uint32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *SwitchRelayResp) GetErrMsg() string {
+	if m != nil {
+		return m.ErrMsg
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*GetPublicKeyReq)(nil), "register_provider_pb.GetPublicKeyReq")
 	proto.RegisterType((*GetPublicKeyResp)(nil), "register_provider_pb.GetPublicKeyResp")
@@ -952,6 +1192,12 @@ func init() {
 	proto.RegisterType((*SwitchPublicResp)(nil), "register_provider_pb.SwitchPublicResp")
 	proto.RegisterType((*PrivateAliveReq)(nil), "register_provider_pb.PrivateAliveReq")
 	proto.RegisterType((*PrivateAliveResp)(nil), "register_provider_pb.PrivateAliveResp")
+	proto.RegisterType((*OfferRelayReq)(nil), "register_provider_pb.OfferRelayReq")
+	proto.RegisterType((*OfferRelayResp)(nil), "register_provider_pb.OfferRelayResp")
+	proto.RegisterType((*GetRelayServerReq)(nil), "register_provider_pb.GetRelayServerReq")
+	proto.RegisterType((*GetRelayServerResp)(nil), "register_provider_pb.GetRelayServerResp")
+	proto.RegisterType((*SwitchRelayReq)(nil), "register_provider_pb.SwitchRelayReq")
+	proto.RegisterType((*SwitchRelayResp)(nil), "register_provider_pb.SwitchRelayResp")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -976,6 +1222,9 @@ type ProviderRegisterServiceClient interface {
 	SwitchPrivate(ctx context.Context, in *SwitchPrivateReq, opts ...grpc.CallOption) (*SwitchPrivateResp, error)
 	SwitchPublic(ctx context.Context, in *SwitchPublicReq, opts ...grpc.CallOption) (*SwitchPublicResp, error)
 	PrivateAlive(ctx context.Context, in *PrivateAliveReq, opts ...grpc.CallOption) (*PrivateAliveResp, error)
+	OfferRelay(ctx context.Context, in *OfferRelayReq, opts ...grpc.CallOption) (*OfferRelayResp, error)
+	GetRelayServer(ctx context.Context, in *GetRelayServerReq, opts ...grpc.CallOption) (*GetRelayServerResp, error)
+	SwitchRelay(ctx context.Context, in *SwitchRelayReq, opts ...grpc.CallOption) (*SwitchRelayResp, error)
 }
 
 type providerRegisterServiceClient struct {
@@ -1085,6 +1334,33 @@ func (c *providerRegisterServiceClient) PrivateAlive(ctx context.Context, in *Pr
 	return out, nil
 }
 
+func (c *providerRegisterServiceClient) OfferRelay(ctx context.Context, in *OfferRelayReq, opts ...grpc.CallOption) (*OfferRelayResp, error) {
+	out := new(OfferRelayResp)
+	err := grpc.Invoke(ctx, "/register_provider_pb.ProviderRegisterService/OfferRelay", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerRegisterServiceClient) GetRelayServer(ctx context.Context, in *GetRelayServerReq, opts ...grpc.CallOption) (*GetRelayServerResp, error) {
+	out := new(GetRelayServerResp)
+	err := grpc.Invoke(ctx, "/register_provider_pb.ProviderRegisterService/GetRelayServer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerRegisterServiceClient) SwitchRelay(ctx context.Context, in *SwitchRelayReq, opts ...grpc.CallOption) (*SwitchRelayResp, error) {
+	out := new(SwitchRelayResp)
+	err := grpc.Invoke(ctx, "/register_provider_pb.ProviderRegisterService/SwitchRelay", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for ProviderRegisterService service
 
 type ProviderRegisterServiceServer interface {
@@ -1099,6 +1375,9 @@ type ProviderRegisterServiceServer interface {
 	SwitchPrivate(context.Context, *SwitchPrivateReq) (*SwitchPrivateResp, error)
 	SwitchPublic(context.Context, *SwitchPublicReq) (*SwitchPublicResp, error)
 	PrivateAlive(context.Context, *PrivateAliveReq) (*PrivateAliveResp, error)
+	OfferRelay(context.Context, *OfferRelayReq) (*OfferRelayResp, error)
+	GetRelayServer(context.Context, *GetRelayServerReq) (*GetRelayServerResp, error)
+	SwitchRelay(context.Context, *SwitchRelayReq) (*SwitchRelayResp, error)
 }
 
 func RegisterProviderRegisterServiceServer(s *grpc.Server, srv ProviderRegisterServiceServer) {
@@ -1303,6 +1582,60 @@ func _ProviderRegisterService_PrivateAlive_Handler(srv interface{}, ctx context.
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProviderRegisterService_OfferRelay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OfferRelayReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderRegisterServiceServer).OfferRelay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/register_provider_pb.ProviderRegisterService/OfferRelay",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderRegisterServiceServer).OfferRelay(ctx, req.(*OfferRelayReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProviderRegisterService_GetRelayServer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRelayServerReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderRegisterServiceServer).GetRelayServer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/register_provider_pb.ProviderRegisterService/GetRelayServer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderRegisterServiceServer).GetRelayServer(ctx, req.(*GetRelayServerReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProviderRegisterService_SwitchRelay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SwitchRelayReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderRegisterServiceServer).SwitchRelay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/register_provider_pb.ProviderRegisterService/SwitchRelay",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderRegisterServiceServer).SwitchRelay(ctx, req.(*SwitchRelayReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ProviderRegisterService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "register_provider_pb.ProviderRegisterService",
 	HandlerType: (*ProviderRegisterServiceServer)(nil),
@@ -1351,6 +1684,18 @@ var _ProviderRegisterService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PrivateAlive",
 			Handler:    _ProviderRegisterService_PrivateAlive_Handler,
 		},
+		{
+			MethodName: "OfferRelay",
+			Handler:    _ProviderRegisterService_OfferRelay_Handler,
+		},
+		{
+			MethodName: "GetRelayServer",
+			Handler:    _ProviderRegisterService_GetRelayServer_Handler,
+		},
+		{
+			MethodName: "SwitchRelay",
+			Handler:    _ProviderRegisterService_SwitchRelay_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "provider_register.proto",
@@ -1359,72 +1704,83 @@ var _ProviderRegisterService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("provider_register.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1065 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x57, 0x4b, 0x6f, 0x23, 0x45,
-	0x10, 0xde, 0x49, 0xc6, 0x8e, 0x5d, 0xb1, 0xd7, 0x4e, 0xdb, 0x64, 0x47, 0x16, 0x02, 0x33, 0x0b,
-	0x8b, 0xf7, 0x41, 0x40, 0xcb, 0x8d, 0xd5, 0x22, 0x65, 0x77, 0x43, 0x88, 0x10, 0x52, 0x34, 0x86,
-	0x70, 0x8c, 0xc6, 0x33, 0x9d, 0xb8, 0xb5, 0xe3, 0x99, 0xde, 0xee, 0xce, 0x64, 0x0d, 0x77, 0xce,
-	0x1c, 0xf9, 0x13, 0xfc, 0x1e, 0xfe, 0x05, 0x37, 0xee, 0xa8, 0x7b, 0x1e, 0x9e, 0x67, 0xe2, 0x48,
-	0x24, 0x37, 0x57, 0xf5, 0x37, 0x5d, 0x8f, 0xae, 0xaa, 0xaf, 0x0c, 0x0f, 0x28, 0x0b, 0x42, 0xe2,
-	0x62, 0x76, 0xca, 0xf0, 0x39, 0xe1, 0x02, 0xb3, 0x3d, 0xca, 0x02, 0x11, 0xa0, 0x61, 0x22, 0x9f,
-	0xa6, 0x08, 0x3a, 0x33, 0x9f, 0x42, 0xef, 0x10, 0x8b, 0xe3, 0x8b, 0x99, 0x47, 0x9c, 0x1f, 0xf0,
-	0xd2, 0xc2, 0xef, 0x90, 0x01, 0x5b, 0x21, 0x66, 0x9c, 0x04, 0xbe, 0xa1, 0x8d, 0xb5, 0x49, 0xd7,
-	0x4a, 0x44, 0xf3, 0x0c, 0xfa, 0x79, 0x30, 0xa7, 0xe8, 0x43, 0x68, 0xd3, 0x44, 0xa1, 0xf0, 0x1d,
-	0x6b, 0xa5, 0x40, 0x9f, 0x42, 0x37, 0x15, 0xbe, 0xb7, 0xf9, 0xdc, 0xd8, 0x50, 0x88, 0xbc, 0x12,
-	0xdd, 0x87, 0x0d, 0x42, 0x8d, 0xcd, 0xb1, 0x36, 0x69, 0x5b, 0x1b, 0x84, 0x9a, 0x7f, 0x37, 0x60,
-	0xdb, 0x8a, 0xbd, 0xbd, 0xd2, 0x23, 0x69, 0x5d, 0x90, 0x05, 0xe6, 0xc2, 0x5e, 0x50, 0x75, 0xb7,
-	0x6e, 0xad, 0x14, 0xf2, 0xd4, 0x0f, 0x5c, 0x7c, 0xe4, 0x1e, 0xf8, 0x8e, 0xba, 0xbe, 0x63, 0xad,
-	0x14, 0xc8, 0x84, 0x4e, 0xea, 0x86, 0x04, 0xe8, 0x0a, 0x90, 0xd3, 0x49, 0xff, 0xb1, 0xef, 0xb0,
-	0x25, 0x15, 0x31, 0xa8, 0x11, 0xf9, 0x9f, 0x53, 0xa2, 0x27, 0xd0, 0xbf, 0xb4, 0x3d, 0x0f, 0x8b,
-	0x7d, 0xd7, 0x65, 0x98, 0x73, 0x09, 0x6c, 0x2a, 0x60, 0x49, 0x2f, 0xad, 0xce, 0x88, 0xe7, 0x1d,
-	0x2c, 0x6c, 0xe2, 0x49, 0xdc, 0x56, 0x64, 0x35, 0xab, 0x43, 0xcf, 0x60, 0x67, 0x61, 0x13, 0x7f,
-	0x2a, 0x02, 0x66, 0x9f, 0xe3, 0x93, 0xc0, 0xbb, 0x58, 0x60, 0xa3, 0xa5, 0xa2, 0x2b, 0x1f, 0xa0,
-	0x31, 0x6c, 0x5f, 0xd0, 0x57, 0xb6, 0xef, 0x5e, 0x12, 0x57, 0xcc, 0x8d, 0xb6, 0xc2, 0x65, 0x55,
-	0x32, 0x0a, 0x37, 0xb8, 0xf4, 0x57, 0x18, 0x50, 0x98, 0xbc, 0x12, 0x4d, 0xa0, 0x27, 0x30, 0x17,
-	0x3f, 0x67, 0xee, 0xda, 0x56, 0xb8, 0xa2, 0x5a, 0xfa, 0x27, 0x55, 0x6f, 0x72, 0x77, 0x76, 0x22,
-	0xff, 0x4a, 0x07, 0x32, 0x62, 0x3b, 0xb4, 0x89, 0x67, 0xcf, 0x88, 0x47, 0xc4, 0xd2, 0xe8, 0x8e,
-	0xb5, 0x89, 0x66, 0xe5, 0x74, 0x08, 0x81, 0x4e, 0x03, 0x26, 0x8c, 0xfb, 0xea, 0x79, 0xd5, 0x6f,
-	0xf9, 0xea, 0xf3, 0x80, 0x0b, 0x99, 0xa4, 0x9e, 0x4a, 0x52, 0x22, 0xca, 0x7c, 0xbb, 0x4b, 0xdf,
-	0x5e, 0x10, 0xe7, 0x4d, 0x20, 0xf3, 0x21, 0x21, 0xfd, 0x28, 0xdf, 0x45, 0x3d, 0xda, 0x03, 0x84,
-	0xdf, 0x0b, 0x66, 0xe7, 0x93, 0xb9, 0x33, 0xde, 0x9c, 0xe8, 0x56, 0xc5, 0x49, 0xb9, 0x62, 0x51,
-	0x55, 0xc5, 0x22, 0xd0, 0x39, 0x39, 0xf7, 0x8d, 0x81, 0x3a, 0x54, 0xbf, 0x65, 0x9c, 0x4e, 0xe0,
-	0x9f, 0x11, 0xb6, 0x38, 0xf2, 0x7d, 0xcc, 0x8c, 0xe1, 0x58, 0x9b, 0xb4, 0xac, 0x9c, 0xce, 0xfc,
-	0x06, 0x3a, 0xab, 0xc2, 0xe6, 0x54, 0xde, 0xe3, 0x04, 0x2e, 0x8e, 0xcb, 0x5a, 0xfd, 0x46, 0xbb,
-	0xd0, 0xc4, 0x8c, 0xfd, 0xc8, 0xcf, 0x55, 0x41, 0xb7, 0xad, 0x58, 0x32, 0xff, 0xd4, 0x00, 0x9d,
-	0x60, 0x46, 0xce, 0x96, 0xaf, 0x92, 0x62, 0xb9, 0xba, 0x39, 0x76, 0xa1, 0x19, 0x55, 0x7b, 0xdc,
-	0x75, 0xb1, 0x94, 0x6f, 0x9a, 0xcd, 0x62, 0xd3, 0x7c, 0x04, 0x10, 0x2a, 0x2b, 0xaf, 0xa5, 0x63,
-	0xba, 0x72, 0x21, 0xa3, 0x49, 0x43, 0x6f, 0xac, 0x42, 0x37, 0xf7, 0x61, 0x50, 0xf2, 0xec, 0x86,
-	0xd1, 0x2d, 0x61, 0x60, 0x61, 0x8e, 0x7d, 0xf7, 0x24, 0x35, 0x75, 0x1b, 0xd1, 0x25, 0xde, 0xeb,
-	0x19, 0xef, 0xbf, 0x82, 0x61, 0xd9, 0x34, 0xa7, 0xd2, 0x36, 0xbf, 0x70, 0x1c, 0xcc, 0xb9, 0xb2,
-	0xdd, 0xb2, 0x12, 0xd1, 0xfc, 0x43, 0x03, 0xb4, 0xef, 0xba, 0x07, 0x99, 0xf2, 0xb9, 0x0d, 0x67,
-	0x77, 0xa1, 0x19, 0x46, 0xf5, 0xaa, 0xab, 0xa3, 0x58, 0xaa, 0x7c, 0x82, 0x2f, 0x61, 0x50, 0xf2,
-	0xe8, 0xca, 0x18, 0x96, 0x30, 0x38, 0xc4, 0xe2, 0x27, 0x66, 0x3b, 0x6f, 0x31, 0x9b, 0x62, 0x16,
-	0x5e, 0x37, 0x6b, 0xff, 0xbf, 0x84, 0x4f, 0x61, 0x58, 0x36, 0xcd, 0x29, 0x7a, 0x01, 0x4d, 0xae,
-	0x24, 0x43, 0x1b, 0x6f, 0x4e, 0xb6, 0x9f, 0x3f, 0xdc, 0xab, 0xe2, 0xac, 0xbd, 0xfc, 0x87, 0xf1,
-	0x27, 0xe6, 0x0b, 0xe8, 0xe6, 0x0e, 0xa4, 0xbf, 0xe9, 0x6d, 0xaa, 0xd2, 0x22, 0x29, 0x9d, 0x35,
-	0x1b, 0xab, 0x59, 0x63, 0xfe, 0x06, 0x1f, 0x1c, 0x62, 0xf1, 0x3a, 0xf0, 0x3c, 0xec, 0x88, 0xe0,
-	0x8e, 0xd3, 0xf1, 0x0b, 0xec, 0x56, 0x19, 0xe7, 0x14, 0xbd, 0x2c, 0x24, 0xe4, 0xb3, 0xea, 0x84,
-	0x14, 0x3f, 0x4d, 0x52, 0xf2, 0x12, 0x7a, 0x85, 0xa3, 0x1b, 0x25, 0xe5, 0x77, 0x4d, 0x4e, 0xab,
-	0x33, 0x86, 0xf9, 0xfc, 0x88, 0xde, 0x52, 0x32, 0x94, 0x51, 0x3d, 0x33, 0xf5, 0xab, 0x6a, 0xfb,
-	0x63, 0xe8, 0x66, 0xfc, 0xe0, 0x34, 0x5e, 0x18, 0xb4, 0x74, 0x61, 0x08, 0xa1, 0x3f, 0xbd, 0x24,
-	0xc2, 0x99, 0x1f, 0x33, 0x12, 0xda, 0xe2, 0xce, 0x26, 0xc7, 0x17, 0xb0, 0x53, 0xb0, 0x7b, 0x65,
-	0xcb, 0xfd, 0xab, 0x41, 0x2f, 0xc6, 0x2b, 0x36, 0xb9, 0x23, 0x37, 0xcb, 0x9c, 0xd6, 0xa8, 0xe1,
-	0x34, 0xf5, 0x1a, 0xcd, 0x6a, 0x0e, 0xde, 0xba, 0x9e, 0x83, 0x5b, 0xd5, 0x1c, 0x6c, 0x7e, 0x9b,
-	0x3e, 0x4f, 0x1c, 0xf6, 0x0d, 0xb9, 0xe1, 0x2f, 0x0d, 0x7a, 0x71, 0x86, 0xf7, 0x3d, 0x12, 0xde,
-	0xd5, 0xf3, 0xa2, 0x21, 0x34, 0x44, 0x20, 0x6c, 0x4f, 0xe5, 0x4b, 0xb7, 0x22, 0x41, 0xee, 0x5b,
-	0x0b, 0xfb, 0xfd, 0x77, 0xc4, 0xc3, 0x53, 0xf2, 0x2b, 0x56, 0xe9, 0xd2, 0xad, 0xac, 0xca, 0x44,
-	0xd0, 0xcf, 0xbb, 0xcb, 0xe9, 0xf3, 0x7f, 0x5a, 0xf0, 0xe0, 0x38, 0xee, 0xd9, 0x64, 0x05, 0x90,
-	0x3d, 0x49, 0x1c, 0x8c, 0x4e, 0xa1, 0x93, 0xdd, 0xab, 0x51, 0x4d, 0x9b, 0x17, 0x16, 0xf5, 0xd1,
-	0xa3, 0x75, 0x60, 0x9c, 0x9a, 0xf7, 0xd0, 0x14, 0x5a, 0x89, 0x4d, 0xf4, 0x49, 0xf5, 0x57, 0x99,
-	0x7d, 0x7b, 0x64, 0x5e, 0x07, 0x51, 0x97, 0xce, 0xa1, 0x57, 0x20, 0x7d, 0x34, 0xa9, 0xfe, 0xb0,
-	0xbc, 0xb5, 0x8c, 0x1e, 0xaf, 0x89, 0x54, 0x96, 0xde, 0x42, 0xbf, 0x48, 0xd0, 0xe8, 0x71, 0x9d,
-	0x8f, 0xa5, 0x1d, 0x62, 0xf4, 0x64, 0x5d, 0x68, 0x12, 0x56, 0x81, 0x48, 0xeb, 0xc2, 0x2a, 0x6f,
-	0x00, 0x75, 0x61, 0x55, 0x30, 0x73, 0x14, 0x56, 0x91, 0x06, 0xeb, 0xc2, 0xaa, 0x60, 0xea, 0xba,
-	0xb0, 0xaa, 0x98, 0xd5, 0xbc, 0x87, 0xde, 0x01, 0x2a, 0x93, 0x0c, 0x7a, 0x5a, 0x7b, 0x47, 0x99,
-	0x0b, 0x47, 0xcf, 0xd6, 0x07, 0x2b, 0x93, 0x27, 0xd0, 0x4e, 0xc7, 0x36, 0xaa, 0xad, 0xa9, 0x15,
-	0xbf, 0x8c, 0x1e, 0x5e, 0x8b, 0x51, 0xf7, 0xce, 0xa0, 0x9b, 0x9b, 0xba, 0xa8, 0xa6, 0x11, 0x8a,
-	0x94, 0x30, 0xfa, 0x7c, 0x2d, 0x9c, 0xb2, 0x71, 0x0a, 0x9d, 0xec, 0xc8, 0xaa, 0x6b, 0xc9, 0xc2,
-	0x34, 0x1f, 0x3d, 0x5a, 0x07, 0x96, 0x18, 0xc8, 0xce, 0x88, 0x3a, 0x03, 0x85, 0xb1, 0x57, 0x67,
-	0xa0, 0x38, 0x6e, 0xcc, 0x7b, 0xb3, 0xa6, 0xfa, 0xdb, 0xff, 0xf5, 0x7f, 0x01, 0x00, 0x00, 0xff,
-	0xff, 0x89, 0x74, 0x97, 0x28, 0x11, 0x10, 0x00, 0x00,
+	// 1246 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0x4f, 0x6f, 0x1b, 0x45,
+	0x14, 0x67, 0x93, 0xb5, 0x63, 0x3f, 0xdb, 0xb1, 0x33, 0x09, 0xa9, 0xb5, 0x42, 0x60, 0xb6, 0x6d,
+	0xea, 0xa4, 0x25, 0xa0, 0x72, 0xa3, 0x0a, 0x52, 0xda, 0x86, 0x10, 0x21, 0x20, 0x5a, 0x43, 0x10,
+	0x12, 0x52, 0xb4, 0xde, 0x1d, 0xc7, 0xa3, 0xae, 0x77, 0xb7, 0x33, 0x13, 0x27, 0x86, 0x7b, 0x8f,
+	0x88, 0x23, 0x77, 0xce, 0x7c, 0x05, 0xbe, 0x06, 0x9f, 0x84, 0x3b, 0x9a, 0xd9, 0x3f, 0xde, 0xbf,
+	0x89, 0x73, 0x88, 0x6f, 0x7e, 0x6f, 0x7e, 0x33, 0xbf, 0xf7, 0x6f, 0xe6, 0xbd, 0x35, 0x3c, 0xf0,
+	0xa9, 0x37, 0x25, 0x36, 0xa6, 0xe7, 0x14, 0x5f, 0x10, 0xc6, 0x31, 0xdd, 0xf7, 0xa9, 0xc7, 0x3d,
+	0xb4, 0x15, 0xc9, 0xe7, 0x31, 0xc2, 0x1f, 0xea, 0x4f, 0xa1, 0x7d, 0x8c, 0xf9, 0xe9, 0xe5, 0xd0,
+	0x21, 0xd6, 0x37, 0x78, 0x66, 0xe0, 0xb7, 0xa8, 0x0b, 0x6b, 0x53, 0x4c, 0x19, 0xf1, 0xdc, 0xae,
+	0xd2, 0x53, 0xfa, 0x2d, 0x23, 0x12, 0xf5, 0x11, 0x74, 0xd2, 0x60, 0xe6, 0xa3, 0x0f, 0xa0, 0xee,
+	0x47, 0x0a, 0x89, 0x6f, 0x1a, 0x73, 0x05, 0x7a, 0x04, 0xad, 0x58, 0xf8, 0xda, 0x64, 0xe3, 0xee,
+	0x8a, 0x44, 0xa4, 0x95, 0x68, 0x1d, 0x56, 0x88, 0xdf, 0x5d, 0xed, 0x29, 0xfd, 0xba, 0xb1, 0x42,
+	0x7c, 0xfd, 0xdf, 0x0a, 0x34, 0x8c, 0xd0, 0xda, 0x1b, 0x2d, 0x12, 0xec, 0x9c, 0x4c, 0x30, 0xe3,
+	0xe6, 0xc4, 0x97, 0x67, 0xab, 0xc6, 0x5c, 0x21, 0x56, 0x5d, 0xcf, 0xc6, 0x27, 0xf6, 0x91, 0x6b,
+	0xc9, 0xe3, 0x9b, 0xc6, 0x5c, 0x81, 0x74, 0x68, 0xc6, 0x66, 0x08, 0x80, 0x2a, 0x01, 0x29, 0x9d,
+	0xb0, 0x1f, 0xbb, 0x16, 0x9d, 0xf9, 0x3c, 0x04, 0x55, 0x02, 0xfb, 0x53, 0x4a, 0xb4, 0x07, 0x9d,
+	0x2b, 0xd3, 0x71, 0x30, 0x3f, 0xb4, 0x6d, 0x8a, 0x19, 0x13, 0xc0, 0xaa, 0x04, 0xe6, 0xf4, 0x82,
+	0x75, 0x48, 0x1c, 0xe7, 0x68, 0x62, 0x12, 0x47, 0xe0, 0xd6, 0x02, 0xd6, 0xa4, 0x0e, 0x3d, 0x83,
+	0x8d, 0x89, 0x49, 0xdc, 0x01, 0xf7, 0xa8, 0x79, 0x81, 0xcf, 0x3c, 0xe7, 0x72, 0x82, 0xbb, 0x35,
+	0xe9, 0x5d, 0x7e, 0x01, 0xf5, 0xa0, 0x71, 0xe9, 0xbf, 0x34, 0x5d, 0xfb, 0x8a, 0xd8, 0x7c, 0xdc,
+	0xad, 0x4b, 0x5c, 0x52, 0x25, 0xbc, 0xb0, 0xbd, 0x2b, 0x77, 0x8e, 0x01, 0x89, 0x49, 0x2b, 0x51,
+	0x1f, 0xda, 0x1c, 0x33, 0xfe, 0x63, 0xe2, 0xac, 0x86, 0xc4, 0x65, 0xd5, 0xc2, 0x3e, 0xa1, 0x7a,
+	0x9d, 0x3a, 0xb3, 0x19, 0xd8, 0x97, 0x5b, 0x10, 0x1e, 0x9b, 0x53, 0x93, 0x38, 0xe6, 0x90, 0x38,
+	0x84, 0xcf, 0xba, 0xad, 0x9e, 0xd2, 0x57, 0x8c, 0x94, 0x0e, 0x21, 0x50, 0x7d, 0x8f, 0xf2, 0xee,
+	0xba, 0x4c, 0xaf, 0xfc, 0x2d, 0xb2, 0x3e, 0xf6, 0x18, 0x17, 0x41, 0x6a, 0xcb, 0x20, 0x45, 0xa2,
+	0x88, 0xb7, 0x3d, 0x73, 0xcd, 0x09, 0xb1, 0x5e, 0x7b, 0x22, 0x1e, 0x02, 0xd2, 0x09, 0xe2, 0x9d,
+	0xd5, 0xa3, 0x7d, 0x40, 0xf8, 0x9a, 0x53, 0x33, 0x1d, 0xcc, 0x8d, 0xde, 0x6a, 0x5f, 0x35, 0x0a,
+	0x56, 0xf2, 0x15, 0x8b, 0x8a, 0x2a, 0x16, 0x81, 0xca, 0xc8, 0x85, 0xdb, 0xdd, 0x94, 0x8b, 0xf2,
+	0xb7, 0xf0, 0xd3, 0xf2, 0xdc, 0x11, 0xa1, 0x93, 0x13, 0xd7, 0xc5, 0xb4, 0xbb, 0xd5, 0x53, 0xfa,
+	0x35, 0x23, 0xa5, 0xd3, 0xbf, 0x80, 0xe6, 0xbc, 0xb0, 0x99, 0x2f, 0xce, 0xb1, 0x3c, 0x1b, 0x87,
+	0x65, 0x2d, 0x7f, 0xa3, 0x6d, 0xa8, 0x62, 0x4a, 0xbf, 0x65, 0x17, 0xb2, 0xa0, 0xeb, 0x46, 0x28,
+	0xe9, 0x7f, 0x2a, 0x80, 0xce, 0x30, 0x25, 0xa3, 0xd9, 0xcb, 0xa8, 0x58, 0x6e, 0xbe, 0x1c, 0xdb,
+	0x50, 0x0d, 0xaa, 0x3d, 0xbc, 0x75, 0xa1, 0x94, 0xbe, 0x34, 0xab, 0xd9, 0x4b, 0xf3, 0x21, 0xc0,
+	0x54, 0xb2, 0xbc, 0x12, 0x86, 0xa9, 0xd2, 0x84, 0x84, 0x26, 0x76, 0xbd, 0x32, 0x77, 0x5d, 0x3f,
+	0x84, 0xcd, 0x9c, 0x65, 0x77, 0xf4, 0x6e, 0x06, 0x9b, 0x06, 0x66, 0xd8, 0xb5, 0xcf, 0x62, 0xaa,
+	0xfb, 0xf0, 0x2e, 0xb2, 0x5e, 0x4d, 0x58, 0xff, 0x19, 0x6c, 0xe5, 0xa9, 0x99, 0x2f, 0xb8, 0xd9,
+	0xa5, 0x65, 0x61, 0xc6, 0x24, 0x77, 0xcd, 0x88, 0x44, 0xfd, 0x0f, 0x05, 0xd0, 0xa1, 0x6d, 0x1f,
+	0x25, 0xca, 0xe7, 0x3e, 0x8c, 0xdd, 0x86, 0xea, 0x34, 0xa8, 0x57, 0x55, 0x2e, 0x85, 0x52, 0x61,
+	0x0a, 0x3e, 0x85, 0xcd, 0x9c, 0x45, 0x37, 0xfa, 0x30, 0x83, 0xcd, 0x63, 0xcc, 0x7f, 0xa0, 0xa6,
+	0xf5, 0x06, 0xd3, 0x01, 0xa6, 0x53, 0x4c, 0xef, 0xc3, 0x87, 0xa2, 0x80, 0x0f, 0x60, 0x2b, 0x4f,
+	0xcd, 0x7c, 0xf4, 0x02, 0xaa, 0x4c, 0x4a, 0x5d, 0xa5, 0xb7, 0xda, 0x6f, 0x3c, 0x7f, 0xb8, 0x5f,
+	0xd4, 0xb3, 0xf6, 0xd3, 0x1b, 0xc3, 0x2d, 0xfa, 0x0b, 0x68, 0xa5, 0x16, 0x84, 0xbd, 0xf1, 0x69,
+	0xb2, 0xd2, 0x02, 0x29, 0x7e, 0x6b, 0x56, 0xe6, 0x6f, 0x8d, 0xfe, 0x1b, 0xbc, 0x7f, 0x8c, 0xf9,
+	0x2b, 0xcf, 0x71, 0xb0, 0xc5, 0xbd, 0x25, 0x87, 0xe3, 0x27, 0xd8, 0x2e, 0x22, 0x67, 0x3e, 0x3a,
+	0xc8, 0x04, 0xe4, 0x71, 0x71, 0x40, 0xb2, 0x5b, 0xa3, 0x90, 0x1c, 0x40, 0x3b, 0xb3, 0x74, 0xa7,
+	0xa0, 0xbc, 0x53, 0xc4, 0x6b, 0x35, 0xa2, 0x98, 0x8d, 0x4f, 0xfc, 0x7b, 0x0a, 0x86, 0x24, 0x55,
+	0xe7, 0xa4, 0x85, 0xb5, 0xfd, 0x11, 0xb4, 0x12, 0x76, 0x30, 0x3f, 0x1c, 0x18, 0x94, 0x78, 0x60,
+	0x98, 0x42, 0x67, 0x70, 0x45, 0xb8, 0x35, 0x3e, 0xa5, 0x64, 0x6a, 0xf2, 0xa5, 0xbd, 0x1c, 0x9f,
+	0xc0, 0x46, 0x86, 0xf7, 0xc6, 0x2b, 0xf7, 0x9f, 0x02, 0xed, 0x10, 0x2f, 0xbb, 0xc9, 0x92, 0xcc,
+	0xcc, 0xf7, 0xb4, 0x4a, 0x49, 0x4f, 0x93, 0xd9, 0xa8, 0x16, 0xf7, 0xe0, 0xb5, 0xdb, 0x7b, 0x70,
+	0xad, 0xb8, 0x07, 0xeb, 0x5f, 0xc6, 0xe9, 0x09, 0xdd, 0xbe, 0x63, 0x6f, 0xf8, 0x5b, 0x81, 0x76,
+	0x18, 0xe1, 0x43, 0x87, 0x4c, 0x97, 0x95, 0x5e, 0xb4, 0x05, 0x15, 0xee, 0x71, 0xd3, 0x91, 0xf1,
+	0x52, 0x8d, 0x40, 0x10, 0xf3, 0xd6, 0xc4, 0xbc, 0xfe, 0x8a, 0x38, 0x78, 0x40, 0x7e, 0xc5, 0x32,
+	0x5c, 0xaa, 0x91, 0x54, 0xe9, 0x08, 0x3a, 0x69, 0x73, 0x99, 0xaf, 0xff, 0xa5, 0x40, 0xeb, 0xfb,
+	0xd1, 0x08, 0x53, 0x03, 0x3b, 0xe6, 0x6c, 0x59, 0x1e, 0x44, 0x39, 0xad, 0x24, 0x72, 0xaa, 0x41,
+	0xcd, 0x32, 0x7d, 0xd3, 0x12, 0xb3, 0x58, 0x90, 0xeb, 0x58, 0xd6, 0x3b, 0xb0, 0x9e, 0x34, 0x92,
+	0xf9, 0xfa, 0x15, 0x6c, 0x1c, 0x63, 0x2e, 0xe5, 0xe5, 0xbe, 0x8a, 0xef, 0x14, 0x40, 0x59, 0x66,
+	0xe6, 0x8b, 0xe8, 0x53, 0xa1, 0xfa, 0x2e, 0x60, 0x09, 0xbe, 0x38, 0x92, 0xaa, 0xc4, 0x13, 0xb7,
+	0x92, 0x7a, 0xe2, 0x7a, 0xd0, 0xb8, 0xa0, 0xa6, 0xcb, 0x8f, 0xae, 0x7d, 0x42, 0x71, 0x68, 0x44,
+	0x52, 0x25, 0xf2, 0x2d, 0xc5, 0xd0, 0x8e, 0x40, 0xd0, 0xff, 0x51, 0x60, 0x3d, 0x28, 0xdf, 0xa5,
+	0xa6, 0x2e, 0xe3, 0x68, 0x25, 0xef, 0x28, 0x02, 0x55, 0xdc, 0x46, 0x99, 0xc4, 0xba, 0x21, 0x7f,
+	0xc7, 0x09, 0x5f, 0x4b, 0xbc, 0xe3, 0x07, 0xd0, 0x4e, 0xd9, 0x7f, 0xb7, 0xdb, 0xf7, 0xfc, 0xf7,
+	0x06, 0x3c, 0x38, 0x0d, 0xbb, 0x4d, 0x34, 0xbc, 0x8a, 0x84, 0x10, 0x0b, 0xa3, 0x73, 0x68, 0x26,
+	0xbf, 0x08, 0x51, 0x49, 0x83, 0xca, 0x7c, 0x62, 0x6a, 0x3b, 0x8b, 0xc0, 0x98, 0xaf, 0xbf, 0x87,
+	0x06, 0x50, 0x8b, 0x38, 0xd1, 0xc7, 0xc5, 0xbb, 0x12, 0x5f, 0x8a, 0x9a, 0x7e, 0x1b, 0x44, 0x1e,
+	0x3a, 0x86, 0x76, 0x66, 0x5c, 0x45, 0xfd, 0xe2, 0x8d, 0xf9, 0x79, 0x5b, 0xdb, 0x5d, 0x10, 0x29,
+	0x99, 0xde, 0x40, 0x27, 0x3b, 0x5a, 0xa2, 0xdd, 0x32, 0x1b, 0x73, 0xd3, 0xaf, 0xb6, 0xb7, 0x28,
+	0x34, 0x72, 0x2b, 0x33, 0x02, 0x96, 0xb9, 0x95, 0x9f, 0x5d, 0xb5, 0xdd, 0x05, 0x91, 0x91, 0x5b,
+	0xd9, 0x01, 0xae, 0xcc, 0xad, 0x82, 0x19, 0x53, 0xdb, 0x5b, 0x14, 0x2a, 0xc9, 0xde, 0xca, 0x77,
+	0x20, 0x3b, 0xc8, 0x3c, 0x2d, 0x3d, 0x23, 0x3f, 0xc5, 0x69, 0xcf, 0x16, 0x07, 0x4b, 0xca, 0x33,
+	0xa8, 0xc7, 0x03, 0x07, 0x2a, 0xad, 0xa9, 0xf9, 0x64, 0xa4, 0x3d, 0xbc, 0x15, 0x23, 0xcf, 0x1d,
+	0x42, 0x2b, 0x35, 0x2f, 0xa0, 0x92, 0x8b, 0x90, 0x1d, 0x66, 0xb4, 0x27, 0x0b, 0xe1, 0x24, 0xc7,
+	0x39, 0x34, 0x93, 0xcd, 0xb6, 0xec, 0x4a, 0x66, 0xe6, 0x10, 0x6d, 0x67, 0x11, 0x58, 0x44, 0x90,
+	0xec, 0x6e, 0x65, 0x04, 0x99, 0x86, 0xad, 0xed, 0x2c, 0x02, 0x93, 0x04, 0x3f, 0x03, 0xcc, 0x9b,
+	0x10, 0x2a, 0x09, 0x6d, 0xaa, 0x97, 0x6a, 0x8f, 0x6e, 0x07, 0xc9, 0xa3, 0x31, 0xac, 0xa7, 0x7b,
+	0x0a, 0x7a, 0x52, 0x5a, 0x1a, 0xe9, 0x9e, 0xa7, 0xf5, 0x17, 0x03, 0x4a, 0x9a, 0x5f, 0xa0, 0x91,
+	0x78, 0x71, 0xd1, 0xa3, 0x9b, 0x62, 0x1b, 0xfb, 0xf0, 0x78, 0x01, 0x94, 0x38, 0x7d, 0x58, 0x95,
+	0x7f, 0xe8, 0x7d, 0xfe, 0xff, 0x00, 0xf2, 0xaa, 0x4d, 0xe8, 0xeb, 0x13, 0x00, 0x00,
 }
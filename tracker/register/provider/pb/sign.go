@@ -197,3 +197,59 @@ func (self *PrivateAliveReq) SignReq(priKey *rsa.PrivateKey) (err error) {
 func (self *PrivateAliveReq) VerifySign(pubKey *rsa.PublicKey) error {
 	return rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, self.hash(), self.Sign)
 }
+
+func (self *OfferRelayReq) hash() []byte {
+	hasher := sha256.New()
+	hasher.Write(util_bytes.FromUint32(self.Version))
+	hasher.Write(self.NodeId)
+	hasher.Write(util_bytes.FromUint64(self.Timestamp))
+	hasher.Write(util_bytes.FromUint32(self.Port))
+	hasher.Write(util_bytes.FromUint32(self.Capacity))
+	return hasher.Sum(nil)
+}
+
+func (self *OfferRelayReq) SignReq(priKey *rsa.PrivateKey) (err error) {
+	self.Sign, err = rsa.SignPKCS1v15(rand.Reader, priKey, crypto.SHA256, self.hash())
+	return
+}
+
+func (self *OfferRelayReq) VerifySign(pubKey *rsa.PublicKey) error {
+	return rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, self.hash(), self.Sign)
+}
+
+func (self *GetRelayServerReq) hash() []byte {
+	hasher := sha256.New()
+	hasher.Write(util_bytes.FromUint32(self.Version))
+	hasher.Write(self.NodeId)
+	hasher.Write(util_bytes.FromUint64(self.Timestamp))
+	return hasher.Sum(nil)
+}
+
+func (self *GetRelayServerReq) SignReq(priKey *rsa.PrivateKey) (err error) {
+	self.Sign, err = rsa.SignPKCS1v15(rand.Reader, priKey, crypto.SHA256, self.hash())
+	return
+}
+
+func (self *GetRelayServerReq) VerifySign(pubKey *rsa.PublicKey) error {
+	return rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, self.hash(), self.Sign)
+}
+
+func (self *SwitchRelayReq) hash() []byte {
+	hasher := sha256.New()
+	hasher.Write(util_bytes.FromUint32(self.Version))
+	hasher.Write(self.NodeId)
+	hasher.Write(util_bytes.FromUint64(self.Timestamp))
+	hasher.Write(self.RelayNodeId)
+	hasher.Write([]byte(self.Host))
+	hasher.Write(util_bytes.FromUint32(self.Port))
+	return hasher.Sum(nil)
+}
+
+func (self *SwitchRelayReq) SignReq(priKey *rsa.PrivateKey) (err error) {
+	self.Sign, err = rsa.SignPKCS1v15(rand.Reader, priKey, crypto.SHA256, self.hash())
+	return
+}
+
+func (self *SwitchRelayReq) VerifySign(pubKey *rsa.PublicKey) error {
+	return rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, self.hash(), self.Sign)
+}
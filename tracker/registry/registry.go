@@ -0,0 +1,247 @@
+// Package registry keeps registered clients and providers of self-hosted tracker in bolt.
+package registry
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/samoslab/nebula/util/dbutil"
+)
+
+var (
+	clientBkt   = []byte("registry_client")
+	providerBkt = []byte("registry_provider")
+)
+
+var NotFoundErr = errors.New("node is not registered")
+
+// Client is a registered client node
+type Client struct {
+	NodeId        []byte `json:"node_id"`
+	PublicKey     []byte `json:"public_key"` // PKCS1 DER
+	ContactEmail  string `json:"contact_email"`
+	EmailVerified bool   `json:"email_verified"`
+	VerifyCode    string `json:"verify_code,omitempty"`
+	VerifyCodeAt  uint64 `json:"verify_code_at,omitempty"` // last time verify code is sent
+	Created       uint64 `json:"created"`
+}
+
+// Provider is a registered provider node
+type Provider struct {
+	NodeId        []byte   `json:"node_id"`
+	PublicKey     []byte   `json:"public_key"` // PKCS1 DER
+	WalletAddress string   `json:"wallet_address"`
+	BillEmail     string   `json:"bill_email"`
+	EmailVerified bool     `json:"email_verified"`
+	VerifyCode    string   `json:"verify_code,omitempty"`
+	VerifyCodeAt  uint64   `json:"verify_code_at,omitempty"` // last time verify code is sent
+	EncryptKey    []byte   `json:"encrypt_key,omitempty"`
+	Host          string   `json:"host"` // outer ip or domain, relay host if served through relay
+	Port          uint32   `json:"port"`
+	DynamicDomain string   `json:"dynamic_domain"`
+	Private       bool     `json:"private"`
+	RelayNodeId   []byte   `json:"relay_node_id"`
+	RelayPort     uint32   `json:"relay_port,omitempty"` // relay listen port if provider offers relay
+	RelayCapacity uint32   `json:"relay_capacity,omitempty"`
+	Storage       []uint64 `json:"storage"` // volume of each storage
+	Total         uint64   `json:"total"`   // available volume reported by private alive
+	MaxFileSize   uint64   `json:"max_file_size"`
+	Availability  float64  `json:"availability"`
+	UpBandwidth   uint64   `json:"up_bandwidth"`
+	DownBandwidth uint64   `json:"down_bandwidth"`
+	Created       uint64   `json:"created"`
+	LastSeen      uint64   `json:"last_seen"`
+}
+
+// Reachable is true if clients can connect provider directly or through relay
+func (self *Provider) Reachable() bool {
+	return len(self.Server()) > 0 && self.Port > 0
+}
+
+func (self *Provider) Server() string {
+	if len(self.DynamicDomain) > 0 {
+		return self.DynamicDomain
+	}
+	return self.Host
+}
+
+func (self *Provider) PubKey() (*rsa.PublicKey, error) {
+	return x509.ParsePKCS1PublicKey(self.PublicKey)
+}
+
+func (self *Client) PubKey() (*rsa.PublicKey, error) {
+	return x509.ParsePKCS1PublicKey(self.PublicKey)
+}
+
+type Registry struct {
+	db *bolt.DB
+}
+
+func New(db *bolt.DB) (*Registry, error) {
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bkt := range [][]byte{clientBkt, providerBkt} {
+			if _, err := tx.CreateBucketIfNotExists(bkt); err != nil {
+				return dbutil.NewCreateBucketFailedErr(bkt, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &Registry{db: db}, nil
+}
+
+func put(db *bolt.DB, bkt []byte, key []byte, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bkt).Put(key, data)
+	})
+}
+
+func get(db *bolt.DB, bkt []byte, key []byte, obj interface{}) error {
+	return db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bkt).Get(key)
+		if data == nil {
+			return NotFoundErr
+		}
+		return json.Unmarshal(data, obj)
+	})
+}
+
+func (self *Registry) PutClient(c *Client) error {
+	if c.Created == 0 {
+		c.Created = uint64(time.Now().Unix())
+	}
+	return put(self.db, clientBkt, c.NodeId, c)
+}
+
+func (self *Registry) Client(nodeId []byte) (*Client, error) {
+	c := &Client{}
+	if err := get(self.db, clientBkt, nodeId, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ClientKey is public key of registered client
+func (self *Registry) ClientKey(nodeId []byte) (*rsa.PublicKey, error) {
+	c, err := self.Client(nodeId)
+	if err != nil {
+		return nil, err
+	}
+	return c.PubKey()
+}
+
+func (self *Registry) PutProvider(p *Provider) error {
+	if p.Created == 0 {
+		p.Created = uint64(time.Now().Unix())
+	}
+	return put(self.db, providerBkt, p.NodeId, p)
+}
+
+func (self *Registry) Provider(nodeId []byte) (*Provider, error) {
+	p := &Provider{}
+	if err := get(self.db, providerBkt, nodeId, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// RemoveProvider unregister provider, blocks it holds are replicated to other providers
+func (self *Registry) RemoveProvider(nodeId []byte) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(providerBkt).Delete(nodeId)
+	})
+}
+
+// ProviderKey is public key of registered provider
+func (self *Registry) ProviderKey(nodeId []byte) (*rsa.PublicKey, error) {
+	p, err := self.Provider(nodeId)
+	if err != nil {
+		return nil, err
+	}
+	return p.PubKey()
+}
+
+func update(db *bolt.DB, bkt []byte, key []byte, obj interface{}, f func() error) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bkt)
+		data := b.Get(key)
+		if data == nil {
+			return NotFoundErr
+		}
+		if err := json.Unmarshal(data, obj); err != nil {
+			return err
+		}
+		if err := f(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, data)
+	})
+}
+
+// UpdateClient load client, change it by f and save it in one transaction
+func (self *Registry) UpdateClient(nodeId []byte, f func(c *Client) error) error {
+	c := &Client{}
+	return update(self.db, clientBkt, nodeId, c, func() error { return f(c) })
+}
+
+// UpdateProvider load provider, change it by f and save it in one transaction
+func (self *Registry) UpdateProvider(nodeId []byte, f func(p *Provider) error) error {
+	p := &Provider{}
+	return update(self.db, providerBkt, nodeId, p, func() error { return f(p) })
+}
+
+// Providers return all registered providers
+func (self *Registry) Providers() ([]*Provider, error) {
+	var res []*Provider
+	err := self.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(providerBkt).ForEach(func(k, v []byte) error {
+			p := &Provider{}
+			if err := json.Unmarshal(v, p); err != nil {
+				return err
+			}
+			res = append(res, p)
+			return nil
+		})
+	})
+	return res, err
+}
+
+// Choose at most n reachable providers randomly, providers in exclude are not chosen
+func (self *Registry) Choose(n int, exclude [][]byte) ([]*Provider, error) {
+	all, err := self.Providers()
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[string]bool, len(exclude))
+	for _, id := range exclude {
+		excluded[string(id)] = true
+	}
+	candidates := make([]*Provider, 0, len(all))
+	for _, p := range all {
+		if p.Reachable() && !excluded[string(p.NodeId)] {
+			candidates = append(candidates, p)
+		}
+	}
+	for i := len(candidates) - 1; i > 0; i-- {
+		j := rand.Intn(i + 1)
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates, nil
+}
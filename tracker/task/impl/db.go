@@ -0,0 +1,167 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"github.com/boltdb/bolt"
+	pb "github.com/samoslab/nebula/tracker/task/pb"
+)
+
+var (
+	holdingBkt      = []byte("task_holding")       // node|sequence -> Holding
+	holdingBlockBkt = []byte("task_holding_block") // node|block hash|block size -> sequence
+	taskBkt         = []byte("task_task")          // node|task id -> Task
+)
+
+var TaskNotFoundErr = errors.New("task not found")
+
+// Holding is a block held by a provider
+type Holding struct {
+	FileHash  []byte `json:"file_hash"`
+	FileSize  uint64 `json:"file_size"`
+	BlockHash []byte `json:"block_hash"`
+	BlockSize uint64 `json:"block_size"`
+}
+
+// Task is a task assigned to a provider, it is deleted when finished
+type Task struct {
+	Id         []byte            `json:"id"`
+	NodeId     []byte            `json:"node_id"`
+	Type       pb.TaskType       `json:"type"`
+	Creation   uint64            `json:"creation"`
+	Dispatched uint64            `json:"dispatched"` // last time it is listed to provider, 0 if never
+	FileHash   []byte            `json:"file_hash"`
+	FileSize   uint64            `json:"file_size"`
+	BlockHash  []byte            `json:"block_hash"`
+	BlockSize  uint64            `json:"block_size"`
+	Opposite   [][]byte          `json:"opposite,omitempty"`
+	ProofId    []byte            `json:"proof_id,omitempty"`
+	ChunkSize  uint32            `json:"chunk_size,omitempty"`
+	ChunkSeq   map[uint32][]byte `json:"chunk_seq,omitempty"`
+}
+
+// blockKey identify a block in maps of pending tasks
+func blockKey(hash []byte, size uint64) string {
+	return string(hash) + string(uint64Bytes(size))
+}
+
+func uint64Bytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// nodeKey is node id length(1)|node id
+func nodeKey(nodeId []byte) []byte {
+	return append([]byte{byte(len(nodeId))}, nodeId...)
+}
+
+func holdingBlockKey(nodeId []byte, hash []byte, size uint64) []byte {
+	return append(append(nodeKey(nodeId), hash...), uint64Bytes(size)...)
+}
+
+// addHolding index block held by node, it is no-op if indexed
+func addHolding(tx *bolt.Tx, nodeId []byte, h *Holding) error {
+	bkt := tx.Bucket(holdingBlockBkt)
+	bk := holdingBlockKey(nodeId, h.BlockHash, h.BlockSize)
+	if bkt.Get(bk) != nil {
+		return nil
+	}
+	hBkt := tx.Bucket(holdingBkt)
+	seq, err := hBkt.NextSequence()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err = hBkt.Put(append(nodeKey(nodeId), uint64Bytes(seq)...), data); err != nil {
+		return err
+	}
+	return bkt.Put(bk, uint64Bytes(seq))
+}
+
+// removeHolding drop block from index of node, it returns nil Holding if not indexed
+func removeHolding(tx *bolt.Tx, nodeId []byte, hash []byte, size uint64) (*Holding, error) {
+	bkt := tx.Bucket(holdingBlockBkt)
+	bk := holdingBlockKey(nodeId, hash, size)
+	seq := bkt.Get(bk)
+	if seq == nil {
+		return nil, nil
+	}
+	hk := append(nodeKey(nodeId), seq...)
+	hBkt := tx.Bucket(holdingBkt)
+	h := &Holding{}
+	if data := hBkt.Get(hk); data != nil {
+		if err := json.Unmarshal(data, h); err != nil {
+			return nil, err
+		}
+	}
+	if err := hBkt.Delete(hk); err != nil {
+		return nil, err
+	}
+	return h, bkt.Delete(bk)
+}
+
+// holdings page through blocks of node after sequence previous
+func holdings(tx *bolt.Tx, nodeId []byte, previous uint64, max int) (res []*pb.HashAndSize, last uint64, hasNext bool, err error) {
+	prefix := nodeKey(nodeId)
+	c := tx.Bucket(holdingBkt).Cursor()
+	last = previous
+	for k, v := c.Seek(append(append([]byte{}, prefix...), uint64Bytes(previous+1)...)); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if len(res) == max {
+			return res, last, true, nil
+		}
+		h := &Holding{}
+		if err = json.Unmarshal(v, h); err != nil {
+			return
+		}
+		res = append(res, &pb.HashAndSize{Hash: h.BlockHash, Size: h.BlockSize})
+		last = binary.BigEndian.Uint64(k[len(prefix):])
+	}
+	return res, last, false, nil
+}
+
+func putTask(tx *bolt.Tx, t *Task) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(taskBkt).Put(append(nodeKey(t.NodeId), t.Id...), data)
+}
+
+func getTask(tx *bolt.Tx, nodeId []byte, id []byte) (*Task, error) {
+	data := tx.Bucket(taskBkt).Get(append(nodeKey(nodeId), id...))
+	if data == nil {
+		return nil, TaskNotFoundErr
+	}
+	t := &Task{}
+	return t, json.Unmarshal(data, t)
+}
+
+func deleteTask(tx *bolt.Tx, t *Task) error {
+	return tx.Bucket(taskBkt).Delete(append(nodeKey(t.NodeId), t.Id...))
+}
+
+// forEachTask iterate tasks of node, or tasks of all nodes if nodeId is nil
+func forEachTask(tx *bolt.Tx, nodeId []byte, f func(t *Task) error) error {
+	var prefix []byte
+	if nodeId != nil {
+		prefix = nodeKey(nodeId)
+	}
+	c := tx.Bucket(taskBkt).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		t := &Task{}
+		if err := json.Unmarshal(v, t); err != nil {
+			return err
+		}
+		if err := f(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
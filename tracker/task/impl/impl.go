@@ -0,0 +1,384 @@
+// Package impl is a self-hostable implementation of ProviderTaskService, it schedules tasks of providers
+// by blocks recorded in metadata store.
+package impl
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+	ppb "github.com/samoslab/nebula/provider/pb"
+	tracker_config "github.com/samoslab/nebula/tracker/config"
+	"github.com/samoslab/nebula/tracker/metadata/store"
+	"github.com/samoslab/nebula/tracker/registry"
+	pb "github.com/samoslab/nebula/tracker/task/pb"
+	"github.com/samoslab/nebula/util/dbutil"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const timestamp_expired = 900
+const timestamp_ahead = -300
+
+// category bits of TaskListReq
+const (
+	category_remove    = 0x1
+	category_prove     = 0x2
+	category_send      = 0x4
+	category_replicate = 0x8
+)
+
+// Config of scheduler
+type Config struct {
+	ReplicaCount    int           // replica count of file stored as MultiReplica
+	ProvePerRound   int           // PROVE tasks created in each round
+	ChallengeChunks int           // chunks challenged by each PROVE task
+	RemovePerRound  int           // removed files handled in each round
+	TaskListMax     int           // max tasks listed to provider once
+	VerifyPageSize  int           // blocks in each page of VerifyBlocks
+	Redispatch      time.Duration // listed but unfinished task is listed again after it
+	TaskExpired     time.Duration // unfinished REPLICATE, SEND and PROVE task is dropped after it, holder not proving in time is dropped
+}
+
+func DefaultConfig() Config {
+	return Config{ReplicaCount: 3,
+		ProvePerRound:   20,
+		ChallengeChunks: 5,
+		RemovePerRound:  100,
+		TaskListMax:     50,
+		VerifyPageSize:  1000,
+		Redispatch:      30 * time.Minute,
+		TaskExpired:     6 * time.Hour}
+}
+
+type ProviderTaskService struct {
+	db         *bolt.DB
+	store      *store.Store
+	registry   *registry.Registry
+	key        *tracker_config.Key
+	config     Config
+	scheduling sync.Mutex
+}
+
+func NewProviderTaskService(db *bolt.DB, st *store.Store, reg *registry.Registry, key *tracker_config.Key, config Config) (*ProviderTaskService, error) {
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bkt := range [][]byte{holdingBkt, holdingBlockBkt, taskBkt} {
+			if _, err := tx.CreateBucketIfNotExists(bkt); err != nil {
+				return dbutil.NewCreateBucketFailedErr(bkt, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &ProviderTaskService{db: db, store: st, registry: reg, key: key, config: config}, nil
+}
+
+type signedReq interface {
+	VerifySign(pubKey *rsa.PublicKey) error
+}
+
+func (self *ProviderTaskService) verify(nodeId []byte, timestamp uint64, req signedReq) (*registry.Provider, error) {
+	interval := time.Now().Unix() - int64(timestamp)
+	if interval > timestamp_expired || interval < timestamp_ahead {
+		return nil, status.Error(codes.InvalidArgument, "timestamp expired")
+	}
+	pro, err := self.registry.Provider(nodeId)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	pubKey, err := pro.PubKey()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err = req.VerifySign(pubKey); err != nil {
+		return nil, status.Error(codes.Unauthenticated, "verify sign failed")
+	}
+	return pro, nil
+}
+
+func now() uint64 {
+	return uint64(time.Now().Unix())
+}
+
+func newId() []byte {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return id
+}
+
+func categoryOf(t pb.TaskType) uint32 {
+	switch t {
+	case pb.TaskType_REMOVE:
+		return category_remove
+	case pb.TaskType_PROVE:
+		return category_prove
+	case pb.TaskType_SEND:
+		return category_send
+	}
+	return category_replicate
+}
+
+func (self *Task) toPb() *pb.Task {
+	t := &pb.Task{Id: self.Id,
+		Creation:  self.Creation,
+		Type:      self.Type,
+		FileHash:  self.FileHash,
+		FileSize:  self.FileSize,
+		BlockHash: self.BlockHash,
+		BlockSize: self.BlockSize,
+		ProofId:   self.ProofId}
+	for _, o := range self.Opposite {
+		t.OppositeId = append(t.OppositeId, base64.StdEncoding.EncodeToString(o))
+	}
+	return t
+}
+
+func (self *ProviderTaskService) TaskList(ctx context.Context, req *pb.TaskListReq) (*pb.TaskListResp, error) {
+	pro, err := self.verify(req.NodeId, req.Timestamp, req)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.TaskListResp{Timestamp: now()}
+	redispatch := resp.Timestamp - uint64(self.config.Redispatch/time.Second)
+	err = self.db.Update(func(tx *bolt.Tx) error {
+		var listed []*Task
+		if err := forEachTask(tx, req.NodeId, func(t *Task) error {
+			if len(listed) < self.config.TaskListMax && req.Category&categoryOf(t.Type) != 0 && t.Dispatched <= redispatch {
+				listed = append(listed, t)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, t := range listed {
+			t.Dispatched = resp.Timestamp
+			if err := putTask(tx, t); err != nil {
+				return err
+			}
+			resp.Task = append(resp.Task, t.toPb())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp.GenAuth(pro.PublicKey)
+	return resp, nil
+}
+
+func (self *ProviderTaskService) task(nodeId []byte, id []byte) (t *Task, err error) {
+	err = self.db.View(func(tx *bolt.Tx) error {
+		t, err = getTask(tx, nodeId, id)
+		return err
+	})
+	if err == TaskNotFoundErr {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return
+}
+
+func (self *ProviderTaskService) GetOppositeInfo(ctx context.Context, req *pb.GetOppositeInfoReq) (*pb.GetOppositeInfoResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	t, err := self.task(req.NodeId, req.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	if t.Type != pb.TaskType_REPLICATE && t.Type != pb.TaskType_SEND {
+		return nil, status.Error(codes.InvalidArgument, "task has no opposite")
+	}
+	resp := &pb.GetOppositeInfoResp{Timestamp: now()}
+	b, err := self.recordBlock(t)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if b != nil && tagged(b) && len(b.Phi) > 0 {
+		resp.ChunkSize, resp.ParamStr, resp.Phi = b.ChunkSize, b.ParamStr, b.Phi
+	}
+	for _, nodeId := range t.Opposite {
+		pro, err := self.registry.Provider(nodeId)
+		if err != nil || !pro.Reachable() {
+			continue
+		}
+		oi := &pb.OppositeInfo{NodeId: base64.StdEncoding.EncodeToString(nodeId), Host: pro.Server(), Port: pro.Port, Ticket: self.key.NewTicket()}
+		if t.Type == pb.TaskType_REPLICATE {
+			// task node retrieve block from opposite
+			oi.Auth = ppb.GenRetrieveAuth(pro.PublicKey, t.FileHash, t.FileSize, t.BlockHash, t.BlockSize, resp.Timestamp, oi.Ticket)
+		} else {
+			// task node store block to opposite
+			oi.Auth = ppb.GenStoreAuth(pro.PublicKey, t.FileHash, t.FileSize, t.BlockHash, t.BlockSize, resp.Timestamp, oi.Ticket)
+		}
+		resp.Info = append(resp.Info, oi)
+	}
+	return resp, nil
+}
+
+func (self *ProviderTaskService) GetProveInfo(ctx context.Context, req *pb.GetProveInfoReq) (*pb.GetProveInfoResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	t, err := self.task(req.NodeId, req.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	if t.Type != pb.TaskType_PROVE {
+		return nil, status.Error(codes.InvalidArgument, "not a PROVE task")
+	}
+	return &pb.GetProveInfoResp{ProofId: t.ProofId, ChunkSize: t.ChunkSize, ChunkSeq: t.ChunkSeq}, nil
+}
+
+func (self *ProviderTaskService) FinishProve(ctx context.Context, req *pb.FinishProveReq) (*pb.FinishProveResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	t, err := self.task(req.NodeId, req.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	if t.Type != pb.TaskType_PROVE || string(t.ProofId) != string(req.ProofId) {
+		return nil, status.Error(codes.InvalidArgument, "proof id not match")
+	}
+	if err = self.verifyProof(t, req.Result, req.Sigma); err != nil {
+		log.Warnf("provider %x failed to prove block %x: %s, remark: %s", t.NodeId, t.BlockHash, err, req.Remark)
+		if err = self.dropHolder(t.NodeId, t.BlockHash, t.BlockSize); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	if err = self.db.Update(func(tx *bolt.Tx) error { return deleteTask(tx, t) }); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.FinishProveResp{}, nil
+}
+
+func (self *ProviderTaskService) FinishTask(ctx context.Context, req *pb.FinishTaskReq) (*pb.FinishTaskResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	t, err := self.task(req.NodeId, req.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Success {
+		log.Warnf("%s task %x of provider %x failed: %s", t.Type, t.Id, t.NodeId, req.Remark)
+	} else {
+		switch t.Type {
+		case pb.TaskType_REPLICATE:
+			err = self.addHolder(t.NodeId, t)
+		case pb.TaskType_SEND:
+			if len(t.Opposite) == 1 {
+				err = self.addHolder(t.Opposite[0], t)
+			}
+		}
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	if err = self.db.Update(func(tx *bolt.Tx) error { return deleteTask(tx, t) }); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.FinishTaskResp{}, nil
+}
+
+func (self *ProviderTaskService) VerifyBlocks(ctx context.Context, req *pb.VerifyBlocksReq) (*pb.VerifyBlocksResp, error) {
+	if _, err := self.verify(req.NodeId, req.Timestamp, req); err != nil {
+		return nil, err
+	}
+	for _, m := range req.Miss {
+		if err := self.dropHolder(req.NodeId, m.Hash, m.Size); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	if len(req.Miss) > 0 {
+		log.Infof("provider %x miss %d blocks", req.NodeId, len(req.Miss))
+	}
+	resp := &pb.VerifyBlocksResp{Last: req.Previous}
+	if !req.Query {
+		return resp, nil
+	}
+	err := self.db.View(func(tx *bolt.Tx) (err error) {
+		resp.Blocks, resp.Last, resp.HasNext, err = holdings(tx, req.NodeId, req.Previous, self.config.VerifyPageSize)
+		return
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return resp, nil
+}
+
+// Tasks return unfinished tasks of provider, or tasks of all providers if nodeId is nil
+func (self *ProviderTaskService) Tasks(nodeId []byte) (res []*Task, err error) {
+	err = self.db.View(func(tx *bolt.Tx) error {
+		return forEachTask(tx, nodeId, func(t *Task) error {
+			res = append(res, t)
+			return nil
+		})
+	})
+	return
+}
+
+var notHolderErr = errors.New("not holder")
+
+// dropHolder remove node from store nodes of block, so the block is replicated to other provider
+func (self *ProviderTaskService) dropHolder(nodeId []byte, hash []byte, size uint64) error {
+	var h *Holding
+	if err := self.db.Update(func(tx *bolt.Tx) (err error) {
+		h, err = removeHolding(tx, nodeId, hash, size)
+		return
+	}); err != nil || h == nil {
+		return err
+	}
+	err := self.store.UpdateFile(h.FileHash, h.FileSize, func(f *store.File) error {
+		for _, b := range blocksOf(f, hash, size) {
+			ids := b.StoreNodeId[:0]
+			for _, id := range b.StoreNodeId {
+				if string(id) != string(nodeId) {
+					ids = append(ids, id)
+				}
+			}
+			b.StoreNodeId = ids
+		}
+		return nil
+	})
+	if err == store.NotExistErr {
+		return nil
+	}
+	return err
+}
+
+// addHolder add node to store nodes of block after it is replicated
+func (self *ProviderTaskService) addHolder(nodeId []byte, t *Task) error {
+	err := self.store.UpdateFile(t.FileHash, t.FileSize, func(f *store.File) error {
+		blocks := blocksOf(f, t.BlockHash, t.BlockSize)
+		if len(blocks) == 0 {
+			return notHolderErr
+		}
+		for _, b := range blocks {
+			if !hasNode(b.StoreNodeId, nodeId) {
+				b.StoreNodeId = append(b.StoreNodeId, nodeId)
+			}
+		}
+		return nil
+	})
+	if err == store.NotExistErr || err == notHolderErr {
+		// file is removed while replicating, block is removed in next round
+		return self.db.Update(func(tx *bolt.Tx) error {
+			return putTask(tx, &Task{Id: newId(), NodeId: nodeId, Type: pb.TaskType_REMOVE, Creation: now(),
+				FileHash: t.FileHash, FileSize: t.FileSize, BlockHash: t.BlockHash, BlockSize: t.BlockSize})
+		})
+	} else if err != nil {
+		return err
+	}
+	return self.db.Update(func(tx *bolt.Tx) error {
+		return addHolding(tx, nodeId, &Holding{FileHash: t.FileHash, FileSize: t.FileSize, BlockHash: t.BlockHash, BlockSize: t.BlockSize})
+	})
+}
@@ -0,0 +1,322 @@
+package impl
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	ppb "github.com/samoslab/nebula/provider/pb"
+	mpb "github.com/samoslab/nebula/tracker/metadata/pb"
+	"github.com/samoslab/nebula/tracker/metadata/store"
+	"github.com/samoslab/nebula/tracker/registry"
+	pb "github.com/samoslab/nebula/tracker/task/pb"
+	"github.com/samoslab/nebula/tracker/testutil"
+	"github.com/samoslab/nebula/util/filecheck"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+type testEnv struct {
+	dir  string
+	ts   *ProviderTaskService
+	st   *store.Store
+	reg  *registry.Registry
+	pros []*testutil.Node
+}
+
+func newTestEnv(t *testing.T, providers int) (*testEnv, func()) {
+	tr := testutil.NewTracker(t)
+	env := &testEnv{dir: tr.Dir, reg: tr.Registry}
+	var err error
+	env.st, err = store.New(tr.DB)
+	require.NoError(t, err)
+	for i := 0; i < providers; i++ {
+		env.pros = append(env.pros, tr.AddProvider(t))
+	}
+	config := DefaultConfig()
+	config.VerifyPageSize = 1
+	env.ts, err = NewProviderTaskService(tr.DB, env.st, env.reg, tr.Key, config)
+	require.NoError(t, err)
+	return env, tr.Close
+}
+
+func (self *testEnv) provider(nodeId []byte) *testutil.Node {
+	for _, p := range self.pros {
+		if string(p.NodeId) == string(nodeId) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (self *testEnv) addFile(t *testing.T, name string, storeType mpb.FileStoreType, block *mpb.StoreBlock) *store.File {
+	f := &store.File{Hash: util_hash.Sha1([]byte(name)), Size: block.Size, StoreType: storeType,
+		Partitions: []*mpb.StorePartition{&mpb.StorePartition{Block: []*mpb.StoreBlock{block}}}}
+	e := &store.Entry{Name: name, FileHash: f.Hash, FileSize: f.Size}
+	_, err := self.st.AddFile([]byte("owner"), &mpb.FilePath{OneOfPath: &mpb.FilePath_Path{Path: "/"}}, e, f, false, false)
+	require.NoError(t, err)
+	return f
+}
+
+func (self *testEnv) taskList(t *testing.T, p *testutil.Node, category uint32) []*pb.Task {
+	req := &pb.TaskListReq{NodeId: p.NodeId, Timestamp: uint64(time.Now().Unix()), Category: category}
+	require.NoError(t, req.SignReq(p.PriKey))
+	resp, err := self.ts.TaskList(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, resp.CheckAuth(p.PubKey))
+	return resp.Task
+}
+
+func (self *testEnv) finish(t *testing.T, p *testutil.Node, task *pb.Task, success bool) {
+	req := &pb.FinishTaskReq{NodeId: p.NodeId, Timestamp: uint64(time.Now().Unix()), TaskId: task.Id, FinishedTime: uint64(time.Now().Unix()), Success: success}
+	require.NoError(t, req.SignReq(p.PriKey))
+	_, err := self.ts.FinishTask(context.Background(), req)
+	require.NoError(t, err)
+}
+
+// tasksOf list tasks of all providers
+func (self *testEnv) tasksOf(t *testing.T, category uint32) map[*testutil.Node][]*pb.Task {
+	res := make(map[*testutil.Node][]*pb.Task)
+	for _, p := range self.pros {
+		if list := self.taskList(t, p, category); len(list) > 0 {
+			res[p] = list
+		}
+	}
+	return res
+}
+
+func TestReplicate(t *testing.T) {
+	env, clean := newTestEnv(t, 5)
+	defer clean()
+	block := &mpb.StoreBlock{Hash: util_hash.Sha1([]byte("block")), Size: 1024 * 1024, StoreNodeId: [][]byte{env.pros[0].NodeId, env.pros[1].NodeId}}
+	f := env.addFile(t, "replica", mpb.FileStoreType_MultiReplica, block)
+	require.NoError(t, env.ts.Schedule())
+
+	tasks := env.tasksOf(t, category_replicate)
+	require.Len(t, tasks, 1)
+	for p, list := range tasks {
+		require.Len(t, list, 1)
+		task := list[0]
+		assert.Equal(t, pb.TaskType_REPLICATE, task.Type)
+		assert.Len(t, task.OppositeId, 2)
+		assert.NotContains(t, [][]byte{env.pros[0].NodeId, env.pros[1].NodeId}, p.NodeId)
+		// listed task is not listed again until redispatch
+		assert.Len(t, env.taskList(t, p, category_replicate), 0)
+
+		req := &pb.GetOppositeInfoReq{NodeId: p.NodeId, Timestamp: uint64(time.Now().Unix()), TaskId: task.Id}
+		require.NoError(t, req.SignReq(p.PriKey))
+		resp, err := env.ts.GetOppositeInfo(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, resp.Info, 2)
+		assert.Empty(t, resp.Phi, "block is not tagged")
+		for _, oi := range resp.Info {
+			nodeId, err := base64.StdEncoding.DecodeString(oi.NodeId)
+			require.NoError(t, err)
+			rr := &ppb.RetrieveReq{FileKey: f.Hash, FileSize: f.Size, BlockKey: block.Hash, BlockSize: block.Size, Timestamp: resp.Timestamp, Ticket: oi.Ticket, Auth: oi.Auth}
+			assert.NoError(t, rr.CheckAuth(env.provider(nodeId).PubKey))
+		}
+		env.finish(t, p, task, true)
+	}
+	stored, err := env.st.File(f.Hash, f.Size)
+	require.NoError(t, err)
+	assert.Len(t, stored.Partitions[0].Block[0].StoreNodeId, 3)
+	require.NoError(t, env.ts.Schedule())
+	assert.Len(t, env.tasksOf(t, category_replicate|category_send), 0)
+}
+
+func TestVerifyBlocksAndRemove(t *testing.T) {
+	env, clean := newTestEnv(t, 4)
+	defer clean()
+	holders := [][]byte{env.pros[0].NodeId, env.pros[1].NodeId, env.pros[2].NodeId}
+	b1 := &mpb.StoreBlock{Hash: util_hash.Sha1([]byte("b1")), Size: 1000, StoreNodeId: holders}
+	b2 := &mpb.StoreBlock{Hash: util_hash.Sha1([]byte("b2")), Size: 2000, StoreNodeId: holders}
+	f1 := env.addFile(t, "f1", mpb.FileStoreType_MultiReplica, b1)
+	env.addFile(t, "f2", mpb.FileStoreType_MultiReplica, b2)
+	require.NoError(t, env.ts.Schedule())
+	assert.Len(t, env.tasksOf(t, category_replicate|category_send), 0)
+
+	p := env.pros[0]
+	verify := func(query bool, previous uint64, miss []*pb.HashAndSize) *pb.VerifyBlocksResp {
+		req := &pb.VerifyBlocksReq{NodeId: p.NodeId, Timestamp: uint64(time.Now().Unix()), Query: query, Previous: previous, Miss: miss}
+		require.NoError(t, req.SignReq(p.PriKey))
+		resp, err := env.ts.VerifyBlocks(context.Background(), req)
+		require.NoError(t, err)
+		return resp
+	}
+	resp := verify(true, 0, nil)
+	require.Len(t, resp.Blocks, 1)
+	assert.True(t, resp.HasNext)
+	first := resp.Blocks[0]
+	resp = verify(true, resp.Last, nil)
+	require.Len(t, resp.Blocks, 1)
+	assert.False(t, resp.HasNext)
+	assert.NotEqual(t, first.Hash, resp.Blocks[0].Hash)
+	resp = verify(false, resp.Last, []*pb.HashAndSize{&pb.HashAndSize{Hash: b1.Hash, Size: b1.Size}})
+	assert.Len(t, resp.Blocks, 0)
+
+	stored, err := env.st.File(f1.Hash, f1.Size)
+	require.NoError(t, err)
+	assert.Len(t, stored.Partitions[0].Block[0].StoreNodeId, 2)
+	require.NoError(t, env.ts.Schedule())
+	tasks := env.tasksOf(t, category_replicate)
+	require.Len(t, tasks, 1)
+	for pro, list := range tasks {
+		// provider reported miss may be chosen again to repair the block
+		assert.NotContains(t, holders[1:], pro.NodeId)
+		assert.Equal(t, b1.Hash, list[0].BlockHash)
+	}
+
+	_, err = env.st.Remove([]byte("owner"), &mpb.FilePath{OneOfPath: &mpb.FilePath_Path{Path: "/f2"}}, false)
+	require.NoError(t, err)
+	require.NoError(t, env.ts.Schedule())
+	tasks = env.tasksOf(t, category_remove)
+	require.Len(t, tasks, 3)
+	for pro, list := range tasks {
+		assert.Contains(t, holders, pro.NodeId)
+		require.Len(t, list, 1)
+		assert.Equal(t, pb.TaskType_REMOVE, list[0].Type)
+		assert.Equal(t, b2.Hash, list[0].BlockHash)
+		env.finish(t, pro, list[0], true)
+	}
+	resp = verify(true, 0, nil)
+	assert.Len(t, resp.Blocks, 0)
+}
+
+// taggedBlock write random block and generate its tag metadata
+type taggedBlock struct {
+	data     []byte
+	paramStr string
+	phi      [][]byte
+	block    *mpb.StoreBlock
+}
+
+func (self *testEnv) taggedBlock(t *testing.T, holders ...*testutil.Node) *taggedBlock {
+	tb := &taggedBlock{data: make([]byte, 4096)}
+	rand.Read(tb.data)
+	path := filepath.Join(self.dir, "block")
+	require.NoError(t, ioutil.WriteFile(path, tb.data, 0600))
+	chunkSize := uint32(1024)
+	paramStr, generator, pubKey, random, phi, err := filecheck.GenMetadata(path, chunkSize)
+	require.NoError(t, err)
+	tb.paramStr, tb.phi = paramStr, phi
+	tb.block = &mpb.StoreBlock{Hash: util_hash.Sha1(tb.data), Size: uint64(len(tb.data)),
+		ChunkSize: chunkSize, ParamStr: paramStr, Generator: generator, PubKey: pubKey, Random: random, Phi: phi}
+	for _, p := range holders {
+		tb.block.StoreNodeId = append(tb.block.StoreNodeId, p.NodeId)
+	}
+	return tb
+}
+
+// prove answer PROVE task of provider, result is tampered if tamper
+func (self *testEnv) prove(t *testing.T, p *testutil.Node, task *pb.Task, tb *taggedBlock, tamper bool) {
+	req := &pb.GetProveInfoReq{NodeId: p.NodeId, Timestamp: uint64(time.Now().Unix()), TaskId: task.Id}
+	require.NoError(t, req.SignReq(p.PriKey))
+	info, err := self.ts.GetProveInfo(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, task.ProofId, info.ProofId)
+	assert.Len(t, info.ChunkSeq, 4)
+	mu, err := filecheck.Mu(tb.data, info.ChunkSize, info.ChunkSeq)
+	require.NoError(t, err)
+	sigma, err := filecheck.Prove(tb.paramStr, tb.phi, info.ChunkSeq)
+	require.NoError(t, err)
+	if tamper {
+		mu[0]++
+	}
+	fr := &pb.FinishProveReq{NodeId: p.NodeId, Timestamp: uint64(time.Now().Unix()), TaskId: task.Id, ProofId: info.ProofId, Result: mu, Sigma: sigma}
+	require.NoError(t, fr.SignReq(p.PriKey))
+	_, err = self.ts.FinishProve(context.Background(), fr)
+	require.NoError(t, err)
+}
+
+func TestProve(t *testing.T) {
+	env, clean := newTestEnv(t, 1)
+	defer clean()
+	p := env.pros[0]
+	tb := env.taggedBlock(t, p)
+	f := env.addFile(t, "tagged", mpb.FileStoreType_ErasureCode, tb.block)
+
+	prove := func(tamper bool) {
+		require.NoError(t, env.ts.Schedule())
+		list := env.taskList(t, p, category_prove)
+		require.Len(t, list, 1)
+		env.prove(t, p, list[0], tb, tamper)
+	}
+	prove(false)
+	stored, err := env.st.File(f.Hash, f.Size)
+	require.NoError(t, err)
+	assert.Len(t, stored.Partitions[0].Block[0].StoreNodeId, 1)
+	prove(true)
+	stored, err = env.st.File(f.Hash, f.Size)
+	require.NoError(t, err)
+	assert.Len(t, stored.Partitions[0].Block[0].StoreNodeId, 0)
+}
+
+func TestReplicateTag(t *testing.T) {
+	env, clean := newTestEnv(t, 2)
+	defer clean()
+	tb := env.taggedBlock(t, env.pros[0])
+	env.addFile(t, "tagged", mpb.FileStoreType_MultiReplica, tb.block)
+	require.NoError(t, env.ts.Schedule())
+	p := env.pros[1]
+	list := env.taskList(t, p, category_replicate)
+	require.Len(t, list, 1)
+
+	req := &pb.GetOppositeInfoReq{NodeId: p.NodeId, Timestamp: uint64(time.Now().Unix()), TaskId: list[0].Id}
+	require.NoError(t, req.SignReq(p.PriKey))
+	resp, err := env.ts.GetOppositeInfo(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, tb.block.ChunkSize, resp.ChunkSize, "new holder gets tag of file record even if source has none")
+	assert.Equal(t, tb.paramStr, resp.ParamStr)
+	assert.Equal(t, tb.phi, resp.Phi)
+}
+
+func TestSilentProvider(t *testing.T) {
+	env, clean := newTestEnv(t, 4)
+	defer clean()
+	silent := env.pros[2]
+	tb := env.taggedBlock(t, env.pros[0], env.pros[1], silent)
+	f := env.addFile(t, "tagged", mpb.FileStoreType_MultiReplica, tb.block)
+	require.NoError(t, env.ts.Schedule())
+	for _, p := range env.pros[:2] {
+		list := env.taskList(t, p, category_prove)
+		require.Len(t, list, 1)
+		env.prove(t, p, list[0], tb, false)
+	}
+	// provider stays registered but does not answer its PROVE task until it expires
+	require.NoError(t, env.ts.db.Update(func(tx *bolt.Tx) error {
+		var tasks []*Task
+		if err := forEachTask(tx, nil, func(t *Task) error {
+			tasks = append(tasks, t)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			task.Creation -= uint64(env.ts.config.TaskExpired/time.Second) + 1
+			if err := putTask(tx, task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	_, err := env.reg.Provider(silent.NodeId)
+	require.NoError(t, err)
+	require.NoError(t, env.ts.Schedule())
+
+	stored, err := env.st.File(f.Hash, f.Size)
+	require.NoError(t, err)
+	assert.NotContains(t, stored.Partitions[0].Block[0].StoreNodeId, silent.NodeId)
+	tasks := env.tasksOf(t, category_replicate)
+	require.Len(t, tasks, 1)
+	for p, list := range tasks {
+		assert.Equal(t, pb.TaskType_REPLICATE, list[0].Type)
+		assert.Equal(t, tb.block.Hash, list[0].BlockHash)
+		assert.NotContains(t, [][]byte{env.pros[0].NodeId, env.pros[1].NodeId}, p.NodeId)
+	}
+}
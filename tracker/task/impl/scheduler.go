@@ -0,0 +1,289 @@
+package impl
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/boltdb/bolt"
+	mpb "github.com/samoslab/nebula/tracker/metadata/pb"
+	"github.com/samoslab/nebula/tracker/metadata/store"
+	pb "github.com/samoslab/nebula/tracker/task/pb"
+	"github.com/samoslab/nebula/util/filecheck"
+	log "github.com/sirupsen/logrus"
+)
+
+func blocksOf(f *store.File, hash []byte, size uint64) []*mpb.StoreBlock {
+	var res []*mpb.StoreBlock
+	for _, p := range f.Partitions {
+		for _, b := range p.Block {
+			if b.Size == size && string(b.Hash) == string(hash) {
+				res = append(res, b)
+			}
+		}
+	}
+	return res
+}
+
+func hasNode(ids [][]byte, nodeId []byte) bool {
+	for _, id := range ids {
+		if string(id) == string(nodeId) {
+			return true
+		}
+	}
+	return false
+}
+
+// tagged block can be proved by aggregate tag
+func tagged(b *mpb.StoreBlock) bool {
+	return b.ChunkSize > 0 && len(b.ParamStr) > 0 && len(b.Generator) > 0 && len(b.PubKey) > 0 && len(b.Random) > 0
+}
+
+// recordBlock block of task in file record, nil if file is removed
+func (self *ProviderTaskService) recordBlock(t *Task) (*mpb.StoreBlock, error) {
+	f, err := self.store.File(t.FileHash, t.FileSize)
+	if err == store.NotExistErr {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	blocks := blocksOf(f, t.BlockHash, t.BlockSize)
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	return blocks[0], nil
+}
+
+// verifyProof check proof only if block is still tagged in file record, holders get the tag of record with
+// REPLICATE or SEND task, so a tagged block proved without aggregate tag is taken as lost
+func (self *ProviderTaskService) verifyProof(t *Task, result []byte, sigma []byte) error {
+	b, err := self.recordBlock(t)
+	if err != nil {
+		return err
+	}
+	if b == nil || !tagged(b) {
+		return nil
+	}
+	if len(sigma) == 0 {
+		return errors.New("no aggregate tag")
+	}
+	ok, err := filecheck.Verify(b.ParamStr, b.Generator, b.PubKey, b.Random, t.ChunkSeq, sigma, result)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("proof verify failed")
+	}
+	return nil
+}
+
+// Schedule run a round of scheduling: remove blocks of removed files, index blocks held by providers,
+// replicate blocks whose replica count drops and challenge random providers to prove blocks.
+func (self *ProviderTaskService) Schedule() error {
+	self.scheduling.Lock()
+	defer self.scheduling.Unlock()
+	if err := self.scheduleRemove(); err != nil {
+		return err
+	}
+	replicating, proving, err := self.pendingTasks()
+	if err != nil {
+		return err
+	}
+	var files []*store.File
+	if err = self.store.ForEachFile(func(f *store.File) error {
+		if !f.Tiny() {
+			files = append(files, f)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	var candidates []*Task
+	for _, f := range files {
+		if err = self.scheduleFile(f, replicating); err != nil {
+			return err
+		}
+		for _, p := range f.Partitions {
+			for _, b := range p.Block {
+				if !tagged(b) {
+					continue
+				}
+				for _, id := range b.StoreNodeId {
+					if !proving[string(id)+blockKey(b.Hash, b.Size)] {
+						candidates = append(candidates, &Task{NodeId: id, FileHash: f.Hash, FileSize: f.Size, BlockHash: b.Hash, BlockSize: b.Size, ChunkSize: b.ChunkSize})
+					}
+				}
+			}
+		}
+	}
+	return self.scheduleProve(candidates)
+}
+
+func (self *ProviderTaskService) scheduleRemove() error {
+	removed, err := self.store.TakeRemoved(self.config.RemovePerRound)
+	if err != nil {
+		return err
+	}
+	return self.db.Update(func(tx *bolt.Tx) error {
+		for _, f := range removed {
+			for _, p := range f.Partitions {
+				for _, b := range p.Block {
+					for _, id := range b.StoreNodeId {
+						if _, err := removeHolding(tx, id, b.Hash, b.Size); err != nil {
+							return err
+						}
+						if err := putTask(tx, &Task{Id: newId(), NodeId: id, Type: pb.TaskType_REMOVE, Creation: now(),
+							FileHash: f.Hash, FileSize: f.Size, BlockHash: b.Hash, BlockSize: b.Size}); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// pendingTasks drop expired tasks and return blocks being replicated and node|block being proved.
+// Expired PROVE is taken as failed proof, so block held by provider which goes silent is replicated to others.
+func (self *ProviderTaskService) pendingTasks() (replicating map[string]bool, proving map[string]bool, err error) {
+	replicating, proving = make(map[string]bool), make(map[string]bool)
+	expired := now() - uint64(self.config.TaskExpired/time.Second)
+	var drop []*Task
+	err = self.db.Update(func(tx *bolt.Tx) error {
+		if err := forEachTask(tx, nil, func(t *Task) error {
+			if t.Type != pb.TaskType_REMOVE && t.Creation < expired {
+				drop = append(drop, t)
+				return nil
+			}
+			switch t.Type {
+			case pb.TaskType_REPLICATE, pb.TaskType_SEND:
+				replicating[blockKey(t.BlockHash, t.BlockSize)] = true
+			case pb.TaskType_PROVE:
+				proving[string(t.NodeId)+blockKey(t.BlockHash, t.BlockSize)] = true
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, t := range drop {
+			log.Infof("%s task %x of provider %x expired", t.Type, t.Id, t.NodeId)
+			if err := deleteTask(tx, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	for _, t := range drop {
+		if t.Type != pb.TaskType_PROVE {
+			continue
+		}
+		log.Warnf("provider %x did not prove block %x in time", t.NodeId, t.BlockHash)
+		if err = self.dropHolder(t.NodeId, t.BlockHash, t.BlockSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// scheduleFile index holders of blocks and create REPLICATE or SEND tasks for blocks lacking replicas
+func (self *ProviderTaskService) scheduleFile(f *store.File, replicating map[string]bool) error {
+	want := 1
+	if f.StoreType == mpb.FileStoreType_MultiReplica {
+		want = self.config.ReplicaCount
+	}
+	var tasks []*Task
+	err := self.db.Update(func(tx *bolt.Tx) error {
+		for _, p := range f.Partitions {
+			for _, b := range p.Block {
+				for _, id := range b.StoreNodeId {
+					if err := addHolding(tx, id, &Holding{FileHash: f.Hash, FileSize: f.Size, BlockHash: b.Hash, BlockSize: b.Size}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range f.Partitions {
+		for _, b := range p.Block {
+			if replicating[blockKey(b.Hash, b.Size)] {
+				continue
+			}
+			var reachable, live [][]byte
+			for _, id := range b.StoreNodeId {
+				pro, err := self.registry.Provider(id)
+				if err != nil {
+					continue
+				}
+				live = append(live, id)
+				if pro.Reachable() {
+					reachable = append(reachable, id)
+				}
+			}
+			if len(live) >= want {
+				continue
+			}
+			if len(live) == 0 {
+				log.Warnf("block %x of file %x is lost", b.Hash, f.Hash)
+				continue
+			}
+			targets, err := self.registry.Choose(want-len(live), b.StoreNodeId)
+			if err != nil {
+				return err
+			}
+			for _, target := range targets {
+				t := &Task{Id: newId(), Creation: now(), FileHash: f.Hash, FileSize: f.Size, BlockHash: b.Hash, BlockSize: b.Size}
+				if len(reachable) > 0 {
+					// target retrieves block from reachable holders
+					t.NodeId, t.Type, t.Opposite = target.NodeId, pb.TaskType_REPLICATE, reachable
+				} else {
+					// holders in private network send block to target
+					t.NodeId, t.Type, t.Opposite = live[rand.Intn(len(live))], pb.TaskType_SEND, [][]byte{target.NodeId}
+				}
+				tasks = append(tasks, t)
+			}
+		}
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return self.db.Update(func(tx *bolt.Tx) error {
+		for _, t := range tasks {
+			if err := putTask(tx, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// scheduleProve challenge random candidates with random chunk coefficients
+func (self *ProviderTaskService) scheduleProve(candidates []*Task) error {
+	if len(candidates) > self.config.ProvePerRound {
+		rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+		candidates = candidates[:self.config.ProvePerRound]
+	}
+	for _, t := range candidates {
+		chunkCount := uint32((t.BlockSize + uint64(t.ChunkSize) - 1) / uint64(t.ChunkSize))
+		chunkSeq, err := filecheck.GenChallenge(chunkCount, self.config.ChallengeChunks)
+		if err != nil {
+			return err
+		}
+		t.Id, t.Type, t.Creation, t.ProofId, t.ChunkSeq = newId(), pb.TaskType_PROVE, now(), newId(), chunkSeq
+	}
+	return self.db.Update(func(tx *bolt.Tx) error {
+		for _, t := range candidates {
+			if err := putTask(tx, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
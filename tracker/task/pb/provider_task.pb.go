@@ -276,6 +276,9 @@ func (m *GetOppositeInfoReq) GetTaskId() []byte {
 type GetOppositeInfoResp struct {
 	Timestamp uint64          `protobuf:"varint,1,opt,name=timestamp" json:"timestamp,omitempty"`
 	Info      []*OppositeInfo `protobuf:"bytes,2,rep,name=info" json:"info,omitempty"`
+	ChunkSize uint32          `protobuf:"varint,3,opt,name=chunkSize" json:"chunkSize,omitempty"`
+	ParamStr  string          `protobuf:"bytes,4,opt,name=paramStr" json:"paramStr,omitempty"`
+	Phi       [][]byte        `protobuf:"bytes,5,rep,name=phi,proto3" json:"phi,omitempty"`
 }
 
 func (m *GetOppositeInfoResp) Reset()                    { *m = GetOppositeInfoResp{} }
@@ -297,6 +300,27 @@ func (m *GetOppositeInfoResp) GetInfo() []*OppositeInfo {
 	return nil
 }
 
+func (m *GetOppositeInfoResp) GetChunkSize() uint32 {
+	if m != nil {
+		return m.ChunkSize
+	}
+	return 0
+}
+
+func (m *GetOppositeInfoResp) GetParamStr() string {
+	if m != nil {
+		return m.ParamStr
+	}
+	return ""
+}
+
+func (m *GetOppositeInfoResp) GetPhi() [][]byte {
+	if m != nil {
+		return m.Phi
+	}
+	return nil
+}
+
 type OppositeInfo struct {
 	NodeId string `protobuf:"bytes,1,opt,name=nodeId" json:"nodeId,omitempty"`
 	Host   string `protobuf:"bytes,2,opt,name=host" json:"host,omitempty"`
@@ -426,6 +450,7 @@ type FinishProveReq struct {
 	FinishedTime uint64 `protobuf:"varint,6,opt,name=finishedTime" json:"finishedTime,omitempty"`
 	Result       []byte `protobuf:"bytes,7,opt,name=result,proto3" json:"result,omitempty"`
 	Remark       string `protobuf:"bytes,8,opt,name=remark" json:"remark,omitempty"`
+	Sigma        []byte `protobuf:"bytes,9,opt,name=sigma,proto3" json:"sigma,omitempty"`
 }
 
 func (m *FinishProveReq) Reset()                    { *m = FinishProveReq{} }
@@ -489,6 +514,13 @@ func (m *FinishProveReq) GetRemark() string {
 	return ""
 }
 
+func (m *FinishProveReq) GetSigma() []byte {
+	if m != nil {
+		return m.Sigma
+	}
+	return nil
+}
+
 type FinishProveResp struct {
 }
 
@@ -948,64 +980,66 @@ var _ProviderTaskService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("provider_task.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 933 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x56, 0x5f, 0x6f, 0x23, 0x35,
-	0x10, 0xef, 0x26, 0x9b, 0x3f, 0x3b, 0x4d, 0xda, 0x9c, 0xdb, 0xbb, 0x5b, 0xc2, 0x09, 0x05, 0x4b,
-	0x88, 0x80, 0x50, 0x1f, 0x8a, 0x90, 0xe0, 0x78, 0x40, 0x77, 0x25, 0x1c, 0x95, 0x8e, 0x5e, 0xe5,
-	0x56, 0xf7, 0x08, 0xda, 0x26, 0xce, 0xc5, 0x4a, 0xb2, 0xde, 0xac, 0x9d, 0x88, 0xf4, 0xcb, 0xf0,
-	0x21, 0xf8, 0x10, 0xbc, 0xf0, 0xc2, 0x37, 0xe0, 0xa3, 0x20, 0x8f, 0xbd, 0x9b, 0xdd, 0x90, 0x80,
-	0x74, 0x0f, 0x7d, 0x9b, 0xdf, 0xd8, 0x3b, 0xe3, 0xdf, 0x78, 0xe6, 0xe7, 0x85, 0x93, 0x24, 0x95,
-	0x2b, 0x31, 0xe2, 0xe9, 0x2f, 0x3a, 0x52, 0xd3, 0xb3, 0x24, 0x95, 0x5a, 0x92, 0x86, 0xb5, 0xef,
-	0xa8, 0x82, 0xc3, 0xdb, 0x48, 0x4d, 0x5f, 0x0b, 0xa5, 0x19, 0x5f, 0x90, 0x27, 0x50, 0x8f, 0xe5,
-	0x88, 0x5f, 0x8e, 0x42, 0xaf, 0xe7, 0xf5, 0x5b, 0xcc, 0x21, 0xf2, 0x0c, 0x02, 0x2d, 0xe6, 0x5c,
-	0xe9, 0x68, 0x9e, 0x84, 0x95, 0x9e, 0xd7, 0xf7, 0xd9, 0xc6, 0x41, 0xba, 0xd0, 0x1c, 0x46, 0x9a,
-	0xbf, 0x93, 0xe9, 0x3a, 0xac, 0xf6, 0xbc, 0x7e, 0x9b, 0xe5, 0x98, 0x10, 0xf0, 0x95, 0x78, 0x17,
-	0x87, 0x3e, 0xc6, 0x43, 0x9b, 0x0e, 0xa1, 0xb5, 0x49, 0xaa, 0x12, 0xf2, 0x31, 0xf8, 0xe6, 0x3c,
-	0xa1, 0xd7, 0xab, 0xf6, 0x0f, 0xcf, 0xdb, 0x67, 0xee, 0x70, 0x67, 0x66, 0x13, 0xc3, 0xa5, 0xff,
-	0x39, 0x00, 0x01, 0x3f, 0x5a, 0xea, 0x09, 0x26, 0x6f, 0x31, 0xb4, 0xe9, 0x6f, 0x15, 0xf0, 0x4d,
-	0x00, 0x72, 0x04, 0x15, 0x91, 0xf1, 0xa9, 0x88, 0x11, 0x9e, 0x36, 0xe5, 0x91, 0x16, 0x32, 0x76,
-	0x91, 0x72, 0x4c, 0x3e, 0x01, 0x5f, 0xaf, 0x13, 0x8e, 0x81, 0x8e, 0xce, 0x1f, 0x95, 0x4e, 0x72,
-	0xbb, 0x4e, 0x38, 0xc3, 0x65, 0x53, 0xa6, 0xb1, 0x98, 0x99, 0x32, 0x59, 0x5a, 0x0e, 0x99, 0xd0,
-	0xc6, 0xfa, 0x31, 0x52, 0x93, 0xb0, 0x86, 0x2b, 0x39, 0xce, 0xd6, 0x6e, 0xc4, 0x3d, 0x0f, 0xeb,
-	0x36, 0x6d, 0x86, 0x0d, 0xbb, 0xbb, 0x99, 0x1c, 0x4e, 0xf1, 0xc3, 0x06, 0x7e, 0xb8, 0x71, 0xe4,
-	0xab, 0xf8, 0x69, 0xd3, 0x72, 0xcf, 0x1d, 0xe4, 0x23, 0x00, 0x99, 0x24, 0x52, 0x09, 0x6d, 0xce,
-	0x13, 0xf4, 0xaa, 0xfd, 0x80, 0x15, 0x3c, 0x24, 0x84, 0x46, 0x92, 0x4a, 0x39, 0xbe, 0x1c, 0x85,
-	0x80, 0x91, 0x33, 0x48, 0x57, 0x40, 0x5e, 0x71, 0xfd, 0x26, 0xdb, 0x1a, 0x8f, 0xe5, 0xfb, 0xb7,
-	0x40, 0x76, 0xcd, 0xd5, 0xcd, 0x35, 0x9b, 0x48, 0xa6, 0x7e, 0x9b, 0x2a, 0x59, 0x44, 0x7f, 0x86,
-	0x93, 0x7f, 0xe5, 0x55, 0x49, 0x39, 0x81, 0xb7, 0x9d, 0xe0, 0x33, 0xf0, 0x45, 0x3c, 0x96, 0x61,
-	0x05, 0x7b, 0xe4, 0x71, 0x7e, 0x33, 0xa5, 0x30, 0xb8, 0x85, 0xde, 0x43, 0xab, 0xe8, 0xdd, 0x62,
-	0x14, 0xe4, 0x8c, 0x08, 0xf8, 0x13, 0xa9, 0x34, 0x92, 0x09, 0x18, 0xda, 0xc6, 0x97, 0xc8, 0x54,
-	0xbb, 0x36, 0x46, 0x3b, 0xef, 0x2e, 0x7f, 0xd3, 0x5d, 0xc8, 0x4d, 0x0c, 0xa7, 0x5c, 0xe3, 0x3d,
-	0x07, 0xcc, 0x21, 0xaa, 0xe0, 0xf8, 0x15, 0xd7, 0xd7, 0xa9, 0x5c, 0x3d, 0x60, 0x41, 0xff, 0xf4,
-	0xa0, 0x53, 0xce, 0xaa, 0x92, 0xe2, 0xbd, 0xd7, 0x4a, 0xf7, 0x6e, 0x12, 0x0f, 0x27, 0xcb, 0xd8,
-	0xf6, 0x93, 0x87, 0x44, 0x37, 0x0e, 0x72, 0x01, 0x4d, 0x0b, 0xf8, 0xc2, 0x15, 0xfb, 0xd3, 0xbc,
-	0xd8, 0xdb, 0x49, 0xce, 0x2e, 0xdc, 0xce, 0x41, 0xac, 0xd3, 0x35, 0xcb, 0x3f, 0xec, 0x7e, 0x0b,
-	0xed, 0xd2, 0x12, 0xe9, 0x40, 0x75, 0xca, 0xd7, 0x2e, 0x9b, 0x31, 0xc9, 0x29, 0xd4, 0x56, 0xd1,
-	0x6c, 0xc9, 0x91, 0x7a, 0x8b, 0x59, 0xf0, 0xbc, 0xf2, 0xb5, 0x47, 0xff, 0xf6, 0xe0, 0xe8, 0x07,
-	0x11, 0x0b, 0x35, 0xc1, 0x64, 0x0f, 0x52, 0xc3, 0xff, 0x28, 0x17, 0x85, 0xd6, 0x18, 0x4f, 0xc3,
-	0x47, 0xb7, 0x62, 0x9e, 0x0d, 0x6f, 0xc9, 0x67, 0xa2, 0xa6, 0x5c, 0x2d, 0x67, 0xda, 0x4d, 0xaf,
-	0x43, 0xd6, 0x3f, 0x8f, 0xd2, 0x29, 0xce, 0x6d, 0xc0, 0x1c, 0xa2, 0x8f, 0xe0, 0xb8, 0xc4, 0x50,
-	0x25, 0xf4, 0x0f, 0x0f, 0xda, 0xd6, 0x87, 0xb2, 0xf7, 0x20, 0xa4, 0xb7, 0xa9, 0xd5, 0x76, 0x50,
-	0x0b, 0xa1, 0xa1, 0x96, 0xc3, 0x21, 0x57, 0x0a, 0x99, 0x37, 0x59, 0x06, 0x0b, 0xe4, 0x1a, 0x25,
-	0x72, 0x9d, 0xec, 0xfa, 0x2c, 0x11, 0x95, 0xd0, 0xaf, 0xe0, 0xd0, 0x28, 0xd9, 0x8b, 0x78, 0x84,
-	0x2d, 0x66, 0x06, 0xcf, 0x28, 0x9d, 0xa5, 0x85, 0xb6, 0x3d, 0xf6, 0x3d, 0x77, 0x7c, 0xd0, 0xa6,
-	0x7f, 0x79, 0x70, 0xfc, 0x96, 0xa7, 0x62, 0xbc, 0x7e, 0x69, 0xe4, 0x4e, 0x99, 0xa2, 0x84, 0xd0,
-	0x58, 0xf1, 0x54, 0x19, 0xf1, 0xb6, 0xcd, 0x94, 0xc1, 0x42, 0xb9, 0x2a, 0xfb, 0xcb, 0x55, 0xdd,
-	0x57, 0xae, 0xc2, 0xfb, 0x64, 0x5a, 0x73, 0xb1, 0xe4, 0xe9, 0x1a, 0xeb, 0xd1, 0x64, 0x16, 0x18,
-	0x01, 0x4f, 0x52, 0xbe, 0x12, 0x72, 0xa9, 0x32, 0x01, 0xcf, 0x30, 0xe9, 0x83, 0x3f, 0x17, 0x4a,
-	0x85, 0x0d, 0x1c, 0x98, 0xd3, 0x7c, 0x60, 0x0a, 0xac, 0x19, 0xee, 0xa0, 0x31, 0x74, 0xca, 0x94,
-	0x14, 0x9e, 0x61, 0x16, 0x29, 0xed, 0x44, 0x0f, 0x6d, 0xf2, 0x05, 0xd4, 0x51, 0xe3, 0x95, 0x1b,
-	0xc2, 0xdd, 0x31, 0xdd, 0x1e, 0x53, 0x95, 0x49, 0xa4, 0xae, 0xf8, 0xaf, 0x56, 0xb9, 0x9a, 0x2c,
-	0x83, 0x9f, 0x3f, 0x87, 0x66, 0xf6, 0x78, 0x91, 0x36, 0x04, 0x6c, 0x70, 0xfd, 0xfa, 0xf2, 0xe2,
-	0xc5, 0xed, 0xa0, 0x73, 0x40, 0x9a, 0xe0, 0xdf, 0x0c, 0xae, 0xbe, 0xef, 0x78, 0x04, 0xa0, 0xce,
-	0x06, 0x3f, 0xbd, 0x79, 0x3b, 0xe8, 0x54, 0x48, 0x00, 0xb5, 0x6b, 0x66, 0xcc, 0xea, 0xf9, 0xef,
-	0x55, 0x38, 0xb9, 0x76, 0x7f, 0x0f, 0x26, 0xc8, 0x0d, 0x4f, 0x57, 0x62, 0xc8, 0xc9, 0x37, 0x36,
-	0xa6, 0x79, 0xbf, 0xc9, 0x69, 0xe9, 0x8d, 0x74, 0xff, 0x11, 0xdd, 0xc7, 0x3b, 0xbc, 0x2a, 0xa1,
-	0x07, 0xe4, 0x0a, 0xf5, 0xb1, 0x24, 0xcf, 0x1f, 0x16, 0xe5, 0x65, 0xeb, 0x35, 0xea, 0x3e, 0xdb,
-	0xbf, 0x88, 0xf1, 0x06, 0xd0, 0x2a, 0x8a, 0x12, 0x09, 0xf7, 0x68, 0xd5, 0xa2, 0xfb, 0xc1, 0x5e,
-	0x15, 0xa3, 0x07, 0xe4, 0x25, 0x1c, 0x16, 0xe6, 0x91, 0x3c, 0xcd, 0xf7, 0x96, 0x75, 0xa8, 0x1b,
-	0xee, 0x5e, 0xc0, 0x18, 0xdf, 0x01, 0x6c, 0xda, 0x9e, 0x3c, 0xd9, 0xda, 0xe9, 0x86, 0xba, 0xfb,
-	0x74, 0xa7, 0x3f, 0xe3, 0x52, 0x6c, 0x8d, 0x02, 0x97, 0xad, 0x21, 0x28, 0x70, 0xd9, 0xee, 0x25,
-	0x7a, 0x70, 0x57, 0xc7, 0x5f, 0xbc, 0x2f, 0xff, 0x09, 0x00, 0x00, 0xff, 0xff, 0x74, 0x91, 0xc2,
-	0x7b, 0xf9, 0x09, 0x00, 0x00,
+	// 970 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x56, 0xcd, 0x6e, 0x23, 0x45,
+	0x10, 0xce, 0xfc, 0x38, 0x9e, 0xa9, 0xd8, 0x89, 0xb7, 0x93, 0xdd, 0xed, 0x35, 0x2b, 0x64, 0x46,
+	0x42, 0x18, 0x84, 0x72, 0x08, 0x42, 0x82, 0xe5, 0x80, 0x76, 0x83, 0x59, 0x22, 0x2d, 0xd9, 0xa8,
+	0x13, 0xed, 0x15, 0x4d, 0xec, 0x76, 0xdc, 0xb2, 0x3d, 0x33, 0xe9, 0x6e, 0x5b, 0x38, 0x2f, 0xc3,
+	0x9d, 0x2b, 0x0f, 0xc1, 0x85, 0x0b, 0x2f, 0xc3, 0x19, 0x75, 0x4d, 0xcf, 0x78, 0xc6, 0xc4, 0x8b,
+	0xb4, 0x87, 0xdc, 0xea, 0xab, 0xee, 0xa9, 0xaa, 0xef, 0xeb, 0xea, 0xea, 0x81, 0xc3, 0x4c, 0xa6,
+	0x4b, 0x31, 0xe2, 0xf2, 0x17, 0x1d, 0xab, 0xe9, 0x71, 0x26, 0x53, 0x9d, 0x92, 0x66, 0x6e, 0x5f,
+	0x47, 0x0a, 0xf6, 0xae, 0x62, 0x35, 0x7d, 0x23, 0x94, 0x66, 0xfc, 0x96, 0x3c, 0x81, 0xdd, 0x24,
+	0x1d, 0xf1, 0xb3, 0x11, 0x75, 0x7a, 0x4e, 0xbf, 0xc5, 0x2c, 0x22, 0xcf, 0x21, 0xd4, 0x62, 0xce,
+	0x95, 0x8e, 0xe7, 0x19, 0x75, 0x7b, 0x4e, 0xdf, 0x67, 0x6b, 0x07, 0xe9, 0x42, 0x30, 0x8c, 0x35,
+	0xbf, 0x49, 0xe5, 0x8a, 0x7a, 0x3d, 0xa7, 0xdf, 0x66, 0x25, 0x26, 0x04, 0x7c, 0x25, 0x6e, 0x12,
+	0xea, 0x63, 0x3c, 0xb4, 0xa3, 0x21, 0xb4, 0xd6, 0x49, 0x55, 0x46, 0x3e, 0x01, 0xdf, 0xd4, 0x43,
+	0x9d, 0x9e, 0xd7, 0xdf, 0x3b, 0x69, 0x1f, 0xdb, 0xe2, 0x8e, 0xcd, 0x26, 0x86, 0x4b, 0xff, 0x53,
+	0x00, 0x01, 0x3f, 0x5e, 0xe8, 0x09, 0x26, 0x6f, 0x31, 0xb4, 0xa3, 0xdf, 0x5c, 0xf0, 0x4d, 0x00,
+	0xb2, 0x0f, 0xae, 0x28, 0xf8, 0xb8, 0x62, 0x84, 0xd5, 0x4a, 0x1e, 0x6b, 0x91, 0x26, 0x36, 0x52,
+	0x89, 0xc9, 0xa7, 0xe0, 0xeb, 0x55, 0xc6, 0x31, 0xd0, 0xfe, 0xc9, 0xa3, 0x5a, 0x25, 0x57, 0xab,
+	0x8c, 0x33, 0x5c, 0x36, 0x32, 0x8d, 0xc5, 0xcc, 0xc8, 0x94, 0xd3, 0xb2, 0xc8, 0x84, 0x36, 0xd6,
+	0x4f, 0xb1, 0x9a, 0xd0, 0x06, 0xae, 0x94, 0xb8, 0x58, 0xbb, 0x14, 0x77, 0x9c, 0xee, 0xe6, 0x69,
+	0x0b, 0x6c, 0xd8, 0x5d, 0xcf, 0xd2, 0xe1, 0x14, 0x3f, 0x6c, 0xe2, 0x87, 0x6b, 0x47, 0xb9, 0x8a,
+	0x9f, 0x06, 0x39, 0xf7, 0xd2, 0x41, 0x3e, 0x06, 0x48, 0xb3, 0x2c, 0x55, 0x42, 0x9b, 0x7a, 0xc2,
+	0x9e, 0xd7, 0x0f, 0x59, 0xc5, 0x43, 0x28, 0x34, 0x33, 0x99, 0xa6, 0xe3, 0xb3, 0x11, 0x05, 0x8c,
+	0x5c, 0xc0, 0x68, 0x09, 0xe4, 0x35, 0xd7, 0x6f, 0x8b, 0xad, 0xc9, 0x38, 0xfd, 0xf0, 0x16, 0x28,
+	0x8e, 0xd9, 0x5b, 0x1f, 0xb3, 0x89, 0x64, 0xf4, 0x5b, 0xab, 0x94, 0xa3, 0xe8, 0x77, 0x07, 0x0e,
+	0xff, 0x93, 0x58, 0x65, 0xf5, 0x0c, 0xce, 0x66, 0x86, 0xcf, 0xc1, 0x17, 0xc9, 0x38, 0xa5, 0x2e,
+	0x36, 0xc9, 0xe3, 0xf2, 0x68, 0x6a, 0x61, 0x70, 0x8b, 0x09, 0x34, 0x9c, 0x2c, 0x92, 0x5c, 0xb0,
+	0xbc, 0x21, 0xd7, 0x0e, 0x73, 0x10, 0x59, 0x2c, 0xe3, 0xf9, 0xa5, 0x96, 0x58, 0x58, 0xc8, 0x4a,
+	0x4c, 0x3a, 0xe0, 0x65, 0x13, 0x41, 0x1b, 0x3d, 0xaf, 0xdf, 0x62, 0xc6, 0x8c, 0xee, 0xa0, 0x55,
+	0xcd, 0xb0, 0x21, 0x4f, 0x58, 0xca, 0x43, 0xc0, 0x9f, 0xa4, 0x4a, 0xa3, 0x32, 0x21, 0x43, 0xdb,
+	0xf8, 0xb2, 0x54, 0x6a, 0x5b, 0x02, 0xda, 0x65, 0xab, 0xfa, 0xeb, 0x56, 0x45, 0xa1, 0xc4, 0x70,
+	0xca, 0x35, 0x36, 0x4d, 0xc8, 0x2c, 0x8a, 0x14, 0x1c, 0xbc, 0xe6, 0xfa, 0x42, 0xa6, 0xcb, 0x07,
+	0x3c, 0x9d, 0xbf, 0x1c, 0xe8, 0xd4, 0xb3, 0xaa, 0xac, 0xda, 0x44, 0x8d, 0x5a, 0x13, 0xd5, 0xb5,
+	0x76, 0x36, 0xb5, 0x3e, 0x85, 0x20, 0x07, 0xfc, 0xd6, 0x1e, 0xdc, 0x67, 0xe5, 0xc1, 0x6d, 0x26,
+	0x39, 0x3e, 0xb5, 0x3b, 0x07, 0x89, 0x96, 0x2b, 0x56, 0x7e, 0xd8, 0xfd, 0x0e, 0xda, 0xb5, 0x25,
+	0x73, 0x4a, 0x53, 0xbe, 0xb2, 0xd9, 0x8c, 0x49, 0x8e, 0xa0, 0xb1, 0x8c, 0x67, 0x0b, 0x8e, 0xd4,
+	0x5b, 0x2c, 0x07, 0x2f, 0xdc, 0x6f, 0x9c, 0xe8, 0x1f, 0x07, 0xf6, 0x7f, 0x14, 0x89, 0x50, 0x13,
+	0x4c, 0xf6, 0x20, 0x1a, 0xbe, 0x47, 0xae, 0x08, 0x5a, 0x63, 0xac, 0x86, 0x8f, 0xae, 0xc4, 0xbc,
+	0x98, 0x04, 0x35, 0x9f, 0x89, 0x2a, 0xb9, 0x5a, 0xcc, 0xb4, 0x1d, 0x05, 0x16, 0xe5, 0xfe, 0x79,
+	0x2c, 0xa7, 0x38, 0x04, 0x42, 0x66, 0x91, 0x21, 0xaf, 0xc4, 0xcd, 0x3c, 0xa6, 0x61, 0x4e, 0x1e,
+	0x41, 0xf4, 0x08, 0x0e, 0x6a, 0xbc, 0x55, 0x16, 0xfd, 0xe9, 0x40, 0x3b, 0xf7, 0xe1, 0x64, 0x7d,
+	0x10, 0x29, 0x36, 0x09, 0x37, 0xee, 0x21, 0x4c, 0xa1, 0xa9, 0x16, 0xc3, 0x21, 0x57, 0x0a, 0xf5,
+	0x08, 0x58, 0x01, 0x2b, 0x94, 0x9b, 0x55, 0xca, 0x51, 0x07, 0xf6, 0xab, 0x44, 0x54, 0x16, 0x7d,
+	0x0d, 0x7b, 0x66, 0x58, 0xbe, 0x4c, 0x46, 0xd8, 0x78, 0xe6, 0x3a, 0x9a, 0x61, 0x9a, 0xd3, 0x42,
+	0x3b, 0x2f, 0xfb, 0x8e, 0x5b, 0x3e, 0x68, 0x47, 0x7f, 0x3b, 0x70, 0xf0, 0x8e, 0x4b, 0x31, 0x5e,
+	0xbd, 0x32, 0x13, 0x55, 0x19, 0x51, 0x28, 0x34, 0x97, 0x5c, 0x2a, 0xf3, 0x3e, 0xe4, 0x2d, 0x56,
+	0xc0, 0x8a, 0x5c, 0xee, 0x76, 0xb9, 0xbc, 0x6d, 0x72, 0x55, 0x9e, 0x40, 0x73, 0x66, 0xb7, 0x0b,
+	0x2e, 0x57, 0xa8, 0x47, 0xc0, 0x72, 0x80, 0xa3, 0x49, 0xf2, 0xa5, 0x48, 0x17, 0xaa, 0x78, 0x23,
+	0x0a, 0x4c, 0xfa, 0xe0, 0xcf, 0x85, 0x52, 0xb4, 0x89, 0xd7, 0xe8, 0xa8, 0xbc, 0x46, 0x15, 0xd6,
+	0x0c, 0x77, 0x44, 0x09, 0x74, 0xea, 0x94, 0x14, 0xd6, 0x30, 0x8b, 0x95, 0xb6, 0x63, 0x15, 0x6d,
+	0xf2, 0x25, 0xec, 0xe2, 0x33, 0xa2, 0xa8, 0xfb, 0x9e, 0x98, 0x76, 0x8f, 0x51, 0x65, 0x12, 0xab,
+	0x73, 0xfe, 0x6b, 0x3e, 0xcf, 0x02, 0x56, 0xc0, 0x2f, 0x5e, 0x40, 0x50, 0xbc, 0x8f, 0xa4, 0x0d,
+	0x21, 0x1b, 0x5c, 0xbc, 0x39, 0x3b, 0x7d, 0x79, 0x35, 0xe8, 0xec, 0x90, 0x00, 0xfc, 0xcb, 0xc1,
+	0xf9, 0x0f, 0x1d, 0x87, 0x00, 0xec, 0xb2, 0xc1, 0xcf, 0x6f, 0xdf, 0x0d, 0x3a, 0x2e, 0x09, 0xa1,
+	0x71, 0xc1, 0x8c, 0xe9, 0x9d, 0xfc, 0xe1, 0xc1, 0xe1, 0x85, 0xfd, 0x41, 0x31, 0x41, 0x2e, 0xb9,
+	0x5c, 0x8a, 0x21, 0x27, 0xdf, 0x42, 0x50, 0xfc, 0x22, 0x90, 0xa3, 0xda, 0x33, 0x6c, 0x7f, 0x55,
+	0xba, 0x8f, 0xef, 0xf1, 0xaa, 0x2c, 0xda, 0x21, 0xe7, 0x38, 0x35, 0x6b, 0x43, 0xfb, 0xa3, 0xea,
+	0xd0, 0xd9, 0x78, 0xf0, 0xba, 0xcf, 0xb7, 0x2f, 0x62, 0xbc, 0x01, 0xb4, 0xaa, 0xa3, 0x8a, 0xd0,
+	0x2d, 0x13, 0xec, 0xb6, 0xfb, 0x6c, 0xeb, 0x6c, 0x8b, 0x76, 0xc8, 0x2b, 0xd8, 0xab, 0xdc, 0x47,
+	0xf2, 0xb4, 0xdc, 0x5b, 0x9f, 0x4e, 0x5d, 0x7a, 0xff, 0x02, 0xc6, 0xf8, 0x1e, 0x60, 0xdd, 0xf6,
+	0xe4, 0xc9, 0xc6, 0x4e, 0x7b, 0xa9, 0xbb, 0x4f, 0xef, 0xf5, 0x17, 0x5c, 0xaa, 0xad, 0x51, 0xe1,
+	0xb2, 0x71, 0x09, 0xba, 0xcf, 0xb6, 0xac, 0x98, 0x30, 0xd7, 0xbb, 0xf8, 0x17, 0xf9, 0xd5, 0xbf,
+	0x03, 0x00, 0xc2, 0x22, 0x4e, 0xb3, 0x5c, 0x0a, 0x00, 0x00,
 }
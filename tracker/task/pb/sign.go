@@ -71,6 +71,9 @@ func (self *FinishProveReq) hash() []byte {
 	if len(self.Remark) > 0 {
 		hasher.Write([]byte(self.Remark))
 	}
+	if len(self.Sigma) > 0 {
+		hasher.Write(self.Sigma)
+	}
 	return hasher.Sum(nil)
 }
 
@@ -0,0 +1,80 @@
+// Package testutil is fixture shared by tests of tracker services: tracker db in a temp dir with registry and key,
+// and registered clients and providers.
+package testutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/samoslab/nebula/tracker/config"
+	"github.com/samoslab/nebula/tracker/registry"
+	util_hash "github.com/samoslab/nebula/util/hash"
+	"github.com/stretchr/testify/require"
+)
+
+// key_bits small key size to keep tests fast
+const key_bits = 1024
+
+// Tracker is db of tracker with registry and key of tracker
+type Tracker struct {
+	Dir       string
+	DB        *bolt.DB
+	Registry  *registry.Registry
+	Key       *config.Key
+	providers int
+}
+
+// NewTracker create tracker db in a temp dir, Close removes it
+func NewTracker(t *testing.T) *Tracker {
+	dir, err := ioutil.TempDir("", "tracker-test")
+	require.NoError(t, err)
+	self := &Tracker{Dir: dir}
+	self.DB, err = bolt.Open(filepath.Join(dir, "tracker.db"), 0600, nil)
+	require.NoError(t, err)
+	self.Registry, err = registry.New(self.DB)
+	require.NoError(t, err)
+	self.Key = config.NewKey(NewNode(t).PriKey)
+	return self
+}
+
+func (self *Tracker) Close() {
+	self.DB.Close()
+	os.RemoveAll(self.Dir)
+}
+
+// Node is key of a client or provider
+type Node struct {
+	PriKey *rsa.PrivateKey
+	PubKey []byte // PKCS1 DER
+	NodeId []byte // sha1 of PubKey
+}
+
+func NewNode(t *testing.T) *Node {
+	priKey, err := rsa.GenerateKey(rand.Reader, key_bits)
+	require.NoError(t, err)
+	pubKey := x509.MarshalPKCS1PublicKey(&priKey.PublicKey)
+	return &Node{PriKey: priKey, PubKey: pubKey, NodeId: util_hash.Sha1(pubKey)}
+}
+
+// AddClient register a new client
+func (self *Tracker) AddClient(t *testing.T) *Node {
+	n := NewNode(t)
+	require.NoError(t, self.Registry.PutClient(&registry.Client{NodeId: n.NodeId, PublicKey: n.PubKey}))
+	return n
+}
+
+// AddProvider register a new provider, the n-th one is at 10.0.0.n:6666
+func (self *Tracker) AddProvider(t *testing.T) *Node {
+	n := NewNode(t)
+	self.providers++
+	require.NoError(t, self.Registry.PutProvider(&registry.Provider{NodeId: n.NodeId, PublicKey: n.PubKey,
+		Host: fmt.Sprintf("10.0.0.%d", self.providers), Port: 6666}))
+	return n
+}
@@ -63,3 +63,7 @@ func FromUint32(val uint32) []byte {
 func ToUint32(b []byte, startIdx int) uint32 {
 	return uint32(b[startIdx+3]) | uint32(b[startIdx+2])<<8 | uint32(b[startIdx+1])<<16 | uint32(b[startIdx])<<24
 }
+
+func ToUint64(b []byte, startIdx int) uint64 {
+	return uint64(ToUint32(b, startIdx))<<32 | uint64(ToUint32(b, startIdx+4))
+}
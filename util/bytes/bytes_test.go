@@ -82,3 +82,11 @@ func TestToUint32(t *testing.T) {
 		t.Errorf("failed")
 	}
 }
+
+func TestToUint64(t *testing.T) {
+	var v uint64 = 123456789987654321
+	b := append([]byte{1, 2}, FromUint64(v)...)
+	if ToUint64(b, 2) != v {
+		t.Errorf("failed")
+	}
+}
@@ -0,0 +1,292 @@
+// Package failover sends gRPC calls to a list of servers providing the same service. A call goes to the first healthy
+// server and fails over to the next one if the server is unavailable or does not answer in time.
+//
+// A call which may have reached a server is only sent again if its method is idempotent, non-idempotent calls fail
+// over only when the connection to a server is not ready before sending, because the server may have executed the
+// first one and there is no dedup key to detect it.
+package failover
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DownTime failed server is tried after healthy ones in down time
+	DownTime = time.Minute
+	// AttemptTimeout bounds one attempt of an idempotent call, so a blackholed server does not take all time of it
+	AttemptTimeout = 10 * time.Second
+	// ConnectTimeout wait for connection to a server before a non-idempotent call is sent to it
+	ConnectTimeout = 5 * time.Second
+)
+
+var NoServerErr = errors.New("no server")
+
+var carrierErr = errors.New("failover connection is not dialed")
+
+type server struct {
+	addr      string
+	seed      bool // configured, kept by Update even if it is not listed
+	conn      *grpc.ClientConn
+	downUntil time.Time
+	failures  int
+	calls     int  // running calls using conn
+	removed   bool // conn is closed when last running call finishes
+}
+
+// Servers list of servers of one kind with health state
+type Servers struct {
+	mutex      sync.Mutex
+	kind       string
+	log        logrus.FieldLogger
+	idempotent map[string]bool
+	servers    []*server
+	carrier    *grpc.ClientConn
+	// AttemptTimeout, ConnectTimeout and DownTime can be changed before first call
+	AttemptTimeout time.Duration
+	ConnectTimeout time.Duration
+	DownTime       time.Duration
+}
+
+// New create empty list, idempotent are full names of methods that are safe to send again, such as
+// "/metadata.pb.MatadataService/ListFiles"
+func New(kind string, log logrus.FieldLogger, idempotent ...string) *Servers {
+	self := &Servers{kind: kind, log: log, idempotent: make(map[string]bool, len(idempotent)),
+		AttemptTimeout: AttemptTimeout, ConnectTimeout: ConnectTimeout, DownTime: DownTime}
+	for _, method := range idempotent {
+		self.idempotent[method] = true
+	}
+	return self
+}
+
+// Add server if it is not in list, seed server is never removed by Update
+func (self *Servers) Add(addr string, seed bool) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.add(addr, seed)
+}
+
+// add must be called with mutex locked
+func (self *Servers) add(addr string, seed bool) error {
+	if len(addr) == 0 {
+		return nil
+	}
+	for _, s := range self.servers {
+		if s.addr == addr {
+			s.seed = s.seed || seed
+			return nil
+		}
+	}
+	// not blocking, calls to unreachable server fail with Unavailable so they fail over
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		return err
+	}
+	self.log.Infof("%s server %s", self.kind, addr)
+	self.servers = append(self.servers, &server{addr: addr, seed: seed, conn: conn})
+	return nil
+}
+
+// Update replace servers that are not seed with listed ones, connection of removed server is closed when calls
+// running on it finish
+func (self *Servers) Update(addrs []string) error {
+	listed := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		listed[addr] = true
+	}
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	kept := self.servers[:0]
+	for _, s := range self.servers {
+		if s.seed || listed[s.addr] {
+			kept = append(kept, s)
+			continue
+		}
+		self.log.Infof("%s server %s is not listed any more", self.kind, s.addr)
+		s.removed = true
+		if s.calls == 0 {
+			s.conn.Close()
+		}
+	}
+	self.servers = kept
+	for _, addr := range addrs {
+		if err := self.add(addr, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// candidates healthy servers in order, then the failed ones in case they are back
+func (self *Servers) candidates() []*server {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	now := time.Now()
+	res := make([]*server, 0, len(self.servers))
+	var down []*server
+	for _, s := range self.servers {
+		if now.Before(s.downUntil) {
+			down = append(down, s)
+		} else {
+			res = append(res, s)
+		}
+	}
+	return append(res, down...)
+}
+
+// List address of servers, healthy ones first
+func (self *Servers) List() []string {
+	servers := self.candidates()
+	res := make([]string, 0, len(servers))
+	for _, s := range servers {
+		res = append(res, s.addr)
+	}
+	return res
+}
+
+// hold connection of server for a call, false if server is removed since candidates were listed
+func (self *Servers) hold(s *server) bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if s.removed {
+		return false
+	}
+	s.calls++
+	return true
+}
+
+func (self *Servers) release(s *server) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if s.calls--; s.calls == 0 && s.removed {
+		s.conn.Close()
+	}
+}
+
+func (self *Servers) markDown(s *server, err error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	s.failures++
+	s.downUntil = time.Now().Add(self.DownTime)
+	self.log.Warnf("%s server %s failed %d times: %v", self.kind, s.addr, s.failures, err)
+}
+
+func (self *Servers) markUp(s *server) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if s.failures > 0 {
+		self.log.Infof("%s server %s is back", self.kind, s.addr)
+	}
+	s.failures, s.downUntil = 0, time.Time{}
+}
+
+// ready wait until connection is ready to send
+func (self *Servers) ready(ctx context.Context, conn *grpc.ClientConn) bool {
+	ctx, cancel := context.WithTimeout(ctx, self.ConnectTimeout)
+	defer cancel()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return true
+		}
+		if state == connectivity.Shutdown || !conn.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+}
+
+// failed is true if err means server is unavailable or does not answer
+func failed(err error) bool {
+	code := status.Code(err)
+	return code == codes.Unavailable || code == codes.DeadlineExceeded
+}
+
+// Do call f with connection of servers in order until the server is not failed. If idempotent is false f is only
+// called again when the connection was not ready, and it is called at most once for a ready connection.
+func (self *Servers) Do(ctx context.Context, idempotent bool, f func(ctx context.Context, conn *grpc.ClientConn) error) error {
+	err := NoServerErr
+	for _, s := range self.candidates() {
+		if ctx.Err() != nil {
+			break
+		}
+		if !self.hold(s) {
+			continue
+		}
+		if !idempotent && !self.ready(ctx, s.conn) {
+			self.release(s)
+			if ctx.Err() != nil {
+				break
+			}
+			err = status.Errorf(codes.Unavailable, "%s server %s is not connected", self.kind, s.addr)
+			self.markDown(s, err)
+			continue
+		}
+		actx, cancel := ctx, context.CancelFunc(func() {})
+		if idempotent {
+			actx, cancel = context.WithTimeout(ctx, self.AttemptTimeout)
+		}
+		err = f(actx, s.conn)
+		cancel()
+		self.release(s)
+		if !failed(err) {
+			self.markUp(s)
+			return err
+		}
+		self.markDown(s, err)
+		if !idempotent {
+			return err
+		}
+	}
+	if err == NoServerErr && ctx.Err() != nil {
+		if ctx.Err() == context.Canceled {
+			return status.Error(codes.Canceled, ctx.Err().Error())
+		}
+		return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	}
+	return err
+}
+
+// intercept unary calls on carrier connection and send them to servers
+func (self *Servers) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return self.Do(ctx, self.idempotent[method], func(ctx context.Context, conn *grpc.ClientConn) error {
+		return conn.Invoke(ctx, method, req, reply, opts...)
+	})
+}
+
+// Conn connection for generated clients, unary calls on it fail over servers. It never connects, so streams are
+// not supported, use Do for them.
+func (self *Servers) Conn() *grpc.ClientConn {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if self.carrier == nil {
+		self.carrier, _ = grpc.Dial(self.kind, grpc.WithInsecure(), grpc.WithUnaryInterceptor(self.intercept),
+			grpc.WithDialer(func(string, time.Duration) (net.Conn, error) { return nil, carrierErr }))
+	}
+	return self.carrier
+}
+
+// Close connections of servers
+func (self *Servers) Close() {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	for _, s := range self.servers {
+		s.removed = true
+		if s.calls == 0 {
+			s.conn.Close()
+		}
+	}
+	self.servers = nil
+	if self.carrier != nil {
+		self.carrier.Close()
+		self.carrier = nil
+	}
+}
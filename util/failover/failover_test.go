@@ -0,0 +1,184 @@
+package failover
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/samoslab/nebula/tracker/metadata/pb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const ping_method = "/metadata.pb.MatadataService/Ping"
+
+// testServer answers Ping and MkFolder with err after block is closed, other methods are not implemented
+type testServer struct {
+	pb.MatadataServiceServer
+	addr  string
+	calls int32
+	err   error
+	block chan struct{}
+	srv   *grpc.Server
+}
+
+func startServer(t *testing.T, err error, block chan struct{}) *testServer {
+	lis, er := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, er)
+	self := &testServer{addr: lis.Addr().String(), err: err, block: block, srv: grpc.NewServer()}
+	pb.RegisterMatadataServiceServer(self.srv, self)
+	go self.srv.Serve(lis)
+	return self
+}
+
+func (self *testServer) wait(ctx context.Context) error {
+	atomic.AddInt32(&self.calls, 1)
+	if self.block != nil {
+		select {
+		case <-self.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return self.err
+}
+
+func (self *testServer) Ping(ctx context.Context, req *pb.PingReq) (*pb.PingResp, error) {
+	if err := self.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.PingResp{}, nil
+}
+
+func (self *testServer) MkFolder(ctx context.Context, req *pb.MkFolderReq) (*pb.MkFolderResp, error) {
+	if err := self.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.MkFolderResp{}, nil
+}
+
+func (self *testServer) Calls() int {
+	return int(atomic.LoadInt32(&self.calls))
+}
+
+// unreachable address nobody listens on
+func unreachable(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+	return lis.Addr().String()
+}
+
+func newServers(t *testing.T, addrs ...string) *Servers {
+	self := New("test", logrus.StandardLogger(), ping_method)
+	self.AttemptTimeout, self.ConnectTimeout = 500*time.Millisecond, 500*time.Millisecond
+	for _, addr := range addrs {
+		require.NoError(t, self.Add(addr, false))
+	}
+	return self
+}
+
+func TestIdempotent(t *testing.T) {
+	down := unreachable(t)
+	unavailable := startServer(t, status.Error(codes.Unavailable, "overloaded"), nil)
+	defer unavailable.srv.Stop()
+	blackhole := startServer(t, nil, make(chan struct{}))
+	defer blackhole.srv.Stop()
+	ok := startServer(t, nil, nil)
+	defer ok.srv.Stop()
+	s := newServers(t, down, unavailable.addr, blackhole.addr, ok.addr)
+	defer s.Close()
+
+	_, err := pb.NewMatadataServiceClient(s.Conn()).Ping(context.Background(), &pb.PingReq{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, unavailable.Calls())
+	assert.Equal(t, 1, blackhole.Calls())
+	assert.Equal(t, 1, ok.Calls())
+	assert.Equal(t, []string{ok.addr, down, unavailable.addr, blackhole.addr}, s.List(), "failed servers are tried last")
+
+	_, err = pb.NewMatadataServiceClient(s.Conn()).Ping(context.Background(), &pb.PingReq{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, ok.Calls())
+	assert.Equal(t, 1, blackhole.Calls(), "healthy server is tried first")
+}
+
+func TestNotIdempotent(t *testing.T) {
+	unavailable := startServer(t, status.Error(codes.Unavailable, "overloaded"), nil)
+	defer unavailable.srv.Stop()
+	ok := startServer(t, nil, nil)
+	defer ok.srv.Stop()
+	s := newServers(t, unreachable(t), unavailable.addr, ok.addr)
+	defer s.Close()
+
+	_, err := pb.NewMatadataServiceClient(s.Conn()).MkFolder(context.Background(), &pb.MkFolderReq{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, unavailable.Calls())
+	assert.Equal(t, 0, ok.Calls(), "call which reached server is not sent again")
+
+	_, err = pb.NewMatadataServiceClient(s.Conn()).MkFolder(context.Background(), &pb.MkFolderReq{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, ok.Calls(), "failed servers are tried last")
+
+	s = newServers(t, unreachable(t), ok.addr)
+	defer s.Close()
+	_, err = pb.NewMatadataServiceClient(s.Conn()).MkFolder(context.Background(), &pb.MkFolderReq{})
+	require.NoError(t, err, "call fails over if server is not connected before sending")
+	assert.Equal(t, 2, ok.Calls())
+}
+
+func TestDeadline(t *testing.T) {
+	blackhole := startServer(t, nil, make(chan struct{}))
+	defer blackhole.srv.Stop()
+	ok := startServer(t, nil, nil)
+	defer ok.srv.Stop()
+	s := newServers(t, blackhole.addr, ok.addr)
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_, err := pb.NewMatadataServiceClient(s.Conn()).MkFolder(ctx, &pb.MkFolderReq{})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, 0, ok.Calls(), "call which reached server is not sent again")
+	assert.Equal(t, []string{ok.addr, blackhole.addr}, s.List(), "server not answering is tried last")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = pb.NewMatadataServiceClient(s.Conn()).Ping(ctx, &pb.PingReq{})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.Equal(t, []string{ok.addr, blackhole.addr}, s.List(), "canceled call does not fail server")
+}
+
+func TestUpdate(t *testing.T) {
+	block := make(chan struct{})
+	running := startServer(t, nil, block)
+	defer running.srv.Stop()
+	s := newServers(t)
+	defer s.Close()
+	require.NoError(t, s.Add("seed:1", true))
+	require.NoError(t, s.Update([]string{running.addr, "a:1"}))
+	assert.Equal(t, []string{"seed:1", running.addr, "a:1"}, s.List())
+
+	done := make(chan error)
+	go func() {
+		_, err := pb.NewMatadataServiceClient(s.Conn()).MkFolder(context.Background(), &pb.MkFolderReq{})
+		done <- err
+	}()
+	for running.Calls() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, s.Update([]string{"b:1", "a:1"}))
+	assert.Equal(t, []string{"a:1", "b:1", "seed:1"}, s.List(), "seed server is kept but failed, server not listed is removed")
+	close(block)
+	assert.NoError(t, <-done, "connection of removed server is kept for running call")
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, srv := range s.servers {
+		assert.NotEqual(t, running.addr, srv.addr)
+	}
+}
@@ -0,0 +1,11 @@
+// +build !purego
+
+package filecheck
+
+import "github.com/Nik-U/pbc"
+
+// pbc backend requires cgo with libpbc and gmp, build with tag purego to use the pure go backend instead
+var (
+	generateA            = pbc.GenerateA
+	newPairingFromString = pbc.NewPairingFromString
+)
@@ -0,0 +1,11 @@
+// +build purego
+
+package filecheck
+
+import "github.com/samoslab/nebula/util/pairing"
+
+// pure go backend, byte compatible with pbc for the type a params generated here
+var (
+	generateA            = pairing.GenerateA
+	newPairingFromString = pairing.NewPairingFromString
+)
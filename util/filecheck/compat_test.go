@@ -0,0 +1,97 @@
+// +build !purego
+
+package filecheck
+
+import (
+	"bytes"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/Nik-U/pbc"
+	"github.com/samoslab/nebula/util/pairing"
+)
+
+// TestBackendCompat check params, elements and tags are interchangeable between pbc and pure go backend
+func TestBackendCompat(t *testing.T) {
+	for _, paramStr := range []string{pbc.GenerateA(160, 512).String(), pairing.GenerateA(160, 512).String()} {
+		cp, err := pbc.NewPairingFromString(paramStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gp, err := pairing.NewPairingFromString(paramStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cp.G1Length() != gp.G1Length() || cp.ZrLength() != gp.ZrLength() {
+			t.Fatalf("length not same")
+		}
+		pubKey := cp.NewG2().Rand().Bytes()
+		for i := uint32(1); i <= 10; i++ {
+			h := hash(pubKey, i)
+			c := cp.NewG1().SetFromHash(h)
+			g := gp.NewG1().SetFromHash(h)
+			if !bytes.Equal(c.Bytes(), g.Bytes()) {
+				t.Fatalf("hash to G1 not same, i: %d", i)
+			}
+			v := new(big.Int).SetBytes(h)
+			c.PowZn(c, cp.NewZr().SetBig(v))
+			g.PowZn(g, gp.NewZr().SetBig(v))
+			if !bytes.Equal(c.Bytes(), g.Bytes()) {
+				t.Fatalf("PowZn not same, i: %d", i)
+			}
+			r := cp.NewG1().Rand()
+			c.Mul(c, r)
+			g.Mul(g, gp.NewG1().SetBytes(r.Bytes()))
+			if !bytes.Equal(c.Bytes(), g.Bytes()) {
+				t.Fatalf("Mul not same, i: %d", i)
+			}
+		}
+	}
+}
+
+// TestBackendTags check tags generated by pbc are proved and verified by pure go backend
+func TestBackendTags(t *testing.T) {
+	var chunkSize uint32 = 512
+	path, data := writeTempFile(t, 8*1024+100)
+	defer os.Remove(path)
+	paramStr, generator, pubKeyBytes, random, phi, err := GenMetadata(path, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunkSeq, err := GenChallenge(uint32(len(phi)), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigma, err := Prove(paramStr, phi, chunkSeq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu, err := Mu(data, chunkSize, chunkSeq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gp, err := pairing.NewPairingFromString(paramStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := gp.NewG1().Set1()
+	agg := gp.NewG1().Set1()
+	for k, v := range chunkSeq {
+		e := gp.NewG1().SetBytes(phi[k-1])
+		e.PowZn(e, gp.NewZr().SetBig(new(big.Int).SetBytes(v)))
+		s.Mul(s, e)
+		h := gp.NewG1().SetFromHash(hash(pubKeyBytes, k))
+		h.PowZn(h, gp.NewZr().SetBig(new(big.Int).SetBytes(v)))
+		agg.Mul(agg, h)
+	}
+	if !bytes.Equal(s.Bytes(), sigma) {
+		t.Fatalf("sigma not same")
+	}
+	agg.Mul(agg, gp.NewG1().PowZn(gp.NewG1().SetBytes(random), gp.NewZr().SetBig(new(big.Int).SetBytes(mu))))
+	left := gp.NewGT().Pair(s, gp.NewG2().SetBytes(generator))
+	right := gp.NewGT().Pair(agg, gp.NewG2().SetBytes(pubKeyBytes))
+	if !left.Equals(right) {
+		t.Errorf("verify by pure go backend failed")
+	}
+}
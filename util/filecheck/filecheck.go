@@ -1,15 +1,19 @@
 package filecheck
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"errors"
+	"fmt"
 	"io"
 	"math/big"
 	"os"
 
-	"github.com/Nik-U/pbc"
 	util_bytes "github.com/samoslab/nebula/util/bytes"
 )
 
+const coefficient_bytes = 20
+
 func GenMetadata(filepath string, chunkSize uint32) (paramStr string, generator []byte, pubKeyBytes []byte, random []byte, phi [][]byte, er error) {
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -24,7 +28,7 @@ func GenMetadata(filepath string, chunkSize uint32) (paramStr string, generator
 	}
 	size := (fi.Size() + int64(chunkSize) - 1) / int64(chunkSize)
 	phi = make([][]byte, 0, size)
-	params := pbc.GenerateA(160, 512)
+	params := generateA(160, 512)
 	paramStr = params.String()
 	pairing := params.NewPairing()
 	g := pairing.NewG2().Rand()
@@ -67,3 +71,102 @@ func hash(pubKeyBytes []byte, i uint32) []byte {
 	hasher.Write(util_bytes.FromUint32(i))
 	return hasher.Sum(nil)
 }
+
+var ErrChunkSeqOutOfRange = errors.New("chunk seq out of range")
+
+// GenChallenge choose count distinct chunks of chunkCount chunks, key is 1-based chunk seq and value is random coefficient
+func GenChallenge(chunkCount uint32, count int) (chunkSeq map[uint32][]byte, er error) {
+	if count > int(chunkCount) {
+		count = int(chunkCount)
+	}
+	chunkSeq = make(map[uint32][]byte, count)
+	for len(chunkSeq) < count {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(chunkCount)))
+		if err != nil {
+			return nil, err
+		}
+		seq := uint32(n.Int64()) + 1
+		if _, ok := chunkSeq[seq]; ok {
+			continue
+		}
+		v := make([]byte, coefficient_bytes)
+		if _, err = rand.Read(v); err != nil {
+			return nil, err
+		}
+		chunkSeq[seq] = v
+	}
+	return
+}
+
+// Mu compute linear combination of challenged chunks of data, same as provider prove result
+func Mu(data []byte, chunkSize uint32, chunkSeq map[uint32][]byte) ([]byte, error) {
+	res := big.NewInt(0)
+	length := int64(len(data))
+	for k, v := range chunkSeq {
+		start := int64(k-1) * int64(chunkSize)
+		if k < 1 || start >= length {
+			return nil, ErrChunkSeqOutOfRange
+		}
+		end := start + int64(chunkSize)
+		if end > length {
+			end = length
+		}
+		bm := new(big.Int).SetBytes(data[start:end])
+		bm.Mul(bm, new(big.Int).SetBytes(v))
+		res.Add(res, bm)
+	}
+	return res.Bytes(), nil
+}
+
+// Prove aggregate tags of challenged chunks: sigma = Π phi[i]^v[i]
+func Prove(paramStr string, phi [][]byte, chunkSeq map[uint32][]byte) (sigma []byte, er error) {
+	pairing, err := newPairingFromString(paramStr)
+	if err != nil {
+		return nil, err
+	}
+	s := pairing.NewG1().Set1()
+	for k, v := range chunkSeq {
+		if k < 1 || int(k) > len(phi) {
+			return nil, ErrChunkSeqOutOfRange
+		}
+		if len(phi[k-1]) != int(pairing.G1Length()) {
+			return nil, fmt.Errorf("phi of chunk %d length error", k)
+		}
+		e := pairing.NewG1().SetBytes(phi[k-1])
+		e.PowZn(e, pairing.NewZr().SetBig(new(big.Int).SetBytes(v)))
+		s.Mul(s, e)
+	}
+	return s.Bytes(), nil
+}
+
+// Verify check e(sigma, g) == e(Π H(pubKey, i)^v[i] · u^mu, pubKey)
+func Verify(paramStr string, generator []byte, pubKeyBytes []byte, random []byte, chunkSeq map[uint32][]byte, sigma []byte, mu []byte) (bool, error) {
+	pairing, err := newPairingFromString(paramStr)
+	if err != nil {
+		return false, err
+	}
+	if len(generator) != int(pairing.G2Length()) || len(pubKeyBytes) != int(pairing.G2Length()) {
+		return false, errors.New("generator or public key length error")
+	}
+	if len(random) != int(pairing.G1Length()) || len(sigma) != int(pairing.G1Length()) {
+		return false, errors.New("random or sigma length error")
+	}
+	g := pairing.NewG2().SetBytes(generator)
+	pubKey := pairing.NewG2().SetBytes(pubKeyBytes)
+	u := pairing.NewG1().SetBytes(random)
+	s := pairing.NewG1().SetBytes(sigma)
+	agg := pairing.NewG1().Set1()
+	for k, v := range chunkSeq {
+		if k < 1 {
+			return false, ErrChunkSeqOutOfRange
+		}
+		h := pairing.NewG1().SetFromHash(hash(pubKeyBytes, k))
+		h.PowZn(h, pairing.NewZr().SetBig(new(big.Int).SetBytes(v)))
+		agg.Mul(agg, h)
+	}
+	um := pairing.NewG1().PowZn(u, pairing.NewZr().SetBig(new(big.Int).SetBytes(mu)))
+	agg.Mul(agg, um)
+	left := pairing.NewGT().Pair(s, g)
+	right := pairing.NewGT().Pair(agg, pubKey)
+	return left.Equals(right), nil
+}
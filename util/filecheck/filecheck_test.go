@@ -0,0 +1,98 @@
+package filecheck
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func writeTempFile(t *testing.T, size int) (path string, data []byte) {
+	data = make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	f, err := ioutil.TempFile("", "filecheck-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name(), data
+}
+
+func TestProveAndVerify(t *testing.T) {
+	var chunkSize uint32 = 1024
+	path, data := writeTempFile(t, 10*1024+300)
+	defer os.Remove(path)
+	paramStr, generator, pubKey, random, phi, err := GenMetadata(path, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(phi) != 11 {
+		t.Fatalf("phi count %d", len(phi))
+	}
+	chunkSeq, err := GenChallenge(uint32(len(phi)), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunkSeq) != 5 {
+		t.Fatalf("challenge count %d", len(chunkSeq))
+	}
+	chunkSeq[11] = []byte{7, 7, 7}
+	sigma, err := Prove(paramStr, phi, chunkSeq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu, err := Mu(data, chunkSize, chunkSeq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := Verify(paramStr, generator, pubKey, random, chunkSeq, sigma, mu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("verify failed")
+	}
+
+	tampered := append([]byte{}, data...)
+	for k := range chunkSeq {
+		tampered[int(k-1)*int(chunkSize)] ^= 0x01
+		break
+	}
+	badMu, err := Mu(tampered, chunkSize, chunkSeq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ = Verify(paramStr, generator, pubKey, random, chunkSeq, sigma, badMu); ok {
+		t.Errorf("tampered data verified")
+	}
+
+	other, err := GenChallenge(uint32(len(phi)), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ = Verify(paramStr, generator, pubKey, random, other, sigma, mu); ok {
+		t.Errorf("proof verified against another challenge")
+	}
+
+	if _, err = Prove(paramStr, phi, map[uint32][]byte{12: {1}}); err != ErrChunkSeqOutOfRange {
+		t.Errorf("expect out of range")
+	}
+}
+
+func TestGenChallenge(t *testing.T) {
+	chunkSeq, err := GenChallenge(3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunkSeq) != 3 {
+		t.Errorf("count %d", len(chunkSeq))
+	}
+	for k, v := range chunkSeq {
+		if k < 1 || k > 3 || len(v) != coefficient_bytes {
+			t.Errorf("wrong challenge %d: %x", k, v)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package filecheck
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// known answer vector generated once by GenMetadata with type a params of 160 and 512 bits, both backends must
+// prove and verify it, so encoding of params, elements, tags, sigma and mu can not change silently
+const (
+	kat_chunk_size = 64
+	kat_param_str  = "type a\nq 7512521416099077440005521105550594508499246476936273431285899647639990565214218396409264356544374673003092994632653242194087013840523811463040912854954751\nh 10280546585731249732460055556524993490587913149658730251122405077883662656805391444604916508742708510248192\nr 730751167114595186142829002853739519958614802431\nexp2 159\nexp1 138\nsign1 1\nsign0 -1\n"
+	kat_generator  = "375478e897516ecee841b3425ad93c48fe7b093680e2964c5f784b7c0f054aba3075a7b7fdd993d471b71ad31845f9e49ef70e964f64129415d1abe17920b997" +
+		"8421bbe9db07793124745f76fdcba1ef01c4405d93e8a3adc99d1d542e84a3cb39efdc89b0dd9b09f060c612eb837f11e393592d85568b3f2b555c87077f7f74"
+	kat_pub_key = "73551d8acedb84c450337663e1f964fbb521a418962461c3196f32fb9e25150999a9464afaaa303388456979e87561a4da8427f1b99a76c9265281c0af288ad7" +
+		"5cfe77d7e51c92c6dc441e0874d6b55830cfb1b8935a4d9221008715aa53d96f1be33fd444a9fe469dc8faea61df3a5cfdccc24ef71b3f38741bff3bf97bb65c"
+	kat_random = "5cd0647c3ee5d478d5f73c49c49a096b78c4dfe8098a0d80e66e13eb27a491464aa1208b9a887a39d90b4437098feac52b572473ddf3af465178ea63a8d49a0d" +
+		"8a4034c445fdb49a7d68bc9c7343237950dd725a8001218387d7a0ad3741fcfe65a2f59e7dadb2b5f8997edb92ec36f7c5ab3350f0349e3fb06610f1c675fee7"
+	kat_sigma = "4d036a4fdc7e14a60c1083c13ba540aa20b95ad632b85a96324486e402d0f8f802dc13604d7144b1fc046fc986eccd7eabbbcacf5df6cfab38e5e32d7a6a5c8b" +
+		"0f12c8e1e09830716044378535af3367d9146d889625c66656c8b3d27380858f2fda8a3e2228d9307d000f96b6526c67a40d52f91e14f2c5703771398e13f157"
+	kat_mu = "231ce4685162738495a6b7c8d9eafc0d1e2efbb761fc0d1e2f405162738495a6b7c8d9eafc0d0cfbd9eafc0d1e2f405162738495a6b7c89550fb95a6fb132b43" +
+		"497040cc"
+)
+
+var kat_phi = []string{
+	"6c5bd8222b2d8c44165c7c2994b2dd4ec9fc25416ca28d4b04d02c436ee16f2f9dd7d840ef1b72a97452c8d28581afabfcdfa805c05b2d9fc1d7888425146ed6" +
+		"494d0d9c619db34bd9d08f05d4c9eaf34976548bffc2711730286de4b327471d20edd8ef2917cfbbad97d1af7e7adc01c9691195192214f9293a0a692f34dcdc",
+	"14194deaaa3ec6f085277d8da1bac8b1863fb4263ae146df57dd2e75ecc0dcdca7c5ae3e3c8b21c1dbc5d45addb44a5bf103c9ab2c719c83edf4cfed0aa73d54" +
+		"72b4ea02d40d3dd236451580623cd8496089855633bc8fe4d5ada807755c9da12119338ec9dba801e9458a604dfd2a8aea1f546bc745278835efb942422b1fa4",
+	"245dbb7c996fa7d2649a6501acf20dbd26907519ee41ad7f3bc16061062835d29a4d69b5c78e4508a196c2e001379971ee408f067c69e2aa508091806349119c" +
+		"1cb2456007f6704ffdf9d96adaad84a116e66d817f9a61773043e5464f7f137e77099d77a038eb21cea87dff1e0ae402df3efb4416faf373980ea656a4bb8297",
+	"40390462aa5911e9589ca20d9df032dd2f15de6db63a1923b7855361c3305313a0059508d81c55f5cc6649b117bd1053382905f2c549240fd80b391a1993b41e" +
+		"424b988a58658051ac6174c7319d04bb87a2a7130772b2816145e7fcc2129b30820f2b6b491087101654ac4ca14fc8e8534448b930787d606203268c857806f7",
+}
+
+// katData is 200 bytes, 4 chunks and the last one is short
+func katData() []byte {
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i*7 + 3)
+	}
+	return data
+}
+
+func katChunkSeq() map[uint32][]byte {
+	return map[uint32][]byte{1: {0x11, 0x22, 0x33}, 3: {0x44, 0x55, 0x66, 0x77}, 4: {0x01}}
+}
+
+func unhex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestKnownAnswer(t *testing.T) {
+	phi := make([][]byte, 0, len(kat_phi))
+	for _, p := range kat_phi {
+		phi = append(phi, unhex(t, p))
+	}
+	generator, pubKey, random := unhex(t, kat_generator), unhex(t, kat_pub_key), unhex(t, kat_random)
+	chunkSeq := katChunkSeq()
+
+	mu, err := Mu(katData(), kat_chunk_size, chunkSeq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mu, unhex(t, kat_mu)) {
+		t.Errorf("mu %x, expected %s", mu, kat_mu)
+	}
+	sigma, err := Prove(kat_param_str, phi, chunkSeq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sigma, unhex(t, kat_sigma)) {
+		t.Errorf("sigma %x, expected %s", sigma, kat_sigma)
+	}
+	ok, err := Verify(kat_param_str, generator, pubKey, random, chunkSeq, unhex(t, kat_sigma), unhex(t, kat_mu))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("known answer is not verified")
+	}
+
+	badMu := unhex(t, kat_mu)
+	badMu[len(badMu)-1] ^= 0x01
+	if ok, _ = Verify(kat_param_str, generator, pubKey, random, chunkSeq, unhex(t, kat_sigma), badMu); ok {
+		t.Errorf("wrong mu verified")
+	}
+	other := katChunkSeq()
+	other[2] = []byte{0x01}
+	if ok, _ = Verify(kat_param_str, generator, pubKey, random, other, unhex(t, kat_sigma), unhex(t, kat_mu)); ok {
+		t.Errorf("proof verified against another challenge")
+	}
+}
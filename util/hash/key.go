@@ -0,0 +1,180 @@
+package hash
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	std_hash "hash"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Algorithm is the multihash code of hash function used for block and file key
+type Algorithm uint64
+
+const (
+	SHA1       Algorithm = 0x11
+	SHA256     Algorithm = 0x12
+	BLAKE2b256 Algorithm = 0xb220
+)
+
+const legacy_key_len = sha1.Size
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown hash algorithm")
+	ErrInvalidKey       = errors.New("invalid key")
+)
+
+var algorithmNames = map[Algorithm]string{SHA1: "sha1", SHA256: "sha256", BLAKE2b256: "blake2b-256"}
+
+// ParseAlgorithm parse algorithm name, empty name is SHA1
+func ParseAlgorithm(name string) (Algorithm, error) {
+	if name == "" {
+		return SHA1, nil
+	}
+	for k, v := range algorithmNames {
+		if v == name {
+			return k, nil
+		}
+	}
+	return 0, ErrUnknownAlgorithm
+}
+
+func (self Algorithm) String() string {
+	if name, ok := algorithmNames[self]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func (self Algorithm) Size() int {
+	switch self {
+	case SHA1:
+		return sha1.Size
+	case SHA256:
+		return sha256.Size
+	case BLAKE2b256:
+		return blake2b.Size256
+	}
+	return 0
+}
+
+func (self Algorithm) New() (std_hash.Hash, error) {
+	switch self {
+	case SHA1:
+		return sha1.New(), nil
+	case SHA256:
+		return sha256.New(), nil
+	case BLAKE2b256:
+		return blake2b.New256(nil)
+	}
+	return nil, ErrUnknownAlgorithm
+}
+
+// Key encode digest as self-describing key: varint code, varint length, digest.
+// SHA1 key keeps the legacy format of raw 20 bytes digest.
+func Key(alg Algorithm, digest []byte) []byte {
+	if alg == SHA1 {
+		return digest
+	}
+	buf := make([]byte, 2*binary.MaxVarintLen64+len(digest))
+	n := binary.PutUvarint(buf, uint64(alg))
+	n += binary.PutUvarint(buf[n:], uint64(len(digest)))
+	n += copy(buf[n:], digest)
+	return buf[:n]
+}
+
+// ParseKey return algorithm and digest of key, 20 bytes key is legacy SHA1
+func ParseKey(key []byte) (alg Algorithm, digest []byte, err error) {
+	if len(key) == legacy_key_len {
+		return SHA1, key, nil
+	}
+	code, n := binary.Uvarint(key)
+	if n <= 0 {
+		return 0, nil, ErrInvalidKey
+	}
+	length, m := binary.Uvarint(key[n:])
+	if m <= 0 {
+		return 0, nil, ErrInvalidKey
+	}
+	alg, digest = Algorithm(code), key[n+m:]
+	if alg.Size() == 0 {
+		return 0, nil, ErrUnknownAlgorithm
+	}
+	if uint64(len(digest)) != length || length != uint64(alg.Size()) {
+		return 0, nil, ErrInvalidKey
+	}
+	return alg, digest, nil
+}
+
+// Sum calculate key of data
+func Sum(alg Algorithm, data []byte) ([]byte, error) {
+	h, err := alg.New()
+	if err != nil {
+		return nil, err
+	}
+	h.Write(data)
+	return Key(alg, h.Sum(nil)), nil
+}
+
+// SumFile calculate key of file, filePath must be exist
+func SumFile(alg Algorithm, filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return SumReader(alg, file)
+}
+
+// SumReader calculate key of all data read from reader
+func SumReader(alg Algorithm, reader io.Reader) ([]byte, error) {
+	h, err := alg.New()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(h, reader); err != nil {
+		return nil, err
+	}
+	return Key(alg, h.Sum(nil)), nil
+}
+
+// VerifyKey check data with the algorithm of key
+func VerifyKey(key []byte, data []byte) bool {
+	alg, _, err := ParseKey(key)
+	if err != nil {
+		return false
+	}
+	sum, err := Sum(alg, data)
+	return err == nil && bytes.Equal(sum, key)
+}
+
+// VerifyFileKey check file with the algorithm of key, the error is about key or reading file
+func VerifyFileKey(key []byte, filePath string) (bool, error) {
+	alg, _, err := ParseKey(key)
+	if err != nil {
+		return false, err
+	}
+	sum, err := SumFile(alg, filePath)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(sum, key), nil
+}
+
+// VerifyReaderKey check all data read from reader with the algorithm of key
+func VerifyReaderKey(key []byte, reader io.Reader) (bool, error) {
+	alg, _, err := ParseKey(key)
+	if err != nil {
+		return false, err
+	}
+	sum, err := SumReader(alg, reader)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(sum, key), nil
+}
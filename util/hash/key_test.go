@@ -0,0 +1,83 @@
+package hash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	data := []byte("nebula")
+	for _, c := range []struct {
+		alg    Algorithm
+		keyLen int
+		prefix string
+	}{{SHA1, 20, ""}, {SHA256, 34, "1220"}, {BLAKE2b256, 36, "a0e40220"}} {
+		key, err := Sum(c.alg, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(key) != c.keyLen || hex.EncodeToString(key[:len(c.prefix)/2]) != c.prefix {
+			t.Errorf("%s key error: %x", c.alg, key)
+		}
+		alg, digest, err := ParseKey(key)
+		if err != nil || alg != c.alg || len(digest) != c.alg.Size() {
+			t.Errorf("%s parse key error: %v", c.alg, err)
+		}
+		if !VerifyKey(key, data) || VerifyKey(key, []byte("other")) {
+			t.Errorf("%s verify key error", c.alg)
+		}
+		name, err := ParseAlgorithm(c.alg.String())
+		if err != nil || name != c.alg {
+			t.Errorf("%s parse algorithm error: %v", c.alg, err)
+		}
+	}
+	if !bytes.Equal(mustSum(t, SHA1, data), Sha1(data)) {
+		t.Errorf("sha1 key is not legacy format")
+	}
+	if alg, err := ParseAlgorithm(""); err != nil || alg != SHA1 {
+		t.Errorf("default algorithm should be sha1")
+	}
+	if _, _, err := ParseKey([]byte{0x12, 0x20, 1, 2, 3}); err != ErrInvalidKey {
+		t.Errorf("expect invalid key, got %v", err)
+	}
+	if _, _, err := ParseKey([]byte{0x13, 0x01, 1}); err != ErrUnknownAlgorithm {
+		t.Errorf("expect unknown algorithm, got %v", err)
+	}
+}
+
+func mustSum(t *testing.T, alg Algorithm, data []byte) []byte {
+	key, err := Sum(alg, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestSumFile(t *testing.T) {
+	data := bytes.Repeat([]byte("nebula"), 10000)
+	f, err := ioutil.TempFile("", "hash-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(data)
+	f.Close()
+	for _, alg := range []Algorithm{SHA1, SHA256, BLAKE2b256} {
+		key, err := SumFile(alg, f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(key, mustSum(t, alg, data)) {
+			t.Errorf("%s file key not same as data key", alg)
+		}
+		if ok, err := VerifyFileKey(key, f.Name()); !ok || err != nil {
+			t.Errorf("%s verify file key failed: %v", alg, err)
+		}
+		if ok, err := VerifyReaderKey(key, bytes.NewReader(data[1:])); ok || err != nil {
+			t.Errorf("%s verify reader key should fail on other data: %v", alg, err)
+		}
+	}
+}
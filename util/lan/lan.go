@@ -0,0 +1,213 @@
+// Package lan let provider announce itself on local network by SSDP, so client on the same network
+// can transfer directly. Announcement is signed by node key, client only trusts address whose
+// signer public key hashes to the node id.
+package lan
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/huin/goupnp/httpu"
+	"github.com/huin/goupnp/ssdp"
+	util_hash "github.com/samoslab/nebula/util/hash"
+)
+
+// SearchTarget of nebula provider in SSDP
+const SearchTarget = "urn:samos-io:service:nebula-provider:1"
+
+const ssdp_addr = "239.255.255.250:1900"
+const sign_prefix = "nebula-lan:"
+
+// sign_window is max difference of announcement timestamp and local time
+const sign_window = 5 * time.Minute
+
+const (
+	header_node      = "X-NEBULA-NODE"
+	header_pubkey    = "X-NEBULA-PUBKEY"
+	header_timestamp = "X-NEBULA-TIMESTAMP"
+	header_sign      = "X-NEBULA-SIGN"
+)
+
+var InvalidAnnouncementErr = errors.New("invalid lan announcement")
+
+// Provider is a verified local address of provider
+type Provider struct {
+	NodeId []byte
+	Host   string
+	Port   uint32
+	Time   time.Time
+}
+
+func signHash(nodeId []byte, host string, port uint32, timestamp uint64) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte(sign_prefix))
+	hasher.Write(nodeId)
+	hasher.Write([]byte(host))
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint32(b, port)
+	binary.BigEndian.PutUint64(b[4:], timestamp)
+	hasher.Write(b)
+	return hasher.Sum(nil)
+}
+
+// Announcer answer SSDP search for nebula provider until Close
+type Announcer struct {
+	conn        net.PacketConn
+	nodeId      []byte
+	pubKeyBytes []byte
+	priKey      *rsa.PrivateKey
+	port        uint32
+}
+
+// Announce listen SSDP multicast on all interfaces, port is the local port of provider service
+func Announce(nodeId []byte, pubKeyBytes []byte, priKey *rsa.PrivateKey, port uint16) (*Announcer, error) {
+	addr, err := net.ResolveUDPAddr("udp4", ssdp_addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	return newAnnouncer(conn, nodeId, pubKeyBytes, priKey, port), nil
+}
+
+func newAnnouncer(conn net.PacketConn, nodeId []byte, pubKeyBytes []byte, priKey *rsa.PrivateKey, port uint16) *Announcer {
+	a := &Announcer{conn: conn, nodeId: nodeId, pubKeyBytes: pubKeyBytes, priKey: priKey, port: uint32(port)}
+	go httpu.Serve(conn, a)
+	return a
+}
+
+func (a *Announcer) Close() error {
+	return a.conn.Close()
+}
+
+// ServeMessage answer M-SEARCH for nebula provider or all services
+func (a *Announcer) ServeMessage(r *http.Request) {
+	if r.Method != "M-SEARCH" {
+		return
+	}
+	if st := r.Header.Get("ST"); st != SearchTarget && st != ssdp.SSDPAll {
+		return
+	}
+	peer, err := net.ResolveUDPAddr("udp", r.RemoteAddr)
+	if err != nil {
+		return
+	}
+	host, err := localIP(peer)
+	if err != nil {
+		return
+	}
+	resp, err := a.response(host, uint64(time.Now().Unix()))
+	if err != nil {
+		return
+	}
+	a.conn.WriteTo(resp, peer)
+}
+
+// localIP is the address which peer can reach, chosen by route without sending anything
+func localIP(peer *net.UDPAddr) (string, error) {
+	conn, err := net.DialUDP("udp", nil, peer)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
+
+func (a *Announcer) response(host string, timestamp uint64) ([]byte, error) {
+	sign, err := rsa.SignPKCS1v15(rand.Reader, a.priKey, crypto.SHA256, signHash(a.nodeId, host, a.port, timestamp))
+	if err != nil {
+		return nil, err
+	}
+	nodeId := hex.EncodeToString(a.nodeId)
+	return []byte(fmt.Sprintf("HTTP/1.1 200 OK\r\n"+
+		"CACHE-CONTROL: max-age=1800\r\n"+
+		"EXT:\r\n"+
+		"LOCATION: http://%s/\r\n"+
+		"ST: %s\r\n"+
+		"USN: uuid:%s::%s\r\n"+
+		"%s: %s\r\n"+
+		"%s: %s\r\n"+
+		"%s: %d\r\n"+
+		"%s: %s\r\n\r\n",
+		net.JoinHostPort(host, strconv.Itoa(int(a.port))), SearchTarget, nodeId, SearchTarget,
+		header_node, nodeId,
+		header_pubkey, base64.StdEncoding.EncodeToString(a.pubKeyBytes),
+		header_timestamp, timestamp,
+		header_sign, base64.StdEncoding.EncodeToString(sign))), nil
+}
+
+// Discover search providers on local network, wait is at least 1 second
+func Discover(maxWaitSeconds int) ([]*Provider, error) {
+	client, err := httpu.NewHTTPUClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	responses, err := ssdp.SSDPRawSearch(client, SearchTarget, maxWaitSeconds, 2)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	res := make([]*Provider, 0, len(responses))
+	for _, resp := range responses {
+		if p, err := verify(resp.Header, now); err == nil {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+func verify(header http.Header, now time.Time) (*Provider, error) {
+	nodeId, err := hex.DecodeString(header.Get(header_node))
+	if err != nil {
+		return nil, InvalidAnnouncementErr
+	}
+	pubKeyBytes, err := base64.StdEncoding.DecodeString(header.Get(header_pubkey))
+	if err != nil || string(util_hash.Sha1(pubKeyBytes)) != string(nodeId) {
+		return nil, InvalidAnnouncementErr
+	}
+	pubKey, err := x509.ParsePKCS1PublicKey(pubKeyBytes)
+	if err != nil {
+		return nil, InvalidAnnouncementErr
+	}
+	sign, err := base64.StdEncoding.DecodeString(header.Get(header_sign))
+	if err != nil {
+		return nil, InvalidAnnouncementErr
+	}
+	timestamp, err := strconv.ParseUint(header.Get(header_timestamp), 10, 64)
+	if err != nil {
+		return nil, InvalidAnnouncementErr
+	}
+	t := time.Unix(int64(timestamp), 0)
+	if t.Before(now.Add(-sign_window)) || t.After(now.Add(sign_window)) {
+		return nil, InvalidAnnouncementErr
+	}
+	location, err := url.Parse(header.Get("LOCATION"))
+	if err != nil {
+		return nil, InvalidAnnouncementErr
+	}
+	port, err := strconv.ParseUint(location.Port(), 10, 16)
+	if err != nil {
+		return nil, InvalidAnnouncementErr
+	}
+	host := location.Hostname()
+	if err = rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, signHash(nodeId, host, uint32(port), timestamp), sign); err != nil {
+		return nil, InvalidAnnouncementErr
+	}
+	return &Provider{NodeId: nodeId, Host: host, Port: uint32(port), Time: t}, nil
+}
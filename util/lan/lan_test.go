@@ -0,0 +1,94 @@
+package lan
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	util_hash "github.com/samoslab/nebula/util/hash"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) (nodeId []byte, pubKeyBytes []byte, priKey *rsa.PrivateKey) {
+	priKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pubKeyBytes = x509.MarshalPKCS1PublicKey(&priKey.PublicKey)
+	return util_hash.Sha1(pubKeyBytes), pubKeyBytes, priKey
+}
+
+func parse(t *testing.T, b []byte) http.Header {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), nil)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	return resp.Header
+}
+
+func TestVerify(t *testing.T) {
+	nodeId, pubKeyBytes, priKey := newKey(t)
+	a := &Announcer{nodeId: nodeId, pubKeyBytes: pubKeyBytes, priKey: priKey, port: 6666}
+	now := time.Now()
+	b, err := a.response("192.168.1.20", uint64(now.Unix()))
+	require.NoError(t, err)
+	header := parse(t, b)
+	require.Equal(t, SearchTarget, header.Get("ST"))
+	p, err := verify(header, now)
+	require.NoError(t, err)
+	require.Equal(t, nodeId, p.NodeId)
+	require.Equal(t, "192.168.1.20", p.Host)
+	require.Equal(t, uint32(6666), p.Port)
+
+	// expired
+	_, err = verify(header, now.Add(time.Hour))
+	require.Equal(t, InvalidAnnouncementErr, err)
+
+	// address is not the signed one
+	header.Set("LOCATION", "http://192.168.1.21:6666/")
+	_, err = verify(header, now)
+	require.Equal(t, InvalidAnnouncementErr, err)
+
+	// node id is not hash of signer key
+	_, otherPubKeyBytes, otherPriKey := newKey(t)
+	a = &Announcer{nodeId: nodeId, pubKeyBytes: otherPubKeyBytes, priKey: otherPriKey, port: 6666}
+	b, err = a.response("192.168.1.20", uint64(now.Unix()))
+	require.NoError(t, err)
+	_, err = verify(parse(t, b), now)
+	require.Equal(t, InvalidAnnouncementErr, err)
+}
+
+func TestAnnouncer(t *testing.T) {
+	nodeId, pubKeyBytes, priKey := newKey(t)
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	a := newAnnouncer(conn, nodeId, pubKeyBytes, priKey, 6666)
+	defer a.Close()
+
+	client, err := net.DialUDP("udp4", nil, conn.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer client.Close()
+	search := func(st string) {
+		_, err := client.Write([]byte("M-SEARCH * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nMAN: \"ssdp:discover\"\r\nMX: 1\r\nST: " + st + "\r\n\r\n"))
+		require.NoError(t, err)
+	}
+	buf := make([]byte, 2048)
+	// other service is not answered
+	search("upnp:rootdevice")
+	client.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+	_, err = client.Read(buf)
+	require.Error(t, err)
+
+	search(SearchTarget)
+	client.SetReadDeadline(time.Now().Add(3 * time.Second))
+	n, err := client.Read(buf)
+	require.NoError(t, err)
+	p, err := verify(parse(t, buf[:n]), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, nodeId, p.NodeId)
+	require.Equal(t, "127.0.0.1", p.Host)
+	require.Equal(t, uint32(6666), p.Port)
+}
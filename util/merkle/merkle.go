@@ -0,0 +1,175 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+)
+
+const DefaultChunkSize = 64 * 1024
+
+const HashSize = sha256.Size
+
+const leaf_prefix = 0x00
+const node_prefix = 0x01
+
+var OutOfRangeErr = errors.New("chunk index out of range")
+
+func LeafHash(chunk []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leaf_prefix})
+	h.Write(chunk)
+	return h.Sum(nil)
+}
+
+func nodeHash(left []byte, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{node_prefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// Leaves hash every chunkSize bytes of data, the last chunk may be shorter
+func Leaves(data []byte, chunkSize uint32) [][]byte {
+	count := ChunkCount(uint64(len(data)), chunkSize)
+	leaves := make([][]byte, 0, count)
+	for i := uint32(0); i < count; i++ {
+		start := uint64(i) * uint64(chunkSize)
+		end := start + uint64(chunkSize)
+		if end > uint64(len(data)) {
+			end = uint64(len(data))
+		}
+		leaves = append(leaves, LeafHash(data[start:end]))
+	}
+	return leaves
+}
+
+func LeavesOfFile(path string, chunkSize uint32) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LeavesOfReader(file, chunkSize)
+}
+
+func LeavesOfReader(reader io.Reader, chunkSize uint32) ([][]byte, error) {
+	leaves := make([][]byte, 0, 64)
+	buf := make([]byte, chunkSize)
+	for {
+		bytesRead, err := io.ReadFull(reader, buf)
+		if bytesRead > 0 {
+			leaves = append(leaves, LeafHash(buf[:bytesRead]))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(leaves) == 0 {
+		leaves = append(leaves, LeafHash(nil))
+	}
+	return leaves, nil
+}
+
+// ChunkCount return chunk count of size, an empty block still has one empty chunk
+func ChunkCount(size uint64, chunkSize uint32) uint32 {
+	if size == 0 {
+		return 1
+	}
+	return uint32((size + uint64(chunkSize) - 1) / uint64(chunkSize))
+}
+
+// Root compute merkle root of leaves, an odd node of a level is promoted to the next level unchanged
+func Root(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		return nil
+	}
+	level := leaves
+	for len(level) > 1 {
+		level = nextLevel(level)
+	}
+	return level[0]
+}
+
+func nextLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 < len(level) {
+			next = append(next, nodeHash(level[i], level[i+1]))
+		} else {
+			next = append(next, level[i])
+		}
+	}
+	return next
+}
+
+// Path return sibling hashes from leaf to root of the leaf at index, the promoted odd node has no sibling at that level
+func Path(leaves [][]byte, index uint32) ([][]byte, error) {
+	if int(index) >= len(leaves) {
+		return nil, OutOfRangeErr
+	}
+	path := make([][]byte, 0, 32)
+	level := leaves
+	idx := int(index)
+	for len(level) > 1 {
+		sibling := idx ^ 1
+		if sibling < len(level) {
+			path = append(path, level[sibling])
+		}
+		level = nextLevel(level)
+		idx = idx / 2
+	}
+	return path, nil
+}
+
+// Verify check chunk at index of a block with chunkCount chunks is committed by root,
+// chunkCount must be computed by verifier from block size, since odd nodes are promoted a forged count moves chunks
+func Verify(root []byte, chunk []byte, index uint32, chunkCount uint32, path [][]byte) bool {
+	if index >= chunkCount {
+		return false
+	}
+	hash := LeafHash(chunk)
+	idx, width, p := index, chunkCount, 0
+	for width > 1 {
+		sibling := idx ^ 1
+		if sibling < width {
+			if p >= len(path) {
+				return false
+			}
+			if idx&1 == 0 {
+				hash = nodeHash(hash, path[p])
+			} else {
+				hash = nodeHash(path[p], hash)
+			}
+			p++
+		}
+		idx, width = idx/2, (width+1)/2
+	}
+	return p == len(path) && bytes.Equal(hash, root)
+}
+
+// Pack concatenate leaves for storage
+func Pack(leaves [][]byte) []byte {
+	res := make([]byte, 0, len(leaves)*HashSize)
+	for _, l := range leaves {
+		res = append(res, l...)
+	}
+	return res
+}
+
+func Unpack(packed []byte) ([][]byte, error) {
+	if len(packed) == 0 || len(packed)%HashSize != 0 {
+		return nil, errors.New("packed merkle leaves length error")
+	}
+	leaves := make([][]byte, 0, len(packed)/HashSize)
+	for i := 0; i < len(packed); i += HashSize {
+		leaves = append(leaves, packed[i:i+HashSize])
+	}
+	return leaves, nil
+}
@@ -0,0 +1,86 @@
+package merkle
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestRootAndPath(t *testing.T) {
+	var chunkSize uint32 = 16
+	for _, size := range []int{0, 1, 15, 16, 17, 48, 80, 100, 16 * 7, 16*8 + 3, 1000} {
+		data := make([]byte, size)
+		rand.Read(data)
+		leaves := Leaves(data, chunkSize)
+		count := ChunkCount(uint64(size), chunkSize)
+		if int(count) != len(leaves) {
+			t.Errorf("size %d chunk count %d != leaves %d", size, count, len(leaves))
+		}
+		fromReader, err := LeavesOfReader(bytes.NewReader(data), chunkSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root := Root(leaves)
+		if !bytes.Equal(root, Root(fromReader)) {
+			t.Errorf("size %d root from reader not same", size)
+		}
+		for i := uint32(0); i < count; i++ {
+			path, err := Path(leaves, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := i * chunkSize
+			end := start + chunkSize
+			if end > uint32(size) {
+				end = uint32(size)
+			}
+			chunk := data[start:end]
+			if !Verify(root, chunk, i, count, path) {
+				t.Errorf("size %d chunk %d verify failed", size, i)
+			}
+			if len(chunk) > 0 {
+				bad := append([]byte{}, chunk...)
+				bad[0] ^= 0xff
+				if Verify(root, bad, i, count, path) {
+					t.Errorf("size %d chunk %d tampered chunk verified", size, i)
+				}
+			}
+			if count > 1 && Verify(root, chunk, (i+1)%count, count, path) {
+				t.Errorf("size %d chunk %d verified at wrong index", size, i)
+			}
+		}
+		if _, err = Path(leaves, count); err != OutOfRangeErr {
+			t.Errorf("size %d expect out of range", size)
+		}
+	}
+}
+
+func TestPackUnpack(t *testing.T) {
+	data := make([]byte, 1000)
+	rand.Read(data)
+	leaves := Leaves(data, 64)
+	unpacked, err := Unpack(Pack(leaves))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(Root(leaves), Root(unpacked)) {
+		t.Errorf("failed")
+	}
+	if _, err = Unpack([]byte{1, 2, 3}); err == nil {
+		t.Errorf("failed")
+	}
+}
+
+func TestForgedChunkCount(t *testing.T) {
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+	leaves := [][]byte{LeafHash(a), LeafHash(b), LeafHash(c)}
+	root := Root(leaves)
+	// with 2 chunks claimed, H(a,b) is sibling of promoted chunk c at index 1
+	forged := [][]byte{nodeHash(leaves[0], leaves[1])}
+	if !Verify(root, c, 1, 2, forged) {
+		t.Errorf("forged count should move chunk, so it must not come from prover")
+	}
+	if Verify(root, c, 1, 3, forged) {
+		t.Errorf("chunk moved by forged count verified with real count")
+	}
+}
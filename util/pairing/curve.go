@@ -0,0 +1,169 @@
+package pairing
+
+import (
+	"math/big"
+)
+
+// point in jacobian coordinates of curve y^2 = x^3 + x, (X, Y, Z) is (X/Z^2, Y/Z^3), infinity if Z is zero
+type point struct {
+	x, y, z *big.Int
+}
+
+func newInfinity() *point {
+	return &point{x: big.NewInt(1), y: big.NewInt(1), z: big.NewInt(0)}
+}
+
+func newAffine(x, y *big.Int) *point {
+	return &point{x: new(big.Int).Set(x), y: new(big.Int).Set(y), z: big.NewInt(1)}
+}
+
+func (self *point) isInfinity() bool {
+	return self.z.Sign() == 0
+}
+
+// affine convert to affine coordinates, must not be infinity
+func (self *point) affine(q *big.Int) (x, y *big.Int) {
+	zInv := new(big.Int).ModInverse(self.z, q)
+	zInv2 := new(big.Int).Mul(zInv, zInv)
+	zInv2.Mod(zInv2, q)
+	x = new(big.Int).Mul(self.x, zInv2)
+	x.Mod(x, q)
+	y = new(big.Int).Mul(self.y, zInv2)
+	y.Mul(y, zInv)
+	y.Mod(y, q)
+	return
+}
+
+func (self *point) double(q *big.Int) *point {
+	if self.isInfinity() || self.y.Sign() == 0 {
+		return newInfinity()
+	}
+	xx := new(big.Int).Mul(self.x, self.x)
+	xx.Mod(xx, q)
+	yy := new(big.Int).Mul(self.y, self.y)
+	yy.Mod(yy, q)
+	yyyy := new(big.Int).Mul(yy, yy)
+	yyyy.Mod(yyyy, q)
+	zz := new(big.Int).Mul(self.z, self.z)
+	zz.Mod(zz, q)
+	// s = 4 * x * yy
+	s := new(big.Int).Mul(self.x, yy)
+	s.Lsh(s, 2)
+	s.Mod(s, q)
+	// m = 3 * xx + a * zz^2, a = 1
+	m := new(big.Int).Mul(xx, big3)
+	zz.Mul(zz, zz)
+	m.Add(m, zz)
+	m.Mod(m, q)
+	x3 := new(big.Int).Mul(m, m)
+	x3.Sub(x3, s)
+	x3.Sub(x3, s)
+	x3.Mod(x3, q)
+	y3 := new(big.Int).Sub(s, x3)
+	y3.Mul(y3, m)
+	yyyy.Lsh(yyyy, 3)
+	y3.Sub(y3, yyyy)
+	y3.Mod(y3, q)
+	z3 := new(big.Int).Mul(self.y, self.z)
+	z3.Lsh(z3, 1)
+	z3.Mod(z3, q)
+	return &point{x: x3, y: y3, z: z3}
+}
+
+func (self *point) add(other *point, q *big.Int) *point {
+	if self.isInfinity() {
+		return other
+	}
+	if other.isInfinity() {
+		return self
+	}
+	z1z1 := new(big.Int).Mul(self.z, self.z)
+	z1z1.Mod(z1z1, q)
+	z2z2 := new(big.Int).Mul(other.z, other.z)
+	z2z2.Mod(z2z2, q)
+	u1 := new(big.Int).Mul(self.x, z2z2)
+	u1.Mod(u1, q)
+	u2 := new(big.Int).Mul(other.x, z1z1)
+	u2.Mod(u2, q)
+	s1 := new(big.Int).Mul(self.y, other.z)
+	s1.Mul(s1, z2z2)
+	s1.Mod(s1, q)
+	s2 := new(big.Int).Mul(other.y, self.z)
+	s2.Mul(s2, z1z1)
+	s2.Mod(s2, q)
+	if u1.Cmp(u2) == 0 {
+		if s1.Cmp(s2) == 0 {
+			return self.double(q)
+		}
+		return newInfinity()
+	}
+	h := new(big.Int).Sub(u2, u1)
+	h.Mod(h, q)
+	r := new(big.Int).Sub(s2, s1)
+	r.Mod(r, q)
+	hh := new(big.Int).Mul(h, h)
+	hh.Mod(hh, q)
+	hhh := new(big.Int).Mul(hh, h)
+	hhh.Mod(hhh, q)
+	v := new(big.Int).Mul(u1, hh)
+	v.Mod(v, q)
+	x3 := new(big.Int).Mul(r, r)
+	x3.Sub(x3, hhh)
+	x3.Sub(x3, v)
+	x3.Sub(x3, v)
+	x3.Mod(x3, q)
+	y3 := new(big.Int).Sub(v, x3)
+	y3.Mul(y3, r)
+	s1.Mul(s1, hhh)
+	y3.Sub(y3, s1)
+	y3.Mod(y3, q)
+	z3 := new(big.Int).Mul(self.z, other.z)
+	z3.Mul(z3, h)
+	z3.Mod(z3, q)
+	return &point{x: x3, y: y3, z: z3}
+}
+
+func (self *point) neg(q *big.Int) *point {
+	y := new(big.Int).Neg(self.y)
+	y.Mod(y, q)
+	return &point{x: new(big.Int).Set(self.x), y: y, z: new(big.Int).Set(self.z)}
+}
+
+// mul compute n * p by double and add, n must not be negative
+func (self *point) mul(n *big.Int, q *big.Int) *point {
+	res := newInfinity()
+	for i := n.BitLen() - 1; i >= 0; i-- {
+		res = res.double(q)
+		if n.Bit(i) == 1 {
+			res = res.add(self, q)
+		}
+	}
+	return res
+}
+
+// curveRhs compute x^3 + x
+func curveRhs(x *big.Int, q *big.Int) *big.Int {
+	t := new(big.Int).Mul(x, x)
+	t.Add(t, big1)
+	t.Mul(t, x)
+	return t.Mod(t, q)
+}
+
+func onCurve(x, y *big.Int, q *big.Int) bool {
+	if x.Cmp(q) >= 0 || y.Cmp(q) >= 0 {
+		return false
+	}
+	yy := new(big.Int).Mul(y, y)
+	yy.Mod(yy, q)
+	return yy.Cmp(curveRhs(x, q)) == 0
+}
+
+// isSquare check quadratic residue, zero is a square as pbc does
+func isSquare(t *big.Int, q *big.Int) bool {
+	return t.Sign() == 0 || big.Jacobi(t, q) == 1
+}
+
+// sqrt of square t, q = 3 mod 4
+func sqrt(t *big.Int, q *big.Int, exp *big.Int) *big.Int {
+	return new(big.Int).Exp(t, exp, q)
+}
@@ -0,0 +1,314 @@
+package pairing
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrIncompatible = errors.New("elements are from incompatible fields or pairings")
+	ErrBadInput     = errors.New("invalid element format")
+	ErrUnsupported  = errors.New("operation is not supported")
+)
+
+type field int
+
+const (
+	fieldG1 field = iota // G2 is the same as G1 for the pairing is symmetric
+	fieldGT
+	fieldZr
+)
+
+// Element is an element of G1, G2, GT or Zr of a pairing, methods follow github.com/Nik-U/pbc that set and return the receiver
+type Element struct {
+	pairing *Pairing
+	field   field
+	p       *point   // G1 and G2
+	gt      fq2      // GT
+	v       *big.Int // Zr
+}
+
+func (self *Element) isCurve() bool {
+	return self.field == fieldG1
+}
+
+func (self *Element) check(others ...*Element) {
+	for _, o := range others {
+		if o.pairing != self.pairing || o.field != self.field {
+			panic(ErrIncompatible)
+		}
+	}
+}
+
+// Set sets el = x and returns el
+func (self *Element) Set(x *Element) *Element {
+	self.check(x)
+	switch {
+	case self.isCurve():
+		self.p = &point{x: new(big.Int).Set(x.p.x), y: new(big.Int).Set(x.p.y), z: new(big.Int).Set(x.p.z)}
+	case self.field == fieldGT:
+		self.gt = fq2{a: new(big.Int).Set(x.gt.a), b: new(big.Int).Set(x.gt.b)}
+	default:
+		self.v = new(big.Int).Set(x.v)
+	}
+	return self
+}
+
+// Set1 sets el to the identity of the group, or one of Zr
+func (self *Element) Set1() *Element {
+	switch {
+	case self.isCurve():
+		self.p = newInfinity()
+	case self.field == fieldGT:
+		self.gt = fq2One()
+	default:
+		self.v = big.NewInt(1)
+	}
+	return self
+}
+
+// Rand sets el to a random element
+func (self *Element) Rand() *Element {
+	pr := self.pairing
+	switch {
+	case self.isCurve():
+		for {
+			x := randMod(pr.q)
+			t := curveRhs(x, pr.q)
+			if !isSquare(t, pr.q) {
+				continue
+			}
+			y := sqrt(t, pr.q, pr.sqrtExp)
+			if randMod(big.NewInt(2)).Sign() == 1 {
+				y.Sub(pr.q, y).Mod(y, pr.q)
+			}
+			if p := newAffine(x, y).mul(pr.h, pr.q); !p.isInfinity() {
+				self.p = p
+				return self
+			}
+		}
+	case self.field == fieldGT:
+		g := pr.NewG1().Rand()
+		self.gt = pr.pair(g.p, g.p)
+	default:
+		self.v = randMod(pr.r)
+	}
+	return self
+}
+
+func randMod(n *big.Int) *big.Int {
+	v, err := rand.Int(rand.Reader, n)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (self *Element) Equals(x *Element) bool {
+	self.check(x)
+	switch {
+	case self.isCurve():
+		if self.p.isInfinity() || x.p.isInfinity() {
+			return self.p.isInfinity() && x.p.isInfinity()
+		}
+		x1, y1 := self.p.affine(self.pairing.q)
+		x2, y2 := x.p.affine(self.pairing.q)
+		return x1.Cmp(x2) == 0 && y1.Cmp(y2) == 0
+	case self.field == fieldGT:
+		return self.gt.equals(x.gt)
+	default:
+		return self.v.Cmp(x.v) == 0
+	}
+}
+
+// Mul sets el = x * y and returns el, which is point addition for G1 and G2
+func (self *Element) Mul(x, y *Element) *Element {
+	self.check(x, y)
+	pr := self.pairing
+	switch {
+	case self.isCurve():
+		self.p = x.p.add(y.p, pr.q)
+	case self.field == fieldGT:
+		self.gt = x.gt.mul(y.gt, pr.q)
+	default:
+		v := new(big.Int).Mul(x.v, y.v)
+		self.v = v.Mod(v, pr.r)
+	}
+	return self
+}
+
+// PowZn sets el = x^i and returns el, i must be an element of Zr
+func (self *Element) PowZn(x, i *Element) *Element {
+	self.check(x)
+	if i.pairing != self.pairing || i.field != fieldZr {
+		panic(ErrIncompatible)
+	}
+	pr := self.pairing
+	switch {
+	case self.isCurve():
+		self.p = x.p.mul(i.v, pr.q)
+	case self.field == fieldGT:
+		self.gt = x.gt.exp(i.v, pr.q)
+	default:
+		self.v = new(big.Int).Exp(x.v, i.v, pr.r)
+	}
+	return self
+}
+
+// Power is the source of repeated exponentiation, kept for api compatibility with pbc
+type Power struct {
+	source *Element
+}
+
+func (self *Element) PreparePower() *Power {
+	source := &Element{pairing: self.pairing, field: self.field}
+	return &Power{source: source.Set(self)}
+}
+
+// PowZn sets target = s^i where s is the source element of power
+func (self *Power) PowZn(target *Element, i *Element) *Element {
+	return target.PowZn(self.source, i)
+}
+
+// Pair sets el = e(x, y) and returns el, el must be an element of GT
+func (self *Element) Pair(x, y *Element) *Element {
+	if self.field != fieldGT || x.pairing != self.pairing || y.pairing != self.pairing || x.field != fieldG1 || y.field != fieldG1 {
+		panic(ErrIncompatible)
+	}
+	self.gt = self.pairing.pair(x.p, y.p)
+	return self
+}
+
+// SetBig sets el = i mod r, el must be an element of Zr
+func (self *Element) SetBig(i *big.Int) *Element {
+	if self.field != fieldZr {
+		panic(ErrIncompatible)
+	}
+	self.v = new(big.Int).Mod(i, self.pairing.r)
+	return self
+}
+
+// BigInt returns value of element of Zr
+func (self *Element) BigInt() *big.Int {
+	if self.field != fieldZr {
+		panic(ErrIncompatible)
+	}
+	return new(big.Int).Set(self.v)
+}
+
+// SetFromHash generates el deterministically from the bytes in hash, same as element_from_hash of pbc
+func (self *Element) SetFromHash(hash []byte) *Element {
+	pr := self.pairing
+	switch {
+	case self.isCurve():
+		x := fromHash(hash, pr.q)
+		x.Mod(x, pr.q)
+		var t *big.Int
+		for {
+			t = curveRhs(x, pr.q)
+			if isSquare(t, pr.q) {
+				break
+			}
+			x.Mul(x, x)
+			x.Add(x, big1)
+			x.Mod(x, pr.q)
+		}
+		y := sqrt(t, pr.q, pr.sqrtExp)
+		// sign of element of Fq is negative if even, pbc negates y of negative sign
+		if y.Sign() != 0 && y.Bit(0) == 0 {
+			y.Sub(pr.q, y)
+		}
+		self.p = newAffine(x, y).mul(pr.h, pr.q)
+	case self.field == fieldZr:
+		v := fromHash(hash, pr.r)
+		self.v = v.Mod(v, pr.r)
+	default:
+		panic(ErrUnsupported)
+	}
+	return self
+}
+
+// fromHash is pbc_mpz_from_hash: fill the length of limit with data and counter, then halve until not greater than limit
+func fromHash(data []byte, limit *big.Int) *big.Int {
+	count := (limit.BitLen() + 7) / 8
+	buf := make([]byte, 0, count)
+	var counter byte
+	for {
+		if len(data) >= count-len(buf) {
+			buf = append(buf, data[:count-len(buf)]...)
+			break
+		}
+		buf = append(buf, data...)
+		buf = append(buf, counter)
+		counter++
+		if len(buf) == count {
+			break
+		}
+	}
+	z := new(big.Int).SetBytes(buf)
+	for z.Cmp(limit) > 0 {
+		z.Rsh(z, 1)
+	}
+	return z
+}
+
+func (self *Element) BytesLen() int {
+	if self.field == fieldZr {
+		return self.pairing.rLen
+	}
+	return 2 * self.pairing.qLen
+}
+
+// Bytes exports el in the same format as pbc, point is x followed by y, element of GT is a followed by b of a + bi
+func (self *Element) Bytes() []byte {
+	pr := self.pairing
+	buf := make([]byte, self.BytesLen())
+	switch {
+	case self.isCurve():
+		if !self.p.isInfinity() {
+			x, y := self.p.affine(pr.q)
+			fillBytes(buf[:pr.qLen], x)
+			fillBytes(buf[pr.qLen:], y)
+		}
+	case self.field == fieldGT:
+		fillBytes(buf[:pr.qLen], self.gt.a)
+		fillBytes(buf[pr.qLen:], self.gt.b)
+	default:
+		fillBytes(buf, self.v)
+	}
+	return buf
+}
+
+func fillBytes(buf []byte, n *big.Int) {
+	b := n.Bytes()
+	copy(buf[len(buf)-len(b):], b)
+}
+
+// SetBytes imports a sequence exported by Bytes, point not on the curve is set to infinity as pbc does
+func (self *Element) SetBytes(buf []byte) *Element {
+	pr := self.pairing
+	if len(buf) < self.BytesLen() {
+		panic(ErrBadInput)
+	}
+	switch {
+	case self.isCurve():
+		x := new(big.Int).SetBytes(buf[:pr.qLen])
+		x.Mod(x, pr.q)
+		y := new(big.Int).SetBytes(buf[pr.qLen : 2*pr.qLen])
+		y.Mod(y, pr.q)
+		if (x.Sign() == 0 && y.Sign() == 0) || !onCurve(x, y, pr.q) {
+			self.p = newInfinity()
+		} else {
+			self.p = newAffine(x, y)
+		}
+	case self.field == fieldGT:
+		a := new(big.Int).SetBytes(buf[:pr.qLen])
+		b := new(big.Int).SetBytes(buf[pr.qLen : 2*pr.qLen])
+		self.gt = fq2{a: a.Mod(a, pr.q), b: b.Mod(b, pr.q)}
+	default:
+		v := new(big.Int).SetBytes(buf[:pr.rLen])
+		self.v = v.Mod(v, pr.r)
+	}
+	return self
+}
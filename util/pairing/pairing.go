@@ -0,0 +1,202 @@
+// Package pairing is a pure go implementation of the symmetric pairing of pbc type a params.
+// Params, serialized elements and hashing to G1 are byte compatible with github.com/Nik-U/pbc,
+// so data produced by one can be used by the other.
+package pairing
+
+import (
+	"math/big"
+)
+
+// Pairing is e: G1 x G2 -> GT, G1 = G2 is the subgroup of order r of curve y^2 = x^3 + x over Fq, GT is the subgroup of order r of Fq2 = Fq[i]/(i^2 + 1)
+type Pairing struct {
+	params  *Params
+	q       *big.Int
+	r       *big.Int
+	h       *big.Int
+	sqrtExp *big.Int // (q + 1) / 4
+	qLen    int
+	rLen    int
+}
+
+func newPairing(params *Params) *Pairing {
+	sqrtExp := new(big.Int).Add(params.q, big1)
+	sqrtExp.Rsh(sqrtExp, 2)
+	return &Pairing{params: params,
+		q:       params.q,
+		r:       params.r,
+		h:       params.h,
+		sqrtExp: sqrtExp,
+		qLen:    (params.q.BitLen() + 7) / 8,
+		rLen:    (params.r.BitLen() + 7) / 8}
+}
+
+func NewPairingFromString(params string) (*Pairing, error) {
+	p, err := NewParamsFromString(params)
+	if err != nil {
+		return nil, err
+	}
+	return p.NewPairing(), nil
+}
+
+func (self *Pairing) IsSymmetric() bool {
+	return true
+}
+
+func (self *Pairing) G1Length() uint {
+	return uint(2 * self.qLen)
+}
+
+func (self *Pairing) G2Length() uint {
+	return uint(2 * self.qLen)
+}
+
+func (self *Pairing) GTLength() uint {
+	return uint(2 * self.qLen)
+}
+
+func (self *Pairing) ZrLength() uint {
+	return uint(self.rLen)
+}
+
+func (self *Pairing) NewG1() *Element {
+	return &Element{pairing: self, field: fieldG1, p: newInfinity()}
+}
+
+func (self *Pairing) NewG2() *Element {
+	return &Element{pairing: self, field: fieldG1, p: newInfinity()}
+}
+
+func (self *Pairing) NewGT() *Element {
+	return &Element{pairing: self, field: fieldGT, gt: fq2One()}
+}
+
+func (self *Pairing) NewZr() *Element {
+	return &Element{pairing: self, field: fieldZr, v: new(big.Int)}
+}
+
+// pair compute reduced tate pairing with distortion map phi(x, y) = (-x, iy): f_{r,P}(phi(Q))^((q^2 - 1) / r)
+func (self *Pairing) pair(p1, p2 *point) fq2 {
+	if p1.isInfinity() || p2.isInfinity() {
+		return fq2One()
+	}
+	q := self.q
+	xp, yp := p1.affine(q)
+	xq, yq := p2.affine(q)
+	tx, ty := new(big.Int).Set(xp), new(big.Int).Set(yp)
+	f := fq2One()
+	lambda, t := new(big.Int), new(big.Int)
+	// line through T with slope lambda evaluated at phi(Q): lambda * (xq + tx) - ty + yq * i, then T = T + S where S.x = sx
+	line := func(sx *big.Int) fq2 {
+		l := fq2{a: new(big.Int).Add(xq, tx), b: new(big.Int).Set(yq)}
+		l.a.Mul(l.a, lambda)
+		l.a.Sub(l.a, ty)
+		l.a.Mod(l.a, q)
+		x3 := new(big.Int).Mul(lambda, lambda)
+		x3.Sub(x3, tx)
+		x3.Sub(x3, sx)
+		x3.Mod(x3, q)
+		t.Sub(tx, x3)
+		t.Mul(t, lambda)
+		t.Sub(t, ty)
+		ty.Mod(t, q)
+		tx.Set(x3)
+		return l
+	}
+	for i := self.r.BitLen() - 2; i >= 0; i-- {
+		if ty.Sign() == 0 {
+			// T is of order 2, not possible for element of G1
+			return fq2One()
+		}
+		// lambda = (3 * tx^2 + 1) / (2 * ty)
+		lambda.Mul(tx, tx)
+		lambda.Mul(lambda, big3)
+		lambda.Add(lambda, big1)
+		t.Lsh(ty, 1)
+		t.ModInverse(t, q)
+		lambda.Mul(lambda, t)
+		lambda.Mod(lambda, q)
+		f = f.square(q).mul(line(tx), q)
+		if self.r.Bit(i) == 1 {
+			if tx.Cmp(xp) == 0 {
+				// vertical line at the last step, its value lies in Fq and is killed by final exponentiation
+				break
+			}
+			// lambda = (ty - yp) / (tx - xp)
+			t.Sub(tx, xp)
+			t.ModInverse(t.Mod(t, q), q)
+			lambda.Sub(ty, yp)
+			lambda.Mul(lambda, t)
+			lambda.Mod(lambda, q)
+			f = f.mul(line(xp), q)
+		}
+	}
+	// f^(q - 1) = conj(f) / f, then power of (q + 1) / r = h
+	f = f.conj(q).mul(f.inverse(q), q)
+	return f.exp(self.h, q)
+}
+
+// fq2 is a + b * i
+type fq2 struct {
+	a, b *big.Int
+}
+
+func fq2One() fq2 {
+	return fq2{a: big.NewInt(1), b: big.NewInt(0)}
+}
+
+func (self fq2) mul(other fq2, q *big.Int) fq2 {
+	ac := new(big.Int).Mul(self.a, other.a)
+	bd := new(big.Int).Mul(self.b, other.b)
+	ad := new(big.Int).Mul(self.a, other.b)
+	bc := new(big.Int).Mul(self.b, other.a)
+	ac.Sub(ac, bd)
+	ad.Add(ad, bc)
+	return fq2{a: ac.Mod(ac, q), b: ad.Mod(ad, q)}
+}
+
+func (self fq2) square(q *big.Int) fq2 {
+	// (a + bi)^2 = (a + b)(a - b) + 2ab * i
+	s := new(big.Int).Add(self.a, self.b)
+	d := new(big.Int).Sub(self.a, self.b)
+	s.Mul(s, d)
+	ab := new(big.Int).Mul(self.a, self.b)
+	ab.Lsh(ab, 1)
+	return fq2{a: s.Mod(s, q), b: ab.Mod(ab, q)}
+}
+
+func (self fq2) conj(q *big.Int) fq2 {
+	b := new(big.Int).Neg(self.b)
+	return fq2{a: new(big.Int).Set(self.a), b: b.Mod(b, q)}
+}
+
+func (self fq2) inverse(q *big.Int) fq2 {
+	// 1 / (a + bi) = (a - bi) / (a^2 + b^2)
+	n := new(big.Int).Mul(self.a, self.a)
+	n.Add(n, new(big.Int).Mul(self.b, self.b))
+	n.Mod(n, q)
+	if n.Sign() == 0 {
+		return fq2{a: new(big.Int), b: new(big.Int)}
+	}
+	n.ModInverse(n, q)
+	c := self.conj(q)
+	c.a.Mul(c.a, n)
+	c.a.Mod(c.a, q)
+	c.b.Mul(c.b, n)
+	c.b.Mod(c.b, q)
+	return c
+}
+
+func (self fq2) exp(n *big.Int, q *big.Int) fq2 {
+	res := fq2One()
+	for i := n.BitLen() - 1; i >= 0; i-- {
+		res = res.square(q)
+		if n.Bit(i) == 1 {
+			res = res.mul(self, q)
+		}
+	}
+	return res
+}
+
+func (self fq2) equals(other fq2) bool {
+	return self.a.Cmp(other.a) == 0 && self.b.Cmp(other.b) == 0
+}
@@ -0,0 +1,130 @@
+package pairing
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+// a.param distributed with pbc
+const aParam = `type a
+q 8780710799663312522437781984754049815806883199414208211028653399266475630880222957078625179422662221423155858769582317459277713367317481324925129998224791
+h 12016012264891146079388821366740534204802954401251311822919615131047207289359704531102844802183906537786776
+r 730750818665451621361119245571504901405976559617
+exp2 159
+exp1 107
+sign1 1
+sign0 1
+`
+
+func TestParams(t *testing.T) {
+	p, err := NewParamsFromString("# comment\n" + aParam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != aParam {
+		t.Errorf("params string not same: %s", p.String())
+	}
+	if _, err = NewParamsFromString("type d\nq 7\n"); err != ErrUnsupportedType {
+		t.Errorf("expect unsupported type, got %v", err)
+	}
+	if _, err = NewParamsFromString(aParam[:len(aParam)-8]); err == nil {
+		t.Errorf("expect error of missing sign0")
+	}
+	g := GenerateA(160, 512)
+	if g.r.BitLen() != 160 || !g.r.ProbablyPrime(20) || !g.q.ProbablyPrime(20) {
+		t.Errorf("wrong generated params: %s", g)
+	}
+	if p, err = NewParamsFromString(g.String()); err != nil || p.String() != g.String() {
+		t.Errorf("parse generated params failed: %v", err)
+	}
+}
+
+func TestPairing(t *testing.T) {
+	pairing, err := NewPairingFromString(aParam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pairing.G1Length() != 128 || pairing.ZrLength() != 20 {
+		t.Errorf("wrong length, G1: %d Zr: %d", pairing.G1Length(), pairing.ZrLength())
+	}
+	g := pairing.NewG2().Rand()
+	p := pairing.NewG1().Rand()
+	a := pairing.NewZr().Rand()
+	b := pairing.NewZr().Rand()
+	// e(p^a, g^b) == e(p, g)^(ab)
+	left := pairing.NewGT().Pair(pairing.NewG1().PowZn(p, a), pairing.NewG2().PowZn(g, b))
+	right := pairing.NewGT().Pair(p, g)
+	right.PowZn(right, pairing.NewZr().Mul(a, b))
+	if !left.Equals(right) {
+		t.Errorf("pairing is not bilinear")
+	}
+	if right.Equals(pairing.NewGT().Set1()) {
+		t.Errorf("pairing is degenerate")
+	}
+	// e(p1 * p2, g) == e(p1, g) * e(p2, g)
+	p2 := pairing.NewG1().SetFromHash([]byte("nebula"))
+	left = pairing.NewGT().Pair(pairing.NewG1().Mul(p, p2), g)
+	right = pairing.NewGT().Mul(pairing.NewGT().Pair(p, g), pairing.NewGT().Pair(p2, g))
+	if !left.Equals(right) {
+		t.Errorf("pairing is not linear in G1")
+	}
+	if !pairing.NewGT().Pair(p, g).Equals(pairing.NewGT().Pair(g, p)) {
+		t.Errorf("pairing is not symmetric")
+	}
+	power := p.PreparePower()
+	if !power.PowZn(pairing.NewG1(), a).Equals(pairing.NewG1().PowZn(p, a)) {
+		t.Errorf("power not same as PowZn")
+	}
+}
+
+func TestElementBytes(t *testing.T) {
+	pairing, err := NewPairingFromString(aParam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha256.Sum256([]byte("nebula"))
+	for i := 0; i < 5; i++ {
+		e := pairing.NewG1().SetFromHash(h[:])
+		if !e.Equals(pairing.NewG1().SetFromHash(h[:])) {
+			t.Errorf("hash is not deterministic")
+		}
+		if !e.p.mul(pairing.r, pairing.q).isInfinity() {
+			t.Errorf("hashed point order is not r")
+		}
+		b := e.Bytes()
+		if len(b) != int(pairing.G1Length()) {
+			t.Fatalf("wrong bytes length: %d", len(b))
+		}
+		if !bytes.Equal(pairing.NewG1().SetBytes(b).Bytes(), b) {
+			t.Errorf("G1 bytes round trip failed")
+		}
+		z := pairing.NewZr().SetFromHash(h[:])
+		if !bytes.Equal(pairing.NewZr().SetBytes(z.Bytes()).Bytes(), z.Bytes()) {
+			t.Errorf("Zr bytes round trip failed")
+		}
+		h = sha256.Sum256(h[:])
+	}
+	invalid := make([]byte, pairing.G1Length())
+	invalid[len(invalid)-1] = 1
+	if !pairing.NewG1().SetBytes(invalid).Equals(pairing.NewG1().Set1()) {
+		t.Errorf("point not on curve should be infinity")
+	}
+	if !bytes.Equal(pairing.NewG1().Bytes(), make([]byte, pairing.G1Length())) {
+		t.Errorf("infinity should be zero bytes")
+	}
+}
+
+func TestFromHash(t *testing.T) {
+	limit := new(big.Int).SetBytes([]byte{0x10, 0, 0, 0, 0})
+	z := fromHash([]byte{0xff, 0xee}, limit)
+	// 0xffee00ffee halved until not greater than limit
+	expect := new(big.Int).SetBytes([]byte{0xff, 0xee, 0x00, 0xff, 0xee})
+	for expect.Cmp(limit) > 0 {
+		expect.Rsh(expect, 1)
+	}
+	if z.Cmp(expect) != 0 {
+		t.Errorf("fromHash %x expect %x", z, expect)
+	}
+}
@@ -0,0 +1,155 @@
+package pairing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnsupportedType = errors.New("only type a params is supported")
+	ErrInvalidParams   = errors.New("invalid params")
+)
+
+var (
+	big1  = big.NewInt(1)
+	big3  = big.NewInt(3)
+	big4  = big.NewInt(4)
+	big12 = big.NewInt(12)
+)
+
+// Params is type a params of pbc: curve y^2 = x^3 + x over Fq, q = 3 mod 4, r is solinas prime 2^exp2 + sign1 * 2^exp1 + sign0, q + 1 = h * r
+type Params struct {
+	q     *big.Int
+	h     *big.Int
+	r     *big.Int
+	exp2  int
+	exp1  int
+	sign1 int
+	sign0 int
+}
+
+// GenerateA generate type a params same way as pbc_param_init_a_gen
+func GenerateA(rbits uint32, qbits uint32) *Params {
+	p := &Params{q: new(big.Int), h: new(big.Int), r: new(big.Int)}
+	for {
+		p.r.SetInt64(0)
+		if randBit() {
+			p.exp2, p.sign1 = int(rbits)-1, 1
+		} else {
+			p.exp2, p.sign1 = int(rbits), -1
+		}
+		p.r.SetBit(p.r, p.exp2, 1)
+		p.exp1 = randInt(p.exp2-1) + 1
+		t := new(big.Int).SetBit(new(big.Int), p.exp1, 1)
+		if p.sign1 > 0 {
+			p.r.Add(p.r, t)
+		} else {
+			p.r.Sub(p.r, t)
+		}
+		if randBit() {
+			p.sign0 = 1
+			p.r.Add(p.r, big1)
+		} else {
+			p.sign0 = -1
+			p.r.Sub(p.r, big1)
+		}
+		if !p.r.ProbablyPrime(20) {
+			continue
+		}
+		bit := int(qbits) - int(rbits) - 4 + 1
+		if bit < 3 {
+			bit = 3
+		}
+		limit := new(big.Int).SetBit(new(big.Int), bit, 1)
+		for i := 0; i < 10; i++ {
+			h, err := rand.Int(rand.Reader, limit)
+			if err != nil {
+				panic(err)
+			}
+			p.h.Mul(h, big12)
+			p.q.Mul(p.h, p.r)
+			p.q.Sub(p.q, big1)
+			if p.q.ProbablyPrime(20) {
+				return p
+			}
+		}
+	}
+}
+
+func randInt(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(v.Int64())
+}
+
+func randBit() bool {
+	return randInt(2) == 1
+}
+
+// NewParamsFromString parse params in the format of pbc, such as the output of String
+func NewParamsFromString(s string) (*Params, error) {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		tokens := strings.Fields(line)
+		for i := 0; i+1 < len(tokens); i += 2 {
+			fields[tokens[i]] = tokens[i+1]
+		}
+	}
+	if fields["type"] != "a" {
+		return nil, ErrUnsupportedType
+	}
+	p := &Params{}
+	for _, v := range []struct {
+		key string
+		val **big.Int
+	}{{"q", &p.q}, {"h", &p.h}, {"r", &p.r}} {
+		n, ok := new(big.Int).SetString(fields[v.key], 10)
+		if !ok || n.Sign() <= 0 {
+			return nil, fmt.Errorf("%s: %s", ErrInvalidParams, v.key)
+		}
+		*v.val = n
+	}
+	for _, v := range []struct {
+		key string
+		val *int
+	}{{"exp2", &p.exp2}, {"exp1", &p.exp1}, {"sign1", &p.sign1}, {"sign0", &p.sign0}} {
+		n, err := strconv.Atoi(fields[v.key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", ErrInvalidParams, v.key)
+		}
+		*v.val = n
+	}
+	qPlus1 := new(big.Int).Add(p.q, big1)
+	if new(big.Int).Mul(p.h, p.r).Cmp(qPlus1) != 0 || new(big.Int).Mod(p.q, big4).Cmp(big3) != 0 {
+		return nil, ErrInvalidParams
+	}
+	return p, nil
+}
+
+// String output params in the format of pbc
+func (self *Params) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("type a\n")
+	fmt.Fprintf(&buf, "q %s\n", self.q)
+	fmt.Fprintf(&buf, "h %s\n", self.h)
+	fmt.Fprintf(&buf, "r %s\n", self.r)
+	fmt.Fprintf(&buf, "exp2 %d\n", self.exp2)
+	fmt.Fprintf(&buf, "exp1 %d\n", self.exp1)
+	fmt.Fprintf(&buf, "sign1 %d\n", self.sign1)
+	fmt.Fprintf(&buf, "sign0 %d\n", self.sign0)
+	return buf.String()
+}
+
+func (self *Params) NewPairing() *Pairing {
+	return newPairing(self)
+}
@@ -0,0 +1,88 @@
+package upnp
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// DefaultGateways find default gateways from route table on linux, otherwise guess .1 address of each IPv4 subnet.
+// Zone is set for link-local IPv6 gateway.
+func DefaultGateways() []net.IPAddr {
+	res := make([]net.IPAddr, 0, 4)
+	if file, err := os.Open("/proc/net/route"); err == nil {
+		res = append(res, parseRoute(file)...)
+		file.Close()
+	}
+	if file, err := os.Open("/proc/net/ipv6_route"); err == nil {
+		res = append(res, parseIPv6Route(file)...)
+		file.Close()
+	}
+	if len(res) > 0 {
+		return res
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return res
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if x, ok := addr.(*net.IPNet); ok && x.IP.To4() != nil {
+				gw := x.IP.Mask(x.Mask).To4()
+				gw[3] |= 1
+				res = append(res, net.IPAddr{IP: gw})
+			}
+		}
+	}
+	return res
+}
+
+// parseRoute parse /proc/net/route, address is little endian hex
+func parseRoute(r io.Reader) []net.IPAddr {
+	res := make([]net.IPAddr, 0, 2)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" || fields[2] == "00000000" {
+			continue
+		}
+		b, err := hex.DecodeString(fields[2])
+		if err != nil || len(b) != net.IPv4len {
+			continue
+		}
+		res = append(res, net.IPAddr{IP: net.IPv4(b[3], b[2], b[1], b[0])})
+	}
+	return res
+}
+
+// parseIPv6Route parse /proc/net/ipv6_route for default route with next hop
+func parseIPv6Route(r io.Reader) []net.IPAddr {
+	res := make([]net.IPAddr, 0, 2)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[1] != "00" || strings.Trim(fields[4], "0") == "" {
+			continue
+		}
+		b, err := hex.DecodeString(fields[4])
+		if err != nil || len(b) != net.IPv6len {
+			continue
+		}
+		gw := net.IPAddr{IP: net.IP(b)}
+		if gw.IP.IsLinkLocalUnicast() {
+			gw.Zone = fields[9]
+		}
+		res = append(res, gw)
+	}
+	return res
+}
@@ -0,0 +1,118 @@
+package upnp
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// min_renew_interval avoids flooding gateway which grants very short lifetime
+var min_renew_interval = 500 * time.Millisecond
+
+// renew_retry_interval is used when renewal failed and mapping is not expired yet
+var renew_retry_interval = time.Minute
+
+// Lease keep a port mapping by renewing it at half of granted lifetime, and delete it on Close
+type Lease struct {
+	mapper       Mapper
+	protocol     string
+	internalPort uint16
+	desc         string
+	lifetime     time.Duration
+	mutex        sync.Mutex
+	externalPort uint16
+	closeOnce    sync.Once
+	closeSignal  chan struct{}
+	done         chan struct{}
+}
+
+// Keep map internal port of protocol and keep renewing it until Close
+func Keep(mapper Mapper, protocol string, internalPort uint16, desc string, lifetime time.Duration) (*Lease, error) {
+	port, granted, err := mapper.AddMapping(protocol, internalPort, internalPort, desc, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	l := &Lease{mapper: mapper,
+		protocol:     protocol,
+		internalPort: internalPort,
+		desc:         desc,
+		lifetime:     lifetime,
+		externalPort: port,
+		closeSignal:  make(chan struct{}),
+		done:         make(chan struct{})}
+	go l.renew(granted)
+	return l, nil
+}
+
+func (l *Lease) Mapper() Mapper {
+	return l.mapper
+}
+
+// ExternalPort may be changed by gateway on renewal
+func (l *Lease) ExternalPort() uint16 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.externalPort
+}
+
+func renewInterval(granted time.Duration) time.Duration {
+	if interval := granted / 2; interval > min_renew_interval {
+		return interval
+	}
+	return min_renew_interval
+}
+
+func (l *Lease) renew(granted time.Duration) {
+	defer close(l.done)
+	if granted == 0 {
+		// permanent mapping
+		<-l.closeSignal
+		return
+	}
+	expire := time.Now().Add(granted)
+	timer := time.NewTimer(renewInterval(granted))
+	defer timer.Stop()
+	for {
+		select {
+		case <-l.closeSignal:
+			return
+		case <-timer.C:
+		}
+		port, g, err := l.mapper.AddMapping(l.protocol, l.internalPort, l.ExternalPort(), l.desc, l.lifetime)
+		if err != nil {
+			log.Warnf("renew %s port mapping of %s %d failed: %s", l.mapper.Protocol(), l.protocol, l.internalPort, err)
+			retry := renew_retry_interval
+			if left := time.Until(expire) / 2; left < retry {
+				retry = left
+			}
+			if retry < min_renew_interval {
+				retry = min_renew_interval
+			}
+			timer.Reset(retry)
+			continue
+		}
+		l.mutex.Lock()
+		if port != l.externalPort {
+			log.Warnf("external port of %s %d changed from %d to %d by gateway", l.protocol, l.internalPort, l.externalPort, port)
+			l.externalPort = port
+		}
+		l.mutex.Unlock()
+		if g == 0 {
+			<-l.closeSignal
+			return
+		}
+		expire = time.Now().Add(g)
+		timer.Reset(renewInterval(g))
+	}
+}
+
+// Close stop renewal and delete the mapping from gateway
+func (l *Lease) Close() (err error) {
+	l.closeOnce.Do(func() {
+		close(l.closeSignal)
+		<-l.done
+		err = l.mapper.DeleteMapping(l.protocol, l.internalPort, l.ExternalPort())
+	})
+	return
+}
@@ -0,0 +1,93 @@
+package upnp
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// Mapper is a gateway protocol which maps an internal port to an external port.
+// It is implemented by IGD (UPnP), NATPMP and PCP.
+type Mapper interface {
+	// Protocol name of gateway, eg: UPnP, NAT-PMP, PCP
+	Protocol() string
+	ExternalIP() (string, error)
+	// AddMapping map internal port of protocol (TCP or UDP) for lifetime, external port is a suggestion
+	// which gateway may not follow. granted lifetime 0 means the mapping is permanent.
+	AddMapping(protocol string, internalPort uint16, externalPort uint16, desc string, lifetime time.Duration) (mappedPort uint16, granted time.Duration, err error)
+	DeleteMapping(protocol string, internalPort uint16, externalPort uint16) error
+}
+
+// DefaultLifetime of mapping, lease renews it before expiry
+const DefaultLifetime = 2 * time.Hour
+
+var NoGatewayErr = errors.New("no port mapping gateway found")
+
+// DiscoverMapper find gateway which supports UPnP, PCP or NAT-PMP in order.
+// PCP is also tried on IPv6 gateway, where mapping opens a pinhole in firewall of the gateway.
+func DiscoverMapper() (Mapper, error) {
+	if igd, err := Discover(); err == nil {
+		return igd, nil
+	}
+	for _, gw := range DefaultGateways() {
+		addr := &net.UDPAddr{IP: gw.IP, Port: GatewayPort, Zone: gw.Zone}
+		if p, err := newPCP(addr); err == nil && p.probe() == nil {
+			return p, nil
+		}
+		if gw.IP.To4() == nil {
+			continue
+		}
+		n := &NATPMP{gateway: addr}
+		if _, err := n.ExternalIP(); err == nil {
+			return n, nil
+		}
+	}
+	return nil, NoGatewayErr
+}
+
+func protocolNumber(protocol string) (byte, error) {
+	switch protocol {
+	case "TCP":
+		return 6, nil
+	case "UDP":
+		return 17, nil
+	}
+	return 0, errors.New("unsupported protocol: " + protocol)
+}
+
+// request_timeout is the first retransmission timeout of NAT-PMP and PCP, it doubles on each retry
+var request_timeout = 250 * time.Millisecond
+
+const request_tries = 4
+
+// call send request to gateway by UDP and wait response accepted by check, request is retransmitted on timeout
+func call(gateway *net.UDPAddr, req []byte, check func(resp []byte) bool) ([]byte, error) {
+	conn, err := net.DialUDP("udp", nil, gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	buf := make([]byte, 1100)
+	timeout := request_timeout
+	for try := 0; try < request_tries; try++ {
+		if _, err = conn.Write(req); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(timeout)
+		for {
+			conn.SetReadDeadline(deadline)
+			n, err := conn.Read(buf)
+			if err != nil {
+				if ne, ok := err.(net.Error); ok && ne.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			if check(buf[:n]) {
+				return append([]byte{}, buf[:n]...), nil
+			}
+		}
+		timeout *= 2
+	}
+	return nil, errors.New("gateway " + gateway.String() + " does not respond")
+}
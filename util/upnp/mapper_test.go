@@ -0,0 +1,219 @@
+package upnp
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGateway speaks NAT-PMP, and PCP if pcp is true, on loopback
+type fakeGateway struct {
+	conn     *net.UDPConn
+	pcp      bool
+	external net.IP
+	lifetime uint32 // granted lifetime, requested lifetime if 0
+	mutex    sync.Mutex
+	mappings map[uint16]uint32 // internal port -> lifetime
+	requests int
+}
+
+func newFakeGateway(t *testing.T, pcp bool) *fakeGateway {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	g := &fakeGateway{conn: conn, pcp: pcp, external: net.IPv4(203, 0, 113, 7), mappings: make(map[uint16]uint32)}
+	go g.serve()
+	return g
+}
+
+func (g *fakeGateway) addr() *net.UDPAddr {
+	return g.conn.LocalAddr().(*net.UDPAddr)
+}
+
+func (g *fakeGateway) close() {
+	g.conn.Close()
+}
+
+func (g *fakeGateway) mapped(port uint16) (uint32, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	l, ok := g.mappings[port]
+	return l, ok
+}
+
+func (g *fakeGateway) requestCount() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.requests
+}
+
+func (g *fakeGateway) grant(port uint16, requested uint32) uint32 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.requests++
+	if requested == 0 {
+		delete(g.mappings, port)
+		return 0
+	}
+	if g.lifetime > 0 {
+		requested = g.lifetime
+	}
+	g.mappings[port] = requested
+	return requested
+}
+
+func (g *fakeGateway) serve() {
+	buf := make([]byte, 1100)
+	for {
+		n, addr, err := g.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req := buf[:n]
+		var resp []byte
+		if req[0] == pcp_version && g.pcp {
+			resp = g.handlePCP(req)
+		} else if req[0] == natpmp_version {
+			resp = g.handleNATPMP(req)
+		} else {
+			// unsupported version in NAT-PMP format
+			resp = []byte{natpmp_version, req[1] | 0x80, 0, 1, 0, 0, 0, 0}
+		}
+		g.conn.WriteToUDP(resp, addr)
+	}
+}
+
+func (g *fakeGateway) handleNATPMP(req []byte) []byte {
+	switch req[1] {
+	case 0:
+		resp := make([]byte, 12)
+		resp[1] = 128
+		copy(resp[8:], g.external.To4())
+		return resp
+	case 1, 2:
+		internal := binary.BigEndian.Uint16(req[4:])
+		external := binary.BigEndian.Uint16(req[6:])
+		lifetime := g.grant(internal, binary.BigEndian.Uint32(req[8:]))
+		resp := make([]byte, 16)
+		resp[1] = 128 + req[1]
+		binary.BigEndian.PutUint16(resp[8:], internal)
+		binary.BigEndian.PutUint16(resp[10:], external)
+		binary.BigEndian.PutUint32(resp[12:], lifetime)
+		return resp
+	}
+	return []byte{natpmp_version, req[1] | 0x80, 0, 5}
+}
+
+func (g *fakeGateway) handlePCP(req []byte) []byte {
+	switch req[1] {
+	case pcp_opcode_announce:
+		resp := make([]byte, pcp_header_len)
+		resp[0], resp[1] = pcp_version, pcp_opcode_announce|0x80
+		return resp
+	case pcp_opcode_map:
+		m := req[pcp_header_len:]
+		internal := binary.BigEndian.Uint16(m[16:])
+		external := binary.BigEndian.Uint16(m[18:])
+		if external == 0 {
+			external = internal + 1000
+		}
+		lifetime := g.grant(internal, binary.BigEndian.Uint32(req[4:]))
+		resp := make([]byte, pcp_header_len+pcp_map_len)
+		resp[0], resp[1] = pcp_version, pcp_opcode_map|0x80
+		binary.BigEndian.PutUint32(resp[4:], lifetime)
+		rm := resp[pcp_header_len:]
+		copy(rm, m[:20])
+		binary.BigEndian.PutUint16(rm[18:], external)
+		copy(rm[20:], g.external.To16())
+		return resp
+	}
+	return []byte{pcp_version, req[1] | 0x80, 0, 4}
+}
+
+func TestNATPMP(t *testing.T) {
+	g := newFakeGateway(t, false)
+	defer g.close()
+	n := &NATPMP{gateway: g.addr()}
+	ip, err := n.ExternalIP()
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.7", ip)
+	port, granted, err := n.AddMapping("TCP", 6666, 6666, "test", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, uint16(6666), port)
+	require.Equal(t, time.Hour, granted)
+	l, ok := g.mapped(6666)
+	require.True(t, ok)
+	require.Equal(t, uint32(3600), l)
+	require.NoError(t, n.DeleteMapping("TCP", 6666, 6666))
+	_, ok = g.mapped(6666)
+	require.False(t, ok)
+
+	p, err := newPCP(g.addr())
+	require.NoError(t, err)
+	require.Error(t, p.probe())
+}
+
+func TestPCP(t *testing.T) {
+	g := newFakeGateway(t, true)
+	defer g.close()
+	p, err := newPCP(g.addr())
+	require.NoError(t, err)
+	require.NoError(t, p.probe())
+	_, err = p.ExternalIP()
+	require.Error(t, err)
+	port, granted, err := p.AddMapping("TCP", 6666, 0, "test", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, uint16(7666), port)
+	require.Equal(t, time.Hour, granted)
+	ip, err := p.ExternalIP()
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.7", ip)
+	_, _, err = p.AddMapping("SCTP", 6666, 0, "test", time.Hour)
+	require.Error(t, err)
+	require.NoError(t, p.DeleteMapping("TCP", 6666, port))
+	_, ok := g.mapped(6666)
+	require.False(t, ok)
+}
+
+func TestLease(t *testing.T) {
+	old := min_renew_interval
+	min_renew_interval = 100 * time.Millisecond
+	defer func() { min_renew_interval = old }()
+	g := newFakeGateway(t, true)
+	defer g.close()
+	g.mutex.Lock()
+	g.lifetime = 1
+	g.mutex.Unlock()
+	p, err := newPCP(g.addr())
+	require.NoError(t, err)
+	lease, err := Keep(p, "TCP", 6667, "test", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, uint16(6667), lease.ExternalPort())
+	// granted 1 second, so it is renewed every half second
+	time.Sleep(1200 * time.Millisecond)
+	require.True(t, g.requestCount() >= 3)
+	_, ok := g.mapped(6667)
+	require.True(t, ok)
+	require.NoError(t, lease.Close())
+	require.NoError(t, lease.Close())
+	_, ok = g.mapped(6667)
+	require.False(t, ok)
+}
+
+func TestParseRoute(t *testing.T) {
+	gws := parseRoute(strings.NewReader("Iface\tDestination\tGateway \tFlags\n" +
+		"eth0\t00000000\t010200C0\t0003\n" +
+		"eth0\t000200C0\t00000000\t0001\n"))
+	require.Equal(t, 1, len(gws))
+	require.Equal(t, "192.0.2.1", gws[0].IP.String())
+	gws = parseIPv6Route(strings.NewReader(
+		"fe800000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000002 00000000 00000001     eth0\n" +
+			"00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000000000000000001 00000400 00000001 00000000 00000003     eth0\n"))
+	require.Equal(t, 1, len(gws))
+	require.Equal(t, "fe80::1", gws[0].IP.String())
+	require.Equal(t, "eth0", gws[0].Zone)
+}
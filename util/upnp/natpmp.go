@@ -0,0 +1,99 @@
+package upnp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// GatewayPort is the port of NAT-PMP and PCP server on gateway
+const GatewayPort = 5351
+
+const natpmp_version = 0
+
+var natpmp_results = []string{"success", "unsupported version", "not authorized", "network failure", "out of resources", "unsupported opcode"}
+
+// NATPMP is NAT-PMP (RFC 6886) client, it only works on IPv4 gateway
+type NATPMP struct {
+	gateway *net.UDPAddr
+}
+
+func NewNATPMP(gateway net.IP) *NATPMP {
+	return &NATPMP{gateway: &net.UDPAddr{IP: gateway, Port: GatewayPort}}
+}
+
+func (n *NATPMP) Protocol() string {
+	return "NAT-PMP"
+}
+
+func natpmpError(result uint16) error {
+	if int(result) < len(natpmp_results) {
+		return fmt.Errorf("NAT-PMP error: %s", natpmp_results[result])
+	}
+	return fmt.Errorf("NAT-PMP error: result code %d", result)
+}
+
+func (n *NATPMP) request(req []byte, respLen int) ([]byte, error) {
+	resp, err := call(n.gateway, req, func(resp []byte) bool {
+		return len(resp) >= 4 && resp[0] == natpmp_version && resp[1] == req[1]|0x80
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result := binary.BigEndian.Uint16(resp[2:]); result != 0 {
+		return nil, natpmpError(result)
+	}
+	if len(resp) < respLen {
+		return nil, fmt.Errorf("NAT-PMP response is too short: %d", len(resp))
+	}
+	return resp, nil
+}
+
+func (n *NATPMP) ExternalIP() (string, error) {
+	resp, err := n.request([]byte{natpmp_version, 0}, 12)
+	if err != nil {
+		return "", err
+	}
+	return net.IP(resp[8:12]).String(), nil
+}
+
+func natpmpOpcode(protocol string) (byte, error) {
+	switch protocol {
+	case "UDP":
+		return 1, nil
+	case "TCP":
+		return 2, nil
+	}
+	return 0, fmt.Errorf("unsupported protocol: %s", protocol)
+}
+
+func (n *NATPMP) mapPort(protocol string, internalPort uint16, externalPort uint16, lifetime time.Duration) (uint16, time.Duration, error) {
+	op, err := natpmpOpcode(protocol)
+	if err != nil {
+		return 0, 0, err
+	}
+	req := make([]byte, 12)
+	req[0], req[1] = natpmp_version, op
+	binary.BigEndian.PutUint16(req[4:], internalPort)
+	binary.BigEndian.PutUint16(req[6:], externalPort)
+	binary.BigEndian.PutUint32(req[8:], uint32(lifetime/time.Second))
+	resp, err := n.request(req, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	return binary.BigEndian.Uint16(resp[10:]), time.Duration(binary.BigEndian.Uint32(resp[12:])) * time.Second, nil
+}
+
+func (n *NATPMP) AddMapping(protocol string, internalPort uint16, externalPort uint16, desc string, lifetime time.Duration) (uint16, time.Duration, error) {
+	if lifetime < time.Second {
+		lifetime = DefaultLifetime
+	}
+	return n.mapPort(protocol, internalPort, externalPort, lifetime)
+}
+
+// DeleteMapping request a mapping with zero lifetime, external port must be zero as RFC 6886 requires
+func (n *NATPMP) DeleteMapping(protocol string, internalPort uint16, externalPort uint16) error {
+	_, _, err := n.mapPort(protocol, internalPort, 0, 0)
+	return err
+}
@@ -0,0 +1,170 @@
+package upnp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const pcp_version = 2
+const pcp_opcode_announce = 0
+const pcp_opcode_map = 1
+const pcp_header_len = 24
+const pcp_map_len = 36
+
+var pcp_results = []string{"success", "unsupported version", "not authorized", "malformed request", "unsupported opcode",
+	"unsupported option", "malformed option", "network failure", "no resources", "unsupported protocol",
+	"user exceeded quota", "cannot provide external", "address mismatch", "excessive remote peers"}
+
+// PCP is Port Control Protocol (RFC 6887) client, it works on both IPv4 and IPv6 gateway
+type PCP struct {
+	gateway  *net.UDPAddr
+	clientIP net.IP
+	mutex    sync.Mutex
+	nonces   map[string][]byte // renew and delete must use the nonce of mapping
+	external net.IP
+}
+
+func NewPCP(gateway net.IP) (*PCP, error) {
+	return newPCP(&net.UDPAddr{IP: gateway, Port: GatewayPort})
+}
+
+func newPCP(gateway *net.UDPAddr) (*PCP, error) {
+	// address is chosen by route without sending anything
+	conn, err := net.DialUDP("udp", nil, gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return &PCP{gateway: gateway, clientIP: conn.LocalAddr().(*net.UDPAddr).IP, nonces: make(map[string][]byte, 2)}, nil
+}
+
+func (p *PCP) Protocol() string {
+	return "PCP"
+}
+
+// ExternalIP is known after a mapping is added, because PCP has no opcode for it
+func (p *PCP) ExternalIP() (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.external == nil {
+		return "", errors.New("external ip of PCP gateway is unknown before mapping")
+	}
+	return p.external.String(), nil
+}
+
+func pcpError(result byte) error {
+	if int(result) < len(pcp_results) {
+		return fmt.Errorf("PCP error: %s", pcp_results[result])
+	}
+	return fmt.Errorf("PCP error: result code %d", result)
+}
+
+func to16(ip net.IP) []byte {
+	if ip == nil {
+		return make([]byte, net.IPv6len)
+	}
+	return ip.To16()
+}
+
+func (p *PCP) nonce(protocol string, internalPort uint16) ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	key := fmt.Sprintf("%s:%d", protocol, internalPort)
+	if n, ok := p.nonces[key]; ok {
+		return n, nil
+	}
+	n := make([]byte, 12)
+	if _, err := rand.Read(n); err != nil {
+		return nil, err
+	}
+	p.nonces[key] = n
+	return n, nil
+}
+
+func (p *PCP) mapPort(protocol string, internalPort uint16, externalPort uint16, lifetime time.Duration) (uint16, net.IP, time.Duration, error) {
+	proto, err := protocolNumber(protocol)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	nonce, err := p.nonce(protocol, internalPort)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	req := make([]byte, pcp_header_len+pcp_map_len)
+	req[0], req[1] = pcp_version, pcp_opcode_map
+	binary.BigEndian.PutUint32(req[4:], uint32(lifetime/time.Second))
+	copy(req[8:], to16(p.clientIP))
+	m := req[pcp_header_len:]
+	copy(m, nonce)
+	m[12] = proto
+	binary.BigEndian.PutUint16(m[16:], internalPort)
+	binary.BigEndian.PutUint16(m[18:], externalPort)
+	if p.clientIP.To4() != nil {
+		copy(m[20:], net.IPv4zero.To16())
+	}
+	resp, err := call(p.gateway, req, func(resp []byte) bool {
+		if len(resp) < 4 || resp[1] != pcp_opcode_map|0x80 {
+			return false
+		}
+		// error response may not include opcode payload
+		return resp[3] != 0 || (len(resp) >= pcp_header_len+pcp_map_len && bytes.Equal(resp[pcp_header_len:pcp_header_len+12], nonce))
+	})
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	if resp[3] != 0 {
+		return 0, nil, 0, pcpError(resp[3])
+	}
+	m = resp[pcp_header_len:]
+	return binary.BigEndian.Uint16(m[18:]), net.IP(append([]byte{}, m[20:36]...)), time.Duration(binary.BigEndian.Uint32(resp[4:])) * time.Second, nil
+}
+
+// probe check gateway speaks PCP by ANNOUNCE, gateway which only speaks NAT-PMP responds unsupported version
+func (p *PCP) probe() error {
+	req := make([]byte, pcp_header_len)
+	req[0], req[1] = pcp_version, pcp_opcode_announce
+	copy(req[8:], to16(p.clientIP))
+	resp, err := call(p.gateway, req, func(resp []byte) bool {
+		return len(resp) >= 4 && resp[1] == pcp_opcode_announce|0x80
+	})
+	if err != nil {
+		return err
+	}
+	if resp[0] != pcp_version {
+		return pcpError(1)
+	}
+	if resp[3] != 0 {
+		return pcpError(resp[3])
+	}
+	return nil
+}
+
+func (p *PCP) AddMapping(protocol string, internalPort uint16, externalPort uint16, desc string, lifetime time.Duration) (uint16, time.Duration, error) {
+	if lifetime < time.Second {
+		lifetime = DefaultLifetime
+	}
+	port, ip, granted, err := p.mapPort(protocol, internalPort, externalPort, lifetime)
+	if err != nil {
+		return 0, 0, err
+	}
+	p.mutex.Lock()
+	p.external = ip
+	p.mutex.Unlock()
+	return port, granted, nil
+}
+
+func (p *PCP) DeleteMapping(protocol string, internalPort uint16, externalPort uint16) error {
+	_, _, _, err := p.mapPort(protocol, internalPort, 0, 0)
+	if err == nil {
+		p.mutex.Lock()
+		delete(p.nonces, fmt.Sprintf("%s:%d", protocol, internalPort))
+		p.mutex.Unlock()
+	}
+	return err
+}
@@ -61,6 +61,39 @@ func (d *IGD) Clear(port uint16) error {
 	return nil
 }
 
+func (d *IGD) Protocol() string {
+	return "UPnP"
+}
+
+// AddMapping map port with lease duration, it falls back to permanent mapping
+// because many IGDv1 routers only support permanent leases.
+func (d *IGD) AddMapping(protocol string, internalPort uint16, externalPort uint16, desc string, lifetime time.Duration) (uint16, time.Duration, error) {
+	ip, err := d.getInternalIP()
+	if err != nil {
+		return 0, 0, err
+	}
+	if externalPort == 0 {
+		externalPort = internalPort
+	}
+	if err = d.client.AddPortMapping("", externalPort, protocol, internalPort, ip, true, desc, uint32(lifetime/time.Second)); err == nil {
+		return externalPort, lifetime, nil
+	}
+	if lifetime == 0 {
+		return 0, 0, err
+	}
+	if err = d.client.AddPortMapping("", externalPort, protocol, internalPort, ip, true, desc, 0); err != nil {
+		return 0, 0, err
+	}
+	return externalPort, 0, nil
+}
+
+func (d *IGD) DeleteMapping(protocol string, internalPort uint16, externalPort uint16) error {
+	if externalPort == 0 {
+		externalPort = internalPort
+	}
+	return d.client.DeletePortMapping("", externalPort, protocol)
+}
+
 // Location returns the URL of the router, for future lookups (see Load).
 func (d *IGD) Location() string {
 	return d.client.GetServiceClient().Location.String()